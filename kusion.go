@@ -8,6 +8,7 @@ import (
 	_ "kusionstack.io/kcl-plugin"
 
 	"kusionstack.io/kusion/pkg/cmd"
+	"kusionstack.io/kusion/pkg/cmd/util"
 	"kusionstack.io/kusion/pkg/util/pretty"
 )
 
@@ -16,9 +17,15 @@ func main() {
 
 	command := cmd.NewDefaultKusionctlCommand()
 
-	if err := command.Execute(); err != nil {
-		pretty.Error.Println(err.Error())
-		os.Exit(1)
+	start := time.Now()
+	executed, err := command.ExecuteC()
+	cmd.Flush()
+	cmd.ReportUsage(executed, time.Since(start), err)
+	if err != nil {
+		if msg := err.Error(); msg != "" {
+			pretty.Error.Println(msg)
+		}
+		os.Exit(util.ExitCode(err))
 	}
 
 	os.Exit(0)
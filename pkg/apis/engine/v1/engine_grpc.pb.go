@@ -0,0 +1,314 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: engine/v1/engine.proto
+
+package enginev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// EngineClient is the client API for Engine service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EngineClient interface {
+	// Preview computes changes of the stack, and streams an event for each changed resource.
+	Preview(ctx context.Context, in *OperationRequest, opts ...grpc.CallOption) (Engine_PreviewClient, error)
+	// Apply applies changes of the stack, and streams events of resources while they are applied.
+	Apply(ctx context.Context, in *OperationRequest, opts ...grpc.CallOption) (Engine_ApplyClient, error)
+	// GetState returns the latest state of the stack.
+	GetState(ctx context.Context, in *StateRequest, opts ...grpc.CallOption) (*State, error)
+	// ListQueue returns the running apply of the stack and the applies waiting for it.
+	ListQueue(ctx context.Context, in *QueueRequest, opts ...grpc.CallOption) (*Queue, error)
+	// CancelOperation removes a waiting apply from the queue of the stack.
+	CancelOperation(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelResponse, error)
+}
+
+type engineClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEngineClient(cc grpc.ClientConnInterface) EngineClient {
+	return &engineClient{cc}
+}
+
+func (c *engineClient) Preview(ctx context.Context, in *OperationRequest, opts ...grpc.CallOption) (Engine_PreviewClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Engine_ServiceDesc.Streams[0], "/kusion.engine.v1.Engine/Preview", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &enginePreviewClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Engine_PreviewClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type enginePreviewClient struct {
+	grpc.ClientStream
+}
+
+func (x *enginePreviewClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *engineClient) Apply(ctx context.Context, in *OperationRequest, opts ...grpc.CallOption) (Engine_ApplyClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Engine_ServiceDesc.Streams[1], "/kusion.engine.v1.Engine/Apply", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &engineApplyClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Engine_ApplyClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type engineApplyClient struct {
+	grpc.ClientStream
+}
+
+func (x *engineApplyClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *engineClient) GetState(ctx context.Context, in *StateRequest, opts ...grpc.CallOption) (*State, error) {
+	out := new(State)
+	err := c.cc.Invoke(ctx, "/kusion.engine.v1.Engine/GetState", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineClient) ListQueue(ctx context.Context, in *QueueRequest, opts ...grpc.CallOption) (*Queue, error) {
+	out := new(Queue)
+	err := c.cc.Invoke(ctx, "/kusion.engine.v1.Engine/ListQueue", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineClient) CancelOperation(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelResponse, error) {
+	out := new(CancelResponse)
+	err := c.cc.Invoke(ctx, "/kusion.engine.v1.Engine/CancelOperation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EngineServer is the server API for Engine service.
+// All implementations must embed UnimplementedEngineServer
+// for forward compatibility
+type EngineServer interface {
+	// Preview computes changes of the stack, and streams an event for each changed resource.
+	Preview(*OperationRequest, Engine_PreviewServer) error
+	// Apply applies changes of the stack, and streams events of resources while they are applied.
+	Apply(*OperationRequest, Engine_ApplyServer) error
+	// GetState returns the latest state of the stack.
+	GetState(context.Context, *StateRequest) (*State, error)
+	// ListQueue returns the running apply of the stack and the applies waiting for it.
+	ListQueue(context.Context, *QueueRequest) (*Queue, error)
+	// CancelOperation removes a waiting apply from the queue of the stack.
+	CancelOperation(context.Context, *CancelRequest) (*CancelResponse, error)
+	mustEmbedUnimplementedEngineServer()
+}
+
+// UnimplementedEngineServer must be embedded to have forward compatible implementations.
+type UnimplementedEngineServer struct {
+}
+
+func (UnimplementedEngineServer) Preview(*OperationRequest, Engine_PreviewServer) error {
+	return status.Errorf(codes.Unimplemented, "method Preview not implemented")
+}
+func (UnimplementedEngineServer) Apply(*OperationRequest, Engine_ApplyServer) error {
+	return status.Errorf(codes.Unimplemented, "method Apply not implemented")
+}
+func (UnimplementedEngineServer) GetState(context.Context, *StateRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetState not implemented")
+}
+func (UnimplementedEngineServer) ListQueue(context.Context, *QueueRequest) (*Queue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListQueue not implemented")
+}
+func (UnimplementedEngineServer) CancelOperation(context.Context, *CancelRequest) (*CancelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelOperation not implemented")
+}
+func (UnimplementedEngineServer) mustEmbedUnimplementedEngineServer() {}
+
+// UnsafeEngineServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EngineServer will
+// result in compilation errors.
+type UnsafeEngineServer interface {
+	mustEmbedUnimplementedEngineServer()
+}
+
+func RegisterEngineServer(s grpc.ServiceRegistrar, srv EngineServer) {
+	s.RegisterService(&Engine_ServiceDesc, srv)
+}
+
+func _Engine_Preview_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(OperationRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EngineServer).Preview(m, &enginePreviewServer{stream})
+}
+
+type Engine_PreviewServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type enginePreviewServer struct {
+	grpc.ServerStream
+}
+
+func (x *enginePreviewServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Engine_Apply_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(OperationRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EngineServer).Apply(m, &engineApplyServer{stream})
+}
+
+type Engine_ApplyServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type engineApplyServer struct {
+	grpc.ServerStream
+}
+
+func (x *engineApplyServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Engine_GetState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).GetState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kusion.engine.v1.Engine/GetState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).GetState(ctx, req.(*StateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Engine_ListQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).ListQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kusion.engine.v1.Engine/ListQueue",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).ListQueue(ctx, req.(*QueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Engine_CancelOperation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).CancelOperation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kusion.engine.v1.Engine/CancelOperation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).CancelOperation(ctx, req.(*CancelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Engine_ServiceDesc is the grpc.ServiceDesc for Engine service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Engine_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kusion.engine.v1.Engine",
+	HandlerType: (*EngineServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetState",
+			Handler:    _Engine_GetState_Handler,
+		},
+		{
+			MethodName: "ListQueue",
+			Handler:    _Engine_ListQueue_Handler,
+		},
+		{
+			MethodName: "CancelOperation",
+			Handler:    _Engine_CancelOperation_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Preview",
+			Handler:       _Engine_Preview_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Apply",
+			Handler:       _Engine_Apply_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "engine/v1/engine.proto",
+}
@@ -0,0 +1,4 @@
+// Package v1alpha1 contains API types of the kusionstack.io group, which are reconciled by the Kusion operator.
+// +kubebuilder:object:generate=true
+// +groupName=kusionstack.io
+package v1alpha1
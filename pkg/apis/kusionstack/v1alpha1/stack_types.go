@@ -0,0 +1,123 @@
+package v1alpha1
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Types of conditions of stacks
+const (
+	// ConditionReady is true if the latest revision of the source is applied
+	ConditionReady = "Ready"
+	// ConditionReconciling is true while the stack is being fetched, compiled and applied
+	ConditionReconciling = "Reconciling"
+)
+
+// Reasons of conditions of stacks
+const (
+	ReasonProgressing = "Progressing"
+	ReasonApplied     = "Applied"
+	ReasonFetchFailed = "FetchFailed"
+	ReasonApplyFailed = "ApplyFailed"
+	ReasonSuspended   = "Suspended"
+)
+
+// DefaultInterval is the default interval to reconcile stacks, which corrects drifts of applied resources
+// and picks up new commits of sources
+var DefaultInterval = metav1.Duration{Duration: 5 * time.Minute}
+
+// GitSource is the location of a stack in a git repository
+type GitSource struct {
+	// URL is the URL of the git repository, e.g. https://github.com/KusionStack/konfig.git
+	URL string `json:"url"`
+
+	// Revision is the branch, tag or commit to apply, the default branch of the repository if not specified
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Path is the directory of the stack relative to the root of the repository
+	Path string `json:"path"`
+
+	// SecretRef is the secret in the same namespace with the username and password keys to clone private
+	// repositories over HTTPS
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+}
+
+// StackSpec defines the desired state of Stack
+type StackSpec struct {
+	// Source is the location of the stack
+	Source GitSource `json:"source"`
+
+	// Interval is the interval to reconcile the stack, 5m by default
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Suspend stops reconciling the stack, resources applied are kept as they are
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// StackStatus defines the observed state of Stack
+type StackStatus struct {
+	// ObservedGeneration is the generation of the stack last reconciled
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastAttemptedRevision is the commit of the source last fetched, and LastAppliedRevision is the commit
+	// last applied successfully
+	// +optional
+	LastAttemptedRevision string `json:"lastAttemptedRevision,omitempty"`
+	// +optional
+	LastAppliedRevision string `json:"lastAppliedRevision,omitempty"`
+
+	// LastAppliedTime is the time the stack was last applied successfully
+	// +optional
+	LastAppliedTime *metav1.Time `json:"lastAppliedTime,omitempty"`
+
+	// Resources is the number of resources in the stack last applied
+	// +optional
+	Resources int `json:"resources,omitempty"`
+
+	// Conditions are the latest observations of the stack
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Revision",type=string,JSONPath=`.status.lastAppliedRevision`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Stack is a stack in a git repository, which is compiled and applied by the Kusion operator continuously
+type Stack struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   StackSpec   `json:"spec,omitempty"`
+	Status StackStatus `json:"status,omitempty"`
+}
+
+// GetInterval returns the interval to reconcile the stack
+func (s *Stack) GetInterval() metav1.Duration {
+	if s.Spec.Interval == nil || s.Spec.Interval.Duration <= 0 {
+		return DefaultInterval
+	}
+	return *s.Spec.Interval
+}
+
+//+kubebuilder:object:root=true
+
+// StackList contains a list of Stack
+type StackList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Stack `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Stack{}, &StackList{})
+}
@@ -17,7 +17,11 @@ var (
 		Create or update or delete resources according to the KCL files within a stack.
 		By default, Kusion will generate an execution plan and present it for your approval before taking any action.
 
-		You can check the plan details and then decide if the actions should be taken or aborted.`
+		You can check the plan details of each resource, choose resources to skip,
+		and then decide if the actions should be taken or aborted.
+
+		A spec file compiled by kusion compile can be applied instead of compiling the stack, and its signature
+		is verified with the key or the certificate identity of the signer.`
 
 	applyExample = `
 		# Apply with specifying work directory
@@ -30,7 +34,28 @@ var (
 		kusion apply -Y settings.yaml
 
 		# Skip interactive approval of plan details before applying
-		kusion apply --yes`
+		kusion apply --yes
+
+		# Apply only if the changes are the same as the reviewed preview
+		kusion apply --yes --plan-hash <hash printed by kusion preview>
+
+		# Apply unless Rego policies in the directory are violated
+		kusion apply --policy ./policies
+
+		# Take over the lock of the state held by an apply which was killed before it expires
+		kusion apply --force-unlock "the apply in CI was cancelled"
+
+		# Delete and create the resource again, e.g. to change its immutable fields
+		kusion apply --replace apps/v1:StatefulSet:default:db
+
+		# Apply even if the estimated cost exceeds the budget of the stack, the reason is recorded in the state
+		kusion apply --override-budget "scale out for the sales promotion"
+
+		# Apply the spec file compiled and signed by the CI after verifying its signature
+		kusion apply spec.yaml --verify-key cosign.pub
+
+		# Apply in CI and write results of resources as a JUnit XML report
+		kusion apply --yes --junit apply.xml`
 )
 
 func NewCmdApply() *cobra.Command {
@@ -60,6 +85,34 @@ func NewCmdApply() *cobra.Command {
 		i18n.T("dry-run to preview the execution effect (always successful) without actually applying the changes"))
 	cmd.Flags().BoolVarP(&o.Watch, "watch", "", false,
 		i18n.T("After creating/updating/deleting the requested object, watch for changes."))
+	cmd.Flags().DurationVarP(&o.WatchTimeout, "watch-timeout", "", 0,
+		i18n.T("Specify how long to watch for resources to be ready, events and logs of unready resources are shown when it expires"))
+	cmd.Flags().DurationVarP(&o.RefreshTTL, "refresh-ttl", "", 0,
+		i18n.T("Specify how long live states refreshed by the preview are reused by the apply instead of being read again, 0 means always reading them again. "+
+			"Reused live states are part of the plan bound by --bind-plan, so changes made after the preview are not detected within the TTL"))
+	cmd.Flags().StringVarP(&o.PlanHash, "plan-hash", "", "",
+		i18n.T("Abort if the changes differ from the preview that printed this plan hash"))
+	cmd.Flags().BoolVarP(&o.BindPlan, "bind-plan", "", true,
+		i18n.T("Abort if the action or the diff of a resource differs from the confirmed preview, e.g. its live state changed after the preview"))
+	cmd.Flags().StringVarP(&o.ForceUnlock, "force-unlock", "", "",
+		i18n.T("Take over the lock of the state held by another operation with the reason, which is recorded in the state. "+
+			"Expired locks are taken over without it"))
+	cmd.Flags().StringSliceVarP(&o.Replace, "replace", "", nil,
+		i18n.T("Specify IDs of resources to delete and create again instead of updating them"))
+	cmd.Flags().BoolVarP(&o.IgnoreCapacity, "ignore-capacity", "", false,
+		i18n.T("Apply even if ResourceQuotas or node capacities are insufficient for the changes"))
+	cmd.Flags().StringVarP(&o.ProgressAddr, "progress-addr", "", "",
+		i18n.T("Specify the address to stream progress events of the apply at over Server-Sent Events, e.g. localhost:8090"))
+	cmd.Flags().StringVarP(&o.Report, "report", "", "",
+		i18n.T("Specify the file to write the report of the apply to, in HTML if it ends with .html and in JSON otherwise"))
+	cmd.Flags().StringVarP(&o.FailureBundle, "failure-bundle", "", "",
+		i18n.T("Specify the tar.gz file to write a diagnostic bundle to if the apply fails, with sensitive values masked"))
+	cmd.Flags().StringVarP(&o.Verify.Key, "verify-key", "", "",
+		i18n.T("Specify the public key or the KMS URI of the key to verify the signature of the spec file"))
+	cmd.Flags().StringVarP(&o.Verify.CertificateIdentity, "certificate-identity", "", "",
+		i18n.T("Specify the identity expected in the certificate of the keyless signed spec file"))
+	cmd.Flags().StringVarP(&o.Verify.CertificateOIDCIssuer, "certificate-oidc-issuer", "", "",
+		i18n.T("Specify the OIDC issuer expected in the certificate of the keyless signed spec file"))
 
 	return cmd
 }
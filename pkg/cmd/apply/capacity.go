@@ -0,0 +1,72 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pterm/pterm"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
+)
+
+type capacityChecker interface {
+	CheckCapacity(ctx context.Context, requests []kubernetes.CapacityRequest) ([]kubernetes.CapacityIssue, error)
+}
+
+// newCapacityChecker returns the Kubernetes runtime to check quotas and capacities of the cluster
+var newCapacityChecker = kubernetes.RuntimeAs[capacityChecker]
+
+// checkCapacity compares resources requested by the changes with ResourceQuotas and node capacities, so
+// that pods are not left Pending after the apply. Issues are printed as warnings, and blocking ones fail
+// the apply unless --ignore-capacity is specified.
+func (o *ApplyOptions) checkCapacity(changes *opsmodels.Changes) error {
+	var requests []kubernetes.CapacityRequest
+	for _, step := range changes.Values() {
+		if o.skipResources[step.ID] || (step.Action != opsmodels.Create && step.Action != opsmodels.Update) {
+			continue
+		}
+		plan, ok := step.To.(*models.Resource)
+		if !ok || plan == nil {
+			continue
+		}
+		request := kubernetes.CapacityRequest{Plan: plan}
+		// replaced resources are deleted first, so their live resources are released
+		if live, ok := step.From.(*models.Resource); ok && !o.replaceResources[step.ID] {
+			request.Live = live
+		}
+		requests = append(requests, request)
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+
+	checker, err := newCapacityChecker(o.RuntimeEnv)
+	if err != nil {
+		return err
+	}
+	if checker == nil {
+		return nil
+	}
+	issues, err := checker.CheckCapacity(context.Background(), requests)
+	if err != nil {
+		return err
+	}
+
+	var blocking int
+	for _, issue := range issues {
+		if issue.Blocking && !o.IgnoreCapacity {
+			blocking++
+			pterm.Error.Println(issue.String())
+		} else {
+			pterm.Warning.Println(issue.String())
+			o.warnings = append(o.warnings, issue.String())
+		}
+	}
+	if blocking > 0 {
+		return fmt.Errorf("capacity check failed, %d issue(s) will leave pods Pending or rejected, "+
+			"apply with --ignore-capacity to skip the check", blocking)
+	}
+	return nil
+}
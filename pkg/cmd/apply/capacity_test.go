@@ -0,0 +1,75 @@
+package apply
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
+)
+
+type fakeCapacityChecker struct {
+	requests []kubernetes.CapacityRequest
+	issues   []kubernetes.CapacityIssue
+}
+
+func (c *fakeCapacityChecker) CheckCapacity(
+	_ context.Context,
+	requests []kubernetes.CapacityRequest,
+) ([]kubernetes.CapacityIssue, error) {
+	c.requests = requests
+	return c.issues, nil
+}
+
+func mockCapacityChecker(t *testing.T, checker *fakeCapacityChecker) {
+	origin := newCapacityChecker
+	newCapacityChecker = func(runtime.Env) (capacityChecker, error) {
+		return checker, nil
+	}
+	t.Cleanup(func() {
+		newCapacityChecker = origin
+	})
+}
+
+func TestApplyOptions_checkCapacity(t *testing.T) {
+	changes := opsmodels.NewChanges(project, stack, &opsmodels.ChangeOrder{
+		StepKeys: []string{sa1.ID, sa2.ID, sa3.ID},
+		ChangeSteps: map[string]*opsmodels.ChangeStep{
+			sa1.ID: opsmodels.NewChangeStep(sa1.ID, opsmodels.Create, nil, &sa1),
+			sa2.ID: opsmodels.NewChangeStep(sa2.ID, opsmodels.Update, &sa2, &sa2),
+			sa3.ID: opsmodels.NewChangeStep(sa3.ID, opsmodels.Delete, &sa3, nil),
+		},
+	})
+	warning := kubernetes.CapacityIssue{Message: "pods may be Pending"}
+	blocking := kubernetes.CapacityIssue{Message: "quota exceeded", Blocking: true}
+
+	t.Run("warning", func(t *testing.T) {
+		checker := &fakeCapacityChecker{issues: []kubernetes.CapacityIssue{warning}}
+		mockCapacityChecker(t, checker)
+		o := NewApplyOptions()
+		assert.Nil(t, o.checkCapacity(changes))
+		assert.Equal(t, []kubernetes.CapacityRequest{{Plan: &sa1}, {Live: &sa2, Plan: &sa2}}, checker.requests)
+	})
+
+	t.Run("replaced", func(t *testing.T) {
+		checker := &fakeCapacityChecker{}
+		mockCapacityChecker(t, checker)
+		o := NewApplyOptions()
+		o.replaceResources = map[string]bool{sa2.ID: true}
+		o.skipResources = map[string]bool{sa1.ID: true}
+		assert.Nil(t, o.checkCapacity(changes))
+		assert.Equal(t, []kubernetes.CapacityRequest{{Plan: &sa2}}, checker.requests)
+	})
+
+	t.Run("blocking", func(t *testing.T) {
+		mockCapacityChecker(t, &fakeCapacityChecker{issues: []kubernetes.CapacityIssue{warning, blocking}})
+		o := NewApplyOptions()
+		assert.ErrorContains(t, o.checkCapacity(changes), "capacity check failed, 1 issue(s)")
+
+		o.IgnoreCapacity = true
+		assert.Nil(t, o.checkCapacity(changes))
+	})
+}
@@ -0,0 +1,75 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pterm/pterm"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+type namespaceEnsurer interface {
+	MissingNamespaces(ctx context.Context, resources []*models.Resource) ([]kubernetes.MissingNamespace, error)
+	CreateNamespaces(ctx context.Context, namespaces []string, labels map[string]string) error
+}
+
+// newNamespaceEnsurer returns the Kubernetes runtime to check and create namespaces
+var newNamespaceEnsurer = kubernetes.RuntimeAs[namespaceEnsurer]
+
+// ensureNamespaces checks that namespaces of resources to create or update exist before walking the graph.
+// Missing namespaces are created with the configured labels if the stack enables it, otherwise the apply fails
+// with all of them instead of failing each resource with NotFound.
+func (o *ApplyOptions) ensureNamespaces(stack *projectstack.Stack, changes *opsmodels.Changes) error {
+	var resources []*models.Resource
+	for _, step := range changes.Values() {
+		if o.skipResources[step.ID] || (step.Action != opsmodels.Create && step.Action != opsmodels.Update) {
+			continue
+		}
+		if plan, ok := step.To.(*models.Resource); ok && plan != nil {
+			resources = append(resources, plan)
+		}
+	}
+	if len(resources) == 0 {
+		return nil
+	}
+
+	ensurer, err := newNamespaceEnsurer(o.RuntimeEnv)
+	if err != nil {
+		return err
+	}
+	if ensurer == nil {
+		return nil
+	}
+	ctx := context.Background()
+	missing, err := ensurer.MissingNamespaces(ctx, resources)
+	if err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	create, labels := stack.NamespaceCreation()
+	if !create {
+		for _, m := range missing {
+			pterm.Error.Println(m.String())
+		}
+		return fmt.Errorf("namespace check failed, %d namespace(s) do not exist, create them or set "+
+			"runtime.kubernetes.createNamespaces of the stack", len(missing))
+	}
+	names := make([]string, 0, len(missing))
+	for _, m := range missing {
+		names = append(names, m.Namespace)
+	}
+	if err = ensurer.CreateNamespaces(ctx, names, labels); err != nil {
+		return err
+	}
+	for _, name := range names {
+		pterm.Info.Printfln("Created namespace %s", name)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package apply
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+type fakeNamespaceEnsurer struct {
+	resources []*models.Resource
+	missing   []kubernetes.MissingNamespace
+	created   []string
+	labels    map[string]string
+}
+
+func (e *fakeNamespaceEnsurer) MissingNamespaces(
+	_ context.Context,
+	resources []*models.Resource,
+) ([]kubernetes.MissingNamespace, error) {
+	e.resources = resources
+	return e.missing, nil
+}
+
+func (e *fakeNamespaceEnsurer) CreateNamespaces(_ context.Context, namespaces []string, labels map[string]string) error {
+	e.created, e.labels = namespaces, labels
+	return nil
+}
+
+func mockNamespaceEnsurer(t *testing.T, ensurer *fakeNamespaceEnsurer) {
+	origin := newNamespaceEnsurer
+	newNamespaceEnsurer = func(runtime.Env) (namespaceEnsurer, error) {
+		return ensurer, nil
+	}
+	t.Cleanup(func() {
+		newNamespaceEnsurer = origin
+	})
+}
+
+func TestApplyOptions_ensureNamespaces(t *testing.T) {
+	changes := opsmodels.NewChanges(project, stack, &opsmodels.ChangeOrder{
+		StepKeys: []string{sa1.ID, sa2.ID, sa3.ID},
+		ChangeSteps: map[string]*opsmodels.ChangeStep{
+			sa1.ID: opsmodels.NewChangeStep(sa1.ID, opsmodels.Create, nil, &sa1),
+			sa2.ID: opsmodels.NewChangeStep(sa2.ID, opsmodels.Update, &sa2, &sa2),
+			sa3.ID: opsmodels.NewChangeStep(sa3.ID, opsmodels.Delete, &sa3, nil),
+		},
+	})
+	missing := []kubernetes.MissingNamespace{{Namespace: "test-ns", ResourceIDs: []string{sa1.ID, sa2.ID}}}
+
+	t.Run("no missing namespace", func(t *testing.T) {
+		ensurer := &fakeNamespaceEnsurer{}
+		mockNamespaceEnsurer(t, ensurer)
+		o := NewApplyOptions()
+		o.skipResources = map[string]bool{sa2.ID: true}
+		assert.Nil(t, o.ensureNamespaces(stack, changes))
+		assert.Equal(t, []*models.Resource{&sa1}, ensurer.resources)
+		assert.Nil(t, ensurer.created)
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		ensurer := &fakeNamespaceEnsurer{missing: missing}
+		mockNamespaceEnsurer(t, ensurer)
+		o := NewApplyOptions()
+		assert.ErrorContains(t, o.ensureNamespaces(stack, changes), "namespace check failed, 1 namespace(s) do not exist")
+		assert.Nil(t, ensurer.created)
+	})
+
+	t.Run("create", func(t *testing.T) {
+		ensurer := &fakeNamespaceEnsurer{missing: missing}
+		mockNamespaceEnsurer(t, ensurer)
+		s := &projectstack.Stack{StackConfiguration: projectstack.StackConfiguration{
+			Name: "dev",
+			Runtime: &projectstack.RuntimeConfig{Kubernetes: &projectstack.KubernetesRuntimeConfig{
+				CreateNamespaces: true,
+				NamespaceLabels:  map[string]string{"team": "kusion"},
+			}},
+		}}
+		o := NewApplyOptions()
+		assert.Nil(t, o.ensureNamespaces(s, changes))
+		assert.Equal(t, []string{"test-ns"}, ensurer.created)
+		assert.Equal(t, map[string]string{"team": "kusion"}, ensurer.labels)
+	})
+}
@@ -1,27 +1,36 @@
 package apply
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/pterm/pterm"
 
 	previewcmd "kusionstack.io/kusion/pkg/cmd/preview"
-	"kusionstack.io/kusion/pkg/cmd/spec"
 	"kusionstack.io/kusion/pkg/engine/backend"
 	_ "kusionstack.io/kusion/pkg/engine/backend/init"
+	"kusionstack.io/kusion/pkg/engine/bundle"
 	"kusionstack.io/kusion/pkg/engine/models"
 	"kusionstack.io/kusion/pkg/engine/operation"
 	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
 	"kusionstack.io/kusion/pkg/engine/states"
-	"kusionstack.io/kusion/pkg/generator"
 	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/notification"
+	"kusionstack.io/kusion/pkg/progress"
 	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/signing"
 	"kusionstack.io/kusion/pkg/status"
+	"kusionstack.io/kusion/pkg/util/junit"
 	"kusionstack.io/kusion/pkg/util/pretty"
 )
 
@@ -29,12 +38,85 @@ import (
 type ApplyOptions struct {
 	previewcmd.PreviewOptions
 	ApplyFlag
+
+	// skipResources contains keys of resources that users choose not to apply in the prompt
+	skipResources map[string]bool
+
+	// replaceResources contains keys of resources that will be deleted and created again instead of updated
+	replaceResources map[string]bool
+
+	// audits are checks overridden by flags, which are recorded in the state
+	audits []states.AuditRecord
+
+	// results are results of resources received from the engine, and warnings are warnings printed during
+	// the apply, both of which are written to the report
+	results  map[string]opsmodels.Message
+	warnings []string
+
+	// progress streams events of the apply if --progress-addr is specified
+	progress *progress.Server
+
+	// notifier notifies webhooks of the project and the stack, and CloudEvents sinks of each resource
+	notifier *notification.Notifier
+
+	// Revision is the commit of the source applied, which is recorded in the state. Stacks are applied even
+	// if no resource is changed when the revision differs from the state, so that the state records it.
+	Revision string
+
+	// OnMessage receives messages of resources during the apply, e.g. to stream them to clients of the server
+	OnMessage func(step *opsmodels.ChangeStep, msg opsmodels.Message)
+
+	// OnHeartbeat receives refreshes of the lock of the state during the apply, err is the failure of the refresh
+	OnHeartbeat func(err error)
+
+	// state is the state applied, which is nil for dry runs
+	state *states.State
+
+	// ctx is canceled with lockLost if the lock of the state is lost during the apply, which aborts the apply
+	ctx      context.Context
+	cancel   context.CancelFunc
+	lockLost error
 }
 
 type ApplyFlag struct {
-	Yes    bool
-	DryRun bool
-	Watch  bool
+	Yes      bool
+	DryRun   bool
+	Watch    bool
+	PlanHash string
+	Replace  []string
+
+	// WatchTimeout is how long --watch waits for resources to be ready, 0 means waiting forever
+	WatchTimeout time.Duration
+
+	// RefreshTTL is how long live states refreshed by the preview are reused by the apply, 0 means reading
+	// them again. Reused live states are part of the plan bound by BindPlan.
+	RefreshTTL time.Duration
+
+	// BindPlan aborts the apply of a resource whose action or diff differs from the confirmed preview,
+	// e.g. because its live state changed between the confirmation and the execution
+	BindPlan bool
+
+	// ForceUnlock is the reason to take over the lock of the state held by another operation which has not
+	// expired, e.g. the process holding it was killed. It is recorded in the state.
+	ForceUnlock string
+
+	// IgnoreCapacity applies even if ResourceQuotas or node capacities are insufficient
+	IgnoreCapacity bool
+
+	// Report is the file to write the report of the apply to, in HTML if its extension is .html
+	// and in JSON otherwise
+	Report string
+
+	// ProgressAddr is the address to stream events of the apply at over Server-Sent Events
+	ProgressAddr string
+
+	// FailureBundle is the file to write a diagnostic bundle to if the apply fails, which is attached to issues
+	FailureBundle string
+
+	// SpecFile is the spec file compiled by kusion compile to apply instead of compiling the stack
+	SpecFile string
+	// Verify contains the key or the certificate identity to verify the signature of the spec file with
+	Verify signing.VerifyOptions
 }
 
 // NewApplyOptions returns a new ApplyOptions instance
@@ -45,11 +127,34 @@ func NewApplyOptions() *ApplyOptions {
 }
 
 func (o *ApplyOptions) Complete(args []string) {
+	// a spec file compiled by kusion compile is applied directly
+	if len(args) == 1 && isSpecFile(args[0]) {
+		o.SpecFile = args[0]
+		args = nil
+	}
 	o.CompileOptions.Complete(args)
 }
 
+func isSpecFile(filename string) bool {
+	switch filepath.Ext(filename) {
+	case ".yaml", ".yml", ".json":
+		return true
+	default:
+		return false
+	}
+}
+
 func (o *ApplyOptions) Validate() error {
-	return o.CompileOptions.Validate()
+	if err := o.CompileOptions.Validate(); err != nil {
+		return err
+	}
+	if !o.Verify.IsEmpty() && o.SpecFile == "" {
+		return fmt.Errorf("signatures can only be verified when applying a spec file")
+	}
+	if o.RefreshTTL < 0 {
+		return fmt.Errorf("invalid --refresh-ttl %s, must not be negative", o.RefreshTTL)
+	}
+	return o.ValidatePreviewFlags()
 }
 
 func (o *ApplyOptions) Run() error {
@@ -64,18 +169,12 @@ func (o *ApplyOptions) Run() error {
 	if err != nil {
 		return err
 	}
+	if o.RuntimeEnv, err = runtime.StackEnv(context.Background(), project, stack); err != nil {
+		return err
+	}
 
-	// generate Spec
-	sp, err := spec.GenerateSpecWithSpinner(&generator.Options{
-		WorkDir:     o.WorkDir,
-		Filenames:   o.Filenames,
-		Settings:    o.Settings,
-		Arguments:   o.Arguments,
-		Overrides:   o.Overrides,
-		DisableNone: o.DisableNone,
-		OverrideAST: o.OverrideAST,
-		NoStyle:     o.NoStyle,
-	}, project, stack)
+	// generate Spec, or load it from the verified spec file
+	sp, err := o.loadSpec(project, stack)
 	if err != nil {
 		return err
 	}
@@ -92,19 +191,29 @@ func (o *ApplyOptions) Run() error {
 		return err
 	}
 
-	// Compute changes for preview
-	changes, err := previewcmd.Preview(&o.PreviewOptions, stateStorage, sp, project, stack)
+	// Compute changes, and block the apply if any gate fails before any change is made
+	changes, err := Prepare(o, stateStorage, sp, project, stack)
 	if err != nil {
 		return err
 	}
 
-	if allUnChange(changes) {
+	if allUnChange(changes) && !o.revisionChanged(stateStorage, project, stack) {
 		fmt.Println("All resources are reconciled. No diff found")
 		return nil
 	}
 
 	// Summary preview table
-	changes.Summary(os.Stdout)
+	previewcmd.PrintSummary(&o.PreviewOptions, changes)
+
+	// Resources with changed immutable fields can not be updated, they must be replaced
+	replaced, err := o.confirmReplacements(changes)
+	if err != nil {
+		return err
+	}
+	if !replaced {
+		fmt.Println("Operation apply canceled")
+		return nil
+	}
 
 	// Detail detection
 	if o.Detail && o.All {
@@ -129,6 +238,13 @@ func (o *ApplyOptions) Run() error {
 					return err
 				}
 				changes.OutputDiff(target)
+			} else if input == "skip" {
+				skipped, err := promptSkip(changes, o.skipResources)
+				if err != nil {
+					return err
+				}
+				o.skipResources = skipped
+				printSkipped(changes, skipped)
 			} else {
 				fmt.Println("Operation apply canceled")
 				return nil
@@ -136,8 +252,45 @@ func (o *ApplyOptions) Run() error {
 		}
 	}
 
-	fmt.Println("Start applying diffs ...")
-	if err := Apply(o, stateStorage, sp, changes, os.Stdout); err != nil {
+	// Stream events of the apply to UIs
+	if o.ProgressAddr != "" {
+		if o.progress, err = progress.Serve(o.ProgressAddr); err != nil {
+			return err
+		}
+		defer o.progress.Close()
+		fmt.Printf("Streaming progress at http://%s%s\n", o.progress.Addr(), progress.Path)
+		o.progress.Publish(&progress.Event{
+			Type:      progress.EventStart,
+			Operation: "apply",
+			Project:   project.Name,
+			Stack:     stack.Name,
+			Total:     len(changes.StepKeys),
+		})
+	}
+
+	start := time.Now()
+	_, err = Execute(o, stateStorage, sp, changes, os.Stdout)
+	finish := &progress.Event{Type: progress.EventFinish, Operation: "apply", Result: ResultSuccess}
+	if err != nil {
+		finish.Result, finish.Error = ResultFailed, err.Error()
+	}
+	o.progress.Publish(finish)
+	if o.Report != "" {
+		if e := WriteReport(o.newReport(project, stack, changes, start, err), o.Report); e != nil {
+			pterm.Warning.Println(e)
+		} else {
+			fmt.Printf("Apply report is written to %s\n", o.Report)
+		}
+	}
+	if o.JUnit != "" {
+		if e := junit.Write(o.newReport(project, stack, changes, start, err).JUnit(), o.JUnit); e != nil {
+			pterm.Warning.Println(e)
+		}
+	}
+	if err != nil {
+		if o.FailureBundle != "" {
+			o.writeFailureBundle(project, stack, sp, changes, err)
+		}
 		return err
 	}
 
@@ -187,20 +340,32 @@ func Apply(
 	out io.Writer,
 ) error {
 	// Validate secret stores
-	project := changes.Project()
-	if !project.SecretStores.IsValid() {
+	secretStores := changes.Stack().GetSecretStores(changes.Project())
+	if !secretStores.IsValid() {
 		return fmt.Errorf("no secret store is provided")
 	}
 
 	// Construct the apply operation
 	ac := &operation.ApplyOperation{
 		Operation: opsmodels.Operation{
-			Stack:        changes.Stack(),
-			StateStorage: storage,
-			MsgCh:        make(chan opsmodels.Message),
-			SecretStores: project.SecretStores,
+			Stack:            changes.Stack(),
+			StateStorage:     storage,
+			MsgCh:            make(chan opsmodels.Message),
+			SecretStores:     secretStores,
+			SkipResources:    o.skipResources,
+			ReplaceResources: o.replaceResources,
+			Parallelism:      o.ResourceParallelism,
+			LiveStates:       o.LiveStates,
+			RuntimeEnv:       o.RuntimeEnv,
+			Context:          o.ctx,
+
+			IgnoreFields:        o.IgnoreFields,
+			IgnoreFieldsConfigs: changes.Stack().GetIgnoreFields(changes.Project()),
 		},
 	}
+	if o.BindPlan {
+		ac.BoundSteps = changes.ChangeSteps
+	}
 
 	// Line summary
 	var ls lineSummary
@@ -216,6 +381,8 @@ func Apply(
 	}
 	// Wait msgCh close
 	var wg sync.WaitGroup
+	wg.Add(1)
+	o.results = map[string]opsmodels.Message{}
 	// Receive msg and print detail
 	go func() {
 		defer func() {
@@ -223,7 +390,6 @@ func Apply(
 				log.Errorf("failed to receive msg and print detail as %v", p)
 			}
 		}()
-		wg.Add(1)
 
 		for {
 			select {
@@ -233,6 +399,14 @@ func Apply(
 					return
 				}
 				changeStep := changes.Get(msg.ResourceID)
+				if msg.OpResult != "" {
+					o.results[msg.ResourceID] = msg
+				}
+				o.progress.Publish(progressEvent(changeStep, msg))
+				o.notifier.Resource(changeStep, msg)
+				if o.OnMessage != nil {
+					o.OnMessage(changeStep, msg)
+				}
 
 				switch msg.OpResult {
 				case opsmodels.Success, opsmodels.Skip:
@@ -253,7 +427,9 @@ func Apply(
 					pterm.Success.WithWriter(out).Println(title)
 					progressbar.UpdateTitle(title)
 					progressbar.Increment()
-					ls.Count(changeStep.Action)
+					if msg.OpResult == opsmodels.Success {
+						ls.Count(changeStep.Action)
+					}
 				case opsmodels.Failed:
 					title := fmt.Sprintf("%s %s %s",
 						changeStep.Action.String(),
@@ -275,16 +451,20 @@ func Apply(
 
 	if o.DryRun {
 		for _, r := range planResources.Resources {
+			opResult := opsmodels.Success
+			if o.skipResources[r.ResourceKey()] {
+				opResult = opsmodels.Skip
+			}
 			ac.MsgCh <- opsmodels.Message{
 				ResourceID: r.ResourceKey(),
-				OpResult:   opsmodels.Success,
+				OpResult:   opResult,
 				OpErr:      nil,
 			}
 		}
 		close(ac.MsgCh)
 	} else {
 		cluster := planResources.ParseCluster()
-		_, st := ac.Apply(&operation.ApplyRequest{
+		rsp, st := ac.Apply(&operation.ApplyRequest{
 			Request: opsmodels.Request{
 				Tenant:   changes.Project().Tenant,
 				Project:  changes.Project(),
@@ -292,11 +472,17 @@ func Apply(
 				Cluster:  cluster,
 				Operator: o.Operator,
 				Spec:     planResources,
+				Audits:   o.audits,
+				Revision: o.Revision,
 			},
 		})
 		if status.IsErr(st) {
-			return fmt.Errorf("apply failed, status:\n%v", st)
+			// wait for results of resources before returning
+			wg.Wait()
+			// keep typed errors of the status, e.g. lock conflicts mapped to exit codes
+			return status.Wrap(st, fmt.Sprintf("apply failed, status:\n%v", st))
 		}
+		o.state = rsp.State
 	}
 
 	// Wait for msgCh closed
@@ -339,15 +525,28 @@ func Watch(o *ApplyOptions,
 		}
 	}
 
-	// Watch operation
-	wo := &operation.WatchOperation{}
-	if err := wo.Watch(&operation.WatchRequest{
+	// Watch operation, whose events of stuck resources are printed under their tables and recorded in the log
+	wo := &operation.WatchOperation{Operation: opsmodels.Operation{MsgCh: make(chan opsmodels.Message), RuntimeEnv: o.RuntimeEnv}}
+	received := make(chan struct{})
+	go func() {
+		defer close(received)
+		for msg := range wo.MsgCh {
+			if e := msg.Event; e != nil {
+				log.Infof("event of %s: %s %s (x%d): %s", msg.ResourceID, e.Object, e.Reason, e.Count, e.Message)
+			}
+		}
+	}()
+	err := wo.Watch(&operation.WatchRequest{
 		Request: opsmodels.Request{
 			Project: changes.Project(),
 			Stack:   changes.Stack(),
 			Spec:    &models.Spec{Resources: toBeWatched},
 		},
-	}); err != nil {
+		Timeout: o.WatchTimeout,
+	})
+	close(wo.MsgCh)
+	<-received
+	if err != nil {
 		return err
 	}
 
@@ -355,6 +554,77 @@ func Watch(o *ApplyOptions,
 	return nil
 }
 
+// writeFailureBundle writes the diagnostic bundle of the failed apply with failed resources in it. Errors of
+// writing are only warned since the apply has failed anyway.
+func (o *ApplyOptions) writeFailureBundle(
+	project *projectstack.Project,
+	stack *projectstack.Stack,
+	sp *models.Spec,
+	changes *opsmodels.Changes,
+	err error,
+) {
+	var failed []string
+	for id, msg := range o.results {
+		if msg.OpResult == opsmodels.Failed {
+			failed = append(failed, id)
+		}
+	}
+	sort.Strings(failed)
+	if e := bundle.Write(context.Background(), o.FailureBundle, &bundle.Request{
+		Operation: "apply",
+		Project:   project,
+		Stack:     stack,
+		Spec:      sp,
+		Changes:   changes,
+		Failed:    failed,
+		Error:     err,
+
+		RuntimeEnv: o.RuntimeEnv,
+	}); e != nil {
+		pterm.Warning.Println(e)
+		return
+	}
+	fmt.Printf("Diagnostic bundle is written to %s, sensitive values in it are masked\n", o.FailureBundle)
+}
+
+// heartbeat streams the refresh of the lock of the state to UIs, warns if the refresh fails, and aborts the
+// apply if the lock is lost, since another operation may change the state then
+func (o *ApplyOptions) heartbeat(_ *states.LockInfo, err error) {
+	e := &progress.Event{Type: progress.EventHeartbeat, Operation: "apply"}
+	if errors.Is(err, states.ErrLockLost) && o.cancel != nil {
+		pterm.Error.Printfln("Aborting the apply: %v", err)
+		o.lockLost = err
+		o.cancel()
+	}
+	if err != nil {
+		e.Error = err.Error()
+		pterm.Warning.Printfln("Failed to refresh the lock of the state: %v", err)
+	}
+	o.progress.Publish(e)
+	if o.OnHeartbeat != nil {
+		o.OnHeartbeat(err)
+	}
+}
+
+// progressEvent returns the event of the resource streamed to UIs
+func progressEvent(changeStep *opsmodels.ChangeStep, msg opsmodels.Message) *progress.Event {
+	e := &progress.Event{
+		Type:       progress.EventResource,
+		ResourceID: msg.ResourceID,
+		Result:     strings.ToLower(string(msg.OpResult)),
+	}
+	if changeStep != nil {
+		e.Action = changeStep.Action.String()
+	}
+	if e.Result == "" {
+		e.Result = "applying"
+	}
+	if msg.OpErr != nil {
+		e.Error = msg.OpErr.Error()
+	}
+	return e
+}
+
 type lineSummary struct {
 	created, updated, deleted int
 }
@@ -380,9 +650,93 @@ func allUnChange(changes *opsmodels.Changes) bool {
 	return true
 }
 
+// revisionChanged returns true if the revision to apply differs from the revision in the latest state
+func (o *ApplyOptions) revisionChanged(
+	storage states.StateStorage,
+	project *projectstack.Project,
+	stack *projectstack.Stack,
+) bool {
+	if o.Revision == "" {
+		return false
+	}
+	latest, err := storage.GetLatestState(&states.StateQuery{
+		Tenant:  project.Tenant,
+		Project: project.Name,
+		Stack:   stack.Name,
+	})
+	if err != nil {
+		log.Errorf("get the latest state failed: %v", err)
+		return true
+	}
+	return latest == nil || latest.Revision != o.Revision
+}
+
+// checkPlanHash returns an error if the hash of changes is not the expected one,
+// which means the spec or the live state has changed since the preview.
+func checkPlanHash(changes *opsmodels.Changes, expected string) error {
+	actual, err := changes.Hash()
+	if err != nil {
+		return err
+	}
+	if actual != expected {
+		return fmt.Errorf("plan hash mismatch, expected %s but got %s. "+
+			"The spec or the live state has changed since the preview, please preview again", expected, actual)
+	}
+	return nil
+}
+
+// confirmReplacements collects resources to replace, which are specified by --replace or confirmed in the
+// prompt if they require replacement. It returns false if users refuse to replace any of them, and an error
+// if any resource requiring replacement is not specified by --replace with --yes.
+func (o *ApplyOptions) confirmReplacements(changes *opsmodels.Changes) (bool, error) {
+	o.replaceResources = make(map[string]bool, len(o.Replace))
+	for _, id := range o.Replace {
+		o.replaceResources[id] = true
+	}
+
+	var missing []string
+	for _, step := range changes.Values(opsmodels.ReplaceChangeStepFilter) {
+		if o.replaceResources[step.ID] {
+			continue
+		}
+		if o.Yes {
+			missing = append(missing, step.ID)
+			continue
+		}
+		ok, err := promptReplace(step)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+		o.replaceResources[step.ID] = true
+	}
+	if len(missing) > 0 {
+		return false, fmt.Errorf("%s require(s) replacement since immutable fields are changed, "+
+			"apply with --replace %s to delete and create them again", strings.Join(missing, ", "), strings.Join(missing, ","))
+	}
+	return true, nil
+}
+
+// promptReplace asks users whether to replace the resource whose immutable fields are changed
+func promptReplace(step *opsmodels.ChangeStep) (bool, error) {
+	prompt := &survey.Confirm{
+		Message: fmt.Sprintf("%s requires replacement since immutable fields %s are changed. "+
+			"Do you want to delete and create it again?", step.ID, strings.Join(step.ReplaceFields, ", ")),
+	}
+
+	var replace bool
+	if err := survey.AskOne(prompt, &replace); err != nil {
+		fmt.Printf("Prompt failed %v\n", err)
+		return false, err
+	}
+	return replace, nil
+}
+
 func prompt() (string, error) {
 	// don`t display yes item when only preview
-	options := []string{"yes", "details", "no"}
+	options := []string{"yes", "details", "skip", "no"}
 
 	prompt := &survey.Select{
 		Message: `Do you want to apply these diffs?`,
@@ -398,3 +752,131 @@ func prompt() (string, error) {
 	}
 	return input, nil
 }
+
+// promptSkip lets users check the diff of each changed resource and toggle the ones that should be skipped in
+// this apply, and returns keys of the resources to skip. Dependencies of the resources to apply are never
+// skipped, since resources can not be applied before their dependencies.
+func promptSkip(changes *opsmodels.Changes, skipped map[string]bool) (map[string]bool, error) {
+	result := make(map[string]bool, len(skipped))
+	for id := range skipped {
+		result[id] = true
+	}
+
+	for {
+		options := make([]string, 0, len(changes.StepKeys)+1)
+		optionMaps := make(map[string]string, len(changes.StepKeys))
+		for _, step := range changes.Values() {
+			if step.Action == opsmodels.UnChange {
+				continue
+			}
+			mark := "[apply]"
+			if result[step.ID] {
+				mark = "[skip] "
+			}
+			option := pterm.Sprintf("%s %s %s", mark, step.ID, pretty.Gray(step.ActionString()))
+			options = append(options, option)
+			optionMaps[option] = step.ID
+		}
+		options = append(options, "done")
+
+		prompt := &survey.Select{
+			Message: `Which resource do you want to check or skip?`,
+			Options: options,
+		}
+		var input string
+		if err := survey.AskOne(prompt, &input); err != nil {
+			fmt.Printf("Prompt failed %v\n", err)
+			return nil, err
+		}
+		if input == "done" {
+			break
+		}
+
+		id := optionMaps[input]
+		action, err := promptResource(id, result[id])
+		if err != nil {
+			return nil, err
+		}
+		switch action {
+		case "diff":
+			changes.OutputDiff(id)
+		case "skip":
+			result[id] = true
+		case "apply":
+			delete(result, id)
+		}
+	}
+
+	if included := includeDependencies(changes, result); len(included) > 0 {
+		fmt.Println("Resources to apply since resources to apply depend on them:")
+		for _, id := range included {
+			fmt.Printf(" * %s\n", id)
+		}
+		fmt.Println()
+	}
+	return result, nil
+}
+
+// promptResource asks what to do with the resource, which is one of diff, skip, apply and back
+func promptResource(id string, skipped bool) (string, error) {
+	toggle := "skip"
+	if skipped {
+		toggle = "apply"
+	}
+	prompt := &survey.Select{
+		Message: fmt.Sprintf(`What do you want to do with %s?`, id),
+		Options: []string{"diff", toggle, "back"},
+	}
+
+	var input string
+	if err := survey.AskOne(prompt, &input); err != nil {
+		fmt.Printf("Prompt failed %v\n", err)
+		return "", err
+	}
+	return input, nil
+}
+
+// includeDependencies removes dependencies of the resources to apply from the skipped resources recursively,
+// and returns keys of the removed ones
+func includeDependencies(changes *opsmodels.Changes, skipped map[string]bool) []string {
+	var queue, included []string
+	for _, step := range changes.Values() {
+		if step.Action != opsmodels.UnChange && !skipped[step.ID] {
+			queue = append(queue, step.ID)
+		}
+	}
+	for len(queue) > 0 {
+		step := changes.Get(queue[0])
+		queue = queue[1:]
+		if step == nil {
+			continue
+		}
+		// only resources to create or update have planned states with dependencies
+		resource, ok := step.To.(*models.Resource)
+		if !ok || resource == nil {
+			continue
+		}
+		for _, dependency := range resource.DependsOn {
+			if skipped[dependency] {
+				delete(skipped, dependency)
+				included = append(included, dependency)
+				queue = append(queue, dependency)
+			}
+		}
+	}
+	return included
+}
+
+func printSkipped(changes *opsmodels.Changes, skipped map[string]bool) {
+	if len(skipped) == 0 {
+		fmt.Println("No resource will be skipped")
+		return
+	}
+	fmt.Println("Resources to skip:")
+	for _, step := range changes.Values() {
+		if skipped[step.ID] {
+			fmt.Printf(" * %s %s\n", step.ID, pretty.Gray(step.Action.String()))
+		}
+	}
+	fmt.Println()
+}
@@ -3,14 +3,18 @@ package apply
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"bou.ke/monkey"
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"kusionstack.io/kusion/pkg/cmd/spec"
 	"kusionstack.io/kusion/pkg/engine"
@@ -19,6 +23,7 @@ import (
 	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
 	"kusionstack.io/kusion/pkg/engine/runtime"
 	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
+	"kusionstack.io/kusion/pkg/engine/states"
 	"kusionstack.io/kusion/pkg/engine/states/local"
 	"kusionstack.io/kusion/pkg/generator"
 	"kusionstack.io/kusion/pkg/projectstack"
@@ -55,6 +60,20 @@ func TestApplyOptions_Run(t *testing.T) {
 		err := o.Run()
 		assert.Nil(t, err)
 	})
+
+	t.Run("PlanHash mismatch", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		mockDetectProjectAndStack()
+		mockGenerateSpec()
+		mockNewKubernetesRuntime()
+		mockOperationPreview()
+
+		o := NewApplyOptions()
+		o.Yes = true
+		o.PlanHash = "outdated"
+		err := o.Run()
+		assert.ErrorContains(t, err, "plan hash mismatch")
+	})
 }
 
 var (
@@ -86,7 +105,7 @@ func mockGenerateSpec() {
 }
 
 func mockNewKubernetesRuntime() {
-	monkey.Patch(kubernetes.NewKubernetesRuntime, func() (runtime.Runtime, error) {
+	monkey.Patch(kubernetes.NewKubernetesRuntime, func(runtime.Env) (runtime.Runtime, error) {
 		return &fakerRuntime{}, nil
 	})
 }
@@ -301,6 +320,82 @@ func Test_prompt(t *testing.T) {
 	})
 }
 
+func Test_promptSkip(t *testing.T) {
+	defer monkey.UnpatchAll()
+	dependent := sa1
+	dependent.DependsOn = []string{sa3.ID}
+	order := &opsmodels.ChangeOrder{
+		StepKeys: []string{sa1.ID, sa2.ID, sa3.ID},
+		ChangeSteps: map[string]*opsmodels.ChangeStep{
+			sa1.ID: {
+				ID:     sa1.ID,
+				Action: opsmodels.Create,
+				To:     &dependent,
+			},
+			sa2.ID: {
+				ID:     sa2.ID,
+				Action: opsmodels.UnChange,
+			},
+			sa3.ID: {
+				ID:     sa3.ID,
+				Action: opsmodels.Create,
+				To:     &sa3,
+			},
+		},
+	}
+	changes := opsmodels.NewChanges(project, stack, order)
+
+	// mockAnswers answers selects with the first options containing the answers in turn
+	mockAnswers := func(answers ...string) *[]string {
+		var options []string
+		monkey.Patch(
+			survey.AskOne,
+			func(p survey.Prompt, response interface{}, opts ...survey.AskOpt) error {
+				answer := answers[0]
+				answers = answers[1:]
+				for _, option := range p.(*survey.Select).Options {
+					if strings.Contains(option, answer) {
+						options = append(options, option)
+						reflect.ValueOf(response).Elem().Set(reflect.ValueOf(option))
+						return nil
+					}
+				}
+				return fmt.Errorf("no option %s", answer)
+			},
+		)
+		return &options
+	}
+
+	t.Run("skip a resource", func(t *testing.T) {
+		options := mockAnswers(sa1.ID, "diff", sa1.ID, "skip", "done")
+		skipped, err := promptSkip(changes, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]bool{sa1.ID: true}, skipped)
+		assert.Contains(t, (*options)[2], "[apply]")
+		assert.Contains(t, (*options)[4], "done")
+	})
+
+	t.Run("apply a skipped resource", func(t *testing.T) {
+		mockAnswers(sa1.ID, "apply", "done")
+		skipped, err := promptSkip(changes, map[string]bool{sa1.ID: true})
+		assert.Nil(t, err)
+		assert.Empty(t, skipped)
+	})
+
+	t.Run("apply dependencies", func(t *testing.T) {
+		mockAnswers(sa3.ID, "skip", "done")
+		skipped, err := promptSkip(changes, nil)
+		assert.Nil(t, err)
+		assert.Empty(t, skipped)
+	})
+
+	t.Run("unchanged resources", func(t *testing.T) {
+		mockAnswers(sa2.ID)
+		_, err := promptSkip(changes, nil)
+		assert.NotNil(t, err)
+	})
+}
+
 func mockPromptOutput(res string) {
 	monkey.Patch(
 		survey.AskOne,
@@ -310,3 +405,92 @@ func mockPromptOutput(res string) {
 		},
 	)
 }
+
+func TestApplyOptions_confirmReplacements(t *testing.T) {
+	defer monkey.UnpatchAll()
+	changes := opsmodels.NewChanges(project, stack, &opsmodels.ChangeOrder{
+		StepKeys: []string{sa1.ID, sa2.ID},
+		ChangeSteps: map[string]*opsmodels.ChangeStep{
+			sa1.ID: {ID: sa1.ID, Action: opsmodels.Update, ReplaceFields: []string{"secrets"}},
+			sa2.ID: {ID: sa2.ID, Action: opsmodels.Update},
+		},
+	})
+
+	t.Run("replace specified", func(t *testing.T) {
+		o := NewApplyOptions()
+		o.Yes = true
+		o.Replace = []string{sa1.ID, sa2.ID}
+		ok, err := o.confirmReplacements(changes)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, map[string]bool{sa1.ID: true, sa2.ID: true}, o.replaceResources)
+	})
+
+	t.Run("replace not specified with yes", func(t *testing.T) {
+		o := NewApplyOptions()
+		o.Yes = true
+		_, err := o.confirmReplacements(changes)
+		assert.ErrorContains(t, err, "apply with --replace "+sa1.ID)
+	})
+
+	t.Run("replace confirmed", func(t *testing.T) {
+		monkey.Patch(promptReplace, func(step *opsmodels.ChangeStep) (bool, error) {
+			return true, nil
+		})
+		o := NewApplyOptions()
+		ok, err := o.confirmReplacements(changes)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, map[string]bool{sa1.ID: true}, o.replaceResources)
+	})
+
+	t.Run("replace refused", func(t *testing.T) {
+		monkey.Patch(promptReplace, func(step *opsmodels.ChangeStep) (bool, error) {
+			return false, nil
+		})
+		o := NewApplyOptions()
+		ok, err := o.confirmReplacements(changes)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func Test_progressEvent(t *testing.T) {
+	step := &opsmodels.ChangeStep{ID: sa1.ID, Action: opsmodels.Create}
+	e := progressEvent(step, opsmodels.Message{ResourceID: sa1.ID})
+	assert.Equal(t, "applying", e.Result)
+	assert.Equal(t, "Create", e.Action)
+
+	e = progressEvent(step, opsmodels.Message{ResourceID: sa1.ID, OpResult: opsmodels.Failed, OpErr: errors.New("mock error")})
+	assert.Equal(t, "failed", e.Result)
+	assert.Equal(t, "mock error", e.Error)
+}
+
+func TestApplyOptions_revisionChanged(t *testing.T) {
+	storage := &local.FileSystemState{Path: filepath.Join(t.TempDir(), local.KusionState)}
+	o := NewApplyOptions()
+	assert.False(t, o.revisionChanged(storage, project, stack))
+
+	o.Revision = "abc123"
+	assert.True(t, o.revisionChanged(storage, project, stack))
+
+	state := states.NewState()
+	state.Tenant, state.Project, state.Stack, state.Revision = project.Tenant, project.Name, stack.Name, "abc123"
+	require.NoError(t, storage.Apply(state))
+	assert.False(t, o.revisionChanged(storage, project, stack))
+
+	o.Revision = "def456"
+	assert.True(t, o.revisionChanged(storage, project, stack))
+}
+
+func TestApplyOptions_validateRefreshTTL(t *testing.T) {
+	o := NewApplyOptions()
+	o.RefreshTTL = time.Minute
+	assert.Nil(t, o.Validate())
+
+	o.BindPlan = true
+	assert.Nil(t, o.Validate())
+
+	o.RefreshTTL = -time.Minute
+	assert.ErrorContains(t, o.Validate(), "must not be negative")
+}
@@ -0,0 +1,79 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pterm/pterm"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
+)
+
+type permissionChecker interface {
+	CheckPermissions(ctx context.Context, requests []kubernetes.PermissionRequest) ([]kubernetes.DeniedPermission, error)
+}
+
+// newPermissionChecker returns the Kubernetes runtime to check permissions of the current identity
+var newPermissionChecker = kubernetes.RuntimeAs[permissionChecker]
+
+// checkPermissions checks that the current identity is allowed to create, patch and delete every Kubernetes
+// resource in the changes before walking the graph, so that the apply fails fast with all missing
+// permissions instead of failing halfway.
+func (o *ApplyOptions) checkPermissions(changes *opsmodels.Changes) error {
+	requests := permissionRequests(changes, o.skipResources, o.replaceResources)
+	if len(requests) == 0 {
+		return nil
+	}
+
+	checker, err := newPermissionChecker(o.RuntimeEnv)
+	if err != nil {
+		return err
+	}
+	if checker == nil {
+		return nil
+	}
+	denied, err := checker.CheckPermissions(context.Background(), requests)
+	if err != nil {
+		return err
+	}
+	if len(denied) == 0 {
+		return nil
+	}
+	for _, d := range denied {
+		pterm.Error.Println(d.String())
+	}
+	return fmt.Errorf("permission check failed, %d permission(s) missing to apply the changes", len(denied))
+}
+
+// permissionRequests returns verbs to perform on Kubernetes resources of the changes. Replaced resources
+// are deleted and created again, and skipped resources are not changed.
+func permissionRequests(changes *opsmodels.Changes, skipped, replaced map[string]bool) []kubernetes.PermissionRequest {
+	var requests []kubernetes.PermissionRequest
+	add := func(verb string, resource interface{}) {
+		if res, ok := resource.(*models.Resource); ok && res != nil && res.Type == runtime.Kubernetes {
+			requests = append(requests, kubernetes.PermissionRequest{Verb: verb, Resource: res})
+		}
+	}
+	for _, step := range changes.Values() {
+		if skipped[step.ID] {
+			continue
+		}
+		switch step.Action {
+		case opsmodels.Create:
+			add("create", step.To)
+		case opsmodels.Update:
+			if replaced[step.ID] {
+				add("delete", step.To)
+				add("create", step.To)
+			} else {
+				add("patch", step.To)
+			}
+		case opsmodels.Delete:
+			add("delete", step.From)
+		}
+	}
+	return requests
+}
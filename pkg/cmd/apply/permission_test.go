@@ -0,0 +1,82 @@
+package apply
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
+)
+
+type fakePermissionChecker struct {
+	requests []kubernetes.PermissionRequest
+	denied   []kubernetes.DeniedPermission
+	err      error
+}
+
+func (c *fakePermissionChecker) CheckPermissions(
+	_ context.Context,
+	requests []kubernetes.PermissionRequest,
+) ([]kubernetes.DeniedPermission, error) {
+	c.requests = requests
+	return c.denied, c.err
+}
+
+func mockPermissionChecker(t *testing.T, checker *fakePermissionChecker) {
+	origin := newPermissionChecker
+	newPermissionChecker = func(runtime.Env) (permissionChecker, error) {
+		return checker, nil
+	}
+	t.Cleanup(func() {
+		newPermissionChecker = origin
+	})
+}
+
+func TestApplyOptions_checkPermissions(t *testing.T) {
+	changes := opsmodels.NewChanges(project, stack, &opsmodels.ChangeOrder{
+		StepKeys: []string{sa1.ID, sa2.ID, sa3.ID},
+		ChangeSteps: map[string]*opsmodels.ChangeStep{
+			sa1.ID: opsmodels.NewChangeStep(sa1.ID, opsmodels.Create, nil, &sa1),
+			sa2.ID: opsmodels.NewChangeStep(sa2.ID, opsmodels.Update, &sa2, &sa2),
+			sa3.ID: opsmodels.NewChangeStep(sa3.ID, opsmodels.Delete, &sa3, nil),
+		},
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		checker := &fakePermissionChecker{}
+		mockPermissionChecker(t, checker)
+		o := NewApplyOptions()
+		o.replaceResources = map[string]bool{sa2.ID: true}
+		o.skipResources = map[string]bool{sa3.ID: true}
+		assert.Nil(t, o.checkPermissions(changes))
+		assert.Equal(t, []kubernetes.PermissionRequest{
+			{Verb: "create", Resource: &sa1},
+			{Verb: "delete", Resource: &sa2},
+			{Verb: "create", Resource: &sa2},
+		}, checker.requests)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		checker := &fakePermissionChecker{denied: []kubernetes.DeniedPermission{
+			{Verb: "delete", Resource: "serviceaccounts", Namespace: namespace, ResourceIDs: []string{sa3.ID}},
+		}}
+		mockPermissionChecker(t, checker)
+		o := NewApplyOptions()
+		assert.EqualError(t, o.checkPermissions(changes),
+			"permission check failed, 1 permission(s) missing to apply the changes")
+		assert.Equal(t, []kubernetes.PermissionRequest{
+			{Verb: "create", Resource: &sa1},
+			{Verb: "patch", Resource: &sa2},
+			{Verb: "delete", Resource: &sa3},
+		}, checker.requests)
+	})
+
+	t.Run("check failed", func(t *testing.T) {
+		mockPermissionChecker(t, &fakePermissionChecker{err: errors.New("connection refused")})
+		assert.EqualError(t, NewApplyOptions().checkPermissions(changes), "connection refused")
+	})
+}
@@ -0,0 +1,129 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/pterm/pterm"
+
+	previewcmd "kusionstack.io/kusion/pkg/cmd/preview"
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/notification"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// Prepare previews the spec and runs the gates of applies before any change is made: the plan hash, policies
+// and the validation on the server. kusion apply, the engine server and the operator all prepare applies by
+// it, so that none of them skips a gate.
+func Prepare(
+	o *ApplyOptions,
+	storage states.StateStorage,
+	sp *models.Spec,
+	project *projectstack.Project,
+	stack *projectstack.Stack,
+) (*opsmodels.Changes, error) {
+	// Compute changes for preview, whose live states are reused by the apply if it follows soon
+	if o.RefreshTTL > 0 {
+		o.LiveStates = opsmodels.NewLiveStateCache(o.RefreshTTL)
+	}
+	changes, err := previewcmd.Preview(&o.PreviewOptions, storage, sp, project, stack)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure the changes are exactly the ones reviewed in the preview
+	if o.PlanHash != "" {
+		if err = checkPlanHash(changes, o.PlanHash); err != nil {
+			return nil, err
+		}
+	}
+
+	// Block the apply if any policy is violated
+	audits, err := previewcmd.CheckPolicies(&o.PreviewOptions, project, stack, sp, changes)
+	defer previewcmd.WriteSARIF(&o.PreviewOptions)
+	if err != nil {
+		previewcmd.WriteJUnit(&o.PreviewOptions, project, stack, changes, err)
+		return nil, err
+	}
+	o.audits = append(o.audits, audits...)
+
+	// Report errors of the cluster before any change is made
+	if err = previewcmd.ValidateOnServer(&o.PreviewOptions, storage, sp, project, stack, changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// Execute applies the changes returned by Prepare, and returns the state applied, which is nil for dry runs.
+// Unless it is a dry run, the state is locked and backed up, then permissions, capacity and namespaces are
+// checked and webhooks are notified before the apply, with audits of overridden checks recorded in the state.
+func Execute(
+	o *ApplyOptions,
+	storage states.StateStorage,
+	sp *models.Spec,
+	changes *opsmodels.Changes,
+	out io.Writer,
+) (*states.State, error) {
+	project, stack := changes.Project(), changes.Stack()
+	if !o.DryRun {
+		// Lock the state during the apply, the lock expires soon if the process dies, and the apply is aborted if
+		// the lock is lost
+		o.ctx, o.cancel = context.WithCancel(context.Background())
+		defer o.cancel()
+		query := &states.StateQuery{
+			Tenant:  project.Tenant,
+			Project: project.Name,
+			Stack:   stack.Name,
+		}
+		unlock, audits, err := states.AcquireLock(storage, query, "apply", o.Operator, o.ForceUnlock, o.heartbeat)
+		if err != nil {
+			return nil, err
+		}
+		o.audits = append(o.audits, audits...)
+		defer func() {
+			if e := unlock(); e != nil {
+				pterm.Warning.Println(e)
+			}
+		}()
+
+		// Back up the state before the apply, which is restored by kusion state restore-backup
+		if _, err = states.BackupState(storage, query); err != nil {
+			return nil, err
+		}
+
+		// Fail fast if the current identity is not allowed to change any of the resources, or pods can not be
+		// created or scheduled. Namespaces are created with the state locked, so that concurrent applies never
+		// change the cluster before they are serialized by the lock.
+		if err = o.checkPermissions(changes); err != nil {
+			return nil, err
+		}
+		if err = o.checkCapacity(changes); err != nil {
+			return nil, err
+		}
+		if err = o.ensureNamespaces(stack, changes); err != nil {
+			return nil, err
+		}
+
+		// Notify webhooks of the project and the stack, failed notifications never fail the apply
+		o.notifier = notification.NewNotifier("apply", project, stack, o.Operator, changes)
+		if err = o.notifier.Start(context.Background()); err != nil {
+			pterm.Warning.Println(err)
+		}
+	}
+
+	fmt.Fprintln(out, "Start applying diffs ...")
+	err := Apply(o, storage, sp, changes, out)
+	if err != nil && o.ctx != nil && o.ctx.Err() != nil {
+		err = fmt.Errorf("the apply is aborted: %w", o.lockLost)
+	}
+	if e := o.notifier.Finish(context.Background(), err); e != nil {
+		pterm.Warning.Println(e)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return o.state, nil
+}
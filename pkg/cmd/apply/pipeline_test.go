@@ -0,0 +1,142 @@
+package apply
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/engine/states/local"
+)
+
+// lockedStorage records locks of the state
+type lockedStorage struct {
+	*local.FileSystemState
+	locks []string
+}
+
+func (s *lockedStorage) Lock(_ *states.StateQuery, info *states.LockInfo) error {
+	s.locks = append(s.locks, "lock by "+info.Operator)
+	return nil
+}
+
+func (s *lockedStorage) RefreshLock(*states.StateQuery, *states.LockInfo) error {
+	return nil
+}
+
+func (s *lockedStorage) Unlock(_ *states.StateQuery, info *states.LockInfo) error {
+	s.locks = append(s.locks, "unlock by "+info.Operator)
+	return nil
+}
+
+func TestPrepare(t *testing.T) {
+	defer monkey.UnpatchAll()
+	mockNewKubernetesRuntime()
+	mockOperationPreview()
+	storage := &local.FileSystemState{Path: filepath.Join(t.TempDir(), local.KusionState)}
+	sp := &models.Spec{Resources: []models.Resource{sa1, sa2, sa3}}
+
+	o := NewApplyOptions()
+	o.NoStyle = true
+	changes, err := Prepare(o, storage, sp, project, stack)
+	require.NoError(t, err)
+	assert.Len(t, changes.StepKeys, 3)
+
+	o.PlanHash = "outdated"
+	_, err = Prepare(o, storage, sp, project, stack)
+	assert.ErrorContains(t, err, "plan hash mismatch")
+}
+
+func TestExecute(t *testing.T) {
+	sp := &models.Spec{Resources: []models.Resource{sa1}}
+	changes := opsmodels.NewChanges(project, stack, &opsmodels.ChangeOrder{
+		StepKeys: []string{sa1.ID},
+		ChangeSteps: map[string]*opsmodels.ChangeStep{
+			sa1.ID: {ID: sa1.ID, Action: opsmodels.Create, To: &sa1},
+		},
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		mockNewKubernetesRuntime()
+		mockOperationApply(opsmodels.Success)
+		storage := &lockedStorage{FileSystemState: &local.FileSystemState{Path: filepath.Join(t.TempDir(), local.KusionState)}}
+
+		o := NewApplyOptions()
+		o.Operator = "alice"
+		var results []opsmodels.OpResult
+		o.OnMessage = func(step *opsmodels.ChangeStep, msg opsmodels.Message) {
+			assert.Equal(t, sa1.ID, step.ID)
+			results = append(results, msg.OpResult)
+		}
+		_, err := Execute(o, storage, sp, changes, io.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"lock by alice", "unlock by alice"}, storage.locks)
+		assert.Equal(t, []opsmodels.OpResult{"", opsmodels.Success}, results)
+	})
+
+	t.Run("checked with the state locked", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		mockOperationApply(opsmodels.Success)
+		storage := &lockedStorage{FileSystemState: &local.FileSystemState{Path: filepath.Join(t.TempDir(), local.KusionState)}}
+		var locks []string
+		origin := newNamespaceEnsurer
+		newNamespaceEnsurer = func(runtime.Env) (namespaceEnsurer, error) {
+			locks = append(locks, storage.locks...)
+			return &fakeNamespaceEnsurer{}, nil
+		}
+		t.Cleanup(func() {
+			newNamespaceEnsurer = origin
+		})
+		mockPermissionChecker(t, &fakePermissionChecker{})
+		mockCapacityChecker(t, &fakeCapacityChecker{})
+
+		o := NewApplyOptions()
+		o.Operator = "alice"
+		_, err := Execute(o, storage, sp, changes, io.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"lock by alice"}, locks)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		storage := &lockedStorage{FileSystemState: &local.FileSystemState{Path: filepath.Join(t.TempDir(), local.KusionState)}}
+
+		o := NewApplyOptions()
+		o.DryRun = true
+		state, err := Execute(o, storage, sp, changes, io.Discard)
+		require.NoError(t, err)
+		assert.Nil(t, state)
+		assert.Empty(t, storage.locks)
+	})
+}
+
+func TestApplyOptions_heartbeat(t *testing.T) {
+	o := NewApplyOptions()
+	o.ctx, o.cancel = context.WithCancel(context.Background())
+	defer o.cancel()
+	var heartbeats []error
+	o.OnHeartbeat = func(err error) {
+		heartbeats = append(heartbeats, err)
+	}
+
+	// failed refreshes are only warned
+	o.heartbeat(nil, errors.New("timeout"))
+	assert.Nil(t, o.ctx.Err())
+
+	lost := fmt.Errorf("%w: taken over by bob", states.ErrLockLost)
+	o.heartbeat(nil, lost)
+	assert.NotNil(t, o.ctx.Err())
+	assert.Equal(t, lost, o.lockLost)
+	assert.Len(t, heartbeats, 2)
+}
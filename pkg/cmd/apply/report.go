@@ -0,0 +1,257 @@
+package apply
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/notification"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/util/junit"
+)
+
+// Results of resources in the apply report
+const (
+	ResultSuccess    = "success"
+	ResultFailed     = "failed"
+	ResultSkipped    = "skipped"
+	ResultNotApplied = "not applied"
+)
+
+// Report is the report of an apply written by --report, which can be attached to change tickets and
+// CI artifacts
+type Report struct {
+	Project   string               `json:"project"`
+	Stack     string               `json:"stack"`
+	Operator  string               `json:"operator,omitempty"`
+	DryRun    bool                 `json:"dryRun,omitempty"`
+	StartTime time.Time            `json:"startTime"`
+	EndTime   time.Time            `json:"endTime"`
+	Duration  string               `json:"duration"`
+	Succeeded bool                 `json:"succeeded"`
+	Error     string               `json:"error,omitempty"`
+	Summary   notification.Summary `json:"summary"`
+	Resources []ResourceReport     `json:"resources"`
+	Warnings  []string             `json:"warnings,omitempty"`
+	Audits    []states.AuditRecord `json:"audits,omitempty"`
+}
+
+// ResourceReport is the change of a resource in the apply report. Sensitive values in diffs are masked.
+type ResourceReport struct {
+	ID     string `json:"id"`
+	Action string `json:"action"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+	Diff   string `json:"diff,omitempty"`
+
+	// Duration and RuntimeLatency are how long the resource took and how long requests to its runtime took
+	Duration       string `json:"duration,omitempty"`
+	RuntimeLatency string `json:"runtimeLatency,omitempty"`
+	Attempts       int    `json:"attempts,omitempty"`
+}
+
+// newReport builds the report of the changes applied since start, where err is the error of the apply
+func (o *ApplyOptions) newReport(
+	project *projectstack.Project,
+	stack *projectstack.Stack,
+	changes *opsmodels.Changes,
+	start time.Time,
+	err error,
+) *Report {
+	end := time.Now()
+	operator := o.Operator
+	if operator == "" {
+		if u, e := user.Current(); e == nil {
+			operator = u.Username
+		}
+	}
+	report := &Report{
+		Project:   project.Name,
+		Stack:     stack.Name,
+		Operator:  operator,
+		DryRun:    o.DryRun,
+		StartTime: start,
+		EndTime:   end,
+		Duration:  end.Sub(start).Round(time.Millisecond).String(),
+		Succeeded: err == nil,
+		Summary:   notification.NewSummary(changes),
+		Warnings:  o.warnings,
+		Audits:    o.audits,
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	for _, step := range changes.Values() {
+		r := ResourceReport{ID: step.ID, Action: step.Action.String(), Result: ResultNotApplied}
+		if msg, ok := o.results[step.ID]; ok {
+			switch msg.OpResult {
+			case opsmodels.Success:
+				r.Result = ResultSuccess
+			case opsmodels.Skip:
+				r.Result = ResultSkipped
+			case opsmodels.Failed:
+				r.Result = ResultFailed
+				if msg.OpErr != nil {
+					r.Error = msg.OpErr.Error()
+				}
+			}
+			if t := msg.Timing; t != nil {
+				r.Duration = t.Duration().Round(time.Millisecond).String()
+				r.RuntimeLatency = t.RuntimeLatency.Round(time.Millisecond).String()
+				r.Attempts = t.Attempts
+			}
+		}
+		if step.Action != opsmodels.UnChange {
+			if diff, e := step.Diff(); e == nil {
+				r.Diff = pterm.RemoveColorFromString(diff)
+			}
+		}
+		report.Resources = append(report.Resources, r)
+	}
+	return report
+}
+
+// JUnit returns the report as a JUnit XML report, where each resource is a test case that fails if the resource
+// failed to apply, and is skipped if it was skipped or not applied
+func (r *Report) JUnit() *junit.TestSuites {
+	className := r.Project + "/" + r.Stack
+	suite := junit.TestSuite{
+		Name:      "kusion apply " + className,
+		Timestamp: r.StartTime.Format(time.RFC3339),
+	}
+	if d, err := time.ParseDuration(r.Duration); err == nil {
+		suite.Time = junit.Seconds(d)
+	}
+
+	for _, res := range r.Resources {
+		c := junit.TestCase{Name: res.ID, ClassName: className, SystemOut: "action: " + res.Action}
+		if d, err := time.ParseDuration(res.Duration); err == nil {
+			c.Time = junit.Seconds(d)
+		}
+		if res.Diff != "" {
+			c.SystemOut += "\n" + res.Diff
+		}
+		switch res.Result {
+		case ResultFailed:
+			c.Failure = &junit.Failure{Message: res.Error, Type: "apply", Contents: res.Error}
+			if res.Diff != "" {
+				c.Failure.Contents += "\n\n" + res.Diff
+			}
+		case ResultSkipped, ResultNotApplied:
+			c.Skipped = &junit.Skipped{Message: res.Result}
+		}
+		suite.Add(c)
+	}
+
+	// errors not of any resource, e.g. the apply is aborted, fail an extra test case of the apply
+	if r.Error != "" && suite.Failures == 0 {
+		suite.Add(junit.TestCase{
+			Name:      "apply",
+			ClassName: className,
+			Failure:   &junit.Failure{Message: r.Error, Type: "error", Contents: r.Error},
+		})
+	}
+
+	report := &junit.TestSuites{Name: "kusion", Time: suite.Time}
+	report.Add(suite)
+	return report
+}
+
+// WriteReport writes the report to the file, which is in HTML if its extension is .html or .htm, and in JSON
+// otherwise
+func WriteReport(report *Report, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("write report failed: %w", err)
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		err = reportTemplate.Execute(f, report)
+	default:
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	}
+	if err != nil {
+		return fmt.Errorf("write report failed: %w", err)
+	}
+	return nil
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"timestamp": func(t time.Time) string { return t.Format(time.RFC3339) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Kusion apply report of {{.Project}}/{{.Stack}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292f; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #d0d7de; padding: 6px 12px; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+pre { background: #f6f8fa; padding: 8px; margin: 0; white-space: pre-wrap; }
+.success { color: #1a7f37; }
+.failed { color: #cf222e; }
+.skipped, .not-applied { color: #6e7781; }
+</style>
+</head>
+<body>
+<h1>Kusion apply report</h1>
+<table>
+<tr><th>Project</th><td>{{.Project}}</td></tr>
+<tr><th>Stack</th><td>{{.Stack}}</td></tr>
+<tr><th>Operator</th><td>{{.Operator}}</td></tr>
+<tr><th>Start time</th><td>{{timestamp .StartTime}}</td></tr>
+<tr><th>Duration</th><td>{{.Duration}}</td></tr>
+<tr><th>Result</th><td>{{if .Succeeded}}<span class="success">succeeded</span>{{else}}<span class="failed">failed</span>{{end}}{{if .DryRun}} (dry run){{end}}</td></tr>
+{{- if .Error}}
+<tr><th>Error</th><td><pre>{{.Error}}</pre></td></tr>
+{{- end}}
+<tr><th>Summary</th><td>{{.Summary.Create}} to create, {{.Summary.Update}} to update, {{.Summary.Delete}} to delete, {{.Summary.UnChange}} unchanged</td></tr>
+</table>
+{{- if .Warnings}}
+<h2>Warnings</h2>
+<ul>
+{{- range .Warnings}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Audits}}
+<h2>Audits</h2>
+<table>
+<tr><th>Action</th><th>Operator</th><th>Reason</th><th>Message</th></tr>
+{{- range .Audits}}
+<tr><td>{{.Action}}</td><td>{{.Operator}}</td><td>{{.Reason}}</td><td>{{.Message}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<h2>Resources</h2>
+<table>
+<tr><th>ID</th><th>Action</th><th>Result</th><th>Duration</th><th>Diff</th></tr>
+{{- range .Resources}}
+<tr>
+<td>{{.ID}}</td>
+<td>{{.Action}}</td>
+<td class="{{if eq .Result "not applied"}}not-applied{{else}}{{.Result}}{{end}}">{{.Result}}{{if .Error}}<pre>{{.Error}}</pre>{{end}}</td>
+<td>{{.Duration}}{{if gt .Attempts 1}} ({{.Attempts}} attempts){{end}}</td>
+<td>{{if .Diff}}<pre>{{.Diff}}</pre>{{end}}</td>
+</tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
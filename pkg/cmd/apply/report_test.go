@@ -0,0 +1,100 @@
+package apply
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/states/local"
+)
+
+func TestWriteReport(t *testing.T) {
+	defer monkey.UnpatchAll()
+	mockOperationApply(opsmodels.Failed)
+
+	o := NewApplyOptions()
+	o.Operator = "alice"
+	o.warnings = []string{"insufficient memory"}
+	planResources := &models.Spec{Resources: []models.Resource{sa1}}
+	changes := opsmodels.NewChanges(project, stack, &opsmodels.ChangeOrder{
+		StepKeys: []string{sa1.ID, sa2.ID},
+		ChangeSteps: map[string]*opsmodels.ChangeStep{
+			sa1.ID: {ID: sa1.ID, Action: opsmodels.Create, To: &sa1},
+			sa2.ID: {ID: sa2.ID, Action: opsmodels.UnChange, From: &sa2, To: &sa2},
+		},
+	})
+	start := time.Now()
+	err := Apply(o, &local.FileSystemState{Path: filepath.Join(t.TempDir(), local.KusionState)}, planResources, changes, os.Stdout)
+	assert.NotNil(t, err)
+
+	report := o.newReport(project, stack, changes, start, err)
+	assert.False(t, report.Succeeded)
+	assert.Equal(t, "alice", report.Operator)
+	assert.Equal(t, 1, report.Summary.Create)
+	assert.Equal(t, []string{"insufficient memory"}, report.Warnings)
+	assert.Len(t, report.Resources, 2)
+	assert.Equal(t, ResultFailed, report.Resources[0].Result)
+	assert.Equal(t, "mock error", report.Resources[0].Error)
+	assert.Contains(t, report.Resources[0].Diff, sa1.ID)
+	assert.NotContains(t, report.Resources[0].Diff, "\x1b[")
+	assert.Equal(t, ResultNotApplied, report.Resources[1].Result)
+	assert.Empty(t, report.Resources[1].Diff)
+
+	dir := t.TempDir()
+	jsonFile := filepath.Join(dir, "report.json")
+	assert.Nil(t, WriteReport(report, jsonFile))
+	data, _ := os.ReadFile(jsonFile)
+	decoded := &Report{}
+	assert.Nil(t, json.Unmarshal(data, decoded))
+	assert.Equal(t, report.Resources, decoded.Resources)
+
+	htmlFile := filepath.Join(dir, "report.html")
+	assert.Nil(t, WriteReport(report, htmlFile))
+	data, _ = os.ReadFile(htmlFile)
+	assert.Contains(t, string(data), "<title>Kusion apply report of")
+	assert.Contains(t, string(data), `<td class="failed">failed<pre>mock error</pre></td>`)
+
+	assert.NotNil(t, WriteReport(report, filepath.Join(dir, "missing", "report.json")))
+}
+
+func TestReport_JUnit(t *testing.T) {
+	report := &Report{
+		Project:   "helloworld",
+		Stack:     "dev",
+		StartTime: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		Duration:  "3s",
+		Error:     "mock error",
+		Resources: []ResourceReport{
+			{ID: sa1.ID, Action: "Create", Result: ResultFailed, Error: "mock error", Diff: "+ sa1", Duration: "1.5s"},
+			{ID: sa2.ID, Action: "Update", Result: ResultSuccess, Diff: "~ sa2"},
+			{ID: sa3.ID, Action: "Delete", Result: ResultNotApplied},
+		},
+	}
+	suites := report.JUnit()
+	assert.Equal(t, 3, suites.Tests)
+	assert.Equal(t, 1, suites.Failures)
+	assert.Equal(t, 1, suites.Skipped)
+	suite := suites.Suites[0]
+	assert.Equal(t, "kusion apply helloworld/dev", suite.Name)
+	assert.Equal(t, "3.000", suite.Time)
+	assert.Equal(t, "2022-01-01T00:00:00Z", suite.Timestamp)
+	assert.Equal(t, "1.500", suite.Cases[0].Time)
+	assert.Equal(t, "mock error\n\n+ sa1", suite.Cases[0].Failure.Contents)
+	assert.Equal(t, "action: Update\n~ sa2", suite.Cases[1].SystemOut)
+	assert.Nil(t, suite.Cases[1].Failure)
+	assert.Equal(t, ResultNotApplied, suite.Cases[2].Skipped.Message)
+
+	// errors not of any resource fail an extra test case
+	report.Resources = report.Resources[1:]
+	suite = report.JUnit().Suites[0]
+	assert.Len(t, suite.Cases, 3)
+	assert.Equal(t, "apply", suite.Cases[2].Name)
+	assert.Equal(t, "mock error", suite.Cases[2].Failure.Message)
+}
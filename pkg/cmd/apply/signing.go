@@ -0,0 +1,67 @@
+package apply
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pterm/pterm"
+
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/signing"
+)
+
+// loadSpec compiles the stack, or loads the spec file if it is specified. The signature of the spec file
+// is verified with the flags, or the verification config of the stack. Stacks with the verification config
+// can only be applied from signed spec files.
+func (o *ApplyOptions) loadSpec(project *projectstack.Project, stack *projectstack.Stack) (*models.Spec, error) {
+	verify := o.verifyOptions(stack)
+	if o.SpecFile == "" {
+		if !verify.IsEmpty() {
+			return nil, fmt.Errorf("the stack %s can only be applied from a signed spec file, "+
+				"apply the spec file compiled by kusion compile --sign", stack.Name)
+		}
+		return spec.GenerateSpecWithSpinner(o.GeneratorOptions(), project, stack)
+	}
+
+	// The file is read once, so that the spec applied is exactly the one verified
+	data, err := os.ReadFile(o.SpecFile)
+	if err != nil {
+		return nil, err
+	}
+	if verify.IsEmpty() {
+		warning := fmt.Sprintf("The signature of %s is not verified", o.SpecFile)
+		pterm.Warning.Println(warning)
+		o.warnings = append(o.warnings, warning)
+	} else {
+		if err = signing.Verify(o.SpecFile, data, verify); err != nil {
+			return nil, err
+		}
+		pterm.Success.Printfln("Verified the signature of %s", o.SpecFile)
+	}
+	return spec.ParseSpecFile(o.SpecFile, data)
+}
+
+// verifyOptions returns options of the flags if specified, otherwise the verification config of the stack
+// whose key is relative to the stack directory
+func (o *ApplyOptions) verifyOptions(stack *projectstack.Stack) *signing.VerifyOptions {
+	if !o.Verify.IsEmpty() {
+		return &o.Verify
+	}
+	if stack.Verification.IsEmpty() {
+		return nil
+	}
+	verify := *stack.Verification
+	if verify.Key != "" && !filepath.IsAbs(verify.Key) && !isKMSURI(verify.Key) {
+		verify.Key = filepath.Join(stack.GetPath(), verify.Key)
+	}
+	return &verify
+}
+
+// isKMSURI returns true if the key is a URI of KMS keys, e.g. awskms://, gcpkms:// and hashivault://
+func isKMSURI(key string) bool {
+	return strings.Contains(key, "://")
+}
@@ -0,0 +1,100 @@
+package apply
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/signing"
+)
+
+func TestApplyOptions_Complete(t *testing.T) {
+	o := NewApplyOptions()
+	o.Complete([]string{"spec.yaml"})
+	assert.Equal(t, "spec.yaml", o.SpecFile)
+	assert.Empty(t, o.Filenames)
+
+	o = NewApplyOptions()
+	o.Complete([]string{"main.k"})
+	assert.Empty(t, o.SpecFile)
+	assert.Equal(t, []string{"main.k"}, o.Filenames)
+}
+
+func TestApplyOptions_loadSpec(t *testing.T) {
+	defer monkey.UnpatchAll()
+	dir := t.TempDir()
+	specFile := filepath.Join(dir, "spec.yaml")
+	assert.Nil(t, os.WriteFile(specFile, []byte("- id: a\n  type: Kubernetes\n  attributes: {}\n"), 0o644))
+	s := &projectstack.Stack{Path: dir}
+
+	var verified *signing.VerifyOptions
+	monkey.Patch(signing.Verify, func(file string, data []byte, o *signing.VerifyOptions) error {
+		assert.Equal(t, specFile, file)
+		assert.Contains(t, string(data), "id: a")
+		verified = o
+		if o.Key == "bad.pub" {
+			return errors.New("invalid signature")
+		}
+		return nil
+	})
+
+	t.Run("unsigned spec file", func(t *testing.T) {
+		verified = nil
+		o := NewApplyOptions()
+		o.SpecFile = specFile
+		sp, err := o.loadSpec(project, s)
+		assert.Nil(t, err)
+		assert.Equal(t, "a", sp.Resources[0].ID)
+		assert.Nil(t, verified)
+	})
+
+	t.Run("verified by flags", func(t *testing.T) {
+		o := NewApplyOptions()
+		o.SpecFile = specFile
+		o.Verify.Key = "cosign.pub"
+		_, err := o.loadSpec(project, s)
+		assert.Nil(t, err)
+		assert.Equal(t, &signing.VerifyOptions{Key: "cosign.pub"}, verified)
+
+		o.Verify.Key = "bad.pub"
+		_, err = o.loadSpec(project, s)
+		assert.EqualError(t, err, "invalid signature")
+	})
+
+	t.Run("verified by stack", func(t *testing.T) {
+		signed := &projectstack.Stack{
+			StackConfiguration: projectstack.StackConfiguration{
+				Name:         "prod",
+				Verification: &signing.VerifyOptions{Key: "cosign.pub"},
+			},
+			Path: dir,
+		}
+		o := NewApplyOptions()
+		o.SpecFile = specFile
+		_, err := o.loadSpec(project, signed)
+		assert.Nil(t, err)
+		assert.Equal(t, &signing.VerifyOptions{Key: filepath.Join(dir, "cosign.pub")}, verified)
+
+		signed.Verification.Key = "awskms:///alias/kusion"
+		_, err = o.loadSpec(project, signed)
+		assert.Nil(t, err)
+		assert.Equal(t, "awskms:///alias/kusion", verified.Key)
+
+		_, err = NewApplyOptions().loadSpec(project, signed)
+		assert.ErrorContains(t, err, "the stack prod can only be applied from a signed spec file")
+	})
+}
+
+func TestApplyOptions_validateVerify(t *testing.T) {
+	o := NewApplyOptions()
+	o.Verify.Key = "cosign.pub"
+	assert.ErrorContains(t, o.Validate(), "signatures can only be verified when applying a spec file")
+
+	o.SpecFile = "spec.yaml"
+	assert.Nil(t, o.Validate())
+}
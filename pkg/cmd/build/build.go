@@ -0,0 +1,64 @@
+package build
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	buildShort = "Build the stack into Kubernetes manifests for Argo CD"
+
+	buildLong = `
+		Compile the stack and print attributes of its Kubernetes resources to stdout as plain multi-document
+		YAML, which is the output expected by Argo CD config management plugins (CMP), so that Kusion stacks
+		can be synced by Argo CD without custom wrappers. Nothing else is printed to stdout, and resources of
+		other runtimes are skipped with warnings in stderr.
+
+		Parameters of the plugin in Argo CD Applications are read from ARGOCD_APP_PARAMETERS:
+		  stack      the stack directory relative to the source path, string
+		  arguments  top-level arguments, map
+		  sets       values set by paths and merged into top-level arguments, map
+		  settings   setting files, array
+
+		The stack directory can also be specified by the plugin env KUSION_STACK, i.e. ARGOCD_ENV_KUSION_STACK.`
+
+	buildExample = `
+		# Build the stack in the current directory
+		kusion build
+
+		# Build the stack with parameters as Argo CD does
+		ARGOCD_APP_PARAMETERS='[{"name":"stack","string":"dev"},{"name":"arguments","map":{"image":"nginx:1.23"}}]' kusion build
+
+		# The generate command of the plugin.yaml of the Argo CD config management plugin
+		command: [kusion, build]`
+)
+
+func NewCmdBuild() *cobra.Command {
+	o := NewBuildOptions()
+
+	cmd := &cobra.Command{
+		Use:         "build",
+		Short:       i18n.T(buildShort),
+		Long:        templates.LongDesc(i18n.T(buildLong)),
+		Example:     templates.Examples(i18n.T(buildExample)),
+		Annotations: map[string]string{util.PlainOutputAnnotation: "true"},
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			defer util.RecoverErr(&err)
+			util.CheckErr(o.Complete(args))
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+			return
+		},
+	}
+
+	o.AddCompileFlags(cmd)
+	cmd.Flags().BoolVarP(&o.DisableNone, "disable-none", "n", false,
+		i18n.T("Disable dumping None values"))
+	cmd.Flags().BoolVarP(&o.OverrideAST, "override-AST", "a", false,
+		i18n.T("Specify the override option"))
+
+	return cmd
+}
@@ -0,0 +1,152 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	compilecmd "kusionstack.io/kusion/pkg/cmd/compile"
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// Environment variables set by Argo CD for config management plugins
+const (
+	// EnvAppParameters is the JSON list of parameters of the plugin in the Application
+	EnvAppParameters = "ARGOCD_APP_PARAMETERS"
+	// EnvStack is the stack directory relative to the source path, set by the env of the plugin in the Application
+	EnvStack = "ARGOCD_ENV_KUSION_STACK"
+)
+
+// Parameters of the plugin in Argo CD Applications
+const (
+	// stackParameter is the stack directory relative to the source path
+	stackParameter = "stack"
+	// argumentsParameter is a map of top-level arguments
+	argumentsParameter = "arguments"
+	// setsParameter is a map of values set by paths
+	setsParameter = "sets"
+	// settingsParameter is an array of setting files
+	settingsParameter = "settings"
+)
+
+type BuildOptions struct {
+	compilecmd.CompileOptions
+
+	// Out is where the manifests are written
+	Out io.Writer
+	// Getenv reads environment variables, which is os.Getenv except in tests
+	Getenv func(string) string
+}
+
+func NewBuildOptions() *BuildOptions {
+	return &BuildOptions{
+		CompileOptions: *compilecmd.NewCompileOptions(),
+		Out:            os.Stdout,
+		Getenv:         os.Getenv,
+	}
+}
+
+// appParameter is a parameter of the plugin in ARGOCD_APP_PARAMETERS
+type appParameter struct {
+	Name   string            `json:"name"`
+	String *string           `json:"string,omitempty"`
+	Array  []string          `json:"array,omitempty"`
+	Map    map[string]string `json:"map,omitempty"`
+}
+
+// Complete reads parameters of the Argo CD Application from the environment, which are appended to flags
+func (o *BuildOptions) Complete(args []string) error {
+	if stack := o.Getenv(EnvStack); stack != "" && o.WorkDir == "" {
+		o.WorkDir = stack
+	}
+	if data := o.Getenv(EnvAppParameters); data != "" {
+		var params []appParameter
+		if err := json.Unmarshal([]byte(data), &params); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvAppParameters, err)
+		}
+		for _, p := range params {
+			switch p.Name {
+			case stackParameter:
+				if p.String != nil && *p.String != "" {
+					o.WorkDir = *p.String
+				}
+			case argumentsParameter:
+				o.Arguments = append(o.Arguments, keyValues(p.Map)...)
+			case setsParameter:
+				o.Sets = append(o.Sets, keyValues(p.Map)...)
+			case settingsParameter:
+				o.Settings = append(o.Settings, p.Array...)
+			}
+		}
+	}
+	if o.WorkDir != "" && !filepath.IsAbs(o.WorkDir) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		o.WorkDir = filepath.Join(cwd, o.WorkDir)
+	}
+	o.CompileOptions.Complete(args)
+	return nil
+}
+
+// keyValues returns key=value pairs of the map sorted by keys
+func keyValues(m map[string]string) []string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+func (o *BuildOptions) Validate() error {
+	return o.CompileOptions.Validate()
+}
+
+func (o *BuildOptions) Run() error {
+	project, stack, err := projectstack.DetectProjectAndStack(o.WorkDir)
+	if err != nil {
+		return err
+	}
+	sp, err := spec.GenerateSpec(o.GeneratorOptions(), project, stack)
+	if err != nil {
+		return err
+	}
+	return WriteManifests(o.Out, sp.Resources)
+}
+
+// WriteManifests writes attributes of Kubernetes resources as a multi-document YAML. Resources of other
+// runtimes can't be synced by Argo CD, and are skipped with warnings in stderr.
+func WriteManifests(w io.Writer, resources models.Resources) error {
+	first := true
+	for i := range resources {
+		r := &resources[i]
+		if r.Type != runtime.Kubernetes {
+			fmt.Fprintf(os.Stderr, "skip the resource %s of the %s runtime\n", r.ID, r.Type)
+			continue
+		}
+		data, err := yamlv3.Marshal(r.Attributes)
+		if err != nil {
+			return err
+		}
+		if !first {
+			if _, err = io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		first = false
+		if _, err = w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
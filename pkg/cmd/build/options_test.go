@@ -0,0 +1,101 @@
+package build
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func TestBuildOptions_Complete(t *testing.T) {
+	env := map[string]string{
+		EnvStack: "prod",
+		EnvAppParameters: `[
+			{"name": "stack", "string": "dev"},
+			{"name": "arguments", "map": {"image": "nginx:1.23", "app": "web"}},
+			{"name": "sets", "map": {"app.replicas": "2"}},
+			{"name": "settings", "array": ["settings.yaml"]},
+			{"name": "unknown", "string": "ignored"}
+		]`,
+	}
+	o := NewBuildOptions()
+	o.Getenv = func(key string) string { return env[key] }
+	assert.Nil(t, o.Complete(nil))
+
+	cwd, _ := os.Getwd()
+	assert.Equal(t, filepath.Join(cwd, "dev"), o.WorkDir)
+	assert.Equal(t, []string{"app=web", "image=nginx:1.23"}, o.Arguments)
+	assert.Equal(t, []string{"app.replicas=2"}, o.Sets)
+	assert.Equal(t, []string{"settings.yaml"}, o.Settings)
+
+	// the stack is specified by the plugin env without parameters
+	delete(env, EnvAppParameters)
+	o = NewBuildOptions()
+	o.Getenv = func(key string) string { return env[key] }
+	assert.Nil(t, o.Complete(nil))
+	assert.Equal(t, filepath.Join(cwd, "prod"), o.WorkDir)
+
+	env[EnvAppParameters] = "{"
+	o = NewBuildOptions()
+	o.Getenv = func(key string) string { return env[key] }
+	assert.ErrorContains(t, o.Complete(nil), "invalid ARGOCD_APP_PARAMETERS")
+}
+
+func TestBuildOptions_Run(t *testing.T) {
+	defer monkey.UnpatchAll()
+	monkey.Patch(projectstack.DetectProjectAndStack, func(stackDir string) (*projectstack.Project, *projectstack.Stack, error) {
+		return &projectstack.Project{}, &projectstack.Stack{}, nil
+	})
+	monkey.Patch(spec.GenerateSpec, func(o *generator.Options, project *projectstack.Project, stack *projectstack.Stack) (*models.Spec, error) {
+		return &models.Spec{Resources: models.Resources{
+			{
+				ID:   "v1:Namespace:web",
+				Type: runtime.Kubernetes,
+				Attributes: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "Namespace",
+					"metadata":   map[string]interface{}{"name": "web"},
+				},
+			},
+			{
+				ID:         "hashicorp:local:local_file:example",
+				Type:       runtime.Terraform,
+				Attributes: map[string]interface{}{"filename": "test.txt"},
+			},
+			{
+				ID:   "v1:ConfigMap:web:config",
+				Type: runtime.Kubernetes,
+				Attributes: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata":   map[string]interface{}{"name": "config", "namespace": "web"},
+				},
+			},
+		}}, nil
+	})
+
+	out := &bytes.Buffer{}
+	o := NewBuildOptions()
+	o.Out = out
+	assert.Nil(t, o.Run())
+	assert.Equal(t, `apiVersion: v1
+kind: Namespace
+metadata:
+    name: web
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+    name: config
+    namespace: web
+`, out.String())
+}
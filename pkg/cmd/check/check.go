@@ -4,7 +4,6 @@ import (
 	"github.com/spf13/cobra"
 	"k8s.io/kubectl/pkg/util/templates"
 
-	"kusionstack.io/kusion/pkg/cmd/compile"
 	"kusionstack.io/kusion/pkg/cmd/util"
 	"kusionstack.io/kusion/pkg/util/i18n"
 )
@@ -13,7 +12,10 @@ var (
 	checkShort = "Check if KCL configurations in current directory ok to compile"
 
 	checkLong = `
-		Check if KCL configurations in current directory ok to compile.`
+		Check if KCL configurations in current directory ok to compile.
+
+		With --cluster, also check if the target cluster serves the API group, version and kind
+		of every resource in the compiled spec, so that gaps are reported before applying.`
 
 	checkExample = `
 		# Check configuration in main.k
@@ -26,12 +28,17 @@ var (
 		kusion check main.k -Y settings.yaml
 
 		# Check main.k with work directory
-		kusion check main.k -w appops/demo/dev`
+		kusion check main.k -w appops/demo/dev
+
+		# Check if the target cluster serves APIs of all resources in the stack
+		kusion check --cluster
+
+		# Check APIs in CI and write missing ones as a SARIF log for code scanning
+		kusion check --cluster --sarif kusion.sarif`
 )
 
 func NewCmdCheck() *cobra.Command {
-	o := compile.NewCompileOptions()
-	o.IsCheck = true
+	o := NewCheckOptions()
 
 	cmd := &cobra.Command{
 		Use:     "check",
@@ -54,6 +61,10 @@ func NewCmdCheck() *cobra.Command {
 		i18n.T("Disable dumping None values"))
 	cmd.Flags().BoolVarP(&o.OverrideAST, "override-AST", "a", false,
 		i18n.T("Specify the override option"))
+	cmd.Flags().BoolVarP(&o.Cluster, "cluster", "", false,
+		i18n.T("Check if the target cluster serves APIs of all resources in the compiled spec"))
+	cmd.Flags().StringVarP(&o.SARIF, "sarif", "", "",
+		i18n.T("Specify the file to write resources requiring APIs not served by the cluster to as a SARIF log, used with --cluster"))
 
 	return cmd
 }
@@ -0,0 +1,95 @@
+package check
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pterm/pterm"
+
+	"kusionstack.io/kusion/pkg/cmd/compile"
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/util/pretty"
+	"kusionstack.io/kusion/pkg/util/sarif"
+	"kusionstack.io/kusion/pkg/version"
+)
+
+type CheckOptions struct {
+	compile.CompileOptions
+	Cluster bool
+
+	// SARIF is the file to write resources requiring APIs not served by the cluster to as a SARIF log
+	SARIF string
+}
+
+// RuleAPINotServed is the rule of resources requiring APIs not served by the cluster in SARIF logs
+const RuleAPINotServed = "api-not-served"
+
+func NewCheckOptions() *CheckOptions {
+	o := &CheckOptions{CompileOptions: *compile.NewCompileOptions()}
+	o.IsCheck = true
+	return o
+}
+
+func (o *CheckOptions) Validate() error {
+	if o.SARIF != "" && !o.Cluster {
+		return fmt.Errorf("--sarif can only be used with --cluster")
+	}
+	return o.CompileOptions.Validate()
+}
+
+func (o *CheckOptions) Run() error {
+	if !o.Cluster {
+		return o.CompileOptions.Run()
+	}
+
+	// Parse project and stack of work directory
+	project, stack, err := projectstack.DetectProjectAndStack(o.WorkDir)
+	if err != nil {
+		return err
+	}
+
+	sp, err := spec.GenerateSpecWithSpinner(o.GeneratorOptions(), project, stack)
+	if err != nil {
+		return err
+	}
+	if sp == nil || len(sp.Resources) == 0 {
+		fmt.Println(pretty.GreenBold("\nNo resource found in this stack."))
+		return nil
+	}
+
+	// the cluster is the one pinned by the stack if any
+	env, err := runtime.StackEnv(context.Background(), project, stack)
+	if err != nil {
+		return err
+	}
+	rt, err := kubernetes.NewKubernetesRuntime(env)
+	if err != nil {
+		return err
+	}
+	missing, err := rt.(*kubernetes.KubernetesRuntime).CheckAPIs(sp.Resources)
+	if err != nil {
+		return err
+	}
+	if o.SARIF != "" {
+		var results []sarif.Result
+		file := sarif.EntryFile(stack.Path, o.Filenames)
+		for _, m := range missing {
+			results = append(results, sarif.NewResult(RuleAPINotServed, sarif.LevelError, m.String(), file, m.ResourceID))
+		}
+		if err = sarif.Write(sarif.NewLog(version.ReleaseVersion(), results), o.SARIF); err != nil {
+			return err
+		}
+	}
+	if len(missing) == 0 {
+		pterm.Success.Println("The cluster serves APIs of all resources in this stack")
+		return nil
+	}
+
+	for _, m := range missing {
+		pterm.Error.Println(m.String())
+	}
+	return fmt.Errorf("preflight check failed, %d resource(s) require APIs not served by the cluster", len(missing))
+}
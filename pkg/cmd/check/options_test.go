@@ -0,0 +1,74 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func TestCheckOptions_Run(t *testing.T) {
+	tests := []struct {
+		name    string
+		missing []kubernetes.MissingAPI
+		wantErr bool
+	}{
+		{
+			name:    "all APIs served",
+			missing: nil,
+			wantErr: false,
+		},
+		{
+			name:    "missing APIs",
+			missing: []kubernetes.MissingAPI{{ResourceID: "foo", APIVersion: "example.com/v1", Kind: "Foo"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer monkey.UnpatchAll()
+			monkey.Patch(projectstack.DetectProjectAndStack, func(stackDir string) (*projectstack.Project, *projectstack.Stack, error) {
+				return &projectstack.Project{}, &projectstack.Stack{}, nil
+			})
+			monkey.Patch(spec.GenerateSpecWithSpinner, func(o *generator.Options, project *projectstack.Project, stack *projectstack.Stack) (*models.Spec, error) {
+				return &models.Spec{Resources: []models.Resource{{ID: "foo", Type: runtime.Kubernetes}}}, nil
+			})
+			monkey.Patch(kubernetes.NewKubernetesRuntime, func(runtime.Env) (runtime.Runtime, error) {
+				return &kubernetes.KubernetesRuntime{}, nil
+			})
+			monkey.PatchInstanceMethod(reflect.TypeOf(&kubernetes.KubernetesRuntime{}), "CheckAPIs",
+				func(k *kubernetes.KubernetesRuntime, resources models.Resources) ([]kubernetes.MissingAPI, error) {
+					return tt.missing, nil
+				})
+
+			o := NewCheckOptions()
+			o.Cluster = true
+			o.SARIF = filepath.Join(t.TempDir(), "kusion.sarif")
+			err := o.Run()
+			assert.Equal(t, tt.wantErr, err != nil)
+
+			data, err := os.ReadFile(o.SARIF)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantErr, strings.Contains(string(data), RuleAPINotServed))
+		})
+	}
+}
+
+func TestCheckOptions_Validate(t *testing.T) {
+	o := NewCheckOptions()
+	o.SARIF = "kusion.sarif"
+	assert.EqualError(t, o.Validate(), "--sarif can only be used with --cluster")
+	o.Cluster = true
+	assert.Nil(t, o.Validate())
+}
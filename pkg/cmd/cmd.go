@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -8,6 +9,7 @@ import (
 	"os/user"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
 	"time"
 
@@ -18,16 +20,35 @@ import (
 	"k8s.io/kubectl/pkg/util/templates"
 
 	"kusionstack.io/kusion/pkg/cmd/apply"
+	"kusionstack.io/kusion/pkg/cmd/build"
 	"kusionstack.io/kusion/pkg/cmd/check"
 	"kusionstack.io/kusion/pkg/cmd/compile"
+	"kusionstack.io/kusion/pkg/cmd/config"
 	"kusionstack.io/kusion/pkg/cmd/deps"
 	"kusionstack.io/kusion/pkg/cmd/destroy"
 	"kusionstack.io/kusion/pkg/cmd/env"
 	cmdinit "kusionstack.io/kusion/pkg/cmd/init"
+	"kusionstack.io/kusion/pkg/cmd/lint"
 	"kusionstack.io/kusion/pkg/cmd/ls"
+	"kusionstack.io/kusion/pkg/cmd/operator"
+	"kusionstack.io/kusion/pkg/cmd/output"
+	"kusionstack.io/kusion/pkg/cmd/plugin"
 	"kusionstack.io/kusion/pkg/cmd/preview"
+	"kusionstack.io/kusion/pkg/cmd/promote"
+	"kusionstack.io/kusion/pkg/cmd/providers"
+	"kusionstack.io/kusion/pkg/cmd/push"
+	"kusionstack.io/kusion/pkg/cmd/server"
+	"kusionstack.io/kusion/pkg/cmd/state"
+	"kusionstack.io/kusion/pkg/cmd/sync"
+	cmdtelemetry "kusionstack.io/kusion/pkg/cmd/telemetry"
+	"kusionstack.io/kusion/pkg/cmd/upgrade"
+	"kusionstack.io/kusion/pkg/cmd/util"
 	"kusionstack.io/kusion/pkg/cmd/version"
+	"kusionstack.io/kusion/pkg/engine/metrics"
+	"kusionstack.io/kusion/pkg/engine/tracing"
 	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/release"
+	"kusionstack.io/kusion/pkg/telemetry"
 	"kusionstack.io/kusion/pkg/util/gitutil"
 	"kusionstack.io/kusion/pkg/util/i18n"
 	"kusionstack.io/kusion/pkg/util/kfile"
@@ -35,6 +56,17 @@ import (
 	versionInfo "kusionstack.io/kusion/pkg/version"
 )
 
+// Addresses of the metrics endpoint, the Pushgateway and the OTLP endpoint, which are set by the flags
+// --metrics-addr, --metrics-pushgateway and --otlp-endpoint of the root command
+var (
+	metricsAddr        string
+	metricsPushGateway string
+	otlpEndpoint       string
+
+	// shutdownTracing flushes spans and shuts down the exporter
+	shutdownTracing = func(context.Context) error { return nil }
+)
+
 // NewDefaultKusionctlCommand creates the `kusionctl` command with default arguments
 func NewDefaultKusionctlCommand() *cobra.Command {
 	return NewDefaultKusionctlCommandWithArgs(os.Args, os.Stdin, os.Stdout, os.Stderr)
@@ -51,9 +83,29 @@ func NewDefaultKusionctlCommandWithArgs(args []string, in io.Reader, out, errOut
 		// sub command exist
 		return kusionctl
 	}
+	addPluginCommand(kusionctl, cmdPathPieces, &plugin.DefaultHandler{})
 	return kusionctl
 }
 
+// addPluginCommand adds the command running the plugin matching the arguments if there is one, so that
+// kusion foo runs the executable kusion-foo on $PATH
+func addPluginCommand(kusionctl *cobra.Command, args []string, handler plugin.Handler) {
+	path, pieces, ok := plugin.Find(handler, args)
+	if !ok {
+		return
+	}
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			// plugins never shadow cobra's help and completion commands either
+			if arg == "help" || arg == cobra.ShellCompRequestCmd || arg == cobra.ShellCompNoDescRequestCmd {
+				return
+			}
+			kusionctl.AddCommand(plugin.NewCmdPluginRun(args[i], path, pieces, handler))
+			return
+		}
+	}
+}
+
 var (
 	rootShort = "kusion manages the Kubernetes cluster by code"
 	rootLong  = "kusion is a cloud-native programmable technology stack, which manages the Kubernetes cluster by code."
@@ -76,6 +128,9 @@ func NewKusionctlCmd(in io.Reader, out, err io.Writer) *cobra.Command {
 		Long:          templates.LongDesc(i18n.T(rootLong)),
 		SilenceErrors: true,
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			serveMetrics()
+			initTracing()
+
 			// If we fail before we start the async update check, go ahead and close the
 			// channel since we know it will never receive a value.
 			var waitForUpdateCheck bool
@@ -85,7 +140,7 @@ func NewKusionctlCmd(in io.Reader, out, err io.Writer) *cobra.Command {
 				}
 			}()
 
-			if v := os.Getenv("KUSION_SKIP_UPDATE_CHECK"); v == "true" {
+			if v := os.Getenv("KUSION_SKIP_UPDATE_CHECK"); v == "true" || release.Offline() || cmd.Annotations[util.PlainOutputAnnotation] == "true" {
 				log.Infof("skipping update check")
 			} else {
 				// Run the version check in parallel so that it doesn't block executing the command.
@@ -108,6 +163,14 @@ func NewKusionctlCmd(in io.Reader, out, err io.Writer) *cobra.Command {
 		},
 	}
 
+	cmds.PersistentFlags().StringVar(&metricsAddr, "metrics-addr", "",
+		i18n.T("Serve Prometheus metrics of engine operations at the address during the command, e.g. :9090"))
+	cmds.PersistentFlags().StringVar(&metricsPushGateway, "metrics-pushgateway", os.Getenv("KUSION_METRICS_PUSHGATEWAY"),
+		i18n.T("Push Prometheus metrics of engine operations to the Pushgateway at the URL when the command exits"))
+	cmds.PersistentFlags().StringVar(&otlpEndpoint, "otlp-endpoint", "",
+		i18n.T("Export traces of engine operations to the OTLP HTTP endpoint, e.g. http://localhost:4318. "+
+			"The standard OTEL_EXPORTER_OTLP_ENDPOINT environment variable is used if not specified"))
+
 	// From this point and forward we get warnings on flags that contain "_" separators
 	cmds.SetGlobalNormalizationFunc(cliflag.WarnWordSepNormalizeFunc)
 
@@ -117,9 +180,14 @@ func NewKusionctlCmd(in io.Reader, out, err io.Writer) *cobra.Command {
 			Commands: []*cobra.Command{
 				cmdinit.NewCmdInit(),
 				compile.NewCmdCompile(),
+				build.NewCmdBuild(),
+				push.NewCmdPush(),
 				check.NewCmdCheck(),
+				lint.NewCmdLint(),
 				ls.NewCmdLs(),
+				config.NewCmdConfig(),
 				deps.NewCmdDeps(),
+				promote.NewCmdPromote(),
 			},
 		},
 		{
@@ -128,6 +196,12 @@ func NewKusionctlCmd(in io.Reader, out, err io.Writer) *cobra.Command {
 				preview.NewCmdPreview(),
 				apply.NewCmdApply(),
 				destroy.NewCmdDestroy(),
+				state.NewCmdState(),
+				providers.NewCmdProviders(),
+				output.NewCmdOutput(),
+				sync.NewCmdSync(),
+				server.NewCmdServer(),
+				operator.NewCmdOperator(),
 			},
 		},
 	}
@@ -137,14 +211,102 @@ func NewKusionctlCmd(in io.Reader, out, err io.Writer) *cobra.Command {
 
 	templates.ActsAsRootCommand(cmds, filters, groups...)
 	// Add other subcommands
-	// TODO: add plugin subcommand
-	// cmds.AddCommand(plugin.NewCmdPlugin(f, ioStreams))
+	cmds.AddCommand(plugin.NewCmdPlugin())
 	cmds.AddCommand(version.NewCmdVersion())
+	cmds.AddCommand(upgrade.NewCmdUpgrade())
+	cmds.AddCommand(cmdtelemetry.NewCmdTelemetry())
 	cmds.AddCommand(env.NewCmdEnv())
 
 	return cmds
 }
 
+// serveMetrics serves metrics of engine operations if --metrics-addr is specified
+func serveMetrics() {
+	if metricsAddr == "" {
+		return
+	}
+	addr, err := metrics.Serve(metricsAddr)
+	if err != nil {
+		pretty.Warning.Printf("serve metrics at %s failed: %v\n", metricsAddr, err)
+		return
+	}
+	log.Infof("serving metrics at http://%s%s", addr, metrics.Path)
+}
+
+// initTracing enables tracing of engine operations if an OTLP endpoint is specified
+func initTracing() {
+	shutdown, err := tracing.Init(context.Background(), otlpEndpoint)
+	if err != nil {
+		pretty.Warning.Printf("enable tracing failed: %v\n", err)
+		return
+	}
+	shutdownTracing = shutdown
+}
+
+// Flush pushes metrics of engine operations to the Pushgateway if --metrics-pushgateway is specified, and
+// flushes traces to the OTLP endpoint if tracing is enabled. It should be called before the command exits.
+// Metrics are grouped by the host, so that pushes from different hosts do not overwrite each other.
+func Flush() {
+	if metricsPushGateway != "" {
+		host, _ := os.Hostname()
+		if err := metrics.Push(metricsPushGateway, map[string]string{"instance": host}); err != nil {
+			pretty.Warning.Printf("push metrics to %s failed: %v\n", metricsPushGateway, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		pretty.Warning.Printf("export traces failed: %v\n", err)
+	}
+}
+
+// ReportUsage reports the usage of the executed command if telemetry is turned on. It should be called before
+// the command exits.
+func ReportUsage(cmd *cobra.Command, duration time.Duration, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), telemetry.Timeout)
+	defer cancel()
+	if err := telemetry.Report(ctx, usageEvent(cmd, duration, err)); err != nil {
+		log.Infof("report usage failed: %v", err)
+	}
+}
+
+// usageEvent returns the telemetry event of the executed command. Names of plugins are not reported.
+func usageEvent(cmd *cobra.Command, duration time.Duration, err error) *telemetry.Event {
+	command := cmd.CommandPath()
+	if cmd.Annotations[plugin.Annotation] == "true" {
+		command = cmd.Root().Name() + " plugin"
+	}
+	return &telemetry.Event{
+		Command:    command,
+		Version:    versionInfo.ReleaseVersion(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		Duration:   duration.Milliseconds(),
+		ErrorClass: errorClass(err),
+	}
+}
+
+// errorClass classifies the error by exit codes, error messages are never reported
+func errorClass(err error) string {
+	switch code := util.ExitCode(err); {
+	case code == util.ExitCodeOK || code == util.ExitCodeChanges:
+		return ""
+	case code == util.ExitCodePolicyViolation:
+		return "policy_violation"
+	case code == util.ExitCodeLockConflict:
+		return "lock_conflict"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case code == util.ExitCodeError:
+		return "error"
+	default:
+		return fmt.Sprintf("exit_%d", code)
+	}
+}
+
 // checkForUpdate checks to see if the CLI needs to be updated,
 // and if so emits a warning, as well as information as to how it can be upgraded.
 func checkForUpdate() string {
@@ -270,7 +432,7 @@ func getUpgradeMessage(latest semver.Version, current semver.Version) string {
 }
 
 // getUpgradeCommand returns a command that will upgrade the CLI to the newest version.
-// If we can not determine how the CLI was installed, the empty string is returned.
+// If the CLI was not installed with kusionup, it upgrades itself by kusion upgrade.
 func getUpgradeCommand() string {
 	exe, err := os.Executable()
 	if err != nil {
@@ -284,7 +446,7 @@ func getUpgradeCommand() string {
 	if isKusionup {
 		return "$ kusionup install"
 	}
-	return ""
+	return "$ kusion upgrade"
 }
 
 // isKusionUpInstall returns true if the current running executable is running on linux based and was installed with kusionup.
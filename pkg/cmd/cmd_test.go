@@ -1,10 +1,17 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/blang/semver/v4"
 	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/cmd/plugin"
+	"kusionstack.io/kusion/pkg/cmd/util"
 )
 
 func TestNewKusionctlCmd(t *testing.T) {
@@ -30,3 +37,50 @@ func TestIsDevVersion(t *testing.T) {
 	cmVer, _ := semver.ParseTolerant("v0.7.1+3d300d71")
 	assert.True(t, isDevVersion(cmVer))
 }
+
+type fakePluginHandler struct{}
+
+func (h *fakePluginHandler) Lookup(name string) (string, bool) {
+	return "/bin/kusion-" + name, name == "foo" || name == "apply"
+}
+
+func (h *fakePluginHandler) Execute(string, []string, *plugin.Context) error {
+	return nil
+}
+
+func TestAddPluginCommand(t *testing.T) {
+	kusionctl := NewKusionctlCmd(nil, nil, nil)
+	addPluginCommand(kusionctl, []string{"unknown"}, &fakePluginHandler{})
+	addPluginCommand(kusionctl, []string{"help", "foo"}, &fakePluginHandler{})
+	_, _, err := kusionctl.Find([]string{"foo"})
+	assert.NotNil(t, err)
+
+	addPluginCommand(kusionctl, []string{"foo", "--flag"}, &fakePluginHandler{})
+	cmd, _, err := kusionctl.Find([]string{"foo", "--flag"})
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", cmd.Name())
+	assert.True(t, cmd.DisableFlagParsing)
+}
+
+func TestUsageEvent(t *testing.T) {
+	root := NewDefaultKusionctlCommand()
+	apply, _, err := root.Find([]string{"apply"})
+	assert.NoError(t, err)
+	e := usageEvent(apply, 1500*time.Millisecond, nil)
+	assert.Equal(t, "kusion apply", e.Command)
+	assert.Equal(t, int64(1500), e.Duration)
+	assert.Empty(t, e.ErrorClass)
+
+	run := plugin.NewCmdPluginRun("secret-name", "/bin/kusion-secret-name", 1, &plugin.DefaultHandler{})
+	root.AddCommand(run)
+	assert.Equal(t, "kusion plugin", usageEvent(run, time.Second, nil).Command)
+}
+
+func TestErrorClass(t *testing.T) {
+	assert.Equal(t, "", errorClass(nil))
+	assert.Equal(t, "", errorClass(util.NewExitError(util.ExitCodeChanges, nil)))
+	assert.Equal(t, "policy_violation", errorClass(util.NewExitError(util.ExitCodePolicyViolation, errors.New("denied"))))
+	assert.Equal(t, "timeout", errorClass(fmt.Errorf("apply failed: %w", context.DeadlineExceeded)))
+	assert.Equal(t, "error", errorClass(errors.New("secret message")))
+	assert.Equal(t, "exit_7", errorClass(util.NewExitError(7, nil)))
+}
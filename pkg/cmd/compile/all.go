@@ -0,0 +1,94 @@
+package compile
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// runAll compiles all stacks of the project in the work directory concurrently. Specs are written into
+// the output file in each stack directory, or printed in the order of stacks if no output file is specified.
+func (o *CompileOptions) runAll() error {
+	workDir := o.WorkDir
+	if workDir == "" {
+		workDir, _ = os.Getwd()
+	}
+	projectDir, err := projectstack.FindProjectPathFrom(workDir)
+	if err != nil {
+		return err
+	}
+	project, err := projectstack.GetProjectFrom(projectDir)
+	if err != nil {
+		return err
+	}
+	if len(project.Stacks) == 0 {
+		return fmt.Errorf("no stack found in the project %s", project.Name)
+	}
+
+	stackOptions := make(map[string]*CompileOptions, len(project.Stacks))
+	for _, stack := range project.Stacks {
+		stackOptions[stack.Name] = o.forStack(stack)
+	}
+
+	startedOn := time.Now()
+	specs, compileErr := spec.GenerateSpecs(project, project.Stacks, func(stack *projectstack.Stack) *generator.Options {
+		return stackOptions[stack.Name].GeneratorOptions()
+	}, o.Parallelism)
+
+	// write specs of succeeded stacks even if some stacks failed
+	for _, stack := range project.Stacks {
+		sp, ok := specs[stack.Name]
+		if !ok {
+			continue
+		}
+		so := stackOptions[stack.Name]
+		if so.Output == Stdout {
+			yaml, err := yamlv3.Marshal(sp.Resources)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("# Stack %s\n%s", stack.Name, yaml)
+			continue
+		}
+		if err = so.output(sp, false); err != nil {
+			return err
+		}
+		if o.Provenance {
+			if err = so.writeProvenance(project, stack, startedOn); err != nil {
+				return err
+			}
+		}
+		if o.Sign {
+			if err = so.sign(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if compileErr != nil && o.IsCheck {
+		// only print err in the check command
+		fmt.Println(compileErr)
+		return nil
+	}
+	return compileErr
+}
+
+// forStack returns the options to compile the stack, settings and the output file are relative to the stack
+// directory. The default settings files of the stack are used if no settings file is specified.
+func (o *CompileOptions) forStack(stack *projectstack.Stack) *CompileOptions {
+	so := *o
+	so.WorkDir = stack.GetPath()
+	if len(o.Settings) == 0 {
+		so.Settings = spec.DefaultSettings(so.WorkDir)
+	}
+	if so.Output == "" {
+		so.Output = Stdout
+	}
+	return &so
+}
@@ -0,0 +1,47 @@
+package compile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func TestCompileOptions_forStack(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, projectstack.KclFile), []byte{}, 0o644))
+	stack := &projectstack.Stack{StackConfiguration: projectstack.StackConfiguration{Name: "dev"}, Path: dir}
+
+	o := NewCompileOptions()
+	o.AllStacks = true
+	so := o.forStack(stack)
+	assert.Equal(t, dir, so.WorkDir)
+	assert.Equal(t, []string{projectstack.KclFile}, so.Settings)
+	assert.Equal(t, Stdout, so.Output)
+
+	o.Settings = []string{"settings.yaml"}
+	o.Output = "spec.yaml"
+	so = o.forStack(stack)
+	assert.Equal(t, []string{"settings.yaml"}, so.Settings)
+	assert.Equal(t, filepath.Join(dir, "spec.yaml"), so.outputPath())
+}
+
+func TestCompileOptions_validateAll(t *testing.T) {
+	o := NewCompileOptions()
+	o.AllStacks = true
+	assert.Nil(t, o.Validate())
+
+	o.Output = "/tmp/spec.yaml"
+	assert.Error(t, o.Validate())
+
+	o.Output = ""
+	o.Provenance = true
+	assert.Error(t, o.Validate())
+
+	o.Provenance = false
+	o.Watch = true
+	assert.Error(t, o.Validate())
+}
@@ -35,7 +35,19 @@ var (
 		kusion compile -O __main__:appConfiguration.image=nginx:latest -a
 
 		# Compile main.k and write result into output.yaml
-		kusion compile main.k -o output.yaml`
+		kusion compile main.k -o output.yaml
+
+		# Compile and write the SLSA provenance of the spec into output.yaml.provenance.json
+		kusion compile -o output.yaml --provenance
+
+		# Compile and sign output.yaml with cosign, the signature is written into output.yaml.sig
+		kusion compile -o output.yaml --sign --sign-key cosign.key
+
+		# Compile all stacks of the project concurrently and write specs into spec.yaml of each stack
+		kusion compile --all -o spec.yaml
+
+		# Recompile every time KCL files, settings, values or CRD files change and print the spec diff
+		kusion compile --watch`
 )
 
 func NewCmdCompile() *cobra.Command {
@@ -63,6 +75,18 @@ func NewCmdCompile() *cobra.Command {
 		i18n.T("Disable dumping None values"))
 	cmd.Flags().BoolVarP(&o.OverrideAST, "override-AST", "a", false,
 		i18n.T("Specify the override option"))
+	cmd.Flags().BoolVarP(&o.Watch, "watch", "", false,
+		i18n.T("Recompile on changes of KCL files, kcl.mod, settings, values and CRD files and print the spec diff versus the previous compile"))
+	cmd.Flags().BoolVarP(&o.Provenance, "provenance", "", false,
+		i18n.T("Write the SLSA provenance of the compiled spec beside the output file"))
+	cmd.Flags().BoolVarP(&o.Sign, "sign", "", false,
+		i18n.T("Sign the output file with cosign, keyless signing is used if no key is specified by --sign-key"))
+	cmd.Flags().StringVarP(&o.SignKey, "sign-key", "", "",
+		i18n.T("Specify the private key or the KMS URI of the key to sign the output file with"))
+	cmd.Flags().BoolVarP(&o.AllStacks, "all", "", false,
+		i18n.T("Compile all stacks of the project concurrently, the output file is relative to each stack directory"))
+	cmd.Flags().IntVarP(&o.Parallelism, "parallelism", "", 0,
+		i18n.T("Max number of stacks compiled concurrently with --all, defaults to the number of CPUs"))
 
 	return cmd
 }
@@ -74,6 +98,8 @@ func (o *CompileOptions) AddCompileFlags(cmd *cobra.Command) {
 		i18n.T("Specify the command line setting files"))
 	cmd.Flags().StringArrayVarP(&o.Arguments, "argument", "D", []string{},
 		i18n.T("Specify the top-level argument"))
+	cmd.Flags().StringArrayVarP(&o.Sets, "set", "", []string{},
+		i18n.T("Set a value by path and deep merge it into top-level arguments, e.g. --set app.env[0].value=prod"))
 	cmd.Flags().StringSliceVarP(&o.Overrides, "overrides", "O", []string{},
 		i18n.T("Specify the configuration override path and value"))
 }
@@ -4,17 +4,27 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	yamlv3 "gopkg.in/yaml.v3"
 
 	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/engine/models"
 	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/generator/provenance"
 	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/signing"
 )
 
 type CompileOptions struct {
-	IsCheck   bool
-	Filenames []string
+	IsCheck     bool
+	Watch       bool
+	Provenance  bool
+	Sign        bool
+	SignKey     string
+	AllStacks   bool
+	Parallelism int
+	Filenames   []string
 	CompileFlags
 }
 
@@ -23,6 +33,7 @@ type CompileFlags struct {
 	WorkDir     string
 	Settings    []string
 	Arguments   []string
+	Sets        []string
 	Overrides   []string
 	DisableNone bool
 	OverrideAST bool
@@ -36,6 +47,7 @@ func NewCompileOptions() *CompileOptions {
 		CompileFlags: CompileFlags{
 			Settings:  []string{},
 			Arguments: []string{},
+			Sets:      []string{},
 			Overrides: []string{},
 		},
 	}
@@ -43,6 +55,10 @@ func NewCompileOptions() *CompileOptions {
 
 func (o *CompileOptions) Complete(args []string) {
 	o.Filenames = args
+	if o.AllStacks {
+		// settings and outputs are resolved in each stack
+		return
+	}
 	o.PreSet(projectstack.IsStack)
 }
 
@@ -56,25 +72,47 @@ func (o *CompileOptions) Validate() error {
 	if len(wrongFiles) != 0 {
 		return fmt.Errorf("you can only compile files with suffix .k, these are wrong files: %v", wrongFiles)
 	}
+	if o.AllStacks {
+		if o.Watch {
+			return fmt.Errorf("--all can not be used with --watch")
+		}
+		if filepath.IsAbs(o.Output) {
+			return fmt.Errorf("the output file must be relative to stack directories with --all")
+		}
+		if o.Provenance && o.Output == "" {
+			return fmt.Errorf("--provenance requires an output file")
+		}
+		if o.Sign && o.Output == "" {
+			return fmt.Errorf("--sign requires an output file")
+		}
+		return nil
+	}
+	if o.Provenance && (o.Output == Stdout || o.Watch) {
+		return fmt.Errorf("--provenance requires an output file and can not be used with --watch")
+	}
+	if o.Sign && (o.Output == Stdout || o.Watch) {
+		return fmt.Errorf("--sign requires an output file and can not be used with --watch")
+	}
 	return nil
 }
 
 func (o *CompileOptions) Run() error {
+	if o.AllStacks {
+		return o.runAll()
+	}
+
 	// Parse project and stack of work directory
 	project, stack, err := projectstack.DetectProjectAndStack(o.WorkDir)
 	if err != nil {
 		return err
 	}
 
-	sp, err := spec.GenerateSpecWithSpinner(&generator.Options{
-		WorkDir:     o.WorkDir,
-		Filenames:   o.Filenames,
-		Settings:    o.Settings,
-		Arguments:   o.Arguments,
-		Overrides:   o.Overrides,
-		DisableNone: o.DisableNone,
-		OverrideAST: o.OverrideAST,
-	}, project, stack)
+	if o.Watch {
+		return o.watch(project, stack)
+	}
+
+	startedOn := time.Now()
+	sp, err := o.compile(project, stack)
 	if err != nil {
 		// only print err in the check command
 		if o.IsCheck {
@@ -85,23 +123,105 @@ func (o *CompileOptions) Run() error {
 		}
 	}
 
+	if err = o.output(sp, true); err != nil {
+		return err
+	}
+	if o.Provenance {
+		if err = o.writeProvenance(project, stack, startedOn); err != nil {
+			return err
+		}
+	}
+	if o.Sign {
+		return o.sign()
+	}
+	return nil
+}
+
+// sign signs the output file with cosign
+func (o *CompileOptions) sign() error {
+	return signing.Sign(o.outputPath(), &signing.SignOptions{Key: o.SignKey})
+}
+
+func (o *CompileOptions) compile(project *projectstack.Project, stack *projectstack.Stack) (*models.Spec, error) {
+	return spec.GenerateSpecWithSpinner(o.GeneratorOptions(), project, stack)
+}
+
+// GeneratorOptions returns the generator options of the compile flags, which are shared by all commands
+// compiling stacks
+func (o *CompileOptions) GeneratorOptions() *generator.Options {
+	return &generator.Options{
+		WorkDir:     o.WorkDir,
+		Filenames:   o.Filenames,
+		Settings:    o.Settings,
+		Arguments:   o.Arguments,
+		Sets:        o.Sets,
+		Overrides:   o.Overrides,
+		DisableNone: o.DisableNone,
+		OverrideAST: o.OverrideAST,
+	}
+}
+
+// output writes the compiled spec into the output file. When the output is stdout,
+// the spec is printed only if printStdout is true.
+func (o *CompileOptions) output(sp *models.Spec, printStdout bool) error {
 	yaml, err := yamlv3.Marshal(sp.Resources)
 	if err != nil {
 		return err
 	}
 	if o.Output == Stdout {
-		fmt.Print(string(yaml))
-	} else {
-		if o.WorkDir != "" {
-			o.Output = filepath.Join(o.WorkDir, o.Output)
+		if printStdout {
+			fmt.Print(string(yaml))
 		}
+		return nil
+	}
 
-		err = os.WriteFile(o.Output, yaml, 0o666)
-		if err != nil {
-			return err
+	return os.WriteFile(o.outputPath(), yaml, 0o666)
+}
+
+func (o *CompileOptions) outputPath() string {
+	if o.WorkDir != "" {
+		return filepath.Join(o.WorkDir, o.Output)
+	}
+	return o.Output
+}
+
+// writeProvenance writes the provenance of the compiled spec beside the output file
+func (o *CompileOptions) writeProvenance(project *projectstack.Project, stack *projectstack.Stack, startedOn time.Time) error {
+	settings := make([]string, 0, len(o.Settings))
+	for _, setting := range o.Settings {
+		if !filepath.IsAbs(setting) {
+			setting = filepath.Join(o.WorkDir, setting)
 		}
+		settings = append(settings, setting)
 	}
-	return nil
+	entryPoint, err := filepath.Rel(project.GetPath(), stack.GetPath())
+	if err != nil {
+		return err
+	}
+
+	output := o.outputPath()
+	statement, err := provenance.New(&provenance.Options{
+		ProjectDir: project.GetPath(),
+		EntryPoint: entryPoint,
+		Settings:   settings,
+		Parameters: map[string]interface{}{
+			"project":     project.Name,
+			"stack":       stack.Name,
+			"filenames":   o.Filenames,
+			"settings":    o.Settings,
+			"arguments":   o.Arguments,
+			"sets":        o.Sets,
+			"overrides":   o.Overrides,
+			"disableNone": o.DisableNone,
+			"overrideAST": o.OverrideAST,
+		},
+		StartedOn:  startedOn,
+		FinishedOn: time.Now(),
+	}, output)
+	if err != nil {
+		return fmt.Errorf("generate provenance failed: %w", err)
+	}
+	return statement.Write(output + provenance.FileSuffix)
 }
 
 func (o *CompileOptions) PreSet(preCheck func(cur string) bool) {
@@ -52,3 +52,30 @@ func TestCompileOptions_preSet(t *testing.T) {
 		})
 	}
 }
+
+func TestCompileOptions_validateProvenance(t *testing.T) {
+	o := NewCompileOptions()
+	o.Provenance = true
+	o.Output = Stdout
+	assert.Error(t, o.Validate())
+
+	o.Output = "spec.yaml"
+	assert.Nil(t, o.Validate())
+
+	o.Watch = true
+	assert.Error(t, o.Validate())
+}
+
+func TestCompileOptions_validateSign(t *testing.T) {
+	o := NewCompileOptions()
+	o.Sign = true
+	o.Output = Stdout
+	assert.ErrorContains(t, o.Validate(), "--sign requires an output file")
+
+	o.Output = "spec.yaml"
+	assert.Nil(t, o.Validate())
+
+	o.AllStacks = true
+	o.Output = ""
+	assert.ErrorContains(t, o.Validate(), "--sign requires an output file")
+}
@@ -0,0 +1,272 @@
+package compile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/generator/cache"
+	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/util/diff"
+	"kusionstack.io/kusion/pkg/util/pretty"
+)
+
+// watchInterval is the interval to check whether inputs have been changed if file system events can not be
+// watched
+var watchInterval = time.Second
+
+// watchDebounce is the time to wait for more events after a file system event, so that a save of several
+// files recompiles the stack only once
+var watchDebounce = 100 * time.Millisecond
+
+// fileSnapshot records the modification time and size of each watched file, or an empty string if it is missing
+type fileSnapshot map[string]string
+
+// takeSnapshot records the files
+func takeSnapshot(files []string) (fileSnapshot, error) {
+	snapshot := make(fileSnapshot, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				snapshot[file] = ""
+				continue
+			}
+			return nil, err
+		}
+		snapshot[file] = fmt.Sprintf("%d/%d", info.ModTime().UnixNano(), info.Size())
+	}
+	return snapshot, nil
+}
+
+// changed returns true if any file is added, removed or modified compared with the other snapshot
+func (s fileSnapshot) changed(other fileSnapshot) bool {
+	if len(s) != len(other) {
+		return true
+	}
+	for path, v := range s {
+		if ov, ok := other[path]; !ok || ov != v {
+			return true
+		}
+	}
+	return false
+}
+
+// inputs returns the files read by the compilation of the stack, which are the inputs hashed by the compile
+// cache and the values files of the stack
+func (o *CompileOptions) inputs(project *projectstack.Project, stack *projectstack.Stack) ([]string, error) {
+	files, err := cache.Inputs(cache.FindRoot(stack.Path, project.Path), o.GeneratorOptions(), stack)
+	if err != nil {
+		return nil, err
+	}
+	values, err := generator.ValuesFiles(project.Path, stack.Path)
+	if err != nil {
+		return nil, err
+	}
+	return append(files, values...), nil
+}
+
+// watchDirs adds directories of the files and all directories under root to the watcher, hidden directories
+// under root are skipped. Directories are watched instead of files, since editors often replace files on saves.
+func watchDirs(watcher *fsnotify.Watcher, root string, files []string) error {
+	dirs := map[string]bool{}
+	for _, file := range files {
+		dirs[filepath.Dir(file)] = true
+	}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		dirs[path] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for dir := range dirs {
+		// directories of missing files are watched once they are created under root
+		if err = watcher.Add(dir); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// settle waits until no event is received for watchDebounce, or the events are closed
+func settle(events <-chan fsnotify.Event) {
+	timer := time.NewTimer(watchDebounce)
+	defer timer.Stop()
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(watchDebounce)
+		case <-timer.C:
+			return
+		}
+	}
+}
+
+// changeSource tells when inputs may have changed by file system events, or by ticks of watchInterval if
+// events can not be watched
+type changeSource struct {
+	events <-chan fsnotify.Event
+	errs   <-chan error
+	ticks  <-chan time.Time
+	stop   func()
+}
+
+// poll checks inputs every watchInterval instead of watching file system events
+func (c *changeSource) poll(reason error) {
+	log.Warnf("failed to watch file system events, check changes every %s instead: %v", watchInterval, reason)
+	c.events, c.errs = nil, nil
+	ticker := time.NewTicker(watchInterval)
+	c.ticks, c.stop = ticker.C, ticker.Stop
+}
+
+// wait returns true when inputs may have changed, or false when the context is done. Inputs are checked after
+// errors of the watcher too, since events may be lost, and ticks take over once the watcher is closed.
+func (c *changeSource) wait(ctx context.Context) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-c.ticks:
+			return true
+		case _, ok := <-c.events:
+			if !ok {
+				c.poll(errors.New("the watcher is closed"))
+				continue
+			}
+			settle(c.events)
+			return true
+		case err, ok := <-c.errs:
+			if !ok {
+				c.poll(errors.New("the watcher is closed"))
+				continue
+			}
+			log.Warnf("failed to watch inputs: %v", err)
+			return true
+		}
+	}
+}
+
+func (c *changeSource) close() {
+	c.stop()
+}
+
+// watch compiles the stack every time its inputs are changed, and prints the spec diff versus the previous
+// compile, until interrupted. Inputs are checked every watchInterval if file system events can not be watched.
+func (o *CompileOptions) watch(project *projectstack.Project, stack *projectstack.Stack) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	root := cache.FindRoot(stack.Path, project.Path)
+	files, err := o.inputs(project, stack)
+	if err != nil {
+		return err
+	}
+	snapshot, err := takeSnapshot(files)
+	if err != nil {
+		return err
+	}
+
+	source := &changeSource{stop: func() {}}
+	defer source.close()
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		defer watcher.Close()
+		if err = watchDirs(watcher, root, files); err != nil {
+			return err
+		}
+		source.events, source.errs = watcher.Events, watcher.Errors
+	} else {
+		source.poll(err)
+	}
+
+	previous, err := o.compile(project, stack)
+	if err != nil {
+		fmt.Println(err)
+	} else if err = o.output(previous, true); err != nil {
+		return err
+	}
+	fmt.Println(pretty.GreenBold("Watching inputs of the stack in %s for changes, press Ctrl+C to stop ...", root))
+
+	for source.wait(ctx) {
+
+		files, err = o.inputs(project, stack)
+		if err != nil {
+			log.Warnf("failed to list inputs: %v", err)
+			continue
+		}
+		current, err := takeSnapshot(files)
+		if err != nil {
+			log.Warnf("failed to check inputs: %v", err)
+			continue
+		}
+		if !current.changed(snapshot) {
+			continue
+		}
+		snapshot = current
+		if source.events != nil {
+			// watch directories created since the last change
+			if err = watchDirs(watcher, root, files); err != nil {
+				log.Warnf("failed to watch inputs: %v", err)
+			}
+		}
+
+		fmt.Printf("\n%s Change detected, recompiling ...\n", time.Now().Format(time.Kitchen))
+		sp, err := o.compile(project, stack)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		if err = o.output(sp, previous == nil); err != nil {
+			return err
+		}
+		if previous != nil {
+			report, err := specDiff(previous, sp)
+			if err != nil {
+				return err
+			}
+			fmt.Println(report)
+		}
+		previous = sp
+	}
+	return nil
+}
+
+// specDiff returns a human-readable diff report of resources between two specs
+func specDiff(from, to *models.Spec) (string, error) {
+	report, err := diff.ToReport(from.Resources.Index(), to.Resources.Index())
+	if err != nil {
+		return "", err
+	}
+	if len(report.Diffs) == 0 {
+		return pretty.Gray("No changes in spec"), nil
+	}
+	return diff.ToHumanString(diff.NewHumanReport(report))
+}
@@ -0,0 +1,123 @@
+package compile
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/resources/crd"
+)
+
+func TestTakeSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	mainFile := filepath.Join(dir, "main.k")
+	baseFile := filepath.Join(dir, "base.k")
+	assert.Nil(t, os.WriteFile(mainFile, []byte("a = 1"), 0o644))
+	files := []string{mainFile, baseFile}
+
+	s1, err := takeSnapshot(files)
+	assert.Nil(t, err)
+	assert.Len(t, s1, 2)
+	assert.Empty(t, s1[baseFile])
+
+	s2, err := takeSnapshot(files)
+	assert.Nil(t, err)
+	assert.False(t, s2.changed(s1))
+
+	assert.Nil(t, os.WriteFile(mainFile, []byte("a = 12"), 0o644))
+	s3, err := takeSnapshot(files)
+	assert.Nil(t, err)
+	assert.True(t, s3.changed(s2))
+
+	assert.Nil(t, os.WriteFile(baseFile, []byte("c = 1"), 0o644))
+	s4, err := takeSnapshot(files)
+	assert.Nil(t, err)
+	assert.True(t, s4.changed(s3))
+
+	s5, err := takeSnapshot(files[:1])
+	assert.Nil(t, err)
+	assert.True(t, s5.changed(s4))
+}
+
+func TestCompileOptions_inputs(t *testing.T) {
+	root := t.TempDir()
+	stackDir := filepath.Join(root, "dev")
+	write := func(path, content string) string {
+		path = filepath.Join(root, path)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.Nil(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+	kclMod := write("kcl.mod", "")
+	mainFile := write(filepath.Join("dev", "main.k"), "a = 1")
+	settings := write(filepath.Join("dev", "kcl.yaml"), "kcl_options: []")
+	crdFile := write(filepath.Join(crd.Directory, "foo.yaml"), "kind: CustomResourceDefinition")
+	write(filepath.Join(".git", "ignored.k"), "b = 1")
+	write(filepath.Join("dev", "stdout.golden.yaml"), "a: 1")
+
+	o := NewCompileOptions()
+	o.WorkDir = stackDir
+	o.Settings = []string{"kcl.yaml"}
+	files, err := o.inputs(&projectstack.Project{Path: root}, &projectstack.Stack{Path: stackDir})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{
+		kclMod, mainFile, crdFile, settings,
+		filepath.Join(root, projectstack.ValuesFile), filepath.Join(stackDir, projectstack.ValuesFile),
+	}, files)
+}
+
+func TestSpecDiff(t *testing.T) {
+	newSpec := func(replicas int) *models.Spec {
+		return &models.Spec{Resources: models.Resources{
+			{
+				ID:         "apps/v1:Deployment:default:nginx",
+				Type:       "Kubernetes",
+				Attributes: map[string]interface{}{"replicas": replicas},
+			},
+		}}
+	}
+
+	report, err := specDiff(newSpec(1), newSpec(1))
+	assert.Nil(t, err)
+	assert.Contains(t, report, "No changes in spec")
+
+	report, err = specDiff(newSpec(1), newSpec(2))
+	assert.Nil(t, err)
+	assert.Contains(t, report, "replicas")
+}
+
+func TestChangeSource_wait(t *testing.T) {
+	defer func(interval time.Duration) { watchInterval = interval }(watchInterval)
+	watchInterval = 10 * time.Millisecond
+
+	events := make(chan fsnotify.Event, 1)
+	errs := make(chan error, 1)
+	source := &changeSource{events: events, errs: errs, stop: func() {}}
+	defer source.close()
+
+	events <- fsnotify.Event{Name: "main.k", Op: fsnotify.Write}
+	assert.True(t, source.wait(context.Background()))
+
+	errs <- errors.New("queue overflow")
+	assert.True(t, source.wait(context.Background()))
+
+	// ticks take over once the watcher is closed
+	close(events)
+	close(errs)
+	assert.True(t, source.wait(context.Background()))
+	assert.Nil(t, source.events)
+	assert.NotNil(t, source.ticks)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	source.ticks = nil
+	assert.False(t, source.wait(ctx))
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	configShort = "Inspect configurations of stacks"
+
+	configLong = `
+		Inspect configurations of the stack in the work directory resolved by Kusion.`
+)
+
+func NewCmdConfig() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: i18n.T(configShort),
+		Long:  templates.LongDesc(i18n.T(configLong)),
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+	cmd.AddCommand(NewCmdEffective())
+	return cmd
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	effectiveShort = "Print the effective values of a stack merged from its layers"
+
+	effectiveLong = `
+		Print the effective values of the stack in the work directory, which are passed to the generator as
+		top-level arguments when the stack is compiled.
+
+		Values are resolved by Kusion in layers, where later layers override earlier ones:
+
+		  1. values.yaml in the project directory, the base values of all stacks
+		  2. values.yaml in each directory down to the stack directory, e.g. values of each environment
+		  3. arguments given by --argument and values set by --set on the command line
+
+		Maps are deep merged key by key. Other values including lists are replaced, and null deletes the key.
+		A plain argument like -D app={...} replaces the value of the key, while --set app.replicas=3 and
+		arguments by paths are merged into it.`
+
+	effectiveExample = `
+		# Print the effective values of the current stack
+		kusion config effective
+
+		# Print the effective values with overrides on the command line as JSON
+		kusion config effective --set app.replicas=3 -D debug=true -o json`
+)
+
+func NewCmdEffective() *cobra.Command {
+	o := NewEffectiveOptions()
+
+	cmd := &cobra.Command{
+		Use:     "effective",
+		Short:   i18n.T(effectiveShort),
+		Long:    templates.LongDesc(i18n.T(effectiveLong)),
+		Example: templates.Examples(i18n.T(effectiveExample)),
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			defer util.RecoverErr(&err)
+			o.Complete(args)
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+			return
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.WorkDir, "workdir", "w", "",
+		i18n.T("Specify the work directory"))
+	cmd.Flags().StringArrayVarP(&o.Arguments, "argument", "D", []string{},
+		i18n.T("Specify the top-level argument"))
+	cmd.Flags().StringArrayVarP(&o.Sets, "set", "", []string{},
+		i18n.T("Set a value by path and deep merge it into top-level arguments, e.g. --set app.env[0].value=prod"))
+	cmd.Flags().StringVarP(&o.Output, "output", "o", OutputYAML,
+		i18n.T("Specify the output format, yaml or json"))
+
+	return cmd
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// Output formats of effective values
+const (
+	OutputYAML = "yaml"
+	OutputJSON = "json"
+)
+
+type EffectiveOptions struct {
+	WorkDir   string
+	Arguments []string
+	Sets      []string
+	Output    string
+}
+
+func NewEffectiveOptions() *EffectiveOptions {
+	return &EffectiveOptions{}
+}
+
+func (o *EffectiveOptions) Complete(_ []string) {
+	if o.WorkDir == "" {
+		o.WorkDir, _ = os.Getwd()
+	}
+}
+
+func (o *EffectiveOptions) Validate() error {
+	if o.Output != OutputYAML && o.Output != OutputJSON {
+		return fmt.Errorf("invalid output format %s, must be %s or %s", o.Output, OutputYAML, OutputJSON)
+	}
+	return nil
+}
+
+func (o *EffectiveOptions) Run() error {
+	project, stack, err := projectstack.DetectProjectAndStack(o.WorkDir)
+	if err != nil {
+		return err
+	}
+	values, files, err := generator.LoadValues(project.Path, stack.Path)
+	if err != nil {
+		return err
+	}
+	effective, err := generator.EffectiveValues(values, o.Arguments, o.Sets)
+	if err != nil {
+		return err
+	}
+
+	if o.Output == OutputJSON {
+		data, err := json.MarshalIndent(effective, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	// layers are listed as comments, so that the output is still valid YAML
+	fmt.Printf("# Layers of the stack %s in order:\n", stack.Name)
+	for _, file := range files {
+		fmt.Printf("#   %s\n", file)
+	}
+	if len(o.Arguments) > 0 || len(o.Sets) > 0 {
+		fmt.Println("#   command line")
+	}
+	var buf bytes.Buffer
+	encoder := yamlv3.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err = encoder.Encode(effective); err != nil {
+		return err
+	}
+	fmt.Print(buf.String())
+	return nil
+}
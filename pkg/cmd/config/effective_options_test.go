@@ -0,0 +1,83 @@
+package config
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+// captureStdout returns what run prints to stdout
+func captureStdout(t *testing.T, run func() error) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	err = run()
+	os.Stdout = stdout
+	require.NoError(t, w.Close())
+	require.NoError(t, err)
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func newProject(t *testing.T) string {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "project.yaml"), "name: web\n")
+	writeFile(t, filepath.Join(dir, "values.yaml"), "app:\n  image: web:1.0\n  replicas: 1\n")
+	writeFile(t, filepath.Join(dir, "dev", "stack.yaml"), "name: dev\n")
+	writeFile(t, filepath.Join(dir, "dev", "values.yaml"), "app:\n  replicas: 2\nenv: dev\n")
+	return filepath.Join(dir, "dev")
+}
+
+func TestEffectiveOptions_Validate(t *testing.T) {
+	o := NewEffectiveOptions()
+	o.Output = OutputJSON
+	assert.NoError(t, o.Validate())
+
+	o.Output = "xml"
+	assert.EqualError(t, o.Validate(), "invalid output format xml, must be yaml or json")
+}
+
+func TestEffectiveOptions_Run(t *testing.T) {
+	stackDir := newProject(t)
+
+	o := &EffectiveOptions{WorkDir: stackDir, Output: OutputYAML}
+	assert.Equal(t, `# Layers of the stack dev in order:
+#   values.yaml
+#   dev/values.yaml
+app:
+  image: web:1.0
+  replicas: 2
+env: dev
+`, captureStdout(t, o.Run))
+
+	o = &EffectiveOptions{
+		WorkDir:   stackDir,
+		Arguments: []string{"env=prod"},
+		Sets:      []string{"app.replicas=3"},
+		Output:    OutputJSON,
+	}
+	assert.Equal(t, `{
+  "app": {
+    "image": "web:1.0",
+    "replicas": 3
+  },
+  "env": "prod"
+}
+`, captureStdout(t, o.Run))
+
+	o = &EffectiveOptions{WorkDir: t.TempDir(), Output: OutputYAML}
+	assert.Error(t, o.Run())
+}
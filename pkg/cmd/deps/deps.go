@@ -26,7 +26,10 @@ var (
 		kusion deps --direct down --focus path/to/focus1 --focus path/to/focus2 --only stack
 
 		# List all the projects that depend on the given focus paths, ignoring some paths from entrance files in each stack
-		kusion deps --direct down --focus path/to/focus1 --focus path/to/focus2 --ignore path/to/ignore`
+		kusion deps --direct down --focus path/to/focus1 --focus path/to/focus2 --ignore path/to/ignore
+
+		# List all the stacks affected by the given focus paths and the projects they belong to in JSON
+		kusion deps --direct down --focus path/to/focus1 --only stack --output json`
 )
 
 func NewCmdDeps() *cobra.Command {
@@ -55,6 +58,8 @@ func NewCmdDeps() *cobra.Command {
 		i18n.T("when direct is set to \"down\", \"only\" means only the downstream project/stack list will be output. Valid values: project, stack. Defaults to project"))
 	cmd.Flags().StringSliceVar(&o.Ignore, "ignore", nil,
 		i18n.T("the file paths to ignore when filtering the affected stacks/projects. Each path needs to be a valid relative path from the workdir. If not set, no paths will be ignored."))
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "",
+		i18n.T("the output format. Valid values: json. If set to json with --only stack, the downstream result contains both stacks and the projects they belong to"))
 
 	return cmd
 }
@@ -36,6 +36,7 @@ func TestDepsOptions_Validate(t *testing.T) {
 		workDir string
 		focus   []string
 		ignore  []string
+		output  string
 		errMsg  string
 	}{
 		{
@@ -86,6 +87,7 @@ func TestDepsOptions_Validate(t *testing.T) {
 				Only:    tc.only,
 				Focus:   tc.focus,
 				Ignore:  tc.ignore,
+				Output:  tc.output,
 			}
 			err := opt.Validate()
 			if err != nil && err.Error() != tc.errMsg {
@@ -276,6 +278,15 @@ func TestFindDownStreams(t *testing.T) {
 	}
 }
 
+func TestStacksToProjects(t *testing.T) {
+	projects, err := projectstack.FindAllProjectsFrom(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := stacksToProjects(workDir, projects, toSet([]string{"appops/projectA/dev", "appops/projectC/dev"}))
+	assert.Equal(t, []string{"appops/projectA", "appops/projectC"}, result.toSlice())
+}
+
 func BenchmarkDownStream(b *testing.B) {
 	tc := downstreamTestCases[0]
 	for i := 0; i < b.N; i++ {
@@ -290,3 +301,13 @@ func BenchmarkDownStream(b *testing.B) {
 		assert.ElementsMatch(b, result.toSlice(), tc.downStreamProjects, "test result mismatch")
 	}
 }
+
+func Test_downstreamsJSON(t *testing.T) {
+	stack := &projectstack.Stack{Path: filepath.Join(workDir, "appops/projectA/dev")}
+	projects := []*projectstack.Project{{Path: filepath.Join(workDir, "appops/projectA"), Stacks: []*projectstack.Stack{stack}}}
+
+	assert.Equal(t, map[string][]string{"projects": {"appops/projectA"}},
+		downstreamsJSON(workDir, projects, toSet([]string{"appops/projectA"}), true))
+	assert.Equal(t, map[string][]string{"projects": {"appops/projectA"}, "stacks": {"appops/projectA/dev"}},
+		downstreamsJSON(workDir, projects, toSet([]string{"appops/projectA/dev"}), false))
+}
@@ -1,6 +1,7 @@
 package deps
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -19,8 +20,12 @@ type DepsOptions struct {
 	Focus   []string
 	Only    string
 	Ignore  []string
+	Output  string
 }
 
+// OutputJSON is the output format which prints the dependency information in JSON
+const OutputJSON = "json"
+
 // stringSet is a simple string set implementation by map
 type stringSet map[string]bool
 
@@ -78,6 +83,10 @@ func (o *DepsOptions) Validate() error {
 		return fmt.Errorf("invalid output direction of the dependency inspection. supported directions: up, down")
 	}
 
+	if o.Output != "" && o.Output != OutputJSON {
+		return fmt.Errorf("invalid output format. supported formats: json")
+	}
+
 	if _, err := os.Stat(o.workDir); err != nil {
 		return fmt.Errorf("invalid work dir: %s", err)
 	}
@@ -107,6 +116,9 @@ func (o *DepsOptions) Run() (err error) {
 		if err != nil {
 			return err
 		}
+		if o.Output == OutputJSON {
+			return printJSON(map[string][]string{"files": upstreamFiles})
+		}
 		for _, f := range upstreamFiles {
 			fmt.Println(f)
 		}
@@ -141,7 +153,10 @@ func (o *DepsOptions) Run() (err error) {
 		}
 
 		// 5. Output the result
-		for name := range downstreams {
+		if o.Output == OutputJSON {
+			return printJSON(downstreamsJSON(o.workDir, projects, downstreams, projectOnly))
+		}
+		for _, name := range downstreams.toSlice() {
 			fmt.Println(name)
 		}
 		return nil
@@ -260,6 +275,44 @@ func findDownStreams(workDir string, projects []*projectstack.Project, focusPath
 	return
 }
 
+// downstreamsJSON returns the JSON output of the downstreams, which contains the downstream projects, and the
+// downstream stacks as well unless only projects are filtered
+func downstreamsJSON(workDir string, projects []*projectstack.Project, downstreams stringSet, projectOnly bool) map[string][]string {
+	if projectOnly {
+		return map[string][]string{"projects": downstreams.toSlice()}
+	}
+	return map[string][]string{
+		"projects": stacksToProjects(workDir, projects, downstreams).toSlice(),
+		"stacks":   downstreams.toSlice(),
+	}
+}
+
+// stacksToProjects returns the relative paths of projects which the given stacks belong to.
+// Both the stacks and the returned projects are relative paths from the workDir.
+func stacksToProjects(workDir string, projects []*projectstack.Project, stacks stringSet) stringSet {
+	result := emptyStringSet()
+	for _, project := range projects {
+		projectRel, _ := filepath.Rel(workDir, project.GetPath())
+		for _, stack := range project.Stacks {
+			stackRel, _ := filepath.Rel(workDir, stack.GetPath())
+			if stacks.contains(stackRel) {
+				result.add(projectRel)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
 func toSet(list []string) stringSet {
 	result := emptyStringSet()
 	for _, item := range list {
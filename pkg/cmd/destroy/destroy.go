@@ -6,6 +6,7 @@ import (
 	"k8s.io/kubectl/pkg/util/templates"
 
 	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/engine/operation"
 )
 
 var (
@@ -19,11 +20,23 @@ var (
 
 		Note that the destroy command does NOT do resource version checks, so if someone submits an
 		update to a resource right when you submit a destroy, their update will be lost along with the
-		rest of the resource.`
+		rest of the resource.
+
+		Resources can be selected by --target and --type to destroy a part of the stack, while the rest
+		of the stack is kept in the state. Resources depending on the selected ones must be selected too,
+		or be destroyed together with --cascade.
+
+		At most --parallelism resources are destroyed concurrently, which is 10 by default.`
 
 	destroyExample = `
 		# Delete the configuration of current stack
-		kusion destroy`
+		kusion destroy
+
+		# Delete ConfigMaps in the default namespace only
+		kusion destroy --target 'v1:ConfigMap:default:*'
+
+		# Delete all S3 buckets and resources depending on them
+		kusion destroy --type aws_s3_bucket --cascade`
 )
 
 func NewCmdDestroy() *cobra.Command {
@@ -50,6 +63,18 @@ func NewCmdDestroy() *cobra.Command {
 		i18n.T("Automatically approve and perform the update after previewing it"))
 	cmd.Flags().BoolVarP(&o.Detail, "detail", "d", false,
 		i18n.T("Automatically show plan details after previewing it"))
+	cmd.Flags().StringSliceVarP(&o.Targets, "target", "", nil,
+		i18n.T("Specify IDs of resources to destroy, which can be glob patterns"))
+	cmd.Flags().StringSliceVarP(&o.Types, "type", "", nil,
+		i18n.T("Specify types of resources to destroy, i.e. kinds of Kubernetes resources or types of Terraform resources"))
+	cmd.Flags().BoolVarP(&o.Cascade, "cascade", "", false,
+		i18n.T("Destroy resources depending on the selected ones too"))
+	cmd.Flags().IntVarP(&o.ResourceParallelism, "parallelism", "", operation.DefaultParallelism,
+		i18n.T("Specify the max number of resources destroyed concurrently"))
+	cmd.Flags().StringVarP(&o.FailureBundle, "failure-bundle", "", "",
+		i18n.T("Specify the tar.gz file to write a diagnostic bundle to if the destroy fails, with sensitive values masked"))
+	cmd.Flags().StringVarP(&o.ForceUnlock, "force-unlock", "", "",
+		i18n.T("Take over the lock of the state held by another operation with the reason. Expired locks are taken over without it"))
 	o.AddBackendFlags(cmd)
 
 	return cmd
@@ -1,8 +1,12 @@
 package destroy
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path"
+	"sort"
 	"strings"
 	"sync"
 
@@ -12,12 +16,14 @@ import (
 	compilecmd "kusionstack.io/kusion/pkg/cmd/compile"
 	"kusionstack.io/kusion/pkg/cmd/spec"
 	"kusionstack.io/kusion/pkg/engine/backend"
+	"kusionstack.io/kusion/pkg/engine/bundle"
 	"kusionstack.io/kusion/pkg/engine/models"
 	"kusionstack.io/kusion/pkg/engine/operation"
 	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
 	"kusionstack.io/kusion/pkg/engine/states"
-	"kusionstack.io/kusion/pkg/generator"
 	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/notification"
 	"kusionstack.io/kusion/pkg/projectstack"
 	"kusionstack.io/kusion/pkg/status"
 	"kusionstack.io/kusion/pkg/util/signals"
@@ -28,7 +34,40 @@ type DestroyOptions struct {
 	Operator string
 	Yes      bool
 	Detail   bool
+	// Targets are patterns of IDs of resources to destroy, e.g. v1:ConfigMap:default:*
+	Targets []string
+	// Types are types of resources to destroy, which are kinds of Kubernetes resources or types of Terraform
+	// resources, e.g. ConfigMap or aws_s3_bucket
+	Types []string
+	// Cascade destroys resources depending on the selected ones too
+	Cascade bool
+	// ResourceParallelism is the max number of resources destroyed concurrently
+	ResourceParallelism int
+	// FailureBundle is the file to write a diagnostic bundle to if the destroy fails, which is attached to issues
+	FailureBundle string
+	// ForceUnlock is the reason to take over the lock of the state held by another operation which has not
+	// expired, e.g. the process holding it was killed
+	ForceUnlock string
 	backend.BackendOps
+
+	// targets are IDs of resources selected by --target and --type, the whole stack is destroyed if empty
+	targets []string
+
+	// failed are IDs of resources failed to be destroyed
+	failed []string
+
+	// audits are records of the lock of the state, which are recorded in the state if it is kept
+	audits []states.AuditRecord
+
+	// notifier notifies webhooks of the project and the stack, and CloudEvents sinks of each resource
+	notifier *notification.Notifier
+
+	// runtimeEnv is the env of runtimes of the stack, e.g. the kubeconfig pinned by the stack
+	runtimeEnv runtime.Env
+
+	// ctx is canceled with lockLost if the lock of the state is lost during the destroy, which aborts the destroy
+	ctx      context.Context
+	lockLost error
 }
 
 func NewDestroyOptions() *DestroyOptions {
@@ -42,6 +81,17 @@ func (o *DestroyOptions) Complete(args []string) {
 }
 
 func (o *DestroyOptions) Validate() error {
+	if o.Cascade && len(o.Targets) == 0 && len(o.Types) == 0 {
+		return fmt.Errorf("--cascade can only be used with --target or --type")
+	}
+	if o.ResourceParallelism < 0 {
+		return fmt.Errorf("invalid --parallelism %d, must not be negative", o.ResourceParallelism)
+	}
+	for _, pattern := range o.Targets {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid --target %s: %w", pattern, err)
+		}
+	}
 	return o.CompileOptions.Validate()
 }
 
@@ -53,17 +103,12 @@ func (o *DestroyOptions) Run() error {
 	if err != nil {
 		return err
 	}
+	if o.runtimeEnv, err = runtime.StackEnv(context.Background(), project, stack); err != nil {
+		return err
+	}
 
 	// Get compile result
-	planResources, err := spec.GenerateSpecWithSpinner(&generator.Options{
-		WorkDir:     o.WorkDir,
-		Filenames:   o.Filenames,
-		Settings:    o.Settings,
-		Arguments:   o.Arguments,
-		Overrides:   o.Overrides,
-		DisableNone: o.DisableNone,
-		OverrideAST: o.OverrideAST,
-	}, project, stack)
+	planResources, err := spec.GenerateSpecWithSpinner(o.GeneratorOptions(), project, stack)
 	if err != nil {
 		return err
 	}
@@ -79,8 +124,33 @@ func (o *DestroyOptions) Run() error {
 		return err
 	}
 
+	// Select resources to destroy, the rest of the stack is kept
+	destroyResources := planResources
+	if len(o.Targets) > 0 || len(o.Types) > 0 {
+		state, err := stateStorage.GetLatestState(&states.StateQuery{
+			Tenant:  project.Tenant,
+			Project: project.Name,
+			Stack:   stack.Name,
+		})
+		if err != nil {
+			return err
+		}
+		var stateResources models.Resources
+		if state != nil {
+			stateResources = state.Resources
+		}
+		if o.targets, err = o.selectTargets(planResources.Resources, stateResources); err != nil {
+			return err
+		}
+		resources, s := operation.DestroyTargets(planResources.Resources, stateResources, o.targets)
+		if status.IsErr(s) {
+			return fmt.Errorf("%s, or destroy resources depending on them with --cascade", s.Message())
+		}
+		destroyResources = &models.Spec{Resources: resources}
+	}
+
 	// Compute changes for preview
-	changes, err := o.preview(planResources, project, stack, stateStorage)
+	changes, err := o.preview(destroyResources, project, stack, stateStorage)
 	if err != nil {
 		return err
 	}
@@ -116,12 +186,74 @@ func (o *DestroyOptions) Run() error {
 		}
 	}
 
+	// Lock the state during the destroy, the lock expires soon if the process dies
+	query := &states.StateQuery{
+		Tenant:  project.Tenant,
+		Project: project.Name,
+		Stack:   stack.Name,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	o.ctx = ctx
+	unlock, audits, err := states.AcquireLock(stateStorage, query, "destroy", o.Operator, o.ForceUnlock,
+		func(info *states.LockInfo, err error) {
+			if errors.Is(err, states.ErrLockLost) {
+				pterm.Error.Printfln("Aborting the destroy: %v", err)
+				o.lockLost = err
+				cancel()
+			} else if err != nil {
+				pterm.Warning.Printfln("Failed to refresh the lock of the state: %v", err)
+			}
+		})
+	if err != nil {
+		return err
+	}
+	o.audits = audits
+	defer func() {
+		if e := unlock(); e != nil {
+			pterm.Warning.Println(e)
+		}
+	}()
+
+	// Back up the state before the destroy, which is restored by kusion state restore-backup
+	if _, err = states.BackupState(stateStorage, query); err != nil {
+		return err
+	}
+
 	// Destroy
+	// Notify webhooks of the project and the stack, failed notifications never fail the destroy
+	o.notifier = notification.NewNotifier("destroy", project, stack, o.Operator, changes)
+	if err := o.notifier.Start(context.Background()); err != nil {
+		pterm.Warning.Println(err)
+	}
+
 	fmt.Println("Start destroying resources......")
-	if err := o.destroy(planResources, changes, stateStorage); err != nil {
-		return err
+	err = o.destroy(planResources, changes, stateStorage)
+	if err != nil && ctx.Err() != nil {
+		err = fmt.Errorf("the destroy is aborted: %w", o.lockLost)
 	}
-	return nil
+	if e := o.notifier.Finish(context.Background(), err); e != nil {
+		pterm.Warning.Println(e)
+	}
+	if err != nil && o.FailureBundle != "" {
+		sort.Strings(o.failed)
+		if e := bundle.Write(context.Background(), o.FailureBundle, &bundle.Request{
+			Operation: "destroy",
+			Project:   project,
+			Stack:     stack,
+			Spec:      planResources,
+			Changes:   changes,
+			Failed:    o.failed,
+			Error:     err,
+
+			RuntimeEnv: o.runtimeEnv,
+		}); e != nil {
+			pterm.Warning.Println(e)
+		} else {
+			fmt.Printf("Diagnostic bundle is written to %s, sensitive values in it are masked\n", o.FailureBundle)
+		}
+	}
+	return err
 }
 
 func (o *DestroyOptions) preview(planResources *models.Spec, project *projectstack.Project,
@@ -135,6 +267,8 @@ func (o *DestroyOptions) preview(planResources *models.Spec, project *projectsta
 			Stack:         stack,
 			StateStorage:  stateStorage,
 			ChangeOrder:   &opsmodels.ChangeOrder{StepKeys: []string{}, ChangeSteps: map[string]*opsmodels.ChangeStep{}},
+			RuntimeEnv:    o.runtimeEnv,
+			Parallelism:   o.ResourceParallelism,
 		},
 	}
 
@@ -164,6 +298,9 @@ func (o *DestroyOptions) destroy(planResources *models.Spec, changes *opsmodels.
 			Stack:        changes.Stack(),
 			StateStorage: stateStorage,
 			MsgCh:        make(chan opsmodels.Message),
+			RuntimeEnv:   o.runtimeEnv,
+			Context:      o.ctx,
+			Parallelism:  o.ResourceParallelism,
 		},
 	}
 
@@ -177,6 +314,7 @@ func (o *DestroyOptions) destroy(planResources *models.Spec, changes *opsmodels.
 	}
 	// wait msgCh close
 	var wg sync.WaitGroup
+	wg.Add(1)
 	// receive msg and print detail
 	go func() {
 		defer func() {
@@ -184,7 +322,6 @@ func (o *DestroyOptions) destroy(planResources *models.Spec, changes *opsmodels.
 				log.Errorf("failed to receive msg and print detail as %v", p)
 			}
 		}()
-		wg.Add(1)
 
 		for {
 			select {
@@ -194,6 +331,7 @@ func (o *DestroyOptions) destroy(planResources *models.Spec, changes *opsmodels.
 					return
 				}
 				changeStep := changes.Get(msg.ResourceID)
+				o.notifier.Resource(changeStep, msg)
 
 				switch msg.OpResult {
 				case opsmodels.Success, opsmodels.Skip:
@@ -216,6 +354,7 @@ func (o *DestroyOptions) destroy(planResources *models.Spec, changes *opsmodels.
 					progressbar.Increment()
 					deleted++
 				case opsmodels.Failed:
+					o.failed = append(o.failed, msg.ResourceID)
 					title := fmt.Sprintf("%s %s %s",
 						changeStep.Action.String(),
 						pterm.Bold.Sprint(changeStep.ID),
@@ -241,10 +380,15 @@ func (o *DestroyOptions) destroy(planResources *models.Spec, changes *opsmodels.
 			Operator: o.Operator,
 			Stack:    changes.Stack(),
 			Spec:     planResources,
+			Audits:   o.audits,
 		},
+		Targets: o.targets,
 	})
 	if status.IsErr(st) {
-		return fmt.Errorf("destroy failed, status: %v", st)
+		// wait for results of resources before returning
+		wg.Wait()
+		// keep typed errors of the status, e.g. lock conflicts mapped to exit codes
+		return status.Wrap(st, fmt.Sprintf("destroy failed, status: %v", st))
 	}
 
 	// wait for msgCh closed
@@ -255,6 +399,59 @@ func (o *DestroyOptions) destroy(planResources *models.Spec, changes *opsmodels.
 	return nil
 }
 
+// selectTargets returns IDs of resources in the spec matching --target or --type, together with resources depending
+// on them in the spec or the state if --cascade is specified
+func (o *DestroyOptions) selectTargets(resources, stateResources models.Resources) ([]string, error) {
+	types := map[string]bool{}
+	for _, t := range o.Types {
+		types[t] = true
+	}
+
+	var targets []string
+	for i := range resources {
+		r := &resources[i]
+		selected := types[resourceType(r)]
+		for _, pattern := range o.Targets {
+			if matched, _ := path.Match(pattern, r.ID); matched {
+				selected = true
+			}
+		}
+		if selected {
+			targets = append(targets, r.ID)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no resources in the stack match --target %v or --type %v", o.Targets, o.Types)
+	}
+	if o.Cascade {
+		specIndex := resources.Index()
+		all := append(append(models.Resources{}, resources...), stateResources...)
+		for _, id := range operation.Dependents(all, targets) {
+			// resources removed from the spec are only in the state, and can't be destroyed with the targets
+			if specIndex[id] == nil {
+				return nil, fmt.Errorf("%s in the state depends on the resources to destroy, but it is not in the stack", id)
+			}
+			targets = append(targets, id)
+		}
+	}
+	sort.Strings(targets)
+	return targets, nil
+}
+
+// resourceType returns the kind of the Kubernetes resource, or the resource type of the Terraform resource
+func resourceType(r *models.Resource) string {
+	switch r.Type {
+	case runtime.Kubernetes:
+		kind, _ := r.Attributes["kind"].(string)
+		return kind
+	case runtime.Terraform:
+		t, _ := r.Extensions["resourceType"].(string)
+		return t
+	default:
+		return ""
+	}
+}
+
 func prompt() (string, error) {
 	prompt := &survey.Select{
 		Message: `Do you want to destroy these diffs?`,
@@ -60,6 +60,7 @@ func TestDestroyOptions_Run(t *testing.T) {
 		mockOperationDestroy(opsmodels.Success)
 
 		o := NewDestroyOptions()
+		o.WorkDir = t.TempDir()
 		mockPromptOutput("yes")
 		err := o.Run()
 		assert.Nil(t, err)
@@ -108,7 +109,7 @@ func Test_preview(t *testing.T) {
 }
 
 func mockNewKubernetesRuntime() {
-	monkey.Patch(kubernetes.NewKubernetesRuntime, func() (runtime.Runtime, error) {
+	monkey.Patch(kubernetes.NewKubernetesRuntime, func(runtime.Env) (runtime.Runtime, error) {
 		return &fakerRuntime{}, nil
 	})
 }
@@ -224,6 +225,30 @@ func Test_destroy(t *testing.T) {
 		err := o.destroy(planResources, changes, stateStorage)
 		assert.Nil(t, err)
 	})
+	t.Run("destroy with parallelism", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		mockNewKubernetesRuntime()
+		var parallelism int
+		monkey.Patch((*operation.DestroyOperation).Destroy,
+			func(o *operation.DestroyOperation, request *operation.DestroyRequest) status.Status {
+				parallelism = o.Parallelism
+				close(o.MsgCh)
+				return nil
+			})
+
+		o := NewDestroyOptions()
+		o.ResourceParallelism = 3
+		planResources := &models.Spec{Resources: []models.Resource{sa1}}
+		order := &opsmodels.ChangeOrder{
+			StepKeys:    []string{sa1.ID},
+			ChangeSteps: map[string]*opsmodels.ChangeStep{sa1.ID: {ID: sa1.ID, Action: opsmodels.Delete}},
+		}
+		changes := opsmodels.NewChanges(project, stack, order)
+		stateStorage := &local.FileSystemState{Path: filepath.Join(o.WorkDir, local.KusionState)}
+
+		assert.Nil(t, o.destroy(planResources, changes, stateStorage))
+		assert.Equal(t, 3, parallelism)
+	})
 	t.Run("destroy failed", func(t *testing.T) {
 		defer monkey.UnpatchAll()
 		mockNewKubernetesRuntime()
@@ -246,6 +271,8 @@ func Test_destroy(t *testing.T) {
 
 		err := o.destroy(planResources, changes, stateStorage)
 		assert.NotNil(t, err)
+		// failed resources are collected into the failure bundle
+		assert.Equal(t, []string{sa1.ID}, o.failed)
 	})
 }
 
@@ -277,6 +304,79 @@ func mockOperationDestroy(res opsmodels.OpResult) {
 		})
 }
 
+func TestDestroyOptions_Validate(t *testing.T) {
+	o := NewDestroyOptions()
+	o.Cascade = true
+	assert.NotNil(t, o.Validate())
+
+	o.Targets = []string{"v1:ConfigMap:default:["}
+	assert.NotNil(t, o.Validate())
+
+	o = NewDestroyOptions()
+	o.ResourceParallelism = -1
+	assert.ErrorContains(t, o.Validate(), "--parallelism")
+}
+
+func TestDestroyOptions_selectTargets(t *testing.T) {
+	namespace := models.Resource{
+		ID:         "v1:Namespace::default",
+		Type:       runtime.Kubernetes,
+		Attributes: map[string]interface{}{"kind": "Namespace"},
+	}
+	configMap := models.Resource{
+		ID:         "v1:ConfigMap:default:app",
+		Type:       runtime.Kubernetes,
+		Attributes: map[string]interface{}{"kind": "ConfigMap"},
+		DependsOn:  []string{namespace.ID},
+	}
+	bucket := models.Resource{
+		ID:         "hashicorp:aws:aws_s3_bucket:logs",
+		Type:       runtime.Terraform,
+		Extensions: map[string]interface{}{"resourceType": "aws_s3_bucket"},
+	}
+	resources := models.Resources{namespace, configMap, bucket}
+
+	o := NewDestroyOptions()
+	o.Targets = []string{"v1:ConfigMap:default:*"}
+	o.Types = []string{"aws_s3_bucket"}
+	got, err := o.selectTargets(resources, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{bucket.ID, configMap.ID}, got)
+
+	o = NewDestroyOptions()
+	o.Types = []string{"Namespace"}
+	o.Cascade = true
+	got, err = o.selectTargets(resources, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{configMap.ID, namespace.ID}, got)
+
+	// dependents are followed through resources in the state
+	secret := models.Resource{
+		ID:         "v1:Secret:default:app",
+		Type:       runtime.Kubernetes,
+		Attributes: map[string]interface{}{"kind": "Secret"},
+		DependsOn:  []string{namespace.ID},
+	}
+	deployment := models.Resource{
+		ID:         "apps/v1:Deployment:default:app",
+		Type:       runtime.Kubernetes,
+		Attributes: map[string]interface{}{"kind": "Deployment"},
+	}
+	stateDeployment := deployment
+	stateDeployment.DependsOn = []string{configMap.ID}
+	got, err = o.selectTargets(append(resources, deployment), models.Resources{namespace, configMap, stateDeployment})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{deployment.ID, configMap.ID, namespace.ID}, got)
+
+	// resources only in the state can't be destroyed with the targets
+	_, err = o.selectTargets(resources, models.Resources{namespace, secret})
+	assert.ErrorContains(t, err, secret.ID+" in the state depends on")
+
+	o.Types = []string{"Deployment"}
+	_, err = o.selectTargets(resources, nil)
+	assert.NotNil(t, err)
+}
+
 func Test_prompt(t *testing.T) {
 	t.Run("prompt error", func(t *testing.T) {
 		monkey.Patch(
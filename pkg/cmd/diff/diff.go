@@ -36,7 +36,16 @@ var (
 		cat pod-1.yaml > pod-full.yaml
 		echo '---' >> pod-full.yaml
 		cat pod-2.yaml >> pod-full.yaml
-		cat pod-full.yaml | kusion diff -`
+		cat pod-full.yaml | kusion diff -
+
+		# Diff resources of two compiled specs
+		kusion diff --spec old.yaml new.yaml
+
+		# Diff the spec file ci-test/stdout.golden.yaml between two git refs
+		kusion diff --spec main:dev/ci-test/stdout.golden.yaml HEAD:dev/ci-test/stdout.golden.yaml
+
+		# Compile the stack in the current directory at two git refs and diff the specs
+		kusion diff --spec main HEAD`
 )
 
 func NewCmdDiff() *cobra.Command {
@@ -67,6 +76,8 @@ func NewCmdDiff() *cobra.Command {
 	}
 
 	// Input documents modification flags
+	cmd.Flags().BoolVar(&o.spec, "spec", false,
+		i18n.T("Diff resources of two compiled specs, which are spec files, REF:PATH of spec files in git or git refs to compile the current stack at"))
 	cmd.Flags().BoolVar(&o.swap, "swap", false,
 		i18n.T("Swap <from> and <to> for comparison. Note that it is invalid when <from> is stdin. The default is false"))
 	cmd.Flags().StringVar(&o.diffMode, "diff-mode", "normal",
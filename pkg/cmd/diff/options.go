@@ -36,6 +36,7 @@ type DiffOptions struct {
 	doNotInspectCerts        bool
 	omitHeader               bool
 	useGoPatchPaths          bool
+	spec                     bool
 	// exitWithCount      bool
 }
 
@@ -70,6 +71,10 @@ func (o *DiffOptions) Complete(args []string) error {
 }
 
 func (o *DiffOptions) Validate() error {
+	if o.spec && (o.toLocation == "" || ytbx.IsStdin(o.fromLocation) || ytbx.IsStdin(o.toLocation)) {
+		return fmt.Errorf("two specs are required to diff specs, which can not be stdin")
+	}
+
 	switch strings.ToLower(o.diffMode) {
 	case DiffModeLive:
 	case DiffModeNormal:
@@ -93,6 +98,10 @@ func (o *DiffOptions) Validate() error {
 func (o *DiffOptions) Run() error {
 	var err error
 
+	if o.spec {
+		return o.runSpecDiff()
+	}
+
 	if strings.ToLower(o.diffMode) == DiffModeLive {
 		if ytbx.IsStdin(o.fromLocation) {
 			return liveDiffWithStdin()
@@ -0,0 +1,168 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+	diffutil "kusionstack.io/kusion/pkg/util/diff"
+	"kusionstack.io/kusion/pkg/util/pretty"
+)
+
+// runSpecDiff diffs resources of two compiled specs grouped by resource IDs
+func (o *DiffOptions) runSpecDiff() error {
+	from, err := loadSpec(o.fromLocation)
+	if err != nil {
+		return err
+	}
+	to, err := loadSpec(o.toLocation)
+	if err != nil {
+		return err
+	}
+	report, err := specDiffReport(from, to, o.outStyle)
+	if err != nil {
+		return err
+	}
+	fmt.Print(report)
+	return nil
+}
+
+// loadSpec loads the spec from the location, which is one of:
+//   - a spec file compiled by kusion compile
+//   - REF:PATH, the spec file at the git ref
+//   - REF, the spec compiled from the stack of the current directory at the git ref
+func loadSpec(location string) (models.Resources, error) {
+	if _, err := os.Stat(location); err == nil {
+		data, err := os.ReadFile(location)
+		if err != nil {
+			return nil, err
+		}
+		return spec.ParseSpec(data)
+	}
+	if ref, path, ok := strings.Cut(location, ":"); ok {
+		data, err := git("", "show", ref+":"+path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s at %s failed: %w", path, ref, err)
+		}
+		return spec.ParseSpec(data)
+	}
+	sp, err := compileAtRef(location)
+	if err != nil {
+		return nil, fmt.Errorf("compile the stack at %s failed: %w", location, err)
+	}
+	return sp.Resources, nil
+}
+
+// compileAtRef compiles the stack of the current directory in a temporary git worktree checked out at the ref
+var compileAtRef = func(ref string) (*models.Spec, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	top, err := git(cwd, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("not in a git repository: %w", err)
+	}
+	root, err := filepath.EvalSymlinks(strings.TrimSpace(string(top)))
+	if err != nil {
+		return nil, err
+	}
+	if cwd, err = filepath.EvalSymlinks(cwd); err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(root, cwd)
+	if err != nil {
+		return nil, err
+	}
+
+	worktree, err := os.MkdirTemp("", "kusion-diff-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(worktree)
+	if _, err = git(root, "worktree", "add", "--detach", worktree, ref); err != nil {
+		return nil, err
+	}
+	defer func() {
+		_, _ = git(root, "worktree", "remove", "--force", worktree)
+	}()
+
+	stackDir := filepath.Join(worktree, rel)
+	project, stack, err := projectstack.DetectProjectAndStack(stackDir)
+	if err != nil {
+		return nil, err
+	}
+	return spec.GenerateSpec(&generator.Options{
+		WorkDir:  stackDir,
+		Settings: spec.DefaultSettings(stackDir),
+	}, project, stack)
+}
+
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// specDiffReport returns the diff report of resources grouped by resource IDs
+func specDiffReport(from, to models.Resources, outStyle string) (string, error) {
+	fromIndex, toIndex := from.Index(), to.Index()
+	ids := make([]string, 0, len(fromIndex)+len(toIndex))
+	for id := range fromIndex {
+		ids = append(ids, id)
+	}
+	for id := range toIndex {
+		if _, ok := fromIndex[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var added, changed, deleted int
+	buf := &strings.Builder{}
+	for _, id := range ids {
+		oldRes, newRes := fromIndex[id], toIndex[id]
+		switch {
+		case oldRes == nil:
+			added++
+			fmt.Fprintln(buf, pretty.Green("+ %s", id))
+		case newRes == nil:
+			deleted++
+			fmt.Fprintln(buf, pretty.Red("- %s", id))
+		default:
+			report, err := diffutil.ToReport(oldRes, newRes)
+			if err != nil {
+				return "", err
+			}
+			if len(report.Diffs) == 0 {
+				continue
+			}
+			changed++
+			detail, err := diffutil.ToReportString(diffutil.NewHumanReport(report), outStyle)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintln(buf, pretty.Yellow("~ %s", id))
+			fmt.Fprintln(buf, strings.TrimRight(detail, "\n"))
+		}
+	}
+	if added+changed+deleted == 0 {
+		return "No changes in spec\n", nil
+	}
+	fmt.Fprintf(buf, "\nSpec diff: %d to add, %d to change, %d to delete\n", added, changed, deleted)
+	return buf.String(), nil
+}
@@ -0,0 +1,86 @@
+package diff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/engine/models"
+	diffutil "kusionstack.io/kusion/pkg/util/diff"
+)
+
+const (
+	oldSpec = `- id: v1:ConfigMap:default:a
+  type: Kubernetes
+  attributes:
+    data:
+      k: v1
+- id: v1:ConfigMap:default:b
+  type: Kubernetes
+  attributes: {}
+- id: v1:ConfigMap:default:c
+  type: Kubernetes
+  attributes: {}
+`
+	newSpec = `resources:
+- id: v1:ConfigMap:default:a
+  type: Kubernetes
+  attributes:
+    data:
+      k: v2
+- id: v1:ConfigMap:default:c
+  type: Kubernetes
+  attributes: {}
+- id: v1:ConfigMap:default:d
+  type: Kubernetes
+  attributes: {}
+`
+)
+
+func Test_specDiffReport(t *testing.T) {
+	from, _ := spec.ParseSpec([]byte(oldSpec))
+	to, _ := spec.ParseSpec([]byte(newSpec))
+
+	report, err := specDiffReport(from, to, diffutil.OutputRaw)
+	assert.Nil(t, err)
+	assert.Contains(t, report, "~ v1:ConfigMap:default:a")
+	assert.Contains(t, report, "- v1:ConfigMap:default:b")
+	assert.Contains(t, report, "+ v1:ConfigMap:default:d")
+	assert.NotContains(t, report, "v1:ConfigMap:default:c")
+	assert.Contains(t, report, "Spec diff: 1 to add, 1 to change, 1 to delete")
+
+	report, err = specDiffReport(from, from, diffutil.OutputHuman)
+	assert.Nil(t, err)
+	assert.Equal(t, "No changes in spec\n", report)
+}
+
+func Test_loadSpec(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "spec.yaml")
+	assert.Nil(t, os.WriteFile(filename, []byte(oldSpec), 0o644))
+	resources, err := loadSpec(filename)
+	assert.Nil(t, err)
+	assert.Len(t, resources, 3)
+
+	defer func(f func(string) (*models.Spec, error)) { compileAtRef = f }(compileAtRef)
+	compileAtRef = func(ref string) (*models.Spec, error) {
+		return &models.Spec{Resources: models.Resources{{ID: ref}}}, nil
+	}
+	resources, err = loadSpec("main")
+	assert.Nil(t, err)
+	assert.Equal(t, "main", resources[0].ID)
+}
+
+func TestDiffOptions_validateSpec(t *testing.T) {
+	o := NewDiffOptions()
+	o.spec = true
+	o.diffMode = DiffModeNormal
+	o.outStyle = diffutil.OutputHuman
+	assert.Nil(t, o.Complete([]string{"old.yaml"}))
+	assert.Error(t, o.Validate())
+
+	assert.Nil(t, o.Complete([]string{"old.yaml", "new.yaml"}))
+	assert.Nil(t, o.Validate())
+}
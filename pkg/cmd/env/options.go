@@ -7,6 +7,8 @@ import (
 	"os"
 	"runtime"
 	"strings"
+
+	"kusionstack.io/kusion/pkg/engine/backend"
 )
 
 type EnvOptions struct {
@@ -26,6 +28,8 @@ func (o *EnvOptions) Validate() error {
 func (o *EnvOptions) Run() error {
 	env := []EnvVar{
 		{Name: "KUSION_PATH", Value: os.Getenv("KUSION_PATH")},
+		// KUSION_BACKEND_CONFIG is not printed since it may contain secrets
+		{Name: backend.EnvBackendType, Value: os.Getenv(backend.EnvBackendType)},
 	}
 
 	if o.envJSON {
@@ -0,0 +1,133 @@
+package init
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/scaffold"
+	"kusionstack.io/kusion/pkg/util/kube/config"
+)
+
+// exportNamespace reads live objects in the namespace of the cluster in the kubeconfig
+var exportNamespace = func(namespace string) ([]*unstructured.Unstructured, error) {
+	cfg, err := clientcmd.BuildConfigFromFlags("", config.GetKubeConfig())
+	if err != nil {
+		return nil, err
+	}
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// partial results are returned when some API groups are unavailable, e.g. metrics
+	resourceLists, err := dc.ServerPreferredNamespacedResources()
+	if err != nil && len(resourceLists) == 0 {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return scaffold.ExportNamespace(context.Background(), client, resourceLists, namespace)
+}
+
+// runFromCluster bootstraps a project with one stack from live objects in the namespace
+func (o *InitOptions) runFromCluster() error {
+	objects, err := exportNamespace(o.Namespace)
+	if err != nil {
+		return fmt.Errorf("read live objects in the namespace %s failed: %w", o.Namespace, err)
+	}
+	if len(objects) == 0 {
+		return fmt.Errorf("no object found in the namespace %s", o.Namespace)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting the working directory: %w", err)
+	}
+	desDir := filepath.Join(cwd, o.ProjectName)
+	if err = scaffold.GenerateFromObjects(desDir, &scaffold.ClusterProject{
+		ProjectName: o.ProjectName,
+		StackName:   o.StackName,
+		Format:      o.Format,
+		Force:       o.Force,
+	}, objects); err != nil {
+		return err
+	}
+
+	fmt.Printf("Created project '%s' with %d resources imported from the namespace %s\n", o.ProjectName, len(objects), o.Namespace)
+	return nil
+}
+
+// exportHelmRelease reads the Helm release in the namespace and its live objects from the cluster in the kubeconfig
+var exportHelmRelease = func(namespace, name string) (*scaffold.HelmRelease, []*unstructured.Unstructured, error) {
+	cfg, err := clientcmd.BuildConfigFromFlags("", config.GetKubeConfig())
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx := context.Background()
+	release, err := scaffold.ReadHelmRelease(ctx, client, namespace, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	groupResources, err := restmapper.GetAPIGroupResources(dc)
+	if err != nil {
+		return nil, nil, err
+	}
+	objects, err := scaffold.HelmReleaseObjects(ctx, client, restmapper.NewDiscoveryRESTMapper(groupResources), release)
+	if err != nil {
+		return nil, nil, err
+	}
+	return release, objects, nil
+}
+
+// runFromHelm bootstraps a project with one stack from the Helm release, with its live objects imported
+func (o *InitOptions) runFromHelm() error {
+	release, objects, err := exportHelmRelease(o.Namespace, o.FromHelm)
+	if err != nil {
+		return fmt.Errorf("read the release %s in the namespace %s failed: %w", o.FromHelm, o.Namespace, err)
+	}
+	if len(objects) == 0 {
+		return fmt.Errorf("no object of the release %s found in the cluster", o.FromHelm)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting the working directory: %w", err)
+	}
+	desDir := filepath.Join(cwd, o.ProjectName)
+	if err = scaffold.GenerateFromObjects(desDir, &scaffold.ClusterProject{
+		ProjectName: o.ProjectName,
+		StackName:   o.StackName,
+		Format:      o.Format,
+		Force:       o.Force,
+		Release:     release,
+		ChartRepo:   o.ChartRepo,
+	}, objects); err != nil {
+		return err
+	}
+
+	fmt.Printf("Created project '%s' with %d resources imported from the release %s (revision %d)\n",
+		o.ProjectName, len(objects), release.Name, release.Version)
+	if o.Format == scaffold.HelmFormat && o.ChartRepo == "" {
+		fmt.Printf("The repository of the chart %s is not recorded in the release, set it in %s\n",
+			release.Chart.Metadata.Name, projectstack.ProjectFile)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"k8s.io/kubectl/pkg/util/templates"
 
 	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/scaffold"
 	"kusionstack.io/kusion/pkg/util/i18n"
 )
 
@@ -19,6 +20,9 @@ var (
 		# Initialize a new KCL project from internal templates
 		kusion init
 
+		# Initialize a new project by answering questions about its runtime, workload, backend and environments
+		kusion init --interactive
+
 		# Initialize a new KCL project from external default templates location
 		kusion init --online=true
 
@@ -26,7 +30,16 @@ var (
 		kusion init https://github.com/<user>/<repo> --online=true
 
 		# Initialize a new KCL project from local directory
-		kusion init /path/to/templates`
+		kusion init /path/to/templates
+
+		# Initialize a new project from live objects in the namespace foo, with the objects imported into the state
+		kusion init --from-cluster --namespace foo
+
+		# Initialize a new project from live objects in the namespace foo as KCL code
+		kusion init --from-cluster --namespace foo --format kcl
+
+		# Initialize a new project from the Helm release nginx in the namespace foo, rendering its chart
+		kusion init --from-helm nginx --namespace foo --format helm --chart-repo https://charts.bitnami.com/bitnami`
 )
 
 func NewCmdInit() *cobra.Command {
@@ -61,5 +74,26 @@ func NewCmdInit() *cobra.Command {
 	cmd.Flags().StringVar(
 		&o.CustomParamsJSON, "custom-params", "",
 		i18n.T("Custom params in JSON string; if not empty, kusion will skip prompt process and use it as template default value"))
+	cmd.Flags().BoolVar(
+		&o.FromCluster, "from-cluster", false,
+		i18n.T("Bootstrap the project from live objects in the namespace of the cluster instead of templates"))
+	cmd.Flags().StringVar(
+		&o.Namespace, "namespace", "",
+		i18n.T("The namespace to read live objects or the Helm release from with --from-cluster or --from-helm"))
+	cmd.Flags().StringVar(
+		&o.StackName, "stack-name", "dev",
+		i18n.T("The stack name with --from-cluster or --from-helm"))
+	cmd.Flags().StringVar(
+		&o.Format, "format", scaffold.ManifestFormat,
+		i18n.T("The format of the stack content with --from-cluster or --from-helm, one of manifest and kcl, or helm with --from-helm"))
+	cmd.Flags().StringVar(
+		&o.FromHelm, "from-helm", "",
+		i18n.T("Bootstrap the project from the Helm release in the namespace instead of templates, with its objects imported into the state"))
+	cmd.Flags().StringVar(
+		&o.ChartRepo, "chart-repo", "",
+		i18n.T("The repository of the chart of the Helm release with --from-helm --format helm"))
+	cmd.Flags().BoolVarP(
+		&o.Interactive, "interactive", "i", false,
+		i18n.T("Prompt for the runtime, workload type, backend and environments of the project instead of choosing a template"))
 	return cmd
 }
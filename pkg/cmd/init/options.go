@@ -22,6 +22,20 @@ type InitOptions struct {
 	Force             bool
 	Yes               bool
 	CustomParamsJSON  string
+
+	// FromCluster bootstraps the project from live objects in the Namespace instead of templates
+	FromCluster bool
+	Namespace   string
+	StackName   string
+	Format      string
+
+	// FromHelm is the Helm release in the Namespace to bootstrap the project from instead of templates
+	FromHelm  string
+	ChartRepo string
+
+	// Interactive prompts for the runtime, the workload type, the backend and environments of the project
+	// instead of choosing a template
+	Interactive bool
 }
 
 func NewInitOptions() *InitOptions {
@@ -29,6 +43,21 @@ func NewInitOptions() *InitOptions {
 }
 
 func (o *InitOptions) Complete(args []string) error {
+	if o.Interactive {
+		return nil
+	}
+	if o.FromCluster {
+		if o.ProjectName == "" {
+			o.ProjectName = o.Namespace
+		}
+		return nil
+	}
+	if o.FromHelm != "" {
+		if o.ProjectName == "" {
+			o.ProjectName = o.FromHelm
+		}
+		return nil
+	}
 	if o.Online { // use online templates, official link or user-specified link
 		if len(args) > 0 {
 			// user-specified link
@@ -51,6 +80,40 @@ func (o *InitOptions) Complete(args []string) error {
 }
 
 func (o *InitOptions) Validate() error {
+	if o.Interactive {
+		if o.FromCluster || o.FromHelm != "" || o.Online || o.Yes || o.CustomParamsJSON != "" {
+			return errors.New("--interactive can't be used with --from-cluster, --from-helm, --online, --yes or --custom-params")
+		}
+		return nil
+	}
+	if o.FromCluster && o.FromHelm != "" {
+		return errors.New("--from-helm and --from-cluster can't be specified together")
+	}
+	if o.FromCluster {
+		if o.Namespace == "" {
+			return errors.New("--namespace is required with --from-cluster")
+		}
+		if o.Format != scaffold.ManifestFormat && o.Format != scaffold.KCLFormat {
+			return fmt.Errorf("unsupported format %s, must be %s or %s", o.Format, scaffold.ManifestFormat, scaffold.KCLFormat)
+		}
+		if err := scaffold.ValidateProjectName(o.ProjectName); err != nil {
+			return fmt.Errorf("'%s' is not a valid project name as [%v]", o.ProjectName, err)
+		}
+		return nil
+	}
+	if o.FromHelm != "" {
+		if o.Namespace == "" {
+			return errors.New("--namespace is required with --from-helm")
+		}
+		if o.Format != scaffold.ManifestFormat && o.Format != scaffold.KCLFormat && o.Format != scaffold.HelmFormat {
+			return fmt.Errorf("unsupported format %s, must be %s, %s or %s",
+				o.Format, scaffold.ManifestFormat, scaffold.KCLFormat, scaffold.HelmFormat)
+		}
+		if err := scaffold.ValidateProjectName(o.ProjectName); err != nil {
+			return fmt.Errorf("'%s' is not a valid project name as [%v]", o.ProjectName, err)
+		}
+		return nil
+	}
 	if o.Online {
 		return nil
 	}
@@ -70,6 +133,16 @@ func (o *InitOptions) Validate() error {
 }
 
 func (o *InitOptions) Run() error {
+	if o.Interactive {
+		return o.runWizard()
+	}
+	if o.FromCluster {
+		return o.runFromCluster()
+	}
+	if o.FromHelm != "" {
+		return o.runFromHelm()
+	}
+
 	// Retrieve the template repo.
 	repo, err := scaffold.RetrieveTemplates(o.TemplateNameOrURL, o.Online)
 	if err != nil {
@@ -284,7 +357,7 @@ func promptValue(valueType string, description string, defaultValue string, isVa
 
 	for {
 		// you can pass multiple validators here and survey will make sure each one passes
-		err = survey.AskOne(prompt, &value)
+		err = askOne(prompt, &value)
 		if err != nil {
 			return "", err
 		}
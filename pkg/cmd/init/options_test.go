@@ -6,12 +6,14 @@ package init
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
 	"bou.ke/monkey"
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"kusionstack.io/kusion/pkg/scaffold"
 )
@@ -148,3 +150,66 @@ func TestPromptValue(t *testing.T) {
 		assert.Equal(t, defaultValue, got)
 	})
 }
+
+func TestInitOptions_fromCluster(t *testing.T) {
+	o := NewInitOptions()
+	o.FromCluster = true
+	o.Format = scaffold.ManifestFormat
+	assert.Nil(t, o.Complete(nil))
+	assert.ErrorContains(t, o.Validate(), "--namespace is required")
+
+	o.Namespace = "foo"
+	assert.Nil(t, o.Complete(nil))
+	assert.Equal(t, "foo", o.ProjectName)
+	assert.Nil(t, o.Validate())
+
+	o.Format = "helm"
+	assert.ErrorContains(t, o.Validate(), "unsupported format helm")
+}
+
+func TestInitOptions_fromHelm(t *testing.T) {
+	o := NewInitOptions()
+	o.FromHelm = "web"
+	o.Format = scaffold.HelmFormat
+	assert.Nil(t, o.Complete(nil))
+	assert.Equal(t, "web", o.ProjectName)
+	assert.ErrorContains(t, o.Validate(), "--namespace is required")
+
+	o.Namespace = "foo"
+	assert.Nil(t, o.Validate())
+
+	o.FromCluster = true
+	assert.ErrorContains(t, o.Validate(), "can't be specified together")
+	o.FromCluster = false
+
+	o.Format = "cue"
+	assert.ErrorContains(t, o.Validate(), "unsupported format cue")
+}
+
+func TestInitOptions_runFromHelm(t *testing.T) {
+	release := &scaffold.HelmRelease{Name: "web", Namespace: "foo", Version: 3}
+	objects := []*unstructured.Unstructured{{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "foo"},
+	}}}
+	origin := exportHelmRelease
+	defer func() { exportHelmRelease = origin }()
+	exportHelmRelease = func(namespace, name string) (*scaffold.HelmRelease, []*unstructured.Unstructured, error) {
+		return release, objects, nil
+	}
+
+	dir := t.TempDir()
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	assert.Nil(t, os.Chdir(dir))
+
+	o := NewInitOptions()
+	o.FromHelm = "web"
+	o.Namespace = "foo"
+	o.StackName = "dev"
+	o.Format = scaffold.ManifestFormat
+	assert.Nil(t, o.Complete(nil))
+	assert.Nil(t, o.Run())
+	assert.FileExists(t, filepath.Join(dir, "web", "dev", "manifests", "service-web.yaml"))
+}
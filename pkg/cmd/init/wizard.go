@@ -0,0 +1,148 @@
+package init
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/pterm/pterm"
+
+	"kusionstack.io/kusion/pkg/engine/backend"
+	"kusionstack.io/kusion/pkg/scaffold"
+)
+
+// askOne asks a question of survey, which is replaced in tests
+var askOne = survey.AskOne
+
+// runWizard prompts for the project name, the runtime, the workload type, the backend and environments, and
+// generates the project with a stack of each environment
+func (o *InitOptions) runWizard() error {
+	pterm.Println("This command will walk you through creating a new kusion project.")
+	pterm.Printfln("Press %s at any time to quit.", pterm.Cyan("^C"))
+	pterm.Println()
+
+	p := &scaffold.WizardProject{Force: o.Force}
+	var err error
+
+	defaultName := o.ProjectName
+	if defaultName == "" {
+		defaultName = "my-app"
+	}
+	if p.ProjectName, err = promptValue("Project Name", "The name of the project, as well as its directory",
+		defaultName, scaffold.ValidateProjectName); err != nil {
+		return err
+	}
+
+	if p.Runtime, err = promptSelect("Target runtime:",
+		"kubernetes deploys workloads to clusters, terraform manages cloud resources by Terraform providers",
+		[]string{scaffold.RuntimeKubernetes, scaffold.RuntimeTerraform}, scaffold.RuntimeKubernetes); err != nil {
+		return err
+	}
+	if p.Runtime == scaffold.RuntimeKubernetes {
+		if p.Workload, err = promptSelect("Workload type:",
+			"service is a long-running Deployment with a Service, job runs once, and cronjob runs on a schedule",
+			[]string{scaffold.WorkloadService, scaffold.WorkloadJob, scaffold.WorkloadCronJob}, scaffold.WorkloadService); err != nil {
+			return err
+		}
+	}
+
+	if p.Backend, err = promptBackend(); err != nil {
+		return err
+	}
+
+	environments, err := promptValue("Environments", "Comma separated environments, each of which is a stack",
+		"dev,prod", validateEnvironments)
+	if err != nil {
+		return err
+	}
+	p.Environments = splitEnvironments(environments)
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting the working directory: %w", err)
+	}
+	if err = scaffold.GenerateWizardProject(filepath.Join(cwd, p.ProjectName), p); err != nil {
+		return err
+	}
+
+	fmt.Printf("Created project '%s' with stacks %s\n", p.ProjectName, strings.Join(p.Environments, ", "))
+	if p.Backend != nil {
+		fmt.Printf("Set credentials of the %s backend in the environment variable KUSION_BACKEND_CONFIG or a credential helper\n",
+			p.Backend.Type)
+	}
+	fmt.Printf("Run `cd %s && kusion preview` to see what will be applied\n", filepath.Join(p.ProjectName, p.Environments[0]))
+	return nil
+}
+
+// promptBackend prompts for the type of the backend and its config fields without credentials, and returns nil
+// for the local backend
+func promptBackend() (*backend.Storage, error) {
+	types := make([]string, 0, len(scaffold.BackendFields))
+	for t := range scaffold.BackendFields {
+		if t != scaffold.LocalBackend {
+			types = append(types, t)
+		}
+	}
+	sort.Strings(types)
+	types = append([]string{scaffold.LocalBackend}, types...)
+
+	t, err := promptSelect("Backend:", "The backend storing states of stacks, local stores them in stack directories",
+		types, scaffold.LocalBackend)
+	if err != nil || t == scaffold.LocalBackend {
+		return nil, err
+	}
+	storage := &backend.Storage{Type: t, Config: map[string]interface{}{}}
+	for _, field := range scaffold.BackendFields[t] {
+		value, err := promptValue(field, fmt.Sprintf("The %s of the %s backend, leave blank to set it later", field, t), "", nil)
+		if err != nil {
+			return nil, err
+		}
+		if value == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(value); err == nil && field == "dbPort" {
+			storage.Config[field] = n
+		} else {
+			storage.Config[field] = value
+		}
+	}
+	return storage, nil
+}
+
+func promptSelect(message, help string, options []string, defaultValue string) (value string, err error) {
+	prompt := &survey.Select{
+		Message: pterm.Cyan(message),
+		Help:    help,
+		Options: options,
+		Default: defaultValue,
+	}
+	err = askOne(prompt, &value)
+	return value, err
+}
+
+func splitEnvironments(value string) []string {
+	var environments []string
+	for _, env := range strings.Split(value, ",") {
+		if env = strings.TrimSpace(env); env != "" {
+			environments = append(environments, env)
+		}
+	}
+	return environments
+}
+
+func validateEnvironments(value string) error {
+	environments := splitEnvironments(value)
+	if len(environments) == 0 {
+		return fmt.Errorf("at least one environment is required")
+	}
+	for _, env := range environments {
+		if err := scaffold.ValidateEnvironment(env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
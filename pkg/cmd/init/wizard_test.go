@@ -0,0 +1,89 @@
+package init
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/pterm/pterm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// mockAskOne answers prompts by their messages, and accepts defaults of other prompts
+func mockAskOne(t *testing.T, answers map[string]string) {
+	old := askOne
+	askOne = func(p survey.Prompt, response interface{}, _ ...survey.AskOpt) error {
+		var message, defaultValue string
+		switch prompt := p.(type) {
+		case *survey.Input:
+			message, defaultValue = prompt.Message, prompt.Default
+		case *survey.Select:
+			message = prompt.Message
+			defaultValue, _ = prompt.Default.(string)
+		}
+		message = pterm.RemoveColorFromString(message)
+		answer, ok := answers[message]
+		if !ok {
+			answer = defaultValue
+		}
+		reflect.ValueOf(response).Elem().Set(reflect.ValueOf(answer))
+		return nil
+	}
+	t.Cleanup(func() { askOne = old })
+}
+
+func chdir(t *testing.T, dir string) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(cwd) })
+}
+
+func TestInitOptions_Interactive(t *testing.T) {
+	chdir(t, t.TempDir())
+	mockAskOne(t, map[string]string{
+		"Project Name:":  "shop",
+		"Workload type:": "cronjob",
+		"Backend:":       "db",
+		"dbHost:":        "mysql.example.com",
+		"dbPort:":        "3306",
+		"Environments:":  "dev, staging",
+	})
+
+	o := NewInitOptions()
+	o.Interactive = true
+	require.NoError(t, o.Complete(nil))
+	require.NoError(t, o.Validate())
+	require.NoError(t, o.Run())
+
+	project, err := projectstack.GetProjectFrom("shop")
+	require.NoError(t, err)
+	assert.Equal(t, "db", project.Backend.Type)
+	assert.Equal(t, map[string]interface{}{"dbHost": "mysql.example.com", "dbPort": 3306}, project.Backend.Config)
+	for _, env := range []string{"dev", "staging"} {
+		main, err := os.ReadFile(filepath.Join("shop", env, "main.k"))
+		require.NoError(t, err)
+		assert.Contains(t, string(main), `kind = "CronJob"`)
+	}
+}
+
+func TestInitOptions_InteractiveCanceled(t *testing.T) {
+	chdir(t, t.TempDir())
+	old := askOne
+	askOne = func(survey.Prompt, interface{}, ...survey.AskOpt) error { return errors.New("interrupt") }
+	t.Cleanup(func() { askOne = old })
+
+	o := &InitOptions{Interactive: true}
+	assert.EqualError(t, o.Run(), "interrupt")
+}
+
+func TestInitOptions_ValidateInteractive(t *testing.T) {
+	o := &InitOptions{Interactive: true, FromCluster: true}
+	assert.ErrorContains(t, o.Validate(), "--interactive can't be used")
+}
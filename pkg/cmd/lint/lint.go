@@ -0,0 +1,78 @@
+package lint
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	lintShort = "Lint resources of a stack against conventions"
+
+	lintLong = `
+		Lint resources compiled from the stack in the work directory against conventions before previewing
+		or applying them.
+
+		Built-in rules check Kubernetes resources:
+		  - required-labels: labels of resources and pod templates, param labels
+		  - naming-convention: names of resources, params pattern and maxLength
+		  - image-tag: tags of container images, params disallowedTags and requireDigest
+		  - replica-minimum: replicas of Deployments, StatefulSets and ReplicaSets, param min
+
+		Rules are disabled and parametrized, and custom rules are written in CEL in the config file, which is
+		lint.yaml in the project directory by default. Findings whose severities reach the threshold of the
+		config file fail the lint, and the others are printed as warnings.`
+
+	lintExample = `
+		# Lint the stack in the work directory
+		kusion lint -w appops/demo/dev
+
+		# Lint with the config file
+		kusion lint --config lint.yaml
+
+		# Lint in CI and write findings as a SARIF log for code scanning
+		kusion lint --sarif kusion.sarif
+
+		# The config file disabling, parametrizing and adding rules
+		threshold: medium
+		rules:
+		  naming-convention:
+		    disabled: true
+		  replica-minimum:
+		    severity: high
+		    params:
+		      min: 3
+		celRules:
+		  - name: no-node-port
+		    expression: "object.kind != 'Service' || !has(object.spec.type) || object.spec.type != 'NodePort'"
+		    message: NodePort services are not allowed`
+)
+
+func NewCmdLint() *cobra.Command {
+	o := NewLintOptions()
+
+	cmd := &cobra.Command{
+		Use:     "lint",
+		Short:   i18n.T(lintShort),
+		Long:    templates.LongDesc(i18n.T(lintLong)),
+		Example: templates.Examples(i18n.T(lintExample)),
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			defer util.RecoverErr(&err)
+			o.Complete(args)
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+			return
+		},
+	}
+
+	o.AddCompileFlags(cmd)
+
+	cmd.Flags().StringVarP(&o.Config, "config", "", "",
+		i18n.T("Specify the config file of rules, defaults to lint.yaml in the project directory"))
+	cmd.Flags().StringVarP(&o.SARIF, "sarif", "", "",
+		i18n.T("Specify the file to write findings to as a SARIF log, which code scanning shows on pull requests"))
+
+	return cmd
+}
@@ -0,0 +1,115 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/pterm/pterm"
+
+	"kusionstack.io/kusion/pkg/cmd/compile"
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/lint"
+	"kusionstack.io/kusion/pkg/policy"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/util/pretty"
+	"kusionstack.io/kusion/pkg/util/sarif"
+	"kusionstack.io/kusion/pkg/version"
+)
+
+type LintOptions struct {
+	compile.CompileOptions
+
+	// Config is the config file of rules, lint.yaml in the project directory is used if it is empty
+	Config string
+
+	// SARIF is the file to write findings to as a SARIF log
+	SARIF string
+}
+
+func NewLintOptions() *LintOptions {
+	return &LintOptions{CompileOptions: *compile.NewCompileOptions()}
+}
+
+func (o *LintOptions) Validate() error {
+	if o.AllStacks || o.Watch {
+		return errors.New("--all and --watch can not be used with kusion lint")
+	}
+	return o.CompileOptions.Validate()
+}
+
+func (o *LintOptions) Run() error {
+	// Parse project and stack of work directory
+	project, stack, err := projectstack.DetectProjectAndStack(o.WorkDir)
+	if err != nil {
+		return err
+	}
+	config, err := o.loadConfig(project)
+	if err != nil {
+		return err
+	}
+
+	sp, err := spec.GenerateSpecWithSpinner(o.GeneratorOptions(), project, stack)
+	if err != nil {
+		return err
+	}
+	input := &policy.Input{Project: project.Name, Stack: stack.Name}
+	if sp != nil {
+		input.Resources = sp.Resources
+	}
+
+	result, err := (&lint.Linter{Config: config}).Check(input)
+	if err != nil {
+		return err
+	}
+	if o.SARIF != "" {
+		if err = writeSARIF(o.SARIF, sarif.EntryFile(stack.Path, o.Filenames), result); err != nil {
+			return err
+		}
+	}
+
+	for _, w := range result.Warnings {
+		pretty.Warning.Printfln("Lint: %s", w)
+	}
+	if len(result.Denies) == 0 {
+		pterm.Success.Printfln("%d resource(s) passed the lint", len(input.Resources))
+		return nil
+	}
+	for _, d := range result.Denies {
+		pterm.Error.Printfln("Lint: %s", d)
+	}
+	return util.NewExitError(util.ExitCodePolicyViolation,
+		fmt.Errorf("lint failed, %d finding(s) reach the severity threshold", len(result.Denies)))
+}
+
+// loadConfig reads the config file of --config, or lint.yaml in the project directory if it exists. All rules
+// are enabled with default params without config files.
+func (o *LintOptions) loadConfig(project *projectstack.Project) (*lint.Config, error) {
+	path := o.Config
+	if path == "" {
+		path = filepath.Join(project.Path, projectstack.LintFile)
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+	}
+	return lint.LoadConfig(path)
+}
+
+// writeSARIF writes findings as a SARIF log, where denies are errors, and warnings of the low severity are notes
+func writeSARIF(path, file string, result *policy.Result) error {
+	var results []sarif.Result
+	for _, d := range result.Denies {
+		results = append(results, sarif.NewResult(d.Rule, sarif.LevelError, d.String(), file, d.Resource))
+	}
+	for _, w := range result.Warnings {
+		level := sarif.LevelWarning
+		if w.Severity == policy.SeverityLow {
+			level = sarif.LevelNote
+		}
+		results = append(results, sarif.NewResult(w.Rule, level, w.String(), file, w.Resource))
+	}
+	return sarif.Write(sarif.NewLog(version.ReleaseVersion(), results), path)
+}
@@ -0,0 +1,93 @@
+package lint
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+var deployment = models.Resource{
+	ID:   "apps/v1:Deployment:default:app",
+	Type: runtime.Kubernetes,
+	Attributes: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":   "app",
+			"labels": map[string]interface{}{"app.kubernetes.io/name": "app"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": 1,
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app.kubernetes.io/name": "app"}},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"name": "main", "image": "nginx:1.23"}},
+				},
+			},
+		},
+	},
+}
+
+func mockProjectAndSpec(t *testing.T, projectDir string) {
+	monkey.Patch(projectstack.DetectProjectAndStack, func(stackDir string) (*projectstack.Project, *projectstack.Stack, error) {
+		return &projectstack.Project{ProjectConfiguration: projectstack.ProjectConfiguration{Name: "demo"}, Path: projectDir},
+			&projectstack.Stack{StackConfiguration: projectstack.StackConfiguration{Name: "dev"}, Path: filepath.Join(projectDir, "dev")}, nil
+	})
+	monkey.Patch(spec.GenerateSpecWithSpinner, func(*generator.Options, *projectstack.Project, *projectstack.Stack) (*models.Spec, error) {
+		return &models.Spec{Resources: models.Resources{deployment}}, nil
+	})
+	t.Cleanup(monkey.UnpatchAll)
+}
+
+func TestLintOptions_Run(t *testing.T) {
+	t.Run("findings fail the lint", func(t *testing.T) {
+		mockProjectAndSpec(t, t.TempDir())
+		o := NewLintOptions()
+		o.SARIF = filepath.Join(t.TempDir(), "kusion.sarif")
+		err := o.Run()
+		var exitErr *util.ExitError
+		assert.True(t, errors.As(err, &exitErr))
+		assert.Equal(t, util.ExitCodePolicyViolation, exitErr.Code)
+		assert.EqualError(t, err, "lint failed, 1 finding(s) reach the severity threshold")
+
+		data, err := os.ReadFile(o.SARIF)
+		assert.Nil(t, err)
+		assert.Contains(t, string(data), `"ruleId": "replica-minimum"`)
+	})
+
+	t.Run("config in the project directory", func(t *testing.T) {
+		projectDir := t.TempDir()
+		mockProjectAndSpec(t, projectDir)
+		assert.Nil(t, os.WriteFile(filepath.Join(projectDir, projectstack.LintFile), []byte(`rules:
+  replica-minimum:
+    params:
+      min: 1
+`), 0o644))
+		assert.Nil(t, NewLintOptions().Run())
+	})
+
+	t.Run("missing config", func(t *testing.T) {
+		mockProjectAndSpec(t, t.TempDir())
+		o := NewLintOptions()
+		o.Config = filepath.Join(t.TempDir(), "lint.yaml")
+		assert.ErrorContains(t, o.Run(), "read the lint config")
+	})
+}
+
+func TestLintOptions_Validate(t *testing.T) {
+	o := NewLintOptions()
+	assert.Nil(t, o.Validate())
+	o.Watch = true
+	assert.EqualError(t, o.Validate(), "--all and --watch can not be used with kusion lint")
+}
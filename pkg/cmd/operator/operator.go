@@ -0,0 +1,64 @@
+package operator
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	operatorShort = "Run the operator which applies Stack resources continuously"
+
+	operatorLong = `
+		Run the Kusion operator, which watches Stack resources of the kusionstack.io group in the cluster.
+		Each Stack points at a stack in a git repository, which is fetched, compiled and applied in the
+		cluster, then applied again after the interval of the Stack to pick up new commits and correct
+		drifts. Results are reported by the Ready condition and events of the Stack.
+
+		The operator runs in the cluster with the in-cluster config, or out of the cluster with the
+		current kube config. Manifests of the CRD and the deployment are in deploy/operator.
+
+		Projects of stacks applied by the operator should use remote backends, since clones of
+		repositories are not persistent.`
+
+	operatorExample = `
+		# Run the operator on stacks in all namespaces
+		kusion operator
+
+		# Run the operator on stacks in the namespace, with leader election
+		kusion operator --namespace kusion-system --leader-elect`
+)
+
+func NewCmdOperator() *cobra.Command {
+	o := NewOperatorOptions()
+
+	cmd := &cobra.Command{
+		Use:     "operator",
+		Short:   i18n.T(operatorShort),
+		Long:    templates.LongDesc(i18n.T(operatorLong)),
+		Example: templates.Examples(i18n.T(operatorExample)),
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			defer util.RecoverErr(&err)
+			o.Complete(args)
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+			return
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.CacheDir, "cache-dir", "", "",
+		i18n.T("Specify the directory to keep clones of repositories in, a temporary directory by default"))
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", "",
+		i18n.T("Specify the namespace of stacks to reconcile, all namespaces by default"))
+	cmd.Flags().StringVarP(&o.MetricsAddr, "operator-metrics-addr", "", ":8080",
+		i18n.T("Specify the address to serve metrics of the operator at, 0 to disable it"))
+	cmd.Flags().StringVarP(&o.HealthAddr, "health-addr", "", ":8081",
+		i18n.T("Specify the address to serve health probes at, 0 to disable it"))
+	cmd.Flags().BoolVarP(&o.LeaderElect, "leader-elect", "", false,
+		i18n.T("Enable leader election, so that only one replica of the operator applies stacks"))
+
+	return cmd
+}
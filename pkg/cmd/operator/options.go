@@ -0,0 +1,36 @@
+package operator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"kusionstack.io/kusion/pkg/operator"
+)
+
+type OperatorOptions struct {
+	operator.Options
+}
+
+func NewOperatorOptions() *OperatorOptions {
+	return &OperatorOptions{}
+}
+
+func (o *OperatorOptions) Complete(_ []string) {
+	if o.CacheDir == "" {
+		o.CacheDir = filepath.Join(os.TempDir(), "kusion-operator")
+	}
+}
+
+func (o *OperatorOptions) Validate() error {
+	if info, err := os.Stat(o.CacheDir); err == nil && !info.IsDir() {
+		return fmt.Errorf("invalid cache directory: %s is not a directory", o.CacheDir)
+	}
+	return nil
+}
+
+func (o *OperatorOptions) Run() error {
+	return operator.Run(ctrl.SetupSignalHandler(), &o.Options)
+}
@@ -0,0 +1,22 @@
+package operator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperatorOptions_Validate(t *testing.T) {
+	o := NewOperatorOptions()
+	o.Complete(nil)
+	assert.Equal(t, filepath.Join(os.TempDir(), "kusion-operator"), o.CacheDir)
+	assert.NoError(t, o.Validate())
+
+	file := filepath.Join(t.TempDir(), "cache")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+	o.CacheDir = file
+	assert.ErrorContains(t, o.Validate(), "is not a directory")
+}
@@ -0,0 +1,108 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"kusionstack.io/kusion/pkg/engine/backend"
+	_ "kusionstack.io/kusion/pkg/engine/backend/init"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// Output formats of outputs
+const (
+	YAMLOutput = "yaml"
+	JSONOutput = "json"
+)
+
+type OutputOptions struct {
+	WorkDir string
+	Output  string
+	Name    string
+	backend.BackendOps
+}
+
+func NewOutputOptions() *OutputOptions {
+	return &OutputOptions{Output: YAMLOutput}
+}
+
+func (o *OutputOptions) Complete(args []string) {
+	if len(args) > 0 {
+		o.Name = args[0]
+	}
+	if o.WorkDir == "" {
+		o.WorkDir, _ = os.Getwd()
+	}
+}
+
+func (o *OutputOptions) Validate() error {
+	if o.Output != YAMLOutput && o.Output != JSONOutput {
+		return fmt.Errorf("invalid output %s, must be %s or %s", o.Output, YAMLOutput, JSONOutput)
+	}
+	return nil
+}
+
+func (o *OutputOptions) Run() error {
+	project, stack, err := projectstack.DetectProjectAndStack(o.WorkDir)
+	if err != nil {
+		return err
+	}
+	storage, err := backend.BackendFromConfig(project.Backend, o.BackendOps, o.WorkDir)
+	if err != nil {
+		return err
+	}
+	state, err := storage.GetLatestState(&states.StateQuery{
+		Tenant:  project.Tenant,
+		Project: project.Name,
+		Stack:   stack.Name,
+	})
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("no state found for the stack %s", stack.Name)
+	}
+
+	out, err := o.format(state.Outputs)
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
+
+// format returns the outputs, or the value of the output if its name is specified, in the output format.
+// Values of strings are returned as is in the YAML format.
+func (o *OutputOptions) format(outputs map[string]interface{}) (string, error) {
+	var value interface{} = outputs
+	if outputs == nil {
+		value = map[string]interface{}{}
+	}
+	if o.Name != "" {
+		v, ok := outputs[o.Name]
+		if !ok {
+			return "", fmt.Errorf("output %s not found in the state, which is evaluated after applies", o.Name)
+		}
+		if s, isString := v.(string); isString && o.Output == YAMLOutput {
+			return s + "\n", nil
+		}
+		value = v
+	}
+
+	if o.Output == JSONOutput {
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+	}
+	data, err := yamlv3.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
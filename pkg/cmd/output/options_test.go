@@ -0,0 +1,77 @@
+package output
+
+import (
+	"path/filepath"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/engine/states/local"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func TestOutputOptions_Validate(t *testing.T) {
+	o := NewOutputOptions()
+	assert.Nil(t, o.Validate())
+	o.Output = "table"
+	assert.NotNil(t, o.Validate())
+}
+
+func TestOutputOptions_format(t *testing.T) {
+	outputs := map[string]interface{}{"ip": "1.2.3.4", "ports": []interface{}{80, 443}}
+
+	t.Run("all outputs", func(t *testing.T) {
+		o := NewOutputOptions()
+		got, err := o.format(outputs)
+		assert.Nil(t, err)
+		assert.Equal(t, "ip: 1.2.3.4\nports:\n    - 80\n    - 443\n", got)
+
+		o.Output = JSONOutput
+		got, err = o.format(nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "{}\n", got)
+	})
+
+	t.Run("output of the name", func(t *testing.T) {
+		o := NewOutputOptions()
+		o.Name = "ip"
+		got, err := o.format(outputs)
+		assert.Nil(t, err)
+		assert.Equal(t, "1.2.3.4\n", got)
+
+		o.Output = JSONOutput
+		got, err = o.format(outputs)
+		assert.Nil(t, err)
+		assert.Equal(t, "\"1.2.3.4\"\n", got)
+	})
+
+	t.Run("output not found", func(t *testing.T) {
+		o := NewOutputOptions()
+		o.Name = "missing"
+		_, err := o.format(outputs)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestOutputOptions_Run(t *testing.T) {
+	dir := t.TempDir()
+	monkey.Patch(projectstack.DetectProjectAndStack, func(stackDir string) (*projectstack.Project, *projectstack.Stack, error) {
+		return &projectstack.Project{ProjectConfiguration: projectstack.ProjectConfiguration{Name: "project"}},
+			&projectstack.Stack{StackConfiguration: projectstack.StackConfiguration{Name: "dev"}}, nil
+	})
+	defer monkey.UnpatchAll()
+
+	o := NewOutputOptions()
+	o.WorkDir = dir
+	assert.NotNil(t, o.Run())
+
+	state := states.NewState()
+	state.Project = "project"
+	state.Stack = "dev"
+	state.Outputs = map[string]interface{}{"ip": "1.2.3.4"}
+	assert.Nil(t, (&local.FileSystemState{Path: filepath.Join(dir, local.KusionState)}).Apply(state))
+	o.Name = "ip"
+	assert.Nil(t, o.Run())
+}
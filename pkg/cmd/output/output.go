@@ -0,0 +1,59 @@
+package output
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	outputShort = "Show outputs of a stack"
+
+	outputLong = `
+		Show outputs in the latest state of the stack in the work directory.
+
+		Outputs are declared in the outputs of stack.yaml, each of which extracts a value from a resource
+		by a JSONPath, and are evaluated on the applied resources after each apply.
+
+		With the name of an output, only its value is shown, which is printed as is if it is a string,
+		so that it can be used in scripts.`
+
+	outputExample = `
+		# Show all outputs of the current stack
+		kusion output
+
+		# Show all outputs in JSON format
+		kusion output -o json
+
+		# Show the value of the output
+		curl http://$(kusion output ip)`
+)
+
+func NewCmdOutput() *cobra.Command {
+	o := NewOutputOptions()
+
+	cmd := &cobra.Command{
+		Use:     "output [NAME]",
+		Short:   i18n.T(outputShort),
+		Long:    templates.LongDesc(i18n.T(outputLong)),
+		Example: templates.Examples(i18n.T(outputExample)),
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			defer util.RecoverErr(&err)
+			o.Complete(args)
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+			return
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.WorkDir, "workdir", "w", "",
+		i18n.T("Specify the work directory"))
+	cmd.Flags().StringVarP(&o.Output, "output", "o", YAMLOutput,
+		i18n.T("Specify the output format, yaml or json"))
+	o.AddBackendFlags(cmd)
+
+	return cmd
+}
@@ -0,0 +1,43 @@
+package plugin
+
+import (
+	"kusionstack.io/kusion/pkg/engine/backend"
+	"kusionstack.io/kusion/pkg/engine/states/local"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/version"
+)
+
+// Context is passed to plugins in JSON on their stdin, so that they know the stack they are run in
+type Context struct {
+	// Version is the version of kusion running the plugin
+	Version string `json:"version"`
+
+	// WorkDir is the work directory of the command
+	WorkDir string `json:"workDir"`
+
+	// Project and Stack are the ones of the work directory, which are omitted outside stacks
+	Project *projectstack.Project `json:"project,omitempty"`
+	Stack   *projectstack.Stack   `json:"stack,omitempty"`
+
+	// Backend is the backend config of the project, which defaults to the local state file of the stack.
+	// Placeholders of environment variables are not replaced, so that secrets are not passed to plugins.
+	Backend *backend.Storage `json:"backend,omitempty"`
+}
+
+// NewContext returns the context of the work directory
+func NewContext(workDir string) *Context {
+	c := &Context{
+		Version: version.ReleaseVersion(),
+		WorkDir: workDir,
+	}
+	project, stack, err := projectstack.DetectProjectAndStack(workDir)
+	if err != nil {
+		return c
+	}
+	c.Project, c.Stack = project, stack
+	c.Backend = project.Backend
+	if c.Backend == nil {
+		c.Backend = backend.NewDefaultBackend(stack.Path, local.KusionState)
+	}
+	return c
+}
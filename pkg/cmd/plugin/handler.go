@@ -0,0 +1,123 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Prefix is the prefix of names of plugin executables, e.g. kusion-foo is run by `kusion foo`
+const Prefix = "kusion-"
+
+// Annotation marks commands that run plugins, whose names are not reported by telemetry
+const Annotation = "kusionstack.io/plugin"
+
+// Handler looks up and executes plugins
+type Handler interface {
+	// Lookup returns the path of the plugin executable of the name without the prefix, and whether it is found
+	Lookup(name string) (string, bool)
+
+	// Execute runs the plugin with the arguments, passing the context in JSON on its stdin
+	Execute(path string, args []string, context *Context) error
+}
+
+// DefaultHandler looks up plugins on $PATH
+type DefaultHandler struct{}
+
+var _ Handler = (*DefaultHandler)(nil)
+
+func (h *DefaultHandler) Lookup(name string) (string, bool) {
+	path, err := exec.LookPath(Prefix + name)
+	if err != nil || path == "" {
+		return "", false
+	}
+	return path, true
+}
+
+func (h *DefaultHandler) Execute(path string, args []string, context *Context) error {
+	data, err := json.Marshal(context)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = bytes.NewReader(append(data, '\n'))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	return cmd.Run()
+}
+
+// Find returns the path of the plugin run by the command line, i.e. the executable whose name is the prefix
+// followed by the longest leading arguments joined by dashes, along with the number of arguments in its name.
+// Arguments from the first flag are not part of names.
+func Find(handler Handler, args []string) (string, int, bool) {
+	var pieces []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			break
+		}
+		// dashes in names of plugins are typed as dashes or underscores
+		pieces = append(pieces, strings.ReplaceAll(arg, "-", "_"))
+	}
+	for n := len(pieces); n > 0; n-- {
+		if path, ok := handler.Lookup(strings.Join(pieces[:n], "-")); ok {
+			return path, n, true
+		}
+	}
+	return "", 0, false
+}
+
+// List returns paths of plugin executables in the directories of $PATH sorted by names. Executables shadowed
+// by ones of the same names in earlier directories are skipped.
+func List() []string {
+	seen := map[string]bool{}
+	var paths []string
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasPrefix(name, Prefix) || seen[name] || !isExecutable(filepath.Join(dir, name)) {
+				continue
+			}
+			seen[name] = true
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return filepath.Base(paths[i]) < filepath.Base(paths[j])
+	})
+	return paths
+}
+
+// CommandName returns the command line running the plugin of the path, e.g. `kusion foo bar` of kusion-foo-bar
+func CommandName(path string) string {
+	name := strings.TrimPrefix(filepath.Base(path), Prefix)
+	if runtime.GOOS == "windows" {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return fmt.Sprintf("kusion %s", strings.ReplaceAll(name, "-", " "))
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(path))
+		return ext == ".exe" || ext == ".bat" || ext == ".cmd"
+	}
+	return info.Mode()&0o111 != 0
+}
@@ -0,0 +1,109 @@
+package plugin
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+)
+
+type fakeHandler struct {
+	plugins map[string]string
+
+	executed string
+	args     []string
+}
+
+func (h *fakeHandler) Lookup(name string) (string, bool) {
+	path, ok := h.plugins[name]
+	return path, ok
+}
+
+func (h *fakeHandler) Execute(path string, args []string, _ *Context) error {
+	h.executed, h.args = path, args
+	return nil
+}
+
+func TestFind(t *testing.T) {
+	handler := &fakeHandler{plugins: map[string]string{
+		"foo":         "/bin/kusion-foo",
+		"foo-bar":     "/bin/kusion-foo-bar",
+		"foo_baz-qux": "/bin/kusion-foo_baz-qux",
+	}}
+	cases := []struct {
+		args   []string
+		path   string
+		pieces int
+	}{
+		{args: []string{"foo"}, path: "/bin/kusion-foo", pieces: 1},
+		{args: []string{"foo", "bar", "arg"}, path: "/bin/kusion-foo-bar", pieces: 2},
+		{args: []string{"foo", "--flag", "bar"}, path: "/bin/kusion-foo", pieces: 1},
+		{args: []string{"foo-baz", "qux"}, path: "/bin/kusion-foo_baz-qux", pieces: 2},
+		{args: []string{"unknown"}},
+		{args: []string{"--flag", "foo"}},
+	}
+	for _, c := range cases {
+		path, pieces, ok := Find(handler, c.args)
+		assert.Equal(t, c.path != "", ok, c.args)
+		assert.Equal(t, c.path, path, c.args)
+		assert.Equal(t, c.pieces, pieces, c.args)
+	}
+}
+
+func writeExecutable(t *testing.T, dir, name, script string) string {
+	path := filepath.Join(dir, name)
+	assert.Nil(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755))
+	return path
+}
+
+func TestList(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	foo := writeExecutable(t, dir1, "kusion-foo", "")
+	writeExecutable(t, dir2, "kusion-foo", "")
+	bar := writeExecutable(t, dir2, "kusion-bar-baz", "")
+	writeExecutable(t, dir2, "kubectl-foo", "")
+	assert.Nil(t, os.WriteFile(filepath.Join(dir2, "kusion-data"), nil, 0o644))
+	t.Setenv("PATH", dir1+string(os.PathListSeparator)+dir2)
+
+	paths := List()
+	assert.Equal(t, []string{bar, foo}, paths)
+	assert.Equal(t, "kusion bar baz", CommandName(paths[0]))
+}
+
+func TestDefaultHandler(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	path := writeExecutable(t, dir, "kusion-foo", "cat > "+out+"\necho \"$@\" >> "+out+"\nexit 3\n")
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	handler := &DefaultHandler{}
+	found, ok := handler.Lookup("foo")
+	assert.True(t, ok)
+	assert.Equal(t, path, found)
+
+	cmd := NewCmdPluginRun("foo", path, 1, handler)
+	err := cmd.RunE(cmd, []string{"--arg", "value"})
+	assert.Equal(t, 3, util.ExitCode(err))
+
+	data, err := os.ReadFile(out)
+	assert.Nil(t, err)
+	// the context is a line of JSON followed by arguments echoed by the plugin
+	lines := strings.SplitN(string(data), "\n", 2)
+	var context Context
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &context))
+	assert.NotEmpty(t, context.WorkDir)
+	assert.Nil(t, context.Stack)
+	assert.Equal(t, "--arg value\n", lines[1])
+}
@@ -0,0 +1,91 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	pluginShort = "Provide utilities for interacting with plugins"
+
+	pluginLong = `
+		Provide utilities for interacting with plugins.
+
+		Plugins are executables on $PATH whose names start with kusion-, which are run as subcommands of
+		kusion, e.g. kusion-foo-bar is run by kusion foo bar with the rest arguments. Builtin commands can not be
+		overridden by plugins.
+
+		The context of the command is passed to plugins in JSON on their stdin, including the version of kusion,
+		the work directory, and the project, the stack and the backend config of the work directory if it is in a
+		stack. Plugins exit with their own exit codes.`
+
+	pluginListShort = "List plugins on $PATH"
+
+	pluginListExample = `
+		# List all plugins
+		kusion plugin list`
+)
+
+func NewCmdPlugin() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plugin",
+		Short: i18n.T(pluginShort),
+		Long:  templates.LongDesc(i18n.T(pluginLong)),
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+	cmd.AddCommand(NewCmdPluginList())
+	return cmd
+}
+
+func NewCmdPluginList() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Short:   i18n.T(pluginListShort),
+		Example: templates.Examples(i18n.T(pluginListExample)),
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			paths := List()
+			if len(paths) == 0 {
+				fmt.Println("No plugin found on $PATH")
+				return
+			}
+			for _, path := range paths {
+				fmt.Printf("%-30s %s\n", CommandName(path), path)
+			}
+		},
+	}
+}
+
+// NewCmdPluginRun returns the command running the plugin of the path, which is added to the root command as the
+// subcommand of the name, and whose name consists of the number of leading arguments
+func NewCmdPluginRun(name, path string, pieces int, handler Handler) *cobra.Command {
+	return &cobra.Command{
+		Use:                name,
+		Short:              fmt.Sprintf("Run the plugin %s", path),
+		DisableFlagParsing: true,
+		Annotations:        map[string]string{Annotation: "true"},
+		RunE: func(_ *cobra.Command, args []string) error {
+			if pieces > 1 {
+				args = args[pieces-1:]
+			}
+			workDir, _ := os.Getwd()
+			err := handler.Execute(path, args, NewContext(workDir))
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				// the plugin reports its own errors
+				return util.NewExitError(exitErr.ExitCode(), nil)
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,107 @@
+package preview
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pterm/pterm"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/policy"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/util/junit"
+)
+
+// WriteJUnit writes the preview of changes as a JUnit XML report to --junit if specified, where each resource
+// is a test case that fails if a policy denies it, and err is the error of the preview
+func WriteJUnit(
+	o *PreviewOptions,
+	project *projectstack.Project,
+	stack *projectstack.Stack,
+	changes *opsmodels.Changes,
+	err error,
+) {
+	if o.JUnit == "" {
+		return
+	}
+	if e := junit.Write(PreviewJUnit(project, stack, changes, err), o.JUnit); e != nil {
+		pterm.Warning.Println(e)
+	}
+}
+
+// PreviewJUnit returns the JUnit XML report of the preview. Policy violations of resources fail their test
+// cases, and other errors fail an extra test case of the preview.
+func PreviewJUnit(
+	project *projectstack.Project,
+	stack *projectstack.Stack,
+	changes *opsmodels.Changes,
+	err error,
+) *junit.TestSuites {
+	className := project.Name + "/" + stack.Name
+	suite := junit.TestSuite{Name: "kusion preview " + className}
+
+	// violations of resources are reported in their test cases
+	violations := map[string][]policy.Violation{}
+	var violationsErr *policy.ViolationsError
+	if errors.As(err, &violationsErr) {
+		for _, v := range violationsErr.Violations {
+			violations[v.Resource] = append(violations[v.Resource], v)
+		}
+	}
+
+	if changes != nil {
+		for _, step := range changes.Values() {
+			c := junit.TestCase{Name: step.ID, ClassName: className, SystemOut: "action: " + step.Action.String()}
+			if step.Action != opsmodels.UnChange {
+				if diff, e := step.Diff(); e == nil {
+					c.SystemOut += "\n" + pterm.RemoveColorFromString(diff)
+				}
+			}
+			if vs, ok := violations[step.ID]; ok {
+				c.Failure = violationsFailure(vs, c.SystemOut)
+				delete(violations, step.ID)
+			}
+			suite.Add(c)
+		}
+	}
+
+	// violations of no resource or of resources not changed
+	var rest []policy.Violation
+	for _, vs := range violations {
+		rest = append(rest, vs...)
+	}
+	if len(rest) > 0 {
+		suite.Add(junit.TestCase{Name: "policies", ClassName: className, Failure: violationsFailure(rest, "")})
+	}
+
+	// other errors, exit errors without errors only change exit codes
+	var exitErr *util.ExitError
+	if err != nil && violationsErr == nil && !(errors.As(err, &exitErr) && exitErr.Err == nil) {
+		suite.Add(junit.TestCase{
+			Name:      "preview",
+			ClassName: className,
+			Failure:   &junit.Failure{Message: err.Error(), Type: "error", Contents: err.Error()},
+		})
+	}
+
+	report := &junit.TestSuites{Name: "kusion"}
+	report.Add(suite)
+	return report
+}
+
+func violationsFailure(violations []policy.Violation, detail string) *junit.Failure {
+	f := &junit.Failure{Type: "policy"}
+	if len(violations) == 1 {
+		f.Message = violations[0].String()
+	} else {
+		f.Message = fmt.Sprintf("%d policy violation(s) found", len(violations))
+	}
+	for _, v := range violations {
+		f.Contents += v.String() + "\n"
+	}
+	if detail != "" {
+		f.Contents += "\n" + detail
+	}
+	return f
+}
@@ -0,0 +1,69 @@
+package preview
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/policy"
+)
+
+func TestPreviewJUnit(t *testing.T) {
+	changes := opsmodels.NewChanges(project, stack, &opsmodels.ChangeOrder{
+		StepKeys: []string{sa1.ID, sa2.ID},
+		ChangeSteps: map[string]*opsmodels.ChangeStep{
+			sa1.ID: opsmodels.NewChangeStep(sa1.ID, opsmodels.Create, nil, &sa1),
+			sa2.ID: opsmodels.NewChangeStep(sa2.ID, opsmodels.UnChange, &sa2, &sa2),
+		},
+	})
+
+	t.Run("passed", func(t *testing.T) {
+		suites := PreviewJUnit(project, stack, changes, util.NewExitError(util.ExitCodeChanges, nil))
+		assert.Equal(t, 2, suites.Tests)
+		assert.Equal(t, 0, suites.Failures)
+		suite := suites.Suites[0]
+		assert.Equal(t, "kusion preview testdata/dev", suite.Name)
+		assert.Equal(t, sa1.ID, suite.Cases[0].Name)
+		assert.Contains(t, suite.Cases[0].SystemOut, "action: Create\n")
+		assert.NotContains(t, suite.Cases[0].SystemOut, "\x1b[")
+		assert.Equal(t, "action: UnChange", suite.Cases[1].SystemOut)
+	})
+
+	t.Run("policy violations", func(t *testing.T) {
+		err := util.NewExitError(util.ExitCodePolicyViolation, (&policy.Result{Denies: []policy.Violation{
+			{Rule: "limits", Resource: sa1.ID, Message: "resource limits are required"},
+			{Message: "too many resources"},
+		}}).Err())
+		suites := PreviewJUnit(project, stack, changes, err)
+		assert.Equal(t, 3, suites.Tests)
+		assert.Equal(t, 2, suites.Failures)
+		cases := suites.Suites[0].Cases
+		assert.Equal(t, "policy", cases[0].Failure.Type)
+		assert.Equal(t, "[limits] [v1:ServiceAccount:test-ns:sa1] resource limits are required", cases[0].Failure.Message)
+		assert.Nil(t, cases[1].Failure)
+		assert.Equal(t, "policies", cases[2].Name)
+		assert.Equal(t, "too many resources", cases[2].Failure.Message)
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		suites := PreviewJUnit(project, stack, nil, errors.New("check policies failed"))
+		assert.Equal(t, 1, suites.Failures)
+		assert.Equal(t, "preview", suites.Suites[0].Cases[0].Name)
+	})
+}
+
+func TestWriteJUnit(t *testing.T) {
+	o := NewPreviewOptions()
+	WriteJUnit(o, project, stack, nil, nil)
+
+	o.JUnit = filepath.Join(t.TempDir(), "preview.xml")
+	WriteJUnit(o, project, stack, nil, errors.New("failed"))
+	data, err := os.ReadFile(o.JUnit)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `<testsuite name="kusion preview testdata/dev" tests="1" failures="1" skipped="0">`)
+}
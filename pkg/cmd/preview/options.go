@@ -1,42 +1,91 @@
 package preview
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/pterm/pterm"
 
 	compilecmd "kusionstack.io/kusion/pkg/cmd/compile"
 	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/cmd/util"
 	"kusionstack.io/kusion/pkg/engine/backend"
 	"kusionstack.io/kusion/pkg/engine/models"
 	"kusionstack.io/kusion/pkg/engine/operation"
 	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/runtime/terraform/tfops"
 	"kusionstack.io/kusion/pkg/engine/states"
 	"kusionstack.io/kusion/pkg/generator"
 	"kusionstack.io/kusion/pkg/log"
 	"kusionstack.io/kusion/pkg/projectstack"
 	"kusionstack.io/kusion/pkg/status"
+	"kusionstack.io/kusion/pkg/util/diff"
 	"kusionstack.io/kusion/pkg/util/pretty"
+	"kusionstack.io/kusion/pkg/util/sarif"
 )
 
 type PreviewOptions struct {
 	compilecmd.CompileOptions
 	PreviewFlags
 	backend.BackendOps
+
+	// findings are results of policies and validations, which are written by --sarif
+	findings []sarif.Result
+
+	// LiveStates caches live states refreshed by the preview for the apply following it, nil if they are not reused
+	LiveStates *opsmodels.LiveStateCache
+
+	// RuntimeEnv is the env of runtimes of the stack, e.g. the kubeconfig pinned by the stack
+	RuntimeEnv runtime.Env
 }
 
 type PreviewFlags struct {
-	Operator     string
-	Detail       bool
-	All          bool
-	NoStyle      bool
-	IgnoreFields []string
+	Operator          string
+	Detail            bool
+	All               bool
+	NoStyle           bool
+	IgnoreFields      []string
+	DetailedExitCode  bool
+	Policies          []string
+	AdmissionPolicies []string
+	Validation        string
+	OverrideBudget    string
+
+	// ResourceParallelism is the max number of resources operated concurrently
+	ResourceParallelism int
+	// RefreshParallelism is the max number of live states read concurrently before changes are computed
+	RefreshParallelism int
+	// DiffLimit is the max size in bytes of a resource shown in diffs, larger resources are summarized
+	DiffLimit int
+	// DiffMode is the mode of rendering diffs, one of human, side-by-side, unified and json-patch
+	DiffMode string
+	// ShowSecrets shows decoded values of Secrets and other sensitive attributes in diffs instead of masking them
+	ShowSecrets bool
+	// Summary is the view of the summary of changes, list or grouped
+	Summary string
+	// OutputFormat prints the preview in the format instead of the summary table, e.g. tfplan-json
+	OutputFormat string
+	// JUnit is the file to write results of resources to as a JUnit XML report, which CI systems render
+	JUnit string
+	// SARIF is the file to write findings of policies and validations to as a SARIF log for code scanning
+	SARIF string
 }
 
+// Views of summaries of changes
+const (
+	// SummaryList lists all resources with their actions
+	SummaryList = "list"
+	// SummaryGrouped counts actions of resources grouped by namespaces and kinds, which suits large changes
+	SummaryGrouped = "grouped"
+)
+
 func NewPreviewOptions() *PreviewOptions {
 	return &PreviewOptions{
 		CompileOptions: *compilecmd.NewCompileOptions(),
+		PreviewFlags:   PreviewFlags{Validation: ValidateClient},
 	}
 }
 
@@ -45,10 +94,56 @@ func (o *PreviewOptions) Complete(args []string) {
 }
 
 func (o *PreviewOptions) Validate() error {
-	return o.CompileOptions.Validate()
+	if err := o.CompileOptions.Validate(); err != nil {
+		return err
+	}
+	return o.ValidatePreviewFlags()
+}
+
+// ValidatePreviewFlags validates flags shared by preview and apply
+func (o *PreviewOptions) ValidatePreviewFlags() error {
+	if o.Validation != ValidateClient && o.Validation != ValidateServer {
+		return fmt.Errorf("invalid --validate %s, must be %s or %s", o.Validation, ValidateClient, ValidateServer)
+	}
+	if o.ResourceParallelism < 0 {
+		return fmt.Errorf("invalid --parallelism %d, must not be negative", o.ResourceParallelism)
+	}
+	if o.RefreshParallelism < 0 {
+		return fmt.Errorf("invalid --refresh-parallelism %d, must not be negative", o.RefreshParallelism)
+	}
+	if o.DiffLimit < 0 {
+		return fmt.Errorf("invalid --diff-limit %d, must not be negative", o.DiffLimit)
+	}
+	if o.DiffMode != "" {
+		if err := diff.ValidateMode(o.DiffMode); err != nil {
+			return fmt.Errorf("invalid --diff-mode: %w", err)
+		}
+	}
+	if o.Summary != "" && o.Summary != SummaryList && o.Summary != SummaryGrouped {
+		return fmt.Errorf("invalid --summary %s, must be %s or %s", o.Summary, SummaryList, SummaryGrouped)
+	}
+	if o.OutputFormat != "" && o.OutputFormat != TFPlanJSONOutput {
+		return fmt.Errorf("invalid --output %s, only %s is supported", o.OutputFormat, TFPlanJSONOutput)
+	}
+	if o.OutputFormat != "" && o.Detail {
+		return fmt.Errorf("--detail can't be used with --output")
+	}
+	return nil
+}
+
+// GeneratorOptions returns the generator options of the compile flags in the style of the preview output
+func (o *PreviewOptions) GeneratorOptions() *generator.Options {
+	opts := o.CompileOptions.GeneratorOptions()
+	opts.NoStyle = o.NoStyle
+	return opts
 }
 
 func (o *PreviewOptions) Run() error {
+	// Only the plan is printed to stdout in output formats, so spinners and styles are disabled
+	if o.OutputFormat != "" {
+		o.NoStyle = true
+	}
+
 	// Set no style
 	if o.NoStyle {
 		pterm.DisableStyling()
@@ -60,24 +155,27 @@ func (o *PreviewOptions) Run() error {
 	if err != nil {
 		return err
 	}
+	if o.RuntimeEnv, err = runtime.StackEnv(context.Background(), project, stack); err != nil {
+		return err
+	}
 
 	// Get compile result
-	spec, err := spec.GenerateSpecWithSpinner(&generator.Options{
-		WorkDir:     o.WorkDir,
-		Filenames:   o.Filenames,
-		Settings:    o.Settings,
-		Arguments:   o.Arguments,
-		Overrides:   o.Overrides,
-		DisableNone: o.DisableNone,
-		OverrideAST: o.OverrideAST,
-		NoStyle:     o.NoStyle,
-	}, project, stack)
+	generateOptions := o.GeneratorOptions()
+	var sp *models.Spec
+	if o.OutputFormat != "" {
+		sp, err = spec.GenerateSpec(generateOptions, project, stack)
+	} else {
+		sp, err = spec.GenerateSpecWithSpinner(generateOptions, project, stack)
+	}
 	if err != nil {
 		return err
 	}
 
 	// return immediately if no resource found in stack
-	if spec == nil || len(spec.Resources) == 0 {
+	if sp == nil || len(sp.Resources) == 0 {
+		if o.OutputFormat == TFPlanJSONOutput {
+			return WriteTFPlanJSON(os.Stdout, nil)
+		}
 		fmt.Println(pretty.GreenBold("\nNo resource found in this stack."))
 		return nil
 	}
@@ -89,18 +187,50 @@ func (o *PreviewOptions) Run() error {
 	}
 
 	// Compute changes for preview
-	changes, err := Preview(o, stateStorage, spec, project, stack)
+	changes, err := Preview(o, stateStorage, sp, project, stack)
+	if err != nil {
+		return err
+	}
+
+	// Check policies before the changes are reported
+	_, err = CheckPolicies(o, project, stack, sp, changes)
+	WriteJUnit(o, project, stack, changes, err)
+	defer WriteSARIF(o)
 	if err != nil {
 		return err
 	}
 
+	// Print the plan for tools reading terraform plans instead of the summary
+	if o.OutputFormat == TFPlanJSONOutput {
+		if err = WriteTFPlanJSON(os.Stdout, changes); err != nil {
+			return err
+		}
+		if o.DetailedExitCode && !changes.AllUnChange() {
+			return util.NewExitError(util.ExitCodeChanges, nil)
+		}
+		return nil
+	}
+
 	if changes.AllUnChange() {
 		fmt.Println("All resources are reconciled. No diff found")
 		return nil
 	}
 
 	// Summary preview table
-	changes.Summary(os.Stdout)
+	PrintSummary(o, changes)
+	printReplacements(changes)
+
+	// Report errors of the cluster before any change is made
+	if err = ValidateOnServer(o, stateStorage, sp, project, stack, changes); err != nil {
+		return err
+	}
+
+	// Print the plan hash, which can be passed to `kusion apply --plan-hash` to apply exactly these changes
+	planHash, err := changes.Hash()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Plan hash: %s\n\n", planHash)
 
 	// Detail detection
 	if o.Detail {
@@ -116,9 +246,31 @@ func (o *PreviewOptions) Run() error {
 		}
 	}
 
+	// Exit with a distinct code so that pipelines can tell there are changes to apply
+	if o.DetailedExitCode {
+		return util.NewExitError(util.ExitCodeChanges, nil)
+	}
+
 	return nil
 }
 
+// PrintSummary prints the summary of changes in the view of --summary
+func PrintSummary(o *PreviewOptions, changes *opsmodels.Changes) {
+	if o.Summary == SummaryGrouped {
+		changes.GroupedSummary(os.Stdout)
+		return
+	}
+	changes.Summary(os.Stdout)
+}
+
+// printReplacements prints resources which require replacement since their immutable fields are changed
+func printReplacements(changes *opsmodels.Changes) {
+	for _, step := range changes.Values(opsmodels.ReplaceChangeStepFilter) {
+		pretty.Warning.Printfln("%s requires replacement since immutable fields %s are changed, "+
+			"apply with --replace %s to delete and create it again", step.ID, strings.Join(step.ReplaceFields, ", "), step.ID)
+	}
+}
+
 // The Preview function calculates the upcoming actions of each resource
 // through the execution Kusion Engine, and you can customize the
 // runtime of engine and the state storage through `runtime` and
@@ -150,22 +302,50 @@ func Preview(
 	log.Info("Start compute preview changes ...")
 
 	// Validate secret stores
-	if !project.SecretStores.IsValid() {
+	secretStores := stack.GetSecretStores(project)
+	if !secretStores.IsValid() {
 		return nil, fmt.Errorf("no secret store is provided")
 	}
 
+	// Validate Terraform providers against kusion.lock of the stack if any
+	lock, err := tfops.ReadLock(stack.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err = lock.Check(planResources.Resources); err != nil {
+		return nil, err
+	}
+
 	// Construct the preview operation
 	pc := &operation.PreviewOperation{
 		Operation: opsmodels.Operation{
-			OperationType: opsmodels.ApplyPreview,
-			Stack:         stack,
-			StateStorage:  storage,
-			IgnoreFields:  o.IgnoreFields,
-			ChangeOrder:   &opsmodels.ChangeOrder{StepKeys: []string{}, ChangeSteps: map[string]*opsmodels.ChangeStep{}},
-			SecretStores:  project.SecretStores,
+			OperationType:       opsmodels.ApplyPreview,
+			Stack:               stack,
+			StateStorage:        storage,
+			IgnoreFields:        o.IgnoreFields,
+			IgnoreFieldsConfigs: stack.GetIgnoreFields(project),
+			ChangeOrder:         &opsmodels.ChangeOrder{StepKeys: []string{}, ChangeSteps: map[string]*opsmodels.ChangeStep{}},
+			SecretStores:        secretStores,
+			Parallelism:         o.ResourceParallelism,
+			RefreshParallelism:  o.RefreshParallelism,
+			LiveStates:          o.LiveStates,
+			RuntimeEnv:          o.RuntimeEnv,
 		},
 	}
 
+	// show the progress of refreshing live states, which takes most of the time of previews of big stacks
+	if !o.NoStyle {
+		sp, _ := pretty.SpinnerT.WithRemoveWhenDone(true).Start("Refreshing live states ...")
+		if sp != nil {
+			defer func() {
+				_ = sp.Stop()
+			}()
+			pc.RefreshProgress = func(refreshed, total int) {
+				sp.UpdateText(fmt.Sprintf("Refreshing live states (%d/%d) ...", refreshed, total))
+			}
+		}
+	}
+
 	log.Info("Start call pc.Preview() ...")
 
 	cluster := planResources.ParseCluster()
@@ -183,5 +363,8 @@ func Preview(
 		return nil, fmt.Errorf("preview failed.\n%s", s.String())
 	}
 
+	rsp.Order.DiffLimits = diff.Limits{MaxResourceSize: o.DiffLimit}
+	rsp.Order.DiffMode = o.DiffMode
+	rsp.Order.ShowSecrets = o.ShowSecrets
 	return opsmodels.NewChanges(project, stack, rsp.Order), nil
 }
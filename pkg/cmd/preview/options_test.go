@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/cmd/util"
 	"kusionstack.io/kusion/pkg/engine"
 	"kusionstack.io/kusion/pkg/engine/models"
 	"kusionstack.io/kusion/pkg/engine/operation"
@@ -45,7 +46,7 @@ var (
 )
 
 func Test_preview(t *testing.T) {
-	stateStorage := &local.FileSystemState{Path: filepath.Join("", local.KusionState)}
+	stateStorage := &local.FileSystemState{Path: filepath.Join(t.TempDir(), local.KusionState)}
 	t.Run("preview success", func(t *testing.T) {
 		defer monkey.UnpatchAll()
 		mockOperationPreview()
@@ -103,6 +104,36 @@ func TestPreviewOptions_Run(t *testing.T) {
 		err := o.Run()
 		assert.Nil(t, err)
 	})
+
+	t.Run("detailed exit code", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		mockDetectProjectAndStack()
+		mockGenerateSpec()
+		mockNewKubernetesRuntime()
+		mockOperationPreview()
+
+		o := NewPreviewOptions()
+		o.DetailedExitCode = true
+		err := o.Run()
+		assert.Equal(t, util.ExitCodeChanges, util.ExitCode(err))
+	})
+
+	t.Run("tfplan json output", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		mockDetectProjectAndStack()
+		monkey.Patch(spec.GenerateSpec, func(o *generator.Options, project *projectstack.Project, stack *projectstack.Stack) (*models.Spec, error) {
+			return &models.Spec{Resources: []models.Resource{sa1, sa2, sa3}}, nil
+		})
+		mockNewKubernetesRuntime()
+		mockOperationPreview()
+
+		o := NewPreviewOptions()
+		o.OutputFormat = TFPlanJSONOutput
+		o.DetailedExitCode = true
+		err := o.Run()
+		assert.Equal(t, util.ExitCodeChanges, util.ExitCode(err))
+		assert.True(t, o.NoStyle)
+	})
 }
 
 type fooRuntime struct{}
@@ -198,7 +229,7 @@ func mockGenerateSpec() {
 }
 
 func mockNewKubernetesRuntime() {
-	monkey.Patch(kubernetes.NewKubernetesRuntime, func() (runtime.Runtime, error) {
+	monkey.Patch(kubernetes.NewKubernetesRuntime, func(runtime.Env) (runtime.Runtime, error) {
 		return &fooRuntime{}, nil
 	})
 }
@@ -208,3 +239,26 @@ func mockPromptDetail(input string) {
 		return input, nil
 	})
 }
+
+func TestPreviewOptions_GeneratorOptions(t *testing.T) {
+	o := NewPreviewOptions()
+	o.WorkDir = "dev"
+	o.Filenames = []string{"main.k"}
+	o.Settings = []string{"kcl.yaml"}
+	o.Arguments = []string{"env=dev"}
+	o.Sets = []string{"app.replicas=2"}
+	o.Overrides = []string{"app.image=nginx"}
+	o.DisableNone = true
+	o.NoStyle = true
+
+	assert.Equal(t, &generator.Options{
+		WorkDir:     "dev",
+		Filenames:   []string{"main.k"},
+		Settings:    []string{"kcl.yaml"},
+		Arguments:   []string{"env=dev"},
+		Sets:        []string{"app.replicas=2"},
+		Overrides:   []string{"app.image=nginx"},
+		DisableNone: true,
+		NoStyle:     true,
+	}, o.GeneratorOptions())
+}
@@ -0,0 +1,155 @@
+package preview
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/policy"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/util/pretty"
+)
+
+// AuditOverrideBudget is the action of audit records of exceeded budgets overridden by --override-budget
+const AuditOverrideBudget = "override-budget"
+
+// CheckPolicies checks the spec and the planned changes against policies configured in project.yaml and
+// specified by flags. Warnings are printed, and denies are returned as an error which exits
+// with util.ExitCodePolicyViolation. An exceeded budget is allowed with --override-budget, which returns
+// an audit record of the override.
+func CheckPolicies(
+	o *PreviewOptions,
+	project *projectstack.Project,
+	stack *projectstack.Stack,
+	sp *models.Spec,
+	changes *opsmodels.Changes,
+) ([]states.AuditRecord, error) {
+	checkers := policyCheckers(o, project, stack)
+	if len(checkers) == 0 {
+		return nil, nil
+	}
+
+	result, err := policy.Check(policyInput(project, stack, sp, changes), checkers...)
+	if err != nil {
+		return nil, fmt.Errorf("check policies failed: %w", err)
+	}
+	audits := overrideBudget(o, result)
+	o.addPolicyFindings(stack, result)
+	for _, w := range result.Warnings {
+		pretty.Warning.Printfln("Policy: %s", w)
+	}
+	if err = result.Err(); err != nil {
+		return nil, util.NewExitError(util.ExitCodePolicyViolation, err)
+	}
+	return audits, nil
+}
+
+// overrideBudget turns budget violations into warnings if --override-budget is specified, and returns
+// audit records of them, which are also logged
+func overrideBudget(o *PreviewOptions, result *policy.Result) []states.AuditRecord {
+	if o.OverrideBudget == "" {
+		return nil
+	}
+	var audits []states.AuditRecord
+	var denies []policy.Violation
+	for _, d := range result.Denies {
+		if d.Rule != policy.RuleBudget {
+			denies = append(denies, d)
+			continue
+		}
+		result.Warnings = append(result.Warnings, d)
+		audit := states.AuditRecord{
+			Action:   AuditOverrideBudget,
+			Reason:   o.OverrideBudget,
+			Operator: o.Operator,
+			Message:  d.Message,
+			Time:     time.Now(),
+		}
+		log.Warnf("budget overridden by %s: %s, reason: %s", audit.Operator, audit.Message, audit.Reason)
+		audits = append(audits, audit)
+	}
+	result.Denies = denies
+	return audits
+}
+
+// policyCheckers returns checkers of policies in project.yaml, whose paths are relative to the project
+// directory, and policies of the --policy and --admission-policy flags. The security scan is enabled by the
+// security config of policies in project.yaml, and the budget check by the budget of the stack in the cost
+// config.
+func policyCheckers(o *PreviewOptions, project *projectstack.Project, stack *projectstack.Stack) []policy.Checker {
+	rego := &policy.RegoChecker{}
+	admission := &policy.CELChecker{}
+	if project.Policy != nil {
+		rego.Package = project.Policy.Package
+		rego.Paths = projectPaths(project, project.Policy.Paths)
+		admission.Paths = projectPaths(project, project.Policy.AdmissionPolicies)
+	}
+	rego.Paths = append(rego.Paths, o.Policies...)
+	admission.Paths = append(admission.Paths, o.AdmissionPolicies...)
+
+	var checkers []policy.Checker
+	if len(rego.Paths) > 0 {
+		checkers = append(checkers, rego)
+	}
+	if len(admission.Paths) > 0 {
+		checkers = append(checkers, admission)
+	}
+	if project.Policy != nil && project.Policy.Security != nil {
+		checkers = append(checkers, &policy.SecurityChecker{
+			Threshold: policy.Severity(project.Policy.Security.Threshold),
+			SkipRules: project.Policy.Security.SkipRules,
+		})
+	}
+	if project.Cost != nil {
+		if budget, ok := project.Cost.Budgets[stack.Name]; ok {
+			checkers = append(checkers, &policy.BudgetChecker{
+				Budget:   budget,
+				Currency: project.Cost.Currency,
+				Prices:   project.Cost.Prices,
+			})
+		}
+	}
+	return checkers
+}
+
+// projectPaths returns paths relative to the project directory
+func projectPaths(project *projectstack.Project, paths []string) []string {
+	var result []string
+	for _, p := range paths {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(project.Path, p)
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+func policyInput(
+	project *projectstack.Project,
+	stack *projectstack.Stack,
+	sp *models.Spec,
+	changes *opsmodels.Changes,
+) *policy.Input {
+	input := &policy.Input{
+		Project:   project.Name,
+		Stack:     stack.Name,
+		Resources: sp.Resources,
+	}
+	if changes != nil {
+		// From of a change step is the live resource, and To is the planned one
+		for _, step := range changes.Values() {
+			input.Changes = append(input.Changes, policy.Change{
+				ID:     step.ID,
+				Action: step.Action.String(),
+				Before: step.From,
+				After:  step.To,
+			})
+		}
+	}
+	return input
+}
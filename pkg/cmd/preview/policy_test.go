@@ -0,0 +1,125 @@
+package preview
+
+import (
+	"errors"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/policy"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func Test_policyCheckers(t *testing.T) {
+	o := NewPreviewOptions()
+	assert.Empty(t, policyCheckers(o, project, stack))
+
+	p := &projectstack.Project{
+		ProjectConfiguration: projectstack.ProjectConfiguration{
+			Policy: &projectstack.PolicyConfig{
+				Paths:             []string{"policies", "/shared/policies"},
+				Package:           "guardrails",
+				AdmissionPolicies: []string{"admission"},
+				Security: &projectstack.SecurityScanConfig{
+					Threshold: "medium",
+					SkipRules: []string{policy.RuleRunAsRoot},
+				},
+			},
+			Cost: &projectstack.CostConfig{
+				Currency: "USD",
+				Prices:   &projectstack.CostPrices{CPU: 20},
+				Budgets:  map[string]float64{stack.Name: 100, "prod": 1000},
+			},
+		},
+		Path: "/project",
+	}
+	o.Policies = []string{"extra"}
+	assert.Equal(t, []policy.Checker{
+		&policy.RegoChecker{
+			Paths:   []string{"/project/policies", "/shared/policies", "extra"},
+			Package: "guardrails",
+		},
+		&policy.CELChecker{Paths: []string{"/project/admission"}},
+		&policy.SecurityChecker{Threshold: policy.SeverityMedium, SkipRules: []string{policy.RuleRunAsRoot}},
+		&policy.BudgetChecker{Budget: 100, Currency: "USD", Prices: &projectstack.CostPrices{CPU: 20}},
+	}, policyCheckers(o, p, stack))
+
+	o = NewPreviewOptions()
+	o.AdmissionPolicies = []string{"admission"}
+	assert.Equal(t, []policy.Checker{&policy.CELChecker{Paths: []string{"admission"}}}, policyCheckers(o, project, stack))
+}
+
+func TestCheckPolicies(t *testing.T) {
+	sp := &models.Spec{Resources: models.Resources{sa1}}
+	o := NewPreviewOptions()
+	o.Policies = []string{"policies"}
+
+	t.Run("no policy", func(t *testing.T) {
+		audits, err := CheckPolicies(NewPreviewOptions(), project, stack, sp, nil)
+		assert.Nil(t, err)
+		assert.Nil(t, audits)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		monkey.Patch(policy.Check, func(input *policy.Input, _ ...policy.Checker) (*policy.Result, error) {
+			assert.Equal(t, sp.Resources, input.Resources)
+			return &policy.Result{
+				Denies:   []policy.Violation{{Message: "deny"}},
+				Warnings: []policy.Violation{{Message: "warn"}},
+			}, nil
+		})
+		_, err := CheckPolicies(o, project, stack, sp, nil)
+		assert.ErrorContains(t, err, "deny")
+		assert.Equal(t, util.ExitCodePolicyViolation, util.ExitCode(err))
+	})
+
+	t.Run("budget overridden", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		monkey.Patch(policy.Check, func(*policy.Input, ...policy.Checker) (*policy.Result, error) {
+			return &policy.Result{Denies: []policy.Violation{{Rule: policy.RuleBudget, Message: "over budget"}}}, nil
+		})
+		_, err := CheckPolicies(o, project, stack, sp, nil)
+		assert.Equal(t, util.ExitCodePolicyViolation, util.ExitCode(err))
+
+		overridden := NewPreviewOptions()
+		overridden.Policies = o.Policies
+		overridden.Operator = "alice"
+		overridden.OverrideBudget = "traffic peak"
+		audits, err := CheckPolicies(overridden, project, stack, sp, nil)
+		assert.Nil(t, err)
+		assert.Len(t, audits, 1)
+		assert.Equal(t, AuditOverrideBudget, audits[0].Action)
+		assert.Equal(t, "traffic peak", audits[0].Reason)
+		assert.Equal(t, "alice", audits[0].Operator)
+		assert.Equal(t, "over budget", audits[0].Message)
+	})
+
+	t.Run("check failed", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		monkey.Patch(policy.Check, func(*policy.Input, ...policy.Checker) (*policy.Result, error) {
+			return nil, errors.New("opa not found")
+		})
+		_, err := CheckPolicies(o, project, stack, sp, nil)
+		assert.EqualError(t, err, "check policies failed: opa not found")
+		assert.Equal(t, util.ExitCodeError, util.ExitCode(err))
+	})
+}
+
+func Test_policyInput(t *testing.T) {
+	live := newSA("sa1")
+	live.Attributes["metadata"].(map[string]interface{})["labels"] = map[string]interface{}{"a": "b"}
+	changes := opsmodels.NewChanges(project, stack, &opsmodels.ChangeOrder{
+		StepKeys: []string{sa1.ID},
+		ChangeSteps: map[string]*opsmodels.ChangeStep{
+			sa1.ID: opsmodels.NewChangeStep(sa1.ID, opsmodels.Update, &live, &sa1),
+		},
+	})
+
+	input := policyInput(project, stack, &models.Spec{Resources: models.Resources{sa1}}, changes)
+	assert.Equal(t, []policy.Change{{ID: sa1.ID, Action: "Update", Before: &live, After: &sa1}}, input.Changes)
+}
@@ -5,6 +5,8 @@ import (
 	"k8s.io/kubectl/pkg/util/templates"
 
 	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/engine/operation"
+	"kusionstack.io/kusion/pkg/util/diff"
 	"kusionstack.io/kusion/pkg/util/i18n"
 )
 
@@ -28,7 +30,28 @@ var (
 		kusion preview -Y settings.yaml
 
 		# Preview with ignored fields
-		kusion preview --ignore-fields="metadata.generation,metadata.managedFields"`
+		kusion preview --ignore-fields="metadata.generation,metadata.managedFields"
+
+		# Preview in CI and exit with code 2 if there are changes
+		kusion preview --detailed-exitcode
+
+		# Preview with Rego policies in the directory
+		kusion preview --policy ./policies
+
+		# Preview with Kubernetes ValidatingAdmissionPolicies evaluated locally
+		kusion preview --admission-policy ./admission-policies
+
+		# Preview and validate changed resources with the server side dry run of the cluster
+		kusion preview --validate=server
+
+		# Preview in the JSON format of terraform plans, and check it with conftest
+		kusion preview -o tfplan-json > plan.json && conftest test plan.json
+
+		# Preview in CI and write results of resources as a JUnit XML report
+		kusion preview --policy ./policies --junit preview.xml
+
+		# Preview in CI and write policy violations and validation errors as a SARIF log for code scanning
+		kusion preview --policy ./policies --validate=server --sarif kusion.sarif`
 )
 
 func NewCmdPreview() *cobra.Command {
@@ -52,6 +75,11 @@ func NewCmdPreview() *cobra.Command {
 	o.AddPreviewFlags(cmd)
 	o.AddBackendFlags(cmd)
 
+	cmd.Flags().BoolVarP(&o.DetailedExitCode, "detailed-exitcode", "", false,
+		i18n.T("Return a detailed exit code: 0 - succeeded with no changes, 1 - error, 2 - succeeded with changes"))
+	cmd.Flags().StringVarP(&o.OutputFormat, "output", "o", "",
+		i18n.T("Specify the output format of the preview, tfplan-json prints it in the JSON format of terraform plans"))
+
 	return cmd
 }
 
@@ -65,5 +93,32 @@ func (o *PreviewOptions) AddPreviewFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&o.NoStyle, "no-style", "", false,
 		i18n.T("no-style sets to RawOutput mode and disables all of styling"))
 	cmd.Flags().StringSliceVarP(&o.IgnoreFields, "ignore-fields", "", nil,
-		i18n.T("Ignore differences of target fields"))
+		i18n.T("Ignore differences of target fields, besides the ones listed by the annotation "+
+			"kusionstack.io/ignore-diff-paths of each resource"))
+	cmd.Flags().StringSliceVarP(&o.Policies, "policy", "", nil,
+		i18n.T("Specify Rego policy directories, files or bundles to check before changes are made"))
+	cmd.Flags().StringSliceVarP(&o.AdmissionPolicies, "admission-policy", "", nil,
+		i18n.T("Specify files or directories of ValidatingAdmissionPolicies to evaluate locally before changes are made"))
+	cmd.Flags().StringVarP(&o.Validation, "validate", "", ValidateClient,
+		i18n.T("Validation mode of changed resources, client or server. "+
+			"With server, resources are submitted with dry-run=server and errors of the cluster are reported"))
+	cmd.Flags().StringVarP(&o.OverrideBudget, "override-budget", "", "",
+		i18n.T("Allow the estimated cost to exceed the budget of the stack with the reason, which is recorded in the state"))
+	cmd.Flags().IntVarP(&o.ResourceParallelism, "parallelism", "", operation.DefaultParallelism,
+		i18n.T("Specify the max number of resources operated concurrently"))
+	cmd.Flags().IntVarP(&o.RefreshParallelism, "refresh-parallelism", "", operation.DefaultRefreshParallelism,
+		i18n.T("Specify the max number of live states of resources read concurrently before changes are computed"))
+	cmd.Flags().IntVarP(&o.DiffLimit, "diff-limit", "", diff.DefaultLimits.MaxResourceSize,
+		i18n.T("Specify the max size in bytes of a resource shown in diffs, larger resources are summarized"))
+	cmd.Flags().StringVarP(&o.DiffMode, "diff-mode", "", diff.ModeHuman,
+		i18n.T("Specify the mode of rendering diffs, one of human, side-by-side, unified and json-patch"))
+	cmd.Flags().StringVarP(&o.Summary, "summary", "", SummaryList,
+		i18n.T("Specify the view of the summary of changes, list or grouped. "+
+			"With grouped, actions are counted by namespaces and kinds of resources"))
+	cmd.Flags().BoolVarP(&o.ShowSecrets, "show-secrets", "", false,
+		i18n.T("Show decoded values of Secrets and other sensitive attributes in diffs instead of masking them"))
+	cmd.Flags().StringVarP(&o.JUnit, "junit", "", "",
+		i18n.T("Specify the file to write results of resources to as a JUnit XML report, which CI systems render in test reports"))
+	cmd.Flags().StringVarP(&o.SARIF, "sarif", "", "",
+		i18n.T("Specify the file to write findings of policies and validations to as a SARIF log, which code scanning shows on pull requests"))
 }
@@ -0,0 +1,61 @@
+package preview
+
+import (
+	"github.com/pterm/pterm"
+
+	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
+	"kusionstack.io/kusion/pkg/policy"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/util/sarif"
+	"kusionstack.io/kusion/pkg/version"
+)
+
+// Rules of findings that are not reported by policies
+const (
+	// RulePolicy is the rule of policy violations whose policies provide no rule
+	RulePolicy = "policy"
+	// RuleServerValidation is the rule of resources rejected by the server side validation of the cluster
+	RuleServerValidation = "server-validation"
+)
+
+// addPolicyFindings records denies and warnings of policies as findings, which are written by --sarif
+func (o *PreviewOptions) addPolicyFindings(stack *projectstack.Stack, result *policy.Result) {
+	file := sarif.EntryFile(stack.Path, o.Filenames)
+	for _, d := range result.Denies {
+		o.findings = append(o.findings, violationResult(d, sarif.LevelError, file))
+	}
+	for _, w := range result.Warnings {
+		level := sarif.LevelWarning
+		if w.Severity == policy.SeverityLow {
+			level = sarif.LevelNote
+		}
+		o.findings = append(o.findings, violationResult(w, level, file))
+	}
+}
+
+func violationResult(v policy.Violation, level, file string) sarif.Result {
+	rule := v.Rule
+	if rule == "" {
+		rule = RulePolicy
+	}
+	return sarif.NewResult(rule, level, v.String(), file, v.Resource)
+}
+
+// addValidationFindings records resources rejected by the cluster as findings, which are written by --sarif
+func (o *PreviewOptions) addValidationFindings(stack *projectstack.Stack, rejected []kubernetes.ValidationError) {
+	file := sarif.EntryFile(stack.Path, o.Filenames)
+	for _, r := range rejected {
+		o.findings = append(o.findings, sarif.NewResult(RuleServerValidation, sarif.LevelError, r.String(), file, r.ResourceID))
+	}
+}
+
+// WriteSARIF writes findings of policies and validations as a SARIF log to --sarif if specified. The log is
+// written even if nothing is found, so that code scanning closes findings fixed since the last run.
+func WriteSARIF(o *PreviewOptions) {
+	if o.SARIF == "" {
+		return
+	}
+	if err := sarif.Write(sarif.NewLog(version.ReleaseVersion(), o.findings), o.SARIF); err != nil {
+		pterm.Warning.Println(err)
+	}
+}
@@ -0,0 +1,43 @@
+package preview
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
+	"kusionstack.io/kusion/pkg/policy"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/util/sarif"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	dir := t.TempDir()
+	s := &projectstack.Stack{Path: dir}
+	o := NewPreviewOptions()
+	o.addPolicyFindings(s, &policy.Result{
+		Denies:   []policy.Violation{{Rule: "limits", Resource: sa1.ID, Message: "resource limits are required"}},
+		Warnings: []policy.Violation{{Severity: policy.SeverityLow, Message: "labels are recommended"}},
+	})
+	o.addValidationFindings(s, []kubernetes.ValidationError{{ResourceID: sa2.ID, Err: errors.New("denied by webhook")}})
+
+	assert.Len(t, o.findings, 3)
+	assert.Equal(t, "limits", o.findings[0].RuleID)
+	assert.Equal(t, sarif.LevelError, o.findings[0].Level)
+	assert.Equal(t, RulePolicy, o.findings[1].RuleID)
+	assert.Equal(t, sarif.LevelNote, o.findings[1].Level)
+	assert.Equal(t, RuleServerValidation, o.findings[2].RuleID)
+	assert.Equal(t, sa2.ID+": denied by webhook", o.findings[2].Message.Text)
+
+	// nothing is written without --sarif
+	WriteSARIF(o)
+	o.SARIF = filepath.Join(dir, "kusion.sarif")
+	WriteSARIF(o)
+	data, err := os.ReadFile(o.SARIF)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"ruleId": "server-validation"`)
+}
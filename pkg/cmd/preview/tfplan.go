@@ -0,0 +1,305 @@
+package preview
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/sensitive"
+)
+
+// TFPlanJSONOutput prints previews in the schema of `terraform show -json`, which is read by tools written for
+// terraform plans, e.g. conftest and Infracost
+const TFPlanJSONOutput = "tfplan-json"
+
+const (
+	// tfPlanFormatVersion is the version of the plan schema emulated, see
+	// https://developer.hashicorp.com/terraform/internals/json-format
+	tfPlanFormatVersion = "1.1"
+	// tfPlanTerraformVersion is the earliest terraform version writing the emulated schema
+	tfPlanTerraformVersion = "1.3.0"
+
+	kubernetesManifestType   = "kubernetes_manifest"
+	kubernetesProviderSource = "registry.terraform.io/hashicorp/kubernetes"
+)
+
+// invalidTFNameChars matches characters not allowed in names of terraform resources
+var invalidTFNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+type tfPlan struct {
+	FormatVersion    string             `json:"format_version"`
+	TerraformVersion string             `json:"terraform_version"`
+	PlannedValues    tfPlanValues       `json:"planned_values"`
+	ResourceChanges  []tfResourceChange `json:"resource_changes"`
+	Configuration    tfConfiguration    `json:"configuration"`
+}
+
+type tfPlanValues struct {
+	RootModule tfModule `json:"root_module"`
+}
+
+type tfModule struct {
+	Resources []tfResource `json:"resources"`
+}
+
+type tfResource struct {
+	Address       string      `json:"address"`
+	Mode          string      `json:"mode"`
+	Type          string      `json:"type"`
+	Name          string      `json:"name"`
+	ProviderName  string      `json:"provider_name"`
+	SchemaVersion int         `json:"schema_version"`
+	Values        interface{} `json:"values"`
+}
+
+type tfResourceChange struct {
+	Address      string   `json:"address"`
+	Mode         string   `json:"mode"`
+	Type         string   `json:"type"`
+	Name         string   `json:"name"`
+	ProviderName string   `json:"provider_name"`
+	Change       tfChange `json:"change"`
+	ActionReason string   `json:"action_reason,omitempty"`
+}
+
+type tfChange struct {
+	Actions      []string    `json:"actions"`
+	Before       interface{} `json:"before"`
+	After        interface{} `json:"after"`
+	AfterUnknown interface{} `json:"after_unknown"`
+}
+
+type tfConfiguration struct {
+	ProviderConfig map[string]tfProviderConfig `json:"provider_config"`
+	RootModule     tfConfigModule              `json:"root_module"`
+}
+
+type tfProviderConfig struct {
+	Name              string                  `json:"name"`
+	FullName          string                  `json:"full_name"`
+	VersionConstraint string                  `json:"version_constraint,omitempty"`
+	Expressions       map[string]tfExpression `json:"expressions,omitempty"`
+}
+
+type tfConfigModule struct {
+	Resources []tfConfigResource `json:"resources"`
+}
+
+type tfConfigResource struct {
+	Address           string                  `json:"address"`
+	Mode              string                  `json:"mode"`
+	Type              string                  `json:"type"`
+	Name              string                  `json:"name"`
+	ProviderConfigKey string                  `json:"provider_config_key"`
+	Expressions       map[string]tfExpression `json:"expressions"`
+	SchemaVersion     int                     `json:"schema_version"`
+}
+
+type tfExpression struct {
+	ConstantValue interface{} `json:"constant_value"`
+}
+
+// tfAddress is where a kusion resource is in the emulated plan
+type tfAddress struct {
+	resourceType string
+	name         string
+	// source is the provider source, e.g. registry.terraform.io/hashicorp/aws
+	source  string
+	version string
+	// values returns the values of the resource in the plan
+	values func(r *models.Resource) interface{}
+}
+
+// WriteTFPlanJSON writes the changes in the schema of `terraform show -json`. Resources of the Terraform runtime
+// keep their types and providers, and Kubernetes resources are written as kubernetes_manifest resources whose
+// manifests are their attributes. Resources of other runtimes are skipped. Sensitive values are masked as in diffs.
+func WriteTFPlanJSON(w io.Writer, changes *opsmodels.Changes) error {
+	plan := tfPlan{
+		FormatVersion:    tfPlanFormatVersion,
+		TerraformVersion: tfPlanTerraformVersion,
+		PlannedValues:    tfPlanValues{RootModule: tfModule{Resources: []tfResource{}}},
+		ResourceChanges:  []tfResourceChange{},
+		Configuration: tfConfiguration{
+			ProviderConfig: map[string]tfProviderConfig{},
+			RootModule:     tfConfigModule{Resources: []tfConfigResource{}},
+		},
+	}
+
+	if changes != nil && changes.ChangeOrder != nil {
+		for _, step := range changes.Values() {
+			before := stepResource(step.From)
+			after := stepResource(step.To)
+			r := after
+			if r == nil {
+				r = before
+			}
+			if r == nil {
+				continue
+			}
+			addr := tfAddressOf(r)
+			if addr == nil {
+				continue
+			}
+			providerKey := addr.source[strings.LastIndex(addr.source, "/")+1:]
+			address := addr.resourceType + "." + addr.name
+
+			change := tfResourceChange{
+				Address:      address,
+				Mode:         "managed",
+				Type:         addr.resourceType,
+				Name:         addr.name,
+				ProviderName: addr.source,
+				Change: tfChange{
+					Actions:      tfActions(step),
+					Before:       tfValues(before, addr),
+					After:        tfValues(after, addr),
+					AfterUnknown: map[string]interface{}{},
+				},
+			}
+			if step.RequiresReplacement() {
+				change.ActionReason = "replace_because_cannot_update"
+			}
+			plan.ResourceChanges = append(plan.ResourceChanges, change)
+
+			if after == nil {
+				continue
+			}
+			plan.PlannedValues.RootModule.Resources = append(plan.PlannedValues.RootModule.Resources, tfResource{
+				Address:      address,
+				Mode:         "managed",
+				Type:         addr.resourceType,
+				Name:         addr.name,
+				ProviderName: addr.source,
+				Values:       tfValues(after, addr),
+			})
+			plan.Configuration.RootModule.Resources = append(plan.Configuration.RootModule.Resources, tfConfigResource{
+				Address:           address,
+				Mode:              "managed",
+				Type:              addr.resourceType,
+				Name:              addr.name,
+				ProviderConfigKey: providerKey,
+				Expressions:       tfExpressions(tfValues(after, addr)),
+			})
+			if _, ok := plan.Configuration.ProviderConfig[providerKey]; !ok {
+				plan.Configuration.ProviderConfig[providerKey] = tfProviderConfig{
+					Name:              providerKey,
+					FullName:          addr.source,
+					VersionConstraint: addr.version,
+					Expressions:       tfExpressions(after.Extensions["providerMeta"]),
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(plan.ResourceChanges, func(i, j int) bool {
+		return plan.ResourceChanges[i].Address < plan.ResourceChanges[j].Address
+	})
+	sort.SliceStable(plan.PlannedValues.RootModule.Resources, func(i, j int) bool {
+		return plan.PlannedValues.RootModule.Resources[i].Address < plan.PlannedValues.RootModule.Resources[j].Address
+	})
+	sort.SliceStable(plan.Configuration.RootModule.Resources, func(i, j int) bool {
+		return plan.Configuration.RootModule.Resources[i].Address < plan.Configuration.RootModule.Resources[j].Address
+	})
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(plan)
+}
+
+// stepResource returns the resource in the data of a change step, or nil if there is none
+func stepResource(value interface{}) *models.Resource {
+	switch v := value.(type) {
+	case *models.Resource:
+		return v
+	case models.Resource:
+		return &v
+	default:
+		return nil
+	}
+}
+
+// tfAddressOf returns where the resource is in the emulated plan, or nil if its runtime is not supported
+func tfAddressOf(r *models.Resource) *tfAddress {
+	switch r.Type {
+	case runtime.Terraform:
+		provider, _ := r.Extensions["provider"].(string)
+		resourceType, _ := r.Extensions["resourceType"].(string)
+		parts := strings.Split(provider, "/")
+		if len(parts) < 3 || resourceType == "" {
+			return nil
+		}
+		return &tfAddress{
+			resourceType: resourceType,
+			name:         tfName(r.ID[strings.LastIndex(r.ID, ":")+1:]),
+			source:       strings.Join(parts[:len(parts)-1], "/"),
+			version:      parts[len(parts)-1],
+			values: func(r *models.Resource) interface{} {
+				return r.Attributes
+			},
+		}
+	case runtime.Kubernetes:
+		return &tfAddress{
+			resourceType: kubernetesManifestType,
+			name:         tfName(r.ID),
+			source:       kubernetesProviderSource,
+			values: func(r *models.Resource) interface{} {
+				return map[string]interface{}{"manifest": r.Attributes}
+			},
+		}
+	default:
+		return nil
+	}
+}
+
+// tfName returns a valid terraform resource name from the kusion resource ID or name
+func tfName(s string) string {
+	name := invalidTFNameChars.ReplaceAllString(s, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') || name[0] == '-' {
+		name = "_" + name
+	}
+	return name
+}
+
+// tfValues returns the masked values of the resource in the plan, or nil if the resource is absent
+func tfValues(r *models.Resource, addr *tfAddress) interface{} {
+	if r == nil {
+		return nil
+	}
+	return addr.values(sensitive.MaskResource(r))
+}
+
+// tfActions returns the terraform actions of the change step
+func tfActions(step *opsmodels.ChangeStep) []string {
+	if step.RequiresReplacement() {
+		return []string{"delete", "create"}
+	}
+	switch step.Action {
+	case opsmodels.Create:
+		return []string{"create"}
+	case opsmodels.Update:
+		return []string{"update"}
+	case opsmodels.Delete:
+		return []string{"delete"}
+	default:
+		return []string{"no-op"}
+	}
+}
+
+// tfExpressions returns constant expressions of top level keys of the values, which tools like Infracost read
+// from configurations, e.g. regions of providers
+func tfExpressions(values interface{}) map[string]tfExpression {
+	m, ok := values.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	expressions := make(map[string]tfExpression, len(m))
+	for k, v := range m {
+		expressions[k] = tfExpression{ConstantValue: v}
+	}
+	return expressions
+}
@@ -0,0 +1,108 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+)
+
+func TestWriteTFPlanJSON(t *testing.T) {
+	file := models.Resource{
+		ID:         "hashicorp:local:local_file:example",
+		Type:       "Terraform",
+		Attributes: map[string]interface{}{"filename": "a.txt", "content": "new"},
+		Extensions: map[string]interface{}{
+			"provider":     "registry.terraform.io/hashicorp/local/2.2.3",
+			"resourceType": "local_file",
+			"providerMeta": map[string]interface{}{"region": "us-east-1"},
+		},
+	}
+	liveFile := file
+	liveFile.Attributes = map[string]interface{}{"filename": "a.txt", "content": "old"}
+	password := models.Resource{
+		ID:         "hashicorp:random:random_password:db",
+		Type:       "Terraform",
+		Attributes: map[string]interface{}{"length": 16, "password": "secret"},
+		Extensions: map[string]interface{}{
+			"provider":     "registry.terraform.io/hashicorp/random/3.4.3",
+			"resourceType": "random_password",
+		},
+	}
+	other := models.Resource{ID: "foo", Type: "Foo"}
+	changes := opsmodels.NewChanges(project, stack, &opsmodels.ChangeOrder{
+		StepKeys: []string{sa1.ID, sa2.ID, file.ID, password.ID, other.ID},
+		ChangeSteps: map[string]*opsmodels.ChangeStep{
+			sa1.ID:      {ID: sa1.ID, Action: opsmodels.Create, From: (*models.Resource)(nil), To: &sa1},
+			sa2.ID:      {ID: sa2.ID, Action: opsmodels.Delete, From: &sa2, To: (*models.Resource)(nil)},
+			file.ID:     {ID: file.ID, Action: opsmodels.Update, From: &liveFile, To: &file, ReplaceFields: []string{"content"}},
+			password.ID: {ID: password.ID, Action: opsmodels.UnChange, From: &password, To: &password},
+			other.ID:    {ID: other.ID, Action: opsmodels.Create, To: &other},
+		},
+	})
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, WriteTFPlanJSON(buf, changes))
+	var plan map[string]interface{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &plan))
+	assert.Equal(t, "1.1", plan["format_version"])
+
+	resourceChanges := plan["resource_changes"].([]interface{})
+	assert.Len(t, resourceChanges, 4)
+	actions := map[string]interface{}{}
+	for _, rc := range resourceChanges {
+		rc := rc.(map[string]interface{})
+		actions[rc["address"].(string)] = rc["change"].(map[string]interface{})["actions"]
+	}
+	assert.Equal(t, map[string]interface{}{
+		"kubernetes_manifest.v1_ServiceAccount_test-ns_sa1": []interface{}{"create"},
+		"kubernetes_manifest.v1_ServiceAccount_test-ns_sa2": []interface{}{"delete"},
+		"local_file.example": []interface{}{"delete", "create"},
+		"random_password.db": []interface{}{"no-op"},
+	}, actions)
+
+	created := resourceChanges[0].(map[string]interface{})
+	assert.Equal(t, "registry.terraform.io/hashicorp/kubernetes", created["provider_name"])
+	assert.Nil(t, created["change"].(map[string]interface{})["before"])
+	assert.Equal(t, "sa1", created["change"].(map[string]interface{})["after"].(map[string]interface{})["manifest"].(map[string]interface{})["metadata"].(map[string]interface{})["name"])
+
+	replaced := resourceChanges[2].(map[string]interface{})
+	assert.Equal(t, "registry.terraform.io/hashicorp/local", replaced["provider_name"])
+	assert.Equal(t, "replace_because_cannot_update", replaced["action_reason"])
+	assert.Equal(t, "old", replaced["change"].(map[string]interface{})["before"].(map[string]interface{})["content"])
+	assert.Equal(t, "new", replaced["change"].(map[string]interface{})["after"].(map[string]interface{})["content"])
+
+	masked := resourceChanges[3].(map[string]interface{})["change"].(map[string]interface{})["after"].(map[string]interface{})
+	assert.NotEqual(t, "secret", masked["password"])
+
+	planned := plan["planned_values"].(map[string]interface{})["root_module"].(map[string]interface{})["resources"].([]interface{})
+	assert.Len(t, planned, 3)
+
+	providers := plan["configuration"].(map[string]interface{})["provider_config"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"name":               "local",
+		"full_name":          "registry.terraform.io/hashicorp/local",
+		"version_constraint": "2.2.3",
+		"expressions":        map[string]interface{}{"region": map[string]interface{}{"constant_value": "us-east-1"}},
+	}, providers["local"])
+	assert.Contains(t, providers, "kubernetes")
+	assert.Contains(t, providers, "random")
+}
+
+func TestWriteTFPlanJSON_Empty(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.Nil(t, WriteTFPlanJSON(buf, nil))
+	var plan map[string]interface{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &plan))
+	assert.Equal(t, []interface{}{}, plan["resource_changes"])
+}
+
+func Test_tfName(t *testing.T) {
+	assert.Equal(t, "apps_v1_Deployment_default_nginx", tfName("apps/v1:Deployment:default:nginx"))
+	assert.Equal(t, "_1st", tfName("1st"))
+	assert.Equal(t, "_", tfName(""))
+}
@@ -0,0 +1,93 @@
+package preview
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pterm/pterm"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// Validation modes of planned resources
+const (
+	// ValidateClient validates resources on the client side, and server side dry run errors are ignored
+	ValidateClient = "client"
+	// ValidateServer submits resources to be changed with dry-run=server and reports errors of the cluster
+	ValidateServer = "server"
+)
+
+// newServerValidator returns the Kubernetes runtime which validates resources with the cluster
+var newServerValidator = kubernetes.RuntimeAs[serverValidator]
+
+type serverValidator interface {
+	ValidateServer(ctx context.Context, resources models.Resources, prior map[string]*models.Resource) ([]kubernetes.ValidationError, error)
+}
+
+// ValidateOnServer submits the resources to be created or updated to the cluster with dry-run=server if
+// --validate=server is specified, and returns an error if any of them is rejected by the schema validation,
+// admission controllers or webhooks of the cluster.
+func ValidateOnServer(
+	o *PreviewOptions,
+	storage states.StateStorage,
+	sp *models.Spec,
+	project *projectstack.Project,
+	stack *projectstack.Stack,
+	changes *opsmodels.Changes,
+) error {
+	if o.Validation != ValidateServer {
+		return nil
+	}
+
+	index := sp.Resources.Index()
+	var resources models.Resources
+	for _, step := range changes.Values(func(c *opsmodels.ChangeStep) bool {
+		return c.Action == opsmodels.Create || c.Action == opsmodels.Update
+	}) {
+		if res, ok := index[step.ID]; ok {
+			resources = append(resources, *res)
+		}
+	}
+	if len(resources) == 0 {
+		return nil
+	}
+
+	prior := map[string]*models.Resource{}
+	state, err := storage.GetLatestState(&states.StateQuery{
+		Tenant:  project.Tenant,
+		Stack:   stack.Name,
+		Project: project.Name,
+		Cluster: sp.ParseCluster(),
+	})
+	if err != nil {
+		return err
+	}
+	if state != nil {
+		prior = state.Resources.Index()
+	}
+
+	validator, err := newServerValidator(o.RuntimeEnv)
+	if err != nil {
+		return err
+	}
+	if validator == nil {
+		return fmt.Errorf("the Kubernetes runtime does not support the server side validation")
+	}
+	rejected, err := validator.ValidateServer(context.Background(), resources, prior)
+	if err != nil {
+		return fmt.Errorf("server side validation failed: %w", err)
+	}
+	if len(rejected) == 0 {
+		pterm.Success.Println("All changed resources passed the server side validation")
+		return nil
+	}
+	o.addValidationFindings(stack, rejected)
+	for _, r := range rejected {
+		pterm.Error.Println(r.String())
+	}
+	return fmt.Errorf("server side validation failed, %d resource(s) rejected by the cluster", len(rejected))
+}
@@ -0,0 +1,97 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/engine/states/local"
+)
+
+type fakeValidator struct {
+	resources models.Resources
+	prior     map[string]*models.Resource
+	rejected  []kubernetes.ValidationError
+	err       error
+}
+
+func (v *fakeValidator) ValidateServer(
+	_ context.Context,
+	resources models.Resources,
+	prior map[string]*models.Resource,
+) ([]kubernetes.ValidationError, error) {
+	v.resources, v.prior = resources, prior
+	return v.rejected, v.err
+}
+
+func TestPreviewOptions_Validate(t *testing.T) {
+	o := NewPreviewOptions()
+	assert.Nil(t, o.Validate())
+	o.Validation = ValidateServer
+	assert.Nil(t, o.Validate())
+	o.Validation = "cluster"
+	assert.EqualError(t, o.Validate(), "invalid --validate cluster, must be client or server")
+
+	o.Validation = ValidateClient
+	o.OutputFormat = "yaml"
+	assert.EqualError(t, o.Validate(), "invalid --output yaml, only tfplan-json is supported")
+	o.OutputFormat = TFPlanJSONOutput
+	assert.Nil(t, o.Validate())
+	o.Detail = true
+	assert.EqualError(t, o.Validate(), "--detail can't be used with --output")
+}
+
+func TestValidateOnServer(t *testing.T) {
+	storage := &local.FileSystemState{Path: filepath.Join(t.TempDir(), local.KusionState)}
+	state := states.NewState()
+	state.Project, state.Stack = project.Name, stack.Name
+	state.Resources = models.Resources{sa2}
+	assert.Nil(t, storage.Apply(state))
+
+	sp := &models.Spec{Resources: models.Resources{sa1, sa2, sa3}}
+	changes := opsmodels.NewChanges(project, stack, &opsmodels.ChangeOrder{
+		StepKeys: []string{sa1.ID, sa2.ID, sa3.ID},
+		ChangeSteps: map[string]*opsmodels.ChangeStep{
+			sa1.ID: {ID: sa1.ID, Action: opsmodels.Create, From: &sa1},
+			sa2.ID: {ID: sa2.ID, Action: opsmodels.Update, From: &sa2},
+			sa3.ID: {ID: sa3.ID, Action: opsmodels.UnChange, From: &sa3},
+		},
+	})
+	newServerValidatorBackup := newServerValidator
+	defer func() { newServerValidator = newServerValidatorBackup }()
+	validator := &fakeValidator{}
+	newServerValidator = func(runtime.Env) (serverValidator, error) { return validator, nil }
+
+	o := NewPreviewOptions()
+	t.Run("client", func(t *testing.T) {
+		assert.Nil(t, ValidateOnServer(o, storage, sp, project, stack, changes))
+		assert.Nil(t, validator.resources)
+	})
+
+	o.Validation = ValidateServer
+	t.Run("passed", func(t *testing.T) {
+		assert.Nil(t, ValidateOnServer(o, storage, sp, project, stack, changes))
+		assert.Equal(t, models.Resources{sa1, sa2}, validator.resources)
+		assert.Equal(t, sa2.ID, validator.prior[sa2.ID].ID)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		validator.rejected = []kubernetes.ValidationError{{ResourceID: sa1.ID, Err: errors.New("denied by webhook")}}
+		err := ValidateOnServer(o, storage, sp, project, stack, changes)
+		assert.EqualError(t, err, "server side validation failed, 1 resource(s) rejected by the cluster")
+	})
+
+	t.Run("cluster unreachable", func(t *testing.T) {
+		validator.rejected, validator.err = nil, errors.New("connection refused")
+		err := ValidateOnServer(o, storage, sp, project, stack, changes)
+		assert.EqualError(t, err, "server side validation failed: connection refused")
+	})
+}
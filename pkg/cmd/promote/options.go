@@ -0,0 +1,164 @@
+package promote
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/pterm/pterm"
+
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/promote"
+	"kusionstack.io/kusion/pkg/util/diff"
+)
+
+// generateSpec compiles the stack with its default settings
+var generateSpec = func(project *projectstack.Project, stack *projectstack.Stack) (*models.Spec, error) {
+	return spec.GenerateSpec(&generator.Options{
+		WorkDir:  stack.Path,
+		Settings: spec.DefaultSettings(stack.Path),
+	}, project, stack)
+}
+
+// confirm asks whether to promote the previewed changes
+var confirm = func() (bool, error) {
+	prompt := &survey.Confirm{
+		Message: "Do you want to promote these changes?",
+	}
+	var yes bool
+	if err := survey.AskOne(prompt, &yes); err != nil {
+		return false, err
+	}
+	return yes, nil
+}
+
+type PromoteOptions struct {
+	WorkDir string
+	From    string
+	To      string
+	Exclude []string
+	Yes     bool
+	DryRun  bool
+}
+
+func NewPromoteOptions() *PromoteOptions {
+	return &PromoteOptions{}
+}
+
+func (o *PromoteOptions) Complete(_ []string) {
+	if o.WorkDir == "" {
+		o.WorkDir, _ = os.Getwd()
+	}
+}
+
+func (o *PromoteOptions) Validate() error {
+	if o.From == "" || o.To == "" {
+		return errors.New("--from and --to are required")
+	}
+	if o.From == o.To {
+		return errors.New("--from and --to must be different stacks")
+	}
+	if o.Yes && o.DryRun {
+		return errors.New("--yes and --dry-run can not be specified together")
+	}
+	return nil
+}
+
+func (o *PromoteOptions) Run() error {
+	projectDir, err := projectstack.FindProjectPathFrom(o.WorkDir)
+	if err != nil {
+		return err
+	}
+	project, err := projectstack.GetProjectFrom(projectDir)
+	if err != nil {
+		return err
+	}
+	from, err := stackOf(project, o.From)
+	if err != nil {
+		return err
+	}
+	to, err := stackOf(project, o.To)
+	if err != nil {
+		return err
+	}
+
+	fromConfig, err := configOf(project, from)
+	if err != nil {
+		return err
+	}
+	toConfig, err := configOf(project, to)
+	if err != nil {
+		return err
+	}
+	exclude := append([]string{}, o.Exclude...)
+	if project.Promotion != nil {
+		exclude = append(exclude, project.Promotion.Exclude...)
+	}
+	changes := promote.Diff(fromConfig, toConfig, exclude)
+	if len(changes) == 0 {
+		fmt.Printf("Stack %s is up to date with stack %s.\n", to.Name, from.Name)
+		return nil
+	}
+
+	report, err := diff.ToReport(toConfig, promote.Promoted(toConfig, changes))
+	if err != nil {
+		return err
+	}
+	preview, err := diff.ToHumanString(diff.NewHumanReport(report))
+	if err != nil {
+		return err
+	}
+	pterm.Bold.Printfln("Promote %s to %s:", from.Name, to.Name)
+	fmt.Println(preview)
+	if o.DryRun {
+		return nil
+	}
+	if !o.Yes {
+		yes, err := confirm()
+		if err != nil {
+			return err
+		}
+		if !yes {
+			fmt.Println("Operation promote canceled")
+			return nil
+		}
+	}
+
+	unresolved, err := promote.Apply(to.Path, changes)
+	if err != nil {
+		return err
+	}
+	for _, c := range unresolved {
+		pterm.Warning.Printfln("Image %v is not referenced by files of the stack %s, promote it to %v manually",
+			c.Old, to.Name, c.New)
+	}
+	fmt.Printf("Promoted %d changes from %s to %s\n", len(changes)-len(unresolved), from.Name, to.Name)
+	return nil
+}
+
+// stackOf returns the stack of the name in the project
+func stackOf(project *projectstack.Project, name string) (*projectstack.Stack, error) {
+	for _, stack := range project.Stacks {
+		if stack.Name == name {
+			return stack, nil
+		}
+	}
+	return nil, fmt.Errorf("stack %s not found in the project %s", name, project.Name)
+}
+
+// configOf returns the configuration of the stack which can be promoted
+func configOf(project *projectstack.Project, stack *projectstack.Stack) (*promote.Config, error) {
+	values, err := promote.LoadValues(stack.Path)
+	if err != nil {
+		return nil, err
+	}
+	sp, err := generateSpec(project, stack)
+	if err != nil {
+		return nil, fmt.Errorf("compile the stack %s failed: %w", stack.Name, err)
+	}
+	return &promote.Config{Values: values, Images: promote.Images(sp)}, nil
+}
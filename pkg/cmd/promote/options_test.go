@@ -0,0 +1,137 @@
+package promote
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// newProject writes a project with the stacks dev and staging, whose main.k is the image of the stack
+func newProject(t *testing.T) string {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "project.yaml"), "name: web\npromotion:\n  exclude:\n    - cluster\n")
+	for stack, values := range map[string]string{
+		"dev":     "  - key: cluster\n    value: dev\n  - key: replicas\n    value: 1\n",
+		"staging": "  - key: cluster\n    value: staging\n  - key: replicas\n    value: 2\n",
+	} {
+		writeFile(t, filepath.Join(dir, stack, "stack.yaml"), "name: "+stack+"\n")
+		writeFile(t, filepath.Join(dir, stack, "kcl.yaml"), "kcl_options:\n"+values)
+	}
+	writeFile(t, filepath.Join(dir, "dev", "main.k"), "image = \"web:1.2.0\"\n")
+	writeFile(t, filepath.Join(dir, "staging", "main.k"), "image = \"web:1.1.0\"\n")
+	return dir
+}
+
+func mockGenerateSpec(t *testing.T) {
+	original := generateSpec
+	generateSpec = func(_ *projectstack.Project, stack *projectstack.Stack) (*models.Spec, error) {
+		data, err := os.ReadFile(filepath.Join(stack.Path, "main.k"))
+		if err != nil {
+			return nil, err
+		}
+		image := string(data[len(`image = "`) : len(data)-len("\"\n")])
+		return &models.Spec{Resources: models.Resources{{
+			ID:   "apps/v1:Deployment:web:web",
+			Type: runtime.Kubernetes,
+			Attributes: map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{"image": image}},
+			},
+		}}}, nil
+	}
+	t.Cleanup(func() { generateSpec = original })
+}
+
+func mockConfirm(t *testing.T, yes bool) *bool {
+	asked := false
+	original := confirm
+	confirm = func() (bool, error) {
+		asked = true
+		return yes, nil
+	}
+	t.Cleanup(func() { confirm = original })
+	return &asked
+}
+
+func TestPromoteOptions_Validate(t *testing.T) {
+	o := NewPromoteOptions()
+	assert.EqualError(t, o.Validate(), "--from and --to are required")
+
+	o.From, o.To = "dev", "dev"
+	assert.EqualError(t, o.Validate(), "--from and --to must be different stacks")
+
+	o.To = "staging"
+	assert.NoError(t, o.Validate())
+
+	o.Yes, o.DryRun = true, true
+	assert.EqualError(t, o.Validate(), "--yes and --dry-run can not be specified together")
+}
+
+func TestPromoteOptions_Run(t *testing.T) {
+	t.Run("promote", func(t *testing.T) {
+		dir := newProject(t)
+		mockGenerateSpec(t)
+		asked := mockConfirm(t, true)
+
+		o := &PromoteOptions{WorkDir: dir, From: "dev", To: "staging"}
+		assert.NoError(t, o.Run())
+		assert.True(t, *asked)
+		assert.Equal(t, "kcl_options:\n  - key: cluster\n    value: staging\n  - key: replicas\n    value: 1\n",
+			readFile(t, filepath.Join(dir, "staging", "kcl.yaml")))
+		assert.Equal(t, "image = \"web:1.2.0\"\n", readFile(t, filepath.Join(dir, "staging", "main.k")))
+
+		// nothing to promote anymore
+		*asked = false
+		assert.NoError(t, o.Run())
+		assert.False(t, *asked)
+	})
+
+	t.Run("exclude and canceled", func(t *testing.T) {
+		dir := newProject(t)
+		mockGenerateSpec(t)
+		mockConfirm(t, false)
+
+		o := &PromoteOptions{WorkDir: dir, From: "dev", To: "staging", Exclude: []string{"replicas"}}
+		assert.NoError(t, o.Run())
+		assert.Equal(t, "image = \"web:1.1.0\"\n", readFile(t, filepath.Join(dir, "staging", "main.k")))
+		assert.Equal(t, []string{"replicas"}, o.Exclude)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		dir := newProject(t)
+		mockGenerateSpec(t)
+		asked := mockConfirm(t, true)
+
+		o := &PromoteOptions{WorkDir: filepath.Join(dir, "dev"), From: "dev", To: "staging", DryRun: true}
+		assert.NoError(t, o.Run())
+		assert.False(t, *asked)
+		assert.Equal(t, "image = \"web:1.1.0\"\n", readFile(t, filepath.Join(dir, "staging", "main.k")))
+	})
+
+	t.Run("stack not found", func(t *testing.T) {
+		dir := newProject(t)
+		mockGenerateSpec(t)
+
+		o := &PromoteOptions{WorkDir: dir, From: "dev", To: "prod", Yes: true}
+		assert.EqualError(t, o.Run(), "stack prod not found in the project web")
+	})
+}
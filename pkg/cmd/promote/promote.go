@@ -0,0 +1,69 @@
+package promote
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	promoteShort = "Promote configurations of a stack to another stack"
+
+	promoteLong = `
+		Promote configurations tested in a stack to another stack of the project in the work directory, e.g.
+		from dev to staging, then from staging to prod.
+
+		Values are kcl_options in settings files of stacks. Values of the source stack are set in the target
+		stack, except keys excluded by --exclude and by promotion.exclude of project.yaml, which are specific
+		to each stack, e.g. cluster. Images are images of containers in compiled specs of both stacks. Tags
+		and digests of images in the source stack replace the ones of the same repositories referenced in
+		files of the target stack.
+
+		The diff of configurations of the target stack is previewed before files are changed. Images defined
+		outside of the target stack directory, e.g. in a shared base, are reported to be promoted manually.`
+
+	promoteExample = `
+		# Promote configurations of dev to staging
+		kusion promote --from dev --to staging
+
+		# Preview the diff only
+		kusion promote --from dev --to staging --dry-run
+
+		# Promote without confirmation and keep the value replicas of prod
+		kusion promote --from staging --to prod --exclude replicas --yes`
+)
+
+func NewCmdPromote() *cobra.Command {
+	o := NewPromoteOptions()
+
+	cmd := &cobra.Command{
+		Use:     "promote",
+		Short:   i18n.T(promoteShort),
+		Long:    templates.LongDesc(i18n.T(promoteLong)),
+		Example: templates.Examples(i18n.T(promoteExample)),
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			defer util.RecoverErr(&err)
+			o.Complete(args)
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+			return
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.WorkDir, "workdir", "w", "",
+		i18n.T("Specify the work directory in the project"))
+	cmd.Flags().StringVarP(&o.From, "from", "", "",
+		i18n.T("Specify the name of the stack whose configurations are promoted"))
+	cmd.Flags().StringVarP(&o.To, "to", "", "",
+		i18n.T("Specify the name of the stack which the configurations are promoted to"))
+	cmd.Flags().StringSliceVarP(&o.Exclude, "exclude", "", nil,
+		i18n.T("Specify keys of values which are not promoted"))
+	cmd.Flags().BoolVarP(&o.Yes, "yes", "y", false,
+		i18n.T("Automatically approve and promote the configurations"))
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "", false,
+		i18n.T("Preview the diff without changing files"))
+
+	return cmd
+}
@@ -0,0 +1,53 @@
+package providers
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	lockShort = "Lock versions and checksums of Terraform providers of a stack in kusion.lock"
+
+	lockLong = `
+		Compile the stack in the work directory, download Terraform providers of its resources and record
+		their exact versions and checksums of their packages in kusion.lock of the stack.
+
+		Commit kusion.lock with the stack. Once it exists, previews and applies fail if resources use providers
+		or versions not in it, and terraform installs only provider packages matching the recorded checksums,
+		so that applies are reproducible across machines. Run this command again after changing providers.`
+
+	lockExample = `
+		# Lock providers of the current stack for this machine
+		kusion providers lock
+
+		# Lock providers for machines of other platforms too, e.g. CI runners
+		kusion providers lock --platform linux_amd64 --platform darwin_arm64`
+)
+
+func NewCmdLock() *cobra.Command {
+	o := NewLockOptions()
+
+	cmd := &cobra.Command{
+		Use:     "lock",
+		Short:   i18n.T(lockShort),
+		Long:    templates.LongDesc(i18n.T(lockLong)),
+		Example: templates.Examples(i18n.T(lockExample)),
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			defer util.RecoverErr(&err)
+			o.Complete(args)
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+			return
+		},
+	}
+
+	o.AddCompileFlags(cmd)
+	cmd.Flags().StringSliceVarP(&o.Platforms, "platform", "", nil,
+		i18n.T("Specify platforms of provider packages whose checksums are recorded, e.g. linux_amd64, "+
+			"which default to the platform of this machine"))
+
+	return cmd
+}
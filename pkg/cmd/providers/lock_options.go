@@ -0,0 +1,67 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	compilecmd "kusionstack.io/kusion/pkg/cmd/compile"
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/engine/runtime/terraform/tfops"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// platformPattern matches platforms of provider packages, e.g. linux_amd64
+var platformPattern = regexp.MustCompile(`^[a-z0-9]+_[a-z0-9]+$`)
+
+type LockOptions struct {
+	compilecmd.CompileOptions
+	// Platforms are platforms of provider packages whose checksums are recorded
+	Platforms []string
+}
+
+func NewLockOptions() *LockOptions {
+	return &LockOptions{
+		CompileOptions: *compilecmd.NewCompileOptions(),
+	}
+}
+
+func (o *LockOptions) Validate() error {
+	for _, platform := range o.Platforms {
+		if !platformPattern.MatchString(platform) {
+			return fmt.Errorf("invalid --platform %s, must be like linux_amd64", platform)
+		}
+	}
+	return o.CompileOptions.Validate()
+}
+
+func (o *LockOptions) Run() error {
+	project, stack, err := projectstack.DetectProjectAndStack(o.WorkDir)
+	if err != nil {
+		return err
+	}
+	sp, err := spec.GenerateSpecWithSpinner(o.GeneratorOptions(), project, stack)
+	if err != nil {
+		return err
+	}
+
+	var addrs []string
+	if sp != nil {
+		addrs = tfops.ProviderAddrs(sp.Resources)
+	}
+	if len(addrs) == 0 {
+		fmt.Println("No Terraform provider found in this stack.")
+		return nil
+	}
+	lock, err := tfops.LockProviders(context.Background(), addrs, o.Platforms)
+	if err != nil {
+		return err
+	}
+	if err = tfops.WriteLock(stack.Path, lock); err != nil {
+		return err
+	}
+	for _, p := range lock.Providers {
+		fmt.Printf("Locked %s %s with %d checksums\n", p.Source, p.Version, len(p.Hashes))
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime/terraform/tfops"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+var (
+	project = &projectstack.Project{
+		ProjectConfiguration: projectstack.ProjectConfiguration{
+			Name: "testdata",
+		},
+	}
+	stack = &projectstack.Stack{
+		StackConfiguration: projectstack.StackConfiguration{
+			Name: "dev",
+		},
+	}
+	localFile = models.Resource{
+		ID:   "hashicorp:local:local_file:kusion_example",
+		Type: "Terraform",
+		Extensions: map[string]interface{}{
+			"provider":     "registry.terraform.io/hashicorp/local/2.2.3",
+			"resourceType": "local_file",
+		},
+	}
+	localLock = &tfops.ProviderLock{
+		Source:  "registry.terraform.io/hashicorp/local",
+		Version: "2.2.3",
+		Hashes:  []string{"h1:local"},
+	}
+)
+
+func TestLockOptions_Validate(t *testing.T) {
+	o := NewLockOptions()
+	o.Platforms = []string{"linux_amd64", "darwin_arm64"}
+	assert.Nil(t, o.Validate())
+
+	o.Platforms = []string{"linux"}
+	assert.EqualError(t, o.Validate(), "invalid --platform linux, must be like linux_amd64")
+}
+
+func TestLockOptions_Run(t *testing.T) {
+	t.Run("lock providers", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		mockDetectProjectAndStack()
+		mockGenerateSpec(localFile)
+		var gotAddrs, gotPlatforms []string
+		monkey.Patch(tfops.LockProviders, func(_ context.Context, addrs []string, platforms []string) (*tfops.Lock, error) {
+			gotAddrs, gotPlatforms = addrs, platforms
+			return &tfops.Lock{Providers: []*tfops.ProviderLock{localLock}}, nil
+		})
+
+		o := NewLockOptions()
+		o.WorkDir = t.TempDir()
+		o.Platforms = []string{"linux_amd64"}
+		assert.Nil(t, o.Run())
+		assert.Equal(t, []string{"registry.terraform.io/hashicorp/local/2.2.3"}, gotAddrs)
+		assert.Equal(t, []string{"linux_amd64"}, gotPlatforms)
+
+		lock, err := tfops.ReadLock(o.WorkDir)
+		assert.Nil(t, err)
+		assert.Equal(t, &tfops.Lock{Providers: []*tfops.ProviderLock{localLock}}, lock)
+	})
+
+	t.Run("no providers", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		mockDetectProjectAndStack()
+		mockGenerateSpec(models.Resource{ID: "v1:Namespace:foo", Type: "Kubernetes"})
+
+		o := NewLockOptions()
+		o.WorkDir = t.TempDir()
+		assert.Nil(t, o.Run())
+
+		lock, err := tfops.ReadLock(o.WorkDir)
+		assert.Nil(t, err)
+		assert.Nil(t, lock)
+	})
+
+	t.Run("lock failed", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		mockDetectProjectAndStack()
+		mockGenerateSpec(localFile)
+		monkey.Patch(tfops.LockProviders, func(context.Context, []string, []string) (*tfops.Lock, error) {
+			return nil, errors.New("terraform providers lock failed")
+		})
+
+		o := NewLockOptions()
+		o.WorkDir = t.TempDir()
+		assert.EqualError(t, o.Run(), "terraform providers lock failed")
+	})
+}
+
+func mockDetectProjectAndStack() {
+	monkey.Patch(projectstack.DetectProjectAndStack, func(stackDir string) (*projectstack.Project, *projectstack.Stack, error) {
+		project.Path = stackDir
+		stack.Path = stackDir
+		return project, stack, nil
+	})
+}
+
+func mockGenerateSpec(resources ...models.Resource) {
+	monkey.Patch(spec.GenerateSpecWithSpinner, func(o *generator.Options, project *projectstack.Project, stack *projectstack.Stack) (*models.Spec, error) {
+		return &models.Spec{Resources: resources}, nil
+	})
+}
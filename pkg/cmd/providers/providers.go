@@ -0,0 +1,28 @@
+package providers
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	providersShort = "Manage Terraform providers of a stack"
+
+	providersLong = `
+		Manage Terraform providers used by resources of the stack in the work directory.`
+)
+
+func NewCmdProviders() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "providers",
+		Short: i18n.T(providersShort),
+		Long:  templates.LongDesc(i18n.T(providersLong)),
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+	cmd.AddCommand(NewCmdLock())
+	return cmd
+}
@@ -0,0 +1,95 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"kusionstack.io/kusion/pkg/cmd/build"
+	compilecmd "kusionstack.io/kusion/pkg/cmd/compile"
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/util/gitutil"
+	"kusionstack.io/kusion/pkg/util/oci"
+)
+
+// manifestsFile is the file of compiled manifests in the artifact
+const manifestsFile = "manifests.yaml"
+
+type PushOptions struct {
+	compilecmd.CompileOptions
+	Reference        string
+	Source           string
+	Revision         string
+	InsecureRegistry bool
+
+	ref *oci.Reference
+}
+
+func NewPushOptions() *PushOptions {
+	return &PushOptions{CompileOptions: *compilecmd.NewCompileOptions()}
+}
+
+func (o *PushOptions) Complete(args []string) {
+	if len(args) > 0 {
+		o.Reference = args[0]
+	}
+	o.CompileOptions.Complete(nil)
+
+	// the source and revision are read from the git repository by default, as flux push artifact does
+	if o.Source == "" {
+		o.Source, _ = gitutil.GetRemoteURL()
+	}
+	if o.Revision == "" {
+		if sha, err := gitutil.GetHeadHash(); err == nil {
+			o.Revision = "sha1:" + sha
+			if branch, err := gitutil.GetCurrentBranch(); err == nil && branch != "" {
+				o.Revision = branch + "@" + o.Revision
+			}
+		}
+	}
+}
+
+func (o *PushOptions) Validate() error {
+	ref, err := oci.ParseReference(o.Reference)
+	if err != nil {
+		return err
+	}
+	o.ref = ref
+	return o.CompileOptions.Validate()
+}
+
+func (o *PushOptions) Run() error {
+	project, stack, err := projectstack.DetectProjectAndStack(o.WorkDir)
+	if err != nil {
+		return err
+	}
+	sp, err := spec.GenerateSpecWithSpinner(o.GeneratorOptions(), project, stack)
+	if err != nil {
+		return err
+	}
+
+	manifests := &bytes.Buffer{}
+	if err = build.WriteManifests(manifests, sp.Resources); err != nil {
+		return err
+	}
+	content, err := oci.TarGz(map[string][]byte{manifestsFile: manifests.Bytes()})
+	if err != nil {
+		return err
+	}
+	annotations := map[string]string{oci.AnnotationCreated: time.Now().UTC().Format(time.RFC3339)}
+	if o.Source != "" {
+		annotations[oci.AnnotationSource] = o.Source
+	}
+	if o.Revision != "" {
+		annotations[oci.AnnotationRevision] = o.Revision
+	}
+
+	digest, err := oci.NewClient(o.InsecureRegistry).PushFluxArtifact(context.Background(), o.ref, content, annotations)
+	if err != nil {
+		return fmt.Errorf("push %s failed: %w", o.ref, err)
+	}
+	fmt.Printf("Pushed %s@%s\n", o.ref, digest)
+	return nil
+}
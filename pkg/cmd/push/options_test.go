@@ -0,0 +1,70 @@
+package push
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/util/oci"
+)
+
+func TestPushOptions_Validate(t *testing.T) {
+	o := NewPushOptions()
+	o.Reference = "ghcr.io/org/app:v1"
+	assert.ErrorContains(t, o.Validate(), "must start with oci://")
+
+	o.Reference = "oci://ghcr.io/org/app:v1"
+	assert.Nil(t, o.Validate())
+	assert.Equal(t, "v1", o.ref.Tag)
+}
+
+func TestPushOptions_Run(t *testing.T) {
+	var manifest string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPost:
+			w.Header().Set("Location", "/v2/app/blobs/uploads/1")
+			w.WriteHeader(http.StatusAccepted)
+		case strings.Contains(r.URL.Path, "/manifests/"):
+			manifest = string(body)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	defer monkey.UnpatchAll()
+	monkey.Patch(projectstack.DetectProjectAndStack, func(stackDir string) (*projectstack.Project, *projectstack.Stack, error) {
+		return &projectstack.Project{}, &projectstack.Stack{}, nil
+	})
+	monkey.Patch(spec.GenerateSpecWithSpinner, func(o *generator.Options, project *projectstack.Project, stack *projectstack.Stack) (*models.Spec, error) {
+		return &models.Spec{Resources: models.Resources{{
+			ID:         "v1:Namespace:web",
+			Type:       runtime.Kubernetes,
+			Attributes: map[string]interface{}{"apiVersion": "v1", "kind": "Namespace"},
+		}}}, nil
+	})
+
+	o := NewPushOptions()
+	o.Reference = oci.Scheme + strings.TrimPrefix(server.URL, "http://") + "/app:dev"
+	o.InsecureRegistry = true
+	o.Revision = "main@sha1:abc"
+	assert.Nil(t, o.Validate())
+	assert.Nil(t, o.Run())
+	assert.Contains(t, manifest, oci.FluxContentMediaType)
+	assert.Contains(t, manifest, `"org.opencontainers.image.revision":"main@sha1:abc"`)
+}
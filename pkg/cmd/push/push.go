@@ -0,0 +1,58 @@
+package push
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	pushShort = "Push the stack as a Flux OCI artifact"
+
+	pushLong = `
+		Compile the stack and push its Kubernetes manifests to the OCI registry as an artifact compatible with
+		Flux, which can be delivered by the OCIRepository of the Flux source controller and applied by Flux
+		Kustomizations, while Kusion models the stack.
+
+		The artifact has the same media types and annotations as the ones pushed by flux push artifact.
+		Its source and revision are read from the git repository of the work directory unless specified.
+		Credentials of the registry are read from the docker config, i.e. logged in by docker login.`
+
+	pushExample = `
+		# Push the stack in the current directory
+		kusion push oci://ghcr.io/org/app/dev:v1.0.0
+
+		# Push the stack to a local registry over HTTP
+		kusion push oci://localhost:5000/app/dev --insecure-registry`
+)
+
+func NewCmdPush() *cobra.Command {
+	o := NewPushOptions()
+
+	cmd := &cobra.Command{
+		Use:     "push oci://REGISTRY/REPOSITORY:TAG",
+		Short:   i18n.T(pushShort),
+		Long:    templates.LongDesc(i18n.T(pushLong)),
+		Example: templates.Examples(i18n.T(pushExample)),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			defer util.RecoverErr(&err)
+			o.Complete(args)
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+			return
+		},
+	}
+
+	o.AddCompileFlags(cmd)
+	cmd.Flags().StringVarP(&o.Source, "source", "", "",
+		i18n.T("Specify the source of the artifact, the git remote URL by default"))
+	cmd.Flags().StringVarP(&o.Revision, "revision", "", "",
+		i18n.T("Specify the revision of the artifact, e.g. main@sha1:<commit>, the current git commit by default"))
+	cmd.Flags().BoolVarP(&o.InsecureRegistry, "insecure-registry", "", false,
+		i18n.T("Connect to the registry over HTTP instead of HTTPS"))
+
+	return cmd
+}
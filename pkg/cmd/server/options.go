@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/pterm/pterm"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	enginev1 "kusionstack.io/kusion/pkg/apis/engine/v1"
+	"kusionstack.io/kusion/pkg/engine/backend"
+	"kusionstack.io/kusion/pkg/server"
+)
+
+// DefaultGRPCAddr is the default address of the gRPC server, which is only reachable locally
+const DefaultGRPCAddr = "127.0.0.1:9090"
+
+type ServerOptions struct {
+	GRPCAddr string
+	Root     string
+	backend.BackendOps
+
+	OIDCIssuerURL     string
+	OIDCClientID      string
+	OIDCUsernameClaim string
+	OIDCGroupsClaim   string
+	RBACFile          string
+
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
+func NewServerOptions() *ServerOptions {
+	return &ServerOptions{}
+}
+
+func (o *ServerOptions) Complete(_ []string) {
+	if o.Root == "" {
+		o.Root, _ = os.Getwd()
+	}
+}
+
+func (o *ServerOptions) Validate() error {
+	if o.GRPCAddr == "" {
+		return fmt.Errorf("the gRPC address is required")
+	}
+	info, err := os.Stat(o.Root)
+	if err != nil {
+		return fmt.Errorf("invalid root directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("invalid root directory: %s is not a directory", o.Root)
+	}
+	// users can only be authorized with role bindings after they are authenticated, and vice versa
+	if (o.OIDCIssuerURL == "") != (o.RBACFile == "") {
+		return fmt.Errorf("--oidc-issuer-url and --rbac-file must be specified together")
+	}
+	if o.OIDCIssuerURL != "" && o.OIDCClientID == "" {
+		return fmt.Errorf("--oidc-client-id is required to verify ID tokens")
+	}
+	if (o.TLSCertFile == "") != (o.TLSKeyFile == "") {
+		return fmt.Errorf("--tls-cert and --tls-key must be specified together")
+	}
+	// bearer tokens must not be sent in plaintext over the network
+	if o.OIDCIssuerURL != "" && o.TLSCertFile == "" && !isLoopback(o.GRPCAddr) {
+		return fmt.Errorf("--tls-cert and --tls-key are required to authenticate users at the non-loopback address %s", o.GRPCAddr)
+	}
+	return nil
+}
+
+// isLoopback returns whether the address is only reachable locally
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (o *ServerOptions) Run() error {
+	// diffs in events are read by programs, not terminals
+	pterm.DisableColor()
+
+	listener, err := net.Listen("tcp", o.GRPCAddr)
+	if err != nil {
+		return fmt.Errorf("listen at %s failed: %w", o.GRPCAddr, err)
+	}
+	engine := server.NewEngineServer(o.Root)
+	engine.BackendOps = o.BackendOps
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var opts []grpc.ServerOption
+	if o.TLSCertFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(o.TLSCertFile, o.TLSKeyFile)
+		if err != nil {
+			return fmt.Errorf("load the TLS certificate failed: %w", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	if o.RBACFile != "" {
+		if engine.RBAC, err = server.LoadRBAC(o.RBACFile); err != nil {
+			return err
+		}
+		authenticator, err := server.NewOIDCAuthenticator(ctx, o.OIDCIssuerURL, o.OIDCClientID,
+			o.OIDCUsernameClaim, o.OIDCGroupsClaim)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, server.AuthOptions(authenticator)...)
+	}
+	fmt.Printf("Serving gRPC at %s, stacks in %s\n", listener.Addr(), o.Root)
+	return Serve(ctx, listener, engine, opts...)
+}
+
+// Serve serves the engine at the listener until the context is done, then waits for running operations to
+// finish, since interrupting an apply leaves resources half applied
+func Serve(ctx context.Context, listener net.Listener, engine enginev1.EngineServer, opts ...grpc.ServerOption) error {
+	s := grpc.NewServer(opts...)
+	enginev1.RegisterEngineServer(s, engine)
+	go func() {
+		<-ctx.Done()
+		s.GracefulStop()
+	}()
+	return s.Serve(listener)
+}
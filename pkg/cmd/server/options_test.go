@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kusionstack.io/kusion/pkg/server"
+)
+
+func TestServerOptions_Validate(t *testing.T) {
+	o := NewServerOptions()
+	o.GRPCAddr = DefaultGRPCAddr
+	o.Complete(nil)
+	assert.NoError(t, o.Validate())
+
+	o.Root = filepath.Join(t.TempDir(), "missing")
+	assert.ErrorContains(t, o.Validate(), "invalid root directory")
+
+	o.Root, o.GRPCAddr = t.TempDir(), ""
+	assert.ErrorContains(t, o.Validate(), "address is required")
+}
+
+func TestServe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Serve(ctx, listener, server.NewEngineServer(t.TempDir()))
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server is not stopped")
+	}
+}
+
+func TestServerOptions_ValidateRBAC(t *testing.T) {
+	o := NewServerOptions()
+	o.GRPCAddr, o.Root = DefaultGRPCAddr, t.TempDir()
+
+	o.RBACFile = "rbac.yaml"
+	assert.ErrorContains(t, o.Validate(), "must be specified together")
+
+	o.OIDCIssuerURL = "https://accounts.example.com"
+	assert.ErrorContains(t, o.Validate(), "--oidc-client-id is required")
+
+	o.OIDCClientID = "kusion"
+	assert.NoError(t, o.Validate())
+}
+
+func TestServerOptions_ValidateTLS(t *testing.T) {
+	o := NewServerOptions()
+	o.GRPCAddr, o.Root = "0.0.0.0:9090", t.TempDir()
+	o.OIDCIssuerURL, o.OIDCClientID, o.RBACFile = "https://accounts.example.com", "kusion", "rbac.yaml"
+	assert.ErrorContains(t, o.Validate(), "--tls-cert and --tls-key are required")
+
+	o.TLSCertFile = "server.crt"
+	assert.ErrorContains(t, o.Validate(), "must be specified together")
+
+	o.TLSKeyFile = "server.key"
+	assert.NoError(t, o.Validate())
+
+	o.TLSCertFile, o.TLSKeyFile = "", ""
+	for _, addr := range []string{"127.0.0.1:9090", "localhost:9090", "[::1]:9090"} {
+		o.GRPCAddr = addr
+		assert.NoError(t, o.Validate(), addr)
+	}
+	o.GRPCAddr = ":9090"
+	assert.Error(t, o.Validate())
+}
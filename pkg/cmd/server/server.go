@@ -0,0 +1,82 @@
+package server
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/server"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	serverShort = "Serve the engine over gRPC"
+
+	serverLong = `
+		Serve the engine over gRPC, so that integrations in any language can preview and apply stacks,
+		stream the progress of operations and read states, with the service defined in
+		pkg/apis/engine/v1/engine.proto.
+
+		Work directories of requests are relative to the root directory, and stacks out of it are
+		never operated on. Stacks which can only be applied from signed spec files can not be applied
+		over gRPC.
+
+		With --oidc-issuer-url and --rbac-file, calls must carry ID tokens of the OpenID Connect provider
+		as bearer tokens in the authorization metadata, and users are authorized on stacks with role
+		bindings in the file. Roles are viewer (read states and queues), previewer (preview and dry run),
+		applier (apply and cancel own applies) and admin (cancel applies of others), each including the
+		roles before it. Since bearer tokens must not be sent in plaintext, --tls-cert and --tls-key are
+		required unless the server is only reachable at a loopback address.`
+
+	serverExample = `
+		# Serve stacks in the current directory at the default address
+		kusion server
+
+		# Serve stacks in the directory at the address
+		kusion server --root ./projects --grpc-addr 127.0.0.1:9090
+
+		# Serve stacks to users of the OpenID Connect provider with role bindings
+		kusion server --grpc-addr 0.0.0.0:9090 --oidc-issuer-url https://accounts.example.com \
+		  --oidc-client-id kusion --rbac-file rbac.yaml --tls-cert server.crt --tls-key server.key`
+)
+
+func NewCmdServer() *cobra.Command {
+	o := NewServerOptions()
+
+	cmd := &cobra.Command{
+		Use:     "server",
+		Short:   i18n.T(serverShort),
+		Long:    templates.LongDesc(i18n.T(serverLong)),
+		Example: templates.Examples(i18n.T(serverExample)),
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			defer util.RecoverErr(&err)
+			o.Complete(args)
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+			return
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.GRPCAddr, "grpc-addr", "", DefaultGRPCAddr,
+		i18n.T("Specify the address to serve gRPC at"))
+	cmd.Flags().StringVarP(&o.Root, "root", "", "",
+		i18n.T("Specify the root directory of stacks, the current directory by default"))
+	cmd.Flags().StringVarP(&o.OIDCIssuerURL, "oidc-issuer-url", "", "",
+		i18n.T("Specify the URL of the OpenID Connect provider issuing ID tokens of users"))
+	cmd.Flags().StringVarP(&o.OIDCClientID, "oidc-client-id", "", "",
+		i18n.T("Specify the client ID which ID tokens must be issued to"))
+	cmd.Flags().StringVarP(&o.OIDCUsernameClaim, "oidc-username-claim", "", server.DefaultUsernameClaim,
+		i18n.T("Specify the claim of ID tokens identifying users"))
+	cmd.Flags().StringVarP(&o.OIDCGroupsClaim, "oidc-groups-claim", "", server.DefaultGroupsClaim,
+		i18n.T("Specify the claim of ID tokens listing groups of users"))
+	cmd.Flags().StringVarP(&o.RBACFile, "rbac-file", "", "",
+		i18n.T("Specify the file of role bindings authorizing users on stacks"))
+	cmd.Flags().StringVarP(&o.TLSCertFile, "tls-cert", "", "",
+		i18n.T("Specify the PEM-encoded certificate file to serve gRPC over TLS"))
+	cmd.Flags().StringVarP(&o.TLSKeyFile, "tls-key", "", "",
+		i18n.T("Specify the PEM-encoded private key file of the TLS certificate"))
+	o.AddBackendFlags(cmd)
+
+	return cmd
+}
@@ -0,0 +1,41 @@
+package spec
+
+import (
+	"fmt"
+	"os"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+)
+
+// ParseSpec parses resources from the output of kusion compile, or a spec with the resources field
+func ParseSpec(data []byte) (models.Resources, error) {
+	var resources models.Resources
+	if err := yamlv3.Unmarshal(data, &resources); err == nil {
+		return resources, nil
+	}
+	sp := &models.Spec{}
+	if err := yamlv3.Unmarshal(data, sp); err != nil {
+		return nil, fmt.Errorf("invalid spec: %w", err)
+	}
+	return sp.Resources, nil
+}
+
+// LoadSpecFile loads the spec from the file compiled by kusion compile
+func LoadSpecFile(path string) (*models.Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSpecFile(path, data)
+}
+
+// ParseSpecFile parses the spec from the data read from the file compiled by kusion compile
+func ParseSpecFile(path string, data []byte) (*models.Spec, error) {
+	resources, err := ParseSpec(data)
+	if err != nil {
+		return nil, fmt.Errorf("load spec from %s failed: %w", path, err)
+	}
+	return &models.Spec{Resources: resources}, nil
+}
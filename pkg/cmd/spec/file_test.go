@@ -0,0 +1,49 @@
+package spec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	resourcesSpec = `- id: v1:ConfigMap:default:a
+  type: Kubernetes
+  attributes: {}
+- id: v1:ConfigMap:default:b
+  type: Kubernetes
+  attributes: {}
+`
+	fieldSpec = `resources:
+- id: v1:ConfigMap:default:a
+  type: Kubernetes
+  attributes: {}
+`
+)
+
+func TestParseSpec(t *testing.T) {
+	resources, err := ParseSpec([]byte(resourcesSpec))
+	assert.Nil(t, err)
+	assert.Len(t, resources, 2)
+
+	resources, err = ParseSpec([]byte(fieldSpec))
+	assert.Nil(t, err)
+	assert.Len(t, resources, 1)
+
+	_, err = ParseSpec([]byte("a: ["))
+	assert.Error(t, err)
+}
+
+func TestLoadSpecFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "spec.yaml")
+	assert.Nil(t, os.WriteFile(file, []byte(resourcesSpec), 0o644))
+	sp, err := LoadSpecFile(file)
+	assert.Nil(t, err)
+	assert.Equal(t, "v1:ConfigMap:default:b", sp.Resources[1].ID)
+
+	assert.Nil(t, os.WriteFile(file, []byte("a: ["), 0o644))
+	_, err = LoadSpecFile(file)
+	assert.ErrorContains(t, err, "load spec from "+file+" failed")
+}
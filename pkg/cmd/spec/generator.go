@@ -2,13 +2,32 @@ package spec
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/pterm/pterm"
 
 	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/validation"
 	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/generator/applyset"
+	"kusionstack.io/kusion/pkg/generator/cache"
+	"kusionstack.io/kusion/pkg/generator/cue"
+	"kusionstack.io/kusion/pkg/generator/helm"
+	"kusionstack.io/kusion/pkg/generator/inheritance"
+	"kusionstack.io/kusion/pkg/generator/interpolation"
+	"kusionstack.io/kusion/pkg/generator/jsonnet"
 	"kusionstack.io/kusion/pkg/generator/kcl"
+	"kusionstack.io/kusion/pkg/generator/kustomize"
+	"kusionstack.io/kusion/pkg/generator/manifest"
+	"kusionstack.io/kusion/pkg/generator/mutator"
+	"kusionstack.io/kusion/pkg/generator/settings"
+	"kusionstack.io/kusion/pkg/generator/sops"
+	// register built-in resource generators
+	_ "kusionstack.io/kusion/pkg/generator/workload"
 	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/util/kfile"
 	"kusionstack.io/kusion/pkg/util/pretty"
 )
 
@@ -21,25 +40,7 @@ func GenerateSpecWithSpinner(o *generator.Options, project *projectstack.Project
 		sp, _ = sp.Start(fmt.Sprintf("Generating Spec in the Stack %s...", stack.Name))
 	}
 
-	// Choose the generator
-	var g generator.Generator
-	pg := project.Generator
-
-	// default Generator
-	if pg == nil {
-		g = &kcl.Generator{}
-	} else {
-		gt := pg.Type
-		// we can add more generators here
-		switch gt {
-		case projectstack.KCLGenerator:
-			g = &kcl.Generator{}
-		default:
-			return nil, fmt.Errorf("unknow generator type:%s", gt)
-		}
-	}
-
-	spec, err := g.GenerateSpec(o, stack)
+	spec, err := GenerateSpec(o, project, stack)
 	if err != nil {
 		if sp != nil {
 			sp.Fail()
@@ -54,3 +55,166 @@ func GenerateSpecWithSpinner(o *generator.Options, project *projectstack.Project
 
 	return spec, nil
 }
+
+// GenerateSpec generates the spec of the stack with the generator configured in the project,
+// then mutates and validates it
+func GenerateSpec(o *generator.Options, project *projectstack.Project, stack *projectstack.Stack) (*models.Spec, error) {
+	// layer values files of the project and the stack under arguments given on the command line
+	values, _, err := generator.LoadValues(project.Path, stack.Path)
+	if err != nil {
+		return nil, err
+	}
+	opts := *o
+	if opts.Arguments, err = generator.LayerArguments(values, o.Arguments); err != nil {
+		return nil, err
+	}
+	// merge values set by paths into top-level arguments
+	if opts.Arguments, err = generator.MergeArguments(opts.Arguments, o.Sets); err != nil {
+		return nil, err
+	}
+	opts.Sets = nil
+
+	// run resource generators registered by platform teams on the generated spec
+	rgs, err := generator.NewResourceGenerators(project, stack)
+	if err != nil {
+		return nil, err
+	}
+
+	// mutators configured in the project run on the generated spec in order
+	mutators, err := mutator.NewMutators(project)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := generateStackSpec(&opts, project, stack, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	for _, rg := range rgs {
+		if err = rg.GenerateResources(spec); err != nil {
+			return nil, err
+		}
+	}
+	if err = mutator.Chain(mutators).Mutate(spec); err != nil {
+		return nil, err
+	}
+	// label Kubernetes resources as an ApplySet, so that kubectl can tell objects of the stack apart
+	applyset.Label(spec, project, stack)
+	// validate the spec before any operation, so that malformed resources fail fast with precise errors
+	warnings, err := validation.ValidateSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range warnings {
+		pterm.Warning.Println(w.Error())
+	}
+	return spec, nil
+}
+
+// generateStackSpec generates the spec of the stack merged onto the spec of its base stack if any.
+// Visited records stacks in the chain of base stacks to detect cycles.
+func generateStackSpec(o *generator.Options, project *projectstack.Project, stack *projectstack.Stack, visited map[string]bool) (*models.Spec, error) {
+	if visited[stack.Path] {
+		return nil, fmt.Errorf("circular base stacks found in the stack %s", stack.Name)
+	}
+	visited[stack.Path] = true
+
+	// decrypt SOPS-encrypted settings files, then resolve ${env:VAR} and ${file:path} references in them
+	ip := &interpolation.Interpolator{Allowlist: project.Interpolation, ProjectDir: project.Path}
+	processed, cleanup, err := settings.Process(o.WorkDir, o.Settings, sops.Decrypt, ip.Process)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	opts := *o
+	opts.Settings = processed
+
+	// specs compiled with decrypted or interpolated settings may contain secrets, never cache them on disk
+	g, err := newGenerator(project, stack, !settings.Changed(o.Settings, processed))
+	if err != nil {
+		return nil, err
+	}
+	if stack.Base != "" {
+		base, err := projectstack.GetStackFrom(filepath.Join(stack.Path, stack.Base))
+		if err != nil {
+			return nil, fmt.Errorf("get the base stack %s of the stack %s failed: %w", stack.Base, stack.Name, err)
+		}
+		g = &inheritance.Generator{Generator: g, Base: func() (*models.Spec, error) {
+			// the base stack is compiled in its own directory with its default settings
+			bo := *o
+			bo.WorkDir = base.Path
+			bo.Filenames = nil
+			bo.Overrides = nil
+			bo.Settings = DefaultSettings(base.Path)
+			return generateStackSpec(&bo, project, base, visited)
+		}}
+	}
+
+	return g.GenerateSpec(&opts, stack)
+}
+
+// newGenerator returns the generator configured in the project, KCL is the default one. Cacheable is false if
+// generated specs must not be cached.
+func newGenerator(project *projectstack.Project, stack *projectstack.Stack, cacheable bool) (generator.Generator, error) {
+	pg := project.Generator
+	gt := projectstack.KCLGenerator
+	if pg != nil && pg.Type != "" {
+		gt = pg.Type
+	}
+
+	// we can add more generators here
+	switch gt {
+	case projectstack.KCLGenerator:
+		g := newKCLGenerator(project, stack, cacheable)
+		// merge resources of the kustomization in the stack directory
+		if kustomize.HasKustomization(stack.Path) {
+			g = &kustomize.MergedGenerator{Generator: g}
+		}
+		return g, nil
+	case projectstack.ManifestGenerator:
+		return manifest.NewGenerator(pg.Configs)
+	case projectstack.HelmGenerator:
+		g, err := helm.NewGenerator(pg.Configs)
+		if err != nil {
+			return nil, err
+		}
+		// namespaces are only set on resources namespaced in the cluster of the stack
+		g.Scope = manifest.NewClusterScope(runtime.Env(stack.RuntimeEnv(project)).Getenv)
+		return g, nil
+	case projectstack.KustomizeGenerator:
+		return kustomize.NewGenerator(pg.Configs)
+	case projectstack.CUEGenerator:
+		return cue.NewGenerator(pg.Configs)
+	case projectstack.JsonnetGenerator:
+		return jsonnet.NewGenerator(pg.Configs)
+	default:
+		return nil, fmt.Errorf("unknow generator type:%s", gt)
+	}
+}
+
+// DefaultSettings returns the default settings files which exist in the stack directory
+func DefaultSettings(stackDir string) []string {
+	result := []string{}
+	for _, setting := range []string{filepath.Join(projectstack.CiTestDir, projectstack.SettingsFile), projectstack.KclFile} {
+		if _, err := os.Stat(filepath.Join(stackDir, setting)); err == nil {
+			result = append(result, setting)
+		}
+	}
+	return result
+}
+
+// newKCLGenerator returns the KCL generator, which skips the compilation if nothing changed since the last time
+func newKCLGenerator(project *projectstack.Project, stack *projectstack.Stack, cacheable bool) generator.Generator {
+	if !cacheable || !cache.Enabled() {
+		return &kcl.Generator{}
+	}
+	dataDir, err := kfile.KusionDataFolder()
+	if err != nil {
+		return &kcl.Generator{}
+	}
+	return &cache.Generator{
+		Generator: &kcl.Generator{},
+		Root:      cache.FindRoot(stack.Path, project.Path),
+		Dir:       filepath.Join(dataDir, "cache", "spec"),
+	}
+}
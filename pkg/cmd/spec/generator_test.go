@@ -0,0 +1,60 @@
+package spec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/generator/cache"
+	"kusionstack.io/kusion/pkg/generator/kcl"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/util/kfile"
+)
+
+func writeStack(t *testing.T, dir, content string) *projectstack.Stack {
+	assert.Nil(t, os.MkdirAll(dir, 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, projectstack.StackFile), []byte(content), 0o644))
+	stack, err := projectstack.GetStackFrom(dir)
+	assert.Nil(t, err)
+	return stack
+}
+
+func Test_generateStackSpec_circularBase(t *testing.T) {
+	dir := t.TempDir()
+	writeStack(t, filepath.Join(dir, "base"), "name: base\nbase: ../prod\n")
+	prod := writeStack(t, filepath.Join(dir, "prod"), "name: prod\nbase: ../base\n")
+
+	_, err := generateStackSpec(&generator.Options{WorkDir: prod.Path}, &projectstack.Project{Path: dir}, prod, map[string]bool{})
+	assert.ErrorContains(t, err, "circular base stacks found in the stack prod")
+}
+
+func Test_generateStackSpec_missingBase(t *testing.T) {
+	dir := t.TempDir()
+	prod := writeStack(t, filepath.Join(dir, "prod"), "name: prod\nbase: ../base\n")
+
+	_, err := generateStackSpec(&generator.Options{WorkDir: prod.Path}, &projectstack.Project{Path: dir}, prod, map[string]bool{})
+	assert.ErrorContains(t, err, "get the base stack ../base of the stack prod failed")
+}
+
+func Test_newKCLGenerator(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(kfile.EnvKusionPath, dir)
+	stack := &projectstack.Stack{Path: dir}
+	project := &projectstack.Project{Path: dir}
+
+	_, ok := newKCLGenerator(project, stack, true).(*cache.Generator)
+	assert.True(t, ok)
+	_, ok = newKCLGenerator(project, stack, false).(*kcl.Generator)
+	assert.True(t, ok)
+}
+
+func TestDefaultSettings(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, []string{}, DefaultSettings(dir))
+
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, projectstack.KclFile), []byte{}, 0o644))
+	assert.Equal(t, []string{projectstack.KclFile}, DefaultSettings(dir))
+}
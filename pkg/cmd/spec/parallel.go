@@ -0,0 +1,74 @@
+package spec
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/util/pretty"
+)
+
+// OptionsFunc returns the generator options of the stack
+type OptionsFunc func(stack *projectstack.Stack) *generator.Options
+
+// GenerateSpecs generates specs of the stacks concurrently by at most parallelism workers, and returns
+// the specs indexed by the stack name. Stacks are all generated even if some of them fail, and the errors
+// are aggregated per stack in the order of the stacks.
+func GenerateSpecs(
+	project *projectstack.Project,
+	stacks []*projectstack.Stack,
+	optionsOf OptionsFunc,
+	parallelism int,
+) (map[string]*models.Spec, error) {
+	if parallelism <= 0 {
+		parallelism = runtime.NumCPU()
+	}
+	if parallelism > len(stacks) {
+		parallelism = len(stacks)
+	}
+
+	specs := make([]*models.Spec, len(stacks))
+	errs := make([]error, len(stacks))
+	indices := make(chan int)
+	var printMu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				stack := stacks[i]
+				specs[i], errs[i] = GenerateSpec(optionsOf(stack), project, stack)
+
+				printMu.Lock()
+				if errs[i] != nil {
+					pretty.Error.Printfln("Generate Spec in the Stack %s failed", stack.Name)
+				} else {
+					pretty.Success.Printfln("Generated Spec in the Stack %s", stack.Name)
+				}
+				printMu.Unlock()
+			}
+		}()
+	}
+	for i := range stacks {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	result := make(map[string]*models.Spec, len(stacks))
+	var merr *multierror.Error
+	for i, stack := range stacks {
+		if errs[i] != nil {
+			merr = multierror.Append(merr, fmt.Errorf("stack %s: %w", stack.Name, errs[i]))
+			continue
+		}
+		result[stack.Name] = specs[i]
+	}
+	return result, merr.ErrorOrNil()
+}
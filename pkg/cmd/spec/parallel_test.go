@@ -0,0 +1,48 @@
+package spec
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func TestGenerateSpecs(t *testing.T) {
+	defer monkey.UnpatchAll()
+	var running, maxRunning int32
+	monkey.Patch(GenerateSpec, func(o *generator.Options, project *projectstack.Project, stack *projectstack.Stack) (*models.Spec, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		if stack.Name == "prod" || stack.Name == "test" {
+			return nil, errors.New("compile failed")
+		}
+		return &models.Spec{Resources: models.Resources{{ID: o.WorkDir}}}, nil
+	})
+
+	project := &projectstack.Project{}
+	stacks := []*projectstack.Stack{}
+	for _, name := range []string{"dev", "pre", "prod", "test"} {
+		stacks = append(stacks, &projectstack.Stack{StackConfiguration: projectstack.StackConfiguration{Name: name}, Path: "/" + name})
+	}
+	specs, err := GenerateSpecs(project, stacks, func(stack *projectstack.Stack) *generator.Options {
+		return &generator.Options{WorkDir: stack.Path}
+	}, 2)
+
+	assert.EqualError(t, err, "2 errors occurred:\n\t* stack prod: compile failed\n\t* stack test: compile failed\n\n")
+	assert.Len(t, specs, 2)
+	assert.Equal(t, "/dev", specs["dev"].Resources[0].ID)
+	assert.Equal(t, "/pre", specs["pre"].Resources[0].ID)
+	assert.LessOrEqual(t, maxRunning, int32(2))
+}
@@ -0,0 +1,60 @@
+package state
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	exportTerraformShort = "Export the state of a stack to a Terraform state"
+
+	exportTerraformLong = `
+		Export resources of the Terraform runtime in the state of the stack in the work directory to a
+		Terraform state file, so that they can be managed by Terraform instead of Kusion.
+
+		Each resource is exported with the address of its resource type and the last part of its ID, e.g.
+		hashicorp:local:local_file:example is exported as local_file.example, which is the address in the
+		configuration written by Kusion for Terraform. Resources which can't be represented in Terraform states,
+		such as Kubernetes resources, are skipped.
+
+		A mapping report is printed, which lists the address each resource is exported as, or why it is
+		skipped, and dependencies on skipped resources which Terraform will not know about.`
+
+	exportTerraformExample = `
+		# Export the state of the current stack to terraform.tfstate
+		kusion state export-terraform
+
+		# Export the state to the file, overwriting it if it exists
+		kusion state export-terraform -o migrated.tfstate --force`
+)
+
+func NewCmdExportTerraform() *cobra.Command {
+	o := NewExportTerraformOptions()
+
+	cmd := &cobra.Command{
+		Use:     "export-terraform",
+		Short:   i18n.T(exportTerraformShort),
+		Long:    templates.LongDesc(i18n.T(exportTerraformLong)),
+		Example: templates.Examples(i18n.T(exportTerraformExample)),
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			defer util.RecoverErr(&err)
+			o.Complete()
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+			return
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.WorkDir, "workdir", "w", "",
+		i18n.T("Specify the work directory"))
+	cmd.Flags().StringVarP(&o.OutputFile, "output", "o", o.OutputFile,
+		i18n.T("Specify the Terraform state file to write"))
+	cmd.Flags().BoolVarP(&o.Force, "force", "", false,
+		i18n.T("Overwrite the output file if it exists"))
+	o.AddBackendFlags(cmd)
+
+	return cmd
+}
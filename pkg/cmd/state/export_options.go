@@ -0,0 +1,85 @@
+package state
+
+import (
+	"fmt"
+	"os"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"kusionstack.io/kusion/pkg/engine/backend"
+	"kusionstack.io/kusion/pkg/engine/runtime/terraform/tfops"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+type ExportTerraformOptions struct {
+	WorkDir    string
+	OutputFile string
+	Force      bool
+	backend.BackendOps
+}
+
+func NewExportTerraformOptions() *ExportTerraformOptions {
+	return &ExportTerraformOptions{OutputFile: tfops.TFSTATEFILE}
+}
+
+func (o *ExportTerraformOptions) Complete() {
+	if o.WorkDir == "" {
+		o.WorkDir, _ = os.Getwd()
+	}
+}
+
+func (o *ExportTerraformOptions) Validate() error {
+	if o.OutputFile == "" {
+		return fmt.Errorf("the output file is required")
+	}
+	if _, err := os.Stat(o.OutputFile); err == nil && !o.Force {
+		return fmt.Errorf("%s already exists, specify --force to overwrite it", o.OutputFile)
+	}
+	return nil
+}
+
+func (o *ExportTerraformOptions) Run() error {
+	project, stack, err := projectstack.DetectProjectAndStack(o.WorkDir)
+	if err != nil {
+		return err
+	}
+	storage, err := backend.BackendFromConfig(project.Backend, o.BackendOps, o.WorkDir)
+	if err != nil {
+		return err
+	}
+	state, err := storage.GetLatestState(&states.StateQuery{
+		Tenant:  project.Tenant,
+		Project: project.Name,
+		Stack:   stack.Name,
+	})
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("no state found for the stack %s", stack.Name)
+	}
+
+	data, mappings, err := tfops.ConvertToRawTFState(state.Resources, state.Serial)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(o.OutputFile, data, 0o600); err != nil {
+		return err
+	}
+
+	// the mapping report tells which resources are left behind
+	report, err := yamlv3.Marshal(mappings)
+	if err != nil {
+		return err
+	}
+	exported := 0
+	for i := range mappings {
+		if mappings[i].Address != "" {
+			exported++
+		}
+	}
+	fmt.Print(string(report))
+	fmt.Printf("Exported %d of %d resources to %s\n", exported, len(mappings), o.OutputFile)
+	return nil
+}
@@ -0,0 +1,61 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/engine/states/local"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func TestExportTerraformOptions_Validate(t *testing.T) {
+	o := NewExportTerraformOptions()
+	o.OutputFile = filepath.Join(t.TempDir(), "terraform.tfstate")
+	assert.Nil(t, o.Validate())
+
+	assert.Nil(t, os.WriteFile(o.OutputFile, []byte("{}"), 0o600))
+	assert.NotNil(t, o.Validate())
+	o.Force = true
+	assert.Nil(t, o.Validate())
+}
+
+func TestExportTerraformOptions_Run(t *testing.T) {
+	dir := t.TempDir()
+	monkey.Patch(projectstack.DetectProjectAndStack, func(stackDir string) (*projectstack.Project, *projectstack.Stack, error) {
+		return &projectstack.Project{ProjectConfiguration: projectstack.ProjectConfiguration{Name: "project"}},
+			&projectstack.Stack{StackConfiguration: projectstack.StackConfiguration{Name: "dev"}}, nil
+	})
+	defer monkey.UnpatchAll()
+
+	o := NewExportTerraformOptions()
+	o.WorkDir = dir
+	o.OutputFile = filepath.Join(dir, "terraform.tfstate")
+	assert.NotNil(t, o.Run())
+
+	state := states.NewState()
+	state.Project = "project"
+	state.Stack = "dev"
+	state.Resources = models.Resources{secret, {
+		ID:         "hashicorp:local:local_file:example",
+		Type:       runtime.Terraform,
+		Attributes: map[string]interface{}{"content": "kusion", "filename": "test.txt"},
+		Extensions: map[string]interface{}{
+			"provider":     "registry.terraform.io/hashicorp/local/2.2.3",
+			"resourceType": "local_file",
+		},
+	}}
+	assert.Nil(t, (&local.FileSystemState{Path: filepath.Join(dir, local.KusionState)}).Apply(state))
+	assert.Nil(t, o.Run())
+
+	data, err := os.ReadFile(o.OutputFile)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"type": "local_file"`)
+	assert.NotContains(t, string(data), secret.ID)
+}
@@ -0,0 +1,62 @@
+package state
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	importTerraformShort = "Import resources in a Terraform state into the state of a stack"
+
+	importTerraformLong = `
+		Import managed resources in a Terraform state file into the state of the stack in the work directory,
+		so that existing Terraform resources can be taken over by Kusion incrementally.
+
+		Each resource instance becomes a resource of the Terraform runtime, whose ID is like
+		hashicorp:local:local_file:example, with its attributes and dependencies. Resources in modules
+		are prefixed with their module names, and instances created by count or for_each are suffixed
+		with their index keys.
+
+		Versions of providers are read from the .terraform.lock.hcl next to the state file, or specified
+		by --provider-version. Provider configurations are not recorded in Terraform states, which should
+		be declared by the providerMeta of resources in the configuration of the stack.`
+
+	importTerraformExample = `
+		# Import resources in the Terraform state into the state of the current stack
+		kusion state import-terraform terraform.tfstate
+
+		# Import resources with the version of their provider
+		kusion state import-terraform terraform.tfstate --provider-version hashicorp/local=2.2.3`
+)
+
+func NewCmdImportTerraform() *cobra.Command {
+	o := NewImportTerraformOptions()
+
+	cmd := &cobra.Command{
+		Use:     "import-terraform STATE_FILE",
+		Short:   i18n.T(importTerraformShort),
+		Long:    templates.LongDesc(i18n.T(importTerraformLong)),
+		Example: templates.Examples(i18n.T(importTerraformExample)),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			defer util.RecoverErr(&err)
+			o.Complete(args)
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+			return
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.WorkDir, "workdir", "w", "",
+		i18n.T("Specify the work directory"))
+	cmd.Flags().StringVarP(&o.LockFile, "lock-file", "", "",
+		i18n.T("Specify the Terraform lock file with versions of providers, .terraform.lock.hcl next to the state file by default"))
+	cmd.Flags().StringToStringVarP(&o.ProviderVersions, "provider-version", "", nil,
+		i18n.T("Specify versions of providers, e.g. hashicorp/local=2.2.3"))
+	o.AddBackendFlags(cmd)
+
+	return cmd
+}
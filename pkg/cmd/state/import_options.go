@@ -0,0 +1,139 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kusionstack.io/kusion/pkg/engine/backend"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime/terraform/tfops"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// defaultProviderRegistry is the registry of providers whose sources are specified without hostnames
+const defaultProviderRegistry = "registry.terraform.io"
+
+type ImportTerraformOptions struct {
+	WorkDir          string
+	StateFile        string
+	LockFile         string
+	ProviderVersions map[string]string
+	backend.BackendOps
+}
+
+func NewImportTerraformOptions() *ImportTerraformOptions {
+	return &ImportTerraformOptions{}
+}
+
+func (o *ImportTerraformOptions) Complete(args []string) {
+	if len(args) > 0 {
+		o.StateFile = args[0]
+	}
+	if o.WorkDir == "" {
+		o.WorkDir, _ = os.Getwd()
+	}
+	if o.LockFile == "" && o.StateFile != "" {
+		o.LockFile = filepath.Join(filepath.Dir(o.StateFile), ".terraform.lock.hcl")
+	}
+}
+
+func (o *ImportTerraformOptions) Validate() error {
+	if o.StateFile == "" {
+		return fmt.Errorf("the terraform state file is required")
+	}
+	for source := range o.ProviderVersions {
+		if len(strings.Split(source, "/")) < 2 {
+			return fmt.Errorf("invalid provider %s, must be like hashicorp/local", source)
+		}
+	}
+	return nil
+}
+
+func (o *ImportTerraformOptions) Run() error {
+	data, err := os.ReadFile(o.StateFile)
+	if err != nil {
+		return err
+	}
+	versions, err := o.providerVersions()
+	if err != nil {
+		return err
+	}
+	imported, err := tfops.ConvertRawTFState(data, versions)
+	if err != nil {
+		return err
+	}
+	if len(imported) == 0 {
+		fmt.Println("No managed resources found in the terraform state")
+		return nil
+	}
+
+	project, stack, err := projectstack.DetectProjectAndStack(o.WorkDir)
+	if err != nil {
+		return err
+	}
+	storage, err := backend.BackendFromConfig(project.Backend, o.BackendOps, o.WorkDir)
+	if err != nil {
+		return err
+	}
+	state, err := storage.GetLatestState(&states.StateQuery{
+		Tenant:  project.Tenant,
+		Project: project.Name,
+		Stack:   stack.Name,
+	})
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		state = states.NewState()
+		state.Tenant = project.Tenant
+		state.Project = project.Name
+		state.Stack = stack.Name
+	}
+	if state.Resources, err = mergeResources(state.Resources, imported); err != nil {
+		return err
+	}
+	state.Serial++
+	state.Timings = nil
+	if err = storage.Apply(state); err != nil {
+		return fmt.Errorf("apply state failed: %w", err)
+	}
+	fmt.Printf("Imported %d resources into the state of the stack %s\n", len(imported), stack.Name)
+	return nil
+}
+
+// providerVersions returns versions of providers locked in the lock file if it exists, overridden by the
+// versions specified by --provider-version
+func (o *ImportTerraformOptions) providerVersions() (map[string]string, error) {
+	versions := map[string]string{}
+	if _, err := os.Stat(o.LockFile); err == nil {
+		if versions, err = tfops.ReadProviderVersions(o.LockFile); err != nil {
+			return nil, fmt.Errorf("read lock file %s failed: %w", o.LockFile, err)
+		}
+	}
+	for source, version := range o.ProviderVersions {
+		if len(strings.Split(source, "/")) == 2 {
+			source = defaultProviderRegistry + "/" + source
+		}
+		versions[source] = version
+	}
+	return versions, nil
+}
+
+// mergeResources appends imported resources to the resources in the state. Resources already in the state
+// are not overwritten, since they may be managed by Kusion with different attributes.
+func mergeResources(resources, imported models.Resources) (models.Resources, error) {
+	index := resources.Index()
+	var conflicts []string
+	for i := range imported {
+		if _, ok := index[imported[i].ID]; ok {
+			conflicts = append(conflicts, imported[i].ID)
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("resources already in the state: %s", strings.Join(conflicts, ", "))
+	}
+	return append(resources, imported...), nil
+}
@@ -0,0 +1,69 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/engine/states/local"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+const tfState = `{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "local_file",
+      "name": "example",
+      "provider": "provider[\"registry.terraform.io/hashicorp/local\"]",
+      "instances": [{"attributes": {"content": "kusion", "filename": "test.txt"}}]
+    }
+  ]
+}`
+
+func TestImportTerraformOptions_Validate(t *testing.T) {
+	o := NewImportTerraformOptions()
+	assert.NotNil(t, o.Validate())
+	o.Complete([]string{"terraform.tfstate"})
+	assert.Nil(t, o.Validate())
+	assert.Equal(t, ".terraform.lock.hcl", o.LockFile)
+	o.ProviderVersions = map[string]string{"local": "2.2.3"}
+	assert.NotNil(t, o.Validate())
+}
+
+func TestImportTerraformOptions_Run(t *testing.T) {
+	dir := t.TempDir()
+	monkey.Patch(projectstack.DetectProjectAndStack, func(stackDir string) (*projectstack.Project, *projectstack.Stack, error) {
+		return &projectstack.Project{ProjectConfiguration: projectstack.ProjectConfiguration{Name: "project"}},
+			&projectstack.Stack{StackConfiguration: projectstack.StackConfiguration{Name: "dev"}}, nil
+	})
+	defer monkey.UnpatchAll()
+
+	stateFile := filepath.Join(dir, "terraform.tfstate")
+	assert.Nil(t, os.WriteFile(stateFile, []byte(tfState), 0o600))
+	o := NewImportTerraformOptions()
+	o.WorkDir = dir
+	o.Complete([]string{stateFile})
+
+	// the version of the provider is unknown
+	assert.ErrorContains(t, o.Run(), "version of the provider registry.terraform.io/hashicorp/local is unknown")
+
+	o.ProviderVersions = map[string]string{"hashicorp/local": "2.2.3"}
+	assert.Nil(t, o.Run())
+	storage := &local.FileSystemState{Path: filepath.Join(dir, local.KusionState)}
+	state, err := storage.GetLatestState(&states.StateQuery{})
+	assert.Nil(t, err)
+	assert.Equal(t, "dev", state.Stack)
+	assert.Equal(t, uint64(1), state.Serial)
+	assert.Len(t, state.Resources, 1)
+	assert.Equal(t, "hashicorp:local:local_file:example", state.Resources[0].ID)
+	assert.Equal(t, "registry.terraform.io/hashicorp/local/2.2.3", state.Resources[0].Extensions["provider"])
+
+	// resources already in the state are not imported again
+	assert.ErrorContains(t, o.Run(), "resources already in the state: hashicorp:local:local_file:example")
+}
@@ -0,0 +1,62 @@
+package state
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	importPulumiShort = "Import resources in a Pulumi stack export into the state of a stack"
+
+	importPulumiLong = `
+		Import resources in the output of "pulumi stack export" into the state of the stack in the work
+		directory, so that stacks managed by Pulumi can be migrated to Kusion.
+
+		Resources of the Pulumi Kubernetes provider become Kubernetes resources of Kusion, whose attributes
+		are their live objects recorded by Pulumi, with their dependencies. Resources of other providers,
+		component resources and providers are skipped, and a report tells why each of them is skipped.
+
+		A KCL skeleton declaring the imported resources is written to --kcl-file, which is a starting point
+		of the configuration of the stack. Secret values must be exported in plaintext with
+		"pulumi stack export --show-secrets".`
+
+	importPulumiExample = `
+		# Import resources in the Pulumi stack export into the state of the current stack
+		pulumi stack export --show-secrets --file pulumi.json
+		kusion state import-pulumi pulumi.json
+
+		# Import resources and write the KCL skeleton to main.k
+		kusion state import-pulumi pulumi.json --kcl-file main.k --force`
+)
+
+func NewCmdImportPulumi() *cobra.Command {
+	o := NewImportPulumiOptions()
+
+	cmd := &cobra.Command{
+		Use:     "import-pulumi EXPORT_FILE",
+		Short:   i18n.T(importPulumiShort),
+		Long:    templates.LongDesc(i18n.T(importPulumiLong)),
+		Example: templates.Examples(i18n.T(importPulumiExample)),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			defer util.RecoverErr(&err)
+			o.Complete(args)
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+			return
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.WorkDir, "workdir", "w", "",
+		i18n.T("Specify the work directory"))
+	cmd.Flags().StringVarP(&o.KCLFile, "kcl-file", "", o.KCLFile,
+		i18n.T("Specify the file the KCL skeleton of imported resources is written to, relative to the work directory"))
+	cmd.Flags().BoolVarP(&o.Force, "force", "", false,
+		i18n.T("Overwrite the KCL file if it exists"))
+	o.AddBackendFlags(cmd)
+
+	return cmd
+}
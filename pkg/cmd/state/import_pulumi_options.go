@@ -0,0 +1,115 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"kusionstack.io/kusion/pkg/engine/backend"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/scaffold"
+)
+
+// defaultPulumiKCLFile is the file the KCL skeleton of resources imported from Pulumi is written to
+const defaultPulumiKCLFile = "pulumi.k"
+
+type ImportPulumiOptions struct {
+	WorkDir    string
+	ExportFile string
+	KCLFile    string
+	Force      bool
+	backend.BackendOps
+}
+
+func NewImportPulumiOptions() *ImportPulumiOptions {
+	return &ImportPulumiOptions{KCLFile: defaultPulumiKCLFile}
+}
+
+func (o *ImportPulumiOptions) Complete(args []string) {
+	if len(args) > 0 {
+		o.ExportFile = args[0]
+	}
+	if o.WorkDir == "" {
+		o.WorkDir, _ = os.Getwd()
+	}
+	if o.KCLFile != "" && !filepath.IsAbs(o.KCLFile) {
+		o.KCLFile = filepath.Join(o.WorkDir, o.KCLFile)
+	}
+}
+
+func (o *ImportPulumiOptions) Validate() error {
+	if o.ExportFile == "" {
+		return fmt.Errorf("the pulumi stack export file is required")
+	}
+	if o.KCLFile == "" {
+		return fmt.Errorf("the KCL file is required")
+	}
+	if _, err := os.Stat(o.KCLFile); err == nil && !o.Force {
+		return fmt.Errorf("%s already exists, specify --force to overwrite it", o.KCLFile)
+	}
+	return nil
+}
+
+func (o *ImportPulumiOptions) Run() error {
+	data, err := os.ReadFile(o.ExportFile)
+	if err != nil {
+		return err
+	}
+	imported, mappings, err := scaffold.ConvertPulumiCheckpoint(data)
+	if err != nil {
+		return err
+	}
+
+	// the mapping report tells which resources are left behind
+	report, err := yamlv3.Marshal(mappings)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(report))
+	if len(imported) == 0 {
+		fmt.Println("No resources can be imported from the pulumi stack export")
+		return nil
+	}
+
+	project, stack, err := projectstack.DetectProjectAndStack(o.WorkDir)
+	if err != nil {
+		return err
+	}
+	storage, err := backend.BackendFromConfig(project.Backend, o.BackendOps, o.WorkDir)
+	if err != nil {
+		return err
+	}
+	state, err := storage.GetLatestState(&states.StateQuery{
+		Tenant:  project.Tenant,
+		Project: project.Name,
+		Stack:   stack.Name,
+	})
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		state = states.NewState()
+		state.Tenant = project.Tenant
+		state.Project = project.Name
+		state.Stack = stack.Name
+	}
+	if state.Resources, err = mergeResources(state.Resources, imported); err != nil {
+		return err
+	}
+
+	skeleton := scaffold.KCLStack(imported, "kusion state import-pulumi")
+	if err = os.WriteFile(o.KCLFile, []byte(skeleton), 0o644); err != nil {
+		return err
+	}
+	state.Serial++
+	state.Timings = nil
+	if err = storage.Apply(state); err != nil {
+		return fmt.Errorf("apply state failed: %w", err)
+	}
+	fmt.Printf("Imported %d of %d resources into the state of the stack %s, the KCL skeleton is written to %s\n",
+		len(imported), len(mappings), stack.Name, o.KCLFile)
+	return nil
+}
@@ -0,0 +1,74 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/engine/states/local"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+const pulumiExport = `{
+  "version": 3,
+  "deployment": {
+    "resources": [
+      {"urn": "urn:pulumi:dev::app::pulumi:pulumi:Stack::app-dev", "custom": false, "type": "pulumi:pulumi:Stack"},
+      {
+        "urn": "urn:pulumi:dev::app::kubernetes:core/v1:Namespace::ns",
+        "custom": true,
+        "type": "kubernetes:core/v1:Namespace",
+        "outputs": {"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "app"}}
+      }
+    ]
+  }
+}`
+
+func TestImportPulumiOptions_Validate(t *testing.T) {
+	dir := t.TempDir()
+	o := NewImportPulumiOptions()
+	o.WorkDir = dir
+	assert.NotNil(t, o.Validate())
+	o.Complete([]string{"pulumi.json"})
+	assert.Equal(t, filepath.Join(dir, "pulumi.k"), o.KCLFile)
+	assert.Nil(t, o.Validate())
+
+	assert.Nil(t, os.WriteFile(o.KCLFile, nil, 0o644))
+	assert.ErrorContains(t, o.Validate(), "already exists")
+	o.Force = true
+	assert.Nil(t, o.Validate())
+}
+
+func TestImportPulumiOptions_Run(t *testing.T) {
+	dir := t.TempDir()
+	monkey.Patch(projectstack.DetectProjectAndStack, func(stackDir string) (*projectstack.Project, *projectstack.Stack, error) {
+		return &projectstack.Project{ProjectConfiguration: projectstack.ProjectConfiguration{Name: "project"}},
+			&projectstack.Stack{StackConfiguration: projectstack.StackConfiguration{Name: "dev"}}, nil
+	})
+	defer monkey.UnpatchAll()
+
+	exportFile := filepath.Join(dir, "pulumi.json")
+	assert.Nil(t, os.WriteFile(exportFile, []byte(pulumiExport), 0o600))
+	o := NewImportPulumiOptions()
+	o.WorkDir = dir
+	o.Complete([]string{exportFile})
+	assert.Nil(t, o.Run())
+
+	storage := &local.FileSystemState{Path: filepath.Join(dir, local.KusionState)}
+	state, err := storage.GetLatestState(&states.StateQuery{})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), state.Serial)
+	assert.Len(t, state.Resources, 1)
+	assert.Equal(t, "v1:Namespace:app", state.Resources[0].ID)
+
+	skeleton, err := os.ReadFile(o.KCLFile)
+	assert.Nil(t, err)
+	assert.Contains(t, string(skeleton), `id = "v1:Namespace:app"`)
+
+	// resources already in the state are not imported again
+	assert.ErrorContains(t, o.Run(), "resources already in the state: v1:Namespace:app")
+}
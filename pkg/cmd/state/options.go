@@ -0,0 +1,167 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"kusionstack.io/kusion/pkg/engine/backend"
+	_ "kusionstack.io/kusion/pkg/engine/backend/init"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/sensitive"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// Output formats of state show
+const (
+	YAMLOutput = "yaml"
+	JSONOutput = "json"
+)
+
+type ShowOptions struct {
+	WorkDir       string
+	Output        string
+	ShowSensitive bool
+	Timings       bool
+	ResourceIDs   []string
+	backend.BackendOps
+}
+
+func NewShowOptions() *ShowOptions {
+	return &ShowOptions{Output: YAMLOutput}
+}
+
+func (o *ShowOptions) Complete(args []string) {
+	o.ResourceIDs = args
+	if o.WorkDir == "" {
+		o.WorkDir, _ = os.Getwd()
+	}
+}
+
+func (o *ShowOptions) Validate() error {
+	if o.Output != YAMLOutput && o.Output != JSONOutput {
+		return fmt.Errorf("invalid output %s, must be %s or %s", o.Output, YAMLOutput, JSONOutput)
+	}
+	return nil
+}
+
+func (o *ShowOptions) Run() error {
+	project, stack, err := projectstack.DetectProjectAndStack(o.WorkDir)
+	if err != nil {
+		return err
+	}
+	storage, err := backend.BackendFromConfig(project.Backend, o.BackendOps, o.WorkDir)
+	if err != nil {
+		return err
+	}
+	state, err := storage.GetLatestState(&states.StateQuery{
+		Tenant:  project.Tenant,
+		Project: project.Name,
+		Stack:   stack.Name,
+	})
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("no state found for the stack %s", stack.Name)
+	}
+
+	var out string
+	if o.Timings {
+		out, err = o.format(o.selectTimings(state.Timings))
+	} else {
+		var resources models.Resources
+		if resources, err = o.selectResources(state.Resources); err != nil {
+			return err
+		}
+		out, err = o.format(resources)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
+
+// selectResources returns resources of the IDs, or all resources if no ID is specified. Sensitive
+// attributes are masked unless --show-sensitive is specified.
+func (o *ShowOptions) selectResources(resources models.Resources) (models.Resources, error) {
+	if len(o.ResourceIDs) > 0 {
+		index := resources.Index()
+		selected := make(models.Resources, 0, len(o.ResourceIDs))
+		for _, id := range o.ResourceIDs {
+			res, ok := index[id]
+			if !ok {
+				return nil, fmt.Errorf("resource %s not found in the state", id)
+			}
+			selected = append(selected, *res)
+		}
+		resources = selected
+	}
+	if o.ShowSensitive {
+		return resources, nil
+	}
+	return sensitive.MaskResources(resources), nil
+}
+
+// selectTimings returns timings of the resources in the last operation of the state, the slowest first
+func (o *ShowOptions) selectTimings(timings []states.ResourceTiming) []timing {
+	ids := map[string]bool{}
+	for _, id := range o.ResourceIDs {
+		ids[id] = true
+	}
+	result := make([]timing, 0, len(timings))
+	for i := range timings {
+		t := &timings[i]
+		if len(ids) > 0 && !ids[t.ID] {
+			continue
+		}
+		result = append(result, timing{
+			ID:             t.ID,
+			Action:         t.Action,
+			StartTime:      t.StartTime,
+			Duration:       t.Duration().Round(time.Millisecond).String(),
+			RuntimeLatency: t.RuntimeLatency.Round(time.Millisecond).String(),
+			Attempts:       t.Attempts,
+			Failed:         t.Failed,
+			duration:       t.Duration(),
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].duration > result[j].duration
+	})
+	return result
+}
+
+// timing is the timing of a resource shown by state show --timings, with durations in readable strings
+type timing struct {
+	ID             string    `json:"id" yaml:"id"`
+	Action         string    `json:"action,omitempty" yaml:"action,omitempty"`
+	StartTime      time.Time `json:"startTime" yaml:"startTime"`
+	Duration       string    `json:"duration" yaml:"duration"`
+	RuntimeLatency string    `json:"runtimeLatency" yaml:"runtimeLatency"`
+	Attempts       int       `json:"attempts,omitempty" yaml:"attempts,omitempty"`
+	Failed         bool      `json:"failed,omitempty" yaml:"failed,omitempty"`
+
+	duration time.Duration
+}
+
+func (o *ShowOptions) format(value interface{}) (string, error) {
+	if o.Output == JSONOutput {
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+	}
+	data, err := yamlv3.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
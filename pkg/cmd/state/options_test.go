@@ -0,0 +1,103 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/sensitive"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/engine/states/local"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+var secret = models.Resource{
+	ID:   "v1:Secret:default:db",
+	Type: runtime.Kubernetes,
+	Attributes: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"data":       map[string]interface{}{"password": "c2VjcmV0"},
+	},
+}
+
+func TestShowOptions_Validate(t *testing.T) {
+	o := NewShowOptions()
+	assert.Nil(t, o.Validate())
+	o.Output = "table"
+	assert.NotNil(t, o.Validate())
+}
+
+func TestShowOptions_selectResources(t *testing.T) {
+	configMap := models.Resource{ID: "v1:ConfigMap:default:app", Type: runtime.Kubernetes}
+	resources := models.Resources{configMap, secret}
+
+	t.Run("mask sensitive values", func(t *testing.T) {
+		o := NewShowOptions()
+		o.ResourceIDs = []string{secret.ID}
+		got, err := o.selectResources(resources)
+		assert.Nil(t, err)
+		assert.Len(t, got, 1)
+		assert.Equal(t, sensitive.MaskValue("c2VjcmV0"), got[0].Attributes["data"].(map[string]interface{})["password"])
+	})
+
+	t.Run("show sensitive values", func(t *testing.T) {
+		o := NewShowOptions()
+		o.ShowSensitive = true
+		got, err := o.selectResources(resources)
+		assert.Nil(t, err)
+		assert.Equal(t, resources, got)
+	})
+
+	t.Run("resource not found", func(t *testing.T) {
+		o := NewShowOptions()
+		o.ResourceIDs = []string{"v1:Secret:default:missing"}
+		_, err := o.selectResources(resources)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestShowOptions_Run(t *testing.T) {
+	dir := t.TempDir()
+	monkey.Patch(projectstack.DetectProjectAndStack, func(stackDir string) (*projectstack.Project, *projectstack.Stack, error) {
+		return &projectstack.Project{ProjectConfiguration: projectstack.ProjectConfiguration{Name: "project"}},
+			&projectstack.Stack{StackConfiguration: projectstack.StackConfiguration{Name: "dev"}}, nil
+	})
+	defer monkey.UnpatchAll()
+
+	o := NewShowOptions()
+	o.WorkDir = dir
+	assert.NotNil(t, o.Run())
+
+	state := states.NewState()
+	state.Project = "project"
+	state.Stack = "dev"
+	state.Resources = models.Resources{secret}
+	assert.Nil(t, (&local.FileSystemState{Path: filepath.Join(dir, local.KusionState)}).Apply(state))
+	assert.Nil(t, o.Run())
+}
+
+func TestShowOptions_selectTimings(t *testing.T) {
+	start := time.Now()
+	timings := []states.ResourceTiming{
+		{ID: "fast", StartTime: start, EndTime: start.Add(time.Second), Attempts: 1},
+		{ID: "slow", StartTime: start, EndTime: start.Add(time.Minute), RuntimeLatency: 30 * time.Second, Attempts: 2},
+	}
+
+	o := NewShowOptions()
+	got := o.selectTimings(timings)
+	assert.Len(t, got, 2)
+	assert.Equal(t, "slow", got[0].ID)
+	assert.Equal(t, "1m0s", got[0].Duration)
+	assert.Equal(t, "30s", got[0].RuntimeLatency)
+
+	o.ResourceIDs = []string{"fast"}
+	got = o.selectTimings(timings)
+	assert.Len(t, got, 1)
+	assert.Equal(t, "1s", got[0].Duration)
+}
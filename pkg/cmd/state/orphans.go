@@ -0,0 +1,66 @@
+package state
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	orphansShort = "Adopt or prune objects labeled as members of a stack but missing from its state"
+
+	orphansLong = `
+		List objects in the cluster labeled as members of the ApplySet of the stack in the work directory but
+		missing from its state, which are left by out-of-band applies, e.g. kubectl apply of manifests of the
+		stack, or by applies whose states were not saved.
+
+		Orphans are listed by the label selector applyset.kubernetes.io/part-of=<id> of the ApplySet in the
+		state, in kinds and namespaces of the ApplySet. Choose to adopt them into the state, so that the next
+		apply manages them, or to prune them from the cluster. Only orphans in the spec of the stack are adopted,
+		since the next apply would delete the others. The state is locked and backed up before either.`
+
+	orphansExample = `
+		# List orphans of the current stack and choose to adopt or prune them
+		kusion state orphans
+
+		# Adopt orphans into the state without prompting
+		kusion state orphans --adopt --yes
+
+		# Prune orphans from the cluster
+		kusion state orphans --prune`
+)
+
+func NewCmdOrphans() *cobra.Command {
+	o := NewOrphansOptions()
+
+	cmd := &cobra.Command{
+		Use:     "orphans",
+		Short:   i18n.T(orphansShort),
+		Long:    templates.LongDesc(i18n.T(orphansLong)),
+		Example: templates.Examples(i18n.T(orphansExample)),
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			defer util.RecoverErr(&err)
+			o.Complete(args)
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+			return
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.WorkDir, "workdir", "w", "",
+		i18n.T("Specify the work directory"))
+	cmd.Flags().StringVarP(&o.Operator, "operator", "", "",
+		i18n.T("Specify the operator, the current user by default"))
+	cmd.Flags().BoolVarP(&o.Adopt, "adopt", "", false,
+		i18n.T("Adopt orphans in the spec of the stack into the state"))
+	cmd.Flags().BoolVarP(&o.Prune, "prune", "", false,
+		i18n.T("Prune orphans from the cluster"))
+	cmd.Flags().BoolVarP(&o.Yes, "yes", "y", false,
+		i18n.T("Automatically approve adopting or pruning orphans"))
+	o.AddBackendFlags(cmd)
+
+	return cmd
+}
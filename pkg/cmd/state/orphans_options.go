@@ -0,0 +1,289 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/pterm/pterm"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/engine/backend"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/generator/applyset"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/status"
+)
+
+// Actions on orphans
+const (
+	actionAdopt  = "adopt"
+	actionPrune  = "prune"
+	actionCancel = "cancel"
+)
+
+type orphanRuntime interface {
+	ListBySelector(ctx context.Context, groupKinds []schema.GroupKind, namespaces []string, selector string) ([]models.Resource, error)
+	Delete(ctx context.Context, request *runtime.DeleteRequest) *runtime.DeleteResponse
+}
+
+// newOrphanRuntime returns the Kubernetes runtime to list and prune orphans
+var newOrphanRuntime = kubernetes.RuntimeAs[orphanRuntime]
+
+// promptAction asks what to do with orphans
+var promptAction = func() (string, error) {
+	prompt := &survey.Select{
+		Message: "Do you want to adopt these objects into the state or prune them from the cluster?",
+		Options: []string{actionAdopt, actionPrune, actionCancel},
+		Default: actionCancel,
+	}
+	var input string
+	if err := survey.AskOne(prompt, &input); err != nil {
+		return "", err
+	}
+	return input, nil
+}
+
+type OrphansOptions struct {
+	WorkDir  string
+	Operator string
+	Adopt    bool
+	Prune    bool
+	Yes      bool
+	backend.BackendOps
+}
+
+func NewOrphansOptions() *OrphansOptions {
+	return &OrphansOptions{}
+}
+
+func (o *OrphansOptions) Complete(_ []string) {
+	if o.WorkDir == "" {
+		o.WorkDir, _ = os.Getwd()
+	}
+}
+
+func (o *OrphansOptions) Validate() error {
+	if o.Adopt && o.Prune {
+		return fmt.Errorf("--adopt and --prune can not be specified together")
+	}
+	if o.Yes && !o.Adopt && !o.Prune {
+		return fmt.Errorf("--yes requires --adopt or --prune")
+	}
+	return nil
+}
+
+func (o *OrphansOptions) Run() error {
+	project, stack, err := projectstack.DetectProjectAndStack(o.WorkDir)
+	if err != nil {
+		return err
+	}
+	storage, err := backend.BackendFromConfig(project.Backend, o.BackendOps, o.WorkDir)
+	if err != nil {
+		return err
+	}
+	query := &states.StateQuery{
+		Tenant:  project.Tenant,
+		Project: project.Name,
+		Stack:   stack.Name,
+	}
+	state, err := storage.GetLatestState(query)
+	if err != nil {
+		return err
+	}
+	var members *applyset.Members
+	if state != nil {
+		members = applyset.MembersOf(state.Resources)
+	}
+	if members == nil {
+		return fmt.Errorf("no ApplySet found in the state of the stack %s, apply the stack first", stack.Name)
+	}
+
+	env, err := runtime.StackEnv(context.Background(), project, stack)
+	if err != nil {
+		return err
+	}
+	rt, err := newOrphanRuntime(env)
+	if err != nil {
+		return err
+	}
+	if rt == nil {
+		return fmt.Errorf("the Kubernetes runtime does not support listing orphans")
+	}
+	orphans, err := findOrphans(rt, members, state)
+	if err != nil {
+		return err
+	}
+	if len(orphans) == 0 {
+		fmt.Printf("No orphan found for the stack %s\n", stack.Name)
+		return nil
+	}
+	fmt.Printf("Found %d orphan(s) labeled as members of the stack %s but missing from its state:\n", len(orphans), stack.Name)
+	for _, r := range orphans {
+		fmt.Printf("  %s\n", r.ID)
+	}
+
+	action, err := o.action()
+	if err != nil {
+		return err
+	}
+	if action != actionAdopt && action != actionPrune {
+		fmt.Println("Operation canceled")
+		return nil
+	}
+
+	// Lock and back up the state before orphans are adopted or pruned, which is restored by
+	// kusion state restore-backup. Orphans are found again with the state locked, since the state may be
+	// changed by another operation before the lock is acquired.
+	operator := o.Operator
+	if operator == "" {
+		if u, e := user.Current(); e == nil {
+			operator = u.Username
+		}
+	}
+	unlock, _, err := states.AcquireLock(storage, query, action, operator, "", nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := unlock(); e != nil {
+			pterm.Warning.Println(e)
+		}
+	}()
+	if _, err = states.BackupState(storage, query); err != nil {
+		return err
+	}
+	if state, err = storage.GetLatestState(query); err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("the state of the stack %s is removed by another operation", stack.Name)
+	}
+	confirmed := orphans
+	if orphans, err = findOrphans(rt, members, state); err != nil {
+		return err
+	}
+	// only the orphans shown to users are adopted or pruned
+	if !sameResources(confirmed, orphans) {
+		return fmt.Errorf("orphans of the stack %s changed after they were listed, run the command again", stack.Name)
+	}
+
+	if action == actionPrune {
+		return o.prune(rt, orphans)
+	}
+	sp, err := spec.GenerateSpec(&generator.Options{WorkDir: o.WorkDir}, project, stack)
+	if err != nil {
+		return err
+	}
+	return o.adopt(storage, query, state, sp, orphans)
+}
+
+// findOrphans lists members of the ApplySet in the cluster which are missing from the state
+func findOrphans(rt orphanRuntime, members *applyset.Members, state *states.State) (models.Resources, error) {
+	listed, err := rt.ListBySelector(context.Background(), members.GroupKinds, members.Namespaces, members.Selector)
+	if err != nil {
+		return nil, err
+	}
+	index := state.Resources.Index()
+	var orphans models.Resources
+	for i := range listed {
+		if _, ok := index[listed[i].ID]; !ok {
+			orphans = append(orphans, listed[i])
+		}
+	}
+	return orphans, nil
+}
+
+// sameResources returns whether both lists contain resources of the same IDs
+func sameResources(a, b models.Resources) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	index := a.Index()
+	for _, r := range b {
+		if _, ok := index[r.ID]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// action returns the action specified by flags, or asks for it
+func (o *OrphansOptions) action() (string, error) {
+	action := ""
+	switch {
+	case o.Adopt:
+		action = actionAdopt
+	case o.Prune:
+		action = actionPrune
+	}
+	if o.Yes {
+		return action, nil
+	}
+	if action == "" {
+		return promptAction()
+	}
+	confirmed := false
+	if err := survey.AskOne(&survey.Confirm{Message: fmt.Sprintf("Do you want to %s these objects?", action)}, &confirmed); err != nil {
+		return "", err
+	}
+	if !confirmed {
+		return actionCancel, nil
+	}
+	return action, nil
+}
+
+// adopt records the orphans in the locked state, so that the next apply manages them. Orphans missing from
+// the spec are never adopted, since the next apply would delete them from the cluster.
+func (o *OrphansOptions) adopt(
+	storage states.StateStorage,
+	query *states.StateQuery,
+	state *states.State,
+	sp *models.Spec,
+	orphans models.Resources,
+) error {
+	index := sp.Resources.Index()
+	var adopted models.Resources
+	for _, r := range orphans {
+		if _, ok := index[r.ID]; !ok {
+			pterm.Warning.Printfln("%s is not adopted since it is not in the spec of the stack %s, and would be deleted "+
+				"by the next apply. Add it to the spec, or prune it", r.ID, query.Stack)
+			continue
+		}
+		adopted = append(adopted, r)
+	}
+	if len(adopted) == 0 {
+		return fmt.Errorf("none of the orphans is in the spec of the stack %s, nothing is adopted", query.Stack)
+	}
+
+	var err error
+	if state.Resources, err = mergeResources(state.Resources, adopted); err != nil {
+		return err
+	}
+	state.Serial++
+	state.Timings = nil
+	if err = storage.Apply(state); err != nil {
+		return fmt.Errorf("apply state failed: %w", err)
+	}
+	fmt.Printf("Adopted %d object(s) into the state of the stack %s\n", len(adopted), query.Stack)
+	return nil
+}
+
+// prune deletes the orphans from the cluster
+func (o *OrphansOptions) prune(rt orphanRuntime, orphans models.Resources) error {
+	for i := range orphans {
+		response := rt.Delete(context.Background(), &runtime.DeleteRequest{Resource: &orphans[i]})
+		if status.IsErr(response.Status) {
+			return fmt.Errorf("prune %s failed: %s", orphans[i].ID, response.Status.String())
+		}
+		fmt.Printf("Pruned %s\n", orphans[i].ID)
+	}
+	return nil
+}
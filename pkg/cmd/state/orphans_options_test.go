@@ -0,0 +1,191 @@
+package state
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	specutil "kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/engine/states/local"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/generator/applyset"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+type fakeOrphanRuntime struct {
+	listed   []models.Resource
+	selector string
+	deleted  []string
+
+	// relisted is returned instead of listed once the state is locked, if it is not nil
+	relisted []models.Resource
+	lists    int
+}
+
+func (f *fakeOrphanRuntime) ListBySelector(_ context.Context, _ []schema.GroupKind, _ []string, selector string) ([]models.Resource, error) {
+	f.selector = selector
+	f.lists++
+	if f.lists > 1 && f.relisted != nil {
+		return f.relisted, nil
+	}
+	return f.listed, nil
+}
+
+func (f *fakeOrphanRuntime) Delete(_ context.Context, request *runtime.DeleteRequest) *runtime.DeleteResponse {
+	f.deleted = append(f.deleted, request.Resource.ID)
+	return &runtime.DeleteResponse{}
+}
+
+func mockOrphanRuntime(t *testing.T, rt *fakeOrphanRuntime) {
+	origin := newOrphanRuntime
+	newOrphanRuntime = func(runtime.Env) (orphanRuntime, error) {
+		return rt, nil
+	}
+	t.Cleanup(func() {
+		newOrphanRuntime = origin
+	})
+}
+
+func newConfigMap(name string) models.Resource {
+	return models.Resource{
+		ID:   "v1:ConfigMap:default:" + name,
+		Type: runtime.Kubernetes,
+		Attributes: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+		},
+	}
+}
+
+func TestOrphansOptions_Validate(t *testing.T) {
+	o := NewOrphansOptions()
+	assert.Nil(t, o.Validate())
+	o.Yes = true
+	assert.EqualError(t, o.Validate(), "--yes requires --adopt or --prune")
+	o.Adopt, o.Prune = true, true
+	assert.EqualError(t, o.Validate(), "--adopt and --prune can not be specified together")
+}
+
+func TestOrphansOptions_Run(t *testing.T) {
+	project := &projectstack.Project{ProjectConfiguration: projectstack.ProjectConfiguration{Name: "project"}}
+	stack := &projectstack.Stack{StackConfiguration: projectstack.StackConfiguration{Name: "dev"}}
+	monkey.Patch(projectstack.DetectProjectAndStack, func(stackDir string) (*projectstack.Project, *projectstack.Stack, error) {
+		return project, stack, nil
+	})
+	defer monkey.UnpatchAll()
+
+	managed, orphan, unknown := newConfigMap("managed"), newConfigMap("orphan"), newConfigMap("unknown")
+	spec := &models.Spec{Resources: models.Resources{managed}}
+	applyset.Label(spec, project, stack)
+	members := applyset.MembersOf(spec.Resources)
+	// the orphan is in the spec, e.g. it was removed from the state by kusion state rm
+	compiled := &models.Spec{Resources: models.Resources{managed, orphan}}
+	monkey.Patch(specutil.GenerateSpec, func(*generator.Options, *projectstack.Project, *projectstack.Stack) (*models.Spec, error) {
+		return compiled, nil
+	})
+
+	newStorage := func(t *testing.T, o *OrphansOptions) states.StateStorage {
+		o.WorkDir = t.TempDir()
+		storage := &local.FileSystemState{Path: filepath.Join(o.WorkDir, local.KusionState)}
+		state := states.NewState()
+		state.Project = "project"
+		state.Stack = "dev"
+		state.Resources = spec.Resources
+		assert.Nil(t, storage.Apply(state))
+		return storage
+	}
+
+	t.Run("no ApplySet", func(t *testing.T) {
+		o := NewOrphansOptions()
+		o.WorkDir = t.TempDir()
+		assert.ErrorContains(t, o.Run(), "no ApplySet found in the state of the stack dev")
+	})
+
+	t.Run("no orphan", func(t *testing.T) {
+		rt := &fakeOrphanRuntime{listed: []models.Resource{managed}}
+		mockOrphanRuntime(t, rt)
+		o := NewOrphansOptions()
+		newStorage(t, o)
+		assert.Nil(t, o.Run())
+		assert.Equal(t, members.Selector, rt.selector)
+	})
+
+	t.Run("adopt", func(t *testing.T) {
+		mockOrphanRuntime(t, &fakeOrphanRuntime{listed: []models.Resource{managed, orphan, unknown}})
+		o := NewOrphansOptions()
+		o.Adopt, o.Yes = true, true
+		storage := newStorage(t, o)
+		assert.Nil(t, o.Run())
+
+		state, err := storage.GetLatestState(nil)
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(1), state.Serial)
+		assert.Equal(t, append(append(models.Resources{}, spec.Resources...), orphan), state.Resources,
+			"orphans missing from the spec are never adopted")
+		backup, err := storage.(states.StateBackuper).GetBackup(nil)
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(0), backup.Serial)
+	})
+
+	t.Run("adopt nothing in the spec", func(t *testing.T) {
+		mockOrphanRuntime(t, &fakeOrphanRuntime{listed: []models.Resource{managed, unknown}})
+		o := NewOrphansOptions()
+		o.Adopt, o.Yes = true, true
+		newStorage(t, o)
+		assert.ErrorContains(t, o.Run(), "none of the orphans is in the spec of the stack dev")
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		rt := &fakeOrphanRuntime{listed: []models.Resource{managed, orphan}}
+		mockOrphanRuntime(t, rt)
+		o := NewOrphansOptions()
+		o.Prune, o.Yes = true, true
+		storage := newStorage(t, o)
+		assert.Nil(t, o.Run())
+		assert.Equal(t, []string{orphan.ID}, rt.deleted)
+
+		state, err := storage.GetLatestState(nil)
+		assert.Nil(t, err)
+		assert.Equal(t, spec.Resources, state.Resources)
+		backup, err := storage.(states.StateBackuper).GetBackup(nil)
+		assert.Nil(t, err)
+		assert.NotNil(t, backup)
+	})
+
+	t.Run("orphans changed after listed", func(t *testing.T) {
+		rt := &fakeOrphanRuntime{
+			listed:   []models.Resource{managed, orphan},
+			relisted: []models.Resource{managed, orphan, unknown},
+		}
+		mockOrphanRuntime(t, rt)
+		o := NewOrphansOptions()
+		o.Prune, o.Yes = true, true
+		newStorage(t, o)
+		assert.ErrorContains(t, o.Run(), "orphans of the stack dev changed after they were listed")
+		assert.Nil(t, rt.deleted)
+	})
+
+	t.Run("prompt cancel", func(t *testing.T) {
+		rt := &fakeOrphanRuntime{listed: []models.Resource{orphan}}
+		mockOrphanRuntime(t, rt)
+		origin := promptAction
+		promptAction = func() (string, error) {
+			return actionCancel, nil
+		}
+		defer func() {
+			promptAction = origin
+		}()
+		o := NewOrphansOptions()
+		newStorage(t, o)
+		assert.Nil(t, o.Run())
+		assert.Nil(t, rt.deleted)
+	})
+}
@@ -0,0 +1,55 @@
+package state
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	restoreBackupShort = "Restore the state of a stack from its backup"
+
+	restoreBackupLong = `
+		Restore the state of the stack in the work directory from the backup written before the last apply
+		or destroy, which recovers the state from a botched operation.
+
+		The state replaced is backed up in turn, so running the command again undoes the restore. Only the
+		state is restored, resources are not changed until the next apply, whose preview shows how they
+		differ from the restored state.
+
+		Backups are kept next to the state, i.e. the kusion_state.json.backup file of local states and the
+		kusion_state.backup.json object of S3 and OSS states. Other backends do not support backups.`
+
+	restoreBackupExample = `
+		# Restore the state of the current stack from its backup
+		kusion state restore-backup`
+)
+
+func NewCmdRestoreBackup() *cobra.Command {
+	o := NewRestoreBackupOptions()
+
+	cmd := &cobra.Command{
+		Use:     "restore-backup",
+		Short:   i18n.T(restoreBackupShort),
+		Long:    templates.LongDesc(i18n.T(restoreBackupLong)),
+		Example: templates.Examples(i18n.T(restoreBackupExample)),
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			defer util.RecoverErr(&err)
+			o.Complete(args)
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+			return
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.WorkDir, "workdir", "w", "",
+		i18n.T("Specify the work directory"))
+	cmd.Flags().StringVarP(&o.Operator, "operator", "", "",
+		i18n.T("Specify the operator"))
+	o.AddBackendFlags(cmd)
+
+	return cmd
+}
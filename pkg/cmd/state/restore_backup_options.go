@@ -0,0 +1,88 @@
+package state
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pterm/pterm"
+
+	"kusionstack.io/kusion/pkg/engine/backend"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+type RestoreBackupOptions struct {
+	WorkDir  string
+	Operator string
+	backend.BackendOps
+}
+
+func NewRestoreBackupOptions() *RestoreBackupOptions {
+	return &RestoreBackupOptions{}
+}
+
+func (o *RestoreBackupOptions) Complete(_ []string) {
+	if o.WorkDir == "" {
+		o.WorkDir, _ = os.Getwd()
+	}
+}
+
+func (o *RestoreBackupOptions) Validate() error {
+	return nil
+}
+
+func (o *RestoreBackupOptions) Run() error {
+	project, stack, err := projectstack.DetectProjectAndStack(o.WorkDir)
+	if err != nil {
+		return err
+	}
+	storage, err := backend.BackendFromConfig(project.Backend, o.BackendOps, o.WorkDir)
+	if err != nil {
+		return err
+	}
+	backuper, ok := storage.(states.StateBackuper)
+	if !ok {
+		return fmt.Errorf("the backend of the stack %s does not support backups", stack.Name)
+	}
+	query := &states.StateQuery{
+		Tenant:  project.Tenant,
+		Project: project.Name,
+		Stack:   stack.Name,
+	}
+
+	unlock, _, err := states.AcquireLock(storage, query, "restore-backup", o.Operator, "", nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := unlock(); e != nil {
+			pterm.Warning.Println(e)
+		}
+	}()
+
+	backup, err := backuper.GetBackup(query)
+	if err != nil {
+		return err
+	}
+	if backup == nil {
+		return fmt.Errorf("no backup found for the stack %s", stack.Name)
+	}
+
+	// back up the state replaced, so that the restore can be undone by restoring again
+	current, err := states.BackupState(storage, query)
+	if err != nil {
+		return err
+	}
+	if current != nil && backup.Serial <= current.Serial {
+		backup.Serial = current.Serial + 1
+	}
+	if o.Operator != "" {
+		backup.Operator = o.Operator
+	}
+	if err = storage.Apply(backup); err != nil {
+		return fmt.Errorf("apply state failed: %w", err)
+	}
+	fmt.Printf("Restored the state of the stack %s from the backup with %d resources\n", stack.Name,
+		len(backup.Resources))
+	return nil
+}
@@ -0,0 +1,57 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/engine/states/local"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func TestRestoreBackupOptions_Run(t *testing.T) {
+	dir := t.TempDir()
+	monkey.Patch(projectstack.DetectProjectAndStack, func(stackDir string) (*projectstack.Project, *projectstack.Stack, error) {
+		return &projectstack.Project{ProjectConfiguration: projectstack.ProjectConfiguration{Name: "project"}},
+			&projectstack.Stack{StackConfiguration: projectstack.StackConfiguration{Name: "dev"}}, nil
+	})
+	defer monkey.UnpatchAll()
+
+	o := NewRestoreBackupOptions()
+	o.WorkDir = dir
+	assert.Nil(t, o.Validate())
+	assert.NotNil(t, o.Run())
+
+	storage := &local.FileSystemState{Path: filepath.Join(dir, local.KusionState)}
+	good := states.NewState()
+	good.Project = "project"
+	good.Stack = "dev"
+	good.Serial = 1
+	good.Resources = models.Resources{secret}
+	assert.Nil(t, storage.Apply(good))
+	_, err := states.BackupState(storage, nil)
+	assert.Nil(t, err)
+
+	botched := states.NewState()
+	botched.Project = "project"
+	botched.Stack = "dev"
+	botched.Serial = 2
+	assert.Nil(t, storage.Apply(botched))
+
+	assert.Nil(t, o.Run())
+	restored, err := storage.GetLatestState(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), restored.Serial)
+	assert.Equal(t, models.Resources{secret}, restored.Resources)
+
+	// restoring again undoes the restore
+	assert.Nil(t, o.Run())
+	restored, err = storage.GetLatestState(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(4), restored.Serial)
+	assert.Empty(t, restored.Resources)
+}
@@ -0,0 +1,66 @@
+package state
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	showShort = "Show resources in the state of a stack"
+
+	showLong = `
+		Show resources in the latest state of the stack in the work directory.
+
+		Sensitive attributes, such as data of Kubernetes Secrets, passwords and tokens of Terraform resources,
+		and attributes listed in the annotation kusionstack.io/sensitive-fields, are masked unless
+		--show-sensitive is specified.
+
+		With --timings, show how long each resource took in the last operation instead, the slowest first,
+		including the latency of requests to its runtime and the number of attempts to apply it.`
+
+	showExample = `
+		# Show all resources in the state of the current stack
+		kusion state show
+
+		# Show the resource in JSON format
+		kusion state show v1:Secret:default:db-password -o json
+
+		# Show the resource with sensitive attributes
+		kusion state show v1:Secret:default:db-password --show-sensitive
+
+		# Show which resources made the last apply slow
+		kusion state show --timings`
+)
+
+func NewCmdShow() *cobra.Command {
+	o := NewShowOptions()
+
+	cmd := &cobra.Command{
+		Use:     "show [RESOURCE_ID...]",
+		Short:   i18n.T(showShort),
+		Long:    templates.LongDesc(i18n.T(showLong)),
+		Example: templates.Examples(i18n.T(showExample)),
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			defer util.RecoverErr(&err)
+			o.Complete(args)
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+			return
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.WorkDir, "workdir", "w", "",
+		i18n.T("Specify the work directory"))
+	cmd.Flags().StringVarP(&o.Output, "output", "o", YAMLOutput,
+		i18n.T("Specify the output format, yaml or json"))
+	cmd.Flags().BoolVarP(&o.ShowSensitive, "show-sensitive", "", false,
+		i18n.T("Show sensitive attributes instead of masking them"))
+	cmd.Flags().BoolVarP(&o.Timings, "timings", "", false,
+		i18n.T("Show timings of resources in the last operation instead of their attributes"))
+	o.AddBackendFlags(cmd)
+
+	return cmd
+}
@@ -0,0 +1,35 @@
+package state
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	stateShort = "Inspect, import, export and restore the state of a stack"
+
+	stateLong = `
+		Inspect the state of the stack in the work directory, which records resources applied by Kusion,
+		move resources between it and other tools, adopt or prune objects of the stack missing from it, or restore
+		it from the backup written before operations.`
+)
+
+func NewCmdState() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "state",
+		Short: i18n.T(stateShort),
+		Long:  templates.LongDesc(i18n.T(stateLong)),
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+	cmd.AddCommand(NewCmdShow())
+	cmd.AddCommand(NewCmdImportTerraform())
+	cmd.AddCommand(NewCmdImportPulumi())
+	cmd.AddCommand(NewCmdExportTerraform())
+	cmd.AddCommand(NewCmdRestoreBackup())
+	cmd.AddCommand(NewCmdOrphans())
+	return cmd
+}
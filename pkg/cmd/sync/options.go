@@ -0,0 +1,114 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/pterm/pterm"
+
+	"kusionstack.io/kusion/pkg/apis/kusionstack/v1alpha1"
+	"kusionstack.io/kusion/pkg/cmd/apply"
+	"kusionstack.io/kusion/pkg/operator"
+)
+
+// DefaultInterval is the default interval to poll repositories
+const DefaultInterval = 5 * time.Minute
+
+// Environment variables of credentials of private repositories
+const (
+	GitUsernameEnv = "KUSION_GIT_USERNAME"
+	GitPasswordEnv = "KUSION_GIT_PASSWORD"
+)
+
+// SyncOptions defines flags for the `sync` command, flags of previews and backends are the same as apply
+type SyncOptions struct {
+	apply.ApplyOptions
+
+	Repo        string
+	Path        string
+	GitRevision string
+	Interval    time.Duration
+	Once        bool
+	CacheDir    string
+}
+
+func NewSyncOptions() *SyncOptions {
+	return &SyncOptions{ApplyOptions: *apply.NewApplyOptions()}
+}
+
+func (o *SyncOptions) Complete(_ []string) {
+	if o.CacheDir == "" {
+		o.CacheDir = filepath.Join(os.TempDir(), "kusion-sync")
+	}
+}
+
+func (o *SyncOptions) Validate() error {
+	if o.Repo == "" {
+		return fmt.Errorf("the repository is required, specify it by --repo")
+	}
+	if o.Path == "" {
+		return fmt.Errorf("the stack directory in the repository is required, specify it by --path")
+	}
+	if o.Interval <= 0 {
+		return fmt.Errorf("the interval must be positive")
+	}
+	return o.ValidatePreviewFlags()
+}
+
+func (o *SyncOptions) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fetcher := operator.NewGitFetcher(o.CacheDir)
+	for {
+		err := o.sync(ctx, fetcher)
+		if o.Once {
+			return err
+		}
+		if err != nil {
+			pterm.Error.Printfln("Sync failed: %v", err)
+		}
+		fmt.Printf("Next sync in %s\n", o.Interval)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(o.Interval):
+		}
+	}
+}
+
+// sync applies the stack at the latest commit of the revision, with the options of a fresh apply in the
+// stack directory
+func (o *SyncOptions) sync(ctx context.Context, fetcher operator.Fetcher) error {
+	source := &v1alpha1.GitSource{URL: o.Repo, Revision: o.GitRevision, Path: o.Path}
+	dir, commit, release, err := fetcher.Fetch(ctx, source, credentials())
+	if err != nil {
+		return err
+	}
+	defer release()
+	fmt.Printf("Syncing %s of %s at %s\n", o.Path, o.Repo, commit)
+
+	ao := o.ApplyOptions
+	ao.WorkDir = dir
+	ao.Yes = true
+	ao.Revision = commit
+	ao.Complete(nil)
+	if err = ao.Validate(); err != nil {
+		return err
+	}
+	return ao.Run()
+}
+
+// credentials returns credentials in environment variables, or nil if they are not set
+func credentials() *operator.Credentials {
+	username, password := os.Getenv(GitUsernameEnv), os.Getenv(GitPasswordEnv)
+	if username == "" && password == "" {
+		return nil
+	}
+	return &operator.Credentials{Username: username, Password: password}
+}
@@ -0,0 +1,81 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kusionstack.io/kusion/pkg/apis/kusionstack/v1alpha1"
+	"kusionstack.io/kusion/pkg/cmd/apply"
+	"kusionstack.io/kusion/pkg/operator"
+)
+
+type fakeFetcher struct {
+	dir      string
+	source   *v1alpha1.GitSource
+	released bool
+	err      error
+}
+
+func (f *fakeFetcher) Fetch(_ context.Context, source *v1alpha1.GitSource, _ *operator.Credentials) (string, string, func(), error) {
+	f.source = source
+	if f.err != nil {
+		return "", "", nil, f.err
+	}
+	return f.dir, "abc123", func() { f.released = true }, nil
+}
+
+func TestSyncOptions_Validate(t *testing.T) {
+	o := NewSyncOptions()
+	o.Interval = DefaultInterval
+	assert.ErrorContains(t, o.Validate(), "--repo")
+	o.Repo = "https://github.com/KusionStack/konfig.git"
+	assert.ErrorContains(t, o.Validate(), "--path")
+	o.Path = "appops/guestbook/dev"
+	assert.NoError(t, o.Validate())
+	o.Interval = 0
+	assert.ErrorContains(t, o.Validate(), "interval")
+}
+
+func TestSyncOptions_sync(t *testing.T) {
+	defer monkey.UnpatchAll()
+	var applied *apply.ApplyOptions
+	monkey.Patch((*apply.ApplyOptions).Run, func(o *apply.ApplyOptions) error {
+		applied = o
+		return nil
+	})
+
+	o := NewSyncOptions()
+	o.Repo, o.Path, o.GitRevision = "https://github.com/KusionStack/konfig.git", "appops/guestbook/dev", "main"
+	o.Operator = "sync-bot"
+	fetcher := &fakeFetcher{dir: t.TempDir()}
+	require.NoError(t, o.sync(context.Background(), fetcher))
+
+	assert.Equal(t, &v1alpha1.GitSource{URL: o.Repo, Revision: "main", Path: o.Path}, fetcher.source)
+	assert.True(t, fetcher.released)
+	require.NotNil(t, applied)
+	assert.Equal(t, fetcher.dir, applied.WorkDir)
+	assert.Equal(t, "abc123", applied.Revision)
+	assert.Equal(t, "sync-bot", applied.Operator)
+	assert.True(t, applied.Yes)
+	// options of the command are not changed by syncs
+	assert.Empty(t, o.WorkDir)
+	assert.Empty(t, o.Revision)
+
+	fetcher.err = errors.New("authentication required")
+	assert.ErrorContains(t, o.sync(context.Background(), fetcher), "authentication required")
+}
+
+func Test_credentials(t *testing.T) {
+	t.Setenv(GitUsernameEnv, "")
+	t.Setenv(GitPasswordEnv, "")
+	assert.Nil(t, credentials())
+
+	t.Setenv(GitUsernameEnv, "bot")
+	t.Setenv(GitPasswordEnv, "token")
+	assert.Equal(t, &operator.Credentials{Username: "bot", Password: "token"}, credentials())
+}
@@ -0,0 +1,74 @@
+package sync
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	syncShort = "Apply a stack in a git repository continuously"
+
+	syncLong = `
+		Poll a git repository, and apply the stack at the latest commit of the revision on each
+		interval, the same as kusion apply --yes in the stack directory. Changes are previewed and
+		checked by policies before they are applied, and the commit applied is recorded in the state.
+
+		Stacks are also applied when no resource is changed but the commit differs from the state,
+		so that the state records the commit synced. Failures of a sync are printed, and the stack is
+		synced again on the next interval.
+
+		Credentials of private repositories over HTTPS are read from the KUSION_GIT_USERNAME and
+		KUSION_GIT_PASSWORD environment variables.`
+
+	syncExample = `
+		# Sync the stack in the repository every 5 minutes
+		kusion sync --repo https://github.com/KusionStack/konfig.git --path appops/guestbook/dev
+
+		# Sync the stack at the branch every minute
+		kusion sync --repo https://github.com/KusionStack/konfig.git --path appops/guestbook/dev --revision release --interval 1m
+
+		# Sync the stack once, e.g. in a CI job
+		kusion sync --repo https://github.com/KusionStack/konfig.git --path appops/guestbook/dev --once`
+)
+
+func NewCmdSync() *cobra.Command {
+	o := NewSyncOptions()
+
+	cmd := &cobra.Command{
+		Use:     "sync",
+		Short:   i18n.T(syncShort),
+		Long:    templates.LongDesc(i18n.T(syncLong)),
+		Example: templates.Examples(i18n.T(syncExample)),
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			defer util.RecoverErr(&err)
+			o.Complete(args)
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+			return
+		},
+	}
+
+	o.AddPreviewFlags(cmd)
+	o.AddBackendFlags(cmd)
+
+	cmd.Flags().StringVarP(&o.Repo, "repo", "", "",
+		i18n.T("Specify the URL of the git repository"))
+	cmd.Flags().StringVarP(&o.Path, "path", "", "",
+		i18n.T("Specify the directory of the stack relative to the root of the repository"))
+	cmd.Flags().StringVarP(&o.GitRevision, "revision", "", "",
+		i18n.T("Specify the branch, tag or commit to sync, the default branch by default"))
+	cmd.Flags().DurationVarP(&o.Interval, "interval", "", DefaultInterval,
+		i18n.T("Specify the interval to poll the repository"))
+	cmd.Flags().BoolVarP(&o.Once, "once", "", false,
+		i18n.T("Sync once and exit"))
+	cmd.Flags().StringVarP(&o.CacheDir, "cache-dir", "", "",
+		i18n.T("Specify the directory to keep the clone of the repository in, a temporary directory by default"))
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "", false,
+		i18n.T("dry-run to preview the execution effect (always successful) without actually applying the changes"))
+
+	return cmd
+}
@@ -0,0 +1,92 @@
+package telemetry
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/telemetry"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	telemetryShort = "Manage anonymous usage telemetry of kusion"
+
+	telemetryLong = `
+		Manage anonymous usage telemetry of kusion, which is off unless you turn it on.
+
+		When telemetry is on, each command reports the name of the builtin command, the version, OS and
+		architecture of kusion, the duration and the class of the error if it failed, e.g. policy_violation.
+		Nothing else is reported: no identifiers of users or machines, arguments, error messages, or anything
+		of projects, stacks and resources. Names of plugins are not reported either.
+
+		Telemetry is disabled regardless of this setting if DO_NOT_TRACK is 1 or KUSION_OFFLINE is true.`
+
+	telemetryExample = `
+		# Turn on telemetry for the current user
+		kusion telemetry on
+
+		# Turn off telemetry
+		kusion telemetry off
+
+		# Show whether telemetry is on
+		kusion telemetry status`
+)
+
+func NewCmdTelemetry() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "telemetry",
+		Short:   i18n.T(telemetryShort),
+		Long:    templates.LongDesc(i18n.T(telemetryLong)),
+		Example: templates.Examples(i18n.T(telemetryExample)),
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+	cmd.AddCommand(newCmdSet("on", "Turn on anonymous usage telemetry", true))
+	cmd.AddCommand(newCmdSet("off", "Turn off anonymous usage telemetry", false))
+	cmd.AddCommand(newCmdStatus())
+	return cmd
+}
+
+func newCmdSet(use, short string, enabled bool) *cobra.Command {
+	return &cobra.Command{
+		Use:   use,
+		Short: i18n.T(short),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) (err error) {
+			defer util.RecoverErr(&err)
+			util.CheckErr(telemetry.SetEnabled(enabled))
+			return printStatus(cmd)
+		},
+	}
+}
+
+func newCmdStatus() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: i18n.T("Show whether anonymous usage telemetry is on"),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return printStatus(cmd)
+		},
+	}
+}
+
+func printStatus(cmd *cobra.Command) error {
+	enabled, disabledBy, err := telemetry.Status()
+	if err != nil {
+		return err
+	}
+	switch {
+	case !enabled:
+		fmt.Fprintln(cmd.OutOrStdout(), "Telemetry is off")
+	case disabledBy != "":
+		fmt.Fprintf(cmd.OutOrStdout(), "Telemetry is on, but disabled by the environment variable %s\n", disabledBy)
+	default:
+		fmt.Fprintln(cmd.OutOrStdout(), "Telemetry is on")
+	}
+	return nil
+}
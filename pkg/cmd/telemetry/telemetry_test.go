@@ -0,0 +1,35 @@
+package telemetry
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/release"
+	"kusionstack.io/kusion/pkg/telemetry"
+	"kusionstack.io/kusion/pkg/util/kfile"
+)
+
+func TestNewCmdTelemetry(t *testing.T) {
+	t.Setenv(kfile.EnvKusionPath, t.TempDir())
+	t.Setenv(telemetry.EnvDoNotTrack, "")
+	t.Setenv(release.EnvOffline, "")
+
+	for _, c := range []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"status"}, want: "Telemetry is off\n"},
+		{args: []string{"on"}, want: "Telemetry is on\n"},
+		{args: []string{"status"}, want: "Telemetry is on\n"},
+		{args: []string{"off"}, want: "Telemetry is off\n"},
+	} {
+		out := &bytes.Buffer{}
+		cmd := NewCmdTelemetry()
+		cmd.SetOut(out)
+		cmd.SetArgs(c.args)
+		assert.NoError(t, cmd.Execute())
+		assert.Equal(t, c.want, out.String(), c.args)
+	}
+}
@@ -0,0 +1,74 @@
+package upgrade
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"kusionstack.io/kusion/pkg/release"
+	"kusionstack.io/kusion/pkg/version"
+)
+
+// Functions to query the release channel and to replace the executable, which are replaced in tests
+var (
+	latestRelease  = release.Latest
+	getRelease     = release.Get
+	upgradeRelease = release.Upgrade
+	executable     = os.Executable
+)
+
+type UpgradeOptions struct {
+	Version string
+
+	executable string
+}
+
+func NewUpgradeOptions() *UpgradeOptions {
+	return &UpgradeOptions{}
+}
+
+func (o *UpgradeOptions) Complete() error {
+	exe, err := executable()
+	if err != nil {
+		return err
+	}
+	if o.executable, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (o *UpgradeOptions) Validate() error {
+	if release.Offline() {
+		return release.ErrOffline
+	}
+	return nil
+}
+
+func (o *UpgradeOptions) Run() error {
+	ctx := context.Background()
+	var r *release.Release
+	var err error
+	if o.Version == "" {
+		r, err = latestRelease(ctx)
+	} else {
+		r, err = getRelease(ctx, o.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("get the release failed: %w", err)
+	}
+
+	current := version.ReleaseVersion()
+	if r.Version == current {
+		fmt.Printf("Kusion %s is already installed\n", current)
+		return nil
+	}
+
+	fmt.Printf("Upgrading kusion from %s to %s\n", current, r.Version)
+	if err = upgradeRelease(ctx, r, o.executable); err != nil {
+		return fmt.Errorf("upgrade to %s failed: %w", r.Version, err)
+	}
+	fmt.Printf("Kusion is upgraded to %s\n", r.Version)
+	return nil
+}
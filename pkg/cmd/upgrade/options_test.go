@@ -0,0 +1,62 @@
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/release"
+)
+
+func mockRelease(t *testing.T, upgraded *string) {
+	oldLatest, oldGet, oldUpgrade := latestRelease, getRelease, upgradeRelease
+	latestRelease = func(context.Context) (*release.Release, error) {
+		return &release.Release{Version: "v0.8.0"}, nil
+	}
+	getRelease = func(_ context.Context, version string) (*release.Release, error) {
+		if version != "v0.7.0" {
+			return nil, errors.New("get " + version + " failed: 404 Not Found")
+		}
+		return &release.Release{Version: version}, nil
+	}
+	upgradeRelease = func(_ context.Context, r *release.Release, _ string) error {
+		*upgraded = r.Version
+		return nil
+	}
+	t.Cleanup(func() {
+		latestRelease, getRelease, upgradeRelease = oldLatest, oldGet, oldUpgrade
+	})
+}
+
+func TestUpgradeOptions_Run(t *testing.T) {
+	t.Run("latest", func(t *testing.T) {
+		var upgraded string
+		mockRelease(t, &upgraded)
+		o := NewUpgradeOptions()
+		assert.NoError(t, o.Run())
+		assert.Equal(t, "v0.8.0", upgraded)
+	})
+
+	t.Run("version", func(t *testing.T) {
+		var upgraded string
+		mockRelease(t, &upgraded)
+		o := &UpgradeOptions{Version: "v0.7.0"}
+		assert.NoError(t, o.Run())
+		assert.Equal(t, "v0.7.0", upgraded)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		var upgraded string
+		mockRelease(t, &upgraded)
+		o := &UpgradeOptions{Version: "v0.1.0"}
+		assert.ErrorContains(t, o.Run(), "404")
+		assert.Empty(t, upgraded)
+	})
+}
+
+func TestUpgradeOptions_Validate(t *testing.T) {
+	t.Setenv(release.EnvOffline, "true")
+	assert.ErrorIs(t, NewUpgradeOptions().Validate(), release.ErrOffline)
+}
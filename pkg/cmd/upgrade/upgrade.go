@@ -0,0 +1,53 @@
+package upgrade
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"kusionstack.io/kusion/pkg/cmd/util"
+	"kusionstack.io/kusion/pkg/util/i18n"
+)
+
+var (
+	upgradeShort = "Upgrade kusion to the latest or a specified release"
+
+	upgradeLong = `
+		Download a release of kusion from the release channel and replace the running kusion binary with it.
+
+		The checksums of the release must be signed by the release workflow of kusion, which is verified by cosign
+		before the archive of this platform is downloaded, and the archive must match its checksum. Cosign must be
+		installed to upgrade.
+
+		Set KUSION_RELEASE_URL to use a mirror of the release channel, or set KUSION_OFFLINE to true in
+		air-gapped environments to disable upgrades and update checks.`
+
+	upgradeExample = `
+		# Upgrade kusion to the latest release
+		kusion upgrade
+
+		# Upgrade or downgrade kusion to a specified release
+		kusion upgrade --version v0.7.0`
+)
+
+func NewCmdUpgrade() *cobra.Command {
+	o := NewUpgradeOptions()
+
+	cmd := &cobra.Command{
+		Use:     "upgrade",
+		Short:   i18n.T(upgradeShort),
+		Long:    templates.LongDesc(i18n.T(upgradeLong)),
+		Example: templates.Examples(i18n.T(upgradeExample)),
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			defer util.RecoverErr(&err)
+			util.CheckErr(o.Complete())
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+			return
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Version, "version", "", "",
+		i18n.T("Specify the release to upgrade to, which defaults to the latest release"))
+
+	return cmd
+}
@@ -0,0 +1,64 @@
+package util
+
+import (
+	"errors"
+
+	"kusionstack.io/kusion/pkg/engine/states"
+)
+
+// Exit codes of kusion commands. CI pipelines can branch on them without parsing the command output.
+const (
+	// ExitCodeOK means the command succeeded. With `preview --detailed-exitcode`, it also means no changes found
+	ExitCodeOK = 0
+
+	// ExitCodeError means the command failed with an error not classified below
+	ExitCodeError = 1
+
+	// ExitCodeChanges means `preview --detailed-exitcode` succeeded and there are changes to apply
+	ExitCodeChanges = 2
+
+	// ExitCodePolicyViolation means the operation is blocked by a policy
+	ExitCodePolicyViolation = 3
+
+	// ExitCodeLockConflict means the state of this stack is locked by another operation
+	ExitCodeLockConflict = 4
+)
+
+// ExitError is an error with the exit code that the kusion process should exit with.
+// An ExitError with a nil Err only changes the exit code and prints nothing.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// NewExitError returns an ExitError with the given exit code and error
+func NewExitError(code int, err error) *ExitError {
+	return &ExitError{Code: code, Err: err}
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return ""
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code of the kusion process according to the error returned by a command
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeOK
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	if errors.Is(err, states.ErrStateLocked) {
+		return ExitCodeLockConflict
+	}
+	return ExitCodeError
+}
@@ -0,0 +1,61 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/status"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "nil error",
+			err:  nil,
+			want: ExitCodeOK,
+		},
+		{
+			name: "unclassified error",
+			err:  errors.New("error"),
+			want: ExitCodeError,
+		},
+		{
+			name: "changes present",
+			err:  NewExitError(ExitCodeChanges, nil),
+			want: ExitCodeChanges,
+		},
+		{
+			name: "wrapped policy violation",
+			err:  fmt.Errorf("apply failed: %w", NewExitError(ExitCodePolicyViolation, errors.New("deny"))),
+			want: ExitCodePolicyViolation,
+		},
+		{
+			name: "state locked",
+			err:  fmt.Errorf("apply state failed. %w", states.ErrStateLocked),
+			want: ExitCodeLockConflict,
+		},
+		{
+			name: "state locked in the status of the apply",
+			err:  status.Wrap(status.NewErrorStatus(fmt.Errorf("apply State failed. %w", states.ErrStateLocked)), "apply failed"),
+			want: ExitCodeLockConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ExitCode(tt.err))
+		})
+	}
+}
+
+func TestExitError_Error(t *testing.T) {
+	assert.Equal(t, "", NewExitError(ExitCodeChanges, nil).Error())
+	assert.Equal(t, "deny", NewExitError(ExitCodePolicyViolation, errors.New("deny")).Error())
+}
@@ -22,3 +22,7 @@ func CheckErr(err error) {
 		panic(err)
 	}
 }
+
+// PlainOutputAnnotation marks commands whose stdout is read by other programs, e.g. Argo CD, so that nothing
+// else such as the update check message is printed to stdout
+const PlainOutputAnnotation = "kusionstack.io/plain-output"
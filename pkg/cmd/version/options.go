@@ -1,15 +1,23 @@
 package version
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/blang/semver/v4"
+
+	"kusionstack.io/kusion/pkg/release"
 	"kusionstack.io/kusion/pkg/version"
 )
 
+// latestRelease returns the latest release in the release channel, which is replaced in tests
+var latestRelease = release.Latest
+
 type VersionOptions struct {
 	ExportJSON bool
 	ExportYAML bool
 	Short      bool
+	Check      bool
 }
 
 func NewVersionOptions() *VersionOptions {
@@ -17,7 +25,7 @@ func NewVersionOptions() *VersionOptions {
 }
 
 func (o *VersionOptions) Complete() {
-	if !(o.ExportYAML || o.ExportJSON || o.Short) {
+	if !(o.ExportYAML || o.ExportJSON || o.Short || o.Check) {
 		o.ExportYAML = true
 	}
 }
@@ -26,12 +34,20 @@ func (o *VersionOptions) Validate() error {
 	if (o.ExportJSON && o.ExportYAML) || (o.ExportJSON && o.Short) || (o.ExportYAML && o.Short) {
 		return fmt.Errorf("invalid options")
 	}
+	if o.Check && (o.ExportJSON || o.ExportYAML || o.Short) {
+		return fmt.Errorf("invalid options, --check can not be used with --json, --yaml or --short")
+	}
+	if o.Check && release.Offline() {
+		return release.ErrOffline
+	}
 
 	return nil
 }
 
-func (o *VersionOptions) Run() {
+func (o *VersionOptions) Run() error {
 	switch {
+	case o.Check:
+		return o.check()
 	case o.ExportJSON:
 		fmt.Println(version.JSON())
 	case o.ExportYAML:
@@ -41,4 +57,29 @@ func (o *VersionOptions) Run() {
 	default:
 		fmt.Println(version.String())
 	}
+	return nil
+}
+
+// check prints the current and the latest version, and how to upgrade if the latest one is newer
+func (o *VersionOptions) check() error {
+	latest, err := latestRelease(context.Background())
+	if err != nil {
+		return fmt.Errorf("check the latest version failed: %w", err)
+	}
+	current := version.ReleaseVersion()
+	fmt.Printf("Current version: %s\n", current)
+	fmt.Printf("Latest version: %s\n", latest.Version)
+
+	latestVer, err := semver.ParseTolerant(latest.Version)
+	if err != nil {
+		return fmt.Errorf("parse the latest version %s failed: %w", latest.Version, err)
+	}
+	// development builds are not semantic versions, which are always suggested to upgrade
+	curVer, err := semver.ParseTolerant(current)
+	if err != nil || latestVer.GT(curVer) {
+		fmt.Println("A new version of kusion is available, run `kusion upgrade` to upgrade.")
+	} else {
+		fmt.Println("Kusion is up to date.")
+	}
+	return nil
 }
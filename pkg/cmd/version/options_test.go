@@ -0,0 +1,46 @@
+package version
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/release"
+)
+
+func TestVersionOptions_Check(t *testing.T) {
+	old := latestRelease
+	t.Cleanup(func() { latestRelease = old })
+
+	t.Run("invalid options", func(t *testing.T) {
+		o := &VersionOptions{Check: true, ExportJSON: true}
+		assert.ErrorContains(t, o.Validate(), "--check can not be used")
+	})
+
+	t.Run("offline", func(t *testing.T) {
+		t.Setenv(release.EnvOffline, "true")
+		o := &VersionOptions{Check: true}
+		assert.ErrorIs(t, o.Validate(), release.ErrOffline)
+	})
+
+	t.Run("checked", func(t *testing.T) {
+		latestRelease = func(context.Context) (*release.Release, error) {
+			return &release.Release{Version: "v99.0.0"}, nil
+		}
+		o := &VersionOptions{Check: true}
+		o.Complete()
+		assert.NoError(t, o.Validate())
+		assert.False(t, o.ExportYAML)
+		assert.NoError(t, o.Run())
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		latestRelease = func(context.Context) (*release.Release, error) {
+			return nil, errors.New("timeout")
+		}
+		o := &VersionOptions{Check: true}
+		assert.ErrorContains(t, o.Run(), "timeout")
+	})
+}
@@ -15,7 +15,10 @@ var (
 
 	versionExample = `
 		# Print the kusion version
-		kusion version`
+		kusion version
+
+		# Check whether a newer version of kusion is released
+		kusion version --check`
 )
 
 func NewCmdVersion() *cobra.Command {
@@ -30,7 +33,7 @@ func NewCmdVersion() *cobra.Command {
 			defer util.RecoverErr(&err)
 			o.Complete()
 			util.CheckErr(o.Validate())
-			o.Run()
+			util.CheckErr(o.Run())
 			return
 		},
 	}
@@ -41,6 +44,8 @@ func NewCmdVersion() *cobra.Command {
 		i18n.T("print version info as YAML"))
 	cmd.Flags().BoolVarP(&o.Short, "short", "s", false,
 		i18n.T("print version info as versionShort string"))
+	cmd.Flags().BoolVarP(&o.Check, "check", "", false,
+		i18n.T("check whether a newer version is released, disabled if KUSION_OFFLINE is true"))
 
 	return cmd
 }
@@ -0,0 +1,78 @@
+// Package cost estimates monthly costs of resources in specs with prices configured in projects.
+package cost
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+const gib = 1 << 30
+
+// ResourceCost is the estimated monthly cost of a resource
+type ResourceCost struct {
+	ID      string
+	Monthly float64
+}
+
+// Estimate is the estimated monthly cost of resources
+type Estimate struct {
+	// Total is the sum of monthly costs of all resources
+	Total float64
+
+	// Resources are costs of resources which are not free, in the order of the spec
+	Resources []ResourceCost
+}
+
+// EstimateResources estimates monthly costs of the resources with the prices. Kubernetes resources are
+// priced by CPU, memory and storage they request, plus the price of their kinds. Other resources are priced
+// by their Terraform resource types.
+func EstimateResources(resources models.Resources, prices *projectstack.CostPrices) (*Estimate, error) {
+	estimate := &Estimate{}
+	if prices == nil {
+		return estimate, nil
+	}
+	for i := range resources {
+		res := &resources[i]
+		monthly, err := resourceCost(res, prices)
+		if err != nil {
+			return nil, fmt.Errorf("estimate the cost of %s failed: %w", res.ID, err)
+		}
+		if monthly == 0 {
+			continue
+		}
+		estimate.Total += monthly
+		estimate.Resources = append(estimate.Resources, ResourceCost{ID: res.ID, Monthly: monthly})
+	}
+	return estimate, nil
+}
+
+func resourceCost(res *models.Resource, prices *projectstack.CostPrices) (float64, error) {
+	if res.Type != runtime.Kubernetes {
+		resourceType, _ := res.Extensions["resourceType"].(string)
+		return prices.Resources[resourceType], nil
+	}
+
+	kind := (&unstructured.Unstructured{Object: res.Attributes}).GetKind()
+	monthly := prices.Resources[kind]
+	requested, err := kubernetes.RequestedResources(res)
+	if err != nil {
+		return 0, err
+	}
+	if q, ok := requested[corev1.ResourceRequestsCPU]; ok {
+		monthly += prices.CPU * float64(q.MilliValue()) / 1000
+	}
+	if q, ok := requested[corev1.ResourceRequestsMemory]; ok {
+		monthly += prices.Memory * float64(q.Value()) / gib
+	}
+	if q, ok := requested[corev1.ResourceRequestsStorage]; ok {
+		monthly += prices.Storage * float64(q.Value()) / gib
+	}
+	return monthly, nil
+}
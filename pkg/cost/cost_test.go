@@ -0,0 +1,89 @@
+package cost
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func TestEstimateResources(t *testing.T) {
+	deployment := models.Resource{
+		ID:   "apps/v1:Deployment:default:web",
+		Type: runtime.Kubernetes,
+		Attributes: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "web"},
+			"spec": map[string]interface{}{
+				"replicas": 2,
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{
+								"name": "main",
+								"resources": map[string]interface{}{
+									"requests": map[string]interface{}{"cpu": "500m", "memory": "2Gi"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	pvc := models.Resource{
+		ID:   "v1:PersistentVolumeClaim:default:data",
+		Type: runtime.Kubernetes,
+		Attributes: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolumeClaim",
+			"metadata":   map[string]interface{}{"name": "data"},
+			"spec": map[string]interface{}{
+				"resources": map[string]interface{}{"requests": map[string]interface{}{"storage": "100Gi"}},
+			},
+		},
+	}
+	ingress := models.Resource{
+		ID:   "networking.k8s.io/v1:Ingress:default:web",
+		Type: runtime.Kubernetes,
+		Attributes: map[string]interface{}{
+			"apiVersion": "networking.k8s.io/v1",
+			"kind":       "Ingress",
+			"metadata":   map[string]interface{}{"name": "web"},
+		},
+	}
+	vm := models.Resource{
+		ID:         "aliyun:alicloud:alicloud_instance:vm",
+		Type:       runtime.Terraform,
+		Extensions: map[string]interface{}{"resourceType": "alicloud_instance"},
+	}
+	configMap := models.Resource{
+		ID:         "v1:ConfigMap:default:cm",
+		Type:       runtime.Kubernetes,
+		Attributes: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"},
+	}
+	resources := models.Resources{deployment, pvc, ingress, vm, configMap}
+
+	estimate, err := EstimateResources(resources, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, &Estimate{}, estimate)
+
+	estimate, err = EstimateResources(resources, &projectstack.CostPrices{
+		CPU:       20,
+		Memory:    2.5,
+		Storage:   0.1,
+		Resources: map[string]float64{"Ingress": 15, "alicloud_instance": 60},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []ResourceCost{
+		{ID: deployment.ID, Monthly: 30},
+		{ID: pvc.ID, Monthly: 10},
+		{ID: ingress.ID, Monthly: 15},
+		{ID: vm.ID, Monthly: 60},
+	}, estimate.Resources)
+	assert.InDelta(t, 115, estimate.Total, 1e-9)
+}
@@ -1,8 +1,12 @@
 package backend
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -12,6 +16,7 @@ import (
 	backendInit "kusionstack.io/kusion/pkg/engine/backend/init"
 	"kusionstack.io/kusion/pkg/engine/states"
 	"kusionstack.io/kusion/pkg/engine/states/local"
+	"kusionstack.io/kusion/pkg/util/credentials"
 	"kusionstack.io/kusion/pkg/util/i18n"
 )
 
@@ -19,8 +24,25 @@ import (
 type Storage struct {
 	Type   string                 `json:"storageType,omitempty" yaml:"storageType,omitempty"`
 	Config map[string]interface{} `json:"config,omitempty" yaml:"config,omitempty"`
+
+	// CredentialHelper vends credentials of the backend, whose config is merged into Config without
+	// overriding it, see the package credentials
+	CredentialHelper string `json:"credentialHelper,omitempty" yaml:"credentialHelper,omitempty"`
 }
 
+// Environment variables overriding the backend config of the project, which are overridden by flags in turn, so
+// that CI runners can switch backends without changing project.yaml or flags of each command
+const (
+	// EnvBackendType overrides the storage type, e.g. s3
+	EnvBackendType = "KUSION_BACKEND_TYPE"
+	// EnvBackendConfig overrides configurations separated by commas, e.g. bucket=kusion,region=us-east-1
+	EnvBackendConfig = "KUSION_BACKEND_CONFIG"
+)
+
+// envPlaceholder matches placeholders of environment variables in backend configs, e.g. ${AWS_SECRET_ACCESS_KEY}
+// or ${OSS_BUCKET:-kusion} with a default value
+var envPlaceholder = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
 // BackendOps kusion cli backend override config
 type BackendOps struct {
 	// Config is a series of backend configurations,
@@ -68,6 +90,10 @@ func NewDefaultBackend(dir string, fileName string) *Storage {
 // BackendFromConfig return stateStorage, this func handler
 // backend config merge and configure backend.
 // return a StateStorage to manage State
+//
+// The backend config of the project is overridden by the environment variables KUSION_BACKEND_TYPE and
+// KUSION_BACKEND_CONFIG, and then by flags. Placeholders like ${VAR} and ${VAR:-default} in string values are
+// replaced by environment variables, so that secrets are not written in project.yaml.
 func BackendFromConfig(config *Storage, override BackendOps, dir string) (states.StateStorage, error) {
 	var backendConfig Storage
 	if config == nil {
@@ -77,20 +103,38 @@ func BackendFromConfig(config *Storage, override BackendOps, dir string) (states
 		backendConfig.Type = config.Type
 	}
 
+	var envConfig []string
+	if t := os.Getenv(EnvBackendType); t != "" {
+		backendConfig.Type = t
+	}
+	if c := os.Getenv(EnvBackendConfig); c != "" {
+		envConfig = strings.Split(c, ",")
+	}
 	if override.Type != "" {
 		backendConfig.Type = override.Type
 	}
 	configOverride := make(map[string]interface{})
-	for _, v := range override.Config {
-		bk := strings.Split(v, "=")
+	for _, v := range append(envConfig, override.Config...) {
+		bk := strings.SplitN(v, "=", 2)
 		if len(bk) != 2 {
 			return nil, fmt.Errorf("kusion cli backend config should be path=kusion_state.json")
 		}
-		configOverride[bk[0]] = bk[1]
+		configOverride[strings.TrimSpace(bk[0])] = bk[1]
 	}
-	if config.Config != nil || override.Config != nil {
+	if config.Config != nil || len(configOverride) > 0 {
 		backendConfig.Config = MergeConfig(config.Config, configOverride)
 	}
+	if config.CredentialHelper != "" {
+		creds, err := credentials.Get(context.Background(), config.CredentialHelper, &credentials.Request{
+			Kind:   credentials.KindBackend,
+			Type:   backendConfig.Type,
+			Config: backendConfig.Config,
+		})
+		if err != nil {
+			return nil, err
+		}
+		backendConfig.Config = MergeConfig(creds.Config, backendConfig.Config)
+	}
 
 	backendFunc := backendInit.GetBackend(backendConfig.Type)
 	if backendFunc == nil {
@@ -104,6 +148,9 @@ func BackendFromConfig(config *Storage, override BackendOps, dir string) (states
 	if err != nil {
 		return nil, err
 	}
+	if backendConfig.Config, err = resolveBackendConfig(backendConfig.Config, backendSchema); err != nil {
+		return nil, err
+	}
 	ctyBackend, err := gocty.ToCtyValue(backendConfig.Config, backendSchema)
 	if err != nil {
 		return nil, err
@@ -117,6 +164,63 @@ func BackendFromConfig(config *Storage, override BackendOps, dir string) (states
 	return bf.StateStorage(), nil
 }
 
+// resolveBackendConfig returns a copy of the config whose placeholders of environment variables are replaced,
+// and whose strings are converted to numbers and bools as the schema requires, since configs from environment
+// variables and flags are always strings
+func resolveBackendConfig(config map[string]interface{}, schema cty.Type) (map[string]interface{}, error) {
+	if config == nil {
+		return nil, nil
+	}
+	resolved := make(map[string]interface{}, len(config))
+	for k, v := range config {
+		s, ok := v.(string)
+		if !ok {
+			resolved[k] = v
+			continue
+		}
+		s, err := ExpandEnv(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in backend config: %w", k, err)
+		}
+		resolved[k] = s
+		if !schema.HasAttribute(k) {
+			continue
+		}
+		switch schema.AttributeType(k) {
+		case cty.Number:
+			if resolved[k], err = strconv.ParseFloat(s, 64); err != nil {
+				return nil, fmt.Errorf("invalid %s in backend config, must be a number: %s", k, s)
+			}
+		case cty.Bool:
+			if resolved[k], err = strconv.ParseBool(s); err != nil {
+				return nil, fmt.Errorf("invalid %s in backend config, must be a bool: %s", k, s)
+			}
+		}
+	}
+	return resolved, nil
+}
+
+// ExpandEnv replaces placeholders like ${VAR} and ${VAR:-default} in the value with environment variables, and
+// fails if a variable without a default value is not set
+func ExpandEnv(value string) (string, error) {
+	var missing []string
+	expanded := envPlaceholder.ReplaceAllStringFunc(value, func(placeholder string) string {
+		m := envPlaceholder.FindStringSubmatch(placeholder)
+		if v, ok := os.LookupEnv(m[1]); ok && v != "" {
+			return v
+		}
+		if m[2] != "" {
+			return m[3]
+		}
+		missing = append(missing, m[1])
+		return placeholder
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variables %s are not set", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
 // validBackendConfig check backend config.
 func validBackendConfig(config map[string]interface{}, schema cty.Type) error {
 	for k := range config {
@@ -1,6 +1,8 @@
 package backend
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -9,6 +11,7 @@ import (
 	_ "kusionstack.io/kusion/pkg/engine/backend/init"
 	"kusionstack.io/kusion/pkg/engine/states"
 	"kusionstack.io/kusion/pkg/engine/states/local"
+	"kusionstack.io/kusion/pkg/util/credentials"
 )
 
 func TestMergeConfig(t *testing.T) {
@@ -127,3 +130,86 @@ func TestValidBackendConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestBackendFromConfig_Env(t *testing.T) {
+	t.Setenv(EnvBackendConfig, "path=${STATE_DIR}/env.json")
+	t.Setenv("STATE_DIR", "/tmp/kusion")
+	config := &Storage{Type: "local", Config: map[string]interface{}{"path": "kusion_state.json"}}
+
+	storage, err := BackendFromConfig(config, BackendOps{}, "./")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&local.FileSystemState{Path: "/tmp/kusion/env.json"}, storage); diff != "" {
+		t.Errorf("\nWrapBackendFromConfigFailed(...): -want message, +got message:\n%s", diff)
+	}
+
+	// flags override environment variables
+	storage, err = BackendFromConfig(config, BackendOps{Config: []string{"path=flag.json"}}, "./")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&local.FileSystemState{Path: "flag.json"}, storage); diff != "" {
+		t.Errorf("\nWrapBackendFromConfigFailed(...): -want message, +got message:\n%s", diff)
+	}
+
+	t.Setenv(EnvBackendType, "unknown")
+	if _, err = BackendFromConfig(config, BackendOps{}, "./"); err == nil {
+		t.Errorf("BackendFromConfig() should fail with the unknown type from %s", EnvBackendType)
+	}
+}
+
+func TestResolveBackendConfig(t *testing.T) {
+	t.Setenv("DB_PORT", "3306")
+	schema := cty.Object(map[string]cty.Type{"dbHost": cty.String, "dbPort": cty.Number, "tls": cty.Bool})
+
+	got, err := resolveBackendConfig(map[string]interface{}{
+		"dbHost": "${DB_HOST:-localhost}",
+		"dbPort": "${DB_PORT}",
+		"tls":    "true",
+	}, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"dbHost": "localhost", "dbPort": float64(3306), "tls": true}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("\nWrapResolveBackendConfigFailed(...): -want message, +got message:\n%s", diff)
+	}
+
+	if _, err = resolveBackendConfig(map[string]interface{}{"dbHost": "${DB_MISSING}"}, schema); err == nil {
+		t.Errorf("resolveBackendConfig() should fail with unset environment variables")
+	}
+	if _, err = resolveBackendConfig(map[string]interface{}{"dbPort": "port"}, schema); err == nil {
+		t.Errorf("resolveBackendConfig() should fail with invalid numbers")
+	}
+}
+
+func TestBackendFromConfig_CredentialHelper(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	script := "#!/bin/sh\necho '{\"config\":{\"path\":\"helper.json\",\"format\":\"v2\"}}'\n"
+	if err := os.WriteFile(filepath.Join(dir, credentials.HelperPrefix+"helper"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// configs of the project are not overridden by the helper
+	config := &Storage{Type: "local", Config: map[string]interface{}{"format": "v1"}, CredentialHelper: "helper"}
+	storage, err := BackendFromConfig(config, BackendOps{}, "./")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&local.FileSystemState{Path: "helper.json", Format: "v1"}, storage); diff != "" {
+		t.Errorf("\nWrapBackendFromConfigFailed(...): -want message, +got message:\n%s", diff)
+	}
+
+	config.CredentialHelper = "missing"
+	if _, err = BackendFromConfig(config, BackendOps{}, "./"); err == nil {
+		t.Errorf("BackendFromConfig() should fail with the missing credential helper")
+	}
+
+	// paths in the config are never run
+	config.CredentialHelper = filepath.Join(dir, credentials.HelperPrefix+"helper")
+	if _, err = BackendFromConfig(config, BackendOps{}, "./"); err == nil {
+		t.Errorf("BackendFromConfig() should fail with the credential helper of a path")
+	}
+}
@@ -0,0 +1,246 @@
+// Package bundle collects diagnostic bundles of failed operations, which users attach to issues so that
+// maintainers can investigate failures without access to their clusters. Sensitive attributes of resources are
+// masked, and values which look like credentials are redacted from errors and logs.
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	"gopkg.in/yaml.v3"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	runtimeinit "kusionstack.io/kusion/pkg/engine/runtime/init"
+	"kusionstack.io/kusion/pkg/engine/sensitive"
+	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/status"
+	"kusionstack.io/kusion/pkg/version"
+)
+
+// root is the directory of all files in bundles
+const root = "kusion-bundle"
+
+// Limits of bundles, which keep them small enough to be attached to issues
+var (
+	// maxLogBytes is the max size of the tail of each log file
+	maxLogBytes int64 = 1 << 20
+	// maxResources is the max number of failed resources whose live objects are collected
+	maxResources = 10
+)
+
+// unsafeChars matches characters of resource IDs which are unsafe in file names
+var unsafeChars = regexp.MustCompile(`[^\w.-]+`)
+
+// Request contains what is collected into a bundle
+type Request struct {
+	// Operation is the name of the failed operation, e.g. apply
+	Operation string
+	Project   *projectstack.Project
+	Stack     *projectstack.Stack
+
+	// Spec is the compiled spec of the operation
+	Spec *models.Spec
+	// Changes are the changes previewed before the operation
+	Changes *opsmodels.Changes
+	// Failed contains keys of resources failed in the operation, whose live objects and diagnoses are collected
+	Failed []string
+	// Error is the error of the operation
+	Error error
+	// RuntimeEnv is the env of runtimes of the stack, which read live objects of failed resources
+	RuntimeEnv runtime.Env
+}
+
+// Write collects the bundle of the failed operation into a tar.gz file at the path. Files are skipped with
+// their errors recorded in the bundle if they can not be collected, e.g. live objects of unreachable clusters.
+func Write(ctx context.Context, path string, req *Request) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("write bundle failed: %w", err)
+	}
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	b := &builder{tw: tar.NewWriter(gw), now: time.Now()}
+	b.collect(ctx, req)
+	if b.err != nil {
+		return fmt.Errorf("write bundle failed: %w", b.err)
+	}
+	if err = b.tw.Close(); err != nil {
+		return fmt.Errorf("write bundle failed: %w", err)
+	}
+	if err = gw.Close(); err != nil {
+		return fmt.Errorf("write bundle failed: %w", err)
+	}
+	return nil
+}
+
+// builder writes files to the tar, and keeps the first error of writing
+type builder struct {
+	tw  *tar.Writer
+	now time.Time
+	err error
+
+	// problems are errors of collecting files, which are written to problems.txt
+	problems []string
+}
+
+func (b *builder) collect(ctx context.Context, req *Request) {
+	summary := map[string]interface{}{
+		"operation": req.Operation,
+		"time":      b.now.Format(time.RFC3339),
+		"failed":    req.Failed,
+	}
+	if req.Project != nil {
+		summary["project"] = req.Project.Name
+	}
+	if req.Stack != nil {
+		summary["stack"] = req.Stack.Name
+	}
+	if req.Error != nil {
+		summary["error"] = sensitive.RedactText(req.Error.Error())
+	}
+	b.writeYAML("summary.yaml", summary)
+	b.writeFile("version.yaml", []byte(version.YAML()))
+
+	if req.Spec != nil {
+		b.writeYAML("spec.yaml", &models.Spec{Resources: sensitive.MaskResources(req.Spec.Resources)})
+	}
+	if req.Changes != nil && req.Changes.ChangeOrder != nil {
+		// diffs of change steps mask sensitive values even if secrets are shown in the terminal
+		order := *req.Changes.ChangeOrder
+		order.ShowSecrets = false
+		b.writeFile("plan.txt", []byte(pterm.RemoveColorFromString(order.Diffs())))
+	}
+	b.collectResources(ctx, req)
+	b.collectLogs()
+
+	if len(b.problems) > 0 {
+		text := strings.Join(b.problems, "\n") + "\n"
+		b.writeFile("problems.txt", []byte(sensitive.RedactText(text)))
+	}
+}
+
+// collectResources writes live objects and diagnoses of failed resources
+func (b *builder) collectResources(ctx context.Context, req *Request) {
+	if req.Spec == nil || len(req.Failed) == 0 {
+		return
+	}
+	index := req.Spec.Resources.Index()
+	var resources models.Resources
+	for _, key := range req.Failed {
+		if res, ok := index[key]; ok {
+			resources = append(resources, *res)
+		}
+	}
+	if len(resources) > maxResources {
+		b.problems = append(b.problems, fmt.Sprintf("only %d of %d failed resources are collected", maxResources, len(resources)))
+		resources = resources[:maxResources]
+	}
+	if len(resources) == 0 {
+		return
+	}
+	runtimes, s := runtimeinit.Runtimes(resources, req.RuntimeEnv)
+	if status.IsErr(s) {
+		b.problems = append(b.problems, fmt.Sprintf("init runtimes failed: %s", s.Message()))
+		return
+	}
+	defer runtimeinit.Close(runtimes)
+
+	for i := range resources {
+		res := &resources[i]
+		name := "resources/" + unsafeChars.ReplaceAllString(res.ResourceKey(), "_")
+		r := runtimes[res.Type]
+		resp := r.Read(ctx, &runtime.ReadRequest{PlanResource: res, Stack: req.Stack})
+		switch {
+		case resp != nil && status.IsErr(resp.Status):
+			b.problems = append(b.problems, fmt.Sprintf("read %s failed: %s", res.ResourceKey(), resp.Status.Message()))
+		case resp == nil || resp.Resource == nil:
+			b.problems = append(b.problems, fmt.Sprintf("%s is not found", res.ResourceKey()))
+		default:
+			b.writeYAML(name+".yaml", sensitive.MaskResource(resp.Resource))
+		}
+
+		if diagnoser, ok := r.(runtime.Diagnoser); ok {
+			diagnosis, err := diagnoser.Diagnose(ctx, res)
+			if err != nil {
+				b.problems = append(b.problems, fmt.Sprintf("diagnose %s failed: %v", res.ResourceKey(), err))
+			} else if diagnosis != "" {
+				b.writeFile(name+".diagnosis.txt", []byte(sensitive.RedactText(diagnosis)))
+			}
+		}
+	}
+}
+
+// collectLogs writes tails of the log files of kusion
+func (b *builder) collectLogs() {
+	dir := log.GetLogDir()
+	for _, path := range []string{dir.DefaultLogDir, dir.ErrorLogDir} {
+		if path == "" {
+			continue
+		}
+		data, err := tail(path, maxLogBytes)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				b.problems = append(b.problems, fmt.Sprintf("read log %s failed: %v", path, err))
+			}
+			continue
+		}
+		b.writeFile("logs/"+filepath.Base(path), []byte(sensitive.RedactText(string(data))))
+	}
+}
+
+func (b *builder) writeYAML(name string, value interface{}) {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		b.problems = append(b.problems, fmt.Sprintf("marshal %s failed: %v", name, err))
+		return
+	}
+	b.writeFile(name, data)
+}
+
+func (b *builder) writeFile(name string, data []byte) {
+	if b.err != nil {
+		return
+	}
+	if b.err = b.tw.WriteHeader(&tar.Header{
+		Name:     root + "/" + name,
+		Mode:     0o644,
+		Size:     int64(len(data)),
+		ModTime:  b.now,
+		Typeflag: tar.TypeReg,
+	}); b.err != nil {
+		return
+	}
+	_, b.err = b.tw.Write(data)
+}
+
+// tail reads the last bytes of the file
+func tail(path string, size int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > size {
+		if _, err = f.Seek(-size, io.SeekEnd); err != nil {
+			return nil, err
+		}
+	}
+	return io.ReadAll(f)
+}
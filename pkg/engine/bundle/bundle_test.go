@@ -0,0 +1,161 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	runtimeinit "kusionstack.io/kusion/pkg/engine/runtime/init"
+	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/status"
+)
+
+func TestWrite(t *testing.T) {
+	defer monkey.UnpatchAll()
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "kusion.log")
+	assert.Nil(t, os.WriteFile(logPath, []byte("INFO connect with token=abc\n"), 0o644))
+	monkey.Patch(log.GetLogDir, func() log.LogDir {
+		return log.LogDir{DefaultLogDir: logPath, ErrorLogDir: filepath.Join(dir, "missing.log")}
+	})
+	monkey.Patch(runtimeinit.Runtimes, func(resources models.Resources, _ runtime.Env) (map[models.Type]runtime.Runtime, status.Status) {
+		return map[models.Type]runtime.Runtime{runtime.Kubernetes: &fakeRuntime{}}, nil
+	})
+
+	secret := models.Resource{
+		ID:   "v1:Secret:default:db",
+		Type: runtime.Kubernetes,
+		Attributes: map[string]interface{}{
+			"kind": "Secret",
+			"data": map[string]interface{}{"password": "c2VjcmV0"},
+		},
+	}
+	missing := models.Resource{ID: "v1:Service:default:missing", Type: runtime.Kubernetes}
+	spec := &models.Spec{Resources: models.Resources{secret, missing}}
+	changes := opsmodels.NewChanges(&projectstack.Project{}, &projectstack.Stack{}, &opsmodels.ChangeOrder{
+		StepKeys: []string{secret.ID},
+		ChangeSteps: map[string]*opsmodels.ChangeStep{
+			secret.ID: {ID: secret.ID, Action: opsmodels.Create, To: &secret},
+		},
+	})
+
+	path := filepath.Join(dir, "bundle.tar.gz")
+	err := Write(context.Background(), path, &Request{
+		Operation: "apply",
+		Project:   &projectstack.Project{ProjectConfiguration: projectstack.ProjectConfiguration{Name: "demo"}},
+		Stack:     &projectstack.Stack{StackConfiguration: projectstack.StackConfiguration{Name: "dev"}},
+		Spec:      spec,
+		Changes:   changes,
+		Failed:    []string{secret.ID, missing.ID},
+		Error:     errors.New("apply failed: password=hunter2"),
+	})
+	assert.Nil(t, err)
+
+	files := readBundle(t, path)
+	assert.ElementsMatch(t, []string{
+		"summary.yaml", "version.yaml", "spec.yaml", "plan.txt", "resources/v1_Secret_default_db.yaml",
+		"resources/v1_Secret_default_db.diagnosis.txt", "logs/kusion.log", "problems.txt",
+	}, keys(files))
+	assert.Contains(t, files["summary.yaml"], "project: demo\n")
+	assert.Contains(t, files["summary.yaml"], "error: 'apply failed: password=(redacted)'\n")
+	for _, name := range []string{"spec.yaml", "plan.txt", "resources/v1_Secret_default_db.yaml"} {
+		assert.NotContains(t, files[name], "c2VjcmV0", name)
+		assert.Contains(t, files[name], "sensitive value hmac:", name)
+	}
+	assert.Equal(t, "Warning BackOff: token=(redacted)\n", files["resources/v1_Secret_default_db.diagnosis.txt"])
+	assert.Equal(t, "INFO connect with token=(redacted)\n", files["logs/kusion.log"])
+	assert.Equal(t, "v1:Service:default:missing is not found\n", files["problems.txt"])
+}
+
+func TestTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kusion.log")
+	assert.Nil(t, os.WriteFile(path, []byte("0123456789"), 0o644))
+
+	data, err := tail(path, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, "6789", string(data))
+	data, err = tail(path, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, "0123456789", string(data))
+}
+
+func readBundle(t *testing.T, path string) map[string]string {
+	f, err := os.Open(path)
+	assert.Nil(t, err)
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	assert.Nil(t, err)
+	tr := tar.NewReader(gr)
+
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		data, err := io.ReadAll(tr)
+		assert.Nil(t, err)
+		rel, err := filepath.Rel(root, header.Name)
+		assert.Nil(t, err)
+		files[rel] = string(data)
+	}
+	return files
+}
+
+func keys(files map[string]string) []string {
+	var result []string
+	for name := range files {
+		result = append(result, name)
+	}
+	return result
+}
+
+type fakeRuntime struct{}
+
+var (
+	_ runtime.Runtime   = (*fakeRuntime)(nil)
+	_ runtime.Diagnoser = (*fakeRuntime)(nil)
+)
+
+func (f *fakeRuntime) Apply(ctx context.Context, request *runtime.ApplyRequest) *runtime.ApplyResponse {
+	return nil
+}
+
+func (f *fakeRuntime) Read(ctx context.Context, request *runtime.ReadRequest) *runtime.ReadResponse {
+	if request.PlanResource.ID == "v1:Service:default:missing" {
+		return &runtime.ReadResponse{}
+	}
+	return &runtime.ReadResponse{Resource: request.PlanResource}
+}
+
+func (f *fakeRuntime) Import(ctx context.Context, request *runtime.ImportRequest) *runtime.ImportResponse {
+	return nil
+}
+
+func (f *fakeRuntime) Delete(ctx context.Context, request *runtime.DeleteRequest) *runtime.DeleteResponse {
+	return nil
+}
+
+func (f *fakeRuntime) Watch(ctx context.Context, request *runtime.WatchRequest) *runtime.WatchResponse {
+	return nil
+}
+
+func (f *fakeRuntime) Diagnose(ctx context.Context, resource *models.Resource) (string, error) {
+	if resource.ID == "v1:Service:default:missing" {
+		return "", nil
+	}
+	return "Warning BackOff: token=abc\n", nil
+}
@@ -9,6 +9,7 @@ import (
 	kcl "kusionstack.io/kclvm-go"
 
 	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/sensitive"
 	"kusionstack.io/kusion/pkg/log"
 	jsonUtil "kusionstack.io/kusion/pkg/util/json"
 )
@@ -19,13 +20,6 @@ func ResourcesYAML2Spec(resourcesYAML []kcl.KCLResult) (*models.Spec, error) {
 	resources := []models.Resource{}
 
 	for _, resourcesYamlMap := range resourcesYAML {
-		// Convert kcl result to yaml string
-		msg := jsonUtil.MustMarshal2String(resourcesYamlMap)
-		if len(msg) > MaxLogLength {
-			msg = msg[0:MaxLogLength]
-		}
-
-		log.Infof("convertKCLResult2Resources resource:%v", msg)
 		// Using yamlv2.Marshal and yamlv3.Unmarshal is a workaround for the error "did not find expected '-' indicator" in unmarshalling yaml
 		yamlByte, err := yamlv2.Marshal(resourcesYamlMap)
 		if err != nil {
@@ -39,6 +33,13 @@ func ResourcesYAML2Spec(resourcesYAML []kcl.KCLResult) (*models.Spec, error) {
 			return nil, err
 		}
 
+		// Log the resource after sensitive attributes are masked
+		msg := jsonUtil.MustMarshal2String(sensitive.MaskResource(item))
+		if len(msg) > MaxLogLength {
+			msg = msg[0:MaxLogLength]
+		}
+		log.Infof("convertKCLResult2Resources resource:%v", msg)
+
 		resources = append(resources, *item)
 	}
 
@@ -0,0 +1,125 @@
+// Package metrics records Prometheus metrics of engine operations, such as resources applied and failed,
+// latencies of runtime requests, durations of graph walks and latencies of the state backend. Metrics can
+// be scraped from the endpoint started by Serve, or pushed to a Prometheus Pushgateway by Push when the
+// command exits.
+package metrics
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/log"
+)
+
+const (
+	namespace = "kusion"
+
+	// JobName is the job of metrics pushed to the Pushgateway
+	JobName = "kusion"
+
+	// Path is the path of the metrics endpoint
+	Path = "/metrics"
+)
+
+// Results of requests and resources
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Registry is the registry of all metrics of Kusion, which does not include metrics of the Go runtime
+// since commands of Kusion are short-lived
+var Registry = prometheus.NewRegistry()
+
+var (
+	resourcesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "resources_total",
+		Help:      "Number of resources applied by the engine, partitioned by action, runtime and result.",
+	}, []string{"action", "runtime", "result"})
+
+	runtimeRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "runtime_request_duration_seconds",
+		Help:      "Latency of requests to runtimes, partitioned by runtime, method and result.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"runtime", "method", "result"})
+
+	graphWalkDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "graph_walk_duration_seconds",
+		Help:      "Duration of walking the resource graph, partitioned by operation and result.",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 12),
+	}, []string{"operation", "result"})
+
+	stateRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "state_backend_request_duration_seconds",
+		Help:      "Latency of requests to the state backend, partitioned by method and result.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "result"})
+)
+
+func init() {
+	Registry.MustRegister(resourcesTotal, runtimeRequestDuration, graphWalkDuration, stateRequestDuration)
+}
+
+// ObserveResource counts the resource applied with the action
+func ObserveResource(action string, runtime models.Type, failed bool) {
+	resourcesTotal.WithLabelValues(action, string(runtime), result(failed)).Inc()
+}
+
+// ObserveRuntimeRequest records the latency of the request to the runtime which started at start
+func ObserveRuntimeRequest(runtime models.Type, method string, start time.Time, failed bool) {
+	runtimeRequestDuration.WithLabelValues(string(runtime), method, result(failed)).Observe(time.Since(start).Seconds())
+}
+
+// ObserveGraphWalk records the duration of walking the resource graph of the operation which started at start
+func ObserveGraphWalk(operation string, start time.Time, failed bool) {
+	graphWalkDuration.WithLabelValues(operation, result(failed)).Observe(time.Since(start).Seconds())
+}
+
+// ObserveStateRequest records the latency of the request to the state backend which started at start
+func ObserveStateRequest(method string, start time.Time, failed bool) {
+	stateRequestDuration.WithLabelValues(method, result(failed)).Observe(time.Since(start).Seconds())
+}
+
+func result(failed bool) string {
+	if failed {
+		return ResultFailure
+	}
+	return ResultSuccess
+}
+
+// Serve exposes metrics at the path /metrics of the address in background, and returns the address
+// listened, which is useful when the port of the address is 0
+func Serve(addr string) (string, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	mux := http.NewServeMux()
+	mux.Handle(Path, promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+	go func() {
+		if err := http.Serve(listener, mux); err != nil && !errors.Is(err, net.ErrClosed) {
+			log.Errorf("serve metrics at %s failed: %v", listener.Addr(), err)
+		}
+	}()
+	return listener.Addr().String(), nil
+}
+
+// Push pushes metrics to the Pushgateway at the url, grouped by the labels
+func Push(url string, labels map[string]string) error {
+	pusher := push.New(url, JobName).Gatherer(Registry)
+	for k, v := range labels {
+		pusher = pusher.Grouping(k, v)
+	}
+	return pusher.Push()
+}
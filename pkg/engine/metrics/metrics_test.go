@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+)
+
+func TestObserve(t *testing.T) {
+	ObserveResource("Create", "Kubernetes", false)
+	ObserveResource("Create", "Kubernetes", true)
+	ObserveResource("Create", "Kubernetes", true)
+	assert.Equal(t, float64(1), testutil.ToFloat64(resourcesTotal.WithLabelValues("Create", "Kubernetes", ResultSuccess)))
+	assert.Equal(t, float64(2), testutil.ToFloat64(resourcesTotal.WithLabelValues("Create", "Kubernetes", ResultFailure)))
+
+	start := time.Now()
+	ObserveRuntimeRequest(models.Type("Terraform"), "apply", start, false)
+	ObserveGraphWalk("apply", start, false)
+	ObserveStateRequest("get", start, true)
+	assert.Equal(t, 1, testutil.CollectAndCount(runtimeRequestDuration))
+	assert.Equal(t, 1, testutil.CollectAndCount(graphWalkDuration))
+	assert.Equal(t, 1, testutil.CollectAndCount(stateRequestDuration))
+}
+
+func TestServe(t *testing.T) {
+	ObserveResource("Delete", "Kubernetes", false)
+	addr, err := Serve("127.0.0.1:0")
+	assert.Nil(t, err)
+
+	resp, err := http.Get("http://" + addr + Path)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), `kusion_resources_total{action="Delete",result="success",runtime="Kubernetes"}`)
+
+	_, err = Serve("invalid-address")
+	assert.NotNil(t, err)
+}
+
+func TestPush(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	assert.Nil(t, Push(server.URL, map[string]string{"instance": "host"}))
+	assert.True(t, strings.HasPrefix(path, "/metrics/job/kusion"), path)
+	assert.Contains(t, path, "/instance/host")
+}
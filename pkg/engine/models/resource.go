@@ -1,6 +1,6 @@
 package models
 
-import "encoding/json"
+import "reflect"
 
 type Type string
 
@@ -28,17 +28,77 @@ func (r *Resource) ResourceKey() string {
 	return r.ID
 }
 
-// DeepCopy return a copy of resource
+// DeepCopy return a copy of resource. Maps and slices in attributes and extensions are copied recursively
+// instead of being marshaled to JSON and back, which takes several times the memory of the resource.
 func (r *Resource) DeepCopy() *Resource {
-	var out Resource
-	data, err := json.Marshal(r)
-	if err != nil {
-		panic(err)
+	if r == nil {
+		return &Resource{}
+	}
+	out := *r
+	if r.Attributes != nil {
+		out.Attributes = deepCopyValue(r.Attributes).(map[string]interface{})
+	}
+	if r.Extensions != nil {
+		out.Extensions = deepCopyValue(r.Extensions).(map[string]interface{})
+	}
+	if r.DependsOn != nil {
+		out.DependsOn = append([]string{}, r.DependsOn...)
 	}
-	_ = json.Unmarshal(data, &out)
 	return &out
 }
 
+// deepCopyValue copies maps and slices, e.g. the ones decoded from JSON or YAML and typed ones set by generators,
+// other values are immutable and shared
+func deepCopyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = deepCopyValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = deepCopyValue(item)
+		}
+		return result
+	}
+
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Kind() == reflect.Slice && !v.IsNil():
+		result := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			result.Index(i).Set(deepCopyReflectValue(v.Index(i)))
+		}
+		return result.Interface()
+	case v.Kind() == reflect.Map && !v.IsNil():
+		result := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			result.SetMapIndex(iter.Key(), deepCopyReflectValue(iter.Value()))
+		}
+		return result.Interface()
+	default:
+		return value
+	}
+}
+
+// deepCopyReflectValue copies the element of a slice or a map, which keeps its type, e.g. interfaces
+func deepCopyReflectValue(v reflect.Value) reflect.Value {
+	if (v.Kind() == reflect.Interface || v.Kind() == reflect.Map || v.Kind() == reflect.Slice) && v.IsNil() {
+		return v
+	}
+	copied := reflect.ValueOf(deepCopyValue(v.Interface()))
+	if v.Kind() == reflect.Interface {
+		result := reflect.New(v.Type()).Elem()
+		result.Set(copied)
+		return result
+	}
+	return copied
+}
+
 func (rs Resources) Index() map[string]*Resource {
 	m := make(map[string]*Resource)
 	for i := range rs {
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResource_DeepCopy(t *testing.T) {
+	r := &Resource{
+		ID: "v1:ConfigMap:default:app",
+		Attributes: map[string]interface{}{
+			"data":    map[string]string{"key": "value"},
+			"args":    []string{"a"},
+			"ports":   []map[string]interface{}{{"port": 80}},
+			"nested":  map[string]interface{}{"items": []interface{}{map[string]interface{}{"k": "v"}}},
+			"empty":   []string(nil),
+			"replica": 1,
+		},
+		DependsOn: []string{"v1:Namespace::default"},
+	}
+	c := r.DeepCopy()
+	assert.Equal(t, r, c)
+
+	c.Attributes["data"].(map[string]string)["key"] = "changed"
+	c.Attributes["args"].([]string)[0] = "changed"
+	c.Attributes["ports"].([]map[string]interface{})[0]["port"] = 8080
+	c.Attributes["nested"].(map[string]interface{})["items"].([]interface{})[0].(map[string]interface{})["k"] = "changed"
+	c.DependsOn[0] = "changed"
+
+	assert.Equal(t, "value", r.Attributes["data"].(map[string]string)["key"])
+	assert.Equal(t, "a", r.Attributes["args"].([]string)[0])
+	assert.Equal(t, 80, r.Attributes["ports"].([]map[string]interface{})[0]["port"])
+	assert.Equal(t, "v", r.Attributes["nested"].(map[string]interface{})["items"].([]interface{})[0].(map[string]interface{})["k"])
+	assert.Equal(t, "v1:Namespace::default", r.DependsOn[0])
+	assert.Nil(t, c.Attributes["empty"])
+}
@@ -4,13 +4,17 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
+	"kusionstack.io/kusion/pkg/engine/metrics"
 	"kusionstack.io/kusion/pkg/engine/models"
 	"kusionstack.io/kusion/pkg/engine/operation/graph"
 	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
 	"kusionstack.io/kusion/pkg/engine/operation/parser"
+	"kusionstack.io/kusion/pkg/engine/retry"
 	runtimeinit "kusionstack.io/kusion/pkg/engine/runtime/init"
 	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/engine/tracing"
 	"kusionstack.io/kusion/pkg/log"
 	"kusionstack.io/kusion/pkg/status"
 	"kusionstack.io/kusion/third_party/terraform/dag"
@@ -77,17 +81,31 @@ func (ao *ApplyOperation) Apply(request *ApplyRequest) (rsp *ApplyResponse, st s
 		return nil, st
 	}
 
+	ctx, span := tracing.Start(o.Context, "Apply", tracing.ProjectKey.String(request.Project.Name),
+		tracing.StackKey.String(request.Stack.Name))
+	defer func() {
+		tracing.End(span, st)
+	}()
+
+	retryPolicy, err := retry.NewPolicy(request.Project.Retry)
+	if err != nil {
+		return nil, status.NewErrorStatusWithCode(status.InvalidArgument, err)
+	}
+
 	// 1. init & build Indexes
 	priorState, resultState := o.InitStates(&request.Request)
 	priorStateResourceIndex := priorState.Resources.Index()
+	// keep outputs of the last apply in states saved during the walk, which are evaluated again after it
+	resultState.Outputs = priorState.Outputs
 
 	resources := request.Spec.Resources
 	resources = append(resources, priorState.Resources...)
-	runtimesMap, s := runtimeinit.Runtimes(resources)
+	runtimesMap, s := runtimeinit.Runtimes(resources, o.RuntimeEnv)
 	if status.IsErr(s) {
 		return nil, s
 	}
 	o.RuntimeMap = runtimesMap
+	defer runtimeinit.Close(runtimesMap)
 
 	// 2. build & walk DAG
 	applyGraph, s := NewApplyGraph(request.Spec, priorState)
@@ -96,6 +114,10 @@ func (ao *ApplyOperation) Apply(request *ApplyRequest) (rsp *ApplyResponse, st s
 	}
 	log.Infof("Apply Graph:\n%s", applyGraph.String())
 
+	// reuse live states refreshed by the preview if they are still fresh. They are part of the plan the apply
+	// is bound to, so bindings are checked against the live states the preview saw.
+	liveStates := o.LiveStates.Take()
+
 	applyOperation := &ApplyOperation{
 		Operation: opsmodels.Operation{
 			OperationType:           opsmodels.Apply,
@@ -108,18 +130,36 @@ func (ao *ApplyOperation) Apply(request *ApplyRequest) (rsp *ApplyResponse, st s
 			MsgCh:                   o.MsgCh,
 			ResultState:             resultState,
 			Lock:                    &sync.Mutex{},
+			Context:                 ctx,
+			Parallelism:             o.Parallelism,
 			SecretStores:            o.SecretStores,
+			SkipResources:           o.SkipResources,
+			ReplaceResources:        o.ReplaceResources,
+			Retry:                   retryPolicy,
+			// ignore the same fields as the preview, so that resources are changed as they were previewed
+			IgnoreFields:           o.IgnoreFields,
+			IgnoreFieldsConfigs:    o.IgnoreFieldsConfigs,
+			BoundSteps:             o.BoundSteps,
+			LiveStateResourceIndex: liveStates,
 		},
 	}
 
-	w := &dag.Walker{Callback: applyOperation.applyWalkFun}
-	w.Update(applyGraph)
-	// Wait
-	if diags := w.Wait(); diags.HasErrors() {
-		st = status.NewErrorStatus(diags.Err())
+	start := time.Now()
+	diags := walkGraph(applyGraph, o.Parallelism, applyOperation.applyWalkFun)
+	metrics.ObserveGraphWalk("apply", start, diags.HasErrors())
+	if diags.HasErrors() {
+		st = status.NewErrorStatus(diagsError(diags))
 		return nil, st
 	}
 
+	// 3. evaluate outputs of the stack on applied resources
+	if len(request.Stack.Outputs) > 0 || len(priorState.Outputs) > 0 {
+		resultState.Outputs = EvaluateOutputs(request.Stack.Outputs, applyOperation.StateResourceIndex)
+		if err := applyOperation.UpdateState(applyOperation.StateResourceIndex); err != nil {
+			return nil, status.NewErrorStatus(err)
+		}
+	}
+
 	return &ApplyResponse{State: resultState}, nil
 }
 
@@ -152,20 +192,23 @@ func (ao *ApplyOperation) applyWalkFun(v dag.Vertex) (diags tfdiags.Diagnostics)
 			o.MsgCh <- opsmodels.Message{ResourceID: rn.Hashcode().(string)}
 
 			s = node.Execute(o)
+			timing := o.Timing(rn.Hashcode().(string))
 			if status.IsErr(s) {
 				o.MsgCh <- opsmodels.Message{
 					ResourceID: rn.Hashcode().(string), OpResult: opsmodels.Failed,
-					OpErr: fmt.Errorf("node execte failed, status:\n%v", s),
+					OpErr: fmt.Errorf("node execte failed, status:\n%v", s), Timing: timing,
 				}
+			} else if o.SkipResources[rn.Hashcode().(string)] {
+				o.MsgCh <- opsmodels.Message{ResourceID: rn.Hashcode().(string), OpResult: opsmodels.Skip}
 			} else {
-				o.MsgCh <- opsmodels.Message{ResourceID: rn.Hashcode().(string), OpResult: opsmodels.Success}
+				o.MsgCh <- opsmodels.Message{ResourceID: rn.Hashcode().(string), OpResult: opsmodels.Success, Timing: timing}
 			}
 		} else {
 			s = node.Execute(o)
 		}
 	}
 	if s != nil {
-		diags = diags.Append(fmt.Errorf("node execte failed, status:\n%v", s))
+		diags = diags.Append(status.Wrap(s, fmt.Sprintf("node execte failed, status:\n%v", s)))
 	}
 	return diags
 }
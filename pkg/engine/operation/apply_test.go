@@ -4,10 +4,12 @@
 package operation
 
 import (
+	"fmt"
 	"path/filepath"
 	"reflect"
 	"sync"
 	"testing"
+	"time"
 
 	"bou.ke/monkey"
 	_ "github.com/go-sql-driver/mysql"
@@ -176,7 +178,7 @@ func TestOperation_Apply(t *testing.T) {
 				o.ResultState = rs
 				return nil
 			})
-			monkey.Patch(runtimeinit.Runtimes, func(resources models.Resources) (map[models.Type]runtime.Runtime, status.Status) {
+			monkey.Patch(runtimeinit.Runtimes, func(resources models.Resources, _ runtime.Env) (map[models.Type]runtime.Runtime, status.Status) {
 				return map[models.Type]runtime.Runtime{runtime.Kubernetes: &kubernetes.KubernetesRuntime{}}, nil
 			})
 
@@ -186,3 +188,143 @@ func TestOperation_Apply(t *testing.T) {
 		})
 	}
 }
+
+func TestOperation_ApplyReusesLiveStates(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	stack := &projectstack.Stack{
+		StackConfiguration: projectstack.StackConfiguration{Name: "fakeStack"},
+		Path:               "fakePath",
+	}
+	project := &projectstack.Project{
+		ProjectConfiguration: projectstack.ProjectConfiguration{Name: "fakeProject", Tenant: "fakeTenant"},
+		Path:                 "fakePath",
+		Stacks:               []*projectstack.Stack{stack},
+	}
+	jack := models.Resource{ID: "jack", Type: runtime.Kubernetes, Attributes: map[string]interface{}{"a": "b"}}
+	cache := opsmodels.NewLiveStateCache(time.Minute)
+	cache.Store(map[string]*models.Resource{"jack": &jack})
+
+	var reused []map[string]*models.Resource
+	monkey.Patch((*graph.ResourceNode).Execute, func(rn *graph.ResourceNode, operation *opsmodels.Operation) status.Status {
+		reused = append(reused, operation.LiveStateResourceIndex)
+		return nil
+	})
+	monkey.Patch(runtimeinit.Runtimes, func(resources models.Resources, _ runtime.Env) (map[models.Type]runtime.Runtime, status.Status) {
+		return map[models.Type]runtime.Runtime{runtime.Kubernetes: &kubernetes.KubernetesRuntime{}}, nil
+	})
+
+	request := &ApplyRequest{opsmodels.Request{
+		Tenant:   "fakeTenant",
+		Stack:    stack,
+		Project:  project,
+		Operator: "faker",
+		Spec:     &models.Spec{Resources: []models.Resource{jack}},
+	}}
+	for i := 0; i < 2; i++ {
+		ao := &ApplyOperation{Operation: opsmodels.Operation{
+			OperationType: opsmodels.Apply,
+			StateStorage:  &local.FileSystemState{Path: filepath.Join(t.TempDir(), local.KusionState)},
+			MsgCh:         make(chan opsmodels.Message, 5),
+			LiveStates:    cache,
+		}}
+		_, st := ao.Apply(request)
+		assert.Nil(t, st)
+	}
+
+	assert.Len(t, reused, 2)
+	assert.Equal(t, map[string]*models.Resource{"jack": &jack}, reused[0])
+	assert.Nil(t, reused[1], "live states are stale after the first apply")
+
+	// applies bound to previews reuse live states as part of the plan
+	reused = nil
+	cache.Store(map[string]*models.Resource{"jack": &jack})
+	ao := &ApplyOperation{Operation: opsmodels.Operation{
+		OperationType: opsmodels.Apply,
+		StateStorage:  &local.FileSystemState{Path: filepath.Join(t.TempDir(), local.KusionState)},
+		MsgCh:         make(chan opsmodels.Message, 5),
+		LiveStates:    cache,
+		BoundSteps:    map[string]*opsmodels.ChangeStep{},
+	}}
+	_, st := ao.Apply(request)
+	assert.Nil(t, st)
+	assert.Len(t, reused, 1)
+	assert.Equal(t, map[string]*models.Resource{"jack": &jack}, reused[0])
+}
+
+func TestOperation_ApplyReplacesResources(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	stack := &projectstack.Stack{
+		StackConfiguration: projectstack.StackConfiguration{Name: "fakeStack"},
+		Path:               "fakePath",
+	}
+	project := &projectstack.Project{
+		ProjectConfiguration: projectstack.ProjectConfiguration{Name: "fakeProject", Tenant: "fakeTenant"},
+		Path:                 "fakePath",
+		Stacks:               []*projectstack.Stack{stack},
+	}
+	jack := models.Resource{ID: "jack", Type: runtime.Kubernetes, Attributes: map[string]interface{}{"a": "b"}}
+
+	var replaced map[string]bool
+	monkey.Patch((*graph.ResourceNode).Execute, func(rn *graph.ResourceNode, operation *opsmodels.Operation) status.Status {
+		replaced = operation.ReplaceResources
+		return nil
+	})
+	monkey.Patch(runtimeinit.Runtimes, func(resources models.Resources, _ runtime.Env) (map[models.Type]runtime.Runtime, status.Status) {
+		return map[models.Type]runtime.Runtime{runtime.Kubernetes: &kubernetes.KubernetesRuntime{}}, nil
+	})
+
+	ao := &ApplyOperation{Operation: opsmodels.Operation{
+		OperationType:    opsmodels.Apply,
+		StateStorage:     &local.FileSystemState{Path: filepath.Join(t.TempDir(), local.KusionState)},
+		MsgCh:            make(chan opsmodels.Message, 5),
+		ReplaceResources: map[string]bool{"jack": true},
+	}}
+	_, st := ao.Apply(&ApplyRequest{opsmodels.Request{
+		Tenant:   "fakeTenant",
+		Stack:    stack,
+		Project:  project,
+		Operator: "faker",
+		Spec:     &models.Spec{Resources: []models.Resource{jack}},
+	}})
+	assert.Nil(t, st)
+	assert.Equal(t, map[string]bool{"jack": true}, replaced)
+}
+
+func TestOperation_ApplyKeepsLockConflicts(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	stack := &projectstack.Stack{
+		StackConfiguration: projectstack.StackConfiguration{Name: "fakeStack"},
+		Path:               "fakePath",
+	}
+	project := &projectstack.Project{
+		ProjectConfiguration: projectstack.ProjectConfiguration{Name: "fakeProject", Tenant: "fakeTenant"},
+		Path:                 "fakePath",
+		Stacks:               []*projectstack.Stack{stack},
+	}
+	jack := models.Resource{ID: "jack", Type: runtime.Kubernetes, Attributes: map[string]interface{}{"a": "b"}}
+
+	monkey.Patch((*graph.ResourceNode).Execute, func(rn *graph.ResourceNode, operation *opsmodels.Operation) status.Status {
+		return status.NewErrorStatus(fmt.Errorf("apply State failed. %w", states.ErrStateLocked))
+	})
+	monkey.Patch(runtimeinit.Runtimes, func(resources models.Resources, _ runtime.Env) (map[models.Type]runtime.Runtime, status.Status) {
+		return map[models.Type]runtime.Runtime{runtime.Kubernetes: &kubernetes.KubernetesRuntime{}}, nil
+	})
+
+	ao := &ApplyOperation{Operation: opsmodels.Operation{
+		OperationType: opsmodels.Apply,
+		StateStorage:  &local.FileSystemState{Path: filepath.Join(t.TempDir(), local.KusionState)},
+		MsgCh:         make(chan opsmodels.Message, 5),
+	}}
+	_, st := ao.Apply(&ApplyRequest{opsmodels.Request{
+		Tenant:   "fakeTenant",
+		Stack:    stack,
+		Project:  project,
+		Operator: "faker",
+		Spec:     &models.Spec{Resources: []models.Resource{jack}},
+	}})
+	assert.True(t, status.IsErr(st))
+	assert.ErrorIs(t, status.Wrap(st, "apply failed"), states.ErrStateLocked)
+}
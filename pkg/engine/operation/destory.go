@@ -3,13 +3,19 @@ package operation
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"sync"
+	"time"
 
+	"kusionstack.io/kusion/pkg/engine/metrics"
 	"kusionstack.io/kusion/pkg/engine/models"
 	"kusionstack.io/kusion/pkg/engine/operation/graph"
 	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
 	"kusionstack.io/kusion/pkg/engine/operation/parser"
+	"kusionstack.io/kusion/pkg/engine/retry"
 	runtimeinit "kusionstack.io/kusion/pkg/engine/runtime/init"
+	"kusionstack.io/kusion/pkg/engine/tracing"
 	"kusionstack.io/kusion/pkg/log"
 	"kusionstack.io/kusion/pkg/status"
 	"kusionstack.io/kusion/third_party/terraform/dag"
@@ -22,6 +28,10 @@ type DestroyOperation struct {
 
 type DestroyRequest struct {
 	opsmodels.Request `json:",inline" yaml:",inline"`
+
+	// Targets are IDs of resources to destroy, while the rest of the stack is kept in the state. All resources
+	// in the spec are destroyed if it is empty.
+	Targets []string `json:"targets,omitempty" yaml:"targets,omitempty"`
 }
 
 func NewDestroyGraph(resource models.Resources) (*dag.AcyclicGraph, status.Status) {
@@ -61,17 +71,40 @@ func (do *DestroyOperation) Destroy(request *DestroyRequest) (st status.Status)
 		return st
 	}
 
+	ctx, span := tracing.Start(o.Context, "Destroy", tracing.ProjectKey.String(request.Project.Name),
+		tracing.StackKey.String(request.Stack.Name))
+	defer func() {
+		tracing.End(span, st)
+	}()
+
+	retryPolicy, err := retry.NewPolicy(request.Project.Retry)
+	if err != nil {
+		return status.NewErrorStatusWithCode(status.InvalidArgument, err)
+	}
+
 	// 1. init & build Indexes
-	_, resultState := o.InitStates(&request.Request)
+	priorState, resultState := o.InitStates(&request.Request)
 	// replace priorState.Resources with models.Resources, so we do Delete in all nodes
 	resources := request.Request.Spec.Resources
 	priorStateResourceIndex := resources.Index()
+	stateResourceIndex := priorStateResourceIndex
+	if len(request.Targets) > 0 {
+		var s status.Status
+		if resources, s = DestroyTargets(resources, priorState.Resources, request.Targets); status.IsErr(s) {
+			return s
+		}
+		priorStateResourceIndex = resources.Index()
+		// keep the rest of the stack in the state, together with its outputs
+		stateResourceIndex = priorState.Resources.Index()
+		resultState.Outputs = priorState.Outputs
+	}
 
-	runtimesMap, s := runtimeinit.Runtimes(resources)
+	runtimesMap, s := runtimeinit.Runtimes(resources, o.RuntimeEnv)
 	if status.IsErr(s) {
 		return s
 	}
 	o.RuntimeMap = runtimesMap
+	defer runtimeinit.Close(runtimesMap)
 
 	// 2. build & walk DAG
 	destroyGraph, s := NewDestroyGraph(resources)
@@ -85,25 +118,129 @@ func (do *DestroyOperation) Destroy(request *DestroyRequest) (st status.Status)
 			StateStorage:            o.StateStorage,
 			CtxResourceIndex:        map[string]*models.Resource{},
 			PriorStateResourceIndex: priorStateResourceIndex,
-			StateResourceIndex:      priorStateResourceIndex,
+			StateResourceIndex:      stateResourceIndex,
 			RuntimeMap:              o.RuntimeMap,
 			Stack:                   o.Stack,
 			MsgCh:                   o.MsgCh,
 			ResultState:             resultState,
 			Lock:                    &sync.Mutex{},
+			Context:                 ctx,
+			Parallelism:             o.Parallelism,
+			Retry:                   retryPolicy,
 		},
 	}
 
-	w := &dag.Walker{Callback: newDo.destroyWalkFun}
-	w.Update(destroyGraph)
-	// Wait
-	if diags := w.Wait(); diags.HasErrors() {
-		st = status.NewErrorStatus(diags.Err())
+	start := time.Now()
+	diags := walkGraph(destroyGraph, o.Parallelism, newDo.destroyWalkFun)
+	metrics.ObserveGraphWalk("destroy", start, diags.HasErrors())
+	if diags.HasErrors() {
+		st = status.NewErrorStatus(diagsError(diags))
 		return st
 	}
+
+	// 3. evaluate outputs of the stack on the rest of the stack
+	if len(request.Targets) > 0 && (len(request.Stack.Outputs) > 0 || len(priorState.Outputs) > 0) {
+		resultState.Outputs = EvaluateOutputs(request.Stack.Outputs, stateResourceIndex)
+		if err := newDo.UpdateState(stateResourceIndex); err != nil {
+			return status.NewErrorStatus(err)
+		}
+	}
 	return nil
 }
 
+// DestroyTargets returns copies of the target resources in the spec, whose dependencies out of the targets are
+// removed since they are kept. It fails if a target is not in the spec, or any resource in the spec or the
+// state out of the targets depends on a target, which would be broken after the destroy.
+func DestroyTargets(specResources, stateResources models.Resources, targets []string) (models.Resources, status.Status) {
+	specIndex := specResources.Index()
+	targeted := make(map[string]bool, len(targets))
+	for _, id := range targets {
+		if specIndex[id] == nil {
+			return nil, status.NewErrorStatusWithMsg(status.InvalidArgument,
+				fmt.Sprintf("resource %s to destroy is not found in the stack", id))
+		}
+		targeted[id] = true
+	}
+
+	for _, resources := range []models.Resources{specResources, stateResources} {
+		for i := range resources {
+			r := &resources[i]
+			if targeted[r.ID] {
+				continue
+			}
+			for _, dep := range dependenciesOf(r) {
+				if targeted[dep] {
+					return nil, status.NewErrorStatusWithMsg(status.InvalidArgument,
+						fmt.Sprintf("can't destroy %s since %s depends on it, destroy %s too", dep, r.ID, r.ID))
+				}
+			}
+		}
+	}
+
+	result := make(models.Resources, 0, len(targets))
+	for i := range specResources {
+		r := specResources[i]
+		if !targeted[r.ID] {
+			continue
+		}
+		var dependsOn []string
+		for _, dep := range r.DependsOn {
+			if targeted[dep] {
+				dependsOn = append(dependsOn, dep)
+			}
+		}
+		r.DependsOn = dependsOn
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+// Dependents returns IDs of resources depending on the resources of the IDs directly or transitively, which are
+// sorted and exclude the IDs
+func Dependents(resources models.Resources, ids []string) []string {
+	dependents := map[string][]string{}
+	for i := range resources {
+		for _, dep := range dependenciesOf(&resources[i]) {
+			dependents[dep] = append(dependents[dep], resources[i].ID)
+		}
+	}
+
+	visited := map[string]bool{}
+	for _, id := range ids {
+		visited[id] = true
+	}
+	var result []string
+	queue := append([]string{}, ids...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, d := range dependents[id] {
+			if !visited[d] {
+				visited[d] = true
+				result = append(result, d)
+				queue = append(queue, d)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// dependenciesOf returns IDs of resources the resource depends on explicitly or by implicit references
+func dependenciesOf(r *models.Resource) []string {
+	deps := append([]string{}, r.DependsOn...)
+	if r.Attributes != nil {
+		v := reflect.ValueOf(r.Attributes)
+		refs, _, s := graph.ReplaceImplicitRef(v, nil, func(map[string]*models.Resource, string) (reflect.Value, status.Status) {
+			return v, nil
+		})
+		if !status.IsErr(s) {
+			deps = append(deps, refs...)
+		}
+	}
+	return parser.Deduplicate(deps)
+}
+
 func (do *DestroyOperation) destroyWalkFun(v dag.Vertex) (diags tfdiags.Diagnostics) {
 	ao := &ApplyOperation{
 		Operation: do.Operation,
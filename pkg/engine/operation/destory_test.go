@@ -16,7 +16,9 @@ import (
 	"kusionstack.io/kusion/pkg/engine/operation/graph"
 	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
 	"kusionstack.io/kusion/pkg/engine/runtime"
+	runtimeinit "kusionstack.io/kusion/pkg/engine/runtime/init"
 	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
+	"kusionstack.io/kusion/pkg/engine/states"
 	"kusionstack.io/kusion/pkg/engine/states/local"
 	"kusionstack.io/kusion/pkg/projectstack"
 	"kusionstack.io/kusion/pkg/status"
@@ -59,7 +61,7 @@ func TestOperation_Destroy(t *testing.T) {
 		},
 	}
 	r := &DestroyRequest{
-		opsmodels.Request{
+		Request: opsmodels.Request{
 			Tenant:   tenant,
 			Stack:    stack,
 			Project:  project,
@@ -92,6 +94,92 @@ func TestOperation_Destroy(t *testing.T) {
 	})
 }
 
+func TestOperation_DestroyTargets(t *testing.T) {
+	stack := &projectstack.Stack{
+		StackConfiguration: projectstack.StackConfiguration{
+			Name: "dev",
+			Outputs: []*projectstack.OutputConfig{
+				{Name: "namespace", Resource: "v1:Namespace::ns", Path: ".metadata.name"},
+				{Name: "app", Resource: "v1:ConfigMap:ns:app", Path: ".metadata.name"},
+			},
+		},
+	}
+	project := &projectstack.Project{ProjectConfiguration: projectstack.ProjectConfiguration{Name: "project"}}
+	namespace := models.Resource{
+		ID:         "v1:Namespace::ns",
+		Type:       runtime.Kubernetes,
+		Attributes: map[string]interface{}{"metadata": map[string]interface{}{"name": "ns"}},
+	}
+	app := models.Resource{
+		ID:         "v1:ConfigMap:ns:app",
+		Type:       runtime.Kubernetes,
+		Attributes: map[string]interface{}{"metadata": map[string]interface{}{"name": "app"}},
+		DependsOn:  []string{namespace.ID},
+	}
+	storage := &local.FileSystemState{Path: filepath.Join(t.TempDir(), local.KusionState)}
+	state := states.NewState()
+	state.Project = project.Name
+	state.Stack = stack.Name
+	state.Resources = models.Resources{namespace, app}
+	assert.Nil(t, storage.Apply(state))
+
+	defer monkey.UnpatchAll()
+	monkey.Patch((*graph.ResourceNode).Execute, func(rn *graph.ResourceNode, operation *opsmodels.Operation) status.Status {
+		assert.Equal(t, app.ID, rn.Hashcode())
+		assert.Nil(t, operation.RefreshResourceIndex(app.ID, nil, opsmodels.Delete))
+		return nil
+	})
+	monkey.Patch(runtimeinit.Runtimes, func(resources models.Resources, _ runtime.Env) (map[models.Type]runtime.Runtime, status.Status) {
+		return map[models.Type]runtime.Runtime{runtime.Kubernetes: &kubernetes.KubernetesRuntime{}}, nil
+	})
+	o := &DestroyOperation{
+		opsmodels.Operation{
+			OperationType: opsmodels.Destroy,
+			StateStorage:  storage,
+			MsgCh:         make(chan opsmodels.Message, 1),
+		},
+	}
+	go readMsgCh(o.MsgCh)
+	st := o.Destroy(&DestroyRequest{
+		Request: opsmodels.Request{
+			Project: project,
+			Stack:   stack,
+			Spec:    &models.Spec{Resources: models.Resources{namespace, app}},
+		},
+		Targets: []string{app.ID},
+	})
+	assert.Nil(t, st)
+
+	latest, err := storage.GetLatestState(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, models.Resources{namespace}, latest.Resources)
+	assert.Equal(t, map[string]interface{}{"namespace": "ns"}, latest.Outputs)
+}
+
+func TestDestroyTargets(t *testing.T) {
+	namespace := models.Resource{ID: "v1:Namespace::ns"}
+	app := models.Resource{ID: "v1:ConfigMap:ns:app", DependsOn: []string{namespace.ID}}
+	ref := models.Resource{
+		ID:         "v1:ConfigMap:ns:ref",
+		Attributes: map[string]interface{}{"data": map[string]interface{}{"app": "$kusion_path.v1:ConfigMap:ns:app.data"}},
+	}
+	spec := models.Resources{namespace, app}
+
+	got, s := DestroyTargets(spec, nil, []string{app.ID})
+	assert.Nil(t, s)
+	assert.Equal(t, models.Resources{{ID: app.ID}}, got)
+	assert.Equal(t, []string{namespace.ID}, app.DependsOn)
+
+	_, s = DestroyTargets(spec, nil, []string{namespace.ID})
+	assert.True(t, status.IsErr(s))
+	_, s = DestroyTargets(spec, models.Resources{ref}, []string{app.ID})
+	assert.True(t, status.IsErr(s))
+	_, s = DestroyTargets(spec, nil, []string{"v1:ConfigMap:ns:missing"})
+	assert.True(t, status.IsErr(s))
+
+	assert.Equal(t, []string{app.ID, ref.ID}, Dependents(models.Resources{namespace, app, ref}, []string{namespace.ID}))
+}
+
 func readMsgCh(ch chan opsmodels.Message) {
 	for {
 		select {
@@ -37,18 +37,17 @@ func (d *Diff) Diff(request *DiffRequest) (string, error) {
 	plan := request.Spec
 
 	// Get the latest state resources
-	latestState, err := d.StateStorage.GetLatestState(
-		&states.StateQuery{
-			Tenant:  request.Tenant,
-			Stack:   request.Stack.Name,
-			Project: request.Project.Name,
-		},
-	)
+	query := &states.StateQuery{
+		Tenant:  request.Tenant,
+		Stack:   request.Stack.Name,
+		Project: request.Project.Name,
+	}
+	latestState, err := d.StateStorage.GetLatestState(query)
 	if err != nil {
 		return "", errors.Wrap(err, "GetLatestState failed")
 	}
 	if latestState == nil {
-		log.Infof("can't find states by request: %v.", jsonutil.MustMarshal2String(request))
+		log.Infof("can't find states by query: %v.", jsonutil.MustMarshal2String(query))
 	}
 	// Get diff result
 	return DiffWithRequestResourceAndState(plan, latestState)
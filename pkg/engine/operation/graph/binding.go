@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/status"
+)
+
+// checkBoundStep returns an error if the change of the resource differs from the step of the preview which
+// the apply is bound to, i.e. the live state of the resource changed after users confirmed the preview.
+// Actions of all resources must equal the previewed ones, and differences of updated resources must be
+// the same too, except the ones referencing other resources whose values are only known during the apply.
+func checkBoundStep(operation *opsmodels.Operation, rn *ResourceNode, live, predictable interface{}) status.Status {
+	bound, ok := operation.BoundSteps[rn.ID]
+	if !ok {
+		return status.NewErrorStatus(fmt.Errorf("%s is not in the preview, please preview again", rn.ID))
+	}
+	if bound.Action != rn.Action {
+		return status.NewErrorStatus(fmt.Errorf("%s was previewed as %s but is %s now since its live state "+
+			"changed after the preview, please preview again", rn.ID, bound.Action, rn.Action))
+	}
+	if rn.Action != opsmodels.Update || containsImplicitRef(bound.To) {
+		return nil
+	}
+
+	expected, err := bound.Digest()
+	if err != nil {
+		return status.NewErrorStatus(err)
+	}
+	actual, err := opsmodels.NewChangeStep(rn.ID, rn.Action, live, predictable).Digest()
+	if err != nil {
+		return status.NewErrorStatus(err)
+	}
+	if actual != expected {
+		return status.NewErrorStatus(fmt.Errorf("changes of %s differ from the preview since its live state "+
+			"changed after the preview, please preview again", rn.ID))
+	}
+	return nil
+}
+
+// containsImplicitRef returns true if any string in the value is an implicit reference to another resource
+func containsImplicitRef(v interface{}) bool {
+	switch value := v.(type) {
+	case *models.Resource:
+		return value != nil && containsImplicitRef(value.Attributes)
+	case string:
+		return strings.HasPrefix(value, ImplicitRefPrefix)
+	case map[string]interface{}:
+		for _, item := range value {
+			if containsImplicitRef(item) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			if containsImplicitRef(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
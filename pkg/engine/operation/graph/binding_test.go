@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+)
+
+func TestCheckBoundStep(t *testing.T) {
+	deployment := func(replicas interface{}) *models.Resource {
+		return &models.Resource{
+			ID:         "apps/v1:Deployment:default:foo",
+			Type:       "Kubernetes",
+			Attributes: map[string]interface{}{"spec": map[string]interface{}{"replicas": replicas}},
+		}
+	}
+	id := "apps/v1:Deployment:default:foo"
+	newOperation := func(step *opsmodels.ChangeStep) *opsmodels.Operation {
+		return &opsmodels.Operation{BoundSteps: map[string]*opsmodels.ChangeStep{id: step}}
+	}
+	rn := &ResourceNode{baseNode: &baseNode{ID: id}, Action: opsmodels.Update}
+
+	t.Run("same changes", func(t *testing.T) {
+		operation := newOperation(opsmodels.NewChangeStep(id, opsmodels.Update, deployment(1), deployment(2)))
+		assert.Nil(t, checkBoundStep(operation, rn, deployment(1), deployment(2)))
+	})
+
+	t.Run("not in the preview", func(t *testing.T) {
+		operation := &opsmodels.Operation{BoundSteps: map[string]*opsmodels.ChangeStep{}}
+		s := checkBoundStep(operation, rn, deployment(1), deployment(2))
+		assert.Equal(t, id+" is not in the preview, please preview again", s.Message())
+	})
+
+	t.Run("action changed", func(t *testing.T) {
+		operation := newOperation(opsmodels.NewChangeStep(id, opsmodels.Update, deployment(1), deployment(2)))
+		s := checkBoundStep(operation, &ResourceNode{baseNode: &baseNode{ID: id}, Action: opsmodels.UnChange},
+			deployment(2), deployment(2))
+		assert.Contains(t, s.Message(), "was previewed as Update but is UnChange now")
+	})
+
+	t.Run("live state changed", func(t *testing.T) {
+		operation := newOperation(opsmodels.NewChangeStep(id, opsmodels.Update, deployment(1), deployment(2)))
+		s := checkBoundStep(operation, rn, deployment(3), deployment(2))
+		assert.Equal(t, "changes of "+id+" differ from the preview since its live state changed after the preview, "+
+			"please preview again", s.Message())
+	})
+
+	t.Run("implicit references", func(t *testing.T) {
+		ref := ImplicitRefPrefix + "v1:Service:default:foo.spec.clusterIP"
+		operation := newOperation(opsmodels.NewChangeStep(id, opsmodels.Update, deployment(1), deployment(ref)))
+		assert.Nil(t, checkBoundStep(operation, rn, deployment(1), deployment("10.0.0.1")))
+	})
+}
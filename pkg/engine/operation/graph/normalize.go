@@ -0,0 +1,95 @@
+package graph
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// quantityParents and quantityKeys are keys of maps of quantities and keys of quantities in Kubernetes resources,
+// e.g. resources.limits and storage of PersistentVolumeClaims. Other strings are never compared as quantities
+// since some of them look like quantities but are not, e.g. "1.10" and "1.1" of versions.
+var (
+	quantityParents = map[string]bool{
+		"limits": true, "requests": true, "capacity": true, "allocatable": true, "hard": true, "used": true,
+		"overhead": true, "default": true, "defaultRequest": true, "max": true, "min": true,
+	}
+	quantityKeys = map[string]bool{
+		"cpu": true, "memory": true, "storage": true, "ephemeral-storage": true, "sizeLimit": true,
+	}
+)
+
+// normalizeEquivalentValues replaces values of the live object which are equivalent to the ones of the
+// predictable object but written differently, so that they are not shown as changes, e.g. quantities 1000m and
+// 1, 1Gi and 1024Mi, and durations 1h and 60m. Only values at the same paths of both objects are compared.
+func normalizeEquivalentValues(live, predictable interface{}) {
+	normalize(live, predictable, "")
+}
+
+func normalize(live, predictable interface{}, parent string) {
+	switch l := live.(type) {
+	case map[string]interface{}:
+		p, ok := predictable.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for k, lv := range l {
+			pv, ok := p[k]
+			if !ok {
+				continue
+			}
+			if equivalent(lv, pv, quantityParents[parent] || quantityKeys[k]) {
+				l[k] = pv
+				continue
+			}
+			normalize(lv, pv, k)
+		}
+	case []interface{}:
+		p, ok := predictable.([]interface{})
+		if !ok || len(p) != len(l) {
+			return
+		}
+		for i := range l {
+			normalize(l[i], p[i], parent)
+		}
+	}
+}
+
+// equivalent returns true if the values are scalars which are different but equivalent quantities if they are
+// quantities, or durations
+func equivalent(a, b interface{}, quantity bool) bool {
+	as, aok := scalarString(a)
+	bs, bok := scalarString(b)
+	if !aok || !bok || as == bs {
+		return false
+	}
+	if quantity {
+		aq, aerr := resource.ParseQuantity(as)
+		bq, berr := resource.ParseQuantity(bs)
+		if aerr == nil && berr == nil {
+			return aq.Cmp(bq) == 0
+		}
+	}
+	// plain numbers are not durations, e.g. replicas
+	if _, ok := a.(string); !ok {
+		return false
+	}
+	if _, ok := b.(string); !ok {
+		return false
+	}
+	ad, aerr := time.ParseDuration(as)
+	bd, berr := time.ParseDuration(bs)
+	return aerr == nil && berr == nil && as != "0" && bs != "0" && ad == bd
+}
+
+func scalarString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case int, int32, int64, float32, float64:
+		return fmt.Sprint(v), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,57 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_normalizeEquivalentValues(t *testing.T) {
+	live := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": 1,
+			"containers": []interface{}{
+				map[string]interface{}{
+					"image": "nginx:1.10",
+					"resources": map[string]interface{}{
+						"limits":   map[string]interface{}{"cpu": "1", "memory": "1Gi"},
+						"requests": map[string]interface{}{"cpu": "100m", "memory": "512Mi"},
+					},
+				},
+			},
+			"storage":  "1Gi",
+			"interval": "1h0m0s",
+			"version":  "1.10",
+		},
+	}
+	predictable := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": "1",
+			"containers": []interface{}{
+				map[string]interface{}{
+					"image": "nginx:1.1",
+					"resources": map[string]interface{}{
+						"limits":   map[string]interface{}{"cpu": "1000m", "memory": "1024Mi"},
+						"requests": map[string]interface{}{"cpu": 0.1, "memory": "1Gi"},
+					},
+				},
+			},
+			"storage":  "1024Mi",
+			"interval": "60m",
+			"version":  "1.1",
+		},
+	}
+	normalizeEquivalentValues(live, predictable)
+
+	spec := live["spec"].(map[string]interface{})
+	resources := spec["containers"].([]interface{})[0].(map[string]interface{})["resources"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"cpu": "1000m", "memory": "1024Mi"}, resources["limits"])
+	// only equivalent values are replaced
+	assert.Equal(t, map[string]interface{}{"cpu": 0.1, "memory": "512Mi"}, resources["requests"])
+	assert.Equal(t, "1024Mi", spec["storage"])
+	assert.Equal(t, "60m", spec["interval"])
+	// numbers and strings which look like quantities elsewhere are not normalized
+	assert.Equal(t, 1, spec["replicas"])
+	assert.Equal(t, "1.10", spec["version"])
+	assert.Equal(t, "nginx:1.10", spec["containers"].([]interface{})[0].(map[string]interface{})["image"])
+}
@@ -0,0 +1,83 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/status"
+)
+
+var (
+	// replaceTimeout is the max duration to wait for the deletion of a replaced resource
+	replaceTimeout = 5 * time.Minute
+	// replacePollInterval is the interval to check whether a replaced resource is deleted
+	replacePollInterval = time.Second
+)
+
+// replaceResource deletes the resource, waits until it is gone, and creates it again with the planned state.
+// Resources with changed immutable fields are applied in this way.
+func (rn *ResourceNode) replaceResource(
+	ctx context.Context,
+	operation *opsmodels.Operation,
+	priorState, planedState, live *models.Resource,
+) (*models.Resource, status.Status) {
+	log.Infof("replace resource: %s", planedState.ResourceKey())
+	rt := operation.RuntimeMap[rn.state.Type]
+
+	deleted := priorState
+	if deleted == nil {
+		deleted = live
+	}
+	if deleted != nil {
+		s := rn.retryRequest(ctx, operation, true, func() status.Status {
+			deleteCtx, done := rn.runtimeRequest(ctx, rn.state.Type, "delete")
+			response := rt.Delete(deleteCtx, &runtime.DeleteRequest{Resource: deleted, Stack: operation.Stack})
+			done(response.Status)
+			return response.Status
+		})
+		if status.IsErr(s) {
+			return nil, s
+		}
+		if s := waitDeleted(ctx, rt, planedState, operation); status.IsErr(s) {
+			return nil, s
+		}
+	}
+
+	// the resource is created, so there is no prior state to merge with
+	var created *models.Resource
+	s := rn.retryRequest(ctx, operation, true, func() status.Status {
+		applyCtx, done := rn.runtimeRequest(ctx, rn.state.Type, "apply")
+		response := rt.Apply(applyCtx, &runtime.ApplyRequest{PlanResource: planedState, Stack: operation.Stack})
+		created = response.Resource
+		done(response.Status)
+		return response.Status
+	})
+	return created, s
+}
+
+// waitDeleted waits until the resource can not be read from the runtime
+func waitDeleted(ctx context.Context, rt runtime.Runtime, resource *models.Resource, operation *opsmodels.Operation) status.Status {
+	deadline := time.Now().Add(replaceTimeout)
+	for {
+		response := rt.Read(ctx, &runtime.ReadRequest{PlanResource: resource, Stack: operation.Stack})
+		if status.IsErr(response.Status) {
+			return response.Status
+		}
+		if response.Resource == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return status.NewErrorStatus(fmt.Errorf("timeout waiting for %s to be deleted", resource.ResourceKey()))
+		}
+		select {
+		case <-ctx.Done():
+			return status.NewErrorStatus(fmt.Errorf("canceled waiting for %s to be deleted: %w", resource.ResourceKey(), ctx.Err()))
+		case <-time.After(replacePollInterval):
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package graph
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/retry"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/engine/states/local"
+	"kusionstack.io/kusion/pkg/status"
+)
+
+// fakeRuntime records calls, and the resource disappears after it is read twice since deleted
+type fakeRuntime struct {
+	calls   []string
+	deleted bool
+	reads   int
+}
+
+func (f *fakeRuntime) Apply(_ context.Context, request *runtime.ApplyRequest) *runtime.ApplyResponse {
+	if request.PriorResource == nil {
+		f.calls = append(f.calls, "create")
+	} else {
+		f.calls = append(f.calls, "update")
+	}
+	return &runtime.ApplyResponse{Resource: request.PlanResource}
+}
+
+func (f *fakeRuntime) Read(_ context.Context, request *runtime.ReadRequest) *runtime.ReadResponse {
+	if f.deleted {
+		f.reads++
+		if f.reads >= 2 {
+			return &runtime.ReadResponse{}
+		}
+	}
+	return &runtime.ReadResponse{Resource: request.PlanResource}
+}
+
+func (f *fakeRuntime) Import(_ context.Context, request *runtime.ImportRequest) *runtime.ImportResponse {
+	return &runtime.ImportResponse{Resource: request.PlanResource}
+}
+
+func (f *fakeRuntime) Delete(context.Context, *runtime.DeleteRequest) *runtime.DeleteResponse {
+	f.calls = append(f.calls, "delete")
+	f.deleted = true
+	return &runtime.DeleteResponse{}
+}
+
+func (f *fakeRuntime) Watch(context.Context, *runtime.WatchRequest) *runtime.WatchResponse {
+	return &runtime.WatchResponse{}
+}
+
+func TestResourceNode_applyResourceReplace(t *testing.T) {
+	replacePollIntervalBackup := replacePollInterval
+	replacePollInterval = 0
+	defer func() { replacePollInterval = replacePollIntervalBackup }()
+
+	prior := &models.Resource{ID: "svc", Type: runtime.Kubernetes, Attributes: map[string]interface{}{"a": "b"}}
+	plan := &models.Resource{ID: "svc", Type: runtime.Kubernetes, Attributes: map[string]interface{}{"a": "c"}}
+	newOperation := func(rt runtime.Runtime, replace map[string]bool) *opsmodels.Operation {
+		return &opsmodels.Operation{
+			OperationType:      opsmodels.Apply,
+			StateStorage:       &local.FileSystemState{Path: filepath.Join(t.TempDir(), local.KusionState)},
+			CtxResourceIndex:   map[string]*models.Resource{},
+			StateResourceIndex: map[string]*models.Resource{},
+			ResultState:        states.NewState(),
+			Lock:               &sync.Mutex{},
+			RuntimeMap:         map[models.Type]runtime.Runtime{runtime.Kubernetes: rt},
+			ReplaceResources:   replace,
+		}
+	}
+
+	t.Run("requires replacement", func(t *testing.T) {
+		rt := &fakeRuntime{}
+		rn := &ResourceNode{baseNode: &baseNode{ID: "svc"}, Action: opsmodels.Update, state: plan, replaceFields: []string{"spec.clusterIP"}}
+		s := rn.applyResource(context.Background(), newOperation(rt, nil), prior, plan, prior)
+		assert.NotNil(t, s)
+		assert.Contains(t, s.Message(), "svc requires replacement since immutable fields spec.clusterIP are changed")
+		assert.Empty(t, rt.calls)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		rt := &fakeRuntime{}
+		rn := &ResourceNode{baseNode: &baseNode{ID: "svc"}, Action: opsmodels.Update, state: plan, replaceFields: []string{"spec.clusterIP"}}
+		operation := newOperation(rt, map[string]bool{"svc": true})
+		assert.Nil(t, rn.applyResource(context.Background(), operation, prior, plan, prior))
+		assert.Equal(t, []string{"delete", "create"}, rt.calls)
+		assert.Equal(t, 2, rt.reads)
+		assert.Equal(t, plan, operation.StateResourceIndex["svc"])
+	})
+
+	t.Run("update", func(t *testing.T) {
+		rt := &fakeRuntime{}
+		rn := &ResourceNode{baseNode: &baseNode{ID: "svc"}, Action: opsmodels.Update, state: plan}
+		assert.Nil(t, rn.applyResource(context.Background(), newOperation(rt, nil), prior, plan, prior))
+		assert.Equal(t, []string{"update"}, rt.calls)
+	})
+}
+
+func Test_waitDeleted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// the resource is never deleted, but the wait returns once it is canceled
+	rt := &fakeRuntime{}
+	resource := &models.Resource{ID: "svc", Type: runtime.Kubernetes}
+	s := waitDeleted(ctx, rt, resource, &opsmodels.Operation{})
+	assert.True(t, status.IsErr(s))
+	assert.Contains(t, s.Message(), "canceled waiting for svc to be deleted")
+}
+
+func TestResourceNode_ExecuteTiming(t *testing.T) {
+	plan := &models.Resource{ID: "svc", Type: runtime.Kubernetes, Attributes: map[string]interface{}{"a": "b"}}
+	rn := &ResourceNode{baseNode: &baseNode{ID: "svc"}, Action: opsmodels.Update, state: plan}
+	operation := &opsmodels.Operation{
+		OperationType:           opsmodels.Apply,
+		StateStorage:            &local.FileSystemState{Path: filepath.Join(t.TempDir(), local.KusionState)},
+		CtxResourceIndex:        map[string]*models.Resource{},
+		PriorStateResourceIndex: map[string]*models.Resource{"svc": plan},
+		StateResourceIndex:      map[string]*models.Resource{},
+		ResultState:             states.NewState(),
+		Lock:                    &sync.Mutex{},
+		RuntimeMap:              map[models.Type]runtime.Runtime{runtime.Kubernetes: &fakeRuntime{}},
+	}
+	assert.Nil(t, rn.Execute(operation))
+
+	timing := operation.Timing("svc")
+	assert.NotNil(t, timing)
+	assert.Equal(t, opsmodels.UnChange.String(), timing.Action)
+	assert.Equal(t, 1, timing.Attempts)
+	assert.False(t, timing.Failed)
+	assert.False(t, timing.EndTime.Before(timing.StartTime))
+	assert.Len(t, operation.ResultState.Timings, 1)
+	assert.Nil(t, operation.Timing("missing"))
+}
+
+func TestResourceNode_ExecuteCanceled(t *testing.T) {
+	plan := &models.Resource{ID: "svc", Type: runtime.Kubernetes, Attributes: map[string]interface{}{"a": "b"}}
+	rn := &ResourceNode{baseNode: &baseNode{ID: "svc"}, Action: opsmodels.Create, state: plan}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rt := &fakeRuntime{}
+	operation := &opsmodels.Operation{
+		OperationType:           opsmodels.Apply,
+		StateStorage:            &local.FileSystemState{Path: filepath.Join(t.TempDir(), local.KusionState)},
+		CtxResourceIndex:        map[string]*models.Resource{},
+		PriorStateResourceIndex: map[string]*models.Resource{},
+		StateResourceIndex:      map[string]*models.Resource{},
+		ResultState:             states.NewState(),
+		Lock:                    &sync.Mutex{},
+		RuntimeMap:              map[models.Type]runtime.Runtime{runtime.Kubernetes: rt},
+		Context:                 ctx,
+	}
+	s := rn.Execute(operation)
+	assert.True(t, status.IsErr(s))
+	assert.Contains(t, s.Message(), "the operation is canceled")
+	assert.Empty(t, rt.calls)
+
+	// the state is never saved once the operation is canceled
+	assert.ErrorContains(t, operation.UpdateState(map[string]*models.Resource{"svc": plan}), "the state is not saved")
+}
+
+// conflictRuntime fails to create resources by conflicts for the number of times
+type conflictRuntime struct {
+	fakeRuntime
+	conflicts int
+}
+
+func (f *conflictRuntime) Apply(ctx context.Context, request *runtime.ApplyRequest) *runtime.ApplyResponse {
+	if f.conflicts > 0 {
+		f.conflicts--
+		f.calls = append(f.calls, "conflict")
+		return &runtime.ApplyResponse{Status: status.NewErrorStatusWithMsg(status.Internal,
+			"Operation cannot be fulfilled on services \"svc\": the object has been modified")}
+	}
+	return f.fakeRuntime.Apply(ctx, request)
+}
+
+func TestResourceNode_applyResourceRetry(t *testing.T) {
+	plan := &models.Resource{ID: "svc", Type: runtime.Kubernetes, Attributes: map[string]interface{}{"a": "b"}}
+	newOperation := func(rt runtime.Runtime, policy *retry.Policy) *opsmodels.Operation {
+		return &opsmodels.Operation{
+			OperationType:      opsmodels.Apply,
+			StateStorage:       &local.FileSystemState{Path: filepath.Join(t.TempDir(), local.KusionState)},
+			CtxResourceIndex:   map[string]*models.Resource{},
+			StateResourceIndex: map[string]*models.Resource{},
+			ResultState:        states.NewState(),
+			Lock:               &sync.Mutex{},
+			RuntimeMap:         map[models.Type]runtime.Runtime{runtime.Kubernetes: rt},
+			Retry:              policy,
+		}
+	}
+	policy := &retry.Policy{Classes: map[string]bool{retry.Conflict: true}, MaxAttempts: 3}
+
+	t.Run("retried", func(t *testing.T) {
+		rt := &conflictRuntime{conflicts: 2}
+		rn := &ResourceNode{baseNode: &baseNode{ID: "svc"}, Action: opsmodels.Create, state: plan, timing: &states.ResourceTiming{ID: "svc"}}
+		operation := newOperation(rt, policy)
+		assert.Nil(t, rn.applyResource(context.Background(), operation, nil, plan, nil))
+		assert.Equal(t, []string{"conflict", "conflict", "create"}, rt.calls)
+		assert.Equal(t, 3, rn.timing.Attempts)
+		assert.Equal(t, plan, operation.StateResourceIndex["svc"])
+	})
+
+	t.Run("attempts run out", func(t *testing.T) {
+		rt := &conflictRuntime{conflicts: 3}
+		rn := &ResourceNode{baseNode: &baseNode{ID: "svc"}, Action: opsmodels.Create, state: plan}
+		s := rn.applyResource(context.Background(), newOperation(rt, policy), nil, plan, nil)
+		assert.NotNil(t, s)
+		assert.Equal(t, []string{"conflict", "conflict", "conflict"}, rt.calls)
+	})
+
+	t.Run("no policy", func(t *testing.T) {
+		rt := &conflictRuntime{conflicts: 1}
+		rn := &ResourceNode{baseNode: &baseNode{ID: "svc"}, Action: opsmodels.Create, state: plan}
+		assert.NotNil(t, rn.applyResource(context.Background(), newOperation(rt, nil), nil, plan, nil))
+		assert.Equal(t, []string{"conflict"}, rt.calls)
+	})
+}
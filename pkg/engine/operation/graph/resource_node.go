@@ -5,10 +5,17 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
+	"k8s.io/client-go/util/jsonpath"
+
+	"kusionstack.io/kusion/pkg/engine/metrics"
 	"kusionstack.io/kusion/pkg/engine/models"
 	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
 	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/sensitive"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/engine/tracing"
 	"kusionstack.io/kusion/pkg/log"
 	"kusionstack.io/kusion/pkg/status"
 	"kusionstack.io/kusion/pkg/util"
@@ -17,10 +24,26 @@ import (
 	"kusionstack.io/kusion/pkg/vals"
 )
 
+// AnnotationIgnoreDiffPaths is the annotation of Kubernetes resources which lists paths of fields whose
+// differences are ignored by previews of the resource, separated by commas. Paths are in the form of JSONPath of
+// kubectl, e.g. $.spec.replicas, spec.template.spec.containers[*].image or
+// metadata.annotations.sidecar\.istio\.io/status, where dots in keys are escaped by backslashes, and lists are
+// matched item by item, so that [*] and indices like [0] both match all items.
+const AnnotationIgnoreDiffPaths = "kusionstack.io/ignore-diff-paths"
+
 type ResourceNode struct {
 	*baseNode
 	Action opsmodels.ActionType
 	state  *models.Resource
+
+	// secretRefs are secret refs in attributes of the state before they are resolved
+	secretRefs []secretRef
+
+	// replaceFields are immutable fields changed by the update of this resource
+	replaceFields []string
+
+	// timing records the timing of this resource when it is applied or destroyed
+	timing *states.ResourceTiming
 }
 
 var _ ExecutableNode = (*ResourceNode)(nil)
@@ -33,6 +56,9 @@ func (rn *ResourceNode) PreExecute(o *opsmodels.Operation) status.Status {
 	value := reflect.ValueOf(rn.state.Attributes)
 	var replaced reflect.Value
 	var s status.Status
+	if rn.secretRefs == nil {
+		rn.secretRefs = collectSecretRefs(nil, rn.state.Attributes)
+	}
 	switch o.OperationType {
 	case opsmodels.ApplyPreview:
 		// replace secret ref
@@ -52,9 +78,35 @@ func (rn *ResourceNode) PreExecute(o *opsmodels.Operation) status.Status {
 	return nil
 }
 
-func (rn *ResourceNode) Execute(operation *opsmodels.Operation) status.Status {
+func (rn *ResourceNode) Execute(operation *opsmodels.Operation) (s status.Status) {
 	log.Debugf("execute node:%s", rn.ID)
 
+	ctx, span := tracing.Start(operation.Context, "ResourceNode", tracing.ResourceIDKey.String(rn.ID),
+		tracing.RuntimeKey.String(string(rn.state.Type)))
+	defer func() {
+		span.SetAttributes(tracing.ActionKey.String(rn.Action.String()))
+		tracing.End(span, s)
+	}()
+
+	// resources skipped by users are left untouched, and their prior states are kept
+	if key := rn.state.ResourceKey(); operation.OperationType == opsmodels.Apply && operation.SkipResources[key] {
+		return rn.skipResource(operation, operation.PriorStateResourceIndex[key])
+	}
+
+	if operation.OperationType == opsmodels.Apply || operation.OperationType == opsmodels.Destroy {
+		rn.timing = &states.ResourceTiming{ID: rn.state.ResourceKey(), StartTime: time.Now()}
+		defer func() {
+			if status.IsErr(s) {
+				rn.finishTiming(operation, true)
+			}
+		}()
+	}
+
+	// nothing is changed once the operation is canceled, e.g. the lock of the state is lost
+	if err := ctx.Err(); err != nil {
+		return status.NewErrorStatus(fmt.Errorf("the operation is canceled: %w", err))
+	}
+
 	if s := rn.PreExecute(operation); status.IsErr(s) {
 		return s
 	}
@@ -67,21 +119,22 @@ func (rn *ResourceNode) Execute(operation *opsmodels.Operation) status.Status {
 	// predictableState represents dry-run result
 	predictableState := planedState
 
-	// 2. get prior state which is stored in kusion_state.json
+	// 2. get prior state which is stored in kusion_state.json, secret refs kept in it are resolved in memory
 	key := rn.state.ResourceKey()
-	priorState := operation.PriorStateResourceIndex[key]
-
-	// 3. get the latest resource from runtime
-	readRequest := &runtime.ReadRequest{PlanResource: planedState, PriorResource: priorState, Stack: operation.Stack}
-
-	resourceType := rn.state.Type
-	response := operation.RuntimeMap[resourceType].Read(context.Background(), readRequest)
-	liveState := response.Resource
-	s := response.Status
+	priorState, s := resolveSecretRefs(operation.PriorStateResourceIndex[key], operation.SecretStores)
 	if status.IsErr(s) {
 		return s
 	}
 
+	// 3. get the latest resource from runtime, unless it has been refreshed before the graph is walked
+	resourceType := rn.state.Type
+	liveState, refreshed := operation.LiveStateResourceIndex[key]
+	if !refreshed {
+		if liveState, s = rn.readLiveState(ctx, operation, planedState, priorState); status.IsErr(s) {
+			return s
+		}
+	}
+
 	// 4. compute ActionType of current resource node between planState and liveState
 	switch operation.OperationType {
 	case opsmodels.Destroy, opsmodels.DestroyPreview:
@@ -93,42 +146,63 @@ func (rn *ResourceNode) Execute(operation *opsmodels.Operation) status.Status {
 			rn.Action = opsmodels.Create
 		} else {
 			// Dry run to fetch predictable state
-			dryRunResp := operation.RuntimeMap[resourceType].Apply(context.Background(), &runtime.ApplyRequest{
-				PriorResource: priorState,
-				PlanResource:  planedState,
-				Stack:         operation.Stack,
-				DryRun:        true,
-			})
-			if status.IsErr(dryRunResp.Status) {
+			var dryRunResp *runtime.ApplyResponse
+			s = rn.retryRequest(ctx, operation, false, func() status.Status {
+				dryRunCtx, done := rn.runtimeRequest(ctx, resourceType, "dry-run")
+				dryRunResp = operation.RuntimeMap[resourceType].Apply(dryRunCtx, &runtime.ApplyRequest{
+					PriorResource: priorState,
+					PlanResource:  planedState,
+					Stack:         operation.Stack,
+					DryRun:        true,
+				})
+				done(dryRunResp.Status)
 				return dryRunResp.Status
+			})
+			if status.IsErr(s) {
+				return s
 			}
 			predictableState = dryRunResp.Resource
-			// Ignore differences of target fields
-			for _, field := range operation.IgnoreFields {
-				splits := strings.Split(field, ".")
-				removeNestedField(liveState.Attributes, splits...)
+			// Ignore differences of target fields, the live state is nil if the resource is recorded in the
+			// state but missing from the runtime
+			for _, splits := range ignoredFields(operation, planedState) {
+				if liveState != nil {
+					removeNestedField(liveState.Attributes, splits...)
+				}
 				removeNestedField(predictableState.Attributes, splits...)
 			}
-			report, err := diff.ToReport(liveState, predictableState)
+			if predictableState.Type == runtime.Kubernetes && liveState != nil {
+				normalizeEquivalentValues(liveState.Attributes, predictableState.Attributes)
+			}
+			changed, err := resourceChanged(liveState, predictableState)
 			if err != nil {
 				return status.NewErrorStatus(err)
 			}
-			if len(report.Diffs) == 0 {
+			if !changed {
 				rn.Action = opsmodels.UnChange
 			} else {
 				rn.Action = opsmodels.Update
+				if detector, ok := operation.RuntimeMap[resourceType].(runtime.ReplacementDetector); ok {
+					rn.replaceFields = detector.RequiresReplacement(liveState, planedState)
+				}
 			}
 		}
 	default:
 		return status.NewErrorStatus(fmt.Errorf("unknown operation: %v", operation.OperationType))
 	}
 
+	// the apply bound to a preview only changes resources in the way users confirmed
+	if operation.OperationType == opsmodels.Apply && operation.BoundSteps != nil {
+		if s = checkBoundStep(operation, rn, liveState, predictableState); status.IsErr(s) {
+			return s
+		}
+	}
+
 	// 5. apply or return
 	switch operation.OperationType {
 	case opsmodels.ApplyPreview, opsmodels.DestroyPreview:
 		fillResponseChangeSteps(operation, rn, liveState, predictableState)
 	case opsmodels.Apply, opsmodels.Destroy:
-		if s = rn.applyResource(operation, priorState, planedState, liveState); status.IsErr(s) {
+		if s = rn.applyResource(ctx, operation, priorState, planedState, liveState); status.IsErr(s) {
 			return s
 		}
 	default:
@@ -138,6 +212,194 @@ func (rn *ResourceNode) Execute(operation *opsmodels.Operation) status.Status {
 	return nil
 }
 
+// Refresh reads the live state of this resource and records it in the operation before the graph is walked,
+// so that live states of all resources can be read concurrently instead of waiting for their dependencies
+func (rn *ResourceNode) Refresh(operation *opsmodels.Operation) (s status.Status) {
+	ctx, span := tracing.Start(operation.Context, "Refresh", tracing.ResourceIDKey.String(rn.ID),
+		tracing.RuntimeKey.String(string(rn.state.Type)))
+	defer func() {
+		tracing.End(span, s)
+	}()
+
+	if s = rn.PreExecute(operation); status.IsErr(s) {
+		return s
+	}
+	planedState := rn.state
+	if rn.Action == opsmodels.Delete {
+		planedState = nil
+	}
+	key := rn.state.ResourceKey()
+	priorState, s := resolveSecretRefs(operation.PriorStateResourceIndex[key], operation.SecretStores)
+	if status.IsErr(s) {
+		return s
+	}
+	liveState, s := rn.readLiveState(ctx, operation, planedState, priorState)
+	if status.IsErr(s) {
+		return s
+	}
+	operation.RecordLiveState(key, liveState)
+	return nil
+}
+
+// readLiveState reads the latest resource from the runtime
+func (rn *ResourceNode) readLiveState(
+	ctx context.Context,
+	operation *opsmodels.Operation,
+	planedState, priorState *models.Resource,
+) (*models.Resource, status.Status) {
+	resourceType := rn.state.Type
+	var response *runtime.ReadResponse
+	s := rn.retryRequest(ctx, operation, false, func() status.Status {
+		readCtx, done := rn.runtimeRequest(ctx, resourceType, "read")
+		response = operation.RuntimeMap[resourceType].Read(readCtx, &runtime.ReadRequest{
+			PlanResource:  planedState,
+			PriorResource: priorState,
+			Stack:         operation.Stack,
+		})
+		done(response.Status)
+		return response.Status
+	})
+	return response.Resource, s
+}
+
+// resourceChanged compares the live and the predictable resource by their content hashes first, and only unequal
+// ones are compared field by field, which takes most of the time of previews of big stacks with few changes
+func resourceChanged(live, predictable *models.Resource) (bool, error) {
+	if diff.Identical(live, predictable) {
+		return false, nil
+	}
+	report, err := diff.ToReport(live, predictable)
+	if err != nil {
+		return false, err
+	}
+	return len(report.Diffs) != 0, nil
+}
+
+// ignoredFields returns paths of fields of the resource ignored by --ignore-fields, ignoreFields of the
+// project and the stack, and the annotation kusionstack.io/ignore-diff-paths of the resource
+func ignoredFields(operation *opsmodels.Operation, resource *models.Resource) [][]string {
+	var result [][]string
+	for _, field := range operation.IgnoreFields {
+		result = append(result, splitFieldPath(field))
+	}
+	if resource == nil {
+		return result
+	}
+	if resource.Type == runtime.Kubernetes {
+		metadata, _ := resource.Attributes["metadata"].(map[string]interface{})
+		annotations, _ := metadata["annotations"].(map[string]interface{})
+		if paths, ok := annotations[AnnotationIgnoreDiffPaths].(string); ok {
+			result = append(result, splitDiffPaths(paths)...)
+		}
+	}
+	if len(operation.IgnoreFieldsConfigs) == 0 {
+		return result
+	}
+	apiVersion, _ := resource.Attributes["apiVersion"].(string)
+	kind, _ := resource.Attributes["kind"].(string)
+	for _, config := range operation.IgnoreFieldsConfigs {
+		if !config.Matches(apiVersion, kind) {
+			continue
+		}
+		for _, field := range config.Fields {
+			result = append(result, splitFieldPath(field))
+		}
+	}
+	return result
+}
+
+// splitFieldPath splits the path of the field by dots, except the ones escaped by backslashes, e.g.
+// metadata.annotations.sidecar\.istio\.io/status
+func splitFieldPath(path string) []string {
+	var fields []string
+	var field strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == '.':
+			field.WriteByte('.')
+			i++
+		case path[i] == '.':
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteByte(path[i])
+		}
+	}
+	return append(fields, field.String())
+}
+
+// splitDiffPaths splits JSONPaths separated by commas into fields, see AnnotationIgnoreDiffPaths. Paths are
+// parsed by the JSONPath parser of kubectl, and invalid paths, or paths with filters, unions and recursive
+// descents, are skipped with warnings.
+func splitDiffPaths(paths string) [][]string {
+	var result [][]string
+	for _, path := range splitTopLevel(paths) {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		fields, err := jsonPathFields(path)
+		if err != nil {
+			log.Warnf("invalid path %q in the annotation %s: %v", path, AnnotationIgnoreDiffPaths, err)
+			continue
+		}
+		result = append(result, fields)
+	}
+	return result
+}
+
+// splitTopLevel splits the paths by commas outside of brackets, which are unions of JSONPath
+func splitTopLevel(paths string) []string {
+	var result []string
+	depth, start := 0, 0
+	for i := 0; i < len(paths); i++ {
+		switch paths[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				result = append(result, paths[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(result, paths[start:])
+}
+
+// jsonPathFields returns fields of the JSONPath, where indices of lists are skipped since all items are matched
+func jsonPathFields(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "$") && !strings.HasPrefix(path, ".") && !strings.HasPrefix(path, "[") {
+		path = "." + path
+	}
+	parser, err := jsonpath.Parse(AnnotationIgnoreDiffPaths, "{"+path+"}")
+	if err != nil {
+		return nil, err
+	}
+	if len(parser.Root.Nodes) != 1 {
+		return nil, fmt.Errorf("exactly one path is expected")
+	}
+	list, ok := parser.Root.Nodes[0].(*jsonpath.ListNode)
+	if !ok {
+		return nil, fmt.Errorf("unsupported path")
+	}
+	var fields []string
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *jsonpath.FieldNode:
+			fields = append(fields, n.Value)
+		case *jsonpath.ArrayNode:
+		default:
+			return nil, fmt.Errorf("unsupported %s", node.Type())
+		}
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no field in the path")
+	}
+	return fields, nil
+}
+
 func removeNestedField(obj interface{}, fields ...string) {
 	m := obj
 	switch next := m.(type) {
@@ -157,24 +419,52 @@ func removeNestedField(obj interface{}, fields ...string) {
 	}
 }
 
-func (rn *ResourceNode) applyResource(operation *opsmodels.Operation, priorState, planedState, live *models.Resource) status.Status {
-	log.Infof("operation:%v, prior:%v, plan:%v, live:%v", rn.Action, jsonutil.Marshal2String(priorState),
-		jsonutil.Marshal2String(planedState), jsonutil.Marshal2String(live))
+func (rn *ResourceNode) applyResource(
+	ctx context.Context,
+	operation *opsmodels.Operation,
+	priorState, planedState, live *models.Resource,
+) status.Status {
+	log.Infof("operation:%v, prior:%v, plan:%v, live:%v", rn.Action, jsonutil.Marshal2String(sensitive.MaskResource(priorState)),
+		jsonutil.Marshal2String(sensitive.MaskResource(planedState)), jsonutil.Marshal2String(sensitive.MaskResource(live)))
 
 	var res *models.Resource
 	var s status.Status
 	resourceType := rn.state.Type
 
 	rt := operation.RuntimeMap[resourceType]
+	key := rn.state.ResourceKey()
+	if rn.timing != nil {
+		rn.timing.Attempts++
+	}
+	if rn.Action == opsmodels.Update && len(rn.replaceFields) > 0 && !operation.ReplaceResources[key] {
+		return status.NewErrorStatus(fmt.Errorf("%s requires replacement since immutable fields %s are changed, "+
+			"apply with --replace %s to delete and create it again", key, strings.Join(rn.replaceFields, ", "), key))
+	}
+
 	switch rn.Action {
-	case opsmodels.Create, opsmodels.Update:
-		response := rt.Apply(context.Background(), &runtime.ApplyRequest{PriorResource: priorState, PlanResource: planedState, Stack: operation.Stack})
-		res = response.Resource
-		s = response.Status
-		log.Debugf("apply resource:%s, response: %v", planedState.ID, jsonutil.Marshal2String(response))
+	case opsmodels.Update:
+		if operation.ReplaceResources[key] {
+			res, s = rn.replaceResource(ctx, operation, priorState, planedState, live)
+			break
+		}
+		fallthrough
+	case opsmodels.Create:
+		s = rn.retryRequest(ctx, operation, true, func() status.Status {
+			applyCtx, done := rn.runtimeRequest(ctx, resourceType, "apply")
+			response := rt.Apply(applyCtx, &runtime.ApplyRequest{PriorResource: priorState, PlanResource: planedState, Stack: operation.Stack})
+			res = response.Resource
+			done(response.Status)
+			return response.Status
+		})
+		log.Debugf("apply resource:%s, response: %v", planedState.ID,
+			jsonutil.Marshal2String(sensitive.MaskResource(res)))
 	case opsmodels.Delete:
-		response := rt.Delete(context.Background(), &runtime.DeleteRequest{Resource: priorState, Stack: operation.Stack})
-		s = response.Status
+		s = rn.retryRequest(ctx, operation, true, func() status.Status {
+			deleteCtx, done := rn.runtimeRequest(ctx, resourceType, "delete")
+			response := rt.Delete(deleteCtx, &runtime.DeleteRequest{Resource: priorState, Stack: operation.Stack})
+			done(response.Status)
+			return response.Status
+		})
 		if s != nil {
 			log.Debugf("delete resource:%s, state: %v", planedState.ID, s.String())
 		}
@@ -182,19 +472,27 @@ func (rn *ResourceNode) applyResource(operation *opsmodels.Operation, priorState
 		log.Infof("planed resource and live state are equal")
 		// auto import resources exist in spec and live cluster but no recorded in kusion_state.json
 		if priorState == nil {
-			response := rt.Import(context.Background(), &runtime.ImportRequest{PlanResource: planedState})
-			s = response.Status
+			s = rn.retryRequest(ctx, operation, true, func() status.Status {
+				importCtx, done := rn.runtimeRequest(ctx, resourceType, "import")
+				response := rt.Import(importCtx, &runtime.ImportRequest{PlanResource: planedState})
+				res = response.Resource
+				done(response.Status)
+				return response.Status
+			})
 			log.Debugf("import resource:%s, state:%v", planedState.ID, jsonutil.Marshal2String(s))
-			res = response.Resource
 		} else {
 			res = priorState
 		}
 	}
+	metrics.ObserveResource(rn.Action.String(), resourceType, status.IsErr(s))
 	if status.IsErr(s) {
 		return s
 	}
 
-	key := rn.state.ResourceKey()
+	// never save values of secret refs in the state
+	res = maskSecretRefs(res, rn.secretRefs)
+	rn.finishTiming(operation, false)
+
 	if e := operation.RefreshResourceIndex(key, res, rn.Action); e != nil {
 		return status.NewErrorStatus(e)
 	}
@@ -207,6 +505,63 @@ func (rn *ResourceNode) applyResource(operation *opsmodels.Operation, priorState
 	return nil
 }
 
+// runtimeRequest starts a span of the request to the runtime. The returned function ends the span, and records
+// the latency of the request.
+func (rn *ResourceNode) runtimeRequest(ctx context.Context, rt models.Type, method string) (context.Context, func(status.Status)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "Runtime "+method, tracing.RuntimeKey.String(string(rt)))
+	return ctx, func(s status.Status) {
+		metrics.ObserveRuntimeRequest(rt, method, start, status.IsErr(s))
+		tracing.End(span, s)
+		if rn.timing != nil {
+			rn.timing.RuntimeLatency += time.Since(start)
+		}
+	}
+}
+
+// retryRequest sends the request to the runtime by the retry policy of the operation. Retries of requests which
+// change the resource are counted as attempts of it.
+func (rn *ResourceNode) retryRequest(
+	ctx context.Context,
+	operation *opsmodels.Operation,
+	countAttempts bool,
+	request func() status.Status,
+) status.Status {
+	var retried func()
+	if countAttempts && rn.timing != nil {
+		retried = func() { rn.timing.Attempts++ }
+	}
+	return operation.Retry.Do(ctx, rn.ID, request, retried)
+}
+
+// finishTiming ends the timing of this resource and records it in the operation
+func (rn *ResourceNode) finishTiming(operation *opsmodels.Operation, failed bool) {
+	if rn.timing == nil {
+		return
+	}
+	rn.timing.Action = rn.Action.String()
+	rn.timing.EndTime = time.Now()
+	rn.timing.Failed = failed
+	operation.RecordTiming(rn.timing)
+}
+
+func (rn *ResourceNode) skipResource(operation *opsmodels.Operation, priorState *models.Resource) status.Status {
+	log.Infof("skip resource: %s", rn.state.ResourceKey())
+
+	// a skipped resource which has never been applied has nothing to keep
+	if priorState == nil {
+		return nil
+	}
+	key := rn.state.ResourceKey()
+	if e := operation.RefreshResourceIndex(key, priorState, opsmodels.UnChange); e != nil {
+		return status.NewErrorStatus(e)
+	}
+	if e := operation.UpdateState(operation.StateResourceIndex); e != nil {
+		return status.NewErrorStatus(e)
+	}
+	return nil
+}
+
 func (rn *ResourceNode) State() *models.Resource {
 	return rn.state
 }
@@ -235,7 +590,9 @@ func fillResponseChangeSteps(ops *opsmodels.Operation, rn *ResourceNode, plan, l
 		order.ChangeSteps = make(map[string]*opsmodels.ChangeStep)
 	}
 	order.StepKeys = append(order.StepKeys, rn.ID)
-	order.ChangeSteps[rn.ID] = opsmodels.NewChangeStep(rn.ID, rn.Action, plan, live)
+	step := opsmodels.NewChangeStep(rn.ID, rn.Action, plan, live)
+	step.ReplaceFields = rn.replaceFields
+	order.ChangeSteps[rn.ID] = step
 }
 
 func ReplaceSecretRef(v reflect.Value, ss *vals.SecretStores) ([]string, reflect.Value, status.Status) {
@@ -313,7 +670,7 @@ func ReplaceRef(v reflect.Value,
 			}
 		}
 
-		if ss != nil && replaceSecretFun != nil {
+		if replaceSecretFun != nil {
 			if prefix, ok := vals.IsSecured(vStr); ok {
 				tStr, err := replaceSecretFun(prefix, vStr, ss)
 				if err != nil {
@@ -15,6 +15,7 @@ import (
 	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
 	"kusionstack.io/kusion/pkg/engine/states"
 	"kusionstack.io/kusion/pkg/engine/states/local"
+	"kusionstack.io/kusion/pkg/projectstack"
 	"kusionstack.io/kusion/pkg/status"
 	"kusionstack.io/kusion/third_party/terraform/dag"
 )
@@ -154,6 +155,27 @@ func TestResourceNode_Execute(t *testing.T) {
 			}},
 			want: status.NewErrorStatusWithMsg(status.IllegalManifest, "can't find specified value in resource:jack by ref:jack.notExist"),
 		},
+		{
+			name: "skip",
+			fields: fields{
+				BaseNode: baseNode{ID: Jack},
+				Action:   opsmodels.Update,
+				state:    illegalResourceState,
+			},
+			args: args{operation: opsmodels.Operation{
+				OperationType:           opsmodels.Apply,
+				StateStorage:            local.NewFileSystemState(),
+				CtxResourceIndex:        map[string]*models.Resource{},
+				PriorStateResourceIndex: priorStateResourceIndex,
+				StateResourceIndex:      map[string]*models.Resource{},
+				MsgCh:                   make(chan opsmodels.Message),
+				ResultState:             states.NewState(),
+				Lock:                    &sync.Mutex{},
+				RuntimeMap:              map[models.Type]runtime.Runtime{runtime.Kubernetes: &kubernetes.KubernetesRuntime{}},
+				SkipResources:           map[string]bool{Eric: true},
+			}},
+			want: nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -189,6 +211,40 @@ func TestResourceNode_Execute(t *testing.T) {
 	}
 }
 
+func TestResourceNode_Execute_missingLiveState(t *testing.T) {
+	prior := &models.Resource{
+		ID:         "apps/v1:Deployment:default:nginx",
+		Type:       runtime.Kubernetes,
+		Attributes: map[string]interface{}{"spec": map[string]interface{}{"replicas": 1}},
+	}
+	operation := &opsmodels.Operation{
+		OperationType:           opsmodels.ApplyPreview,
+		CtxResourceIndex:        map[string]*models.Resource{},
+		PriorStateResourceIndex: map[string]*models.Resource{prior.ResourceKey(): prior},
+		StateResourceIndex:      map[string]*models.Resource{},
+		IgnoreFields:            []string{"spec.replicas"},
+		ChangeOrder:             &opsmodels.ChangeOrder{},
+		Lock:                    &sync.Mutex{},
+		RuntimeMap:              map[models.Type]runtime.Runtime{runtime.Kubernetes: &kubernetes.KubernetesRuntime{}},
+	}
+	rn := &ResourceNode{baseNode: &baseNode{ID: prior.ID}, Action: opsmodels.Update, state: prior.DeepCopy()}
+
+	monkey.PatchInstanceMethod(reflect.TypeOf(operation.RuntimeMap[runtime.Kubernetes]), "Apply",
+		func(k *kubernetes.KubernetesRuntime, ctx context.Context, request *runtime.ApplyRequest) *runtime.ApplyResponse {
+			return &runtime.ApplyResponse{Resource: request.PlanResource.DeepCopy()}
+		})
+	// the object is recorded in the state but deleted from the cluster
+	monkey.PatchInstanceMethod(reflect.TypeOf(operation.RuntimeMap[runtime.Kubernetes]), "Read",
+		func(k *kubernetes.KubernetesRuntime, ctx context.Context, request *runtime.ReadRequest) *runtime.ReadResponse {
+			return &runtime.ReadResponse{}
+		})
+	defer monkey.UnpatchAll()
+
+	assert.Nil(t, rn.Execute(operation))
+	assert.Equal(t, opsmodels.Update, rn.Action)
+	assert.Contains(t, operation.ChangeOrder.ChangeSteps, prior.ID)
+}
+
 func Test_removeNestedField(t *testing.T) {
 	t.Run("remove nested field", func(t *testing.T) {
 		e1 := []interface{}{
@@ -260,3 +316,75 @@ func Test_removeNestedField(t *testing.T) {
 		assert.Len(t, ports[0], 2)
 	})
 }
+
+func Test_ignoredFields(t *testing.T) {
+	deployment := &models.Resource{
+		ID:         "apps/v1:Deployment:default:web",
+		Type:       runtime.Kubernetes,
+		Attributes: map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment"},
+	}
+	service := &models.Resource{
+		ID:         "v1:Service:default:web",
+		Type:       runtime.Kubernetes,
+		Attributes: map[string]interface{}{"apiVersion": "v1", "kind": "Service"},
+	}
+	operation := &opsmodels.Operation{
+		IgnoreFields: []string{"status"},
+		IgnoreFieldsConfigs: []*projectstack.IgnoreFieldsConfig{
+			{Fields: []string{"metadata.generation"}},
+			{APIVersion: "apps/v1", Kind: "Deployment", Fields: []string{`metadata.annotations.sidecar\.istio\.io/status`}},
+		},
+	}
+
+	assert.Equal(t, [][]string{
+		{"status"}, {"metadata", "generation"}, {"metadata", "annotations", "sidecar.istio.io/status"},
+	}, ignoredFields(operation, deployment))
+	assert.Equal(t, [][]string{{"status"}, {"metadata", "generation"}}, ignoredFields(operation, service))
+
+	annotated := &models.Resource{
+		ID:   "apps/v1:Deployment:default:worker",
+		Type: runtime.Kubernetes,
+		Attributes: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{AnnotationIgnoreDiffPaths: "$.spec.replicas, spec.template.spec.containers[*].image"},
+			},
+		},
+	}
+	assert.Equal(t, [][]string{
+		{"spec", "replicas"}, {"spec", "template", "spec", "containers", "image"},
+	}, ignoredFields(&opsmodels.Operation{}, annotated))
+}
+
+func Test_splitDiffPaths(t *testing.T) {
+	tests := map[string][][]string{
+		"":                                  nil,
+		"spec.replicas":                     {{"spec", "replicas"}},
+		"$.spec.replicas,.status":           {{"spec", "replicas"}, {"status"}},
+		"spec.containers[0].env[*].value":   {{"spec", "containers", "env", "value"}},
+		`metadata.labels['app'], data.key`:  {{"metadata", "labels", "app"}, {"data", "key"}},
+		`metadata.annotations.a\.b/c`:       {{"metadata", "annotations", "a.b/c"}},
+		"spec.containers[?(@.name=='web')]": nil,
+		"spec.containers[0,1].image":        nil,
+		"spec..image, status":               {{"status"}},
+		"spec['unclosed":                    nil,
+		"spec.replicas, , status":           {{"spec", "replicas"}, {"status"}},
+	}
+	for paths, want := range tests {
+		assert.Equal(t, want, splitDiffPaths(paths), paths)
+	}
+}
+
+func Test_resourceChanged(t *testing.T) {
+	live := &models.Resource{ID: "a", Type: runtime.Kubernetes, Attributes: map[string]interface{}{"replicas": 1}}
+	predictable := &models.Resource{ID: "a", Type: runtime.Kubernetes, Attributes: map[string]interface{}{"replicas": 1}}
+	changed, err := resourceChanged(live, predictable)
+	assert.Nil(t, err)
+	assert.False(t, changed)
+
+	predictable.Attributes["replicas"] = 2
+	changed, err = resourceChanged(live, predictable)
+	assert.Nil(t, err)
+	assert.True(t, changed)
+}
@@ -0,0 +1,108 @@
+package graph
+
+import (
+	"reflect"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/status"
+	"kusionstack.io/kusion/pkg/vals"
+)
+
+// secretRef is a secret ref like ref+vault://path#key in attributes, and the path of it.
+// Elements of the path are keys of maps or indices of slices.
+type secretRef struct {
+	path []interface{}
+	ref  string
+}
+
+// collectSecretRefs returns all secret refs in the value
+func collectSecretRefs(path []interface{}, value interface{}) []secretRef {
+	var result []secretRef
+	switch v := value.(type) {
+	case string:
+		if _, ok := vals.IsSecured(v); ok {
+			result = append(result, secretRef{path: append([]interface{}{}, path...), ref: v})
+		}
+	case []interface{}:
+		for i, item := range v {
+			result = append(result, collectSecretRefs(append(path, i), item)...)
+		}
+	case map[string]interface{}:
+		for k, item := range v {
+			result = append(result, collectSecretRefs(append(path, k), item)...)
+		}
+	}
+	if result == nil {
+		return []secretRef{}
+	}
+	return result
+}
+
+// resolveSecretRefs returns a copy of the resource whose secret refs are resolved,
+// or the resource itself if there is no secret ref in it
+func resolveSecretRefs(res *models.Resource, ss *vals.SecretStores) (*models.Resource, status.Status) {
+	if res == nil || len(collectSecretRefs(nil, res.Attributes)) == 0 {
+		return res, nil
+	}
+	resolved := res.DeepCopy()
+	_, replaced, s := ReplaceSecretRef(reflect.ValueOf(resolved.Attributes), ss)
+	if status.IsErr(s) {
+		return nil, s
+	}
+	if !replaced.IsZero() {
+		resolved.Attributes = replaced.Interface().(map[string]interface{})
+	}
+	return resolved, nil
+}
+
+// maskSecretRefs returns a copy of the resource whose values resolved from secret refs are replaced by the refs,
+// so that secrets are never saved in the state in plaintext. The data of a Kubernetes Secret which is resolved
+// from a secret ref in its stringData is replaced too.
+func maskSecretRefs(res *models.Resource, refs []secretRef) *models.Resource {
+	if res == nil || len(refs) == 0 {
+		return res
+	}
+	masked := res.DeepCopy()
+	for _, r := range refs {
+		setExisting(masked.Attributes, r.path, r.ref)
+		if masked.Attributes["kind"] == "Secret" && len(r.path) == 2 && r.path[0] == "stringData" {
+			setExisting(masked.Attributes, []interface{}{"data", r.path[1]}, r.ref)
+		}
+	}
+	return masked
+}
+
+// setExisting sets the value at the path if the path exists
+func setExisting(obj interface{}, path []interface{}, value interface{}) {
+	for i, p := range path {
+		last := i == len(path)-1
+		switch o := obj.(type) {
+		case map[string]interface{}:
+			key, ok := p.(string)
+			if !ok {
+				return
+			}
+			next, found := o[key]
+			if !found {
+				return
+			}
+			if last {
+				o[key] = value
+				return
+			}
+			obj = next
+		case []interface{}:
+			index, ok := p.(int)
+			if !ok || index >= len(o) {
+				return
+			}
+			if last {
+				o[index] = value
+				return
+			}
+			obj = o[index]
+		default:
+			return
+		}
+	}
+}
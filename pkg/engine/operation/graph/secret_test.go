@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/vals"
+)
+
+const passwordRef = "ref+vault://secret/db#/password"
+
+func newSecret(password string) *models.Resource {
+	return &models.Resource{
+		ID:   "v1:Secret:default:db",
+		Type: "Kubernetes",
+		Attributes: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"name": "db", "namespace": "default"},
+			"stringData": map[string]interface{}{"password": password, "user": "admin"},
+			"env":        []interface{}{map[string]interface{}{"value": password}},
+		},
+	}
+}
+
+func Test_resolveSecretRefs(t *testing.T) {
+	defer monkey.UnpatchAll()
+	monkey.Patch(vals.ParseSecretRef, func(prefix, src string, ss *vals.SecretStores) (string, error) {
+		return "pass", nil
+	})
+
+	res, s := resolveSecretRefs(nil, nil)
+	assert.Nil(t, s)
+	assert.Nil(t, res)
+
+	plain := newSecret("pass")
+	res, s = resolveSecretRefs(plain, nil)
+	assert.Nil(t, s)
+	assert.Same(t, plain, res)
+
+	prior := newSecret(passwordRef)
+	res, s = resolveSecretRefs(prior, nil)
+	assert.Nil(t, s)
+	assert.Equal(t, newSecret("pass"), res)
+	assert.Equal(t, newSecret(passwordRef), prior, "the prior state should not be modified")
+}
+
+func Test_maskSecretRefs(t *testing.T) {
+	refs := collectSecretRefs(nil, newSecret(passwordRef).Attributes)
+	assert.Len(t, refs, 2)
+
+	live := newSecret("pass")
+	delete(live.Attributes, "stringData")
+	live.Attributes["data"] = map[string]interface{}{
+		"password": base64.StdEncoding.EncodeToString([]byte("pass")),
+		"user":     base64.StdEncoding.EncodeToString([]byte("admin")),
+	}
+
+	masked := maskSecretRefs(live, refs)
+	assert.Equal(t, map[string]interface{}{
+		"password": passwordRef,
+		"user":     base64.StdEncoding.EncodeToString([]byte("admin")),
+	}, masked.Attributes["data"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"value": passwordRef}}, masked.Attributes["env"])
+	assert.NotContains(t, masked.Attributes, "stringData")
+	assert.Equal(t, "pass", live.Attributes["env"].([]interface{})[0].(map[string]interface{})["value"], "the live state should not be modified")
+
+	assert.Nil(t, maskSecretRefs(nil, refs))
+	assert.Same(t, live, maskSecretRefs(live, nil))
+}
@@ -2,14 +2,22 @@ package models
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
+	"os"
+	"sort"
 	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/pterm/pterm"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/sensitive"
 	"kusionstack.io/kusion/pkg/log"
 	"kusionstack.io/kusion/pkg/projectstack"
 	"kusionstack.io/kusion/pkg/util/diff"
@@ -21,24 +29,50 @@ type ChangeStep struct {
 	Action ActionType  // the operation performed by this step.
 	From   interface{} // old data
 	To     interface{} // new data
+
+	// ReplaceFields are immutable fields changed by this step, the resource must be replaced to apply them
+	ReplaceFields []string
+}
+
+// RequiresReplacement returns true if the resource must be deleted and created again to apply this step
+func (cs *ChangeStep) RequiresReplacement() bool {
+	return len(cs.ReplaceFields) > 0
+}
+
+// ActionString returns the action of this step, with a note if the resource requires replacement
+func (cs *ChangeStep) ActionString() string {
+	if cs.RequiresReplacement() {
+		return cs.Action.String() + " (requires replacement)"
+	}
+	return cs.Action.String()
 }
 
 // Diff compares objects(from and to) which stores in ChangeStep,
 // and return a human-readable string report.
 func (cs *ChangeStep) Diff() (string, error) {
+	return cs.DiffWithLimits(diff.DefaultLimits)
+}
+
+// DiffWithLimits returns the human-readable report with large values truncated and large resources summarized
+// by the limits
+func (cs *ChangeStep) DiffWithLimits(limits diff.Limits) (string, error) {
+	return cs.diff(limits, diff.ModeHuman, false)
+}
+
+// diff returns the report rendered in the mode, where data of Kubernetes Secrets is decoded and sensitive
+// values are masked unless showSecrets is true
+func (cs *ChangeStep) diff(limits diff.Limits, mode string, showSecrets bool) (string, error) {
+	from, to := sensitive.Decode(cs.From), sensitive.Decode(cs.To)
+	if !showSecrets {
+		from, to = sensitive.Mask(from), sensitive.Mask(to)
+	}
 	// Generate diff report
-	diffReport, err := diff.ToReport(cs.From, cs.To)
+	reportString, err := diff.ToLimitedString(from, to, limits, mode)
 	if err != nil {
 		log.Errorf("failed to compute diff with ChangeStep ID: %s", cs.ID)
 		return "", err
 	}
 
-	reportString, err := diff.ToHumanString(diff.NewHumanReport(diffReport))
-	if err != nil {
-		log.Warn("diff to string error: %v", err)
-		return "", err
-	}
-
 	buf := bytes.NewBufferString("")
 
 	if len(cs.ID) != 0 {
@@ -49,6 +83,10 @@ func (cs *ChangeStep) Diff() (string, error) {
 		buf.WriteString(pretty.GreenBold("Plan: "))
 		buf.WriteString(pterm.Sprintf("%s\n", cs.Action.PrettyString()))
 	}
+	if cs.RequiresReplacement() {
+		buf.WriteString(pretty.RedBold("Requires replacement: "))
+		buf.WriteString(pretty.Red("%s\n", strings.Join(cs.ReplaceFields, ", ")))
+	}
 	buf.WriteString(pretty.GreenBold("Diff: "))
 	if len(strings.TrimSpace(reportString)) == 0 && cs.Action == UnChange {
 		buf.WriteString(pretty.Gray("<EMPTY>"))
@@ -59,6 +97,24 @@ func (cs *ChangeStep) Diff() (string, error) {
 	return buf.String(), nil
 }
 
+// Digest returns the hash of the action and the differences between from and to of this step, which stays the
+// same as long as the resource is changed in the same way, even if fields equal in both objects change
+func (cs *ChangeStep) Digest() (string, error) {
+	report, err := diff.ToReport(cs.From, cs.To)
+	if err != nil {
+		return "", err
+	}
+	diffs, err := diff.ToRawString(diff.NewHumanReport(report))
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if err = json.NewEncoder(h).Encode([]interface{}{cs.ID, cs.Action, diffs}); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 func NewChangeStep(id string, op ActionType, from, to interface{}) *ChangeStep {
 	return &ChangeStep{
 		ID:     id,
@@ -75,6 +131,7 @@ var (
 	UpdateChangeStepFilter   = func(c *ChangeStep) bool { return c.Action == Update }
 	DeleteChangeStepFilter   = func(c *ChangeStep) bool { return c.Action == Delete }
 	UnChangeChangeStepFilter = func(c *ChangeStep) bool { return c.Action == UnChange }
+	ReplaceChangeStepFilter  = func(c *ChangeStep) bool { return c.RequiresReplacement() }
 )
 
 type Changes struct {
@@ -86,6 +143,15 @@ type Changes struct {
 type ChangeOrder struct {
 	StepKeys    []string
 	ChangeSteps map[string]*ChangeStep
+
+	// DiffLimits bound diffs of large resources, default limits are used if they are not set
+	DiffLimits diff.Limits
+
+	// DiffMode is the mode of rendering diffs, e.g. side-by-side, which defaults to human
+	DiffMode string
+
+	// ShowSecrets shows decoded sensitive values in diffs instead of masking them
+	ShowSecrets bool
 }
 
 func NewChanges(p *projectstack.Project, s *projectstack.Stack, order *ChangeOrder) *Changes {
@@ -133,19 +199,72 @@ func (p *Changes) Project() *projectstack.Project {
 
 func (o *ChangeOrder) Diffs() string {
 	buf := bytes.NewBufferString("")
+	_ = o.WriteDiffs(buf)
+	return buf.String()
+}
 
+// WriteDiffs writes diffs of steps one by one, so that only the diff of one resource is held in memory
+func (o *ChangeOrder) WriteDiffs(w io.Writer) error {
 	for _, key := range o.StepKeys {
 		step := o.ChangeSteps[key]
 		// Generate diff report
-		diffString, err := step.Diff()
+		diffString, err := step.diff(o.DiffLimits, o.DiffMode, o.ShowSecrets)
 		if err != nil {
 			log.Errorf("failed to generate diff string with ChangeStep ID: %s", step.ID)
 			continue
 		}
 
-		buf.WriteString(diffString)
+		if _, err = io.WriteString(w, diffString); err != nil {
+			return err
+		}
 	}
-	return buf.String()
+	return nil
+}
+
+// serverPopulatedFields are fields of Kubernetes objects populated by the API server, which change between a
+// preview and an apply even if nobody touches the resource
+var serverPopulatedFields = [][]string{
+	{"metadata", "resourceVersion"},
+	{"metadata", "managedFields"},
+	{"metadata", "generation"},
+	{"status"},
+}
+
+// Hash returns a digest of all change steps. Two change orders share the same hash only if they have
+// the same steps with the same actions and data, regardless of the order the steps were computed in.
+// It is used to check that the changes to apply are exactly the ones reviewed in a preview, so fields
+// populated by servers are removed before hashing like ignored fields are removed before diffing.
+func (o *ChangeOrder) Hash() (string, error) {
+	keys := make([]string, 0, len(o.ChangeSteps))
+	for key := range o.ChangeSteps {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	encoder := json.NewEncoder(h)
+	for _, key := range keys {
+		step := o.ChangeSteps[key]
+		from, to := withoutServerPopulatedFields(step.From), withoutServerPopulatedFields(step.To)
+		if err := encoder.Encode([]interface{}{step.ID, step.Action, from, to}); err != nil {
+			return "", fmt.Errorf("failed to hash change step %s: %w", step.ID, err)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// withoutServerPopulatedFields returns a copy of the Kubernetes resource without serverPopulatedFields, other
+// data is returned as it is
+func withoutServerPopulatedFields(data interface{}) interface{} {
+	resource, ok := data.(*models.Resource)
+	if !ok || resource == nil || resource.Type != runtime.Kubernetes {
+		return data
+	}
+	resource = resource.DeepCopy()
+	for _, fields := range serverPopulatedFields {
+		unstructured.RemoveNestedField(resource.Attributes, fields...)
+	}
+	return resource
 }
 
 func (p *Changes) AllUnChange() bool {
@@ -170,7 +289,7 @@ func (p *Changes) Summary(writer io.Writer) {
 			itemPrefix = " * └─"
 		}
 
-		tableData = append(tableData, []string{itemPrefix, step.ID, step.Action.String()})
+		tableData = append(tableData, []string{itemPrefix, step.ID, step.ActionString()})
 	}
 
 	pterm.DefaultTable.WithHasHeader().
@@ -191,7 +310,7 @@ func (o *ChangeOrder) PromptDetails() (string, error) {
 
 	for _, key := range o.StepKeys {
 		cs := o.ChangeSteps[key]
-		humanKeyAndOp := pterm.Sprintf("%s %s", cs.ID, pretty.Gray(cs.Action.String()))
+		humanKeyAndOp := pterm.Sprintf("%s %s", cs.ID, pretty.Gray(cs.ActionString()))
 		options = append(options, humanKeyAndOp)
 		optionMaps[humanKeyAndOp] = cs.ID
 	}
@@ -217,11 +336,14 @@ func (o *ChangeOrder) PromptDetails() (string, error) {
 func (o *ChangeOrder) OutputDiff(target string) {
 	switch target {
 	case "all":
-		fmt.Println(o.Diffs())
+		if err := o.WriteDiffs(os.Stdout); err != nil {
+			log.Errorf("failed to output diffs: %v", err)
+		}
+		fmt.Println()
 	default:
 		rinID := target
 		if cs, ok := o.ChangeSteps[rinID]; ok {
-			diffString, err := cs.Diff()
+			diffString, err := cs.diff(o.DiffLimits, o.DiffMode, o.ShowSecrets)
 			if err != nil {
 				log.Error("failed to output specify diff with rinID: %s, err: %v", rinID, err)
 			}
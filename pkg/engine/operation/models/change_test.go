@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/pterm/pterm"
 	"github.com/stretchr/testify/assert"
 
 	"kusionstack.io/kusion/pkg/engine/models"
@@ -146,6 +147,17 @@ func TestChangeStep_Diff(t *testing.T) {
 	}
 }
 
+func TestChangeStep_ActionString(t *testing.T) {
+	step := &ChangeStep{ID: "id", Action: Update}
+	assert.False(t, step.RequiresReplacement())
+	assert.Equal(t, "Update", step.ActionString())
+
+	step.ReplaceFields = []string{"spec.clusterIP"}
+	assert.True(t, step.RequiresReplacement())
+	assert.Equal(t, "Update (requires replacement)", step.ActionString())
+	assert.True(t, ReplaceChangeStepFilter(step))
+}
+
 func TestChanges_Get(t *testing.T) {
 	type fields struct {
 		order   *ChangeOrder
@@ -494,3 +506,156 @@ func TestChanges_AllUnChange(t *testing.T) {
 		assert.True(t, flag)
 	})
 }
+
+func TestChangeOrder_Hash(t *testing.T) {
+	newOrder := func(keys []string, replicas int) *ChangeOrder {
+		return &ChangeOrder{
+			StepKeys: keys,
+			ChangeSteps: map[string]*ChangeStep{
+				"foo": NewChangeStep("foo", Update, map[string]interface{}{"replicas": 1}, map[string]interface{}{"replicas": replicas}),
+				"bar": NewChangeStep("bar", Create, nil, map[string]interface{}{"name": "bar"}),
+			},
+		}
+	}
+
+	hash, err := newOrder([]string{"foo", "bar"}, 2).Hash()
+	assert.Nil(t, err)
+	assert.Len(t, hash, 64)
+
+	t.Run("independent of step order", func(t *testing.T) {
+		got, err := newOrder([]string{"bar", "foo"}, 2).Hash()
+		assert.Nil(t, err)
+		assert.Equal(t, hash, got)
+	})
+
+	t.Run("changed data", func(t *testing.T) {
+		got, err := newOrder([]string{"foo", "bar"}, 3).Hash()
+		assert.Nil(t, err)
+		assert.NotEqual(t, hash, got)
+	})
+
+	t.Run("changed action", func(t *testing.T) {
+		order := newOrder([]string{"foo", "bar"}, 2)
+		order.ChangeSteps["bar"].Action = UnChange
+		got, err := order.Hash()
+		assert.Nil(t, err)
+		assert.NotEqual(t, hash, got)
+	})
+
+	t.Run("server populated fields", func(t *testing.T) {
+		newDeployment := func(resourceVersion string, generation, replicas int) *models.Resource {
+			return &models.Resource{
+				ID:   "apps/v1:Deployment:default:foo",
+				Type: "Kubernetes",
+				Attributes: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name":            "foo",
+						"resourceVersion": resourceVersion,
+						"generation":      generation,
+						"managedFields":   []interface{}{map[string]interface{}{"manager": resourceVersion}},
+					},
+					"spec":   map[string]interface{}{"replicas": replicas},
+					"status": map[string]interface{}{"observedGeneration": generation},
+				},
+			}
+		}
+		newDeploymentOrder := func(from *models.Resource, replicas int) *ChangeOrder {
+			return &ChangeOrder{
+				StepKeys: []string{"foo"},
+				ChangeSteps: map[string]*ChangeStep{
+					"foo": NewChangeStep("foo", Update, from, newDeployment("", 0, replicas)),
+				},
+			}
+		}
+		live := newDeployment("1", 1, 1)
+		previewed, err := newDeploymentOrder(live, 2).Hash()
+		assert.Nil(t, err)
+
+		got, err := newDeploymentOrder(newDeployment("2", 2, 1), 2).Hash()
+		assert.Nil(t, err)
+		assert.Equal(t, previewed, got)
+		assert.Equal(t, "1", live.Attributes["metadata"].(map[string]interface{})["resourceVersion"])
+
+		got, err = newDeploymentOrder(newDeployment("2", 2, 1), 3).Hash()
+		assert.Nil(t, err)
+		assert.NotEqual(t, previewed, got)
+	})
+}
+
+func TestChangeStep_DiffMasksSensitiveValues(t *testing.T) {
+	secret := func(password string) *models.Resource {
+		return &models.Resource{
+			ID:   "v1:Secret:default:db",
+			Type: "Kubernetes",
+			Attributes: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"data":       map[string]interface{}{"password": password},
+			},
+		}
+	}
+	cs := &ChangeStep{ID: "v1:Secret:default:db", Action: Update, From: secret("cGFzc3dvcmQx"), To: secret("cGFzc3dvcmQy")}
+	got, err := cs.Diff()
+	assert.Nil(t, err)
+	assert.NotContains(t, got, "cGFzc3dvcmQx")
+	assert.NotContains(t, got, "cGFzc3dvcmQy")
+	assert.Contains(t, got, "sensitive value hmac:")
+}
+
+func TestChangeOrder_DiffsDecodeSecrets(t *testing.T) {
+	from := &models.Resource{
+		ID:   "v1:Secret:default:db",
+		Type: "Kubernetes",
+		Attributes: map[string]interface{}{
+			"kind": "Secret",
+			"data": map[string]interface{}{"user": "YWRtaW4=", "password": "cGFzc3dvcmQx"},
+		},
+	}
+	to := &models.Resource{
+		ID:   "v1:Secret:default:db",
+		Type: "Kubernetes",
+		Attributes: map[string]interface{}{
+			"kind":       "Secret",
+			"data":       map[string]interface{}{"password": "cGFzc3dvcmQy"},
+			"stringData": map[string]interface{}{"user": "admin"},
+		},
+	}
+	order := &ChangeOrder{
+		StepKeys:    []string{"v1:Secret:default:db"},
+		ChangeSteps: map[string]*ChangeStep{"v1:Secret:default:db": {ID: "v1:Secret:default:db", Action: Update, From: from, To: to}},
+	}
+
+	// only the changed key is shown, whose values are masked
+	got := pterm.RemoveColorFromString(order.Diffs())
+	assert.Contains(t, got, "data.password")
+	assert.NotContains(t, got, "user")
+	assert.NotContains(t, got, "password1")
+	assert.NotContains(t, got, "password2")
+
+	order.ShowSecrets = true
+	got = pterm.RemoveColorFromString(order.Diffs())
+	assert.Contains(t, got, "password1")
+	assert.Contains(t, got, "password2")
+	assert.NotContains(t, got, "cGFzc3dvcmQ")
+}
+
+func TestChangeStep_Digest(t *testing.T) {
+	digest := func(from, to map[string]interface{}) string {
+		got, err := NewChangeStep("foo", Update, from, to).Digest()
+		assert.Nil(t, err)
+		return got
+	}
+
+	expected := digest(map[string]interface{}{"replicas": 1, "image": "nginx"}, map[string]interface{}{"replicas": 2, "image": "nginx"})
+	assert.Len(t, expected, 64)
+
+	t.Run("equal fields changed", func(t *testing.T) {
+		got := digest(map[string]interface{}{"replicas": 1, "image": "redis"}, map[string]interface{}{"replicas": 2, "image": "redis"})
+		assert.Equal(t, expected, got)
+	})
+
+	t.Run("different changes", func(t *testing.T) {
+		got := digest(map[string]interface{}{"replicas": 3, "image": "nginx"}, map[string]interface{}{"replicas": 2, "image": "nginx"})
+		assert.NotEqual(t, expected, got)
+	})
+}
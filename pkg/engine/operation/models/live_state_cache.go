@@ -0,0 +1,59 @@
+package models
+
+import (
+	"sync"
+	"time"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+)
+
+// LiveStateCache keeps live states refreshed by a preview, so that the apply following the preview in the
+// same invocation reuses them instead of reading every resource from runtimes again. Cached live states
+// expire after the TTL, e.g. when users take long to confirm the preview.
+type LiveStateCache struct {
+	TTL time.Duration
+
+	lock        sync.Mutex
+	refreshedAt time.Time
+	resources   map[string]*models.Resource
+}
+
+// NewLiveStateCache returns a cache of live states which expire after the ttl
+func NewLiveStateCache(ttl time.Duration) *LiveStateCache {
+	return &LiveStateCache{TTL: ttl}
+}
+
+// Store caches copies of live states keyed by resource keys, where nil means the resource does not exist
+func (c *LiveStateCache) Store(resources map[string]*models.Resource) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.resources = make(map[string]*models.Resource, len(resources))
+	for key, res := range resources {
+		if res != nil {
+			res = res.DeepCopy()
+		}
+		c.resources[key] = res
+	}
+	c.refreshedAt = time.Now()
+}
+
+// Take returns cached live states and empties the cache, since live states are stale once resources are
+// applied. It returns nil if nothing is cached or cached live states have expired.
+func (c *LiveStateCache) Take() map[string]*models.Resource {
+	if c == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	resources := c.resources
+	c.resources = nil
+	if resources == nil || time.Since(c.refreshedAt) > c.TTL {
+		return nil
+	}
+	return resources
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+)
+
+func TestLiveStateCache(t *testing.T) {
+	web := &models.Resource{ID: "web", Attributes: map[string]interface{}{"spec": map[string]interface{}{"replicas": 1}}}
+	live := map[string]*models.Resource{"web": web, "missing": nil}
+
+	c := NewLiveStateCache(time.Minute)
+	c.Store(live)
+	// changes of live states after they are stored don't affect the cache
+	delete(web.Attributes, "spec")
+
+	got := c.Take()
+	assert.Equal(t, map[string]*models.Resource{
+		"web":     {ID: "web", Attributes: map[string]interface{}{"spec": map[string]interface{}{"replicas": 1}}},
+		"missing": nil,
+	}, got)
+	assert.Nil(t, c.Take(), "live states are taken only once")
+
+	c.Store(live)
+	c.refreshedAt = time.Now().Add(-2 * time.Minute)
+	assert.Nil(t, c.Take(), "expired live states are not reused")
+
+	var nilCache *LiveStateCache
+	nilCache.Store(live)
+	assert.Nil(t, nilCache.Take())
+}
@@ -1,12 +1,17 @@
 package models
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/jinzhu/copier"
 
+	"kusionstack.io/kusion/pkg/engine/metrics"
 	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/retry"
 	"kusionstack.io/kusion/pkg/engine/runtime"
 	"kusionstack.io/kusion/pkg/engine/states"
 	"kusionstack.io/kusion/pkg/log"
@@ -36,12 +41,20 @@ type Operation struct {
 	// IgnoreFields will be ignored in preview stage
 	IgnoreFields []string
 
+	// IgnoreFieldsConfigs are fields of selected resources ignored in preview stage, which are configured in the
+	// project and the stack
+	IgnoreFieldsConfigs []*projectstack.IgnoreFieldsConfig
+
 	// ChangeOrder is resources' change order during this operation
 	ChangeOrder *ChangeOrder
 
 	// RuntimeMap contains all infrastructure runtimes involved this operation. The key of this map is the Runtime type
 	RuntimeMap map[models.Type]runtime.Runtime
 
+	// RuntimeEnv is the env of runtimes of the stack, e.g. the kubeconfig pinned by the stack, nil means runtimes
+	// use the env of this process
+	RuntimeEnv runtime.Env
+
 	// Stack contains info about where this command is invoked
 	Stack *projectstack.Stack
 
@@ -57,12 +70,53 @@ type Operation struct {
 
 	// SecretStores contains all available secret stores
 	SecretStores *vals.SecretStores
+
+	// SkipResources contains keys of resources that will be left untouched during this operation
+	SkipResources map[string]bool
+
+	// ReplaceResources contains keys of resources that will be deleted and created again instead of updated
+	ReplaceResources map[string]bool
+
+	// Context carries the trace span of this operation, spans of resource nodes and runtime requests are its children.
+	// Callers of applies and destroys may set it to cancel them, e.g. when the lock of the state is lost, after which
+	// no resource is changed and the state is not saved.
+	Context context.Context
+
+	// Timings records timings of resources executed in this operation, keyed by resource keys
+	Timings map[string]*states.ResourceTiming
+
+	// Parallelism is the max number of resources operated concurrently when the graph is walked
+	Parallelism int
+
+	// RefreshParallelism is the max number of live states read concurrently before previews are computed
+	RefreshParallelism int
+
+	// RefreshProgress is called with the number of live states read and the total number while refreshing
+	RefreshProgress func(refreshed, total int)
+
+	// LiveStateResourceIndex contains live states read before the graph is walked, keyed by resource keys.
+	// Resources not in it read their live states from runtimes when they are executed.
+	LiveStateResourceIndex map[string]*models.Resource
+
+	// Retry is the retry policy of requests to runtimes configured by the project, nil means never retrying
+	Retry *retry.Policy
+
+	// LiveStates caches live states refreshed by previews for the apply following them, nil means live
+	// states are not reused. Applies bound to previews check their bindings against the reused live states,
+	// so changes of resources made after the preview are not detected until the cache expires.
+	LiveStates *LiveStateCache
+
+	// BoundSteps are steps of the preview confirmed by users keyed by resource IDs, which binds the apply to
+	// the preview. Resources fail to apply if their changes differ from these steps, nil means no binding.
+	BoundSteps map[string]*ChangeStep
 }
 
 type Message struct {
-	ResourceID string   // ResourceNode.ID()
-	OpResult   OpResult // Success/Failed/Skip
-	OpErr      error    // Operate error detail
+	ResourceID string                 // ResourceNode.ID()
+	OpResult   OpResult               // Success/Failed/Skip/Event
+	OpErr      error                  // Operate error detail
+	Timing     *states.ResourceTiming // Timing of the resource, nil if it is not executed yet
+	Event      *runtime.Event         // Event reported by the runtime while waiting for the resource, if OpResult is Event
 }
 
 type Request struct {
@@ -72,6 +126,12 @@ type Request struct {
 	Cluster  string                `json:"cluster"`
 	Operator string                `json:"operator"`
 	Spec     *models.Spec          `json:"spec"`
+
+	// Audits are checks overridden by the operator, which are recorded in the result state
+	Audits []states.AuditRecord `json:"audits,omitempty"`
+
+	// Revision is the commit of the source applied, which is recorded in the result state
+	Revision string `json:"revision,omitempty"`
 }
 
 type OpResult string
@@ -81,6 +141,8 @@ const (
 	Success OpResult = "Success"
 	Failed  OpResult = "Failed"
 	Skip    OpResult = "Skip"
+	// Event means the message carries an event of the resource, which is not a result of it
+	Event OpResult = "Event"
 )
 
 // RefreshResourceIndex refresh resources in CtxResourceIndex & StateResourceIndex
@@ -101,6 +163,43 @@ func (o *Operation) RefreshResourceIndex(resourceKey string, resource *models.Re
 	return nil
 }
 
+// RecordTiming records a copy of the timing of the resource, which is saved in the result state by UpdateState
+func (o *Operation) RecordTiming(timing *states.ResourceTiming) {
+	o.Lock.Lock()
+	defer o.Lock.Unlock()
+
+	if o.Timings == nil {
+		o.Timings = map[string]*states.ResourceTiming{}
+	}
+	t := *timing
+	o.Timings[timing.ID] = &t
+}
+
+// RecordLiveState records the live state of the resource read before the graph is walked, which is nil if
+// the resource does not exist
+func (o *Operation) RecordLiveState(resourceKey string, resource *models.Resource) {
+	o.Lock.Lock()
+	defer o.Lock.Unlock()
+
+	if o.LiveStateResourceIndex == nil {
+		o.LiveStateResourceIndex = map[string]*models.Resource{}
+	}
+	o.LiveStateResourceIndex[resourceKey] = resource
+}
+
+// Timing returns a copy of the recorded timing of the resource, or nil if it is not recorded
+func (o *Operation) Timing(resourceKey string) *states.ResourceTiming {
+	o.Lock.Lock()
+	defer o.Lock.Unlock()
+
+	timing, ok := o.Timings[resourceKey]
+	if !ok {
+		return nil
+	}
+	t := *timing
+	return &t
+}
+
 func (o *Operation) InitStates(request *Request) (*states.State, *states.State) {
 	query := &states.StateQuery{
 		Tenant:  request.Tenant,
@@ -108,12 +207,14 @@ func (o *Operation) InitStates(request *Request) (*states.State, *states.State)
 		Project: request.Project.Name,
 		Cluster: request.Cluster,
 	}
+	start := time.Now()
 	latestState, err := o.StateStorage.GetLatestState(
 		query,
 	)
+	metrics.ObserveStateRequest("get", start, err != nil)
 	util.CheckNotError(err, fmt.Sprintf("get the latest State failed with query: %v", jsonutil.Marshal2PrettyString(query)))
 	if latestState == nil {
-		log.Infof("can't find states with request: %v", jsonutil.Marshal2PrettyString(request))
+		log.Infof("can't find states with query: %v", jsonutil.Marshal2PrettyString(query))
 		latestState = states.NewState()
 	}
 	resultState := states.NewState()
@@ -132,6 +233,11 @@ func (o *Operation) UpdateState(resourceIndex map[string]*models.Resource) error
 	o.Lock.Lock()
 	defer o.Lock.Unlock()
 
+	// the state may be owned by another operation once this one is canceled
+	if o.Context != nil && o.Context.Err() != nil {
+		return fmt.Errorf("the operation is canceled, the state is not saved: %w", o.Context.Err())
+	}
+
 	state := o.ResultState
 	state.Serial += 1
 	state.Resources = nil
@@ -146,7 +252,16 @@ func (o *Operation) UpdateState(resourceIndex map[string]*models.Resource) error
 	}
 
 	state.Resources = res
+	state.Timings = nil
+	for _, timing := range o.Timings {
+		state.Timings = append(state.Timings, *timing)
+	}
+	sort.Slice(state.Timings, func(i, j int) bool {
+		return state.Timings[i].StartTime.Before(state.Timings[j].StartTime)
+	})
+	start := time.Now()
 	err := o.StateStorage.Apply(state)
+	metrics.ObserveStateRequest("apply", start, err != nil)
 	if err != nil {
 		return fmt.Errorf("apply State failed. %w", err)
 	}
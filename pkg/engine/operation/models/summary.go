@@ -0,0 +1,144 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pterm/pterm"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/util/pretty"
+)
+
+// summaryActions are actions counted by grouped summaries, in the order of their columns
+var summaryActions = []ActionType{Create, Update, Delete, UnChange}
+
+// Group is the namespace and the kind of resources counted together in grouped summaries
+type Group struct {
+	// Namespace is the namespace of Kubernetes resources, or empty for cluster-scoped and Terraform resources
+	Namespace string
+	// Kind is the kind of Kubernetes resources, or the resource type of Terraform resources
+	Kind string
+}
+
+// GroupCounts returns the number of steps of each action in each group of resources
+func (o *ChangeOrder) GroupCounts() map[Group]map[ActionType]int {
+	counts := map[Group]map[ActionType]int{}
+	for _, key := range o.StepKeys {
+		step := o.ChangeSteps[key]
+		if step == nil {
+			continue
+		}
+		group := stepGroup(step)
+		if counts[group] == nil {
+			counts[group] = map[ActionType]int{}
+		}
+		counts[group][step.Action]++
+	}
+	return counts
+}
+
+// GroupedSummary writes the number of planned actions of resources grouped by their namespaces and kinds,
+// which reviewers of large changes triage before reading diffs of resources one by one
+func (p *Changes) GroupedSummary(writer io.Writer) {
+	counts := p.GroupCounts()
+	groups := make([]Group, 0, len(counts))
+	for group := range counts {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Namespace != groups[j].Namespace {
+			return groups[i].Namespace < groups[j].Namespace
+		}
+		return groups[i].Kind < groups[j].Kind
+	})
+
+	header := []string{"Namespace", "Kind"}
+	for _, action := range summaryActions {
+		header = append(header, action.String())
+	}
+	tableData := pterm.TableData{header}
+	totals := map[ActionType]int{}
+	for _, group := range groups {
+		namespace := group.Namespace
+		if namespace == "" {
+			namespace = "-"
+		}
+		row := []string{namespace, group.Kind}
+		for _, action := range summaryActions {
+			row = append(row, countString(action, counts[group][action]))
+			totals[action] += counts[group][action]
+		}
+		tableData = append(tableData, row)
+	}
+	totalRow := []string{"Total", ""}
+	for _, action := range summaryActions {
+		totalRow = append(totalRow, countString(action, totals[action]))
+	}
+	tableData = append(tableData, totalRow)
+
+	fmt.Fprintf(writer, "Stack: %s\n", p.stack.Name)
+	pterm.DefaultTable.WithHasHeader().
+		WithHeaderStyle(&pterm.ThemeDefault.TableHeaderStyle).
+		WithLeftAlignment(true).
+		WithSeparator("  ").
+		WithData(tableData).
+		WithWriter(writer).
+		Render()
+	pterm.Println() // Blank line
+}
+
+// countString returns the count colored like the action, or - if it is zero
+func countString(action ActionType, count int) string {
+	switch {
+	case count == 0:
+		return "-"
+	case action == Create:
+		return pretty.Green("%d", count)
+	case action == Update:
+		return pretty.Blue("%d", count)
+	case action == Delete:
+		return pretty.Red("%d", count)
+	default:
+		return strconv.Itoa(count)
+	}
+}
+
+// stepGroup returns the group of the resource of the step, which is read from the resource if the step has
+// it, or parsed from the ID of the step like apiVersion:kind:namespace:name otherwise
+func stepGroup(step *ChangeStep) Group {
+	for _, data := range []interface{}{step.From, step.To} {
+		res, ok := data.(*models.Resource)
+		if !ok || res == nil {
+			continue
+		}
+		if res.Type != runtime.Kubernetes {
+			if resourceType, ok := res.Extensions["resourceType"].(string); ok {
+				return Group{Kind: resourceType}
+			}
+			return Group{Kind: string(res.Type)}
+		}
+		group := Group{}
+		group.Kind, _ = res.Attributes["kind"].(string)
+		if metadata, ok := res.Attributes["metadata"].(map[string]interface{}); ok {
+			group.Namespace, _ = metadata["namespace"].(string)
+		}
+		if group.Kind != "" {
+			return group
+		}
+	}
+
+	parts := strings.Split(step.ID, ":")
+	switch len(parts) {
+	case 4:
+		return Group{Kind: parts[1], Namespace: parts[2]}
+	case 3:
+		return Group{Kind: parts[1]}
+	default:
+		return Group{Kind: step.ID}
+	}
+}
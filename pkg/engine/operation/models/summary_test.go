@@ -0,0 +1,59 @@
+package models
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pterm/pterm"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func TestChanges_GroupedSummary(t *testing.T) {
+	deployment := func(name string) *models.Resource {
+		return &models.Resource{
+			ID:   "apps/v1:Deployment:web:" + name,
+			Type: "Kubernetes",
+			Attributes: map[string]interface{}{
+				"kind":     "Deployment",
+				"metadata": map[string]interface{}{"namespace": "web", "name": name},
+			},
+		}
+	}
+	instance := &models.Resource{
+		ID:         "hashicorp:aws:aws_instance:vm",
+		Type:       "Terraform",
+		Extensions: map[string]interface{}{"resourceType": "aws_instance"},
+	}
+	order := &ChangeOrder{
+		StepKeys: []string{"a", "b", "c", "d", "e"},
+		ChangeSteps: map[string]*ChangeStep{
+			"a": NewChangeStep("apps/v1:Deployment:web:a", Create, deployment("a"), nil),
+			"b": NewChangeStep("apps/v1:Deployment:web:b", Update, deployment("b"), deployment("b")),
+			"c": NewChangeStep("apps/v1:Deployment:web:c", Create, deployment("c"), nil),
+			"d": NewChangeStep("v1:Namespace:web", Delete, nil, nil),
+			"e": NewChangeStep(instance.ID, UnChange, instance, instance),
+		},
+	}
+
+	assert.Equal(t, map[Group]map[ActionType]int{
+		{Namespace: "web", Kind: "Deployment"}: {Create: 2, Update: 1},
+		{Kind: "Namespace"}:                    {Delete: 1},
+		{Kind: "aws_instance"}:                 {UnChange: 1},
+	}, order.GroupCounts())
+
+	buf := &bytes.Buffer{}
+	changes := NewChanges(&projectstack.Project{}, &projectstack.Stack{
+		StackConfiguration: projectstack.StackConfiguration{Name: "dev"},
+	}, order)
+	changes.GroupedSummary(buf)
+	output := pterm.RemoveColorFromString(buf.String())
+	assert.Contains(t, output, "Stack: dev\n")
+	assert.Regexp(t, `Namespace\s+Kind\s+Create\s+Update\s+Delete\s+UnChange`, output)
+	assert.Regexp(t, `-\s+Namespace\s+-\s+-\s+1\s+-`, output)
+	assert.Regexp(t, `-\s+aws_instance\s+-\s+-\s+-\s+1`, output)
+	assert.Regexp(t, `web\s+Deployment\s+2\s+1\s+-\s+-`, output)
+	assert.Regexp(t, `Total\s+2\s+1\s+1\s+1`, output)
+}
@@ -0,0 +1,72 @@
+package operation
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/util/jsonpath"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// EvaluateOutputs evaluates outputs of the stack on the applied resources keyed by their IDs. Outputs whose
+// resources or values are missing, e.g. load balancer addresses not assigned yet, are logged and skipped.
+func EvaluateOutputs(outputs []*projectstack.OutputConfig, resourceIndex map[string]*models.Resource) map[string]interface{} {
+	if len(outputs) == 0 {
+		return nil
+	}
+	result := map[string]interface{}{}
+	for _, output := range outputs {
+		resource := resourceIndex[output.Resource]
+		if resource == nil {
+			log.Warnf("resource %s of the output %s is not found", output.Resource, output.Name)
+			continue
+		}
+		value, err := EvaluateJSONPath(output.Path, resource.Attributes)
+		if err != nil {
+			log.Warnf("evaluate the output %s failed: %v", output.Name, err)
+			continue
+		}
+		result[output.Name] = value
+	}
+	return result
+}
+
+// EvaluateJSONPath returns the value at the JSONPath in the attributes, or a list of values if the path
+// matches more than one, e.g. {.spec.ports[*].port}. The braces and the leading dot of the path can be omitted.
+func EvaluateJSONPath(path string, attributes map[string]interface{}) (interface{}, error) {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "{") {
+		if !strings.HasPrefix(path, ".") {
+			path = "." + path
+		}
+		path = "{" + path + "}"
+	}
+	jp := jsonpath.New("output")
+	if err := jp.Parse(path); err != nil {
+		return nil, fmt.Errorf("invalid path %s: %w", path, err)
+	}
+	results, err := jp.FindResults(attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []interface{}
+	for _, result := range results {
+		for _, v := range result {
+			if v.IsValid() && v.CanInterface() {
+				values = append(values, v.Interface())
+			}
+		}
+	}
+	switch len(values) {
+	case 0:
+		return nil, fmt.Errorf("no value at the path %s", path)
+	case 1:
+		return values[0], nil
+	default:
+		return values, nil
+	}
+}
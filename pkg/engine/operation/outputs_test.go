@@ -0,0 +1,54 @@
+package operation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func TestEvaluateOutputs(t *testing.T) {
+	service := &models.Resource{
+		ID: "v1:Service:default:nginx",
+		Attributes: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"ports": []interface{}{
+					map[string]interface{}{"port": 80},
+					map[string]interface{}{"port": 443},
+				},
+			},
+			"status": map[string]interface{}{
+				"loadBalancer": map[string]interface{}{
+					"ingress": []interface{}{map[string]interface{}{"ip": "1.2.3.4"}},
+				},
+			},
+		},
+	}
+	index := map[string]*models.Resource{service.ID: service, "v1:Namespace::deleted": nil}
+
+	outputs := []*projectstack.OutputConfig{
+		{Name: "ip", Resource: service.ID, Path: "{.status.loadBalancer.ingress[0].ip}"},
+		{Name: "ports", Resource: service.ID, Path: "spec.ports[*].port"},
+		{Name: "hostname", Resource: service.ID, Path: ".status.loadBalancer.ingress[0].hostname"},
+		{Name: "deleted", Resource: "v1:Namespace::deleted", Path: ".metadata.name"},
+		{Name: "missing", Resource: "v1:Service:default:missing", Path: ".metadata.name"},
+	}
+	assert.Equal(t, map[string]interface{}{
+		"ip":    "1.2.3.4",
+		"ports": []interface{}{80, 443},
+	}, EvaluateOutputs(outputs, index))
+	assert.Nil(t, EvaluateOutputs(nil, index))
+}
+
+func TestEvaluateJSONPath(t *testing.T) {
+	_, err := EvaluateJSONPath("{.spec", map[string]interface{}{})
+	assert.NotNil(t, err)
+
+	got, err := EvaluateJSONPath("metadata", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "nginx"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "nginx"}, got)
+}
@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+const (
+	crdGroup = "apiextensions.k8s.io"
+	crdKind  = "CustomResourceDefinition"
+)
+
+// CRDDependencies returns IDs of CustomResourceDefinitions in the resources by IDs of custom resources whose kinds
+// are defined by them, so that CRDs are applied before their custom resources and deleted after them
+func CRDDependencies(resources map[string]*models.Resource) map[string][]string {
+	crds := map[schema.GroupKind][]string{}
+	for id, res := range resources {
+		if res == nil || res.Type != runtime.Kubernetes {
+			continue
+		}
+		obj := &unstructured.Unstructured{Object: res.Attributes}
+		if gvk := obj.GroupVersionKind(); gvk.Group != crdGroup || gvk.Kind != crdKind {
+			continue
+		}
+		group, _, _ := unstructured.NestedString(obj.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(obj.Object, "spec", "names", "kind")
+		if group == "" || kind == "" {
+			continue
+		}
+		gk := schema.GroupKind{Group: group, Kind: kind}
+		crds[gk] = append(crds[gk], id)
+	}
+	if len(crds) == 0 {
+		return nil
+	}
+
+	result := map[string][]string{}
+	for id, res := range resources {
+		if res == nil || res.Type != runtime.Kubernetes {
+			continue
+		}
+		gk := (&unstructured.Unstructured{Object: res.Attributes}).GroupVersionKind().GroupKind()
+		if ids, ok := crds[gk]; ok {
+			result[id] = ids
+		}
+	}
+	return result
+}
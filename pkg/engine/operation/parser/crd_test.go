@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/operation/graph"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/third_party/terraform/dag"
+)
+
+func newK8sResource(id, apiVersion, kind string, spec map[string]interface{}) models.Resource {
+	attributes := map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": id},
+	}
+	if spec != nil {
+		attributes["spec"] = spec
+	}
+	return models.Resource{ID: id, Type: runtime.Kubernetes, Attributes: attributes}
+}
+
+var crdResources = models.Resources{
+	newK8sResource("foo", "example.com/v1", "Foo", nil),
+	newK8sResource("bar", "example.com/v1alpha1", "Bar", nil),
+	newK8sResource("pod", "v1", "Pod", nil),
+	newK8sResource("foo-crd", "apiextensions.k8s.io/v1", "CustomResourceDefinition", map[string]interface{}{
+		"group": "example.com",
+		"names": map[string]interface{}{"kind": "Foo"},
+	}),
+}
+
+func TestCRDDependencies(t *testing.T) {
+	got := CRDDependencies(crdResources.Index())
+	if len(got) != 1 || len(got["foo"]) != 1 || got["foo"][0] != "foo-crd" {
+		t.Errorf("CRDDependencies() = %v, want map[foo:[foo-crd]]", got)
+	}
+
+	if got = CRDDependencies(crdResources[:3].Index()); got != nil {
+		t.Errorf("CRDDependencies() without CRDs = %v, want nil", got)
+	}
+}
+
+func TestSpecParser_ParseCRDs(t *testing.T) {
+	ag := &dag.AcyclicGraph{}
+	ag.Add(&graph.RootNode{})
+
+	spec := &SpecParser{spec: &models.Spec{Resources: crdResources}}
+	_ = spec.Parse(ag)
+	actual := strings.TrimSpace(ag.String())
+	expected := strings.TrimSpace(testGraphCRDsStr)
+
+	if actual != expected {
+		t.Errorf("wrong result\ngot:\n%s\n\nwant:\n%s", actual, expected)
+	}
+}
+
+const testGraphCRDsStr = `
+bar
+foo
+foo-crd
+  foo
+pod
+root
+  bar
+  foo-crd
+  pod
+`
@@ -41,14 +41,20 @@ func (d *DeleteResourceParser) Parse(g *dag.AcyclicGraph) (s status.Status) {
 	root, err := g.Root()
 	util.CheckNotError(err, "get dag root error")
 
+	// custom resources are deleted before CRDs defining their kinds
+	crdDependencies := CRDDependencies(resourceIndex)
+	dependsOn := func(key string) []string {
+		return Deduplicate(append(append([]string{}, resourceIndex[key].DependsOn...), crdDependencies[key]...))
+	}
+
 	priorDependsOn := make(map[string][]string)
-	for key, v := range resourceIndex {
-		for _, dp := range v.DependsOn {
+	for key := range resourceIndex {
+		for _, dp := range dependsOn(key) {
 			priorDependsOn[dp] = append(priorDependsOn[dp], key)
 		}
 	}
 
-	for key, resource := range resourceIndex {
+	for key := range resourceIndex {
 		rn, s := graph.NewResourceNode(key, resourceIndex[key], opsmodels.Delete)
 		if status.IsErr(s) {
 			return s
@@ -70,7 +76,7 @@ func (d *DeleteResourceParser) Parse(g *dag.AcyclicGraph) (s status.Status) {
 
 		// always get the latest vertex in the g.
 		rn = GetVertex(g, rn).(*graph.ResourceNode)
-		s = LinkRefNodes(g, resource.DependsOn, resourceIndex, rn, opsmodels.Delete, manifestGraphMap)
+		s = LinkRefNodes(g, dependsOn(key), resourceIndex, rn, opsmodels.Delete, manifestGraphMap)
 		if status.IsErr(s) {
 			return s
 		}
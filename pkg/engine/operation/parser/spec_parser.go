@@ -36,6 +36,7 @@ func (m *SpecParser) Parse(g *dag.AcyclicGraph) (s status.Status) {
 	util.CheckNotError(err, "get dag root error")
 	util.CheckNotNil(root, fmt.Sprintf("No root in this DAG:%s", json.Marshal2String(g)))
 	resourceIndex := sp.Resources.Index()
+	crdDependencies := CRDDependencies(resourceIndex)
 	for key, resourceState := range resourceIndex {
 		rn, s := graph.NewResourceNode(key, resourceIndex[key], opsmodels.Update)
 		if status.IsErr(s) {
@@ -52,7 +53,7 @@ func (m *SpecParser) Parse(g *dag.AcyclicGraph) (s status.Status) {
 			g.Connect(dag.BasicEdge(root, rn))
 		}
 		// handle explicate dependency
-		refNodeKeys := resourceState.DependsOn
+		refNodeKeys := append([]string{}, resourceState.DependsOn...)
 
 		// handle implicit dependency
 		v := reflect.ValueOf(resourceState.Attributes)
@@ -64,6 +65,9 @@ func (m *SpecParser) Parse(g *dag.AcyclicGraph) (s status.Status) {
 		}
 		refNodeKeys = append(refNodeKeys, implicitRefKeys...)
 
+		// custom resources depend on CRDs defining their kinds in the same spec
+		refNodeKeys = append(refNodeKeys, crdDependencies[key]...)
+
 		// Deduplicate
 		refNodeKeys = Deduplicate(refNodeKeys)
 
@@ -1,15 +1,20 @@
 package operation
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
+	"kusionstack.io/kusion/pkg/engine/metrics"
 	"kusionstack.io/kusion/pkg/engine/models"
 	"kusionstack.io/kusion/pkg/engine/operation/graph"
 	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
 	runtimeinit "kusionstack.io/kusion/pkg/engine/runtime/init"
 	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/engine/tracing"
 	"kusionstack.io/kusion/pkg/log"
 	"kusionstack.io/kusion/pkg/status"
 	"kusionstack.io/kusion/third_party/terraform/dag"
@@ -52,6 +57,12 @@ func (po *PreviewOperation) Preview(request *PreviewRequest) (rsp *PreviewRespon
 		return nil, s
 	}
 
+	ctx, span := tracing.Start(context.Background(), "Preview", tracing.ProjectKey.String(request.Project.Name),
+		tracing.StackKey.String(request.Stack.Name))
+	defer func() {
+		tracing.End(span, s)
+	}()
+
 	var (
 		priorState, resultState *states.State
 		priorStateResourceIndex map[string]*models.Resource
@@ -64,11 +75,18 @@ func (po *PreviewOperation) Preview(request *PreviewRequest) (rsp *PreviewRespon
 	// Kusion is a multi-runtime system. We initialize runtimes dynamically by resource types
 	resources := request.Spec.Resources
 	resources = append(resources, priorState.Resources...)
-	runtimesMap, s := runtimeinit.Runtimes(resources)
+	runtimesMap, s := runtimeinit.Runtimes(resources, o.RuntimeEnv)
 	if status.IsErr(s) {
 		return nil, s
 	}
 	o.RuntimeMap = runtimesMap
+	defer runtimeinit.Close(runtimesMap)
+	// previews read all resources at once, which runtimes supporting bulk reads serve from their caches
+	for _, rt := range runtimesMap {
+		if cacher, ok := rt.(runtime.ReadCacher); ok {
+			cacher.EnableReadCache()
+		}
+	}
 
 	switch o.OperationType {
 	case opsmodels.ApplyPreview:
@@ -94,19 +112,32 @@ func (po *PreviewOperation) Preview(request *PreviewRequest) (rsp *PreviewRespon
 			PriorStateResourceIndex: priorStateResourceIndex,
 			StateResourceIndex:      priorStateResourceIndex,
 			IgnoreFields:            o.IgnoreFields,
+			IgnoreFieldsConfigs:     o.IgnoreFieldsConfigs,
 			ChangeOrder:             o.ChangeOrder,
 			RuntimeMap:              o.RuntimeMap,
 			Stack:                   o.Stack,
 			ResultState:             resultState,
 			Lock:                    &sync.Mutex{},
+			Context:                 ctx,
+			Parallelism:             o.Parallelism,
 			SecretStores:            o.SecretStores,
+			RefreshParallelism:      o.RefreshParallelism,
+			RefreshProgress:         o.RefreshProgress,
 		},
 	}
 
-	w := &dag.Walker{Callback: previewOperation.previewWalkFun}
-	w.Update(ag)
-	// Wait
-	if diags := w.Wait(); diags.HasErrors() {
+	if s = previewOperation.refresh(ag); status.IsErr(s) {
+		return nil, s
+	}
+	// keep live states for the apply following this preview before resource nodes modify them
+	if o.OperationType == opsmodels.ApplyPreview {
+		o.LiveStates.Store(previewOperation.LiveStateResourceIndex)
+	}
+
+	start := time.Now()
+	diags := walkGraph(ag, o.Parallelism, previewOperation.previewWalkFun)
+	metrics.ObserveGraphWalk("preview", start, diags.HasErrors())
+	if diags.HasErrors() {
 		return nil, status.NewErrorStatus(diags.Err())
 	}
 
@@ -0,0 +1,81 @@
+package operation
+
+import (
+	"sync"
+
+	"kusionstack.io/kusion/pkg/engine/operation/graph"
+	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/status"
+	"kusionstack.io/kusion/third_party/terraform/dag"
+)
+
+// DefaultRefreshParallelism is the default max number of live states read concurrently before previews
+const DefaultRefreshParallelism = 10
+
+// refresh reads live states of all resources in the graph by at most RefreshParallelism workers before the
+// graph is walked. Resources are read one by one in the walk as their dependencies finish, which dominates the
+// time of previews of big stacks. The refresh stops at the first failure, which fails the preview.
+func (po *PreviewOperation) refresh(ag *dag.AcyclicGraph) status.Status {
+	var nodes []*graph.ResourceNode
+	for _, v := range ag.Vertices() {
+		if rn, ok := v.(*graph.ResourceNode); ok {
+			nodes = append(nodes, rn)
+		}
+	}
+	total := len(nodes)
+	if total == 0 {
+		return nil
+	}
+	parallelism := po.RefreshParallelism
+	if parallelism <= 0 {
+		parallelism = DefaultRefreshParallelism
+	}
+	if parallelism > total {
+		parallelism = total
+	}
+	log.Infof("refreshing live states of %d resources by %d workers", total, parallelism)
+
+	var (
+		lock      sync.Mutex
+		refreshed int
+		failed    status.Status
+		wg        sync.WaitGroup
+	)
+	queue := make(chan *graph.ResourceNode)
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rn := range queue {
+				lock.Lock()
+				skip := failed != nil
+				lock.Unlock()
+				if skip {
+					continue
+				}
+
+				s := rn.Refresh(&po.Operation)
+
+				// progress is reported in order under the lock, so that reporters need not be concurrency safe
+				lock.Lock()
+				if status.IsErr(s) {
+					if failed == nil {
+						failed = s
+					}
+				} else {
+					refreshed++
+					if po.RefreshProgress != nil {
+						po.RefreshProgress(refreshed, total)
+					}
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+	for _, rn := range nodes {
+		queue <- rn
+	}
+	close(queue)
+	wg.Wait()
+	return failed
+}
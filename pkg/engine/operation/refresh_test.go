@@ -0,0 +1,105 @@
+package operation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/status"
+	"kusionstack.io/kusion/third_party/terraform/dag"
+)
+
+// slowReadRuntime reads resources slowly, recording the max number of concurrent reads
+type slowReadRuntime struct {
+	fakePreviewRuntime
+
+	lock    sync.Mutex
+	running int
+	max     int
+	reads   int
+	fail    bool
+}
+
+func (r *slowReadRuntime) Read(_ context.Context, request *runtime.ReadRequest) *runtime.ReadResponse {
+	r.lock.Lock()
+	r.running++
+	r.reads++
+	if r.running > r.max {
+		r.max = r.running
+	}
+	r.lock.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	r.lock.Lock()
+	r.running--
+	r.lock.Unlock()
+
+	if r.fail {
+		return &runtime.ReadResponse{Status: status.NewErrorStatus(errors.New("forbidden"))}
+	}
+	return &runtime.ReadResponse{Resource: request.PlanResource}
+}
+
+// newRefresh returns the preview operation refreshing the graph of the count of resources
+func newRefresh(t *testing.T, rt runtime.Runtime, count, parallelism int) (*PreviewOperation, *dag.AcyclicGraph) {
+	spec := &models.Spec{}
+	for i := 0; i < count; i++ {
+		spec.Resources = append(spec.Resources, models.Resource{
+			ID:         fmt.Sprintf("cm-%d", i),
+			Type:       runtime.Kubernetes,
+			Attributes: map[string]interface{}{"data": map[string]interface{}{"index": i}},
+		})
+	}
+	ag, s := NewApplyGraph(spec, states.NewState())
+	require.Nil(t, s)
+	po := &PreviewOperation{Operation: opsmodels.Operation{
+		OperationType:           opsmodels.ApplyPreview,
+		PriorStateResourceIndex: map[string]*models.Resource{},
+		RuntimeMap:              map[models.Type]runtime.Runtime{runtime.Kubernetes: rt},
+		Lock:                    &sync.Mutex{},
+		Context:                 context.Background(),
+		RefreshParallelism:      parallelism,
+	}}
+	return po, ag
+}
+
+func TestPreviewOperation_refresh(t *testing.T) {
+	t.Run("bounded workers", func(t *testing.T) {
+		rt := &slowReadRuntime{}
+		po, ag := newRefresh(t, rt, 20, 4)
+		var progress [][2]int
+		po.RefreshProgress = func(refreshed, total int) {
+			progress = append(progress, [2]int{refreshed, total})
+		}
+
+		require.Nil(t, po.refresh(ag))
+		assert.Equal(t, 20, rt.reads)
+		assert.LessOrEqual(t, rt.max, 4)
+		assert.Greater(t, rt.max, 1)
+		require.Len(t, progress, 20)
+		assert.Equal(t, [2]int{1, 20}, progress[0])
+		assert.Equal(t, [2]int{20, 20}, progress[19])
+		require.Len(t, po.LiveStateResourceIndex, 20)
+		assert.Equal(t, "cm-3", po.LiveStateResourceIndex["cm-3"].ID)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		rt := &slowReadRuntime{fail: true}
+		po, ag := newRefresh(t, rt, 10, 1)
+
+		s := po.refresh(ag)
+		require.True(t, status.IsErr(s))
+		assert.Contains(t, s.String(), "forbidden")
+		// reads after the failure are skipped
+		assert.Equal(t, 1, rt.reads)
+	})
+}
@@ -0,0 +1,203 @@
+package operation
+
+import (
+	"container/heap"
+	"errors"
+	"sync"
+
+	"kusionstack.io/kusion/pkg/status"
+	"kusionstack.io/kusion/third_party/terraform/dag"
+	"kusionstack.io/kusion/third_party/terraform/tfdiags"
+)
+
+// DefaultParallelism is the default max number of resources operated concurrently in graph walks
+const DefaultParallelism = 10
+
+// errUpstreamFailed is the error of vertices skipped since their dependencies failed, which is excluded from
+// results of walks as the failures of dependencies are reported
+var errUpstreamFailed = errors.New("upstream dependencies failed")
+
+// scheduler walks a graph by a pool of workers. A vertex runs after all its dependencies, i.e. sources of the
+// edges targeting it, succeeded, and is skipped if any of them failed. Each worker queues the vertices it makes
+// ready, and idle workers steal from the others. Queued vertices with longer chains of dependents run first,
+// since they are on the critical path which bounds the time of the whole walk.
+type scheduler struct {
+	graph    *dag.AcyclicGraph
+	callback dag.WalkFunc
+
+	lock    sync.Mutex
+	cond    *sync.Cond
+	queues  []*vertexQueue
+	pending map[dag.Vertex]int
+	// depth is the number of vertices in the longest chain of dependents of each vertex, including itself
+	depth     map[dag.Vertex]int
+	failed    map[dag.Vertex]bool
+	remaining int
+	diags     tfdiags.Diagnostics
+}
+
+// diagsError flattens the diagnostics into an error, which unwraps to the first error of them, so that typed
+// errors of vertices are kept for callers, e.g. lock conflicts of the state
+func diagsError(diags tfdiags.Diagnostics) error {
+	err := diags.Err()
+	if wrapper, ok := err.(interface{ WrappedErrors() []error }); ok {
+		if errs := wrapper.WrappedErrors(); len(errs) > 0 {
+			return status.WrapError(err.Error(), errs[0])
+		}
+	}
+	return err
+}
+
+// walkGraph walks the graph by at most parallelism workers, and returns diagnostics of all vertices except the
+// ones skipped due to failures of their dependencies
+func walkGraph(g *dag.AcyclicGraph, parallelism int, callback dag.WalkFunc) tfdiags.Diagnostics {
+	if parallelism <= 0 {
+		parallelism = DefaultParallelism
+	}
+	vertices := g.Vertices()
+	if len(vertices) == 0 {
+		return nil
+	}
+	if parallelism > len(vertices) {
+		parallelism = len(vertices)
+	}
+
+	s := &scheduler{
+		graph:     g,
+		callback:  callback,
+		queues:    make([]*vertexQueue, parallelism),
+		pending:   make(map[dag.Vertex]int, len(vertices)),
+		depth:     make(map[dag.Vertex]int, len(vertices)),
+		failed:    map[dag.Vertex]bool{},
+		remaining: len(vertices),
+	}
+	s.cond = sync.NewCond(&s.lock)
+	for i := range s.queues {
+		s.queues[i] = &vertexQueue{}
+	}
+	// vertices without dependencies are spread across workers
+	next := 0
+	for _, v := range vertices {
+		s.pending[v] = g.UpEdges(v).Len()
+		if s.pending[v] == 0 {
+			heap.Push(s.queues[next%parallelism], &queuedVertex{vertex: v, depth: s.depthOf(v)})
+			next++
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			s.work(worker)
+		}(i)
+	}
+	wg.Wait()
+	return s.diags
+}
+
+// depthOf returns the number of vertices in the longest chain of dependents of the vertex
+func (s *scheduler) depthOf(v dag.Vertex) int {
+	if d, ok := s.depth[v]; ok {
+		return d
+	}
+	d := 1
+	for _, dependent := range s.graph.DownEdges(v) {
+		if dd := s.depthOf(dependent) + 1; dd > d {
+			d = dd
+		}
+	}
+	s.depth[v] = d
+	return d
+}
+
+func (s *scheduler) work(worker int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for {
+		v, ok := s.take(worker)
+		for !ok {
+			if s.remaining == 0 {
+				return
+			}
+			s.cond.Wait()
+			v, ok = s.take(worker)
+		}
+
+		upstreamFailed := s.upstreamFailed(v)
+		var diags tfdiags.Diagnostics
+		if upstreamFailed {
+			diags = diags.Append(errUpstreamFailed)
+		} else {
+			s.lock.Unlock()
+			diags = s.callback(v)
+			s.lock.Lock()
+		}
+		s.finish(worker, v, diags, upstreamFailed)
+	}
+}
+
+// take pops the most critical vertex of the queue of the worker, or steals the most critical one queued by the
+// other workers if its own queue is empty
+func (s *scheduler) take(worker int) (dag.Vertex, bool) {
+	victim := s.queues[worker]
+	if victim.Len() == 0 {
+		for _, q := range s.queues {
+			if q.Len() > 0 && (victim.Len() == 0 || (*q)[0].depth > (*victim)[0].depth) {
+				victim = q
+			}
+		}
+	}
+	if victim.Len() == 0 {
+		return nil, false
+	}
+	return heap.Pop(victim).(*queuedVertex).vertex, true
+}
+
+func (s *scheduler) upstreamFailed(v dag.Vertex) bool {
+	for _, dep := range s.graph.UpEdges(v) {
+		if s.failed[dep] {
+			return true
+		}
+	}
+	return false
+}
+
+// finish records the result of the vertex, and queues its dependents whose dependencies are all finished
+func (s *scheduler) finish(worker int, v dag.Vertex, diags tfdiags.Diagnostics, upstreamFailed bool) {
+	if diags.HasErrors() {
+		s.failed[v] = true
+	}
+	if !upstreamFailed {
+		s.diags = s.diags.Append(diags)
+	}
+	s.remaining--
+	for _, dependent := range s.graph.DownEdges(v) {
+		s.pending[dependent]--
+		if s.pending[dependent] == 0 {
+			heap.Push(s.queues[worker], &queuedVertex{vertex: dependent, depth: s.depthOf(dependent)})
+		}
+	}
+	s.cond.Broadcast()
+}
+
+type queuedVertex struct {
+	vertex dag.Vertex
+	depth  int
+}
+
+// vertexQueue is a max heap of vertices by their depths
+type vertexQueue []*queuedVertex
+
+func (q vertexQueue) Len() int            { return len(q) }
+func (q vertexQueue) Less(i, j int) bool  { return q[i].depth > q[j].depth }
+func (q vertexQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *vertexQueue) Push(x interface{}) { *q = append(*q, x.(*queuedVertex)) }
+func (q *vertexQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
@@ -0,0 +1,104 @@
+package operation
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/third_party/terraform/dag"
+	"kusionstack.io/kusion/third_party/terraform/tfdiags"
+)
+
+// newGraph returns the graph of the vertices, in which each edge is a pair of a dependency and its dependent
+func newGraph(vertices []string, edges [][2]string) *dag.AcyclicGraph {
+	g := &dag.AcyclicGraph{}
+	for _, v := range vertices {
+		g.Add(v)
+	}
+	for _, e := range edges {
+		g.Connect(dag.BasicEdge(e[0], e[1]))
+	}
+	return g
+}
+
+func TestWalkGraph(t *testing.T) {
+	t.Run("dependencies first", func(t *testing.T) {
+		g := newGraph([]string{"a", "b", "c", "d"}, [][2]string{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}})
+		var lock sync.Mutex
+		finished := map[string]bool{}
+		diags := walkGraph(g, 4, func(v dag.Vertex) tfdiags.Diagnostics {
+			lock.Lock()
+			defer lock.Unlock()
+			for _, dep := range g.UpEdges(v) {
+				assert.True(t, finished[dep.(string)], "%s runs before %s", v, dep)
+			}
+			finished[v.(string)] = true
+			return nil
+		})
+		assert.False(t, diags.HasErrors())
+		assert.Len(t, finished, 4)
+	})
+
+	t.Run("bounded workers", func(t *testing.T) {
+		var vertices []string
+		for i := 0; i < 20; i++ {
+			vertices = append(vertices, string(rune('a'+i)))
+		}
+		g := newGraph(vertices, nil)
+		var lock sync.Mutex
+		running, max := 0, 0
+		walkGraph(g, 3, func(v dag.Vertex) tfdiags.Diagnostics {
+			lock.Lock()
+			running++
+			if running > max {
+				max = running
+			}
+			lock.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			lock.Lock()
+			running--
+			lock.Unlock()
+			return nil
+		})
+		assert.LessOrEqual(t, max, 3)
+		assert.Greater(t, max, 1)
+	})
+
+	t.Run("critical path first", func(t *testing.T) {
+		// a -> b -> c is longer than the other chains, so a runs first although it is added last
+		g := newGraph([]string{"x", "y", "a", "b", "c"}, [][2]string{{"a", "b"}, {"b", "c"}})
+		var order []string
+		walkGraph(g, 1, func(v dag.Vertex) tfdiags.Diagnostics {
+			order = append(order, v.(string))
+			return nil
+		})
+		assert.Equal(t, "a", order[0])
+		assert.Len(t, order, 5)
+	})
+
+	t.Run("upstream failed", func(t *testing.T) {
+		g := newGraph([]string{"a", "b", "c", "d"}, [][2]string{{"a", "b"}, {"b", "c"}})
+		var lock sync.Mutex
+		var called []string
+		diags := walkGraph(g, 2, func(v dag.Vertex) tfdiags.Diagnostics {
+			lock.Lock()
+			called = append(called, v.(string))
+			lock.Unlock()
+			var diags tfdiags.Diagnostics
+			if v == "a" {
+				diags = diags.Append(errors.New("a failed"))
+			}
+			return diags
+		})
+		assert.ElementsMatch(t, []string{"a", "d"}, called)
+		assert.Len(t, diags, 1)
+		assert.Equal(t, "a failed", diags.Err().Error())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Nil(t, walkGraph(&dag.AcyclicGraph{}, 0, nil))
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gosuri/uilive"
@@ -13,20 +15,32 @@ import (
 	k8swatch "k8s.io/apimachinery/pkg/watch"
 
 	"kusionstack.io/kusion/pkg/engine"
+	"kusionstack.io/kusion/pkg/engine/models"
 	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
 	"kusionstack.io/kusion/pkg/engine/printers"
 	"kusionstack.io/kusion/pkg/engine/runtime"
 	runtimeinit "kusionstack.io/kusion/pkg/engine/runtime/init"
+	"kusionstack.io/kusion/pkg/log"
 	"kusionstack.io/kusion/pkg/status"
 	"kusionstack.io/kusion/pkg/util/pretty"
 )
 
+// eventPollInterval is how often runtimes are asked for events of resources which are not ready yet
+var eventPollInterval = 5 * time.Second
+
+// maxPrintedEvents is the max number of the latest events printed under the table of each resource
+const maxPrintedEvents = 5
+
 type WatchOperation struct {
 	opsmodels.Operation
 }
 
 type WatchRequest struct {
 	opsmodels.Request `json:",inline" yaml:",inline"`
+
+	// Timeout is how long to wait for resources to be ready, 0 means waiting forever. When it expires, the
+	// resources which are not ready are diagnosed by their runtimes, e.g. by events and logs of their pods.
+	Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
 }
 
 func (wo *WatchOperation) Watch(req *WatchRequest) error {
@@ -35,7 +49,7 @@ func (wo *WatchOperation) Watch(req *WatchRequest) error {
 
 	// init runtimes
 	resources := req.Spec.Resources
-	runtimes, s := runtimeinit.Runtimes(resources)
+	runtimes, s := runtimeinit.Runtimes(resources, wo.RuntimeEnv)
 	if status.IsErr(s) {
 		return errors.New(s.Message())
 	}
@@ -66,6 +80,30 @@ func (wo *WatchOperation) Watch(req *WatchRequest) error {
 		msgChs[res.ResourceKey()] = resp.ResultChs
 	}
 
+	// Stream events of resources explaining why they are stuck, until they are ready
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	events := &eventLog{events: map[string][]runtime.Event{}}
+	stops := make(map[string]chan struct{}, len(ids))
+	since := time.Now()
+	for i := range resources {
+		res := &resources[i]
+		lister, ok := runtimes[res.Type].(runtime.EventLister)
+		if !ok {
+			continue
+		}
+		stop := make(chan struct{})
+		stops[res.ResourceKey()] = stop
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wo.streamEvents(ctx, lister, res, since, stop, events)
+		}()
+	}
+
 	// Console writer
 	writer := uilive.New()
 	writer.RefreshInterval = time.Minute * 1
@@ -141,6 +179,13 @@ func (wo *WatchOperation) Watch(req *WatchRequest) error {
 		}(id, chs, table)
 	}
 
+	var deadline <-chan time.Time
+	if req.Timeout > 0 {
+		timer := time.NewTimer(req.Timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
 	// Waiting for all tables completed
 	for {
 		// Finish watch
@@ -152,18 +197,122 @@ func (wo *WatchOperation) Watch(req *WatchRequest) error {
 		for id, table := range tables {
 			// All channels are isCompleted
 			if table.IsCompleted() {
+				if stop, ok := stops[id]; ok && !finished[id] {
+					close(stop)
+				}
 				finished[id] = true
 			}
 		}
 
 		// Render table every 1s
-		<-ticker.C
-		wo.printTables(writer, ids, tables)
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			wo.printTables(writer, ids, tables, events)
+			return wo.timeoutError(ctx, req, finished)
+		}
+		wo.printTables(writer, ids, tables, events)
 	}
 	return nil
 }
 
-func (wo *WatchOperation) printTables(w *uilive.Writer, ids []string, tables map[string]*printers.Table) {
+// timeoutError returns the error of resources which are not ready before the timeout, along with diagnoses of
+// them given by their runtimes
+func (wo *WatchOperation) timeoutError(ctx context.Context, req *WatchRequest, finished map[string]bool) error {
+	var pending []string
+	diagnoses := &strings.Builder{}
+	for i := range req.Spec.Resources {
+		res := &req.Spec.Resources[i]
+		key := res.ResourceKey()
+		if finished[key] {
+			continue
+		}
+		pending = append(pending, key)
+
+		diagnoser, ok := wo.RuntimeMap[res.Type].(runtime.Diagnoser)
+		if !ok {
+			continue
+		}
+		diagnosis, err := diagnoser.Diagnose(ctx, res)
+		if err != nil {
+			diagnosis = fmt.Sprintf("failed to diagnose: %v\n", err)
+		}
+		if diagnosis != "" {
+			fmt.Fprintf(diagnoses, "\n[%s]\n%s", key, diagnosis)
+		}
+	}
+	return fmt.Errorf("timed out after %s waiting for resources to be ready: %s\n%s",
+		req.Timeout, strings.Join(pending, ", "), diagnoses.String())
+}
+
+// streamEvents polls events of the resource until it is ready, and sends new ones to the message channel if any,
+// besides printing them under the table of the resource, so that users see why the resource is stuck in real time
+func (wo *WatchOperation) streamEvents(ctx context.Context, lister runtime.EventLister, res *models.Resource,
+	since time.Time, stop <-chan struct{}, events *eventLog,
+) {
+	key := res.ResourceKey()
+	// counts of events sent, keyed by their objects, reasons and messages
+	sent := map[string]int32{}
+	ticker := time.NewTicker(eventPollInterval)
+	defer ticker.Stop()
+	for {
+		listed, err := lister.ListEvents(ctx, res, since)
+		if err != nil && ctx.Err() == nil {
+			log.Warnf("list events of %s failed: %v", key, err)
+		}
+		for i := range listed {
+			e := listed[i]
+			id := e.Object + "/" + e.Reason + "/" + e.Message
+			if count, ok := sent[id]; ok && count >= e.Count {
+				continue
+			}
+			sent[id] = e.Count
+			events.add(key, e)
+			if wo.MsgCh == nil {
+				continue
+			}
+			select {
+			case wo.MsgCh <- opsmodels.Message{ResourceID: key, OpResult: opsmodels.Event, Event: &e}:
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// eventLog keeps the latest events of resources, keyed by resource keys
+type eventLog struct {
+	lock   sync.Mutex
+	events map[string][]runtime.Event
+}
+
+func (l *eventLog) add(key string, e runtime.Event) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	events := append(l.events[key], e)
+	if len(events) > maxPrintedEvents {
+		events = events[len(events)-maxPrintedEvents:]
+	}
+	l.events[key] = events
+}
+
+func (l *eventLog) latest(key string) []runtime.Event {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return append([]runtime.Event(nil), l.events[key]...)
+}
+
+func (wo *WatchOperation) printTables(w *uilive.Writer, ids []string, tables map[string]*printers.Table, events *eventLog) {
 	for i, id := range ids {
 		// Print resource Key as heading text
 		_, _ = fmt.Fprintf(w, "%s\n", pretty.LightCyanBold("[%s]", id))
@@ -176,6 +325,15 @@ func (wo *WatchOperation) printTables(w *uilive.Writer, ids []string, tables map
 		data := table.Print()
 		_ = pterm.DefaultTable.WithHasHeader().WithSeparator("  ").WithData(data).WithWriter(w).Render()
 
+		// Print the latest events explaining why the resource is stuck
+		for _, e := range events.latest(id) {
+			count := ""
+			if e.Count > 1 {
+				count = fmt.Sprintf(" (x%d)", e.Count)
+			}
+			_, _ = fmt.Fprintln(w, pretty.Yellow("Warning %s%s %s: %s", e.Reason, count, e.Object, e.Message))
+		}
+
 		// Split each resource with blank line
 		if i != len(ids)-1 {
 			_, _ = fmt.Fprintln(w)
@@ -2,7 +2,9 @@ package operation
 
 import (
 	"context"
+	"sync"
 	"testing"
+	"time"
 
 	"bou.ke/monkey"
 	"github.com/stretchr/testify/assert"
@@ -30,7 +32,7 @@ func TestWatchOperation_Watch(t *testing.T) {
 			},
 		},
 	}
-	monkey.Patch(runtimeinit.Runtimes, func(resources models.Resources) (map[models.Type]runtime.Runtime, status.Status) {
+	monkey.Patch(runtimeinit.Runtimes, func(resources models.Resources, _ runtime.Env) (map[models.Type]runtime.Runtime, status.Status) {
 		return map[models.Type]runtime.Runtime{runtime.Kubernetes: fooRuntime}, nil
 	})
 	wo := &WatchOperation{opsmodels.Operation{RuntimeMap: map[models.Type]runtime.Runtime{runtime.Kubernetes: fooRuntime}}}
@@ -38,6 +40,48 @@ func TestWatchOperation_Watch(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestWatchOperation_WatchEvents(t *testing.T) {
+	defer monkey.UnpatchAll()
+	interval := eventPollInterval
+	eventPollInterval = 10 * time.Millisecond
+	defer func() { eventPollInterval = interval }()
+
+	res := models.Resource{ID: "apps/v1:Deployment:foo:bar", Type: runtime.Kubernetes, Attributes: barDeployment}
+	rt := &eventWatchRuntime{ready: make(chan struct{})}
+	monkey.Patch(runtimeinit.Runtimes, func(resources models.Resources, _ runtime.Env) (map[models.Type]runtime.Runtime, status.Status) {
+		return map[models.Type]runtime.Runtime{runtime.Kubernetes: rt}, nil
+	})
+
+	wo := &WatchOperation{opsmodels.Operation{MsgCh: make(chan opsmodels.Message)}}
+	var messages []opsmodels.Message
+	received := make(chan struct{})
+	go func() {
+		defer close(received)
+		for msg := range wo.MsgCh {
+			messages = append(messages, msg)
+			if len(messages) == 3 {
+				close(rt.ready)
+			}
+		}
+	}()
+	err := wo.Watch(&WatchRequest{Request: opsmodels.Request{Spec: &models.Spec{Resources: models.Resources{res}}}})
+	assert.Nil(t, err)
+	close(wo.MsgCh)
+	<-received
+
+	scheduling := runtime.Event{Object: "Pod foo/bar-1", Reason: "FailedScheduling", Message: "no nodes", Count: 1}
+	pull := runtime.Event{Object: "Pod foo/bar-1", Reason: "BackOff", Message: "back-off pulling image", Count: 1}
+	assert.Len(t, messages, 3)
+	for _, msg := range messages {
+		assert.Equal(t, res.ResourceKey(), msg.ResourceID)
+		assert.Equal(t, opsmodels.Event, msg.OpResult)
+	}
+	assert.Equal(t, scheduling, *messages[0].Event)
+	assert.Equal(t, pull, *messages[1].Event)
+	scheduling.Count = 2
+	assert.Equal(t, scheduling, *messages[2].Event)
+}
+
 var barDeployment = map[string]interface{}{
 	"apiVersion": "apps/v1",
 	"kind":       "Deployment",
@@ -98,3 +142,43 @@ func (f *fooWatchRuntime) Watch(ctx context.Context, request *runtime.WatchReque
 		Status:    nil,
 	}
 }
+
+// eventWatchRuntime reports events of the resource until it is ready
+type eventWatchRuntime struct {
+	fooWatchRuntime
+	ready chan struct{}
+
+	lock  sync.Mutex
+	polls int
+}
+
+func (f *eventWatchRuntime) Watch(ctx context.Context, request *runtime.WatchRequest) *runtime.WatchResponse {
+	out := make(chan k8sWatch.Event)
+	go func() {
+		<-f.ready
+		out <- k8sWatch.Event{
+			Type:   k8sWatch.Deleted,
+			Object: &unstructured.Unstructured{Object: barDeployment},
+		}
+		close(out)
+	}()
+	return &runtime.WatchResponse{ResultChs: []<-chan k8sWatch.Event{out}}
+}
+
+func (f *eventWatchRuntime) ListEvents(ctx context.Context, resource *models.Resource, since time.Time) ([]runtime.Event, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.polls++
+	scheduling := runtime.Event{Object: "Pod foo/bar-1", Reason: "FailedScheduling", Message: "no nodes", Count: 1}
+	pull := runtime.Event{Object: "Pod foo/bar-1", Reason: "BackOff", Message: "back-off pulling image", Count: 1}
+	switch f.polls {
+	case 1:
+		return []runtime.Event{scheduling}, nil
+	case 2:
+		return []runtime.Event{scheduling, pull}, nil
+	default:
+		// the scheduling fails again
+		scheduling.Count = 2
+		return []runtime.Event{pull, scheduling}, nil
+	}
+}
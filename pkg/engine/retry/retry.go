@@ -0,0 +1,151 @@
+// Package retry classifies errors of requests to runtimes and retries transient ones by the retry policy of the
+// project, so that all runtimes are retried in the same way, e.g. conflicts of Kubernetes resources updated by
+// controllers concurrently and throttled requests of cloud providers.
+package retry
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/status"
+)
+
+// Classes of retryable errors
+const (
+	// Conflict means the resource has been modified concurrently, e.g. Kubernetes 409 conflicts
+	Conflict = "conflict"
+	// Throttled means requests are rate limited, e.g. HTTP 429 and throttling errors of cloud providers
+	Throttled = "throttled"
+	// WebhookTimeout means admission webhooks called by the API server time out
+	WebhookTimeout = "webhook-timeout"
+)
+
+// Defaults of policies
+const (
+	DefaultMaxAttempts = 3
+	DefaultBackoff     = time.Second
+	DefaultMaxBackoff  = 30 * time.Second
+)
+
+// classes are patterns of messages of errors in each class, matched in order
+var classes = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{WebhookTimeout, regexp.MustCompile(`(?is)calling webhook.*(deadline exceeded|timed out|i/o timeout|Client\.Timeout)`)},
+	{Throttled, regexp.MustCompile(`(?i)too many requests|\b429\b|rate ?limit|throttl|TooManyRequests|RequestLimitExceeded|SlowDown`)},
+	{Conflict, regexp.MustCompile(`(?i)the object has been modified|operation cannot be fulfilled|\bconflict\b|ConcurrentModification`)},
+}
+
+// Classify returns the class of the error message, or empty if it is not retryable
+func Classify(message string) string {
+	for _, c := range classes {
+		if c.pattern.MatchString(message) {
+			return c.name
+		}
+	}
+	return ""
+}
+
+// Policy decides whether failed requests are retried and how long to wait before retries
+type Policy struct {
+	// Classes are classes of errors to retry
+	Classes map[string]bool
+	// MaxAttempts is the max number of attempts of each request including the first one
+	MaxAttempts int
+	// Backoff is the delay before the first retry, which doubles for each later retry up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// NewPolicy returns the policy of the retry config of the project, or nil if it is not configured, which never
+// retries
+func NewPolicy(config *projectstack.RetryConfig) (*Policy, error) {
+	if config == nil {
+		return nil, nil
+	}
+	p := &Policy{
+		Classes:     map[string]bool{},
+		MaxAttempts: config.MaxAttempts,
+		Backoff:     DefaultBackoff,
+		MaxBackoff:  DefaultMaxBackoff,
+	}
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = DefaultMaxAttempts
+	} else if p.MaxAttempts < 0 {
+		return nil, fmt.Errorf("invalid maxAttempts %d of retry, must be positive", config.MaxAttempts)
+	}
+
+	names := config.Errors
+	if len(names) == 0 {
+		names = []string{Conflict, Throttled, WebhookTimeout}
+	}
+	for _, name := range names {
+		switch name {
+		case Conflict, Throttled, WebhookTimeout:
+			p.Classes[name] = true
+		default:
+			return nil, fmt.Errorf("unknown error class %s of retry, must be one of %s", name,
+				strings.Join([]string{Conflict, Throttled, WebhookTimeout}, ", "))
+		}
+	}
+
+	var err error
+	if config.Backoff != "" {
+		if p.Backoff, err = time.ParseDuration(config.Backoff); err != nil || p.Backoff < 0 {
+			return nil, fmt.Errorf("invalid backoff %s of retry, must be a duration like 500ms", config.Backoff)
+		}
+	}
+	if config.MaxBackoff != "" {
+		if p.MaxBackoff, err = time.ParseDuration(config.MaxBackoff); err != nil || p.MaxBackoff < 0 {
+			return nil, fmt.Errorf("invalid maxBackoff %s of retry, must be a duration like 30s", config.MaxBackoff)
+		}
+	}
+	return p, nil
+}
+
+// ShouldRetry returns true if the request failed with the status after the number of attempts should be retried
+func (p *Policy) ShouldRetry(s status.Status, attempts int) bool {
+	if p == nil || !status.IsErr(s) || attempts >= p.MaxAttempts {
+		return false
+	}
+	return p.Classes[Classify(s.Message())]
+}
+
+// Delay returns how long to wait before the retry, which is 1 for the first retry
+func (p *Policy) Delay(retry int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < retry && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+// Do sends the request until it succeeds, fails with an error which should not be retried, or the context is
+// done. The function retried is called before each retry, e.g. to count attempts.
+func (p *Policy) Do(ctx context.Context, id string, request func() status.Status, retried func()) status.Status {
+	s := request()
+	for attempts := 1; p.ShouldRetry(s, attempts); attempts++ {
+		delay := p.Delay(attempts)
+		log.Infof("retry %s in %s after %d attempts failed with %s errors: %s", id, delay, attempts,
+			Classify(s.Message()), s.Message())
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return s
+		}
+		if retried != nil {
+			retried()
+		}
+		s = request()
+	}
+	return s
+}
@@ -0,0 +1,113 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/status"
+)
+
+func TestClassify(t *testing.T) {
+	tests := map[string]string{
+		`Operation cannot be fulfilled on deployments.apps "web": the object has been modified`: Conflict,
+		"the server has received too many requests and has asked us to try again later":         Throttled,
+		"Throttling: Rate exceeded, status code: 400":                                           Throttled,
+		`Internal error occurred: failed calling webhook "validate.example.com": ` +
+			`Post "https://webhook.svc:443/validate?timeout=10s": context deadline exceeded`: WebhookTimeout,
+		`failed calling webhook "validate.example.com": Post "https://webhook.svc:443/validate?timeout=10s": ` +
+			`dial tcp: connect: connection refused`: "",
+		`deployments.apps "web" not found`: "",
+	}
+	for message, class := range tests {
+		assert.Equal(t, class, Classify(message), message)
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	p, err := NewPolicy(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, p)
+
+	p, err = NewPolicy(&projectstack.RetryConfig{})
+	assert.Nil(t, err)
+	assert.Equal(t, &Policy{
+		Classes:     map[string]bool{Conflict: true, Throttled: true, WebhookTimeout: true},
+		MaxAttempts: DefaultMaxAttempts,
+		Backoff:     DefaultBackoff,
+		MaxBackoff:  DefaultMaxBackoff,
+	}, p)
+
+	p, err = NewPolicy(&projectstack.RetryConfig{Errors: []string{Throttled}, MaxAttempts: 5, Backoff: "500ms", MaxBackoff: "2s"})
+	assert.Nil(t, err)
+	assert.Equal(t, &Policy{
+		Classes:     map[string]bool{Throttled: true},
+		MaxAttempts: 5,
+		Backoff:     500 * time.Millisecond,
+		MaxBackoff:  2 * time.Second,
+	}, p)
+
+	for _, config := range []*projectstack.RetryConfig{
+		{Errors: []string{"timeout"}},
+		{MaxAttempts: -1},
+		{Backoff: "1"},
+		{MaxBackoff: "-1s"},
+	} {
+		_, err = NewPolicy(config)
+		assert.NotNil(t, err, config)
+	}
+}
+
+func TestPolicy_Delay(t *testing.T) {
+	p := &Policy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	var delays []time.Duration
+	for retry := 1; retry <= 5; retry++ {
+		delays = append(delays, p.Delay(retry))
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, delays)
+}
+
+func TestPolicy_Do(t *testing.T) {
+	conflict := status.NewErrorStatusWithMsg(status.Internal, "the object has been modified")
+	notFound := status.NewErrorStatusWithMsg(status.NotFound, "not found")
+	p := &Policy{Classes: map[string]bool{Conflict: true}, MaxAttempts: 3}
+
+	// results returns a request which returns the statuses in order, and counts its calls
+	results := func(statuses ...status.Status) (func() status.Status, *int) {
+		calls := 0
+		return func() status.Status {
+			s := statuses[calls]
+			calls++
+			return s
+		}, &calls
+	}
+
+	request, calls := results(conflict, conflict, nil)
+	retries := 0
+	assert.Nil(t, p.Do(context.Background(), "svc", request, func() { retries++ }))
+	assert.Equal(t, 3, *calls)
+	assert.Equal(t, 2, retries)
+
+	request, calls = results(conflict, conflict, conflict, nil)
+	assert.Equal(t, conflict, p.Do(context.Background(), "svc", request, nil))
+	assert.Equal(t, 3, *calls)
+
+	request, calls = results(notFound)
+	assert.Equal(t, notFound, p.Do(context.Background(), "svc", request, nil))
+	assert.Equal(t, 1, *calls)
+
+	// policies not configured never retry
+	request, calls = results(conflict)
+	assert.Equal(t, conflict, (*Policy)(nil).Do(context.Background(), "svc", request, nil))
+	assert.Equal(t, 1, *calls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	request, calls = results(conflict, nil)
+	slow := &Policy{Classes: map[string]bool{Conflict: true}, MaxAttempts: 3, Backoff: time.Hour, MaxBackoff: time.Hour}
+	assert.Equal(t, conflict, slow.Do(ctx, "svc", request, nil))
+	assert.Equal(t, 1, *calls)
+}
@@ -0,0 +1,62 @@
+package runtime
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/util/credentials"
+)
+
+// Env are environment variables of runtimes of a stack, e.g. $KUBECONFIG pinned by the runtime config of the
+// stack. They override variables of this process only for the runtimes and the processes they run, so that
+// stacks operated by the same process, e.g. the server, never share them.
+type Env map[string]string
+
+// StackEnv returns the env of runtimes of the stack of the project, which are credentials vended by the
+// credential helper of the project overridden by the runtime config of the stack. Both override the credentials
+// selected in the local environment, e.g. the current context of the kubeconfig.
+func StackEnv(ctx context.Context, project *projectstack.Project, stack *projectstack.Stack) (Env, error) {
+	creds, err := credentials.Env(ctx, project.CredentialHelper, project.Name, stack.Name)
+	if err != nil {
+		return nil, err
+	}
+	pinned := stack.RuntimeEnv(project)
+	if len(creds) == 0 && len(pinned) == 0 {
+		return nil, nil
+	}
+	env := make(Env, len(creds)+len(pinned))
+	for k, v := range creds {
+		env[k] = v
+	}
+	for k, v := range pinned {
+		env[k] = v
+	}
+	return env, nil
+}
+
+// Getenv returns the variable in the env, or of this process if it is not in the env
+func (e Env) Getenv(key string) string {
+	if v, ok := e[key]; ok {
+		return v
+	}
+	return os.Getenv(key)
+}
+
+// Environ returns variables of this process overridden by the env in the form of os.Environ, which are passed
+// to processes run by runtimes
+func (e Env) Environ() []string {
+	environ := os.Environ()
+	result := make([]string, 0, len(environ)+len(e))
+	for _, kv := range environ {
+		k, _, _ := strings.Cut(kv, "=")
+		if _, ok := e[k]; !ok {
+			result = append(result, kv)
+		}
+	}
+	for k, v := range e {
+		result = append(result, k+"="+v)
+	}
+	return result
+}
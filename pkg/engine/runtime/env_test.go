@@ -0,0 +1,56 @@
+package runtime
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/util/credentials"
+)
+
+func TestStackEnv(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	helper := "#!/bin/sh\necho '{\"env\":{\"AWS_PROFILE\":\"vended\",\"AWS_SESSION_TOKEN\":\"token\"}}'\n"
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, credentials.HelperPrefix+"test"), []byte(helper), 0o755))
+
+	project := &projectstack.Project{ProjectConfiguration: projectstack.ProjectConfiguration{Name: "p"}}
+	stack := &projectstack.Stack{StackConfiguration: projectstack.StackConfiguration{
+		Name: "dev",
+		Runtime: &projectstack.RuntimeConfig{
+			Terraform: &projectstack.TerraformRuntimeConfig{Env: map[string]string{"AWS_PROFILE": "pinned"}},
+		},
+	}}
+	env, err := StackEnv(context.Background(), project, &projectstack.Stack{})
+	assert.Nil(t, err)
+	assert.Nil(t, env)
+
+	// the runtime config of the stack overrides vended credentials
+	project.CredentialHelper = "test"
+	env, err = StackEnv(context.Background(), project, stack)
+	assert.Nil(t, err)
+	assert.Equal(t, Env{"AWS_PROFILE": "pinned", "AWS_SESSION_TOKEN": "token"}, env)
+
+	project.CredentialHelper = "missing"
+	_, err = StackEnv(context.Background(), project, stack)
+	assert.NotNil(t, err)
+}
+
+func TestEnv(t *testing.T) {
+	t.Setenv("KUSION_TEST_ENV", "process")
+	t.Setenv("KUSION_TEST_OTHER", "process")
+	env := Env{"KUSION_TEST_ENV": "stack"}
+
+	assert.Equal(t, "stack", env.Getenv("KUSION_TEST_ENV"))
+	assert.Equal(t, "process", env.Getenv("KUSION_TEST_OTHER"))
+	assert.Equal(t, "process", Env(nil).Getenv("KUSION_TEST_ENV"))
+
+	environ := env.Environ()
+	assert.Contains(t, environ, "KUSION_TEST_ENV=stack")
+	assert.Contains(t, environ, "KUSION_TEST_OTHER=process")
+	assert.NotContains(t, environ, "KUSION_TEST_ENV=process")
+}
@@ -8,6 +8,7 @@ import (
 	"kusionstack.io/kusion/pkg/engine/runtime"
 	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
 	"kusionstack.io/kusion/pkg/engine/runtime/terraform"
+	"kusionstack.io/kusion/pkg/log"
 	"kusionstack.io/kusion/pkg/status"
 )
 
@@ -16,10 +17,12 @@ var SupportRuntimes = map[models.Type]InitFn{
 	runtime.Terraform:  terraform.NewTerraformRuntime,
 }
 
-// InitFn runtime init func
-type InitFn func() (runtime.Runtime, error)
+// InitFn runtime init func, runtimes run with the env of the stack
+type InitFn func(env runtime.Env) (runtime.Runtime, error)
 
-func Runtimes(resources models.Resources) (map[models.Type]runtime.Runtime, status.Status) {
+// Runtimes returns runtimes of types of the resources with the env of the stack, which are initialized lazily
+// when they are called
+func Runtimes(resources models.Resources, env runtime.Env) (map[models.Type]runtime.Runtime, status.Status) {
 	runtimesMap := map[models.Type]runtime.Runtime{}
 	if resources == nil {
 		return runtimesMap, nil
@@ -35,13 +38,23 @@ func Runtimes(resources models.Resources) (map[models.Type]runtime.Runtime, stat
 			return nil, status.NewErrorStatusWithCode(status.IllegalManifest, fmt.Errorf("unknow resource type: %s. Currently supported resource types are: %v",
 				rt, reflect.ValueOf(SupportRuntimes).MapKeys()))
 		} else if runtimesMap[rt] == nil {
-			r, err := SupportRuntimes[rt]()
-			if err != nil {
-				return nil, status.NewErrorStatus(fmt.Errorf("init %s runtime failed", rt))
-			}
-			runtimesMap[rt] = r
+			// runtimes are initialized on their first requests, e.g. kubeconfig is loaded only if Kubernetes
+			// resources are read or changed
+			runtimesMap[rt] = newLazyRuntime(rt, SupportRuntimes[rt], env)
 		}
 	}
 
 	return runtimesMap, nil
 }
+
+// Close closes runtimes which hold resources across requests, errors are only logged since they do not affect
+// results of operations
+func Close(runtimes map[models.Type]runtime.Runtime) {
+	for rt, r := range runtimes {
+		if closer, ok := r.(runtime.Closer); ok {
+			if err := closer.Close(); err != nil {
+				log.Warnf("close %s runtime failed: %v", rt, err)
+			}
+		}
+	}
+}
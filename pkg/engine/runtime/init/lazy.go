@@ -0,0 +1,156 @@
+package init
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/status"
+)
+
+var (
+	_ runtime.Runtime             = &lazyRuntime{}
+	_ runtime.ReplacementDetector = &lazyRuntime{}
+	_ runtime.Diagnoser           = &lazyRuntime{}
+	_ runtime.EventLister         = &lazyRuntime{}
+	_ runtime.ReadCacher          = &lazyRuntime{}
+	_ runtime.Closer              = &lazyRuntime{}
+)
+
+// lazyRuntime initializes the runtime on its first request, so that operations never pay for runtimes they
+// do not call, e.g. loading kubeconfig when all resources are skipped or unchanged in states. Optional
+// interfaces are forwarded to the runtime if it implements them.
+type lazyRuntime struct {
+	rt   models.Type
+	init InitFn
+	env  runtime.Env
+
+	once      sync.Once
+	runtime   runtime.Runtime
+	err       error
+	readCache bool
+	lock      sync.Mutex
+}
+
+func newLazyRuntime(rt models.Type, init InitFn, env runtime.Env) *lazyRuntime {
+	return &lazyRuntime{rt: rt, init: init, env: env}
+}
+
+// get returns the runtime, which is initialized at the first call
+func (l *lazyRuntime) get() (runtime.Runtime, status.Status) {
+	l.once.Do(func() {
+		r, err := l.init(l.env)
+		if err != nil {
+			l.err = fmt.Errorf("init %s runtime failed: %w", l.rt, err)
+			return
+		}
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		if cacher, ok := r.(runtime.ReadCacher); ok && l.readCache {
+			cacher.EnableReadCache()
+		}
+		l.runtime = r
+	})
+	if l.err != nil {
+		return nil, status.NewErrorStatus(l.err)
+	}
+	return l.runtime, nil
+}
+
+// initialized returns the runtime if it has been initialized, or nil
+func (l *lazyRuntime) initialized() runtime.Runtime {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.runtime
+}
+
+func (l *lazyRuntime) Apply(ctx context.Context, request *runtime.ApplyRequest) *runtime.ApplyResponse {
+	r, s := l.get()
+	if status.IsErr(s) {
+		return &runtime.ApplyResponse{Status: s}
+	}
+	return r.Apply(ctx, request)
+}
+
+func (l *lazyRuntime) Read(ctx context.Context, request *runtime.ReadRequest) *runtime.ReadResponse {
+	r, s := l.get()
+	if status.IsErr(s) {
+		return &runtime.ReadResponse{Status: s}
+	}
+	return r.Read(ctx, request)
+}
+
+func (l *lazyRuntime) Import(ctx context.Context, request *runtime.ImportRequest) *runtime.ImportResponse {
+	r, s := l.get()
+	if status.IsErr(s) {
+		return &runtime.ImportResponse{Status: s}
+	}
+	return r.Import(ctx, request)
+}
+
+func (l *lazyRuntime) Delete(ctx context.Context, request *runtime.DeleteRequest) *runtime.DeleteResponse {
+	r, s := l.get()
+	if status.IsErr(s) {
+		return &runtime.DeleteResponse{Status: s}
+	}
+	return r.Delete(ctx, request)
+}
+
+func (l *lazyRuntime) Watch(ctx context.Context, request *runtime.WatchRequest) *runtime.WatchResponse {
+	r, s := l.get()
+	if status.IsErr(s) {
+		return &runtime.WatchResponse{Status: s}
+	}
+	return r.Watch(ctx, request)
+}
+
+// RequiresReplacement is only called for updated resources, whose runtime has been initialized to read them
+func (l *lazyRuntime) RequiresReplacement(live, plan *models.Resource) []string {
+	if detector, ok := l.initialized().(runtime.ReplacementDetector); ok {
+		return detector.RequiresReplacement(live, plan)
+	}
+	return nil
+}
+
+func (l *lazyRuntime) Diagnose(ctx context.Context, resource *models.Resource) (string, error) {
+	r, s := l.get()
+	if status.IsErr(s) {
+		return "", l.err
+	}
+	if diagnoser, ok := r.(runtime.Diagnoser); ok {
+		return diagnoser.Diagnose(ctx, resource)
+	}
+	return "", nil
+}
+
+func (l *lazyRuntime) ListEvents(ctx context.Context, resource *models.Resource, since time.Time) ([]runtime.Event, error) {
+	r, s := l.get()
+	if status.IsErr(s) {
+		return nil, l.err
+	}
+	if lister, ok := r.(runtime.EventLister); ok {
+		return lister.ListEvents(ctx, resource, since)
+	}
+	return nil, nil
+}
+
+// EnableReadCache enables the cache of the runtime when it is initialized
+func (l *lazyRuntime) EnableReadCache() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.readCache = true
+	if cacher, ok := l.runtime.(runtime.ReadCacher); ok {
+		cacher.EnableReadCache()
+	}
+}
+
+// Close closes the runtime if it has been initialized
+func (l *lazyRuntime) Close() error {
+	if closer, ok := l.initialized().(runtime.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package init
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/status"
+)
+
+type fakeRuntime struct {
+	runtime.Runtime
+	readCache bool
+	closed    bool
+}
+
+func (f *fakeRuntime) Read(ctx context.Context, request *runtime.ReadRequest) *runtime.ReadResponse {
+	return &runtime.ReadResponse{Resource: request.PlanResource}
+}
+
+func (f *fakeRuntime) EnableReadCache() {
+	f.readCache = true
+}
+
+func (f *fakeRuntime) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestLazyRuntime(t *testing.T) {
+	inits := 0
+	fake := &fakeRuntime{}
+	env := runtime.Env{"KUBECONFIG": "/project/prod/kubeconfig"}
+	l := newLazyRuntime("fake", func(e runtime.Env) (runtime.Runtime, error) {
+		assert.Equal(t, env, e)
+		inits++
+		return fake, nil
+	}, env)
+
+	// closing or enabling caches of runtimes never called does not initialize them
+	l.EnableReadCache()
+	assert.Nil(t, l.Close())
+	assert.Nil(t, l.RequiresReplacement(nil, nil))
+	assert.Equal(t, 0, inits)
+
+	res := &models.Resource{ID: "a"}
+	for i := 0; i < 2; i++ {
+		response := l.Read(context.TODO(), &runtime.ReadRequest{PlanResource: res})
+		assert.Nil(t, response.Status)
+		assert.Equal(t, res, response.Resource)
+	}
+	assert.Equal(t, 1, inits)
+	assert.True(t, fake.readCache)
+
+	assert.Nil(t, l.Close())
+	assert.True(t, fake.closed)
+}
+
+func TestLazyRuntime_InitFailed(t *testing.T) {
+	l := newLazyRuntime("fake", func(runtime.Env) (runtime.Runtime, error) {
+		return nil, errors.New("no kubeconfig")
+	}, nil)
+
+	response := l.Apply(context.TODO(), &runtime.ApplyRequest{})
+	assert.True(t, status.IsErr(response.Status))
+	assert.Contains(t, response.Status.String(), "init fake runtime failed: no kubeconfig")
+
+	_, err := l.Diagnose(context.TODO(), &models.Resource{})
+	assert.NotNil(t, err)
+}
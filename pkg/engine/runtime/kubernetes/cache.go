@@ -0,0 +1,115 @@
+package kubernetes
+
+import (
+	"context"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/log"
+)
+
+var _ runtime.ReadCacher = (*KubernetesRuntime)(nil)
+
+// listPageSize is the max number of resources in a page of lists populating the read cache
+const listPageSize = 500
+
+// listThreshold is the number of reads of resources of the same kind and namespace from which they are listed,
+// since a few resources are cheaper to get one by one than listing all resources of their kind
+const listThreshold = 5
+
+// uncachedKinds are never listed, e.g. lists of Secrets load data of all Secrets in the namespace, and require
+// the permission to list Secrets the stack does not manage
+var uncachedKinds = map[schema.GroupKind]bool{
+	{Kind: "Secret"}: true,
+}
+
+// readCache caches resources listed per kind and namespace, so that reads of many resources of the same kind
+// cost one list instead of one get per resource
+type readCache struct {
+	lock  sync.Mutex
+	lists map[listKey]*cachedList
+}
+
+type listKey struct {
+	gvk       schema.GroupVersionKind
+	namespace string
+}
+
+// cachedList is a list of resources indexed by namespace/name, populated once reads reach listThreshold
+type cachedList struct {
+	reads int
+	once  sync.Once
+	items map[string]*unstructured.Unstructured
+	err   error
+}
+
+// EnableReadCache makes later reads served from lists of resources of the same kind and namespace
+func (k *KubernetesRuntime) EnableReadCache() {
+	k.cache = &readCache{lists: map[listKey]*cachedList{}}
+}
+
+// get returns the resource from the list of its kind and namespace, which is listed once reads of them reach
+// listThreshold. Reads fall back to gets before that, for uncachedKinds, and if the list fails, e.g. when users
+// are allowed to get but not list resources.
+func (c *readCache) get(
+	ctx context.Context,
+	resource dynamic.ResourceInterface,
+	obj *unstructured.Unstructured,
+) (item *unstructured.Unstructured, cached bool) {
+	key := listKey{gvk: obj.GroupVersionKind(), namespace: obj.GetNamespace()}
+	if uncachedKinds[key.gvk.GroupKind()] {
+		return nil, false
+	}
+	c.lock.Lock()
+	list, ok := c.lists[key]
+	if !ok {
+		list = &cachedList{}
+		c.lists[key] = list
+	}
+	list.reads++
+	reads := list.reads
+	c.lock.Unlock()
+	if reads < listThreshold {
+		return nil, false
+	}
+
+	list.once.Do(func() {
+		list.items, list.err = listAll(ctx, resource)
+		if list.err != nil {
+			log.Infof("list %s in the namespace %q failed, read by get instead: %v", key.gvk, key.namespace, list.err)
+		}
+	})
+	if list.err != nil {
+		return nil, false
+	}
+	if item, ok := list.items[obj.GetNamespace()+"/"+obj.GetName()]; ok {
+		// callers may modify the resource, e.g. remove ignored fields
+		return item.DeepCopy(), true
+	}
+	return nil, true
+}
+
+// listAll lists all resources page by page, and indexes them by namespace/name
+func listAll(ctx context.Context, resource dynamic.ResourceInterface) (map[string]*unstructured.Unstructured, error) {
+	items := map[string]*unstructured.Unstructured{}
+	options := metav1.ListOptions{Limit: listPageSize}
+	for {
+		list, err := resource.List(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			item := &list.Items[i]
+			items[item.GetNamespace()+"/"+item.GetName()] = item
+		}
+		if list.GetContinue() == "" {
+			return items, nil
+		}
+		options.Continue = list.GetContinue()
+	}
+}
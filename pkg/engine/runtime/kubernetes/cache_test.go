@@ -0,0 +1,108 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+func newCacheRuntime(names ...string) (*KubernetesRuntime, *fake.FakeDynamicClient) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	var objects []k8sruntime.Object
+	for _, name := range names {
+		cm := &unstructured.Unstructured{}
+		cm.SetAPIVersion("v1")
+		cm.SetKind("ConfigMap")
+		cm.SetName(name)
+		cm.SetNamespace("default")
+		objects = append(objects, cm)
+	}
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{{Version: "v1", Resource: "configmaps"}: "ConfigMapList"}, objects...)
+	return &KubernetesRuntime{client: client, mapper: mapper}, client
+}
+
+func readConfigMap(t *testing.T, k *KubernetesRuntime, name string) *models.Resource {
+	r := newK8sResource(name, "v1", "ConfigMap", nil)
+	r.Attributes["metadata"].(map[string]interface{})["namespace"] = "default"
+	response := k.Read(context.Background(), &runtime.ReadRequest{PlanResource: &r})
+	require.Nil(t, response.Status)
+	return response.Resource
+}
+
+func verbs(client *fake.FakeDynamicClient) []string {
+	var result []string
+	for _, action := range client.Actions() {
+		result = append(result, action.GetVerb())
+	}
+	return result
+}
+
+func TestKubernetesRuntime_ReadCache(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		k, client := newCacheRuntime("a", "b")
+		assert.NotNil(t, readConfigMap(t, k, "a"))
+		assert.NotNil(t, readConfigMap(t, k, "b"))
+		assert.Nil(t, readConfigMap(t, k, "missing"))
+		assert.Equal(t, []string{"get", "get", "get"}, verbs(client))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		k, client := newCacheRuntime("a", "b", "c", "d", "e")
+		k.EnableReadCache()
+		// resources are got one by one until reads reach the threshold
+		for _, name := range []string{"b", "c", "d"} {
+			assert.NotNil(t, readConfigMap(t, k, name))
+		}
+		assert.Nil(t, readConfigMap(t, k, "missing"))
+		a := readConfigMap(t, k, "a")
+		require.NotNil(t, a)
+		assert.Equal(t, "a", a.Attributes["metadata"].(map[string]interface{})["name"])
+		assert.NotNil(t, readConfigMap(t, k, "e"))
+		assert.Nil(t, readConfigMap(t, k, "missing"))
+		assert.Equal(t, []string{"get", "get", "get", "get", "list"}, verbs(client))
+
+		// resources read are copies of the cache
+		delete(a.Attributes, "metadata")
+		assert.NotNil(t, readConfigMap(t, k, "a").Attributes["metadata"])
+	})
+
+	t.Run("list forbidden", func(t *testing.T) {
+		k, client := newCacheRuntime("a")
+		client.PrependReactor("list", "configmaps", func(k8stesting.Action) (bool, k8sruntime.Object, error) {
+			return true, nil, k8serrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "", nil)
+		})
+		k.EnableReadCache()
+		for i := 0; i < listThreshold; i++ {
+			assert.NotNil(t, readConfigMap(t, k, "a"))
+		}
+		assert.Nil(t, readConfigMap(t, k, "missing"))
+		assert.Equal(t, []string{"get", "get", "get", "get", "list", "get", "get"}, verbs(client))
+	})
+
+	t.Run("secrets are never listed", func(t *testing.T) {
+		c := &readCache{lists: map[listKey]*cachedList{}}
+		secret := &unstructured.Unstructured{}
+		secret.SetAPIVersion("v1")
+		secret.SetKind("Secret")
+		secret.SetName("token")
+		for i := 0; i < listThreshold; i++ {
+			_, cached := c.get(context.Background(), nil, secret)
+			assert.False(t, cached)
+		}
+		assert.Empty(t, c.lists)
+	})
+}
@@ -0,0 +1,514 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/log"
+)
+
+var (
+	resourceQuotaGVR = corev1.SchemeGroupVersion.WithResource("resourcequotas")
+	nodeGVR          = corev1.SchemeGroupVersion.WithResource("nodes")
+	podGVR           = corev1.SchemeGroupVersion.WithResource("pods")
+)
+
+// quotaAliases are names of quota resources which are the same as other ones
+var quotaAliases = map[corev1.ResourceName]corev1.ResourceName{
+	corev1.ResourceCPU:    corev1.ResourceRequestsCPU,
+	corev1.ResourceMemory: corev1.ResourceRequestsMemory,
+}
+
+// CapacityRequest is a Kubernetes resource to be created or updated, along with its live state
+type CapacityRequest struct {
+	// Live is the live resource, which is nil if the resource will be created
+	Live *models.Resource
+
+	// Plan is the planned resource
+	Plan *models.Resource
+}
+
+// CapacityIssue describes a shortage of quotas or node capacity for the planned resources
+type CapacityIssue struct {
+	Message string
+
+	// Blocking is true if pods can never be scheduled or created, e.g. a ResourceQuota is exceeded or
+	// no node is large enough, otherwise pods may be Pending until more capacity is available
+	Blocking bool
+}
+
+func (i CapacityIssue) String() string {
+	return i.Message
+}
+
+// CheckCapacity sums CPU, memory and storage requested by the planned resources, and compares them against
+// ResourceQuotas of their namespaces and allocatable capacities of schedulable nodes. Only increases over the
+// live resources are counted, since the live ones are already accounted by the cluster. ResourceQuotas and
+// nodes which the current identity is not allowed to read are not checked.
+func (k *KubernetesRuntime) CheckCapacity(ctx context.Context, requests []CapacityRequest) ([]CapacityIssue, error) {
+	namespaces := map[string]corev1.ResourceList{}
+	var totals corev1.ResourceList
+	type podDemand struct {
+		id       string
+		requests corev1.ResourceList
+	}
+	var pods []podDemand
+	for _, r := range requests {
+		if r.Plan == nil || r.Plan.Type != runtime.Kubernetes {
+			continue
+		}
+		planned, pod, err := demandOf(r.Plan)
+		if err != nil {
+			return nil, fmt.Errorf("compute requested resources of %s failed: %w", r.Plan.ID, err)
+		}
+		if planned == nil {
+			continue
+		}
+		if pod != nil {
+			pods = append(pods, podDemand{id: r.Plan.ID, requests: pod})
+		}
+		var live corev1.ResourceList
+		if r.Live != nil {
+			if live, _, err = demandOf(r.Live); err != nil {
+				return nil, fmt.Errorf("compute requested resources of %s failed: %w", r.Live.ID, err)
+			}
+		}
+		delta := subtractPositive(planned, live)
+
+		ns := (&unstructured.Unstructured{Object: r.Plan.Attributes}).GetNamespace()
+		if ns == "" {
+			ns = metav1.NamespaceDefault
+		}
+		namespaces[ns] = addResources(namespaces[ns], delta)
+		totals = addResources(totals, delta)
+	}
+	if len(namespaces) == 0 {
+		return nil, nil
+	}
+
+	var issues []CapacityIssue
+	nsNames := make([]string, 0, len(namespaces))
+	for ns := range namespaces {
+		nsNames = append(nsNames, ns)
+	}
+	sort.Strings(nsNames)
+	for _, ns := range nsNames {
+		quotaIssues, err := k.checkQuotas(ctx, ns, namespaces[ns])
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, quotaIssues...)
+	}
+
+	nodes, err := k.listSchedulableNodes(ctx)
+	if err != nil || len(nodes) == 0 {
+		return issues, err
+	}
+	for _, p := range pods {
+		if !fitsAnyNode(p.requests, nodes) {
+			issues = append(issues, CapacityIssue{
+				Message:  fmt.Sprintf("pods of %s request %s, which fit no schedulable node", p.id, formatResources(p.requests)),
+				Blocking: true,
+			})
+		}
+	}
+
+	free, err := k.freeCapacity(ctx, nodes)
+	if err != nil || free == nil {
+		return issues, err
+	}
+	for _, name := range []corev1.ResourceName{corev1.ResourceRequestsCPU, corev1.ResourceRequestsMemory} {
+		requested, ok := totals[name]
+		nodeName := corev1.ResourceName(strings.TrimPrefix(string(name), "requests."))
+		available := free[nodeName]
+		if ok && requested.Cmp(available) > 0 {
+			issues = append(issues, CapacityIssue{
+				Message: fmt.Sprintf("%s %s requested by the changes exceeds %s available on schedulable nodes, "+
+					"pods may be Pending", nodeName, requested.String(), available.String()),
+			})
+		}
+	}
+	return issues, nil
+}
+
+// checkQuotas compares the requested resources with the remaining of ResourceQuotas in the namespace
+func (k *KubernetesRuntime) checkQuotas(ctx context.Context, namespace string, requested corev1.ResourceList) ([]CapacityIssue, error) {
+	list, err := k.client.Resource(resourceQuotaGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if k8serrors.IsForbidden(err) || k8serrors.IsNotFound(err) {
+			log.Infof("skip checking ResourceQuotas of the namespace %s: %v", namespace, err)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("list ResourceQuotas of the namespace %s failed: %w", namespace, err)
+	}
+
+	var issues []CapacityIssue
+	for _, item := range list.Items {
+		quota := &corev1.ResourceQuota{}
+		if err = k8sruntime.DefaultUnstructuredConverter.FromUnstructured(item.Object, quota); err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(quota.Spec.Hard))
+		for name := range quota.Spec.Hard {
+			names = append(names, string(name))
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			name := corev1.ResourceName(n)
+			key := name
+			if alias, ok := quotaAliases[name]; ok {
+				key = alias
+			}
+			want, ok := requested[key]
+			if !ok || want.IsZero() {
+				continue
+			}
+			remaining := quota.Spec.Hard[name].DeepCopy()
+			if used, ok := quota.Status.Used[name]; ok {
+				remaining.Sub(used)
+			}
+			if want.Cmp(remaining) > 0 {
+				issues = append(issues, CapacityIssue{
+					Message: fmt.Sprintf("%s %s requested in the namespace %s exceeds the remaining %s of the ResourceQuota %s",
+						name, want.String(), namespace, remaining.String(), quota.Name),
+					Blocking: true,
+				})
+			}
+		}
+	}
+	return issues, nil
+}
+
+// listSchedulableNodes returns nodes which are not cordoned, or nil if nodes are not allowed to list
+func (k *KubernetesRuntime) listSchedulableNodes(ctx context.Context) ([]*corev1.Node, error) {
+	list, err := k.client.Resource(nodeGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if k8serrors.IsForbidden(err) {
+			log.Infof("skip checking capacities of nodes: %v", err)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("list nodes failed: %w", err)
+	}
+	var nodes []*corev1.Node
+	for _, item := range list.Items {
+		node := &corev1.Node{}
+		if err = k8sruntime.DefaultUnstructuredConverter.FromUnstructured(item.Object, node); err != nil {
+			return nil, err
+		}
+		if !node.Spec.Unschedulable {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+// freeCapacity returns the allocatable CPU and memory of the nodes minus requests of pods running on them,
+// or nil if pods are not allowed to list
+func (k *KubernetesRuntime) freeCapacity(ctx context.Context, nodes []*corev1.Node) (corev1.ResourceList, error) {
+	free := corev1.ResourceList{}
+	nodeNames := map[string]bool{}
+	for _, node := range nodes {
+		nodeNames[node.Name] = true
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			if q, ok := node.Status.Allocatable[name]; ok {
+				addQuantity(free, name, q)
+			}
+		}
+	}
+
+	list, err := k.client.Resource(podGVR).List(ctx, metav1.ListOptions{
+		FieldSelector: "status.phase!=Succeeded,status.phase!=Failed",
+	})
+	if err != nil {
+		if k8serrors.IsForbidden(err) {
+			log.Infof("skip checking free capacities of nodes: %v", err)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("list pods failed: %w", err)
+	}
+	for _, item := range list.Items {
+		pod := &corev1.Pod{}
+		if err = k8sruntime.DefaultUnstructuredConverter.FromUnstructured(item.Object, pod); err != nil {
+			return nil, err
+		}
+		if !nodeNames[pod.Spec.NodeName] || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for name, q := range podRequests(&pod.Spec) {
+			if q2, ok := free[name]; ok {
+				q2.Sub(q)
+				free[name] = q2
+			}
+		}
+	}
+	return free, nil
+}
+
+func fitsAnyNode(requests corev1.ResourceList, nodes []*corev1.Node) bool {
+	for _, node := range nodes {
+		fits := true
+		for name, q := range requests {
+			if allocatable, ok := node.Status.Allocatable[name]; ok && q.Cmp(allocatable) > 0 {
+				fits = false
+				break
+			}
+		}
+		if fits {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestedResources returns resources requested by all pods and PersistentVolumeClaims of the Kubernetes
+// resource keyed by names of quota resources, e.g. requests.cpu, requests.memory and requests.storage. It
+// returns nil if the resource requests nothing.
+func RequestedResources(res *models.Resource) (corev1.ResourceList, error) {
+	total, _, err := demandOf(res)
+	return total, err
+}
+
+// demandOf returns resources requested by the Kubernetes resource keyed by names of quota resources, and
+// CPU and memory requested by each of its pods. Both are nil if the resource requests nothing.
+func demandOf(res *models.Resource) (corev1.ResourceList, corev1.ResourceList, error) {
+	obj := &unstructured.Unstructured{Object: res.Attributes}
+	var specPath []string
+	replicas := int64(1)
+	switch obj.GetKind() {
+	case "Pod":
+		specPath = []string{"spec"}
+	case "Deployment", "ReplicaSet", "StatefulSet", "ReplicationController":
+		specPath = []string{"spec", "template", "spec"}
+		if r, ok := nestedInt(obj.Object, "spec", "replicas"); ok {
+			replicas = r
+		}
+	case "Job":
+		specPath = []string{"spec", "template", "spec"}
+		if p, ok := nestedInt(obj.Object, "spec", "parallelism"); ok {
+			replicas = p
+		}
+	case "CronJob":
+		specPath = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+		if p, ok := nestedInt(obj.Object, "spec", "jobTemplate", "spec", "parallelism"); ok {
+			replicas = p
+		}
+	case "DaemonSet":
+		// the number of pods depends on nodes, only pods are checked if they fit nodes
+		specPath = []string{"spec", "template", "spec"}
+		replicas = 0
+	case "PersistentVolumeClaim":
+		storage, err := claimStorage(obj.Object)
+		if err != nil || storage == nil {
+			return nil, nil, err
+		}
+		return corev1.ResourceList{
+			corev1.ResourceRequestsStorage:        *storage,
+			corev1.ResourcePersistentVolumeClaims: *resource.NewQuantity(1, resource.DecimalSI),
+		}, nil, nil
+	default:
+		return nil, nil, nil
+	}
+
+	podSpecMap, ok, err := unstructured.NestedMap(obj.Object, specPath...)
+	if err != nil || !ok {
+		return nil, nil, err
+	}
+	podSpec := &corev1.PodSpec{}
+	if err = k8sruntime.DefaultUnstructuredConverter.FromUnstructured(integralNumbers(podSpecMap).(map[string]interface{}), podSpec); err != nil {
+		return nil, nil, err
+	}
+	pod := podRequests(podSpec)
+	limits := podLimits(podSpec)
+
+	total := corev1.ResourceList{corev1.ResourcePods: *resource.NewQuantity(replicas, resource.DecimalSI)}
+	for name, q := range pod {
+		total[corev1.ResourceName("requests."+string(name))] = multiply(q, replicas)
+	}
+	for name, q := range limits {
+		total[corev1.ResourceName("limits."+string(name))] = multiply(q, replicas)
+	}
+
+	if obj.GetKind() == "StatefulSet" {
+		templates, _, err := unstructured.NestedSlice(obj.Object, "spec", "volumeClaimTemplates")
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, t := range templates {
+			template, ok := t.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			storage, err := claimStorage(template)
+			if err != nil {
+				return nil, nil, err
+			}
+			if storage != nil {
+				addQuantity(total, corev1.ResourceRequestsStorage, multiply(*storage, replicas))
+				addQuantity(total, corev1.ResourcePersistentVolumeClaims, *resource.NewQuantity(replicas, resource.DecimalSI))
+			}
+		}
+	}
+	if len(pod) == 0 {
+		pod = nil
+	}
+	return total, pod, nil
+}
+
+// nestedInt returns the integer field, which may be decoded as any number type from JSON or YAML
+func nestedInt(obj map[string]interface{}, fields ...string) (int64, bool) {
+	value, ok, _ := unstructured.NestedFieldNoCopy(obj, fields...)
+	if !ok {
+		return 0, false
+	}
+	switch v := integralNumbers(value).(type) {
+	case int64:
+		return v, true
+	case int32:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// claimStorage returns the storage requested by the PersistentVolumeClaim
+func claimStorage(claim map[string]interface{}) (*resource.Quantity, error) {
+	value, ok, err := unstructured.NestedFieldNoCopy(claim, "spec", "resources", "requests", "storage")
+	if err != nil || !ok {
+		return nil, err
+	}
+	q, err := resource.ParseQuantity(fmt.Sprint(value))
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// podRequests returns CPU and memory requested by the pod, which are the larger of the sum of containers
+// and the largest init container. Limits are used if requests are not specified, as the cluster does.
+func podRequests(spec *corev1.PodSpec) corev1.ResourceList {
+	return podResources(spec, func(r corev1.ResourceRequirements) corev1.ResourceList {
+		requests := corev1.ResourceList{}
+		for name, q := range r.Limits {
+			requests[name] = q
+		}
+		for name, q := range r.Requests {
+			requests[name] = q
+		}
+		return requests
+	})
+}
+
+// podLimits returns CPU and memory limits of the pod
+func podLimits(spec *corev1.PodSpec) corev1.ResourceList {
+	return podResources(spec, func(r corev1.ResourceRequirements) corev1.ResourceList {
+		return r.Limits
+	})
+}
+
+func podResources(spec *corev1.PodSpec, get func(corev1.ResourceRequirements) corev1.ResourceList) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for _, c := range spec.Containers {
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			if q, ok := get(c.Resources)[name]; ok {
+				addQuantity(result, name, q)
+			}
+		}
+	}
+	for _, c := range spec.InitContainers {
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			if q, ok := get(c.Resources)[name]; ok {
+				if current, ok := result[name]; !ok || q.Cmp(current) > 0 {
+					result[name] = q.DeepCopy()
+				}
+			}
+		}
+	}
+	return result
+}
+
+// integralNumbers converts float64 numbers without fractions into int64, since the converter does not
+// accept floats for integer fields
+func integralNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = integralNumbers(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = integralNumbers(item)
+		}
+		return result
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+		return v
+	case int:
+		return int64(v)
+	default:
+		return v
+	}
+}
+
+func multiply(q resource.Quantity, n int64) resource.Quantity {
+	return *resource.NewMilliQuantity(q.MilliValue()*n, q.Format)
+}
+
+func addQuantity(list corev1.ResourceList, name corev1.ResourceName, q resource.Quantity) {
+	if current, ok := list[name]; ok {
+		current.Add(q)
+		list[name] = current
+		return
+	}
+	list[name] = q.DeepCopy()
+}
+
+func addResources(list, other corev1.ResourceList) corev1.ResourceList {
+	if list == nil {
+		list = corev1.ResourceList{}
+	}
+	for name, q := range other {
+		addQuantity(list, name, q)
+	}
+	return list
+}
+
+// subtractPositive returns the increases of the planned resources over the live ones
+func subtractPositive(planned, live corev1.ResourceList) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for name, q := range planned {
+		delta := q.DeepCopy()
+		if l, ok := live[name]; ok {
+			delta.Sub(l)
+		}
+		if delta.Sign() > 0 {
+			result[name] = delta
+		}
+	}
+	return result
+}
+
+func formatResources(list corev1.ResourceList) string {
+	var parts []string
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if q, ok := list[name]; ok {
+			parts = append(parts, fmt.Sprintf("%s %s", name, q.String()))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
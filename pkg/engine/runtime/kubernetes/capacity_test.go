@@ -0,0 +1,120 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+)
+
+func newDeployment(name string, replicas int, cpu, memory string) models.Resource {
+	return newK8sResource(name, "apps/v1", "Deployment", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": name, "namespace": "app"},
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name": "main",
+							"resources": map[string]interface{}{
+								"requests": map[string]interface{}{"cpu": cpu, "memory": memory},
+								"limits":   map[string]interface{}{"cpu": cpu, "memory": memory},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+func newUnstructured(object map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: object}
+}
+
+func TestKubernetesRuntime_CheckCapacity(t *testing.T) {
+	quota := newUnstructured(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ResourceQuota",
+		"metadata":   map[string]interface{}{"name": "compute", "namespace": "app"},
+		"spec":       map[string]interface{}{"hard": map[string]interface{}{"cpu": "4", "requests.storage": "10Gi"}},
+		"status":     map[string]interface{}{"used": map[string]interface{}{"cpu": "2", "requests.storage": "0"}},
+	})
+	node := newUnstructured(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Node",
+		"metadata":   map[string]interface{}{"name": "node1"},
+		"status":     map[string]interface{}{"allocatable": map[string]interface{}{"cpu": "8", "memory": "16Gi"}},
+	})
+	cordoned := newUnstructured(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Node",
+		"metadata":   map[string]interface{}{"name": "node2"},
+		"spec":       map[string]interface{}{"unschedulable": true},
+		"status":     map[string]interface{}{"allocatable": map[string]interface{}{"cpu": "64", "memory": "256Gi"}},
+	})
+	running := newUnstructured(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "running", "namespace": "other"},
+		"spec": map[string]interface{}{
+			"nodeName": "node1",
+			"containers": []interface{}{
+				map[string]interface{}{"name": "main", "resources": map[string]interface{}{
+					"requests": map[string]interface{}{"cpu": "6", "memory": "1Gi"},
+				}},
+			},
+		},
+		"status": map[string]interface{}{"phase": "Running"},
+	})
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(k8sruntime.NewScheme(), map[schema.GroupVersionResource]string{
+		resourceQuotaGVR: "ResourceQuotaList",
+		nodeGVR:          "NodeList",
+		podGVR:           "PodList",
+	}, quota, node, cordoned, running)
+	k := &KubernetesRuntime{client: client}
+
+	live := newDeployment("web", 1, "1", "1Gi")
+	web := newDeployment("web", 3, "1", "1Gi")
+	huge := newDeployment("huge", 1, "16", "1Gi")
+	pvc := newK8sResource("data", "v1", "PersistentVolumeClaim", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "data", "namespace": "app"},
+		"spec": map[string]interface{}{
+			"resources": map[string]interface{}{"requests": map[string]interface{}{"storage": "5Gi"}},
+		},
+	})
+	cm := newK8sResource("cm", "v1", "ConfigMap", nil)
+
+	issues, err := k.CheckCapacity(context.Background(), []CapacityRequest{
+		{Live: &live, Plan: &web},
+		{Plan: &huge},
+		{Plan: &pvc},
+		{Plan: &cm},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []CapacityIssue{
+		{Message: "cpu 18 requested in the namespace app exceeds the remaining 2 of the ResourceQuota compute", Blocking: true},
+		{Message: "pods of huge request cpu 16, memory 1Gi, which fit no schedulable node", Blocking: true},
+		{Message: "cpu 18 requested by the changes exceeds 2 available on schedulable nodes, pods may be Pending"},
+	}, issues)
+
+	issues, err = k.CheckCapacity(context.Background(), []CapacityRequest{{Live: &web, Plan: &live}, {Plan: &cm}})
+	assert.Nil(t, err)
+	assert.Empty(t, issues)
+
+	client.PrependReactor("list", "*", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, nil, k8serrors.NewForbidden(schema.GroupResource{Resource: action.GetResource().Resource}, "", nil)
+	})
+	issues, err = k.CheckCapacity(context.Background(), []CapacityRequest{{Plan: &huge}})
+	assert.Nil(t, err)
+	assert.Empty(t, issues)
+}
@@ -0,0 +1,65 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+
+	"kusionstack.io/kusion/pkg/log"
+)
+
+const crdGroup = "apiextensions.k8s.io"
+
+var (
+	// crdEstablishedTimeout is how long applying a CRD waits for it to be established
+	crdEstablishedTimeout = time.Minute
+	// crdEstablishedInterval is the interval of checking whether a CRD is established
+	crdEstablishedInterval = time.Second
+)
+
+// isCRD returns true if the object is a CustomResourceDefinition
+func isCRD(obj *unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	return gvk.Group == crdGroup && gvk.Kind == crdKind
+}
+
+// crdEstablished returns true if the Established condition of the CRD is true, after which its custom resources
+// can be created
+func crdEstablished(obj *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == "Established" {
+			return condition["status"] == string(metav1.ConditionTrue)
+		}
+	}
+	return false
+}
+
+// waitCRDEstablished waits for the CRD of the name to be established, so that custom resources applied after it
+// in the same apply are accepted by the cluster
+func waitCRDEstablished(ctx context.Context, resource dynamic.ResourceInterface, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, crdEstablishedTimeout)
+	defer cancel()
+
+	log.Infof("waiting for CRD %s to be established", name)
+	err := wait.PollImmediateUntilWithContext(ctx, crdEstablishedInterval, func(ctx context.Context) (bool, error) {
+		obj, err := resource.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return crdEstablished(obj), nil
+	})
+	if err != nil {
+		return fmt.Errorf("CRD %s is not established: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+var crdGVR = schema.GroupVersionResource{Group: crdGroup, Version: "v1", Resource: "customresourcedefinitions"}
+
+func newCRD(established string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       crdKind,
+		"metadata":   map[string]interface{}{"name": "foos.example.com"},
+	}}
+	if established != "" {
+		obj.Object["status"] = map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "NamesAccepted", "status": "True"},
+				map[string]interface{}{"type": "Established", "status": established},
+			},
+		}
+	}
+	return obj
+}
+
+func TestCRDEstablished(t *testing.T) {
+	assert.True(t, isCRD(newCRD("")))
+	assert.False(t, isCRD(&unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Pod"}}))
+
+	assert.True(t, crdEstablished(newCRD("True")))
+	assert.False(t, crdEstablished(newCRD("False")))
+	assert.False(t, crdEstablished(newCRD("")))
+}
+
+func TestWaitCRDEstablished(t *testing.T) {
+	defer func(timeout, interval time.Duration) {
+		crdEstablishedTimeout, crdEstablishedInterval = timeout, interval
+	}(crdEstablishedTimeout, crdEstablishedInterval)
+	crdEstablishedTimeout, crdEstablishedInterval = 50*time.Millisecond, 10*time.Millisecond
+
+	t.Run("established", func(t *testing.T) {
+		client := fake.NewSimpleDynamicClient(k8sruntime.NewScheme(), newCRD("True"))
+		err := waitCRDEstablished(context.Background(), client.Resource(crdGVR), "foos.example.com")
+		assert.Nil(t, err)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		client := fake.NewSimpleDynamicClient(k8sruntime.NewScheme(), newCRD("False"))
+		err := waitCRDEstablished(context.Background(), client.Resource(crdGVR), "foos.example.com")
+		assert.ErrorContains(t, err, "CRD foos.example.com is not established")
+	})
+}
+
+func TestKubernetesRuntime_ApplyDryRunUndefinedKind(t *testing.T) {
+	k := &KubernetesRuntime{mapper: meta.NewDefaultRESTMapper(nil)}
+	foo := newK8sResource("foo", "example.com/v1", "Foo", nil)
+
+	response := k.Apply(context.Background(), &runtime.ApplyRequest{PlanResource: &foo, DryRun: true})
+	assert.Nil(t, response.Status)
+	assert.Equal(t, &foo, response.Resource)
+
+	response = k.Apply(context.Background(), &runtime.ApplyRequest{PlanResource: &foo})
+	assert.NotNil(t, response.Status)
+}
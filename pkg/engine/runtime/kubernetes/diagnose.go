@@ -0,0 +1,274 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+var _ runtime.Diagnoser = (*KubernetesRuntime)(nil)
+
+// Limits of the diagnosis, which keep error messages readable
+var (
+	maxDiagnosedEvents = 10
+	maxDiagnosedPods   = 3
+	diagnosedLogLines  = int64(20)
+)
+
+// Diagnose gathers recent warning events of the resource, and for workloads the statuses, warning events and
+// the last lines of container logs of their unhealthy pods, so that a timed out rollout can be explained.
+// Logs of the previous instances are read for containers which have restarted, since they usually crashed.
+func (k *KubernetesRuntime) Diagnose(ctx context.Context, resource *models.Resource) (string, error) {
+	if k.clientset == nil {
+		return "", nil
+	}
+	obj, ri, err := k.buildKubernetesResourceByState(resource)
+	if err != nil {
+		return "", err
+	}
+	b := &strings.Builder{}
+	live, err := ri.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return "", err
+		}
+		fmt.Fprintf(b, "%s %s is not found\n", obj.GetKind(), objectName(obj))
+		return b.String(), nil
+	}
+
+	if err = k.writeEvents(ctx, b, live.GetKind(), live.GetNamespace(), live.GetName(), ""); err != nil {
+		return "", err
+	}
+
+	pods, err := k.workloadPods(ctx, live)
+	if err != nil {
+		return "", err
+	}
+	diagnosed := 0
+	for i := range pods {
+		pod := &pods[i]
+		if podHealthy(pod) {
+			continue
+		}
+		if diagnosed == maxDiagnosedPods {
+			fmt.Fprintf(b, "... and more unhealthy pods\n")
+			break
+		}
+		diagnosed++
+		k.writePod(ctx, b, pod)
+	}
+	return b.String(), nil
+}
+
+// workloadPods returns pods of the workload selected by its selector, or the object itself if it is a pod
+func (k *KubernetesRuntime) workloadPods(ctx context.Context, obj *unstructured.Unstructured) ([]corev1.Pod, error) {
+	if obj.GetKind() == "Pod" {
+		pod := corev1.Pod{}
+		if err := k8sruntime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &pod); err != nil {
+			return nil, err
+		}
+		return []corev1.Pod{pod}, nil
+	}
+
+	raw, found, _ := unstructured.NestedMap(obj.Object, "spec", "selector")
+	if !found {
+		return nil, nil
+	}
+	selector := &metav1.LabelSelector{}
+	if err := k8sruntime.DefaultUnstructuredConverter.FromUnstructured(raw, selector); err != nil {
+		// the selector of the workload is not a label selector, e.g. selector of services
+		return nil, nil
+	}
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil || labelSelector.Empty() {
+		return nil, nil
+	}
+	pods, err := k.clientset.CoreV1().Pods(obj.GetNamespace()).List(ctx, metav1.ListOptions{
+		LabelSelector: labelSelector.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list pods of %s %s failed: %w", obj.GetKind(), objectName(obj), err)
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].Name < pods.Items[j].Name
+	})
+	return pods.Items, nil
+}
+
+// writeEvents writes recent warning events of the object, the latest last
+func (k *KubernetesRuntime) writeEvents(ctx context.Context, b *strings.Builder, kind, namespace, name, indent string) error {
+	warnings, err := k.warningEvents(ctx, kind, namespace, name)
+	if err != nil {
+		return err
+	}
+	if len(warnings) == 0 {
+		return nil
+	}
+	if len(warnings) > maxDiagnosedEvents {
+		warnings = warnings[len(warnings)-maxDiagnosedEvents:]
+	}
+
+	fmt.Fprintf(b, "%sEvents of %s %s:\n", indent, kind, qualifiedName(namespace, name))
+	for _, e := range warnings {
+		count := ""
+		if e.Count > 1 {
+			count = fmt.Sprintf(" (x%d)", e.Count)
+		}
+		fmt.Fprintf(b, "%s  %s %s%s: %s\n", indent, e.Type, e.Reason, count, strings.TrimSpace(e.Message))
+	}
+	return nil
+}
+
+// warningEvents returns warning events of the object, the latest last
+func (k *KubernetesRuntime) warningEvents(ctx context.Context, kind, namespace, name string) ([]corev1.Event, error) {
+	events, err := k.clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("involvedObject.kind=%s,involvedObject.name=%s", kind, name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list events of %s %s/%s failed: %w", kind, namespace, name, err)
+	}
+
+	var warnings []corev1.Event
+	for _, e := range events.Items {
+		if e.InvolvedObject.Kind == kind && e.InvolvedObject.Name == name && e.Type == corev1.EventTypeWarning {
+			warnings = append(warnings, e)
+		}
+	}
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return eventTime(&warnings[i]).Before(eventTime(&warnings[j]))
+	})
+	return warnings, nil
+}
+
+// writePod writes why the pod is unhealthy, its warning events and logs of its unready containers
+func (k *KubernetesRuntime) writePod(ctx context.Context, b *strings.Builder, pod *corev1.Pod) {
+	fmt.Fprintf(b, "Pod %s is %s:\n", qualifiedName(pod.Namespace, pod.Name), podPhase(pod))
+	for _, c := range pod.Status.Conditions {
+		if c.Status != corev1.ConditionTrue && c.Message != "" {
+			fmt.Fprintf(b, "  %s: %s\n", c.Type, c.Message)
+		}
+	}
+	if err := k.writeEvents(ctx, b, "Pod", pod.Namespace, pod.Name, "  "); err != nil {
+		fmt.Fprintf(b, "  %v\n", err)
+	}
+
+	statuses := make([]corev1.ContainerStatus, 0, len(pod.Status.InitContainerStatuses)+len(pod.Status.ContainerStatuses))
+	statuses = append(append(statuses, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		if cs.Ready || cs.State.Terminated != nil && cs.State.Terminated.ExitCode == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "  Container %s%s\n", cs.Name, containerState(&cs))
+		if cs.State.Waiting != nil && cs.RestartCount == 0 && cs.LastTerminationState.Terminated == nil {
+			// the container has never started, e.g. its image can not be pulled
+			continue
+		}
+
+		previous := cs.RestartCount > 0 && cs.State.Running == nil
+		data, err := k.clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+			Container: cs.Name,
+			TailLines: &diagnosedLogLines,
+			Previous:  previous,
+		}).Do(ctx).Raw()
+		if err != nil {
+			fmt.Fprintf(b, "    failed to read logs: %v\n", err)
+			continue
+		}
+		logs := strings.TrimRight(string(data), "\n")
+		if logs == "" {
+			continue
+		}
+		which := "logs"
+		if previous {
+			which = "logs of the previous instance"
+		}
+		fmt.Fprintf(b, "    Last %d lines of %s:\n", diagnosedLogLines, which)
+		for _, line := range strings.Split(logs, "\n") {
+			fmt.Fprintf(b, "      %s\n", line)
+		}
+	}
+}
+
+// podHealthy returns true if the pod has completed, or is running with all containers ready
+func podHealthy(pod *corev1.Pod) bool {
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return true
+	case corev1.PodRunning:
+		for _, cs := range pod.Status.ContainerStatuses {
+			if !cs.Ready {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func podPhase(pod *corev1.Pod) string {
+	phase := strings.ToLower(string(pod.Status.Phase))
+	if phase == "" {
+		phase = "pending"
+	}
+	if pod.Status.Reason != "" {
+		phase += " (" + pod.Status.Reason + ")"
+	}
+	return phase
+}
+
+// containerState describes the current state and the last termination of the container
+func containerState(cs *corev1.ContainerStatus) string {
+	var s string
+	switch {
+	case cs.State.Waiting != nil:
+		s = " is waiting: " + cs.State.Waiting.Reason
+		if cs.State.Waiting.Message != "" {
+			s += ", " + cs.State.Waiting.Message
+		}
+	case cs.State.Terminated != nil:
+		s = fmt.Sprintf(" terminated with exit code %d: %s", cs.State.Terminated.ExitCode, cs.State.Terminated.Reason)
+	default:
+		s = " is not ready"
+	}
+	if cs.RestartCount > 0 {
+		s += fmt.Sprintf(", restarted %d times", cs.RestartCount)
+		if t := cs.LastTerminationState.Terminated; t != nil {
+			s += fmt.Sprintf(", last terminated with exit code %d: %s", t.ExitCode, t.Reason)
+		}
+	}
+	return s
+}
+
+func eventTime(e *corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	default:
+		return e.CreationTimestamp.Time
+	}
+}
+
+func objectName(obj *unstructured.Unstructured) string {
+	return qualifiedName(obj.GetNamespace(), obj.GetName())
+}
+
+func qualifiedName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
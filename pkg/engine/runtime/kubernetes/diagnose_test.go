@@ -0,0 +1,104 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestKubernetesRuntime_Diagnose(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+	web := newK8sResource("web", "apps/v1", "Deployment", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web", "namespace": "app"},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+		},
+	})
+	missing := newK8sResource("missing", "apps/v1", "Deployment", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "missing", "namespace": "app"},
+	})
+	client := fake.NewSimpleDynamicClient(k8sruntime.NewScheme(), newUnstructured(web.Attributes))
+
+	clientset := kubefake.NewSimpleClientset(
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "e1", Namespace: "app"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Deployment", Name: "web"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "FailedCreate",
+			Message:        "exceeded quota",
+			Count:          3,
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "e2", Namespace: "app"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-crash"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "BackOff",
+			Message:        "Back-off restarting failed container",
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "e3", Namespace: "app"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-crash"},
+			Type:           corev1.EventTypeNormal,
+			Reason:         "Pulled",
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-crash", Namespace: "app", Labels: map[string]string{"app": "web"}},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:                 "main",
+					RestartCount:         2,
+					State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+					LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"}},
+				}},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-pull", Namespace: "app", Labels: map[string]string{"app": "web"}},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "main",
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+				}},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-ready", Namespace: "app", Labels: map[string]string{"app": "web"}},
+			Status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "main", Ready: true}},
+			},
+		},
+	)
+	k := &KubernetesRuntime{client: client, mapper: mapper, clientset: clientset}
+
+	diagnosis, err := k.Diagnose(context.Background(), &web)
+	assert.Nil(t, err)
+	assert.Contains(t, diagnosis, "Events of Deployment app/web:\n  Warning FailedCreate (x3): exceeded quota\n")
+	assert.Contains(t, diagnosis, "Pod app/web-crash is running:\n")
+	assert.Contains(t, diagnosis, "    Warning BackOff: Back-off restarting failed container\n")
+	assert.NotContains(t, diagnosis, "Pulled")
+	assert.Contains(t, diagnosis, "  Container main is waiting: CrashLoopBackOff, restarted 2 times, "+
+		"last terminated with exit code 1: Error\n    Last 20 lines of logs of the previous instance:\n      fake logs\n")
+	assert.Contains(t, diagnosis, "Pod app/web-pull is pending:\n  Container main is waiting: ImagePullBackOff\n")
+	assert.NotContains(t, diagnosis, "web-ready")
+
+	diagnosis, err = k.Diagnose(context.Background(), &missing)
+	assert.Nil(t, err)
+	assert.Equal(t, "Deployment app/missing is not found\n", diagnosis)
+
+	diagnosis, err = (&KubernetesRuntime{}).Diagnose(context.Background(), &web)
+	assert.Nil(t, err)
+	assert.Empty(t, diagnosis)
+}
@@ -0,0 +1,109 @@
+package kubernetes
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+var _ runtime.EventLister = (*KubernetesRuntime)(nil)
+
+// listedEventReasons are reasons of warning events which explain why resources are stuck. Other warnings, e.g.
+// failed updates of endpoints, are left to Diagnose.
+var listedEventReasons = map[string]bool{
+	"FailedScheduling": true, // no node fits the pod
+	"Failed":           true, // e.g. ErrImagePull, or containers fail to be created
+	"ErrImagePull":     true,
+	"ImagePullBackOff": true,
+	"BackOff":          true, // back-off pulling images or restarting crashed containers
+	"Unhealthy":        true, // liveness or readiness probes fail
+	"FailedMount":      true, // volumes can not be mounted, e.g. secrets not found
+	"FailedCreate":     true, // pods can not be created, e.g. by quotas
+}
+
+// ListEvents returns warning events of the resource, and for workloads of their unhealthy pods, whose reasons
+// explain why the resource is stuck, e.g. FailedScheduling, ImagePullBackOff and Unhealthy
+func (k *KubernetesRuntime) ListEvents(ctx context.Context, resource *models.Resource, since time.Time) ([]runtime.Event, error) {
+	if k.clientset == nil {
+		return nil, nil
+	}
+	obj, ri, err := k.buildKubernetesResourceByState(resource)
+	if err != nil {
+		return nil, err
+	}
+	live, err := ri.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	events, err := k.listEvents(ctx, live.GetKind(), live.GetNamespace(), live.GetName(), since)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := k.workloadPods(ctx, live)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods {
+		pod := &pods[i]
+		if podHealthy(pod) {
+			continue
+		}
+		podEvents, err := k.listEvents(ctx, "Pod", pod.Namespace, pod.Name, since)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, podEvents...)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events, nil
+}
+
+// listEvents returns listed warning events of the object which occurred since the time
+func (k *KubernetesRuntime) listEvents(ctx context.Context, kind, namespace, name string, since time.Time) ([]runtime.Event, error) {
+	warnings, err := k.warningEvents(ctx, kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	var events []runtime.Event
+	for i := range warnings {
+		e := &warnings[i]
+		if !listedEventReasons[e.Reason] || eventTime(e).Before(since) {
+			continue
+		}
+		events = append(events, runtime.Event{
+			Object:  kind + " " + qualifiedName(namespace, name),
+			Reason:  e.Reason,
+			Message: strings.TrimSpace(e.Message),
+			Count:   eventCount(e),
+			Time:    eventTime(e),
+		})
+	}
+	return events, nil
+}
+
+// eventCount returns how many times the event has occurred. Events recorded by the events.k8s.io API leave the
+// count empty and count by their series instead.
+func eventCount(e *corev1.Event) int32 {
+	switch {
+	case e.Count > 0:
+		return e.Count
+	case e.Series != nil:
+		return e.Series.Count
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,126 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+func TestKubernetesRuntime_ListEvents(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+	web := newK8sResource("web", "apps/v1", "Deployment", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web", "namespace": "app"},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+		},
+	})
+	missing := newK8sResource("missing", "apps/v1", "Deployment", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "missing", "namespace": "app"},
+	})
+	client := fake.NewSimpleDynamicClient(k8sruntime.NewScheme(), newUnstructured(web.Attributes))
+
+	since := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) metav1.Time {
+		return metav1.NewTime(since.Add(d))
+	}
+	clientset := kubefake.NewSimpleClientset(
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "old", Namespace: "app"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-pending"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "FailedScheduling",
+			Message:        "old",
+			LastTimestamp:  at(-time.Minute),
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "scheduling", Namespace: "app"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-pending"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "FailedScheduling",
+			Message:        "0/3 nodes are available: 3 Insufficient cpu. ",
+			Count:          2,
+			LastTimestamp:  at(2 * time.Second),
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "pull", Namespace: "app"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-pending"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "BackOff",
+			Message:        "Back-off pulling image \"web:v2\"",
+			LastTimestamp:  at(time.Second),
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "pulled", Namespace: "app"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-pending"},
+			Type:           corev1.EventTypeNormal,
+			Reason:         "Pulled",
+			LastTimestamp:  at(time.Second),
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "endpoints", Namespace: "app"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Deployment", Name: "web"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "FailedToUpdateEndpoint",
+			LastTimestamp:  at(time.Second),
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "probe", Namespace: "app"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-ready"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "Unhealthy",
+			LastTimestamp:  at(time.Second),
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-pending", Namespace: "app", Labels: map[string]string{"app": "web"}},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-ready", Namespace: "app", Labels: map[string]string{"app": "web"}},
+			Status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "main", Ready: true}},
+			},
+		},
+	)
+	k := &KubernetesRuntime{client: client, mapper: mapper, clientset: clientset}
+
+	events, err := k.ListEvents(context.Background(), &web, since)
+	assert.Nil(t, err)
+	assert.Equal(t, []runtime.Event{
+		{
+			Object:  "Pod app/web-pending",
+			Reason:  "BackOff",
+			Message: "Back-off pulling image \"web:v2\"",
+			Count:   1,
+			Time:    at(time.Second).Time,
+		},
+		{
+			Object:  "Pod app/web-pending",
+			Reason:  "FailedScheduling",
+			Message: "0/3 nodes are available: 3 Insufficient cpu.",
+			Count:   2,
+			Time:    at(2 * time.Second).Time,
+		},
+	}, events)
+
+	events, err = k.ListEvents(context.Background(), &missing, since)
+	assert.Nil(t, err)
+	assert.Empty(t, events)
+
+	events, err = (&KubernetesRuntime{}).ListEvents(context.Background(), &web, since)
+	assert.Nil(t, err)
+	assert.Empty(t, events)
+}
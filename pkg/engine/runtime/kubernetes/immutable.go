@@ -0,0 +1,128 @@
+package kubernetes
+
+import (
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+var _ runtime.ReplacementDetector = (*KubernetesRuntime)(nil)
+
+// immutableFields are paths of fields which can not be changed once the resource is created
+var immutableFields = map[schema.GroupKind][]string{
+	{Kind: "Service"}:                                                {"spec.clusterIP"},
+	{Kind: "PersistentVolumeClaim"}:                                  {"spec.storageClassName", "spec.accessModes", "spec.selector", "spec.volumeMode", "spec.volumeName"},
+	{Kind: "Secret"}:                                                 {"type"},
+	{Group: "apps", Kind: "Deployment"}:                              {"spec.selector"},
+	{Group: "apps", Kind: "ReplicaSet"}:                              {"spec.selector"},
+	{Group: "apps", Kind: "DaemonSet"}:                               {"spec.selector"},
+	{Group: "apps", Kind: "StatefulSet"}:                             {"spec.selector", "spec.serviceName", "spec.volumeClaimTemplates", "spec.podManagementPolicy"},
+	{Group: "batch", Kind: "Job"}:                                    {"spec.selector", "spec.template", "spec.completions", "spec.completionMode"},
+	{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"}:        {"roleRef"},
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}: {"roleRef"},
+	{Group: "storage.k8s.io", Kind: "StorageClass"}:                  {"provisioner", "parameters", "reclaimPolicy", "volumeBindingMode"},
+}
+
+// immutableDataFields are fields of ConfigMaps and Secrets marked as immutable
+var immutableDataFields = []string{"data", "binaryData", "stringData"}
+
+// defaultedFields are immutable fields whose nested fields are defaulted by the cluster, so they are compared
+// as subsets of the live values. Other fields are compared for equality, e.g. a label removed from a selector
+// is a change.
+var defaultedFields = map[string]bool{
+	"spec.template":             true,
+	"spec.volumeClaimTemplates": true,
+}
+
+// RequiresReplacement returns paths of immutable fields which are specified in the planned resource and differ
+// from the live one. Fields omitted in the planned resource are kept or defaulted by the cluster, so they are
+// not changes.
+func (k *KubernetesRuntime) RequiresReplacement(live, plan *models.Resource) []string {
+	if live == nil || plan == nil {
+		return nil
+	}
+	return ImmutableChanges(live.Attributes, plan.Attributes)
+}
+
+// ImmutableChanges returns paths of immutable fields which are specified in the planned object and differ
+// from the live one
+func ImmutableChanges(live, plan map[string]interface{}) []string {
+	gvk := (&unstructured.Unstructured{Object: plan}).GroupVersionKind()
+	fields := immutableFields[gvk.GroupKind()]
+	if gvk.Group == "" && (gvk.Kind == "ConfigMap" || gvk.Kind == "Secret") {
+		if immutable, _, _ := unstructured.NestedBool(live, "immutable"); immutable {
+			fields = append(append([]string{}, fields...), immutableDataFields...)
+		}
+	}
+
+	var changed []string
+	for _, field := range fields {
+		path := strings.Split(field, ".")
+		planValue, found, _ := unstructured.NestedFieldNoCopy(plan, path...)
+		if !found {
+			continue
+		}
+		liveValue, _, _ := unstructured.NestedFieldNoCopy(live, path...)
+		if defaultedFields[field] && !isSubset(planValue, liveValue) ||
+			!defaultedFields[field] && !isEqual(planValue, liveValue) {
+			changed = append(changed, field)
+		}
+	}
+	return changed
+}
+
+// isSubset returns true if every field specified in the planned value equals the one in the live value. Fields
+// only in the live value are defaulted by the cluster, e.g. terminationMessagePath of containers, so they are
+// not changes.
+func isSubset(plan, live interface{}) bool {
+	switch p := plan.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, v := range p {
+			if !isSubset(v, l[k]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok || len(l) != len(p) {
+			return false
+		}
+		for i := range p {
+			if !isSubset(p[i], l[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(normalizeNumber(plan), normalizeNumber(live))
+	}
+}
+
+// isEqual returns true if the planned value equals the live value, regardless of types of numbers
+func isEqual(plan, live interface{}) bool {
+	return isSubset(plan, live) && isSubset(live, plan)
+}
+
+// normalizeNumber converts numbers decoded from JSON or YAML into float64, so that they can be compared
+func normalizeNumber(v interface{}) interface{} {
+	switch value := v.(type) {
+	case int:
+		return float64(value)
+	case int32:
+		return float64(value)
+	case int64:
+		return float64(value)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,109 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImmutableChanges(t *testing.T) {
+	tests := []struct {
+		name string
+		live map[string]interface{}
+		plan map[string]interface{}
+		want []string
+	}{
+		{
+			name: "service cluster IP",
+			live: map[string]interface{}{"apiVersion": "v1", "kind": "Service", "spec": map[string]interface{}{"clusterIP": "10.0.0.1"}},
+			plan: map[string]interface{}{"apiVersion": "v1", "kind": "Service", "spec": map[string]interface{}{"clusterIP": "None"}},
+			want: []string{"spec.clusterIP"},
+		},
+		{
+			name: "omitted field",
+			live: map[string]interface{}{"apiVersion": "v1", "kind": "Service", "spec": map[string]interface{}{"clusterIP": "10.0.0.1"}},
+			plan: map[string]interface{}{"apiVersion": "v1", "kind": "Service", "spec": map[string]interface{}{"type": "NodePort"}},
+		},
+		{
+			name: "job template with defaults",
+			live: map[string]interface{}{"apiVersion": "batch/v1", "kind": "Job", "spec": map[string]interface{}{
+				"completions": int64(1),
+				"template": map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+					map[string]interface{}{"name": "main", "image": "app:v1", "terminationMessagePath": "/dev/termination-log"},
+				}}},
+			}},
+			plan: map[string]interface{}{"apiVersion": "batch/v1", "kind": "Job", "spec": map[string]interface{}{
+				"completions": 1,
+				"template": map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+					map[string]interface{}{"name": "main", "image": "app:v1"},
+				}}},
+			}},
+		},
+		{
+			name: "job image",
+			live: map[string]interface{}{"apiVersion": "batch/v1", "kind": "Job", "spec": map[string]interface{}{
+				"template": map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+					map[string]interface{}{"name": "main", "image": "app:v1"},
+				}}},
+			}},
+			plan: map[string]interface{}{"apiVersion": "batch/v1", "kind": "Job", "spec": map[string]interface{}{
+				"template": map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+					map[string]interface{}{"name": "main", "image": "app:v2"},
+				}}},
+			}},
+			want: []string{"spec.template"},
+		},
+		{
+			name: "pvc storage class and access modes",
+			live: map[string]interface{}{"apiVersion": "v1", "kind": "PersistentVolumeClaim", "spec": map[string]interface{}{
+				"storageClassName": "standard", "accessModes": []interface{}{"ReadWriteOnce"},
+			}},
+			plan: map[string]interface{}{"apiVersion": "v1", "kind": "PersistentVolumeClaim", "spec": map[string]interface{}{
+				"storageClassName": "fast", "accessModes": []interface{}{"ReadWriteOnce", "ReadOnlyMany"},
+			}},
+			want: []string{"spec.storageClassName", "spec.accessModes"},
+		},
+		{
+			name: "immutable config map",
+			live: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "immutable": true, "data": map[string]interface{}{"a": "b"}},
+			plan: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "immutable": true, "data": map[string]interface{}{"a": "c"}},
+			want: []string{"data"},
+		},
+		{
+			name: "mutable config map",
+			live: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "data": map[string]interface{}{"a": "b"}},
+			plan: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "data": map[string]interface{}{"a": "c"}},
+		},
+		{
+			name: "deployment selector",
+			live: map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "spec": map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "a"}}, "replicas": 1,
+			}},
+			plan: map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "spec": map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "b"}}, "replicas": 2,
+			}},
+			want: []string{"spec.selector"},
+		},
+		{
+			name: "label removed from deployment selector",
+			live: map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "spec": map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "a", "tier": "web"}},
+			}},
+			plan: map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "spec": map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "a"}},
+			}},
+			want: []string{"spec.selector"},
+		},
+		{
+			name: "key removed from immutable secret",
+			live: map[string]interface{}{"apiVersion": "v1", "kind": "Secret", "immutable": true, "data": map[string]interface{}{"a": "b", "c": "d"}},
+			plan: map[string]interface{}{"apiVersion": "v1", "kind": "Secret", "immutable": true, "data": map[string]interface{}{"a": "b"}},
+			want: []string{"data"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ImmutableChanges(tt.live, tt.plan))
+		})
+	}
+}
@@ -21,7 +21,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
 	k8swatch "k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
-	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 
 	"kusionstack.io/kusion/pkg/engine/models"
@@ -38,21 +38,44 @@ var _ runtime.Runtime = (*KubernetesRuntime)(nil)
 type KubernetesRuntime struct {
 	client dynamic.Interface
 	mapper meta.RESTMapper
+
+	// clientset is used for requests not supported by the dynamic client, e.g. reading logs of pods
+	clientset kubernetes.Interface
+
+	// cache serves reads from lists of resources if it is enabled
+	cache *readCache
 }
 
-// NewKubernetesRuntime create a new KubernetesRuntime
-func NewKubernetesRuntime() (runtime.Runtime, error) {
-	client, mapper, err := getKubernetesClient()
+// NewKubernetesRuntime create a new KubernetesRuntime, whose kubeconfig and context are selected by the env
+func NewKubernetesRuntime(env runtime.Env) (runtime.Runtime, error) {
+	client, mapper, clientset, err := getKubernetesClient(env)
 	if err != nil {
 		return nil, err
 	}
 
 	return &KubernetesRuntime{
-		client: client,
-		mapper: mapper,
+		client:    client,
+		mapper:    mapper,
+		clientset: clientset,
 	}, nil
 }
 
+// RuntimeAs returns the Kubernetes runtime configured by the env as T, which is an interface of operations on
+// the cluster beyond runtime.Runtime, e.g. permission checks of applies. It returns the zero value of T if the
+// runtime does not implement T, e.g. fakes of the runtime in tests.
+func RuntimeAs[T any](env runtime.Env) (T, error) {
+	var zero T
+	rt, err := NewKubernetesRuntime(env)
+	if err != nil {
+		return zero, err
+	}
+	t, ok := rt.(T)
+	if !ok {
+		return zero, nil
+	}
+	return t, nil
+}
+
 // Apply kubernetes Resource by client-go
 func (k *KubernetesRuntime) Apply(ctx context.Context, request *runtime.ApplyRequest) *runtime.ApplyResponse {
 	planState := request.PlanResource
@@ -66,6 +89,12 @@ func (k *KubernetesRuntime) Apply(ctx context.Context, request *runtime.ApplyReq
 	// Get kubernetes Resource interface from plan state
 	planObj, resource, err := k.buildKubernetesResourceByState(planState)
 	if err != nil {
+		// The kind may be defined by a CRD applied before it in the same apply, which is not created by dry-run
+		// yet, so fall back to ClientSideDryRun
+		if request.DryRun && meta.IsNoMatchError(err) {
+			log.Infof("%v, fall back to ClientSideDryRun", err)
+			return &runtime.ApplyResponse{Resource: planState.DeepCopy()}
+		}
 		return &runtime.ApplyResponse{Status: status.NewErrorStatus(err)}
 	}
 
@@ -147,6 +176,12 @@ func (k *KubernetesRuntime) Apply(ctx context.Context, request *runtime.ApplyReq
 		if err != nil {
 			return &runtime.ApplyResponse{Status: status.NewErrorStatus(err)}
 		}
+		// Custom resources depending on the CRD are accepted only after it is established
+		if isCRD(planObj) {
+			if err = waitCRDEstablished(ctx, resource, planObj.GetName()); err != nil {
+				return &runtime.ApplyResponse{Status: status.NewErrorStatus(err)}
+			}
+		}
 		// Save modified
 		res = planObj
 	}
@@ -182,14 +217,20 @@ func (k *KubernetesRuntime) Read(ctx context.Context, request *runtime.ReadReque
 		return &runtime.ReadResponse{Status: status.NewErrorStatus(err)}
 	}
 
-	// Read resource
-	v, err := resource.Get(ctx, obj.GetName(), metav1.GetOptions{})
-	if err != nil {
-		if k8serrors.IsNotFound(err) {
-			log.Infof("%s not found, ignore", requestResource.ResourceKey())
-			return &runtime.ReadResponse{}
+	// Read resource from the cache if it is enabled, otherwise get it
+	var v *unstructured.Unstructured
+	cached := false
+	if k.cache != nil {
+		v, cached = k.cache.get(ctx, resource, obj)
+	}
+	if !cached {
+		if v, err = resource.Get(ctx, obj.GetName(), metav1.GetOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			return &runtime.ReadResponse{Status: status.NewErrorStatus(err)}
 		}
-		return &runtime.ReadResponse{Status: status.NewErrorStatus(err)}
+	}
+	if v == nil || err != nil {
+		log.Infof("%s not found, ignore", requestResource.ResourceKey())
+		return &runtime.ReadResponse{}
 	}
 
 	return &runtime.ReadResponse{Resource: &models.Resource{
@@ -217,29 +258,11 @@ func (k *KubernetesRuntime) Import(ctx context.Context, request *runtime.ImportR
 
 	// clean up resource to make it looks like last-applied-config
 	ur := &unstructured.Unstructured{Object: response.Resource.Attributes}
-	lastApplied := ur.GetAnnotations()[corev1.LastAppliedConfigAnnotation]
-	if len(lastApplied) != 0 {
-		err := ur.UnmarshalJSON([]byte(lastApplied))
-		if err != nil {
-			return &runtime.ImportResponse{Status: status.NewErrorStatusWithCode(status.IllegalManifest, err)}
-		}
-	} else {
-		// normalize resources
-		if k8s.Service == ur.GetKind() {
-			if err := normalizeService(ur); err != nil {
-				return &runtime.ImportResponse{
-					Resource: nil,
-					Status:   status.NewErrorStatusWithCode(status.IllegalManifest, err),
-				}
-			}
+	if err := cleanUpLiveObject(ur); err != nil {
+		return &runtime.ImportResponse{
+			Resource: nil,
+			Status:   status.NewErrorStatusWithCode(status.IllegalManifest, err),
 		}
-
-		const metadata = "metadata"
-		unstructured.RemoveNestedField(ur.Object, "status")
-		unstructured.RemoveNestedField(ur.Object, metadata, "resourceVersion")
-		unstructured.RemoveNestedField(ur.Object, metadata, "creationTimestamp")
-		unstructured.RemoveNestedField(ur.Object, metadata, "selfLink")
-		unstructured.RemoveNestedField(ur.Object, metadata, "uid")
 	}
 	response.Resource.Attributes = ur.Object
 	return &runtime.ImportResponse{
@@ -248,6 +271,29 @@ func (k *KubernetesRuntime) Import(ctx context.Context, request *runtime.ImportR
 	}
 }
 
+// cleanUpLiveObject makes the live object look like its last-applied-config, so that it can be recorded in states
+func cleanUpLiveObject(ur *unstructured.Unstructured) error {
+	lastApplied := ur.GetAnnotations()[corev1.LastAppliedConfigAnnotation]
+	if len(lastApplied) != 0 {
+		return ur.UnmarshalJSON([]byte(lastApplied))
+	}
+
+	// normalize resources
+	if k8s.Service == ur.GetKind() {
+		if err := normalizeService(ur); err != nil {
+			return err
+		}
+	}
+
+	const metadata = "metadata"
+	unstructured.RemoveNestedField(ur.Object, "status")
+	unstructured.RemoveNestedField(ur.Object, metadata, "resourceVersion")
+	unstructured.RemoveNestedField(ur.Object, metadata, "creationTimestamp")
+	unstructured.RemoveNestedField(ur.Object, metadata, "selfLink")
+	unstructured.RemoveNestedField(ur.Object, metadata, "uid")
+	return nil
+}
+
 func normalizeService(ur *unstructured.Unstructured) error {
 	target := &corev1.Service{}
 	if err := k8sruntime.DefaultUnstructuredConverter.FromUnstructured(ur.Object, target); err != nil {
@@ -366,26 +412,32 @@ func (k *KubernetesRuntime) Watch(ctx context.Context, request *runtime.WatchReq
 }
 
 // getKubernetesClient get kubernetes client
-func getKubernetesClient() (dynamic.Interface, meta.RESTMapper, error) {
-	// build config
-	cfg, err := clientcmd.BuildConfigFromFlags("", config.GetKubeConfig())
+func getKubernetesClient(env runtime.Env) (dynamic.Interface, meta.RESTMapper, kubernetes.Interface, error) {
+	// build config in the kube context pinned by the stack if any
+	cfg, err := config.BuildConfigWithEnv(env.Getenv)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	// DynamicRESTMapper can discover resource types at runtime dynamically
-	mapper, err := apiutil.NewDynamicRESTMapper(cfg)
+	// DynamicRESTMapper can discover resource types at runtime dynamically, discovery is deferred to the first
+	// mapping so that constructing the runtime never calls the cluster
+	mapper, err := apiutil.NewDynamicRESTMapper(cfg, apiutil.WithLazyDiscovery)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	// Prepare the dynamic client
 	dyn, err := dynamic.NewForConfig(cfg)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	return dyn, mapper, nil
+	return dyn, mapper, clientset, nil
 }
 
 // buildKubernetesResourceByState get resource by attribute
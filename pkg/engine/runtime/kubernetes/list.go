@@ -0,0 +1,62 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kusionstack.io/kusion/pkg/engine"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+// ListBySelector lists objects of the kinds in the namespaces matching the label selector, and returns them as
+// resources cleaned up like imported ones, sorted by IDs. Cluster-scoped kinds are listed in the whole cluster,
+// and kinds unknown to the cluster are skipped.
+func (k *KubernetesRuntime) ListBySelector(
+	ctx context.Context,
+	groupKinds []schema.GroupKind,
+	namespaces []string,
+	selector string,
+) ([]models.Resource, error) {
+	var resources []models.Resource
+	for _, gk := range groupKinds {
+		mapping, err := k.mapper.RESTMapping(gk)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		scopes := []string{""}
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			scopes = namespaces
+		}
+		for _, namespace := range scopes {
+			list, err := k.client.Resource(mapping.Resource).Namespace(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				return nil, fmt.Errorf("list %s in %q failed: %w", gk, namespace, err)
+			}
+			for i := range list.Items {
+				obj := &list.Items[i]
+				if err = cleanUpLiveObject(obj); err != nil {
+					return nil, err
+				}
+				resources = append(resources, models.Resource{
+					ID:         engine.BuildIDForKubernetes(obj.GetAPIVersion(), obj.GetKind(), obj.GetNamespace(), obj.GetName()),
+					Type:       runtime.Kubernetes,
+					Attributes: obj.Object,
+				})
+			}
+		}
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].ID < resources[j].ID
+	})
+	return resources, nil
+}
@@ -0,0 +1,59 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+func TestKubernetesRuntime_ListBySelector(t *testing.T) {
+	// kinds are mapped to their preferred versions like discovery
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	newObject := func(kind, namespace, name string, labels map[string]string) k8sruntime.Object {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("v1")
+		obj.SetKind(kind)
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		obj.SetLabels(labels)
+		obj.SetResourceVersion("1")
+		return obj
+	}
+	member := map[string]string{"part-of": "stack"}
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			{Version: "v1", Resource: "configmaps"}: "ConfigMapList",
+			{Version: "v1", Resource: "namespaces"}: "NamespaceList",
+		},
+		newObject("ConfigMap", "b", "cm", member),
+		newObject("ConfigMap", "a", "cm", member),
+		newObject("ConfigMap", "a", "other", nil),
+		newObject("ConfigMap", "c", "cm", member),
+		newObject("Namespace", "", "a", member),
+	)
+	k := &KubernetesRuntime{client: client, mapper: mapper}
+
+	resources, err := k.ListBySelector(context.Background(), []schema.GroupKind{
+		{Kind: "ConfigMap"}, {Kind: "Namespace"}, {Group: "example.com", Kind: "Foo"},
+	}, []string{"a", "b"}, "part-of=stack")
+	assert.Nil(t, err)
+
+	var ids []string
+	for _, r := range resources {
+		ids = append(ids, r.ID)
+		assert.Equal(t, runtime.Kubernetes, r.Type)
+		_, found, _ := unstructured.NestedString(r.Attributes, "metadata", "resourceVersion")
+		assert.False(t, found)
+	}
+	assert.Equal(t, []string{"v1:ConfigMap:a:cm", "v1:ConfigMap:b:cm", "v1:Namespace:a"}, ids)
+}
@@ -0,0 +1,95 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/printers/k8s"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+// MissingNamespace is a namespace which does not exist in the cluster, along with IDs of the resources in it
+type MissingNamespace struct {
+	Namespace string
+
+	// ResourceIDs are IDs of resources to apply in the namespace
+	ResourceIDs []string
+}
+
+func (m MissingNamespace) String() string {
+	return fmt.Sprintf("namespace %s does not exist, required by %s", m.Namespace, strings.Join(m.ResourceIDs, ", "))
+}
+
+// MissingNamespaces returns namespaces of the namespaced resources which do not exist in the cluster, sorted by
+// names. Namespaces declared by Namespace resources in the resources are created by the apply, so they are
+// not missing. Resources of kinds unknown to the cluster, e.g. ones defined by CRDs applied in the same apply,
+// are skipped.
+func (k *KubernetesRuntime) MissingNamespaces(ctx context.Context, resources []*models.Resource) ([]MissingNamespace, error) {
+	declared := map[string]bool{}
+	required := map[string][]string{}
+	for _, res := range resources {
+		if res == nil || res.Type != runtime.Kubernetes {
+			continue
+		}
+		obj := &unstructured.Unstructured{Object: res.Attributes}
+		gvk := obj.GroupVersionKind()
+		if gvk.Group == "" && gvk.Kind == k8s.Namespace {
+			declared[obj.GetName()] = true
+			continue
+		}
+		if obj.GetNamespace() == "" {
+			continue
+		}
+		mapping, err := k.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, err
+		}
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			required[obj.GetNamespace()] = append(required[obj.GetNamespace()], res.ID)
+		}
+	}
+
+	var missing []MissingNamespace
+	for namespace, ids := range required {
+		if declared[namespace] {
+			continue
+		}
+		_, err := k.clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+		if err == nil {
+			continue
+		}
+		if !k8serrors.IsNotFound(err) {
+			return nil, err
+		}
+		sort.Strings(ids)
+		missing = append(missing, MissingNamespace{Namespace: namespace, ResourceIDs: ids})
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].Namespace < missing[j].Namespace
+	})
+	return missing, nil
+}
+
+// CreateNamespaces creates the namespaces with the labels, namespaces created meanwhile are ignored
+func (k *KubernetesRuntime) CreateNamespaces(ctx context.Context, namespaces []string, labels map[string]string) error {
+	for _, name := range namespaces {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+		if _, err := k.clientset.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil &&
+			!k8serrors.IsAlreadyExists(err) {
+			return fmt.Errorf("create namespace %s failed: %w", name, err)
+		}
+	}
+	return nil
+}
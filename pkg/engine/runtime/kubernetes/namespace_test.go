@@ -0,0 +1,57 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+)
+
+func TestKubernetesRuntime_MissingNamespaces(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, meta.RESTScopeRoot)
+	clientset := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "exists"}})
+	k := &KubernetesRuntime{mapper: mapper, clientset: clientset}
+
+	inNamespace := func(id, apiVersion, kind, namespace string) *models.Resource {
+		r := newK8sResource(id, apiVersion, kind, nil)
+		r.Attributes["metadata"].(map[string]interface{})["namespace"] = namespace
+		return &r
+	}
+	declared := newK8sResource("declared", "v1", "Namespace", nil)
+	resources := []*models.Resource{
+		inNamespace("cm1", "v1", "ConfigMap", "missing"),
+		inNamespace("cm2", "v1", "ConfigMap", "exists"),
+		inNamespace("cm3", "v1", "ConfigMap", "declared"),
+		inNamespace("cm0", "v1", "ConfigMap", "missing"),
+		inNamespace("role", "rbac.authorization.k8s.io/v1", "ClusterRole", "ignored"),
+		inNamespace("foo", "example.com/v1", "Foo", "unknown"),
+		&declared,
+	}
+
+	missing, err := k.MissingNamespaces(context.Background(), resources)
+	assert.Nil(t, err)
+	assert.Equal(t, []MissingNamespace{{Namespace: "missing", ResourceIDs: []string{"cm0", "cm1"}}}, missing)
+	assert.Equal(t, "namespace missing does not exist, required by cm0, cm1", missing[0].String())
+}
+
+func TestKubernetesRuntime_CreateNamespaces(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "exists"}})
+	k := &KubernetesRuntime{clientset: clientset}
+
+	labels := map[string]string{"team": "kusion"}
+	assert.Nil(t, k.CreateNamespaces(context.Background(), []string{"exists", "created"}, labels))
+
+	ns, err := clientset.CoreV1().Namespaces().Get(context.Background(), "created", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, labels, ns.Labels)
+}
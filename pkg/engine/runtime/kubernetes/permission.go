@@ -0,0 +1,154 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+var selfSubjectAccessReviewGVR = authorizationv1.SchemeGroupVersion.WithResource("selfsubjectaccessreviews")
+
+// PermissionRequest is a verb to perform on a Kubernetes resource
+type PermissionRequest struct {
+	// Verb is the verb of the request, e.g. create, patch and delete
+	Verb string
+
+	// Resource is the Kubernetes resource
+	Resource *models.Resource
+}
+
+// DeniedPermission is a verb the current identity is not allowed to perform on a kind of resources in
+// a namespace, along with IDs of the resources which need it
+type DeniedPermission struct {
+	Verb      string
+	Group     string
+	Resource  string
+	Namespace string
+
+	// ResourceIDs are IDs of resources in the plan which need the permission
+	ResourceIDs []string
+
+	// Reason is the reason of the denial given by the cluster, which may be empty
+	Reason string
+}
+
+func (p DeniedPermission) String() string {
+	resource := p.Resource
+	if p.Group != "" {
+		resource += "." + p.Group
+	}
+	scope := "cluster scope"
+	if p.Namespace != "" {
+		scope = "namespace " + p.Namespace
+	}
+	s := fmt.Sprintf("cannot %s %s in %s, required by %s", p.Verb, resource, scope, strings.Join(p.ResourceIDs, ", "))
+	if p.Reason != "" {
+		s += ": " + p.Reason
+	}
+	return s
+}
+
+type permissionKey struct {
+	verb      string
+	group     string
+	resource  string
+	namespace string
+}
+
+// CheckPermissions checks with SelfSubjectAccessReviews whether the current identity is allowed to perform
+// the requests. Requests are grouped by the verb, the kind of resources and the namespace, so that one
+// review is submitted for each group. Resources whose APIs are not served by the cluster yet are skipped,
+// since they can not be checked before their CustomResourceDefinitions are created. It returns the denied
+// permissions, and an error only if the reviews can not be submitted.
+func (k *KubernetesRuntime) CheckPermissions(ctx context.Context, requests []PermissionRequest) ([]DeniedPermission, error) {
+	var keys []permissionKey
+	resourceIDs := map[permissionKey][]string{}
+	for _, r := range requests {
+		if r.Resource == nil || r.Resource.Type != runtime.Kubernetes {
+			continue
+		}
+		obj := &unstructured.Unstructured{Object: r.Resource.Attributes}
+		gvk := obj.GroupVersionKind()
+		mapping, err := k.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, err
+		}
+		key := permissionKey{verb: r.Verb, group: mapping.Resource.Group, resource: mapping.Resource.Resource}
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			key.namespace = obj.GetNamespace()
+			if key.namespace == "" {
+				key.namespace = metav1.NamespaceDefault
+			}
+		}
+		if _, ok := resourceIDs[key]; !ok {
+			keys = append(keys, key)
+		}
+		resourceIDs[key] = append(resourceIDs[key], r.Resource.ID)
+	}
+
+	var denied []DeniedPermission
+	for _, key := range keys {
+		allowed, reason, err := k.accessReview(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("check permission to %s %s failed: %w", key.verb, key.resource, err)
+		}
+		if allowed {
+			continue
+		}
+		ids := resourceIDs[key]
+		sort.Strings(ids)
+		denied = append(denied, DeniedPermission{
+			Verb:        key.verb,
+			Group:       key.group,
+			Resource:    key.resource,
+			Namespace:   key.namespace,
+			ResourceIDs: ids,
+			Reason:      reason,
+		})
+	}
+	return denied, nil
+}
+
+// accessReview submits a SelfSubjectAccessReview of the permission
+func (k *KubernetesRuntime) accessReview(ctx context.Context, key permissionKey) (bool, string, error) {
+	review := &authorizationv1.SelfSubjectAccessReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: authorizationv1.SchemeGroupVersion.String(),
+			Kind:       "SelfSubjectAccessReview",
+		},
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: key.namespace,
+				Verb:      key.verb,
+				Group:     key.group,
+				Resource:  key.resource,
+			},
+		},
+	}
+	content, err := k8sruntime.DefaultUnstructuredConverter.ToUnstructured(review)
+	if err != nil {
+		return false, "", err
+	}
+	result, err := k.client.Resource(selfSubjectAccessReviewGVR).Create(
+		ctx, &unstructured.Unstructured{Object: content}, metav1.CreateOptions{})
+	if err != nil {
+		return false, "", err
+	}
+	if err = k8sruntime.DefaultUnstructuredConverter.FromUnstructured(result.Object, review); err != nil {
+		return false, "", err
+	}
+	return review.Status.Allowed, review.Status.Reason, nil
+}
@@ -0,0 +1,75 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+func TestKubernetesRuntime_CheckPermissions(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, meta.RESTScopeRoot)
+
+	client := fake.NewSimpleDynamicClient(k8sruntime.NewScheme())
+	var reviews []string
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		attributes, _, _ := unstructured.NestedStringMap(review.Object, "spec", "resourceAttributes")
+		reviews = append(reviews, attributes["verb"]+" "+attributes["resource"]+" "+attributes["namespace"])
+		allowed := attributes["verb"] != "delete" && attributes["resource"] != "clusterroles"
+		_ = unstructured.SetNestedField(review.Object, allowed, "status", "allowed")
+		if !allowed {
+			_ = unstructured.SetNestedField(review.Object, "no RBAC policy matched", "status", "reason")
+		}
+		return true, review, nil
+	})
+	k := &KubernetesRuntime{client: client, mapper: mapper}
+
+	newConfigMap := func(name, namespace string) *models.Resource {
+		r := newK8sResource(name, "v1", "ConfigMap", nil)
+		if namespace != "" {
+			r.Attributes["metadata"].(map[string]interface{})["namespace"] = namespace
+		}
+		return &r
+	}
+	role := newK8sResource("role", "rbac.authorization.k8s.io/v1", "ClusterRole", nil)
+	foo := newK8sResource("foo", "example.com/v1", "Foo", nil)
+	requests := []PermissionRequest{
+		{Verb: "create", Resource: newConfigMap("a", "app")},
+		{Verb: "create", Resource: newConfigMap("b", "app")},
+		{Verb: "patch", Resource: newConfigMap("c", "")},
+		{Verb: "delete", Resource: newConfigMap("e", "app")},
+		{Verb: "delete", Resource: newConfigMap("d", "app")},
+		{Verb: "create", Resource: &role},
+		{Verb: "create", Resource: &foo},
+		{Verb: "create", Resource: &models.Resource{ID: "tf", Type: runtime.Terraform}},
+	}
+
+	denied, err := k.CheckPermissions(context.Background(), requests)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"create configmaps app", "patch configmaps default", "delete configmaps app", "create clusterroles "}, reviews)
+	assert.Equal(t, []DeniedPermission{
+		{Verb: "delete", Resource: "configmaps", Namespace: "app", ResourceIDs: []string{"d", "e"}, Reason: "no RBAC policy matched"},
+		{Verb: "create", Group: "rbac.authorization.k8s.io", Resource: "clusterroles", ResourceIDs: []string{"role"}, Reason: "no RBAC policy matched"},
+	}, denied)
+	assert.Equal(t, "cannot delete configmaps in namespace app, required by d, e: no RBAC policy matched", denied[0].String())
+	assert.Equal(t, "cannot create clusterroles.rbac.authorization.k8s.io in cluster scope, required by role: no RBAC policy matched", denied[1].String())
+
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+	_, err = k.CheckPermissions(context.Background(), requests[:1])
+	assert.EqualError(t, err, "check permission to create configmaps failed: connection refused")
+}
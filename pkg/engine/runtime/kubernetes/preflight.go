@@ -0,0 +1,100 @@
+package kubernetes
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+const crdKind = "CustomResourceDefinition"
+
+// MissingAPI describes a resource in the spec whose API is not served by the target cluster
+type MissingAPI struct {
+	// ResourceID is the ID of the resource
+	ResourceID string
+
+	// APIVersion and Kind are the API required by the resource
+	APIVersion string
+	Kind       string
+}
+
+func (m MissingAPI) String() string {
+	return fmt.Sprintf("%s: %s/%s is not served by the cluster", m.ResourceID, m.APIVersion, m.Kind)
+}
+
+// CheckAPIs verifies that the cluster serves the API group, version and kind of every Kubernetes
+// resource in the spec, and returns the resources whose APIs are missing. APIs defined by
+// CustomResourceDefinitions in the same spec are treated as available, since they will be created
+// before the custom resources during applying.
+func (k *KubernetesRuntime) CheckAPIs(resources models.Resources) ([]MissingAPI, error) {
+	provided, err := providedAPIs(resources)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []MissingAPI
+	for _, res := range resources {
+		if res.Type != runtime.Kubernetes {
+			continue
+		}
+		obj := &unstructured.Unstructured{Object: res.Attributes}
+		gvk := obj.GroupVersionKind()
+		if gvk.Kind == "" || gvk.Version == "" {
+			return nil, fmt.Errorf("resource %s has no apiVersion or kind", res.ID)
+		}
+		if provided[gvk] {
+			continue
+		}
+
+		if _, err = k.mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			if meta.IsNoMatchError(err) {
+				missing = append(missing, MissingAPI{ResourceID: res.ID, APIVersion: obj.GetAPIVersion(), Kind: gvk.Kind})
+				continue
+			}
+			return nil, err
+		}
+	}
+	return missing, nil
+}
+
+// providedAPIs returns group, version and kind served by CustomResourceDefinitions in the resources
+func providedAPIs(resources models.Resources) (map[schema.GroupVersionKind]bool, error) {
+	result := map[schema.GroupVersionKind]bool{}
+	for _, res := range resources {
+		obj := &unstructured.Unstructured{Object: res.Attributes}
+		if res.Type != runtime.Kubernetes || obj.GetKind() != crdKind {
+			continue
+		}
+
+		group, _, err := unstructured.NestedString(obj.Object, "spec", "group")
+		if err != nil {
+			return nil, err
+		}
+		kind, _, err := unstructured.NestedString(obj.Object, "spec", "names", "kind")
+		if err != nil {
+			return nil, err
+		}
+		versions, _, err := unstructured.NestedSlice(obj.Object, "spec", "versions")
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range versions {
+			version, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if served, ok := version["served"].(bool); ok && !served {
+				continue
+			}
+			if name, ok := version["name"].(string); ok {
+				result[schema.GroupVersionKind{Group: group, Version: name, Kind: kind}] = true
+			}
+		}
+	}
+	return result, nil
+}
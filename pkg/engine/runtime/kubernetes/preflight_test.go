@@ -0,0 +1,58 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+func newK8sResource(id, apiVersion, kind string, extra map[string]interface{}) models.Resource {
+	attributes := map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": id},
+	}
+	for k, v := range extra {
+		attributes[k] = v
+	}
+	return models.Resource{ID: id, Type: runtime.Kubernetes, Attributes: attributes}
+}
+
+func TestKubernetesRuntime_CheckAPIs(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: crdKind}, meta.RESTScopeRoot)
+	k := &KubernetesRuntime{mapper: mapper}
+
+	crd := newK8sResource("crd", "apiextensions.k8s.io/v1", crdKind, map[string]interface{}{
+		"spec": map[string]interface{}{
+			"group": "example.com",
+			"names": map[string]interface{}{"kind": "Foo"},
+			"versions": []interface{}{
+				map[string]interface{}{"name": "v1", "served": true},
+				map[string]interface{}{"name": "v1alpha1", "served": false},
+			},
+		},
+	})
+
+	resources := models.Resources{
+		newK8sResource("deploy", "apps/v1", "Deployment", nil),
+		newK8sResource("old-deploy", "extensions/v1beta1", "Deployment", nil),
+		crd,
+		newK8sResource("foo", "example.com/v1", "Foo", nil),
+		newK8sResource("old-foo", "example.com/v1alpha1", "Foo", nil),
+		{ID: "tf", Type: runtime.Terraform, Attributes: map[string]interface{}{}},
+	}
+
+	missing, err := k.CheckAPIs(resources)
+	assert.Nil(t, err)
+	assert.Equal(t, []MissingAPI{
+		{ResourceID: "old-deploy", APIVersion: "extensions/v1beta1", Kind: "Deployment"},
+		{ResourceID: "old-foo", APIVersion: "example.com/v1alpha1", Kind: "Foo"},
+	}, missing)
+}
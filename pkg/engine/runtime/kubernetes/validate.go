@@ -0,0 +1,91 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	jsonutil "kusionstack.io/kusion/pkg/util/json"
+)
+
+// ValidationError describes a resource rejected by the cluster in the server side dry run
+type ValidationError struct {
+	// ResourceID is the ID of the resource
+	ResourceID string
+
+	// Err is the error returned by the cluster
+	Err error
+}
+
+func (v ValidationError) String() string {
+	return fmt.Sprintf("%s: %v", v.ResourceID, v.Err)
+}
+
+// ValidateServer submits every Kubernetes resource to the cluster with dry-run=server, so that errors of
+// the schema validation, admission controllers and webhooks are found before any change is made. Resources
+// are created if they do not exist, otherwise patched in the same way as Apply, with the prior resources
+// in the state keyed by resource IDs. It returns the resources rejected by the cluster, and an error only
+// if the cluster can not be reached.
+func (k *KubernetesRuntime) ValidateServer(
+	ctx context.Context,
+	resources models.Resources,
+	priorResources map[string]*models.Resource,
+) ([]ValidationError, error) {
+	var rejected []ValidationError
+	for i := range resources {
+		res := &resources[i]
+		if res.Type != runtime.Kubernetes {
+			continue
+		}
+		err := k.dryRun(ctx, priorResources[res.ID], res)
+		if err == nil {
+			continue
+		}
+		if _, ok := err.(k8serrors.APIStatus); ok || meta.IsNoMatchError(err) {
+			rejected = append(rejected, ValidationError{ResourceID: res.ID, Err: err})
+			continue
+		}
+		return nil, err
+	}
+	return rejected, nil
+}
+
+// dryRun creates or patches the resource with dry-run=server
+func (k *KubernetesRuntime) dryRun(ctx context.Context, prior, plan *models.Resource) error {
+	planObj, resource, err := k.buildKubernetesResourceByState(plan)
+	if err != nil {
+		return err
+	}
+
+	live, err := resource.Get(ctx, planObj.GetName(), metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		_, err = resource.Create(ctx, planObj, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	original := ""
+	if prior != nil {
+		original = jsonutil.MustMarshal2String(prior.Attributes)
+	}
+	patchBody, err := jsonmergepatch.CreateThreeWayJSONMergePatch(
+		[]byte(original),
+		[]byte(jsonutil.MustMarshal2String(plan.Attributes)),
+		[]byte(jsonutil.MustMarshal2String(live.Object)),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = resource.Patch(ctx, planObj.GetName(), types.MergePatchType, patchBody,
+		metav1.PatchOptions{DryRun: []string{metav1.DryRunAll}, FieldManager: "kusion"})
+	return err
+}
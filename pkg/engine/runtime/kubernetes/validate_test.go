@@ -0,0 +1,69 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+func TestKubernetesRuntime_ValidateServer(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+
+	live := &unstructured.Unstructured{}
+	live.SetAPIVersion("v1")
+	live.SetKind("ConfigMap")
+	live.SetName("live")
+	live.SetNamespace("default")
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{{Version: "v1", Resource: "configmaps"}: "ConfigMapList"}, live)
+
+	var dryRuns []string
+	client.PrependReactor("create", "configmaps", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		obj := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		dryRuns = append(dryRuns, "create "+obj.GetName())
+		if obj.GetName() == "invalid" {
+			return true, nil, k8serrors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "invalid",
+				field.ErrorList{field.Invalid(field.NewPath("data"), "x", "denied by webhook")})
+		}
+		return true, obj, nil
+	})
+	client.PrependReactor("patch", "configmaps", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		dryRuns = append(dryRuns, "patch "+action.(k8stesting.PatchAction).GetName())
+		return true, live, nil
+	})
+	k := &KubernetesRuntime{client: client, mapper: mapper}
+
+	newConfigMap := func(name string) models.Resource {
+		r := newK8sResource(name, "v1", "ConfigMap", nil)
+		r.Attributes["metadata"].(map[string]interface{})["namespace"] = "default"
+		return r
+	}
+	resources := models.Resources{
+		newConfigMap("new"),
+		newConfigMap("invalid"),
+		newConfigMap("live"),
+		newK8sResource("foo", "example.com/v1", "Foo", nil),
+		{ID: "tf", Type: runtime.Terraform, Attributes: map[string]interface{}{}},
+	}
+
+	rejected, err := k.ValidateServer(context.Background(), resources, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"create new", "create invalid", "patch live"}, dryRuns)
+	assert.Len(t, rejected, 2)
+	assert.Equal(t, "invalid", rejected[0].ResourceID)
+	assert.Contains(t, rejected[0].String(), "denied by webhook")
+	assert.Equal(t, "foo", rejected[1].ResourceID)
+}
@@ -2,6 +2,7 @@ package runtime
 
 import (
 	"context"
+	"time"
 
 	"k8s.io/apimachinery/pkg/watch"
 
@@ -42,6 +43,58 @@ type Runtime interface {
 	Watch(ctx context.Context, request *WatchRequest) *WatchResponse
 }
 
+// ReplacementDetector is an optional interface for runtimes whose resources have immutable fields. A resource
+// can not be updated if any of its immutable fields is changed, and must be deleted and created again instead.
+type ReplacementDetector interface {
+	// RequiresReplacement returns paths of immutable fields which differ between the live and the planned resource
+	RequiresReplacement(live, plan *models.Resource) []string
+}
+
+// Diagnoser is an optional interface for runtimes which can explain why a resource is not healthy, e.g. by
+// recent events and logs of the resource, so that failures of health waits are actionable.
+type Diagnoser interface {
+	// Diagnose returns a readable diagnosis of the resource, which is empty if nothing is found
+	Diagnose(ctx context.Context, resource *models.Resource) (string, error)
+}
+
+// ReadCacher is an optional interface for runtimes which can serve reads from a cache populated by bulk
+// requests, e.g. one list of all resources of a kind instead of one get per resource. Previews enable the cache
+// since they read all resources of the stack at once, while applies read the latest resources one by one.
+type ReadCacher interface {
+	// EnableReadCache makes later reads served from the cache
+	EnableReadCache()
+}
+
+// Event is a notable event of a resource reported by its runtime while the resource converges, e.g. a
+// Kubernetes warning event of a pod which can not be scheduled
+type Event struct {
+	// Object is the object the event is about, which is the resource itself or one of its children like pods
+	Object string
+	// Reason is a short reason of the event in CamelCase, e.g. FailedScheduling
+	Reason string
+	// Message is a readable description of the event
+	Message string
+	// Count is how many times the event has occurred
+	Count int32
+	// Time is when the event occurred last
+	Time time.Time
+}
+
+// EventLister is an optional interface for runtimes which can explain in real time why a resource is stuck,
+// e.g. by events of pods which can not be scheduled or whose images can not be pulled, so that users waiting for
+// the resource to converge see them before the wait times out.
+type EventLister interface {
+	// ListEvents returns notable events of the resource and its children which occurred since the time, the latest last
+	ListEvents(ctx context.Context, resource *models.Resource, since time.Time) ([]Event, error)
+}
+
+// Closer is an optional interface for runtimes holding resources across requests, e.g. provider processes shared
+// by resources. Operations close their runtimes when they finish.
+type Closer interface {
+	// Close releases resources held by the runtime
+	Close() error
+}
+
 type ApplyRequest struct {
 	// PriorResource is the last applied resource saved in state storage
 	PriorResource *models.Resource
@@ -16,23 +16,62 @@ import (
 	"kusionstack.io/kusion/pkg/status"
 )
 
-var _ runtime.Runtime = &TerraformRuntime{}
+var (
+	_ runtime.Runtime = &TerraformRuntime{}
+	_ runtime.Closer  = &TerraformRuntime{}
+)
 
 type TerraformRuntime struct {
 	tfops.WorkSpace
 	mu *sync.Mutex
+
+	// providers are provider processes shared by resources of the same provider
+	providers *tfops.ProviderPool
+
+	// locks are kusion.lock files read by stack directories
+	locks map[string]*tfops.Lock
 }
 
-func NewTerraformRuntime() (runtime.Runtime, error) {
+// NewTerraformRuntime returns the Terraform runtime, whose terraform commands and providers run with the env
+func NewTerraformRuntime(env runtime.Env) (runtime.Runtime, error) {
 	fs := afero.Afero{Fs: afero.NewOsFs()}
 	ws := tfops.NewWorkSpace(fs)
+	ws.SetEnv(env)
+	providers := tfops.NewProviderPool(env)
+	ws.SetProviderPool(providers)
 	TFRuntime := &TerraformRuntime{
 		WorkSpace: *ws,
 		mu:        &sync.Mutex{},
+		providers: providers,
+		locks:     map[string]*tfops.Lock{},
 	}
 	return TFRuntime, nil
 }
 
+// readLock returns kusion.lock of the stack directory, which is read once by each runtime
+func (t *TerraformRuntime) readLock(stackPath string) (*tfops.Lock, error) {
+	if t.locks == nil {
+		t.locks = map[string]*tfops.Lock{}
+	}
+	if lock, ok := t.locks[stackPath]; ok {
+		return lock, nil
+	}
+	lock, err := tfops.ReadLock(stackPath)
+	if err != nil {
+		return nil, err
+	}
+	t.locks[stackPath] = lock
+	return lock, nil
+}
+
+// Close stops provider processes started by this runtime
+func (t *TerraformRuntime) Close() error {
+	if t.providers == nil {
+		return nil
+	}
+	return t.providers.Close()
+}
+
 // Apply terraform apply resource
 func (t *TerraformRuntime) Apply(ctx context.Context, request *runtime.ApplyRequest) *runtime.ApplyResponse {
 	planState := request.PlanResource
@@ -54,17 +93,24 @@ func (t *TerraformRuntime) Apply(ctx context.Context, request *runtime.ApplyRequ
 	}
 
 	t.mu.Lock()
+	defer t.mu.Unlock()
 	stackPath := request.Stack.GetPath()
 	tfCacheDir := filepath.Join(stackPath, "."+planState.ResourceKey())
 	t.WorkSpace.SetStackDir(stackPath)
 	t.WorkSpace.SetCacheDir(tfCacheDir)
 	t.WorkSpace.SetResource(planState)
+	t.WorkSpace.SetProviderConfigs(request.Stack.TerraformProviders())
+	lock, err := t.readLock(stackPath)
+	if err != nil {
+		return &runtime.ApplyResponse{Resource: nil, Status: status.NewErrorStatus(err)}
+	}
+	t.WorkSpace.SetLock(lock)
 
 	if err := t.WorkSpace.WriteHCL(); err != nil {
 		return &runtime.ApplyResponse{Resource: nil, Status: status.NewErrorStatus(err)}
 	}
 
-	_, err := os.Stat(filepath.Join(tfCacheDir, tfops.HCLLOCKFILE))
+	_, err = os.Stat(filepath.Join(tfCacheDir, tfops.HCLLOCKFILE))
 	if err != nil {
 		if os.IsNotExist(err) {
 			if err := t.WorkSpace.InitWorkSpace(ctx); err != nil {
@@ -79,7 +125,6 @@ func (t *TerraformRuntime) Apply(ctx context.Context, request *runtime.ApplyRequ
 	if err != nil {
 		return &runtime.ApplyResponse{Resource: nil, Status: status.NewErrorStatus(err)}
 	}
-	t.mu.Unlock()
 
 	// get terraform provider version
 	providerAddr, err := t.WorkSpace.GetProvider()
@@ -118,15 +163,22 @@ func (t *TerraformRuntime) Read(ctx context.Context, request *runtime.ReadReques
 	var tfstate *tfops.TFState
 
 	t.mu.Lock()
+	defer t.mu.Unlock()
 	stackPath := request.Stack.GetPath()
 	tfCacheDir := filepath.Join(stackPath, "."+requestResource.ResourceKey())
 	t.WorkSpace.SetStackDir(stackPath)
 	t.WorkSpace.SetCacheDir(tfCacheDir)
 	t.WorkSpace.SetResource(requestResource)
+	t.WorkSpace.SetProviderConfigs(request.Stack.TerraformProviders())
+	lock, err := t.readLock(stackPath)
+	if err != nil {
+		return &runtime.ReadResponse{Resource: nil, Status: status.NewErrorStatus(err)}
+	}
+	t.WorkSpace.SetLock(lock)
 	if err := t.WorkSpace.WriteHCL(); err != nil {
 		return &runtime.ReadResponse{Resource: nil, Status: status.NewErrorStatus(err)}
 	}
-	_, err := os.Stat(filepath.Join(tfCacheDir, tfops.HCLLOCKFILE))
+	_, err = os.Stat(filepath.Join(tfCacheDir, tfops.HCLLOCKFILE))
 	if err != nil {
 		if os.IsNotExist(err) {
 			if err := t.WorkSpace.InitWorkSpace(ctx); err != nil {
@@ -146,7 +198,6 @@ func (t *TerraformRuntime) Read(ctx context.Context, request *runtime.ReadReques
 	if err != nil {
 		return &runtime.ReadResponse{Resource: nil, Status: status.NewErrorStatus(err)}
 	}
-	t.mu.Unlock()
 	if tfstate == nil || tfstate.Values == nil {
 		return &runtime.ReadResponse{Resource: nil, Status: nil}
 	}
@@ -182,13 +233,13 @@ func (t *TerraformRuntime) Delete(ctx context.Context, request *runtime.DeleteRe
 	tfCacheDir := filepath.Join(stackPath, "."+request.Resource.ResourceKey())
 	defer os.RemoveAll(tfCacheDir)
 	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.WorkSpace.SetStackDir(stackPath)
 	t.WorkSpace.SetCacheDir(tfCacheDir)
 	t.WorkSpace.SetResource(request.Resource)
 	if err := t.WorkSpace.Destroy(ctx); err != nil {
 		return &runtime.DeleteResponse{Status: status.NewErrorStatus(err)}
 	}
-	t.mu.Unlock()
 
 	return &runtime.DeleteResponse{Status: nil}
 }
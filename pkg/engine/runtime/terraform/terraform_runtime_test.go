@@ -2,8 +2,6 @@ package terraform
 
 import (
 	"context"
-	"os"
-	"path/filepath"
 	"sync"
 	"testing"
 
@@ -29,12 +27,10 @@ var testResource = models.Resource{
 }
 
 func TestTerraformRuntime(t *testing.T) {
-	cwd, _ := os.Getwd()
 	stack := &projectstack.Stack{
 		StackConfiguration: projectstack.StackConfiguration{Name: "fakeStack"},
-		Path:               filepath.Join(cwd, "fakePath"),
+		Path:               t.TempDir(),
 	}
-	defer os.RemoveAll(stack.GetPath())
 	tfRuntime := TerraformRuntime{
 		WorkSpace: *tfops.NewWorkSpace(afero.Afero{Fs: afero.NewOsFs()}),
 		mu:        &sync.Mutex{},
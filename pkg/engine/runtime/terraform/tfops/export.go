@@ -0,0 +1,135 @@
+package tfops
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+)
+
+// terraformName matches valid names of terraform resources
+var terraformName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// ExportMapping records which terraform resource a kusion resource is exported as, or why it is not exported
+type ExportMapping struct {
+	// ID is the ID of the kusion resource
+	ID string `json:"id" yaml:"id"`
+
+	// Address is the address of the terraform resource, e.g. local_file.example, empty if not exported
+	Address string `json:"address,omitempty" yaml:"address,omitempty"`
+
+	// Provider is the provider of the terraform resource, e.g. registry.terraform.io/hashicorp/local/2.2.3
+	Provider string `json:"provider,omitempty" yaml:"provider,omitempty"`
+
+	// Reason is why the resource is not exported
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+
+	// DroppedDependencies are dependencies not exported, which terraform will not know about
+	DroppedDependencies []string `json:"droppedDependencies,omitempty" yaml:"droppedDependencies,omitempty"`
+}
+
+// ConvertToRawTFState converts kusion resources of the Terraform runtime to a state file which terraform reads,
+// named by the last part of their IDs as in main.tf.json written by kusion. Resources which can't be represented
+// in terraform states, e.g. resources of other runtimes, are skipped, and the returned mappings record the
+// address of each exported resource and the reason of each skipped one.
+func ConvertToRawTFState(resources models.Resources, serial uint64) ([]byte, []ExportMapping, error) {
+	state := rawState{
+		Version: 4,
+		Serial:  serial,
+		Outputs: map[string]interface{}{},
+	}
+	lineage, err := newLineage()
+	if err != nil {
+		return nil, nil, err
+	}
+	state.Lineage = lineage
+
+	mappings := make([]ExportMapping, len(resources))
+	addresses := map[string]string{}
+	exported := map[string]int{}
+	for i := range resources {
+		r := &resources[i]
+		mappings[i] = ExportMapping{ID: r.ID}
+		address, source, reason := exportAddress(r)
+		if reason == "" {
+			if id, ok := exported[address]; ok {
+				reason = fmt.Sprintf("its address %s is taken by %s", address, resources[id].ID)
+			}
+		}
+		if reason != "" {
+			mappings[i].Reason = reason
+			continue
+		}
+		exported[address] = i
+		addresses[r.ID] = address
+		mappings[i].Address = address
+		mappings[i].Provider = r.Extensions["provider"].(string)
+		state.Resources = append(state.Resources, rawResource{
+			Mode:     "managed",
+			Type:     r.Extensions["resourceType"].(string),
+			Name:     r.ID[strings.LastIndex(r.ID, ":")+1:],
+			Provider: fmt.Sprintf("provider[\"%s\"]", source),
+		})
+	}
+
+	for i := range state.Resources {
+		tr := &state.Resources[i]
+		index := exported[tr.Type+"."+tr.Name]
+		r := &resources[index]
+		instance := rawInstance{Attributes: r.Attributes, SensitiveAttributes: []interface{}{}}
+		for _, dep := range r.DependsOn {
+			if address, ok := addresses[dep]; ok {
+				instance.Dependencies = append(instance.Dependencies, address)
+			} else {
+				mappings[index].DroppedDependencies = append(mappings[index].DroppedDependencies, dep)
+			}
+		}
+		sort.Strings(instance.Dependencies)
+		tr.Instances = []rawInstance{instance}
+	}
+	sort.Slice(state.Resources, func(i, j int) bool {
+		return state.Resources[i].Type+"."+state.Resources[i].Name < state.Resources[j].Type+"."+state.Resources[j].Name
+	})
+	if state.Resources == nil {
+		state.Resources = []rawResource{}
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, mappings, nil
+}
+
+// exportAddress returns the terraform address and provider source of the resource, or the reason it can't be exported
+func exportAddress(r *models.Resource) (address, source, reason string) {
+	if r.Type != "Terraform" {
+		return "", "", fmt.Sprintf("resources of the %s runtime are not terraform resources", r.Type)
+	}
+	provider, _ := r.Extensions["provider"].(string)
+	resourceType, _ := r.Extensions["resourceType"].(string)
+	parts := strings.Split(provider, "/")
+	if len(parts) < 3 || resourceType == "" {
+		return "", "", "its provider or resource type is missing in extensions"
+	}
+	name := r.ID[strings.LastIndex(r.ID, ":")+1:]
+	if !terraformName.MatchString(name) {
+		return "", "", fmt.Sprintf("%s is not a valid terraform resource name", name)
+	}
+	return resourceType + "." + name, strings.Join(parts[:len(parts)-1], "/"), ""
+}
+
+// newLineage returns a random UUID identifying the state, which terraform uses to tell states apart
+func newLineage() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
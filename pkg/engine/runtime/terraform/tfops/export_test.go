@@ -0,0 +1,88 @@
+package tfops
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+)
+
+func TestConvertToRawTFState(t *testing.T) {
+	resources := models.Resources{
+		{
+			ID:         "hashicorp:random:random_password:db",
+			Type:       "Terraform",
+			Attributes: map[string]interface{}{"length": float64(16)},
+			DependsOn:  []string{"hashicorp:local:local_file:config", "v1:Namespace:default"},
+			Extensions: map[string]interface{}{
+				"provider":     "registry.terraform.io/hashicorp/random/3.4.3",
+				"resourceType": "random_password",
+			},
+		},
+		{
+			ID:         "hashicorp:local:local_file:config",
+			Type:       "Terraform",
+			Attributes: map[string]interface{}{"content": "kusion", "filename": "config.txt"},
+			Extensions: map[string]interface{}{
+				"provider":     "registry.terraform.io/hashicorp/local/2.2.3",
+				"resourceType": "local_file",
+			},
+		},
+		{
+			ID:   "v1:Namespace:default",
+			Type: "Kubernetes",
+		},
+		{
+			ID:   "hashicorp:local:local_file:broken",
+			Type: "Terraform",
+		},
+	}
+
+	data, mappings, err := ConvertToRawTFState(resources, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []ExportMapping{
+		{
+			ID:                  "hashicorp:random:random_password:db",
+			Address:             "random_password.db",
+			Provider:            "registry.terraform.io/hashicorp/random/3.4.3",
+			DroppedDependencies: []string{"v1:Namespace:default"},
+		},
+		{
+			ID:       "hashicorp:local:local_file:config",
+			Address:  "local_file.config",
+			Provider: "registry.terraform.io/hashicorp/local/2.2.3",
+		},
+		{ID: "v1:Namespace:default", Reason: "resources of the Kubernetes runtime are not terraform resources"},
+		{ID: "hashicorp:local:local_file:broken", Reason: "its provider or resource type is missing in extensions"},
+	}, mappings)
+
+	// the exported state is imported back as it was, except resources which can't be represented
+	got, err := ConvertRawTFState(data, map[string]string{
+		"registry.terraform.io/hashicorp/local":  "2.2.3",
+		"registry.terraform.io/hashicorp/random": "3.4.3",
+	})
+	assert.Nil(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, "hashicorp:local:local_file:config", got[0].ID)
+	assert.Equal(t, resources[1].Attributes, got[0].Attributes)
+	assert.Equal(t, []string{"hashicorp:local:local_file:config"}, got[1].DependsOn)
+}
+
+func TestConvertToRawTFState_Conflict(t *testing.T) {
+	resource := models.Resource{
+		ID:   "hashicorp:local:local_file:config",
+		Type: "Terraform",
+		Extensions: map[string]interface{}{
+			"provider":     "registry.terraform.io/hashicorp/local/2.2.3",
+			"resourceType": "local_file",
+		},
+	}
+	other := resource
+	other.ID = "other:local:local_file:config"
+
+	_, mappings, err := ConvertToRawTFState(models.Resources{resource, other}, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "local_file.config", mappings[0].Address)
+	assert.Equal(t, "its address local_file.config is taken by hashicorp:local:local_file:config", mappings[1].Reason)
+}
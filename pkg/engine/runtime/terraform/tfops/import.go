@@ -0,0 +1,171 @@
+package tfops
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+)
+
+// rawState is the state file written by terraform, whose schema is from
+// https://github.com/hashicorp/terraform/blob/main/internal/states/statefile/version4.go
+type rawState struct {
+	Version          int                    `json:"version"`
+	TerraformVersion string                 `json:"terraform_version,omitempty"`
+	Serial           uint64                 `json:"serial"`
+	Lineage          string                 `json:"lineage,omitempty"`
+	Outputs          map[string]interface{} `json:"outputs"`
+	Resources        []rawResource          `json:"resources"`
+}
+
+type rawResource struct {
+	Module    string        `json:"module,omitempty"`
+	Mode      string        `json:"mode"`
+	Type      string        `json:"type"`
+	Name      string        `json:"name"`
+	Provider  string        `json:"provider"`
+	Instances []rawInstance `json:"instances"`
+}
+
+type rawInstance struct {
+	IndexKey            interface{}            `json:"index_key,omitempty"`
+	SchemaVersion       uint64                 `json:"schema_version"`
+	Attributes          map[string]interface{} `json:"attributes"`
+	SensitiveAttributes []interface{}          `json:"sensitive_attributes"`
+	Dependencies        []string               `json:"dependencies,omitempty"`
+}
+
+// providerAddrPattern matches provider addresses in terraform states, e.g. provider["registry.terraform.io/hashicorp/local"]
+var providerAddrPattern = regexp.MustCompile(`provider\["([^"]+)"\]`)
+
+// invalidNameChars matches characters not allowed in names of resources written to main.tf.json
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// ConvertRawTFState converts managed resources in the state file written by terraform to kusion resources of the
+// Terraform runtime. Versions maps provider sources, e.g. registry.terraform.io/hashicorp/local, to their versions,
+// which are not recorded in terraform states. Dependencies of resources are converted to IDs of the kusion
+// resources they depend on.
+func ConvertRawTFState(data []byte, versions map[string]string) (models.Resources, error) {
+	var state rawState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parse terraform state failed: %w", err)
+	}
+	if state.Version != 4 {
+		return nil, fmt.Errorf("unsupported terraform state version %d, only version 4 is supported", state.Version)
+	}
+
+	var resources models.Resources
+	// addresses of terraform resources, e.g. module.db.aws_instance.main, to IDs of their instances
+	addresses := map[string][]string{}
+	dependencies := map[string][]string{}
+	for _, r := range state.Resources {
+		if r.Mode != "managed" {
+			continue
+		}
+		match := providerAddrPattern.FindStringSubmatch(r.Provider)
+		if match == nil {
+			return nil, fmt.Errorf("invalid provider %s of the resource %s.%s", r.Provider, r.Type, r.Name)
+		}
+		source := match[1]
+		version, ok := versions[source]
+		if !ok {
+			return nil, fmt.Errorf("version of the provider %s is unknown", source)
+		}
+		provider := strings.Split(source, "/")
+		address := r.Type + "." + r.Name
+		if r.Module != "" {
+			address = r.Module + "." + address
+		}
+
+		for _, instance := range r.Instances {
+			id := strings.Join([]string{
+				provider[len(provider)-2], provider[len(provider)-1], r.Type, resourceName(r.Module, r.Name, instance.IndexKey),
+			}, ":")
+			addresses[address] = append(addresses[address], id)
+			dependencies[id] = instance.Dependencies
+			resources = append(resources, models.Resource{
+				ID:         id,
+				Type:       "Terraform",
+				Attributes: instance.Attributes,
+				Extensions: map[string]interface{}{
+					"provider":     source + "/" + version,
+					"resourceType": r.Type,
+				},
+			})
+		}
+	}
+
+	for i := range resources {
+		r := &resources[i]
+		for _, dep := range dependencies[r.ID] {
+			// dependencies on data sources are dropped since they are not imported
+			r.DependsOn = append(r.DependsOn, addresses[dep]...)
+		}
+		sort.Strings(r.DependsOn)
+	}
+	return resources, nil
+}
+
+// resourceName returns the name of the instance in the module, e.g. db_main_0 for module.db.aws_instance.main[0]
+func resourceName(module, name string, indexKey interface{}) string {
+	var parts []string
+	for _, part := range strings.Split(module, ".") {
+		if part != "" && part != "module" {
+			parts = append(parts, part)
+		}
+	}
+	parts = append(parts, name)
+	if indexKey != nil {
+		parts = append(parts, fmt.Sprint(indexKey))
+	}
+	return invalidNameChars.ReplaceAllString(strings.Join(parts, "_"), "_")
+}
+
+// ReadProviderVersions reads versions of providers locked in the terraform lock file, e.g. .terraform.lock.hcl,
+// keyed by their sources
+func ReadProviderVersions(lockFile string) (map[string]string, error) {
+	parser := hclparse.NewParser()
+	hclFile, diags := parser.ParseHCLFile(lockFile)
+	if diags.HasErrors() {
+		return nil, errors.New(diags.Error())
+	}
+	content, diags := hclFile.Body.Content(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{
+				Type:       "provider",
+				LabelNames: []string{"source_addr"},
+			},
+		},
+	})
+	if diags.HasErrors() {
+		return nil, errors.New(diags.Error())
+	}
+
+	versions := map[string]string{}
+	for _, block := range content.Blocks {
+		attrs, diags := block.Body.Content(&hcl.BodySchema{
+			Attributes: []hcl.AttributeSchema{
+				{Name: "version", Required: true},
+				{Name: "constraints"},
+				{Name: "hashes"},
+			},
+		})
+		if diags.HasErrors() {
+			return nil, errors.New(diags.Error())
+		}
+		var version string
+		if diags = gohcl.DecodeExpression(attrs.Attributes["version"].Expr, nil, &version); diags.HasErrors() {
+			return nil, errors.New(diags.Error())
+		}
+		versions[block.Labels[0]] = version
+	}
+	return versions, nil
+}
@@ -0,0 +1,105 @@
+package tfops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+)
+
+const rawTFState = `{
+  "version": 4,
+  "terraform_version": "1.3.6",
+  "resources": [
+    {
+      "mode": "data",
+      "type": "local_file",
+      "name": "config",
+      "provider": "provider[\"registry.terraform.io/hashicorp/local\"]",
+      "instances": [{"attributes": {"filename": "config.txt"}}]
+    },
+    {
+      "mode": "managed",
+      "type": "local_file",
+      "name": "config",
+      "provider": "provider[\"registry.terraform.io/hashicorp/local\"]",
+      "instances": [{"attributes": {"content": "kusion", "filename": "config.txt"}}]
+    },
+    {
+      "module": "module.app",
+      "mode": "managed",
+      "type": "random_password",
+      "name": "db",
+      "each": "map",
+      "provider": "provider[\"registry.terraform.io/hashicorp/random\"]",
+      "instances": [
+        {
+          "index_key": "dev.1",
+          "attributes": {"length": 16},
+          "dependencies": ["data.local_file.config", "local_file.config"]
+        }
+      ]
+    }
+  ]
+}`
+
+func TestConvertRawTFState(t *testing.T) {
+	versions := map[string]string{
+		"registry.terraform.io/hashicorp/local":  "2.2.3",
+		"registry.terraform.io/hashicorp/random": "3.4.3",
+	}
+	got, err := ConvertRawTFState([]byte(rawTFState), versions)
+	assert.Nil(t, err)
+	assert.Equal(t, models.Resources{
+		{
+			ID:         "hashicorp:local:local_file:config",
+			Type:       "Terraform",
+			Attributes: map[string]interface{}{"content": "kusion", "filename": "config.txt"},
+			Extensions: map[string]interface{}{
+				"provider":     "registry.terraform.io/hashicorp/local/2.2.3",
+				"resourceType": "local_file",
+			},
+		},
+		{
+			ID:         "hashicorp:random:random_password:app_db_dev_1",
+			Type:       "Terraform",
+			Attributes: map[string]interface{}{"length": float64(16)},
+			DependsOn:  []string{"hashicorp:local:local_file:config"},
+			Extensions: map[string]interface{}{
+				"provider":     "registry.terraform.io/hashicorp/random/3.4.3",
+				"resourceType": "random_password",
+			},
+		},
+	}, got)
+
+	_, err = ConvertRawTFState([]byte(rawTFState), map[string]string{"registry.terraform.io/hashicorp/local": "2.2.3"})
+	assert.ErrorContains(t, err, "version of the provider registry.terraform.io/hashicorp/random is unknown")
+
+	_, err = ConvertRawTFState([]byte(`{"version": 3}`), versions)
+	assert.ErrorContains(t, err, "unsupported terraform state version 3")
+}
+
+func TestReadProviderVersions(t *testing.T) {
+	lockFile := filepath.Join(t.TempDir(), ".terraform.lock.hcl")
+	assert.Nil(t, os.WriteFile(lockFile, []byte(`
+provider "registry.terraform.io/hashicorp/local" {
+  version = "2.2.3"
+  hashes = ["h1:abc"]
+}
+
+provider "registry.terraform.io/hashicorp/random" {
+  version     = "3.4.3"
+  constraints = ">= 3.0.0"
+}
+`), 0o600))
+
+	versions, err := ReadProviderVersions(lockFile)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"registry.terraform.io/hashicorp/local":  "2.2.3",
+		"registry.terraform.io/hashicorp/random": "3.4.3",
+	}, versions)
+}
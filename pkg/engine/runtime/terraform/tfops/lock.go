@@ -0,0 +1,229 @@
+package tfops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"gopkg.in/yaml.v3"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+const (
+	// LockFile is the file in stack directories recording exact versions and checksums of Terraform providers
+	// of the stack, which is written by kusion providers lock and honored by previews and applies
+	LockFile = "kusion.lock"
+
+	// tfLockFile is the dependency lock file of terraform in workspaces
+	tfLockFile = ".terraform.lock.hcl"
+
+	lockFileHeader = "# This file is maintained by kusion providers lock, manual edits may be lost.\n"
+)
+
+// ProviderLock is a Terraform provider locked at an exact version with checksums of its packages
+type ProviderLock struct {
+	// Source is the address of the provider without its version, e.g. registry.terraform.io/hashicorp/local
+	Source  string   `yaml:"source"`
+	Version string   `yaml:"version"`
+	Hashes  []string `yaml:"hashes,omitempty"`
+}
+
+// Lock is the content of kusion.lock
+type Lock struct {
+	Providers []*ProviderLock `yaml:"providers"`
+}
+
+// ReadLock reads kusion.lock in the stack directory, or returns nil if it does not exist
+func ReadLock(stackDir string) (*Lock, error) {
+	data, err := os.ReadFile(filepath.Join(stackDir, LockFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	lock := &Lock{}
+	if err = yaml.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", LockFile, err)
+	}
+	return lock, nil
+}
+
+// WriteLock writes kusion.lock in the stack directory
+func WriteLock(stackDir string, lock *Lock) error {
+	sort.Slice(lock.Providers, func(i, j int) bool {
+		return lock.Providers[i].Source < lock.Providers[j].Source
+	})
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(stackDir, LockFile), append([]byte(lockFileHeader), data...), 0o644)
+}
+
+// Get returns the lock of the provider source, or nil if it is not locked
+func (l *Lock) Get(source string) *ProviderLock {
+	if l == nil {
+		return nil
+	}
+	for _, p := range l.Providers {
+		if p.Source == source {
+			return p
+		}
+	}
+	return nil
+}
+
+// Check returns an error if providers of Terraform resources are not locked at their versions. A nil lock,
+// i.e. no kusion.lock in the stack, accepts any providers
+func (l *Lock) Check(resources models.Resources) error {
+	if l == nil {
+		return nil
+	}
+	var problems []string
+	for _, addr := range ProviderAddrs(resources) {
+		source, version := splitProviderAddr(addr)
+		switch p := l.Get(source); {
+		case p == nil:
+			problems = append(problems, fmt.Sprintf("%s is not locked", source))
+		case p.Version != version:
+			problems = append(problems, fmt.Sprintf("%s %s is locked at %s", source, version, p.Version))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("providers do not match %s, run kusion providers lock to update it: %s",
+			LockFile, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ProviderAddrs returns sorted unique addresses of providers of Terraform resources with their versions, e.g.
+// registry.terraform.io/hashicorp/local/2.2.3
+func ProviderAddrs(resources models.Resources) []string {
+	seen := map[string]bool{}
+	var addrs []string
+	for i := range resources {
+		if resources[i].Type != runtime.Terraform {
+			continue
+		}
+		addr, ok := resources[i].Extensions["provider"].(string)
+		if !ok || seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// LockProviders downloads the providers and returns their locks with checksums of their packages of the
+// platforms, e.g. linux_amd64, which default to the platform of this machine
+func LockProviders(ctx context.Context, addrs []string, platforms []string) (*Lock, error) {
+	dir, err := os.MkdirTemp("", "kusion-providers-lock")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	required := map[string]interface{}{}
+	for _, addr := range addrs {
+		source, version := splitProviderAddr(addr)
+		parts := strings.Split(source, "/")
+		name := parts[len(parts)-1]
+		if _, ok := required[name]; ok {
+			return nil, fmt.Errorf("provider %s conflicts with another provider named %s", addr, name)
+		}
+		required[name] = map[string]string{"source": source, "version": version}
+	}
+	main, err := json.Marshal(map[string]interface{}{
+		"terraform": map[string]interface{}{"required_providers": required},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(filepath.Join(dir, HCLMAINFILE), main, 0o600); err != nil {
+		return nil, err
+	}
+
+	args := []string{"providers", "lock"}
+	for _, platform := range platforms {
+		args = append(args, "-platform="+platform)
+	}
+	cmd := exec.CommandContext(ctx, "terraform", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("terraform providers lock failed: %s", strings.TrimSpace(string(out)))
+	}
+	return readTerraformLock(filepath.Join(dir, tfLockFile))
+}
+
+// readTerraformLock reads locks of providers from the dependency lock file of terraform
+func readTerraformLock(path string) (*Lock, error) {
+	hclFile, diags := hclparse.NewParser().ParseHCLFile(path)
+	if diags.HasErrors() {
+		return nil, errors.New(diags.Error())
+	}
+	content, diags := hclFile.Body.Content(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: "provider", LabelNames: []string{"source_addr"}}},
+	})
+	if diags.HasErrors() {
+		return nil, errors.New(diags.Error())
+	}
+
+	lock := &Lock{}
+	for _, block := range content.Blocks {
+		attrs, diags := block.Body.Content(&hcl.BodySchema{
+			Attributes: []hcl.AttributeSchema{{Name: "version", Required: true}, {Name: "constraints"}, {Name: "hashes"}},
+		})
+		if diags.HasErrors() {
+			return nil, errors.New(diags.Error())
+		}
+		p := &ProviderLock{Source: block.Labels[0]}
+		if diags = gohcl.DecodeExpression(attrs.Attributes["version"].Expr, nil, &p.Version); diags.HasErrors() {
+			return nil, errors.New(diags.Error())
+		}
+		if hashes, ok := attrs.Attributes["hashes"]; ok {
+			if diags = gohcl.DecodeExpression(hashes.Expr, nil, &p.Hashes); diags.HasErrors() {
+				return nil, errors.New(diags.Error())
+			}
+		}
+		lock.Providers = append(lock.Providers, p)
+	}
+	return lock, nil
+}
+
+// terraformLock renders the dependency lock file of terraform of the provider, so that terraform verifies
+// packages it installs by the checksums
+func terraformLock(p *ProviderLock) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "provider %q {\n", p.Source)
+	fmt.Fprintf(&b, "  version     = %q\n", p.Version)
+	fmt.Fprintf(&b, "  constraints = %q\n", p.Version)
+	b.WriteString("  hashes = [\n")
+	for _, h := range p.Hashes {
+		fmt.Fprintf(&b, "    %q,\n", h)
+	}
+	b.WriteString("  ]\n}\n")
+	return []byte(b.String())
+}
+
+// splitProviderAddr splits the address of the provider into its source and version
+func splitProviderAddr(addr string) (string, string) {
+	i := strings.LastIndex(addr, "/")
+	if i < 0 {
+		return addr, ""
+	}
+	return addr[:i], addr[i+1:]
+}
@@ -0,0 +1,127 @@
+package tfops
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+)
+
+func TestReadWriteLock(t *testing.T) {
+	dir := t.TempDir()
+	lock, err := ReadLock(dir)
+	if err != nil || lock != nil {
+		t.Fatalf("ReadLock(...) without kusion.lock = %v, %v, want nil, nil", lock, err)
+	}
+
+	want := &Lock{Providers: []*ProviderLock{
+		{Source: "registry.terraform.io/hashicorp/random", Version: "3.4.3", Hashes: []string{"h1:random"}},
+		{Source: "registry.terraform.io/hashicorp/local", Version: "2.2.3", Hashes: []string{"h1:local", "zh:local"}},
+	}}
+	if err = WriteLock(dir, want); err != nil {
+		t.Fatalf("WriteLock(...) error: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, LockFile))
+	if !strings.HasPrefix(string(data), lockFileHeader) {
+		t.Errorf("kusion.lock does not start with the header:\n%s", data)
+	}
+
+	got, err := ReadLock(dir)
+	if err != nil {
+		t.Fatalf("ReadLock(...) error: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadLock(...): -want, +got:\n%s", diff)
+	}
+	if got.Providers[0].Source != "registry.terraform.io/hashicorp/local" {
+		t.Errorf("providers in kusion.lock are not sorted by sources: %v", got.Providers[0].Source)
+	}
+}
+
+func TestLockCheck(t *testing.T) {
+	resources := models.Resources{resourceTest, {ID: "default:v1:Namespace:foo", Type: "Kubernetes"}}
+	cases := map[string]struct {
+		lock    *Lock
+		wantErr string
+	}{
+		"noLock": {
+			lock: nil,
+		},
+		"locked": {
+			lock: &Lock{Providers: []*ProviderLock{{Source: "registry.terraform.io/hashicorp/local", Version: "2.2.3"}}},
+		},
+		"notLocked": {
+			lock:    &Lock{},
+			wantErr: "registry.terraform.io/hashicorp/local is not locked",
+		},
+		"otherVersion": {
+			lock:    &Lock{Providers: []*ProviderLock{{Source: "registry.terraform.io/hashicorp/local", Version: "2.2.2"}}},
+			wantErr: "registry.terraform.io/hashicorp/local 2.2.3 is locked at 2.2.2",
+		},
+	}
+
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := tt.lock.Check(resources)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Check(...) error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Check(...) = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProviderAddrs(t *testing.T) {
+	random := models.Resource{
+		ID:         "random_password",
+		Type:       "Terraform",
+		Extensions: map[string]interface{}{"provider": "registry.terraform.io/hashicorp/random/3.4.3"},
+	}
+	got := ProviderAddrs(models.Resources{random, resourceTest, resourceTest, {ID: "foo", Type: "Kubernetes"}})
+	want := []string{"registry.terraform.io/hashicorp/local/2.2.3", "registry.terraform.io/hashicorp/random/3.4.3"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ProviderAddrs(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestTerraformLock(t *testing.T) {
+	want := &ProviderLock{
+		Source:  "registry.terraform.io/hashicorp/local",
+		Version: "2.2.3",
+		Hashes:  []string{"h1:local", "zh:local"},
+	}
+	path := filepath.Join(t.TempDir(), tfLockFile)
+	if err := os.WriteFile(path, terraformLock(want), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readTerraformLock(path)
+	if err != nil {
+		t.Fatalf("readTerraformLock(...) error: %v", err)
+	}
+	if diff := cmp.Diff(&Lock{Providers: []*ProviderLock{want}}, got); diff != "" {
+		t.Errorf("readTerraformLock(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestWriteHCLWithLock(t *testing.T) {
+	w := NewWorkSpace(fs)
+	w.SetResource(&resourceTest)
+	w.SetCacheDir(t.TempDir())
+	p := &ProviderLock{Source: "registry.terraform.io/hashicorp/local", Version: "2.2.3", Hashes: []string{"h1:local"}}
+	w.SetLock(&Lock{Providers: []*ProviderLock{p}})
+	if err := w.WriteHCL(); err != nil {
+		t.Fatalf("writeHCL error: %v", err)
+	}
+
+	got, _ := fs.ReadFile(filepath.Join(w.tfCacheDir, tfLockFile))
+	if diff := cmp.Diff(string(terraformLock(p)), string(got)); diff != "" {
+		t.Errorf("WriteHCL(...): -want lock, +got lock:\n%s", diff)
+	}
+}
@@ -0,0 +1,211 @@
+package tfops
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/log"
+)
+
+const (
+	// envReattachProviders tells terraform to connect to running provider processes instead of starting them
+	envReattachProviders = "TF_REATTACH_PROVIDERS"
+
+	// pluginMagicCookie is the handshake cookie of terraform provider plugins
+	pluginMagicCookie = "TF_PLUGIN_MAGIC_COOKIE=d602bf8f470bc67ca7faa0386276bbdd4330efaf76d1a219cb4d6991ca9872b2"
+
+	// providerStartTimeout is how long to wait for a provider process to be ready
+	providerStartTimeout = 30 * time.Second
+)
+
+// ProviderPool keeps provider plugin processes alive and shares them across terraform commands of resources
+// with the same provider, instead of starting a provider process in each command. Terraform connects to the
+// running providers by TF_REATTACH_PROVIDERS. Commands using the pool must not run concurrently, since each of
+// them configures the shared provider with the config of its resource.
+type ProviderPool struct {
+	lock      sync.Mutex
+	providers map[string]*providerProcess
+
+	// env overrides environment variables of provider processes, e.g. credentials of the stack
+	env runtime.Env
+}
+
+// providerProcess is a running provider plugin
+type providerProcess struct {
+	// source is the provider source address, e.g. registry.terraform.io/hashicorp/local
+	source   string
+	cmd      *exec.Cmd
+	reattach reattachConfig
+	// exited is closed when the process exits
+	exited chan struct{}
+}
+
+// reattachConfig is the config of a running provider in TF_REATTACH_PROVIDERS
+type reattachConfig struct {
+	Protocol        string
+	ProtocolVersion int
+	Pid             int
+	Test            bool
+	Addr            reattachAddr
+}
+
+type reattachAddr struct {
+	Network string
+	String  string
+}
+
+// NewProviderPool returns an empty provider pool, whose provider processes run with the env
+func NewProviderPool(env runtime.Env) *ProviderPool {
+	return &ProviderPool{providers: map[string]*providerProcess{}, env: env}
+}
+
+// ReattachEnv returns the TF_REATTACH_PROVIDERS env of the provider, e.g. registry.terraform.io/hashicorp/local/2.2.3,
+// whose plugin is installed in the terraform working directory. The provider process is started if it is not
+// running yet.
+func (p *ProviderPool) ReattachEnv(provider, workDir string) (string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	pp, ok := p.providers[provider]
+	if ok && pp.running() {
+		return pp.env()
+	}
+	pp, err := startProvider(provider, workDir, p.env)
+	if err != nil {
+		return "", err
+	}
+	p.providers[provider] = pp
+	return pp.env()
+}
+
+// Close stops all provider processes in the pool
+func (p *ProviderPool) Close() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var errs []string
+	for provider, pp := range p.providers {
+		if err := pp.stop(); err != nil {
+			errs = append(errs, fmt.Sprintf("stop provider %s failed: %v", provider, err))
+		}
+		delete(p.providers, provider)
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// startProvider starts the provider plugin installed by terraform init in the working directory, and reads the
+// address it serves at from its handshake line, e.g. 1|5|unix|/tmp/plugin123|grpc|
+func startProvider(provider, workDir string, env runtime.Env) (*providerProcess, error) {
+	parts := strings.Split(provider, "/")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid provider %s", provider)
+	}
+	source := strings.Join(parts[:len(parts)-1], "/")
+	binaries, err := filepath.Glob(filepath.Join(workDir, ".terraform", "providers", provider,
+		goruntime.GOOS+"_"+goruntime.GOARCH, tfProviderPrefix+"-*"))
+	if err != nil {
+		return nil, err
+	}
+	if len(binaries) == 0 {
+		return nil, fmt.Errorf("plugin of provider %s is not installed in %s", provider, workDir)
+	}
+
+	cmd := exec.Command(binaries[0])
+	cmd.Env = append(env.Environ(), pluginMagicCookie, "PLUGIN_PROTOCOL_VERSIONS=5,6")
+	cmd.Stderr = io.Discard
+	stdout, stdoutWriter := io.Pipe()
+	cmd.Stdout = stdoutWriter
+	if err = cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start provider %s failed: %w", provider, err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		_ = stdoutWriter.Close()
+		close(exited)
+	}()
+
+	handshake := make(chan string, 1)
+	reader := bufio.NewReader(stdout)
+	go func() {
+		line, _ := reader.ReadString('\n')
+		handshake <- line
+		// drain later outputs, so that the provider never blocks on writing them
+		_, _ = io.Copy(io.Discard, reader)
+	}()
+
+	var line string
+	select {
+	case line = <-handshake:
+	case <-time.After(providerStartTimeout):
+	}
+	reattach, err := parseHandshake(line)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		return nil, fmt.Errorf("start provider %s failed: %w", provider, err)
+	}
+	reattach.Pid = cmd.Process.Pid
+	log.Infof("provider %s is running at %s", provider, reattach.Addr.String)
+	return &providerProcess{source: source, cmd: cmd, reattach: reattach, exited: exited}, nil
+}
+
+func parseHandshake(line string) (reattachConfig, error) {
+	parts := strings.Split(strings.TrimSpace(line), "|")
+	if len(parts) < 5 {
+		return reattachConfig{}, fmt.Errorf("invalid handshake %q", strings.TrimSpace(line))
+	}
+	version, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return reattachConfig{}, fmt.Errorf("invalid protocol version in handshake %q", line)
+	}
+	return reattachConfig{
+		Protocol:        parts[4],
+		ProtocolVersion: version,
+		// Test keeps the provider running after terraform exits
+		Test: true,
+		Addr: reattachAddr{Network: parts[2], String: parts[3]},
+	}, nil
+}
+
+func (pp *providerProcess) env() (string, error) {
+	data, err := json.Marshal(map[string]reattachConfig{pp.source: pp.reattach})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s=%s", envReattachProviders, data), nil
+}
+
+func (pp *providerProcess) running() bool {
+	select {
+	case <-pp.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+func (pp *providerProcess) stop() error {
+	if !pp.running() {
+		return nil
+	}
+	if err := pp.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	<-pp.exited
+	return nil
+}
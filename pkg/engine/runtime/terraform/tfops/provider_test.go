@@ -0,0 +1,67 @@
+package tfops
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const fakeProvider = "registry.terraform.io/hashicorp/local/2.2.3"
+
+// installFakeProvider installs a provider plugin which prints the handshake and waits
+func installFakeProvider(t *testing.T, handshake string) string {
+	workDir := t.TempDir()
+	dir := filepath.Join(workDir, ".terraform", "providers", fakeProvider, goruntime.GOOS+"_"+goruntime.GOARCH)
+	assert.Nil(t, os.MkdirAll(dir, os.ModePerm))
+	script := "#!/bin/sh\necho '" + handshake + "'\nexec sleep 60\n"
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "terraform-provider-local_v2.2.3_x5"), []byte(script), 0o700))
+	return workDir
+}
+
+func TestProviderPool(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("the fake provider is a shell script")
+	}
+	workDir := installFakeProvider(t, "1|5|unix|/tmp/plugin123|grpc|")
+	pool := NewProviderPool(nil)
+
+	env, err := pool.ReattachEnv(fakeProvider, workDir)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(env, envReattachProviders+"="))
+	var providers map[string]reattachConfig
+	assert.Nil(t, json.Unmarshal([]byte(strings.TrimPrefix(env, envReattachProviders+"=")), &providers))
+	config := providers["registry.terraform.io/hashicorp/local"]
+	assert.Equal(t, "grpc", config.Protocol)
+	assert.Equal(t, 5, config.ProtocolVersion)
+	assert.True(t, config.Test)
+	assert.Equal(t, reattachAddr{Network: "unix", String: "/tmp/plugin123"}, config.Addr)
+
+	// the running provider is shared
+	pp := pool.providers[fakeProvider]
+	again, err := pool.ReattachEnv(fakeProvider, workDir)
+	assert.Nil(t, err)
+	assert.Equal(t, env, again)
+	assert.Same(t, pp, pool.providers[fakeProvider])
+
+	assert.Nil(t, pool.Close())
+	assert.False(t, pp.running())
+	assert.Empty(t, pool.providers)
+}
+
+func TestProviderPool_Failed(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("the fake provider is a shell script")
+	}
+	pool := NewProviderPool(nil)
+	_, err := pool.ReattachEnv(fakeProvider, t.TempDir())
+	assert.ErrorContains(t, err, "is not installed")
+
+	_, err = pool.ReattachEnv(fakeProvider, installFakeProvider(t, "unexpected output"))
+	assert.ErrorContains(t, err, "invalid handshake")
+	assert.Empty(t, pool.providers)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/spf13/afero"
 
 	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
 	"kusionstack.io/kusion/pkg/log"
 	"kusionstack.io/kusion/pkg/util/kfile"
 )
@@ -27,6 +28,8 @@ const (
 	envLog           = "TF_LOG"
 	tfDebugLOG       = "DEBUG"
 	envLogFile       = "TF_LOG_PATH"
+	envWorkspace     = "TF_WORKSPACE"
+	tfStateDir       = "terraform.tfstate.d"
 	tfProviderPrefix = "terraform-provider"
 )
 
@@ -37,6 +40,16 @@ type WorkSpace struct {
 	fs         afero.Afero
 	stackDir   string
 	tfCacheDir string
+	providers  *ProviderPool
+
+	// providerConfigs override configs of providers by provider names, e.g. the region pinned by the stack
+	providerConfigs map[string]map[string]interface{}
+
+	// lock is kusion.lock of the stack, whose checksums of providers are verified by terraform
+	lock *Lock
+
+	// env overrides environment variables of terraform commands, e.g. $TF_WORKSPACE pinned by the stack
+	env runtime.Env
 }
 
 // SetResource set workspace resource
@@ -49,6 +62,26 @@ func (w *WorkSpace) SetFS(fs afero.Afero) {
 	w.fs = fs
 }
 
+// SetProviderPool set the pool of provider processes shared by terraform commands
+func (w *WorkSpace) SetProviderPool(providers *ProviderPool) {
+	w.providers = providers
+}
+
+// SetProviderConfigs set configs overriding configs of providers in resources by provider names
+func (w *WorkSpace) SetProviderConfigs(configs map[string]map[string]interface{}) {
+	w.providerConfigs = configs
+}
+
+// SetLock set kusion.lock of the stack
+func (w *WorkSpace) SetLock(lock *Lock) {
+	w.lock = lock
+}
+
+// SetEnv set environment variables overriding the ones of this process in terraform commands
+func (w *WorkSpace) SetEnv(env runtime.Env) {
+	w.env = env
+}
+
 // SetStackDir set workspace work directory.
 func (w *WorkSpace) SetStackDir(stackDir string) {
 	w.stackDir = stackDir
@@ -71,18 +104,32 @@ func (w *WorkSpace) WriteHCL() error {
 	provider := strings.Split(w.resource.Extensions["provider"].(string), "/")
 	resourceType := w.resource.Extensions["resourceType"].(string)
 	resourceNames := strings.Split(w.resource.ResourceKey(), ":")
+	providerName := provider[len(provider)-2]
+	providerMeta := w.resource.Extensions["providerMeta"]
+	if override := w.providerConfigs[providerName]; len(override) > 0 {
+		meta := map[string]interface{}{}
+		if m, ok := providerMeta.(map[string]interface{}); ok {
+			for k, v := range m {
+				meta[k] = v
+			}
+		}
+		for k, v := range override {
+			meta[k] = v
+		}
+		providerMeta = meta
+	}
 
 	m := map[string]interface{}{
 		"terraform": map[string]interface{}{
 			"required_providers": map[string]interface{}{
-				provider[len(provider)-2]: map[string]string{
+				providerName: map[string]string{
 					"source":  strings.Join(provider[:len(provider)-1], "/"),
 					"version": provider[len(provider)-1],
 				},
 			},
 		},
 		"provider": map[string]interface{}{
-			provider[len(provider)-2]: w.resource.Extensions["providerMeta"],
+			providerName: providerMeta,
 		},
 		"resource": map[string]interface{}{
 			resourceType: map[string]interface{}{
@@ -106,11 +153,24 @@ func (w *WorkSpace) WriteHCL() error {
 			return err
 		}
 	}
+	// the local backend of terraform lists directories of states as workspaces, so the workspace selected by
+	// $TF_WORKSPACE exists before its first apply
+	if err = w.fs.MkdirAll(filepath.Dir(w.statePath()), os.ModePerm); err != nil {
+		return fmt.Errorf("create workspace error: %v", err)
+	}
 	err = w.fs.WriteFile(filepath.Join(w.tfCacheDir, HCLMAINFILE), hclMain, 0o600)
 	if err != nil {
 		return fmt.Errorf("write hcl main.tf.json error: %v", err)
 	}
 
+	// providers locked by kusion.lock are installed only if their checksums match
+	source, _ := splitProviderAddr(w.resource.Extensions["provider"].(string))
+	if p := w.lock.Get(source); p != nil {
+		if err = w.fs.WriteFile(filepath.Join(w.tfCacheDir, tfLockFile), terraformLock(p), 0o600); err != nil {
+			return fmt.Errorf("write %s error: %v", tfLockFile, err)
+		}
+	}
+
 	return nil
 }
 
@@ -138,7 +198,12 @@ func (w *WorkSpace) WriteTFState(priorState *models.Resource) error {
 		return fmt.Errorf("marshal hcl state error: %v", err)
 	}
 
-	err = w.fs.WriteFile(filepath.Join(w.tfCacheDir, TFSTATEFILE), hclState, os.ModePerm)
+	// the directory of the state of the workspace also creates the workspace
+	statePath := w.statePath()
+	if err = w.fs.MkdirAll(filepath.Dir(statePath), os.ModePerm); err != nil {
+		return fmt.Errorf("create the directory of the state error: %v", err)
+	}
+	err = w.fs.WriteFile(statePath, hclState, os.ModePerm)
 	if err != nil {
 		return fmt.Errorf("write hcl  error: %v", err)
 	}
@@ -150,7 +215,7 @@ func (w *WorkSpace) InitWorkSpace(ctx context.Context) error {
 	chdir := fmt.Sprintf("-chdir=%s", w.tfCacheDir)
 	cmd := exec.CommandContext(ctx, "terraform", chdir, "init")
 	cmd.Dir = w.stackDir
-	cmd.Env = append(os.Environ(), envTFLog, w.getEnvProviderLogPath())
+	cmd.Env = append(w.env.Environ(), envTFLog, w.getEnvProviderLogPath())
 	_, err := cmd.Output()
 	if e, ok := err.(*exec.ExitError); ok {
 		return errors.New(string(e.Stderr))
@@ -168,7 +233,7 @@ func (w *WorkSpace) Apply(ctx context.Context) (*TFState, error) {
 
 	cmd := exec.CommandContext(ctx, "terraform", chdir, "apply", "-auto-approve", "-json", "-lock=false")
 	cmd.Dir = w.stackDir
-	cmd.Env = append(os.Environ(), envTFLog, w.getEnvProviderLogPath())
+	cmd.Env = w.providerEnv()
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, TFError(out)
@@ -183,7 +248,7 @@ func (w *WorkSpace) Apply(ctx context.Context) (*TFState, error) {
 // Read make terraform show call. Return terraform state model
 // TODO: terraform show livestate.
 func (w *WorkSpace) Read(ctx context.Context) (*TFState, error) {
-	_, err := w.fs.Stat(filepath.Join(w.tfCacheDir, "terraform.tfstate"))
+	_, err := w.fs.Stat(w.statePath())
 	if os.IsNotExist(err) {
 		return nil, nil
 	}
@@ -193,6 +258,7 @@ func (w *WorkSpace) Read(ctx context.Context) (*TFState, error) {
 	chdir := fmt.Sprintf("-chdir=%s", w.tfCacheDir)
 	cmd := exec.CommandContext(ctx, "terraform", chdir, "show", "-json")
 	cmd.Dir = w.stackDir
+	cmd.Env = w.env.Environ()
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, TFError(out)
@@ -213,7 +279,7 @@ func (w *WorkSpace) RefreshOnly(ctx context.Context) (*TFState, error) {
 	}
 	cmd := exec.CommandContext(ctx, "terraform", chdir, "apply", "-auto-approve", "-json", "--refresh-only", "-lock=false")
 	cmd.Dir = w.stackDir
-	cmd.Env = append(os.Environ(), envTFLog, w.getEnvProviderLogPath())
+	cmd.Env = w.providerEnv()
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, TFError(out)
@@ -230,7 +296,7 @@ func (w *WorkSpace) Destroy(ctx context.Context) error {
 	chdir := fmt.Sprintf("-chdir=%s", w.tfCacheDir)
 	cmd := exec.CommandContext(ctx, "terraform", chdir, "destroy", "-auto-approve")
 	cmd.Dir = w.stackDir
-	cmd.Env = append(os.Environ(), envTFLog, w.getEnvProviderLogPath())
+	cmd.Env = w.providerEnv()
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return TFError(out)
@@ -306,7 +372,7 @@ func (w *WorkSpace) CleanAndInitWorkspace(ctx context.Context, chdir string) err
 func (w *WorkSpace) checkHashUpdate(ctx context.Context, chdir string) bool {
 	cmd := exec.CommandContext(ctx, "terraform", chdir, "providers", "lock")
 	cmd.Dir = w.stackDir
-	cmd.Env = append(os.Environ(), envTFLog, w.getEnvProviderLogPath())
+	cmd.Env = append(w.env.Environ(), envTFLog, w.getEnvProviderLogPath())
 	output, _ := cmd.Output()
 	return strings.Contains(string(output), "Terraform has updated the lock file")
 }
@@ -320,6 +386,31 @@ func (w *WorkSpace) checkVersionUpdate(ctx context.Context) (bool, error) {
 	return providerAddr != w.resource.Extensions["provider"].(string), nil
 }
 
+// providerEnv returns the environmental variables of terraform commands calling the provider, which connect to
+// the running provider process in the provider pool instead of starting a new one if the workspace has the pool
+func (w *WorkSpace) providerEnv() []string {
+	env := append(w.env.Environ(), envTFLog, w.getEnvProviderLogPath())
+	if w.providers == nil {
+		return env
+	}
+	reattach, err := w.providers.ReattachEnv(w.resource.Extensions["provider"].(string), w.tfCacheDir)
+	if err != nil {
+		log.Warnf("reuse the provider process failed, terraform will start its own: %v", err)
+		return env
+	}
+	return append(env, reattach)
+}
+
+// statePath returns the path of the local state of the workspace selected by $TF_WORKSPACE, which is in the
+// directory of the workspace unless it is the default workspace
+func (w *WorkSpace) statePath() string {
+	workspace := w.env.Getenv(envWorkspace)
+	if workspace == "" || workspace == "default" {
+		return filepath.Join(w.tfCacheDir, TFSTATEFILE)
+	}
+	return filepath.Join(w.tfCacheDir, tfStateDir, workspace, TFSTATEFILE)
+}
+
 // getProviderLogPath returns the provider log path environmental variable,
 // the environmental variables that determine the provider log go to a file.
 func (w *WorkSpace) getEnvProviderLogPath() string {
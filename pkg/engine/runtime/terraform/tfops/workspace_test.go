@@ -82,6 +82,18 @@ func TestWriteHCL(t *testing.T) {
 				maintf: "{\"provider\":{\"local\":null},\"resource\":{\"local_file\":{\"kusion_example\":{\"content\":\"kusion\",\"filename\":\"test.txt\"}}},\"terraform\":{\"required_providers\":{\"local\":{\"source\":\"registry.terraform.io/hashicorp/local\",\"version\":\"2.2.3\"}}}}",
 			},
 		},
+		"overrideProviderConfigs": {
+			args: args{
+				w: func() *WorkSpace {
+					w := NewWorkSpace(fs)
+					w.SetProviderConfigs(map[string]map[string]interface{}{"local": {"region": "us-east-1"}, "aws": {"profile": "prod"}})
+					return w
+				}(),
+			},
+			want: want{
+				maintf: "{\"provider\":{\"local\":{\"region\":\"us-east-1\"}},\"resource\":{\"local_file\":{\"kusion_example\":{\"content\":\"kusion\",\"filename\":\"test.txt\"}}},\"terraform\":{\"required_providers\":{\"local\":{\"source\":\"registry.terraform.io/hashicorp/local\",\"version\":\"2.2.3\"}}}}",
+			},
+		},
 	}
 
 	for name, tt := range cases {
@@ -353,3 +365,19 @@ func TestDestory(t *testing.T) {
 		})
 	}
 }
+
+func TestStatePath(t *testing.T) {
+	cases := map[string]string{
+		"":          filepath.Join(".test", TFSTATEFILE),
+		"default":   filepath.Join(".test", TFSTATEFILE),
+		"demo-prod": filepath.Join(".test", "terraform.tfstate.d", "demo-prod", TFSTATEFILE),
+	}
+	w := NewWorkSpace(fs)
+	w.SetCacheDir(".test")
+	for workspace, want := range cases {
+		t.Setenv(envWorkspace, workspace)
+		if got := w.statePath(); got != want {
+			t.Errorf("statePath() of the workspace %q = %s, want %s", workspace, got, want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package sensitive
+
+import (
+	"regexp"
+)
+
+// Redacted replaces sensitive values redacted from texts
+const Redacted = "(redacted)"
+
+var (
+	// assignments matches values assigned to keys named like passwords, secrets and tokens, e.g.
+	// password=xxx, "secretKey": "xxx" and token: xxx
+	assignments = regexp.MustCompile(`(?i)((?:[\w.-]*(?:password|passwd|passphrase|secret|token|access[_-]?key|` +
+		`private[_-]?key|api[_-]?key|credentials?))"?\s*[:=]\s*)("[^"]*"|'[^']*'|[^\s,;&"'}\]]+)`)
+
+	// bearerTokens matches tokens in authorization headers
+	bearerTokens = regexp.MustCompile(`(?i)(\b(?:bearer|basic)\s+)[\w.~+/=-]+`)
+
+	// privateKeys matches PEM encoded private keys
+	privateKeys = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+// RedactText redacts values which look sensitive in free-form texts like logs and errors, such as values of
+// passwords and tokens, bearer tokens and private keys. Resources should be masked by MaskResource instead,
+// which knows their sensitive attributes.
+func RedactText(text string) string {
+	text = privateKeys.ReplaceAllString(text, Redacted)
+	text = bearerTokens.ReplaceAllString(text, "${1}"+Redacted)
+	return assignments.ReplaceAllString(text, "${1}"+Redacted)
+}
@@ -0,0 +1,60 @@
+package sensitive
+
+import (
+	"encoding/base64"
+	"unicode/utf8"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+// DecodeSecret returns a copy of the Kubernetes Secret whose data is decoded from base64 and merged with its
+// stringData like the API server does, so that diffs compare values instead of their encodings, e.g. a value
+// moved from stringData to data is unchanged. Values which are not valid UTF-8 after decoding are kept encoded.
+// Other resources are returned as they are. Decoded Secrets must be masked before they are printed unless
+// secrets are shown explicitly.
+func DecodeSecret(res *models.Resource) *models.Resource {
+	if res == nil || res.Type != runtime.Kubernetes || res.Attributes["kind"] != "Secret" {
+		return res
+	}
+	data, _ := res.Attributes["data"].(map[string]interface{})
+	stringData, _ := res.Attributes["stringData"].(map[string]interface{})
+	if len(data) == 0 && len(stringData) == 0 {
+		return res
+	}
+
+	decoded := make(map[string]interface{}, len(data)+len(stringData))
+	for k, v := range data {
+		decoded[k] = v
+		if s, ok := v.(string); ok {
+			if b, err := base64.StdEncoding.DecodeString(s); err == nil && utf8.Valid(b) {
+				decoded[k] = string(b)
+			}
+		}
+	}
+	for k, v := range stringData {
+		decoded[k] = v
+	}
+
+	result := *res
+	attributes := make(map[string]interface{}, len(res.Attributes))
+	for k, v := range res.Attributes {
+		attributes[k] = v
+	}
+	delete(attributes, "stringData")
+	attributes["data"] = decoded
+	result.Attributes = attributes
+	return &result
+}
+
+// Decode decodes the value if it is a Kubernetes Secret, which is the type of data of change steps
+func Decode(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *models.Resource:
+		return DecodeSecret(v)
+	case models.Resource:
+		return *DecodeSecret(&v)
+	default:
+		return value
+	}
+}
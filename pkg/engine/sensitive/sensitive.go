@@ -0,0 +1,218 @@
+// Package sensitive finds sensitive attributes of resources, such as data of Kubernetes Secrets and
+// passwords of Terraform resources, and masks them before resources are printed or logged. Masked values
+// keep a short keyed digest of the original values, so that changes of them are still detectable in diffs
+// of the same run, while the digests can not be brute-forced to recover weak secrets.
+package sensitive
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+const (
+	// AnnotationSensitiveFields is the annotation of Kubernetes resources which lists paths of their
+	// sensitive attributes separated by commas, e.g. "spec.template.spec.containers.*.env"
+	AnnotationSensitiveFields = "kusionstack.io/sensitive-fields"
+
+	// ExtensionSensitiveFields is the extension of resources which lists paths of their sensitive attributes
+	ExtensionSensitiveFields = "sensitiveFields"
+
+	// wildcard matches any key of maps and any index of lists in paths
+	wildcard = "*"
+)
+
+// sensitiveNames matches names of Terraform attributes whose values are sensitive
+var sensitiveNames = regexp.MustCompile(
+	`(?i)(^|_)(password|passwd|passphrase|secret|secret_key|access_key|private_key|api_key|token|credentials?)$`)
+
+// Paths returns paths of sensitive attributes of the resource. Data of Kubernetes Secrets, attributes of
+// Terraform resources named like passwords, secrets and tokens, and paths listed in the annotation
+// kusionstack.io/sensitive-fields or the extension sensitiveFields are sensitive.
+func Paths(res *models.Resource) [][]string {
+	if res == nil {
+		return nil
+	}
+	var paths [][]string
+	addPaths := func(fields string) {
+		for _, f := range strings.Split(fields, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				paths = append(paths, strings.Split(f, "."))
+			}
+		}
+	}
+
+	if res.Type == runtime.Kubernetes {
+		if res.Attributes["kind"] == "Secret" {
+			paths = append(paths, []string{"data", wildcard}, []string{"stringData", wildcard})
+		}
+		if metadata, ok := res.Attributes["metadata"].(map[string]interface{}); ok {
+			if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+				if fields, ok := annotations[AnnotationSensitiveFields].(string); ok {
+					addPaths(fields)
+				}
+			}
+		}
+	}
+
+	switch fields := res.Extensions[ExtensionSensitiveFields].(type) {
+	case string:
+		addPaths(fields)
+	case []interface{}:
+		for _, f := range fields {
+			if s, ok := f.(string); ok {
+				addPaths(s)
+			}
+		}
+	case []string:
+		addPaths(strings.Join(fields, ","))
+	}
+	return paths
+}
+
+// MaskResource returns a copy of the resource whose sensitive attributes are masked, or the resource
+// itself if it has no sensitive attributes
+func MaskResource(res *models.Resource) *models.Resource {
+	if res == nil {
+		return nil
+	}
+	paths := Paths(res)
+	byName := res.Type != runtime.Kubernetes
+	if len(paths) == 0 && !byName {
+		return res
+	}
+
+	masked := *res
+	attributes := copyValue(res.Attributes).(map[string]interface{})
+	for _, p := range paths {
+		maskPath(attributes, p)
+	}
+	if byName {
+		maskByName(attributes)
+	}
+	masked.Attributes = attributes
+	return &masked
+}
+
+// Mask masks the value if it is a resource, which is the type of data of change steps
+func Mask(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *models.Resource:
+		return MaskResource(v)
+	case models.Resource:
+		return *MaskResource(&v)
+	default:
+		return value
+	}
+}
+
+// MaskResources returns copies of the resources whose sensitive attributes are masked
+func MaskResources(resources models.Resources) models.Resources {
+	if resources == nil {
+		return nil
+	}
+	result := make(models.Resources, len(resources))
+	for i := range resources {
+		result[i] = *MaskResource(&resources[i])
+	}
+	return result
+}
+
+// maskKey is the random key of digests of masked values, which is generated once per process, so that the
+// digests are only comparable within the same run
+var maskKey = newMaskKey()
+
+func newMaskKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Errorf("failed to generate the key to mask sensitive values: %w", err))
+	}
+	return key
+}
+
+// MaskValue returns the placeholder of the sensitive value, which contains an HMAC digest of the value keyed
+// by a random key of the process
+func MaskValue(value interface{}) string {
+	data, _ := json.Marshal(value)
+	mac := hmac.New(sha256.New, maskKey)
+	mac.Write(data)
+	return fmt.Sprintf("(sensitive value hmac:%s)", hex.EncodeToString(mac.Sum(nil))[:12])
+}
+
+// maskPath masks values at the path in the object
+func maskPath(value interface{}, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	key, last := path[0], len(path) == 1
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			if key != wildcard && key != k {
+				continue
+			}
+			if last {
+				v[k] = MaskValue(item)
+			} else {
+				maskPath(item, path[1:])
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if key != wildcard && key != strconv.Itoa(i) {
+				continue
+			}
+			if last {
+				v[i] = MaskValue(item)
+			} else {
+				maskPath(item, path[1:])
+			}
+		}
+	}
+}
+
+// maskByName masks values whose keys are names of sensitive attributes
+func maskByName(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			if _, isMap := item.(map[string]interface{}); !isMap && item != nil && sensitiveNames.MatchString(k) {
+				v[k] = MaskValue(item)
+				continue
+			}
+			maskByName(item)
+		}
+	case []interface{}:
+		for _, item := range v {
+			maskByName(item)
+		}
+	}
+}
+
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			result[k] = copyValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = copyValue(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
@@ -0,0 +1,113 @@
+package sensitive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+func TestMaskResource(t *testing.T) {
+	t.Run("secret data", func(t *testing.T) {
+		res := &models.Resource{
+			ID:   "v1:Secret:default:db",
+			Type: runtime.Kubernetes,
+			Attributes: map[string]interface{}{
+				"kind":       "Secret",
+				"data":       map[string]interface{}{"password": "c2VjcmV0"},
+				"stringData": map[string]interface{}{"user": "admin"},
+				"type":       "Opaque",
+			},
+		}
+		masked := MaskResource(res)
+		assert.Equal(t, MaskValue("c2VjcmV0"), masked.Attributes["data"].(map[string]interface{})["password"])
+		assert.Equal(t, MaskValue("admin"), masked.Attributes["stringData"].(map[string]interface{})["user"])
+		assert.Equal(t, "Opaque", masked.Attributes["type"])
+		// the original resource is not changed
+		assert.Equal(t, "c2VjcmV0", res.Attributes["data"].(map[string]interface{})["password"])
+	})
+
+	t.Run("annotation paths", func(t *testing.T) {
+		res := &models.Resource{
+			Type: runtime.Kubernetes,
+			Attributes: map[string]interface{}{
+				"kind": "Deployment",
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{AnnotationSensitiveFields: "spec.containers.*.env"},
+				},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "app", "env": []interface{}{"TOKEN=abc"}},
+					},
+				},
+			},
+		}
+		masked := MaskResource(res)
+		container := masked.Attributes["spec"].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, MaskValue([]interface{}{"TOKEN=abc"}), container["env"])
+		assert.Equal(t, "app", container["name"])
+	})
+
+	t.Run("terraform attributes by name", func(t *testing.T) {
+		res := &models.Resource{
+			Type: runtime.Terraform,
+			Attributes: map[string]interface{}{
+				"name":            "db",
+				"master_password": "p@ss",
+				"config":          map[string]interface{}{"api_key": "key"},
+			},
+			Extensions: map[string]interface{}{ExtensionSensitiveFields: []interface{}{"name"}},
+		}
+		masked := MaskResource(res)
+		assert.Equal(t, MaskValue("db"), masked.Attributes["name"])
+		assert.Equal(t, MaskValue("p@ss"), masked.Attributes["master_password"])
+		assert.Equal(t, MaskValue("key"), masked.Attributes["config"].(map[string]interface{})["api_key"])
+	})
+
+	t.Run("no sensitive attributes", func(t *testing.T) {
+		res := &models.Resource{Type: runtime.Kubernetes, Attributes: map[string]interface{}{"kind": "ConfigMap"}}
+		assert.Same(t, res, MaskResource(res))
+		assert.Nil(t, MaskResource(nil))
+	})
+}
+
+func TestMaskValue(t *testing.T) {
+	assert.Equal(t, MaskValue("a"), MaskValue("a"))
+	assert.NotEqual(t, MaskValue("a"), MaskValue("b"))
+	assert.NotContains(t, MaskValue("secret"), "secret")
+
+	// digests are keyed by the process, so that they can not be brute-forced offline
+	sum := sha256.Sum256([]byte(`"secret"`))
+	assert.NotContains(t, MaskValue("secret"), hex.EncodeToString(sum[:])[:12])
+	origin := maskKey
+	defer func() { maskKey = origin }()
+	masked := MaskValue("secret")
+	maskKey = newMaskKey()
+	assert.NotEqual(t, masked, MaskValue("secret"))
+}
+
+func TestDecodeSecret(t *testing.T) {
+	secret := &models.Resource{
+		ID:   "v1:Secret:default:db",
+		Type: runtime.Kubernetes,
+		Attributes: map[string]interface{}{
+			"kind":       "Secret",
+			"data":       map[string]interface{}{"password": "cGFzc3dvcmQ=", "binary": "/w==", "user": "cm9vdA=="},
+			"stringData": map[string]interface{}{"user": "admin"},
+		},
+	}
+	decoded := DecodeSecret(secret)
+	assert.Equal(t, map[string]interface{}{
+		"kind": "Secret",
+		"data": map[string]interface{}{"password": "password", "binary": "/w==", "user": "admin"},
+	}, decoded.Attributes)
+	// the original secret is not modified
+	assert.Equal(t, "cGFzc3dvcmQ=", secret.Attributes["data"].(map[string]interface{})["password"])
+
+	configMap := &models.Resource{Type: runtime.Kubernetes, Attributes: map[string]interface{}{"kind": "ConfigMap"}}
+	assert.Same(t, configMap, DecodeSecret(configMap))
+}
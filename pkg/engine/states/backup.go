@@ -0,0 +1,67 @@
+package states
+
+import (
+	"bytes"
+	"fmt"
+
+	"kusionstack.io/kusion/pkg/log"
+)
+
+// BackupObjectName is the name of backup objects, which are stored in the same directory as the state objects of
+// remote storages. Like LockObjectName, it does not share the prefix of the state object names.
+const BackupObjectName = "kusion_state.backup.json"
+
+// StateBackuper is an optional interface for state storages which can keep a backup of the state of a stack,
+// which is written before each operation and restored by kusion state restore-backup
+type StateBackuper interface {
+	// Backup saves a copy of the state, which replaces the previous backup of the stack
+	Backup(state *State) error
+
+	// GetBackup returns the backup of the state, or nil if there is none
+	GetBackup(query *StateQuery) (*State, error)
+}
+
+// BackupState backs up the latest state of the stack if the storage is a StateBackuper, and returns the state
+// backed up, which is nil if there is no state or the storage does not support backups
+func BackupState(storage StateStorage, query *StateQuery) (*State, error) {
+	backuper, ok := storage.(StateBackuper)
+	if !ok {
+		log.Infof("the state storage %T does not support backups", storage)
+		return nil, nil
+	}
+	state, err := storage.GetLatestState(query)
+	if err != nil {
+		return nil, fmt.Errorf("get the state to back up failed: %w", err)
+	}
+	if state == nil {
+		return nil, nil
+	}
+	if err = backuper.Backup(state); err != nil {
+		return nil, fmt.Errorf("back up the state failed: %w", err)
+	}
+	return state, nil
+}
+
+// WriteBackupObject writes the state to the backup object of the key
+func WriteBackupObject(store LockObjectStore, key string, state *State) error {
+	var buf bytes.Buffer
+	if err := Encode(&buf, state); err != nil {
+		return err
+	}
+	if err := store.WriteLockObject(key, buf.Bytes()); err != nil {
+		return fmt.Errorf("write the backup %s failed: %w", key, err)
+	}
+	return nil
+}
+
+// ReadBackupObject reads the state in the backup object of the key, or nil if it does not exist
+func ReadBackupObject(store LockObjectStore, key string) (*State, error) {
+	data, err := store.ReadLockObject(key)
+	if err != nil {
+		return nil, fmt.Errorf("read the backup %s failed: %w", key, err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return Decode(bytes.NewReader(data))
+}
@@ -0,0 +1,195 @@
+package states
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+)
+
+// resourcesPlaceholder is where resources are in the indented JSON of a state whose resources are nil
+var resourcesPlaceholder = []byte("\n  \"resources\": null")
+
+// Encode writes the state as indented JSON, which is the same as json.MarshalIndent(state, "", "  "). Resources
+// are marshaled and written one by one, so that the JSON of the whole state is never held in memory, which is
+// huge for stacks with thousands of resources.
+func Encode(w io.Writer, state *State) error {
+	head := *state
+	head.Resources = nil
+	data, err := json.MarshalIndent(&head, "", "  ")
+	if err != nil {
+		return err
+	}
+	i := bytes.Index(data, resourcesPlaceholder)
+	if i < 0 {
+		return errors.New("resources are missing in the JSON of the state")
+	}
+	prefix, suffix := data[:i+len(resourcesPlaceholder)-len("null")], data[i+len(resourcesPlaceholder):]
+	if _, err = w.Write(prefix); err != nil {
+		return err
+	}
+
+	if err = encodeResources(w, state.Resources); err != nil {
+		return err
+	}
+	_, err = w.Write(suffix)
+	return err
+}
+
+func encodeResources(w io.Writer, resources models.Resources) error {
+	if resources == nil {
+		_, err := io.WriteString(w, "null")
+		return err
+	}
+	if len(resources) == 0 {
+		_, err := io.WriteString(w, "[]")
+		return err
+	}
+	if _, err := io.WriteString(w, "[\n    "); err != nil {
+		return err
+	}
+	for i := range resources {
+		if i > 0 {
+			if _, err := io.WriteString(w, ",\n    "); err != nil {
+				return err
+			}
+		}
+		data, err := json.MarshalIndent(&resources[i], "    ", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal resource %s failed: %w", resources[i].ID, err)
+		}
+		if _, err = w.Write(data); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "\n  ]")
+	return err
+}
+
+// Decode reads a state in JSON written by Encode. Resources are decoded one by one from the stream instead of
+// reading the whole JSON first. Integers in attributes are decoded as int, and other numbers as float64.
+func Decode(r io.Reader) (*State, error) {
+	state, _, err := DecodeFields(r)
+	return state, err
+}
+
+// DecodeFields is Decode which also returns raw values of fields of the state other than resources, including
+// fields unknown to State which are written by storages, e.g. chunks of local states in the v2 format
+func DecodeFields(r io.Reader) (*State, map[string]json.RawMessage, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, nil, err
+	}
+
+	head := map[string]json.RawMessage{}
+	var resources models.Resources
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("unexpected %v in the state, expecting a field name", token)
+		}
+		if key == "resources" {
+			if resources, err = decodeResources(dec); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		var value json.RawMessage
+		if err = dec.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		head[key] = value
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, nil, err
+	}
+
+	data, err := json.Marshal(head)
+	if err != nil {
+		return nil, nil, err
+	}
+	state := &State{}
+	if err = json.Unmarshal(data, state); err != nil {
+		return nil, nil, err
+	}
+	state.Resources = resources
+	return state, head, nil
+}
+
+func decodeResources(dec *json.Decoder) (models.Resources, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, nil
+	}
+	if d, ok := token.(json.Delim); !ok || d != '[' {
+		return nil, fmt.Errorf("unexpected %v in the state, expecting resources", token)
+	}
+	resources := models.Resources{}
+	for dec.More() {
+		var res models.Resource
+		if err = dec.Decode(&res); err != nil {
+			return nil, err
+		}
+		normalizeNumbers(res.Attributes)
+		normalizeNumbers(res.Extensions)
+		resources = append(resources, res)
+	}
+	return resources, expectDelim(dec, ']')
+}
+
+// DecodeResource reads a resource in JSON, whose numbers are decoded as Decode does
+func DecodeResource(r io.Reader) (*models.Resource, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	res := &models.Resource{}
+	if err := dec.Decode(res); err != nil {
+		return nil, err
+	}
+	normalizeNumbers(res.Attributes)
+	normalizeNumbers(res.Extensions)
+	return res, nil
+}
+
+// normalizeNumbers replaces json.Number in the value with int or float64 in place, as YAML decoders do
+func normalizeNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeNumbers(item)
+		}
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 0); err == nil {
+			return int(i)
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return value
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := token.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("unexpected %v in the state, expecting %v", token, delim)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package states
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+)
+
+func newBenchState(count int) *State {
+	state := NewState()
+	state.Project = "demo"
+	state.Stack = "dev"
+	state.CreateTime = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("demo-%d", i)
+		state.Resources = append(state.Resources, models.Resource{
+			ID:   "v1:ConfigMap:default:" + name,
+			Type: "Kubernetes",
+			Attributes: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": name, "namespace": "default", "generation": i},
+				"data":       map[string]interface{}{"ratio": 0.5, "items": []interface{}{"a", "<b>", 3}},
+			},
+			DependsOn:  []string{"v1:Namespace:default"},
+			Extensions: map[string]interface{}{"replicas": 2},
+		})
+	}
+	return state
+}
+
+func TestEncode(t *testing.T) {
+	cases := map[string]*State{
+		"nil resources":   {Project: "demo", Stack: "dev"},
+		"empty resources": NewState(),
+		"resources":       newBenchState(3),
+	}
+	for name, state := range cases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.Nil(t, Encode(&buf, state))
+			expected, _ := json.MarshalIndent(state, "", "  ")
+			assert.Equal(t, string(expected), buf.String())
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	state := newBenchState(3)
+	var buf bytes.Buffer
+	assert.Nil(t, Encode(&buf, state))
+
+	actual, err := Decode(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, state, actual)
+
+	_, err = Decode(bytes.NewBufferString(`{"resources": {}}`))
+	assert.NotNil(t, err)
+}
+
+func BenchmarkEncode(b *testing.B) {
+	state := newBenchState(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := Encode(io.Discard, state); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, newBenchState(10000)); err != nil {
+		b.Fatal(err)
+	}
+	data := buf.Bytes()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package local
 
 import (
+	"fmt"
+
 	"github.com/zclconf/go-cty/cty"
 	"kusionstack.io/kusion/pkg/engine/states"
 )
@@ -14,12 +16,13 @@ func NewLocalBackend() states.Backend {
 }
 
 func (f *LocalBackend) StateStorage() states.StateStorage {
-	return &FileSystemState{f.Path}
+	return &FileSystemState{Path: f.Path, Format: f.Format}
 }
 
 func (f *LocalBackend) ConfigSchema() cty.Type {
 	config := map[string]cty.Type{
-		"path": cty.String,
+		"path":   cty.String,
+		"format": cty.String,
 	}
 	return cty.Object(config)
 }
@@ -31,5 +34,12 @@ func (f *LocalBackend) Configure(obj cty.Value) error {
 	} else {
 		f.Path = KusionState
 	}
+	f.Format = ""
+	if format := obj.GetAttr("format"); !format.IsNull() {
+		f.Format = format.AsString()
+	}
+	if f.Format != "" && f.Format != FormatV1 && f.Format != FormatV2 {
+		return fmt.Errorf("invalid format %s of the local backend, must be %s or %s", f.Format, FormatV1, FormatV2)
+	}
 	return nil
 }
@@ -23,7 +23,8 @@ func TestLocalBackend_ConfigSchema(t *testing.T) {
 				Path: stateFile,
 			},
 			want: cty.Object(map[string]cty.Type{
-				"path": cty.String,
+				"path":   cty.String,
+				"format": cty.String,
 			}),
 		},
 	}
@@ -62,6 +63,32 @@ func TestLocalBackend_Configure(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "v2 format",
+			fields: fields{
+				Path: stateFile,
+			},
+			wantErr: false,
+			args: args{
+				config: map[string]interface{}{
+					"path":   stateFile,
+					"format": FormatV2,
+				},
+			},
+		},
+		{
+			name: "invalid format",
+			fields: fields{
+				Path: stateFile,
+			},
+			wantErr: true,
+			args: args{
+				config: map[string]interface{}{
+					"path":   stateFile,
+					"format": "v3",
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
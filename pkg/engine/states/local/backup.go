@@ -0,0 +1,26 @@
+package local
+
+import (
+	"kusionstack.io/kusion/pkg/engine/states"
+)
+
+var _ states.StateBackuper = &FileSystemState{}
+
+// backupFileSuffix is the suffix of the backup file, which is next to the state file
+const backupFileSuffix = ".backup"
+
+// Backup is an implementation of StateBackuper.Backup
+func (f *FileSystemState) Backup(state *states.State) error {
+	return f.withFileLock(true, func() error {
+		return states.WriteBackupObject(fileLockStore{}, f.Path+backupFileSuffix, state)
+	})
+}
+
+// GetBackup is an implementation of StateBackuper.GetBackup
+func (f *FileSystemState) GetBackup(_ *states.StateQuery) (backup *states.State, err error) {
+	err = f.withFileLock(false, func() error {
+		backup, err = states.ReadBackupObject(fileLockStore{}, f.Path+backupFileSuffix)
+		return err
+	})
+	return backup, err
+}
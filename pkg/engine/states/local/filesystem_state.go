@@ -1,13 +1,12 @@
 package local
 
 import (
-	"encoding/json"
+	"bufio"
+	"io"
 	"io/fs"
 	"os"
 	"time"
 
-	"gopkg.in/yaml.v3"
-
 	"kusionstack.io/kusion/pkg/engine/states"
 	"kusionstack.io/kusion/pkg/log"
 )
@@ -17,6 +16,9 @@ var _ states.StateStorage = &FileSystemState{}
 type FileSystemState struct {
 	// state Path is in the same dir where command line is invoked
 	Path string
+
+	// Format is the format states are written in, FormatV1 if empty. States in any format are read.
+	Format string
 }
 
 func NewFileSystemState() states.StateStorage {
@@ -25,7 +27,16 @@ func NewFileSystemState() states.StateStorage {
 
 const KusionState = "kusion_state.json"
 
-func (f *FileSystemState) GetLatestState(query *states.StateQuery) (*states.State, error) {
+func (f *FileSystemState) GetLatestState(_ *states.StateQuery) (state *states.State, err error) {
+	err = f.withFileLock(false, func() error {
+		state, err = f.getLatestState()
+		return err
+	})
+	return state, err
+}
+
+// getLatestState reads the state file, whose OS lock must be held by the caller
+func (f *FileSystemState) getLatestState() (*states.State, error) {
 	// create a new state file if no file exists
 	file, err := os.OpenFile(f.Path, os.O_RDWR|os.O_CREATE, fs.ModePerm)
 	if err != nil {
@@ -33,57 +44,94 @@ func (f *FileSystemState) GetLatestState(query *states.StateQuery) (*states.Stat
 	}
 	defer file.Close()
 
-	jsonFile, err := os.ReadFile(f.Path)
+	info, err := file.Stat()
 	if err != nil {
 		return nil, err
 	}
+	if info.Size() == 0 {
+		log.Infof("file %s is empty. Skip unmarshal json", f.Path)
+		return nil, nil
+	}
 
-	if len(jsonFile) != 0 {
-		state := &states.State{}
-		// JSON is a subset of YAML.
-		// We are using yaml.Unmarshal here (instead of json.Unmarshal) because the
-		// Go JSON library doesn't try to pick the right number type (int, float,
-		// etc.) when unmarshalling to interface{}, it just picks float64 universally.
-		// go-yaml does the right thing.
-		err = yaml.Unmarshal(jsonFile, state)
-		if err != nil {
+	// resources are decoded from the file one by one, instead of reading the whole file first
+	state, fields, err := states.DecodeFields(bufio.NewReader(file))
+	if err != nil {
+		return nil, err
+	}
+	if chunks, ok := fields[chunksField]; ok {
+		if state.Resources, err = f.readChunks(chunks); err != nil {
 			return nil, err
 		}
-		return state, nil
-	} else {
-		log.Infof("file %s is empty. Skip unmarshal json", f.Path)
-		return nil, nil
 	}
+	return state, nil
 }
 
 func (f *FileSystemState) Apply(state *states.State) error {
+	// the createTime is read and the state is written while holding the lock, so that writes of other
+	// processes are not interleaved
+	return f.withFileLock(true, func() error {
+		return f.apply(state)
+	})
+}
+
+func (f *FileSystemState) apply(state *states.State) error {
 	now := time.Now()
 
-	// don't change createTime in the state
-	oldState, err := f.GetLatestState(nil)
-	if err != nil {
-		return err
+	// don't change createTime in the state. The state is updated after each resource is applied, and the
+	// createTime kept in it saves reading the whole state file before each update.
+	if state.CreateTime.IsZero() {
+		oldState, err := f.getLatestState()
+		if err != nil {
+			return err
+		}
+		if oldState == nil || oldState.CreateTime.IsZero() {
+			state.CreateTime = now
+		} else {
+			state.CreateTime = oldState.CreateTime
+		}
 	}
+	state.ModifiedTime = now
 
-	if oldState == nil || oldState.CreateTime.IsZero() {
-		state.CreateTime = now
-	} else {
-		state.CreateTime = oldState.CreateTime
+	if f.Format == FormatV2 {
+		return f.applyV2(state)
 	}
+	if err := writeFile(f.Path, func(w io.Writer) error {
+		return states.Encode(w, state)
+	}); err != nil {
+		return err
+	}
+	// chunks of the state written in the v2 format before are not needed any more
+	return os.RemoveAll(f.chunksDir())
+}
 
-	state.ModifiedTime = now
-	jsonByte, err := json.MarshalIndent(state, "", "  ")
+// writeFile writes the file by the write function. The file is written to a temporary file first, so that it is
+// never left half written.
+func writeFile(path string, write func(w io.Writer) error) error {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fs.ModePerm)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(f.Path, jsonByte, fs.ModePerm)
+	w := bufio.NewWriter(file)
+	if err = write(w); err == nil {
+		err = w.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
 }
 
 func (f *FileSystemState) Delete(id string) error {
 	log.Infof("Delete state file:%s", f.Path)
-	err := os.Remove(f.Path)
-	if err != nil {
-		return err
-	}
-	return nil
+	return f.withFileLock(true, func() error {
+		if err := os.Remove(f.Path); err != nil {
+			return err
+		}
+		return os.RemoveAll(f.chunksDir())
+	})
 }
@@ -4,12 +4,16 @@
 package local
 
 import (
+	"errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
+	"kusionstack.io/kusion/pkg/engine/models"
 	"kusionstack.io/kusion/pkg/engine/states"
 
 	"bou.ke/monkey"
@@ -19,10 +23,22 @@ import (
 var stateFile string
 
 func TestMain(m *testing.M) {
-	currentDir, _ := os.Getwd()
-	stateFile = filepath.Join(currentDir, "testdata", "kusion_state.json")
+	// copy the state to a temp dir, so that lock files are never written to testdata
+	dir, err := os.MkdirTemp("", "kusion-state")
+	if err != nil {
+		panic(err)
+	}
+	data, err := os.ReadFile(filepath.Join("testdata", "kusion_state.json"))
+	if err != nil {
+		panic(err)
+	}
+	stateFile = filepath.Join(dir, "kusion_state.json")
+	if err = os.WriteFile(stateFile, data, 0o644); err != nil {
+		panic(err)
+	}
 
 	m.Run()
+	os.RemoveAll(dir)
 	os.Exit(0)
 }
 
@@ -96,7 +112,7 @@ func FileSystemStateSetUp(t *testing.T) *FileSystemState {
 		return nil
 	})
 
-	return &FileSystemState{Path: "kusion_state_filesystem.json"}
+	return &FileSystemState{Path: filepath.Join(t.TempDir(), "kusion_state_filesystem.json")}
 }
 
 func TestFileSystemState(t *testing.T) {
@@ -110,3 +126,60 @@ func TestFileSystemState(t *testing.T) {
 	err = fileSystemState.Delete("kusion_state_filesystem.json")
 	assert.NoError(t, err)
 }
+
+func TestFileSystemState_Lock(t *testing.T) {
+	s := &FileSystemState{Path: filepath.Join(t.TempDir(), KusionState)}
+	first := states.NewLockInfo("apply", "alice", time.Minute)
+	second := states.NewLockInfo("apply", "bob", time.Minute)
+
+	assert.Nil(t, s.Lock(nil, first))
+	assert.FileExists(t, s.Path+lockFileSuffix)
+	assert.True(t, errors.Is(s.Lock(nil, second), states.ErrStateLocked))
+	assert.Nil(t, s.RefreshLock(nil, first))
+	assert.Nil(t, s.Unlock(nil, first))
+	assert.NoFileExists(t, s.Path+lockFileSuffix)
+	assert.Nil(t, s.Lock(nil, second))
+}
+
+func TestFileSystemState_Backup(t *testing.T) {
+	s := &FileSystemState{Path: filepath.Join(t.TempDir(), KusionState)}
+	backup, err := s.GetBackup(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, backup)
+	backup, err = states.BackupState(s, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, backup)
+
+	state := states.NewState()
+	state.Serial = 3
+	state.Resources = models.Resources{{ID: "v1:ConfigMap:default:app", Type: "Kubernetes"}}
+	assert.Nil(t, s.Apply(state))
+	backup, err = states.BackupState(s, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), backup.Serial)
+	assert.FileExists(t, s.Path+backupFileSuffix)
+
+	backup, err = s.GetBackup(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), backup.Serial)
+	assert.Equal(t, state.Resources, backup.Resources)
+}
+
+func BenchmarkFileSystemState_Apply(b *testing.B) {
+	s := &FileSystemState{Path: filepath.Join(b.TempDir(), KusionState)}
+	state := states.NewState()
+	for i := 0; i < 10000; i++ {
+		state.Resources = append(state.Resources, models.Resource{
+			ID:         fmt.Sprintf("v1:ConfigMap:default:demo-%d", i),
+			Type:       "Kubernetes",
+			Attributes: map[string]interface{}{"data": map[string]interface{}{"index": i}},
+		})
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := s.Apply(state); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
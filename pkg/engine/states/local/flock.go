@@ -0,0 +1,43 @@
+package local
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// flockDir is the directory of files locked by the OS around reads and writes of state files, which is
+	// next to the state files, so that users ignore one directory in version control instead of lock files.
+	// The state file itself is replaced by each write, so it can't be locked directly.
+	flockDir = ".kusion"
+
+	// flockFileSuffix is the suffix of the lock file of the state file in flockDir
+	flockFileSuffix = ".flock"
+)
+
+// flockPath returns the path of the lock file of the state file
+func (f *FileSystemState) flockPath() string {
+	return filepath.Join(filepath.Dir(f.Path), flockDir, filepath.Base(f.Path)+flockFileSuffix)
+}
+
+// withFileLock runs fn while holding the OS lock of the state file, shared for reads and exclusive for writes,
+// so that processes on the same machine, e.g. applies of the same stack in two terminals, never interleave
+// writes of the state or read a state being written. The lock is released by the OS if the process exits.
+func (f *FileSystemState) withFileLock(exclusive bool, fn func() error) error {
+	path := f.flockPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err = lockFile(file, exclusive); err != nil {
+		return fmt.Errorf("lock the state file %s failed: %w", f.Path, err)
+	}
+	defer func() { _ = unlockFile(file) }()
+	return fn()
+}
@@ -0,0 +1,68 @@
+package local
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/states"
+)
+
+func TestFileSystemState_WithFileLock(t *testing.T) {
+	s := &FileSystemState{Path: filepath.Join(t.TempDir(), KusionState)}
+
+	locked, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		_ = s.withFileLock(true, func() error {
+			close(locked)
+			<-release
+			return nil
+		})
+	}()
+	<-locked
+
+	done := make(chan error)
+	go func() {
+		done <- s.Apply(states.NewState())
+	}()
+	select {
+	case <-done:
+		t.Fatal("the state is written while it is locked")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	assert.Nil(t, <-done)
+	assert.FileExists(t, filepath.Join(filepath.Dir(s.Path), ".kusion", KusionState+".flock"))
+	assert.NoFileExists(t, s.Path+flockFileSuffix)
+}
+
+func TestFileSystemState_ConcurrentApply(t *testing.T) {
+	for _, format := range []string{FormatV1, FormatV2} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), KusionState)
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(serial uint64) {
+					defer wg.Done()
+					// each writer has its own storage like a separate process
+					s := &FileSystemState{Path: path, Format: format}
+					state := states.NewState()
+					state.Serial = serial
+					state.Resources = models.Resources{{ID: "v1:ConfigMap:default:app", Type: "Kubernetes"}}
+					assert.Nil(t, s.Apply(state))
+				}(uint64(i))
+			}
+			wg.Wait()
+
+			latest, err := (&FileSystemState{Path: path}).GetLatestState(nil)
+			assert.Nil(t, err)
+			assert.NotNil(t, latest)
+			assert.Len(t, latest.Resources, 1)
+		})
+	}
+}
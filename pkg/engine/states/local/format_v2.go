@@ -0,0 +1,160 @@
+package local
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/states"
+)
+
+// Formats of local states
+const (
+	// FormatV1 is a JSON file of the whole state
+	FormatV1 = "v1"
+	// FormatV2 is a JSON file of the state without resources, whose resources are gzipped JSON files named by
+	// the hashes of their content in the chunks directory next to the state file. Unchanged resources keep
+	// their files, so only changed resources are written by each update, and show up in diffs of git.
+	FormatV2 = "v2"
+)
+
+const (
+	// chunksField is the field of the state file in the v2 format listing chunks of its resources in order
+	chunksField = "chunks"
+	// chunksDirSuffix is the suffix of the chunks directory, which is next to the state file
+	chunksDirSuffix = ".chunks"
+	// chunkFileSuffix is the suffix of chunk files
+	chunkFileSuffix = ".json.gz"
+)
+
+// stateV2 is the content of the state file in the v2 format
+type stateV2 struct {
+	*states.State
+	Chunks []string `json:"chunks"`
+}
+
+func (f *FileSystemState) chunksDir() string {
+	return f.Path + chunksDirSuffix
+}
+
+// applyV2 writes chunks of resources which do not exist yet, then the state file listing them, and finally
+// removes chunks not listed, so that the state file never lists missing chunks
+func (f *FileSystemState) applyV2(state *states.State) error {
+	dir := f.chunksDir()
+	if err := os.MkdirAll(dir, fs.ModePerm); err != nil {
+		return err
+	}
+
+	content := stateV2{State: &states.State{}, Chunks: make([]string, 0, len(state.Resources))}
+	*content.State = *state
+	content.Resources = nil
+	listed := make(map[string]bool, len(state.Resources))
+	for i := range state.Resources {
+		chunk, err := writeChunk(dir, &state.Resources[i])
+		if err != nil {
+			return fmt.Errorf("write resource %s failed: %w", state.Resources[i].ID, err)
+		}
+		content.Chunks = append(content.Chunks, chunk)
+		listed[chunk] = true
+	}
+
+	if err := writeFile(f.Path, func(w io.Writer) error {
+		data, err := json.MarshalIndent(&content, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !listed[entry.Name()] {
+			if err = os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeChunk writes the gzipped JSON of the resource to the chunk named by its hash unless it exists, and
+// returns the name of the chunk
+func writeChunk(dir string, resource *models.Resource) (string, error) {
+	data, err := json.MarshalIndent(resource, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	chunk := hex.EncodeToString(sum[:]) + chunkFileSuffix
+	path := filepath.Join(dir, chunk)
+	if _, err = os.Stat(path); err == nil {
+		return chunk, nil
+	}
+
+	// the gzip header has no modification time, so the same resource is always written as the same bytes
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err = zw.Write(data); err != nil {
+		return "", err
+	}
+	if err = zw.Close(); err != nil {
+		return "", err
+	}
+	return chunk, writeFile(path, func(w io.Writer) error {
+		_, err := w.Write(buf.Bytes())
+		return err
+	})
+}
+
+// readChunks reads resources in the chunks listed in the state file in the v2 format
+func (f *FileSystemState) readChunks(field json.RawMessage) (models.Resources, error) {
+	var chunks []string
+	if err := json.Unmarshal(field, &chunks); err != nil {
+		return nil, fmt.Errorf("invalid chunks in the state %s: %w", f.Path, err)
+	}
+	resources := make(models.Resources, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk != filepath.Base(chunk) || !strings.HasSuffix(chunk, chunkFileSuffix) {
+			return nil, fmt.Errorf("invalid chunk %s in the state %s", chunk, f.Path)
+		}
+		resource, err := readChunk(filepath.Join(f.chunksDir(), chunk))
+		if err != nil {
+			return nil, fmt.Errorf("read chunk %s of the state %s failed: %w", chunk, f.Path, err)
+		}
+		resources = append(resources, *resource)
+	}
+	return resources, nil
+}
+
+func readChunk(path string) (*models.Resource, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("the chunk is missing, which may not be committed with the state")
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return states.DecodeResource(zr)
+}
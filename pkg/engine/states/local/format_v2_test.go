@@ -0,0 +1,79 @@
+package local
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/states"
+)
+
+func TestFileSystemState_FormatV2(t *testing.T) {
+	path := filepath.Join(t.TempDir(), KusionState)
+	state := states.NewState()
+	state.Serial = 1
+	state.Outputs = map[string]interface{}{"ip": "1.2.3.4"}
+	for i := 0; i < 3; i++ {
+		state.Resources = append(state.Resources, models.Resource{
+			ID:         fmt.Sprintf("v1:ConfigMap:default:app-%d", i),
+			Type:       "Kubernetes",
+			Attributes: map[string]interface{}{"data": map[string]interface{}{"index": i, "ratio": 0.5}},
+			DependsOn:  []string{"v1:Namespace::default"},
+		})
+	}
+
+	// a state in the v1 format is read and migrated to the v2 format
+	v1 := &FileSystemState{Path: path}
+	assert.Nil(t, v1.Apply(state))
+	v2 := &FileSystemState{Path: path, Format: FormatV2}
+	got, err := v2.GetLatestState(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, state.Resources, got.Resources)
+	assert.Nil(t, v2.Apply(got))
+	assertChunks(t, v2, 3)
+
+	got, err = v2.GetLatestState(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), got.Serial)
+	assert.Equal(t, state.Outputs, got.Outputs)
+	assert.Equal(t, state.Resources, got.Resources)
+	head, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Contains(t, string(head), `"resources": null`)
+
+	// chunks of resources deleted or changed are removed
+	got.Resources = got.Resources[1:]
+	got.Resources[0].Attributes["data"] = map[string]interface{}{"index": 10}
+	assert.Nil(t, v2.Apply(got))
+	assertChunks(t, v2, 2)
+	latest, err := v2.GetLatestState(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, got.Resources, latest.Resources)
+
+	// chunks are removed when the state is written in the v1 format again
+	assert.Nil(t, v1.Apply(latest))
+	assert.NoDirExists(t, v1.chunksDir())
+	latest, err = v1.GetLatestState(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, got.Resources, latest.Resources)
+}
+
+func TestFileSystemState_MissingChunk(t *testing.T) {
+	s := &FileSystemState{Path: filepath.Join(t.TempDir(), KusionState), Format: FormatV2}
+	state := states.NewState()
+	state.Resources = models.Resources{{ID: "v1:ConfigMap:default:app", Type: "Kubernetes"}}
+	assert.Nil(t, s.Apply(state))
+	assert.Nil(t, os.RemoveAll(s.chunksDir()))
+	_, err := s.GetLatestState(nil)
+	assert.NotNil(t, err)
+}
+
+func assertChunks(t *testing.T, s *FileSystemState, n int) {
+	entries, err := os.ReadDir(s.chunksDir())
+	assert.Nil(t, err)
+	assert.Len(t, entries, n)
+}
@@ -0,0 +1,59 @@
+package local
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+
+	"kusionstack.io/kusion/pkg/engine/states"
+)
+
+var _ states.StateLocker = &FileSystemState{}
+
+// lockFileSuffix is the suffix of the lock file, which is next to the state file
+const lockFileSuffix = ".lock"
+
+// Lock is an implementation of StateLocker.Lock. The lock file is checked and written while holding the OS lock of
+// the state file, so that two processes on the same machine can't both take it.
+func (f *FileSystemState) Lock(_ *states.StateQuery, info *states.LockInfo) error {
+	return f.withFileLock(true, func() error {
+		return states.LockObject(fileLockStore{}, f.Path+lockFileSuffix, info)
+	})
+}
+
+// RefreshLock is an implementation of StateLocker.RefreshLock
+func (f *FileSystemState) RefreshLock(_ *states.StateQuery, info *states.LockInfo) error {
+	return f.withFileLock(true, func() error {
+		return states.RefreshObjectLock(fileLockStore{}, f.Path+lockFileSuffix, info)
+	})
+}
+
+// Unlock is an implementation of StateLocker.Unlock
+func (f *FileSystemState) Unlock(_ *states.StateQuery, info *states.LockInfo) error {
+	return f.withFileLock(true, func() error {
+		return states.UnlockObject(fileLockStore{}, f.Path+lockFileSuffix, info)
+	})
+}
+
+// fileLockStore keeps lock objects in local files
+type fileLockStore struct{}
+
+func (fileLockStore) ReadLockObject(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (fileLockStore) WriteLockObject(path string, data []byte) error {
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (fileLockStore) DeleteLockObject(path string) error {
+	err := os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,351 @@
+package states
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"kusionstack.io/kusion/pkg/log"
+)
+
+// DefaultLockTTL is how long a lock is valid since it is acquired or refreshed. Locks of running operations are
+// refreshed periodically, so a lock expires soon after the process holding it dies, instead of blocking others.
+const DefaultLockTTL = 5 * time.Minute
+
+// LockObjectName is the name of lock objects, which are stored in the same directory as the state objects of
+// remote storages. It does not share the prefix of the state object names, so listing states never finds locks.
+const LockObjectName = "kusion_state.lock"
+
+// Actions of audit records of locks, which are recorded in the state and written to the log once the lock is
+// released
+const (
+	// AuditAcquireLock is the action of acquiring a lock
+	AuditAcquireLock = "acquire-lock"
+	// AuditReleaseLock is the action of releasing a lock
+	AuditReleaseLock = "release-lock"
+	// AuditTakeOverLock is the action of taking over an expired lock
+	AuditTakeOverLock = "take-over-lock"
+	// AuditForceUnlock is the action of taking over a lock which has not expired by --force-unlock
+	AuditForceUnlock = "force-unlock"
+)
+
+// StateLocker is an optional interface for state storages which can lock the state of a stack during an
+// operation, so that concurrent operations do not overwrite the state of each other
+type StateLocker interface {
+	// Lock acquires the lock of the state, and fails with ErrStateLocked if it is held by another operation and
+	// has not expired, unless info.ForceReason is specified. The lock taken over is set to info.TakenOver.
+	Lock(query *StateQuery, info *LockInfo) error
+
+	// RefreshLock extends the expiration of the lock, and fails if the lock is not held by info any more
+	RefreshLock(query *StateQuery, info *LockInfo) error
+
+	// Unlock releases the lock if it is held by info
+	Unlock(query *StateQuery, info *LockInfo) error
+}
+
+// LockInfo describes the holder of a lock
+type LockInfo struct {
+	// ID is the unique ID of the lock
+	ID string `json:"id" yaml:"id"`
+
+	// Operation is the operation holding the lock, e.g. apply
+	Operation string `json:"operation" yaml:"operation"`
+
+	// Operator is the person who runs the operation, Host is the machine it runs on, and PID is the process
+	Operator string `json:"operator,omitempty" yaml:"operator,omitempty"`
+	Host     string `json:"host,omitempty" yaml:"host,omitempty"`
+	PID      int    `json:"pid,omitempty" yaml:"pid,omitempty"`
+
+	// CreateTime is the time the lock is acquired, and ExpireTime is the time it expires unless refreshed
+	CreateTime time.Time `json:"createTime" yaml:"createTime"`
+	ExpireTime time.Time `json:"expireTime" yaml:"expireTime"`
+
+	// TTL is how long the lock is valid since it is acquired or refreshed, e.g. 5m0s
+	TTL string `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+
+	// ForceReason is the reason given by --force-unlock to take over the lock of another operation even if it
+	// has not expired, e.g. the process holding it was killed
+	ForceReason string `json:"forceReason,omitempty" yaml:"forceReason,omitempty"`
+
+	// TakenOver is the lock of another operation taken over when this lock was acquired
+	TakenOver *LockInfo `json:"takenOver,omitempty" yaml:"takenOver,omitempty"`
+}
+
+// NewLockInfo returns the lock info of the operation, which expires after the TTL
+func NewLockInfo(operation, operator string, ttl time.Duration) *LockInfo {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	host, _ := os.Hostname()
+	now := time.Now()
+	return &LockInfo{
+		ID:         hex.EncodeToString(id),
+		Operation:  operation,
+		Operator:   operator,
+		Host:       host,
+		PID:        os.Getpid(),
+		CreateTime: now,
+		ExpireTime: now.Add(ttl),
+		TTL:        ttl.String(),
+	}
+}
+
+// Expired returns true if the lock has expired at the time
+func (l *LockInfo) Expired(now time.Time) bool {
+	return !l.ExpireTime.IsZero() && now.After(l.ExpireTime)
+}
+
+func (l *LockInfo) String() string {
+	holder := l.Operation
+	if l.Operator != "" {
+		holder += " by " + l.Operator
+	}
+	if l.Host != "" {
+		holder += " on " + l.Host
+	}
+	if l.PID != 0 {
+		holder += fmt.Sprintf(" (pid %d)", l.PID)
+	}
+	return fmt.Sprintf("%s since %s, expires at %s", holder, l.CreateTime.Format(time.RFC3339),
+		l.ExpireTime.Format(time.RFC3339))
+}
+
+// Audits returns audit records of acquiring the lock, led by the record of the lock taken over when this lock was
+// acquired if any
+func (l *LockInfo) Audits() []AuditRecord {
+	acquire := AuditRecord{
+		Action:   AuditAcquireLock,
+		Operator: l.Operator,
+		Message:  fmt.Sprintf("acquired the lock %s for %s", l.ID, l),
+		Time:     l.CreateTime,
+	}
+	if l.TakenOver == nil {
+		return []AuditRecord{acquire}
+	}
+	takeOver := AuditRecord{
+		Action:   AuditTakeOverLock,
+		Reason:   "the lock expired at " + l.TakenOver.ExpireTime.Format(time.RFC3339),
+		Operator: l.Operator,
+		Message:  fmt.Sprintf("took over the lock %s of %s", l.TakenOver.ID, l.TakenOver),
+		Time:     l.CreateTime,
+	}
+	if !l.TakenOver.Expired(l.CreateTime) {
+		takeOver.Action, takeOver.Reason = AuditForceUnlock, l.ForceReason
+	}
+	return []AuditRecord{takeOver, acquire}
+}
+
+// ReleaseAudit returns the audit record of releasing the lock at the time
+func (l *LockInfo) ReleaseAudit(now time.Time) AuditRecord {
+	return AuditRecord{
+		Action:   AuditReleaseLock,
+		Operator: l.Operator,
+		Message:  fmt.Sprintf("released the lock %s of %s", l.ID, l.Operation),
+		Time:     now,
+	}
+}
+
+// LockObjectStore reads and writes lock objects and backups of states, which is implemented by storages keeping
+// states in files or object stores, e.g. local files, S3 and OSS
+type LockObjectStore interface {
+	// ReadLockObject returns the content of the lock object, or nil if it does not exist
+	ReadLockObject(key string) ([]byte, error)
+	WriteLockObject(key string, data []byte) error
+	DeleteLockObject(key string) error
+}
+
+// LockObject acquires the lock by writing the lock object of the key. An expired lock object is taken over, and
+// so is a lock object which has not expired if info.ForceReason is specified.
+// Object stores without conditional writes can not make this atomic, so it is a best-effort protection.
+func LockObject(store LockObjectStore, key string, info *LockInfo) error {
+	holder, err := readLockObject(store, key)
+	if err != nil {
+		return err
+	}
+	if holder != nil && holder.ID != info.ID {
+		switch {
+		case holder.Expired(time.Now()):
+			log.Infof("take over the expired lock %s of %s", holder.ID, holder)
+		case info.ForceReason != "":
+			log.Warnf("force to take over the lock %s of %s, reason: %s", holder.ID, holder, info.ForceReason)
+		default:
+			return fmt.Errorf("%w: locked by %s", ErrStateLocked, holder)
+		}
+		// only the last lock taken over is kept
+		holder.TakenOver = nil
+		info.TakenOver = holder
+	}
+	return writeLockObject(store, key, info)
+}
+
+// RefreshObjectLock writes the lock object of the key with the new expiration, if the lock is still held by info
+func RefreshObjectLock(store LockObjectStore, key string, info *LockInfo) error {
+	holder, err := readLockObject(store, key)
+	if err != nil {
+		return err
+	}
+	if holder != nil && holder.ID != info.ID {
+		return fmt.Errorf("%w: the lock was taken over by %s", ErrStateLocked, holder)
+	}
+	return writeLockObject(store, key, info)
+}
+
+// UnlockObject deletes the lock object of the key, if the lock is held by info
+func UnlockObject(store LockObjectStore, key string, info *LockInfo) error {
+	holder, err := readLockObject(store, key)
+	if err != nil {
+		return err
+	}
+	if holder == nil || holder.ID != info.ID {
+		return nil
+	}
+	return store.DeleteLockObject(key)
+}
+
+func readLockObject(store LockObjectStore, key string) (*LockInfo, error) {
+	data, err := store.ReadLockObject(key)
+	if err != nil {
+		return nil, fmt.Errorf("read the lock %s failed: %w", key, err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	holder := &LockInfo{}
+	if err = json.Unmarshal(data, holder); err != nil {
+		return nil, fmt.Errorf("parse the lock %s failed: %w", key, err)
+	}
+	return holder, nil
+}
+
+func writeLockObject(store LockObjectStore, key string, info *LockInfo) error {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = store.WriteLockObject(key, data); err != nil {
+		return fmt.Errorf("write the lock %s failed: %w", key, err)
+	}
+	return nil
+}
+
+// AcquireLock locks the state if the storage is a StateLocker, and keeps refreshing the lock with heartbeats
+// until the returned function is called to release it. States of other storages are not locked.
+// A lock of another operation which has not expired is taken over if forceReason is specified. Audit records of
+// acquiring the lock are returned, which should be recorded in the state. The state is saved before the lock is
+// released, and may not be kept at all, e.g. after the whole stack is destroyed, so all audit records of the
+// lock are written to the log once it is released.
+func AcquireLock(
+	storage StateStorage,
+	query *StateQuery,
+	operation, operator, forceReason string,
+	heartbeat func(*LockInfo, error),
+) (func() error, []AuditRecord, error) {
+	locker, ok := storage.(StateLocker)
+	if !ok {
+		log.Infof("the state storage %T does not support locks", storage)
+		return func() error { return nil }, nil, nil
+	}
+	info := NewLockInfo(operation, operator, DefaultLockTTL)
+	info.ForceReason = forceReason
+	if err := locker.Lock(query, info); err != nil {
+		return nil, nil, err
+	}
+	log.Infof("acquire the lock %s of the state for %s", info.ID, info)
+	audits := info.Audits()
+
+	keeper := KeepLock(locker, query, info, DefaultLockTTL, heartbeat)
+	return func() error {
+		keeper.Stop()
+		if err := locker.Unlock(query, info); err != nil {
+			return fmt.Errorf("release the lock of the state failed: %w", err)
+		}
+		log.Infof("release the lock %s of the state", info.ID)
+		for _, audit := range append(audits, info.ReleaseAudit(time.Now())) {
+			log.Infof("audit of the lock %s: %s by %s at %s, reason: %q, %s", info.ID, audit.Action, audit.Operator,
+				audit.Time.Format(time.RFC3339), audit.Reason, audit.Message)
+		}
+		return nil
+	}, audits, nil
+}
+
+// LockKeeper refreshes a lock periodically during a long operation, and reports each refresh as a heartbeat
+type LockKeeper struct {
+	locker StateLocker
+	query  *StateQuery
+	info   *LockInfo
+	ttl    time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// KeepLock refreshes the lock every third of the TTL until Stop is called, and calls heartbeat with the
+// refreshed lock and the error of each refresh, which may be nil. Refreshes stop once the lock is lost, whose
+// error wraps ErrLockLost.
+func KeepLock(
+	locker StateLocker,
+	query *StateQuery,
+	info *LockInfo,
+	ttl time.Duration,
+	heartbeat func(*LockInfo, error),
+) *LockKeeper {
+	k := &LockKeeper{
+		locker: locker,
+		query:  query,
+		info:   info,
+		ttl:    ttl,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go k.run(heartbeat)
+	return k
+}
+
+func (k *LockKeeper) run(heartbeat func(*LockInfo, error)) {
+	defer close(k.done)
+	ticker := time.NewTicker(k.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-k.stop:
+			return
+		case now := <-ticker.C:
+			info := *k.info
+			info.ExpireTime = now.Add(k.ttl)
+			err := k.locker.RefreshLock(k.query, &info)
+			lost := false
+			if err == nil {
+				k.info.ExpireTime = info.ExpireTime
+			} else {
+				// others may lock the state once the lock expires, so it is lost even if the refresh may succeed later
+				lost = errors.Is(err, ErrStateLocked) || now.After(k.info.ExpireTime)
+				if lost {
+					err = fmt.Errorf("%w: %v", ErrLockLost, err)
+				}
+				log.Errorf("refresh the lock %s failed: %v", info.ID, err)
+			}
+			if heartbeat != nil {
+				heartbeat(&info, err)
+			}
+			if lost {
+				return
+			}
+		}
+	}
+}
+
+// Stop stops refreshing the lock, and waits for the running refresh to finish
+func (k *LockKeeper) Stop() {
+	if k == nil {
+		return
+	}
+	k.once.Do(func() {
+		close(k.stop)
+	})
+	<-k.done
+}
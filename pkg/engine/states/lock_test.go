@@ -0,0 +1,194 @@
+package states
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryLockStore keeps lock objects and states in memory
+type memoryLockStore struct {
+	lock    sync.Mutex
+	objects map[string][]byte
+}
+
+func (m *memoryLockStore) ReadLockObject(key string) ([]byte, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.objects[key], nil
+}
+
+func (m *memoryLockStore) WriteLockObject(key string, data []byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.objects[key] = data
+	return nil
+}
+
+func (m *memoryLockStore) DeleteLockObject(key string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.objects, key)
+	return nil
+}
+
+func (m *memoryLockStore) GetLatestState(*StateQuery) (*State, error) { return nil, nil }
+func (m *memoryLockStore) Apply(*State) error                         { return nil }
+func (m *memoryLockStore) Delete(string) error                        { return nil }
+
+func (m *memoryLockStore) Lock(_ *StateQuery, info *LockInfo) error {
+	return LockObject(m, LockObjectName, info)
+}
+
+func (m *memoryLockStore) RefreshLock(_ *StateQuery, info *LockInfo) error {
+	return RefreshObjectLock(m, LockObjectName, info)
+}
+
+func (m *memoryLockStore) Unlock(_ *StateQuery, info *LockInfo) error {
+	return UnlockObject(m, LockObjectName, info)
+}
+
+func TestLockObject(t *testing.T) {
+	store := &memoryLockStore{objects: map[string][]byte{}}
+	first := NewLockInfo("apply", "alice", time.Minute)
+	second := NewLockInfo("destroy", "bob", time.Minute)
+	assert.NotEqual(t, first.ID, second.ID)
+
+	assert.Nil(t, LockObject(store, "lock", first))
+	assert.Nil(t, LockObject(store, "lock", first))
+	err := LockObject(store, "lock", second)
+	assert.True(t, errors.Is(err, ErrStateLocked))
+	assert.Contains(t, err.Error(), "locked by apply by alice")
+	assert.True(t, errors.Is(RefreshObjectLock(store, "lock", second), ErrStateLocked))
+
+	// unlocking a lock held by another operation does nothing
+	assert.Nil(t, UnlockObject(store, "lock", second))
+	assert.NotNil(t, store.objects["lock"])
+
+	// expired locks are taken over
+	first.ExpireTime = time.Now().Add(-time.Second)
+	assert.Nil(t, RefreshObjectLock(store, "lock", first))
+	assert.Nil(t, LockObject(store, "lock", second))
+	assert.True(t, errors.Is(RefreshObjectLock(store, "lock", first), ErrStateLocked))
+
+	assert.Nil(t, UnlockObject(store, "lock", second))
+	assert.Nil(t, store.objects["lock"])
+}
+
+func TestAcquireLock(t *testing.T) {
+	store := &memoryLockStore{objects: map[string][]byte{}}
+	heartbeats := make(chan error, 10)
+	release, audits, err := AcquireLock(store, &StateQuery{}, "apply", "alice", "", func(_ *LockInfo, err error) {
+		heartbeats <- err
+	})
+	assert.Nil(t, err)
+	assert.Len(t, audits, 1)
+	assert.Equal(t, AuditAcquireLock, audits[0].Action)
+	assert.Equal(t, "alice", audits[0].Operator)
+
+	_, _, err = AcquireLock(store, &StateQuery{}, "apply", "bob", "", nil)
+	assert.True(t, errors.Is(err, ErrStateLocked))
+
+	assert.Nil(t, release())
+	assert.Empty(t, store.objects)
+
+	// storages which can not lock states are not locked
+	release, audits, err = AcquireLock(&unlockableStorage{}, &StateQuery{}, "apply", "alice", "", nil)
+	assert.Nil(t, err)
+	assert.Empty(t, audits)
+	assert.Nil(t, release())
+}
+
+func TestAcquireLock_ForceUnlock(t *testing.T) {
+	store := &memoryLockStore{objects: map[string][]byte{}}
+	first := NewLockInfo("apply", "alice", time.Minute)
+	assert.Nil(t, store.Lock(nil, first))
+
+	release, audits, err := AcquireLock(store, &StateQuery{}, "destroy", "bob", "the apply was killed", nil)
+	assert.Nil(t, err)
+	assert.Len(t, audits, 2)
+	assert.Equal(t, AuditForceUnlock, audits[0].Action)
+	assert.Equal(t, AuditAcquireLock, audits[1].Action)
+	assert.Equal(t, "the apply was killed", audits[0].Reason)
+	assert.Equal(t, "bob", audits[0].Operator)
+	assert.Contains(t, audits[0].Message, "took over the lock "+first.ID+" of apply by alice")
+
+	// the operation whose lock is taken over can't refresh or release it any more
+	assert.True(t, errors.Is(store.RefreshLock(nil, first), ErrStateLocked))
+	assert.Nil(t, store.Unlock(nil, first))
+	assert.NotNil(t, store.objects[LockObjectName])
+	assert.Nil(t, release())
+	assert.Empty(t, store.objects)
+}
+
+func TestLockInfo_Audits(t *testing.T) {
+	info := NewLockInfo("apply", "bob", time.Minute)
+	assert.Equal(t, DefaultLockTTL.String(), NewLockInfo("apply", "bob", DefaultLockTTL).TTL)
+	assert.NotZero(t, info.PID)
+	audits := info.Audits()
+	assert.Len(t, audits, 1)
+	assert.Equal(t, AuditAcquireLock, audits[0].Action)
+	assert.Contains(t, audits[0].Message, "acquired the lock "+info.ID)
+
+	expired := NewLockInfo("apply", "alice", time.Minute)
+	expired.ExpireTime = info.CreateTime.Add(-time.Second)
+	info.TakenOver = expired
+	audits = info.Audits()
+	assert.Len(t, audits, 2)
+	assert.Equal(t, AuditTakeOverLock, audits[0].Action)
+	assert.Contains(t, audits[0].Reason, "the lock expired at ")
+	assert.Equal(t, info.CreateTime, audits[0].Time)
+	assert.Equal(t, AuditAcquireLock, audits[1].Action)
+
+	now := time.Now()
+	release := info.ReleaseAudit(now)
+	assert.Equal(t, AuditReleaseLock, release.Action)
+	assert.Equal(t, "bob", release.Operator)
+	assert.Equal(t, now, release.Time)
+}
+
+func TestKeepLock(t *testing.T) {
+	store := &memoryLockStore{objects: map[string][]byte{}}
+	info := NewLockInfo("apply", "alice", 30*time.Millisecond)
+	assert.Nil(t, store.Lock(nil, info))
+	expire := info.ExpireTime
+
+	heartbeats := make(chan error, 10)
+	keeper := KeepLock(store, nil, info, 30*time.Millisecond, func(_ *LockInfo, err error) {
+		heartbeats <- err
+	})
+	assert.Nil(t, <-heartbeats)
+	keeper.Stop()
+	keeper.Stop()
+	assert.True(t, info.ExpireTime.After(expire))
+}
+
+func TestKeepLock_Lost(t *testing.T) {
+	store := &memoryLockStore{objects: map[string][]byte{}}
+	info := NewLockInfo("apply", "alice", 30*time.Millisecond)
+	assert.Nil(t, store.Lock(nil, info))
+	// another operation takes over the lock
+	other := NewLockInfo("apply", "bob", time.Minute)
+	other.ForceReason = "stuck"
+	assert.Nil(t, store.Lock(nil, other))
+
+	heartbeats := make(chan error, 10)
+	keeper := KeepLock(store, nil, info, 30*time.Millisecond, func(_ *LockInfo, err error) {
+		heartbeats <- err
+	})
+	err := <-heartbeats
+	assert.True(t, errors.Is(err, ErrLockLost))
+	// refreshes stop once the lock is lost
+	<-keeper.done
+	keeper.Stop()
+	assert.Empty(t, heartbeats)
+}
+
+type unlockableStorage struct{}
+
+func (unlockableStorage) GetLatestState(*StateQuery) (*State, error) { return nil, nil }
+func (unlockableStorage) Apply(*State) error                         { return nil }
+func (unlockableStorage) Delete(string) error                        { return nil }
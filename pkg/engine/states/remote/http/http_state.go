@@ -88,6 +88,9 @@ func (s *HTTPState) Apply(state *states.State) error {
 	if err != nil {
 		return err
 	}
+	if res.StatusCode == http.StatusLocked || res.StatusCode == http.StatusConflict {
+		return fmt.Errorf("apply state failed. %w. StatusCode:%v, Status:%s", states.ErrStateLocked, res.StatusCode, res.Status)
+	}
 	if res.StatusCode != 200 {
 		return fmt.Errorf("apply state failed. StatusCode:%v, Status:%s", res.StatusCode, res.Status)
 	}
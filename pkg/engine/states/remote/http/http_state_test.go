@@ -1,6 +1,7 @@
 package http
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -80,6 +81,24 @@ func TestHTTPState_Apply(t *testing.T) {
 				}, nil
 			},
 		},
+		{
+			name: "apply_locked",
+			fields: fields{
+				urlPrefix:          prefix,
+				applyURLFormat:     format,
+				getLatestURLFormat: format,
+			},
+			args: args{state: state},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return errors.Is(err, states.ErrStateLocked)
+			},
+			mockFunc: func(c *http.Client, req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					Status:     "Locked",
+					StatusCode: 423,
+				}, nil
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,21 @@
+package oss
+
+import (
+	"kusionstack.io/kusion/pkg/engine/states"
+)
+
+var _ states.StateBackuper = &OssState{}
+
+// Backup is an implementation of StateBackuper.Backup, the backup is an object next to the state object
+func (s *OssState) Backup(state *states.State) error {
+	return states.WriteBackupObject(ossLockStore{s.bucket}, backupKey(state.Tenant, state.Project, state.Stack), state)
+}
+
+// GetBackup is an implementation of StateBackuper.GetBackup
+func (s *OssState) GetBackup(query *states.StateQuery) (*states.State, error) {
+	return states.ReadBackupObject(ossLockStore{s.bucket}, backupKey(query.Tenant, query.Project, query.Stack))
+}
+
+func backupKey(tenant, project, stack string) string {
+	return tenant + "/" + project + "/" + stack + "/" + states.BackupObjectName
+}
@@ -0,0 +1,57 @@
+package oss
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+
+	"kusionstack.io/kusion/pkg/engine/states"
+)
+
+var _ states.StateLocker = &OssState{}
+
+// Lock is an implementation of StateLocker.Lock, the lock is an object next to the state object
+func (s *OssState) Lock(query *states.StateQuery, info *states.LockInfo) error {
+	return states.LockObject(ossLockStore{s.bucket}, lockKey(query), info)
+}
+
+// RefreshLock is an implementation of StateLocker.RefreshLock
+func (s *OssState) RefreshLock(query *states.StateQuery, info *states.LockInfo) error {
+	return states.RefreshObjectLock(ossLockStore{s.bucket}, lockKey(query), info)
+}
+
+// Unlock is an implementation of StateLocker.Unlock
+func (s *OssState) Unlock(query *states.StateQuery, info *states.LockInfo) error {
+	return states.UnlockObject(ossLockStore{s.bucket}, lockKey(query), info)
+}
+
+func lockKey(query *states.StateQuery) string {
+	return query.Tenant + "/" + query.Project + "/" + query.Stack + "/" + states.LockObjectName
+}
+
+// ossLockStore keeps lock objects in the bucket of the state
+type ossLockStore struct {
+	bucket *oss.Bucket
+}
+
+func (l ossLockStore) ReadLockObject(key string) ([]byte, error) {
+	body, err := l.bucket.GetObject(key)
+	if err != nil {
+		if e, ok := err.(oss.ServiceError); ok && e.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+func (l ossLockStore) WriteLockObject(key string, data []byte) error {
+	return l.bucket.PutObject(key, bytes.NewReader(data))
+}
+
+func (l ossLockStore) DeleteLockObject(key string) error {
+	return l.bucket.DeleteObject(key)
+}
@@ -2,12 +2,9 @@ package oss
 
 import (
 	"bytes"
-	"encoding/json"
 	"errors"
-	"io"
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
-	"gopkg.in/yaml.v3"
 
 	"kusionstack.io/kusion/pkg/engine/states"
 )
@@ -41,12 +38,12 @@ func NewOSSState(endPoint, accessKeyID, accessKeySecret, bucketName string) (*Os
 }
 
 func (s *OssState) Apply(state *states.State) error {
-	jsonByte, err := json.MarshalIndent(state, "", "  ")
-	if err != nil {
+	var buf bytes.Buffer
+	if err := states.Encode(&buf, state); err != nil {
 		return err
 	}
 	prefix := state.Tenant + "/" + state.Project + "/" + state.Stack + "/" + OSSStateName
-	err = s.bucket.PutObject(prefix, bytes.NewReader(jsonByte))
+	err := s.bucket.PutObject(prefix, &buf)
 	if err != nil {
 		return err
 	}
@@ -74,15 +71,5 @@ func (s *OssState) GetLatestState(query *states.StateQuery) (*states.State, erro
 	}
 	defer body.Close()
 
-	data, err := io.ReadAll(body)
-	if err != nil {
-		return nil, err
-	}
-	state := &states.State{}
-	// JSON is a subset of YAML. Please check FileSystemState.GetLatestState for detail explanation
-	err = yaml.Unmarshal(data, state)
-	if err != nil {
-		return nil, err
-	}
-	return state, nil
+	return states.Decode(body)
 }
@@ -0,0 +1,21 @@
+package s3
+
+import (
+	"kusionstack.io/kusion/pkg/engine/states"
+)
+
+var _ states.StateBackuper = &S3State{}
+
+// Backup is an implementation of StateBackuper.Backup, the backup is an object next to the state object
+func (s *S3State) Backup(state *states.State) error {
+	return states.WriteBackupObject(s3LockStore{s}, backupKey(state.Tenant, state.Project, state.Stack), state)
+}
+
+// GetBackup is an implementation of StateBackuper.GetBackup
+func (s *S3State) GetBackup(query *states.StateQuery) (*states.State, error) {
+	return states.ReadBackupObject(s3LockStore{s}, backupKey(query.Tenant, query.Project, query.Stack))
+}
+
+func backupKey(tenant, project, stack string) string {
+	return tenant + "/" + project + "/" + stack + "/" + states.BackupObjectName
+}
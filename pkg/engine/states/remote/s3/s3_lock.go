@@ -0,0 +1,70 @@
+package s3
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"kusionstack.io/kusion/pkg/engine/states"
+)
+
+var _ states.StateLocker = &S3State{}
+
+// Lock is an implementation of StateLocker.Lock, the lock is an object next to the state object
+func (s *S3State) Lock(query *states.StateQuery, info *states.LockInfo) error {
+	return states.LockObject(s3LockStore{s}, lockKey(query), info)
+}
+
+// RefreshLock is an implementation of StateLocker.RefreshLock
+func (s *S3State) RefreshLock(query *states.StateQuery, info *states.LockInfo) error {
+	return states.RefreshObjectLock(s3LockStore{s}, lockKey(query), info)
+}
+
+// Unlock is an implementation of StateLocker.Unlock
+func (s *S3State) Unlock(query *states.StateQuery, info *states.LockInfo) error {
+	return states.UnlockObject(s3LockStore{s}, lockKey(query), info)
+}
+
+func lockKey(query *states.StateQuery) string {
+	return query.Tenant + "/" + query.Project + "/" + query.Stack + "/" + states.LockObjectName
+}
+
+// s3LockStore keeps lock objects in the bucket of the state
+type s3LockStore struct {
+	s *S3State
+}
+
+func (l s3LockStore) ReadLockObject(key string) ([]byte, error) {
+	out, err := s3.New(l.s.sess).GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(l.s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if e, ok := err.(awserr.Error); ok && e.Code() == s3.ErrCodeNoSuchKey {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+func (l s3LockStore) WriteLockObject(key string, data []byte) error {
+	_, err := s3.New(l.s.sess).PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(l.s.bucketName),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+func (l s3LockStore) DeleteLockObject(key string) error {
+	_, err := s3.New(l.s.sess).DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(l.s.bucketName),
+		Key:    aws.String(key),
+	})
+	return err
+}
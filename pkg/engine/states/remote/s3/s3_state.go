@@ -2,9 +2,7 @@ package s3
 
 import (
 	"bytes"
-	"encoding/json"
 	"errors"
-	"io"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -44,16 +42,16 @@ func NewS3State(endPoint, accessKeyID, accessKeySecret, bucketName string, regio
 }
 
 func (s *S3State) Apply(state *states.State) error {
-	jsonByte, err := json.MarshalIndent(state, "", "  ")
-	if err != nil {
+	var buf bytes.Buffer
+	if err := states.Encode(&buf, state); err != nil {
 		return err
 	}
 	prefix := state.Tenant + "/" + state.Project + "/" + state.Stack + "/" + S3StateName
 	s3Client := s3.New(s.sess)
-	_, err = s3Client.PutObject(&s3.PutObjectInput{
+	_, err := s3Client.PutObject(&s3.PutObjectInput{
 		Bucket: aws.String(s.bucketName),
 		Key:    aws.String(prefix),
-		Body:   bytes.NewReader(jsonByte),
+		Body:   bytes.NewReader(buf.Bytes()),
 	})
 	if err != nil {
 		return err
@@ -94,14 +92,5 @@ func (s *S3State) GetLatestState(query *states.StateQuery) (*states.State, error
 	}
 	defer out.Body.Close()
 
-	data, err := io.ReadAll(out.Body)
-	if err != nil {
-		return nil, err
-	}
-	state := &states.State{}
-	err = json.Unmarshal(data, state)
-	if err != nil {
-		return nil, err
-	}
-	return state, nil
+	return states.Decode(out.Body)
 }
@@ -1,6 +1,7 @@
 package states
 
 import (
+	"errors"
 	"time"
 
 	"kusionstack.io/kusion/pkg/engine/models"
@@ -8,6 +9,13 @@ import (
 	"kusionstack.io/kusion/pkg/version"
 )
 
+// ErrStateLocked means the state is locked by another operation and can't be modified now
+var ErrStateLocked = errors.New("the state is locked by another operation")
+
+// ErrLockLost is reported by heartbeats of LockKeeper when the lock is not held any more, since it is taken over
+// by another operation or expires before it is refreshed. The operation holding it must stop changing the state.
+var ErrLockLost = errors.New("the lock of the state is lost")
+
 // StateStorage represents the set of methods to manipulate State in a specified storage
 type StateStorage interface {
 	// GetLatestState return nil if state not exists
@@ -64,6 +72,9 @@ type State struct {
 	// Operator represents the person who triggered this operation
 	Operator string `json:"operator,omitempty" yaml:"operator,omitempty"`
 
+	// Revision is the commit of the source applied, which is recorded by kusion sync
+	Revision string `json:"revision,omitempty" yaml:"revision,omitempty"`
+
 	// Resources records all resources in this operation
 	Resources models.Resources `json:"resources" yaml:"resources"`
 
@@ -72,6 +83,60 @@ type State struct {
 
 	// ModifiedTime is the time State is modified each time
 	ModifiedTime time.Time `json:"modifiedTime,omitempty" yaml:"modifiedTime"`
+
+	// Audits records checks overridden by the operator in this operation
+	Audits []AuditRecord `json:"audits,omitempty" yaml:"audits,omitempty"`
+
+	// Timings records how long each resource took in this operation
+	Timings []ResourceTiming `json:"timings,omitempty" yaml:"timings,omitempty"`
+	// Outputs are values of outputs declared by the stack, which are extracted from resources after applies
+	Outputs map[string]interface{} `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+}
+
+// AuditRecord records a check overridden by the operator, e.g. an exceeded budget
+type AuditRecord struct {
+	// Action is the overridden check, e.g. override-budget
+	Action string `json:"action" yaml:"action"`
+
+	// Reason is the reason given by the operator
+	Reason string `json:"reason" yaml:"reason"`
+
+	// Operator is the person who overrode the check
+	Operator string `json:"operator,omitempty" yaml:"operator,omitempty"`
+
+	// Message describes what was overridden
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+
+	// Time is the time the check was overridden
+	Time time.Time `json:"time" yaml:"time"`
+}
+
+// ResourceTiming records when a resource started and finished in an operation, and how long the
+// requests to its runtime took
+type ResourceTiming struct {
+	// ID is the ID of the resource
+	ID string `json:"id" yaml:"id"`
+
+	// Action is the action applied to the resource, e.g. Create or Update
+	Action string `json:"action,omitempty" yaml:"action,omitempty"`
+
+	// StartTime and EndTime are the time the resource started and finished
+	StartTime time.Time `json:"startTime" yaml:"startTime"`
+	EndTime   time.Time `json:"endTime" yaml:"endTime"`
+
+	// Attempts is the number of attempts to apply the resource, more than 1 means it was retried
+	Attempts int `json:"attempts,omitempty" yaml:"attempts,omitempty"`
+
+	// RuntimeLatency is the total latency of requests to the runtime, e.g. reads, dry runs and applies
+	RuntimeLatency time.Duration `json:"runtimeLatency" yaml:"runtimeLatency"`
+
+	// Failed means the resource failed in the operation
+	Failed bool `json:"failed,omitempty" yaml:"failed,omitempty"`
+}
+
+// Duration returns how long the resource took in the operation
+func (t *ResourceTiming) Duration() time.Duration {
+	return t.EndTime.Sub(t.StartTime)
 }
 
 func NewState() *State {
@@ -0,0 +1,107 @@
+// Package tracing traces engine operations with OpenTelemetry. Operations, resource nodes and requests to
+// runtimes are recorded as spans, and exported to an OTLP endpoint over HTTP when tracing is enabled by Init.
+// Spans are dropped when tracing is not enabled.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"kusionstack.io/kusion/pkg/status"
+	"kusionstack.io/kusion/pkg/version"
+)
+
+const (
+	// InstrumentationName is the name of the tracer of the engine
+	InstrumentationName = "kusionstack.io/kusion/pkg/engine"
+
+	// ServiceName is the service of spans exported by Kusion
+	ServiceName = "kusion"
+)
+
+// Attributes of spans
+const (
+	ProjectKey    = attribute.Key("kusion.project")
+	StackKey      = attribute.Key("kusion.stack")
+	ResourceIDKey = attribute.Key("kusion.resource.id")
+	ActionKey     = attribute.Key("kusion.resource.action")
+	RuntimeKey    = attribute.Key("kusion.runtime")
+)
+
+// Environment variables of the OTLP exporter, either of which enables tracing
+var endpointEnvs = []string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT"}
+
+// Init enables tracing and exports spans to the OTLP endpoint, e.g. http://localhost:4318. If the endpoint
+// is empty, the endpoint in the standard OTLP environment variables is used, and tracing stays disabled if
+// none of them is set. The returned function flushes spans and shuts down the exporter, which should be
+// called before the command exits.
+func Init(ctx context.Context, endpoint string) (func(context.Context) error, error) {
+	var opts []otlptracehttp.Option
+	if endpoint != "" {
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid OTLP endpoint %s, must be a URL like http://localhost:4318", endpoint)
+		}
+		opts = append(opts, otlptracehttp.WithEndpoint(u.Host))
+		if u.Scheme == "http" {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if u.Path != "" && u.Path != "/" {
+			opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+		}
+	} else if !endpointConfigured() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create OTLP exporter failed: %w", err)
+	}
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceNameKey.String(ServiceName),
+		semconv.ServiceVersionKey.String(version.ReleaseVersion()),
+	)
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+func endpointConfigured() bool {
+	for _, env := range endpointEnvs {
+		if os.Getenv(env) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Start starts a span of the engine. The background context is used if ctx is nil, which is the case of
+// operations built without a context.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return otel.Tracer(InstrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends the span, and records the error of the status if any
+func End(span trace.Span, s status.Status) {
+	if status.IsErr(s) {
+		err := errors.New(s.Message())
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
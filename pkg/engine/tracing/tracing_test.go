@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"kusionstack.io/kusion/pkg/status"
+)
+
+func TestInit(t *testing.T) {
+	for _, env := range endpointEnvs {
+		t.Setenv(env, "")
+	}
+
+	shutdown, err := Init(context.Background(), "")
+	assert.Nil(t, err)
+	assert.Nil(t, shutdown(context.Background()))
+
+	_, err = Init(context.Background(), "localhost")
+	assert.NotNil(t, err)
+
+	provider := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(provider)
+	shutdown, err = Init(context.Background(), "http://localhost:4318/v1/traces")
+	assert.Nil(t, err)
+	assert.Nil(t, shutdown(context.Background()))
+}
+
+func TestStartEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(provider)
+
+	// operations built without a context start spans from the background context
+	var background context.Context
+	ctx, parent := Start(background, "Apply", ProjectKey.String("project"))
+	_, child := Start(ctx, "ResourceNode", ResourceIDKey.String("v1:Namespace:default"))
+	End(child, status.NewErrorStatus(errors.New("apply failed")))
+	End(parent, nil)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "ResourceNode", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "apply failed", spans[0].Status().Description)
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
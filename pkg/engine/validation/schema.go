@@ -0,0 +1,198 @@
+package validation
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// specSchemaJSON is the JSON Schema of the Spec, see spec.schema.json
+//
+//go:embed spec.schema.json
+var specSchemaJSON []byte
+
+// schema is the subset of JSON Schema draft-07 keywords used by spec.schema.json
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 schemaTypes        `json:"type,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	AdditionalProperties *additional        `json:"additionalProperties,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Definitions          map[string]*schema `json:"definitions,omitempty"`
+}
+
+// schemaTypes accepts both a single type and a list of types
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*t = multiple
+	return nil
+}
+
+// additional is the value of additionalProperties, which is either a boolean or a schema
+type additional struct {
+	Allowed bool
+	Schema  *schema
+}
+
+func (a *additional) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.Allowed); err == nil {
+		return nil
+	}
+	a.Allowed = true
+	a.Schema = &schema{}
+	return json.Unmarshal(data, a.Schema)
+}
+
+// parseSchema parses the schema document and checks all references in it are resolvable
+func parseSchema(data []byte) (*schema, error) {
+	root := &schema{}
+	if err := json.Unmarshal(data, root); err != nil {
+		return nil, fmt.Errorf("invalid spec schema: %w", err)
+	}
+	for name := range root.Definitions {
+		if _, err := root.definition("#/definitions/" + name); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+// definition resolves a local reference like #/definitions/resource
+func (s *schema) definition(ref string) (*schema, error) {
+	name := strings.TrimPrefix(ref, "#/definitions/")
+	if name == ref {
+		return nil, fmt.Errorf("unsupported schema reference %q", ref)
+	}
+	d, ok := s.Definitions[name]
+	if !ok {
+		return nil, fmt.Errorf("schema definition %q not found", name)
+	}
+	return d, nil
+}
+
+// validate validates the JSON value against the schema s, references are resolved in root
+func (s *schema) validate(root *schema, path string, value interface{}) Errors {
+	if s.Ref != "" {
+		d, err := root.definition(s.Ref)
+		if err != nil {
+			return Errors{{Path: path, Message: err.Error()}}
+		}
+		return d.validate(root, path, value)
+	}
+
+	if len(s.Type) > 0 && !s.matchType(value) {
+		return Errors{{Path: path, Message: fmt.Sprintf("must be %s, got %s", strings.Join(s.Type, " or "), typeOf(value))}}
+	}
+
+	if len(s.Enum) > 0 && !s.inEnum(value) {
+		return Errors{{Path: path, Message: fmt.Sprintf("must be one of %v, got %v", s.Enum, value)}}
+	}
+
+	var errs Errors
+	switch v := value.(type) {
+	case string:
+		if s.MinLength != nil && len(v) < *s.MinLength {
+			if *s.MinLength == 1 {
+				errs = append(errs, &Error{Path: path, Message: "must not be empty"})
+			} else {
+				errs = append(errs, &Error{Path: path, Message: fmt.Sprintf("must be at least %d characters", *s.MinLength)})
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				errs = append(errs, s.Items.validate(root, fmt.Sprintf("%s[%d]", path, i), item)...)
+			}
+		}
+	case map[string]interface{}:
+		for _, key := range s.Required {
+			if _, ok := v[key]; !ok {
+				errs = append(errs, &Error{Path: join(path, key), Message: "required"})
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if ps, ok := s.Properties[key]; ok {
+				errs = append(errs, ps.validate(root, join(path, key), v[key])...)
+				continue
+			}
+			if s.AdditionalProperties == nil {
+				continue
+			}
+			if !s.AdditionalProperties.Allowed {
+				errs = append(errs, &Error{Path: join(path, key), Message: "unknown field"})
+			} else if s.AdditionalProperties.Schema != nil {
+				errs = append(errs, s.AdditionalProperties.Schema.validate(root, join(path, key), v[key])...)
+			}
+		}
+	}
+	return errs
+}
+
+func (s *schema) matchType(value interface{}) bool {
+	actual := typeOf(value)
+	for _, t := range s.Type {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *schema) inEnum(value interface{}) bool {
+	for _, e := range s.Enum {
+		if e == value {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf returns the JSON Schema type name of a decoded JSON value
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,234 @@
+// Package validation validates the compiled Spec against the JSON Schema of Spec and Resource, and against
+// the schemas of resource attributes where they are known, before the Spec is handed over to any operation.
+// It reports every violation with the precise path in the Spec, instead of failing in the middle of the
+// operation with errors like IllegalManifest. Fields unknown to the built-in Kubernetes scheme and resource
+// types without schemas are only reported as warnings, since they may be newer than the schemas compiled in.
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/operation/graph"
+	kusionruntime "kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+// Error is a violation found in the Spec
+type Error struct {
+	// Path is the location of the violation, e.g. resources[1].attributes.metadata.name
+	Path string
+
+	// Message describes the violation
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Errors contains all violations found in the Spec
+type Errors []*Error
+
+func (e Errors) Error() string {
+	lines := make([]string, 0, len(e)+1)
+	lines = append(lines, fmt.Sprintf("invalid spec, %d error(s) found:", len(e)))
+	for _, err := range e {
+		lines = append(lines, "  "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// typeSchema is the definitions in spec.schema.json used to validate resources of a specified type
+type typeSchema struct {
+	attributes string
+	extensions string
+}
+
+// typeSchemas contains schemas of all supported resource types
+var typeSchemas = map[models.Type]typeSchema{
+	kusionruntime.Kubernetes: {attributes: "#/definitions/kubernetesAttributes"},
+	kusionruntime.Terraform:  {extensions: "#/definitions/terraformExtensions"},
+}
+
+var specSchema *schema
+
+func init() {
+	var err error
+	if specSchema, err = parseSchema(specSchemaJSON); err != nil {
+		panic(err)
+	}
+}
+
+// ValidateSpec validates the Spec and returns Errors if any violation is found, along with warnings of what
+// can not be validated
+func ValidateSpec(sp *models.Spec) (Errors, error) {
+	if sp == nil {
+		return nil, nil
+	}
+
+	// validate the JSON representation, which is exactly what operations consume
+	data, err := json.Marshal(sp)
+	if err != nil {
+		return nil, fmt.Errorf("marshal spec failed: %w", err)
+	}
+	var doc interface{}
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("unmarshal spec failed: %w", err)
+	}
+
+	errs := specSchema.validate(specSchema, "", doc)
+	if len(errs) > 0 {
+		// resources are not well-formed, so skip validations below
+		return nil, errs
+	}
+
+	resources, _ := doc.(map[string]interface{})["resources"].([]interface{})
+	ids := map[string]int{}
+	for i, r := range sp.Resources {
+		if j, ok := ids[r.ID]; ok {
+			errs = append(errs, &Error{
+				Path:    fmt.Sprintf("resources[%d].id", i),
+				Message: fmt.Sprintf("duplicate ID %q, already used by resources[%d]", r.ID, j),
+			})
+			continue
+		}
+		ids[r.ID] = i
+	}
+
+	var warnings Errors
+	for i, r := range sp.Resources {
+		path := fmt.Sprintf("resources[%d]", i)
+		doc := resources[i].(map[string]interface{})
+		resourceErrs, resourceWarnings := validateResource(path, r, doc)
+		errs = append(errs, resourceErrs...)
+		warnings = append(warnings, resourceWarnings...)
+		walkImplicitRefs(path+".attributes", doc["attributes"], func(p, ref string) {
+			id := strings.Split(ref, ".")[0]
+			if _, ok := ids[id]; !ok {
+				errs = append(errs, &Error{Path: p, Message: fmt.Sprintf("referenced resource %q not found", id)})
+			}
+		})
+		for j, dep := range r.DependsOn {
+			if _, ok := ids[dep]; !ok {
+				errs = append(errs, &Error{
+					Path:    fmt.Sprintf("%s.dependsOn[%d]", path, j),
+					Message: fmt.Sprintf("resource %q not found", dep),
+				})
+			} else if dep == r.ID {
+				errs = append(errs, &Error{
+					Path:    fmt.Sprintf("%s.dependsOn[%d]", path, j),
+					Message: "resource depends on itself",
+				})
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return warnings, errs
+	}
+	return warnings, nil
+}
+
+// validateResource validates attributes and extensions of the resource by schemas of its type, and returns
+// violations and warnings
+func validateResource(path string, r models.Resource, doc map[string]interface{}) (Errors, Errors) {
+	ts, ok := typeSchemas[r.Type]
+	if !ok {
+		known := make([]string, 0, len(typeSchemas))
+		for t := range typeSchemas {
+			known = append(known, string(t))
+		}
+		sort.Strings(known)
+		return nil, Errors{{
+			Path:    path + ".type",
+			Message: fmt.Sprintf("resource type %q is not validated, types with schemas are %s", r.Type, strings.Join(known, ", ")),
+		}}
+	}
+
+	var errs Errors
+	if ts.attributes != "" {
+		errs = append(errs, validateDefinition(ts.attributes, path+".attributes", doc["attributes"])...)
+	}
+	if ts.extensions != "" {
+		if _, ok := doc["extensions"]; !ok {
+			errs = append(errs, &Error{Path: path + ".extensions", Message: "required"})
+		} else {
+			errs = append(errs, validateDefinition(ts.extensions, path+".extensions", doc["extensions"])...)
+		}
+	}
+	var warnings Errors
+	if len(errs) == 0 && r.Type == kusionruntime.Kubernetes {
+		// implicit refs are replaced by values of other resources during the operation,
+		// so the types of fields can't be validated before that
+		hasRefs := false
+		walkImplicitRefs("", doc["attributes"], func(string, string) { hasRefs = true })
+		if !hasRefs {
+			errs, warnings = validateKubernetesObject(path+".attributes", doc["attributes"].(map[string]interface{}))
+		}
+	}
+	return errs, warnings
+}
+
+func validateDefinition(ref, path string, value interface{}) Errors {
+	return (&schema{Ref: ref}).validate(specSchema, path, value)
+}
+
+// validateKubernetesObject validates the attributes by the schema of built-in Kubernetes kinds, and returns
+// violations and warnings. Custom resources and unknown kinds are skipped, and fields unknown to the scheme are
+// warnings, since they may be added by Kubernetes versions newer than the scheme.
+func validateKubernetesObject(path string, attributes map[string]interface{}) (Errors, Errors) {
+	gvk := k8sschema.FromAPIVersionAndKind(attributes["apiVersion"].(string), attributes["kind"].(string))
+	obj, err := scheme.Scheme.New(gvk)
+	if err != nil {
+		return nil, nil
+	}
+
+	err = runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(attributes, obj, true)
+	if err == nil {
+		return nil, nil
+	}
+	if strict, ok := runtime.AsStrictDecodingError(err); ok {
+		var warnings Errors
+		for _, e := range strict.Errors() {
+			// unknown field errors are in the form of: unknown field "spec.replica"
+			field := strings.TrimSuffix(strings.TrimPrefix(e.Error(), `unknown field "`), `"`)
+			if field == e.Error() {
+				warnings = append(warnings, &Error{Path: path, Message: e.Error()})
+				continue
+			}
+			warnings = append(warnings, &Error{Path: join(path, field), Message: "unknown field"})
+		}
+		return nil, warnings
+	}
+	return Errors{{Path: path, Message: fmt.Sprintf("invalid %s: %v", gvk.Kind, err)}}, nil
+}
+
+// walkImplicitRefs calls fn with the path and the reference of every implicit ref in the value
+func walkImplicitRefs(path string, value interface{}, fn func(path, ref string)) {
+	switch v := value.(type) {
+	case string:
+		if strings.HasPrefix(v, graph.ImplicitRefPrefix) {
+			fn(path, strings.TrimPrefix(v, graph.ImplicitRefPrefix))
+		}
+	case []interface{}:
+		for i, item := range v {
+			walkImplicitRefs(fmt.Sprintf("%s[%d]", path, i), item, fn)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walkImplicitRefs(join(path, key), v[key], fn)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+func deployment(id string, spec map[string]interface{}) models.Resource {
+	return models.Resource{
+		ID:   id,
+		Type: runtime.Kubernetes,
+		Attributes: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "nginx", "namespace": "default"},
+			"spec":       spec,
+		},
+	}
+}
+
+func tfResource(id string, extensions map[string]interface{}) models.Resource {
+	return models.Resource{
+		ID:         id,
+		Type:       runtime.Terraform,
+		Attributes: map[string]interface{}{"name": "bucket"},
+		Extensions: extensions,
+	}
+}
+
+func TestValidateSpec(t *testing.T) {
+	tfExtensions := map[string]interface{}{"provider": "registry.terraform.io/hashicorp/aws/4.0.0", "resourceType": "aws_s3_bucket"}
+	tests := []struct {
+		name         string
+		sp           *models.Spec
+		want         []string
+		wantWarnings []string
+	}{
+		{
+			name: "nil spec",
+			sp:   nil,
+		},
+		{
+			name: "valid",
+			sp: &models.Spec{Resources: models.Resources{
+				deployment("deploy", map[string]interface{}{"replicas": float64(2)}),
+				tfResource("bucket", tfExtensions),
+				{
+					ID:         "ref",
+					Type:       runtime.Kubernetes,
+					Attributes: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "$kusion_path.bucket.name"}},
+					DependsOn:  []string{"deploy"},
+				},
+				{
+					ID:         "foo",
+					Type:       runtime.Kubernetes,
+					Attributes: map[string]interface{}{"apiVersion": "example.com/v1", "kind": "Foo", "metadata": map[string]interface{}{"name": "foo"}, "any": "thing"},
+				},
+			}},
+		},
+		{
+			name: "malformed resources",
+			sp: &models.Spec{Resources: models.Resources{
+				{Type: runtime.Kubernetes, Attributes: map[string]interface{}{}},
+				{ID: "a", Type: runtime.Kubernetes},
+			}},
+			want: []string{
+				"resources[0].id: must not be empty",
+				"resources[1].attributes: must be object, got null",
+			},
+		},
+		{
+			name: "invalid attributes",
+			sp: &models.Spec{Resources: models.Resources{
+				{ID: "a", Type: runtime.Kubernetes, Attributes: map[string]interface{}{"kind": "Service", "metadata": map[string]interface{}{"labels": map[string]interface{}{"app": float64(1)}}}},
+				deployment("b", map[string]interface{}{"replicas": "two"}),
+				tfResource("c", nil),
+				tfResource("d", map[string]interface{}{"provider": "aws"}),
+			}},
+			want: []string{
+				"resources[0].attributes.apiVersion: required",
+				"resources[0].attributes.metadata.name: required",
+				"resources[0].attributes.metadata.labels.app: must be string, got integer",
+				"resources[1].attributes: invalid Deployment: unrecognized type: int32",
+				"resources[2].extensions: required",
+				"resources[3].extensions.resourceType: required",
+			},
+		},
+		{
+			name: "unknown fields and types",
+			sp: &models.Spec{Resources: models.Resources{
+				deployment("a", map[string]interface{}{"replica": float64(2)}),
+				{ID: "b", Type: "Unknown", Attributes: map[string]interface{}{}},
+			}},
+			wantWarnings: []string{
+				"resources[0].attributes.spec.replica: unknown field",
+				`resources[1].type: resource type "Unknown" is not validated, types with schemas are Kubernetes, Terraform`,
+			},
+		},
+		{
+			name: "invalid references",
+			sp: &models.Spec{Resources: models.Resources{
+				deployment("a", nil),
+				deployment("a", nil),
+				{ID: "b", Type: runtime.Terraform, Attributes: map[string]interface{}{"bucket": "$kusion_path.c.name"}, DependsOn: []string{"b", "d"}, Extensions: tfExtensions},
+			}},
+			want: []string{
+				`resources[1].id: duplicate ID "a", already used by resources[0]`,
+				`resources[2].attributes.bucket: referenced resource "c" not found`,
+				"resources[2].dependsOn[0]: resource depends on itself",
+				`resources[2].dependsOn[1]: resource "d" not found`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := ValidateSpec(tt.sp)
+			var gotWarnings []string
+			for _, w := range warnings {
+				gotWarnings = append(gotWarnings, w.Error())
+			}
+			assert.Equal(t, tt.wantWarnings, gotWarnings)
+			if len(tt.want) == 0 {
+				assert.Nil(t, err)
+				return
+			}
+			errs, ok := err.(Errors)
+			assert.True(t, ok)
+			var got []string
+			for _, e := range errs {
+				got = append(got, e.Error())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestErrors_Error(t *testing.T) {
+	errs := Errors{{Path: "resources[0].id", Message: "required"}}
+	assert.Equal(t, "invalid spec, 1 error(s) found:\n  resources[0].id: required", errs.Error())
+}
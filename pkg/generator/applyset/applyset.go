@@ -0,0 +1,221 @@
+// Package applyset labels Kubernetes resources of a stack as an ApplySet, which is the way kubectl and
+// other tools tell objects managed together apart, see
+// https://github.com/kubernetes/enhancements/tree/master/keps/sig-cli/3659-kubectl-apply-prune
+//
+// Every Kubernetes resource of the stack is labeled with applyset.kubernetes.io/part-of and
+// app.kubernetes.io/managed-by=kusion, and a Secret is added as the parent of the ApplySet, so that
+// objects of a stack can be listed by
+//
+//	kubectl get all -A -l applyset.kubernetes.io/part-of=<id>
+package applyset
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kusionstack.io/kusion/pkg/engine"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// Labels and annotations of ApplySets
+const (
+	LabelID                        = "applyset.kubernetes.io/id"
+	LabelPartOf                    = "applyset.kubernetes.io/part-of"
+	LabelManagedBy                 = "app.kubernetes.io/managed-by"
+	AnnotationTooling              = "applyset.kubernetes.io/tooling"
+	AnnotationContainsGroupKinds   = "applyset.kubernetes.io/contains-group-kinds"
+	AnnotationAdditionalNamespaces = "applyset.kubernetes.io/additional-namespaces"
+)
+
+const (
+	// ManagedBy is the value of the managed-by label of resources applied by kusion
+	ManagedBy = "kusion"
+	// Tooling is the tooling of ApplySets of kusion, whose version is the version of the labeling rather than
+	// the version of kusion, so that parents are not updated by every upgrade of kusion
+	Tooling = "kusion/v1"
+
+	// parentPrefix is the prefix of names of parent Secrets
+	parentPrefix = "kusion-applyset-"
+	// defaultNamespace is the namespace of parents of stacks without namespaced resources
+	defaultNamespace = "default"
+)
+
+// invalidNameChars matches characters not allowed in names of Secrets
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// ID returns the ID of the ApplySet whose parent is the object, which is defined by the KEP as
+// applyset-<base64url(sha256(<name>.<namespace>.<kind>.<group>))>-v1
+func ID(name, namespace string, gk schema.GroupKind) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{name, namespace, gk.Kind, gk.Group}, ".")))
+	return "applyset-" + base64.RawURLEncoding.EncodeToString(sum[:]) + "-v1"
+}
+
+// Label labels Kubernetes resources in the spec as members of the ApplySet of the stack, and adds the parent
+// Secret of the ApplySet to the spec. The parent is in the namespace of the first namespaced resource, and
+// depends on the Namespace if it is in the spec. Specs without Kubernetes resources are not changed.
+func Label(spec *models.Spec, project *projectstack.Project, stack *projectstack.Stack) {
+	if spec == nil {
+		return
+	}
+	name := parentName(project, stack)
+	var members []*unstructured.Unstructured
+	namespace := ""
+	for i := range spec.Resources {
+		r := &spec.Resources[i]
+		if r.Type != runtime.Kubernetes || r.Attributes == nil {
+			continue
+		}
+		obj := &unstructured.Unstructured{Object: r.Attributes}
+		if obj.GetKind() == "Secret" && obj.GetName() == name {
+			continue
+		}
+		if namespace == "" {
+			namespace = obj.GetNamespace()
+		}
+		members = append(members, obj)
+	}
+	if len(members) == 0 {
+		return
+	}
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	id := ID(name, namespace, schema.GroupKind{Kind: "Secret"})
+	groupKinds := map[string]bool{}
+	namespaces := map[string]bool{}
+	for _, obj := range members {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[LabelPartOf] = id
+		labels[LabelManagedBy] = ManagedBy
+		obj.SetLabels(labels)
+
+		gk := obj.GroupVersionKind().GroupKind()
+		groupKinds[gk.String()] = true
+		if ns := obj.GetNamespace(); ns != "" && ns != namespace {
+			namespaces[ns] = true
+		}
+	}
+
+	parent := &unstructured.Unstructured{}
+	parent.SetAPIVersion("v1")
+	parent.SetKind("Secret")
+	parent.SetName(name)
+	parent.SetNamespace(namespace)
+	parent.SetLabels(map[string]string{LabelID: id, LabelManagedBy: ManagedBy})
+	annotations := map[string]string{
+		AnnotationTooling:            Tooling,
+		AnnotationContainsGroupKinds: strings.Join(sortedKeys(groupKinds), ","),
+	}
+	if len(namespaces) > 0 {
+		annotations[AnnotationAdditionalNamespaces] = strings.Join(sortedKeys(namespaces), ",")
+	}
+	parent.SetAnnotations(annotations)
+
+	resource := models.Resource{
+		ID:         engine.BuildIDForKubernetes("v1", "Secret", namespace, name),
+		Type:       runtime.Kubernetes,
+		Attributes: parent.Object,
+	}
+	namespaceID := engine.BuildIDForKubernetes("v1", "Namespace", "", namespace)
+	if _, ok := spec.Resources.Index()[namespaceID]; ok {
+		resource.DependsOn = []string{namespaceID}
+	}
+	for i := range spec.Resources {
+		if spec.Resources[i].ID == resource.ID {
+			spec.Resources[i] = resource
+			return
+		}
+	}
+	spec.Resources = append(spec.Resources, resource)
+}
+
+// parentName returns the name of the parent Secret of the stack
+func parentName(project *projectstack.Project, stack *projectstack.Stack) string {
+	name := invalidNameChars.ReplaceAllString(strings.ToLower(project.Name+"-"+stack.Name), "-")
+	name = parentPrefix + strings.Trim(name, "-")
+	if len(name) > 253 {
+		name = strings.TrimRight(name[:253], "-")
+	}
+	return name
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Members tells where members of the ApplySet of a stack are, so that objects labeled as its members can be
+// listed from the cluster
+type Members struct {
+	// Selector is the label selector of the members
+	Selector string
+
+	// GroupKinds and Namespaces are kinds and namespaces of the members
+	GroupKinds []schema.GroupKind
+	Namespaces []string
+}
+
+// MembersOf returns members of the ApplySet whose parent is in the resources, e.g. the ones in the state of the
+// stack, or nil if there is no parent. Kinds and namespaces of the members are the ones recorded by the parent
+// and the ones of the resources.
+func MembersOf(resources models.Resources) *Members {
+	var parent *unstructured.Unstructured
+	groupKinds := map[string]bool{}
+	namespaces := map[string]bool{}
+	for i := range resources {
+		r := &resources[i]
+		if r.Type != runtime.Kubernetes || r.Attributes == nil {
+			continue
+		}
+		obj := &unstructured.Unstructured{Object: r.Attributes}
+		if obj.GetKind() == "Secret" && obj.GetLabels()[LabelID] != "" && strings.HasPrefix(obj.GetName(), parentPrefix) {
+			parent = obj
+			continue
+		}
+		groupKinds[obj.GroupVersionKind().GroupKind().String()] = true
+		if ns := obj.GetNamespace(); ns != "" {
+			namespaces[ns] = true
+		}
+	}
+	if parent == nil {
+		return nil
+	}
+
+	annotations := parent.GetAnnotations()
+	for _, gk := range strings.Split(annotations[AnnotationContainsGroupKinds], ",") {
+		if gk != "" {
+			groupKinds[gk] = true
+		}
+	}
+	namespaces[parent.GetNamespace()] = true
+	for _, ns := range strings.Split(annotations[AnnotationAdditionalNamespaces], ",") {
+		if ns != "" {
+			namespaces[ns] = true
+		}
+	}
+
+	members := &Members{
+		Selector:   LabelPartOf + "=" + parent.GetLabels()[LabelID],
+		Namespaces: sortedKeys(namespaces),
+	}
+	for _, gk := range sortedKeys(groupKinds) {
+		members.GroupKinds = append(members.GroupKinds, schema.ParseGroupKind(gk))
+	}
+	return members
+}
@@ -0,0 +1,131 @@
+package applyset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+var (
+	project = &projectstack.Project{ProjectConfiguration: projectstack.ProjectConfiguration{Name: "Demo"}}
+	stack   = &projectstack.Stack{StackConfiguration: projectstack.StackConfiguration{Name: "dev"}}
+)
+
+func TestID(t *testing.T) {
+	// the hash of test-set.test.Secret. for the parent Secret test-set in the namespace test
+	assert.Equal(t, "applyset-ENXSipy3t2EU-SCzlce-MnQ8VBkN_SQT5i8TbZKJ9wc-v1",
+		ID("test-set", "test", schema.GroupKind{Kind: "Secret"}))
+	assert.NotEqual(t, ID("test-set", "test", schema.GroupKind{Kind: "Secret"}),
+		ID("test-set", "test", schema.GroupKind{Group: "example.com", Kind: "Secret"}))
+}
+
+func TestLabel(t *testing.T) {
+	spec := &models.Spec{Resources: models.Resources{
+		{
+			ID:   "apps/v1:Deployment:app:nginx",
+			Type: "Kubernetes",
+			Attributes: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]interface{}{
+					"name":      "nginx",
+					"namespace": "app",
+					"labels":    map[string]interface{}{"app": "nginx"},
+				},
+			},
+		},
+		{
+			ID:   "v1:Namespace:app",
+			Type: "Kubernetes",
+			Attributes: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Namespace",
+				"metadata":   map[string]interface{}{"name": "app"},
+			},
+		},
+		{
+			ID:   "v1:ConfigMap:other:config",
+			Type: "Kubernetes",
+			Attributes: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": "config", "namespace": "other"},
+			},
+		},
+		{ID: "hashicorp:local:local_file:example", Type: "Terraform"},
+	}}
+
+	Label(spec, project, stack)
+	assert.Len(t, spec.Resources, 5)
+	id := ID("kusion-applyset-demo-dev", "app", schema.GroupKind{Kind: "Secret"})
+	assert.Equal(t, map[string]interface{}{
+		"app":       "nginx",
+		LabelPartOf: id, LabelManagedBy: ManagedBy,
+	}, spec.Resources[0].Attributes["metadata"].(map[string]interface{})["labels"])
+	assert.Nil(t, spec.Resources[3].Attributes)
+
+	parent := spec.Resources[4]
+	assert.Equal(t, "v1:Secret:app:kusion-applyset-demo-dev", parent.ID)
+	assert.Equal(t, []string{"v1:Namespace:app"}, parent.DependsOn)
+	assert.Equal(t, map[string]interface{}{
+		"name":      "kusion-applyset-demo-dev",
+		"namespace": "app",
+		"labels":    map[string]interface{}{LabelID: id, LabelManagedBy: ManagedBy},
+		"annotations": map[string]interface{}{
+			AnnotationTooling:              Tooling,
+			AnnotationContainsGroupKinds:   "ConfigMap,Deployment.apps,Namespace",
+			AnnotationAdditionalNamespaces: "other",
+		},
+	}, parent.Attributes["metadata"])
+
+	// labeling again keeps the spec the same
+	Label(spec, project, stack)
+	assert.Len(t, spec.Resources, 5)
+	assert.Equal(t, parent, spec.Resources[4])
+}
+
+func TestLabel_NoKubernetesResources(t *testing.T) {
+	spec := &models.Spec{Resources: models.Resources{{ID: "hashicorp:local:local_file:example", Type: "Terraform"}}}
+	Label(spec, project, stack)
+	assert.Len(t, spec.Resources, 1)
+	Label(nil, project, stack)
+}
+
+func TestMembersOf(t *testing.T) {
+	resources := models.Resources{
+		{
+			ID:   "v1:Service:app:nginx",
+			Type: "Kubernetes",
+			Attributes: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Service",
+				"metadata":   map[string]interface{}{"name": "nginx", "namespace": "web"},
+			},
+		},
+		{ID: "hashicorp:local:local_file:example", Type: "Terraform"},
+	}
+	assert.Nil(t, MembersOf(resources))
+
+	spec := &models.Spec{Resources: append(models.Resources{{
+		ID:   "apps/v1:Deployment:app:nginx",
+		Type: "Kubernetes",
+		Attributes: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "nginx", "namespace": "app"},
+		},
+	}}, resources...)}
+	Label(spec, project, stack)
+
+	members := MembersOf(spec.Resources)
+	id := ID("kusion-applyset-demo-dev", "app", schema.GroupKind{Kind: "Secret"})
+	assert.Equal(t, &Members{
+		Selector:   LabelPartOf + "=" + id,
+		GroupKinds: []schema.GroupKind{{Group: "apps", Kind: "Deployment"}, {Kind: "Service"}},
+		Namespaces: []string{"app", "web"},
+	}, members)
+}
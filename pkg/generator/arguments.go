@@ -0,0 +1,209 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MergeArguments merges values set by paths into top-level arguments. A set value is in the form of
+// path=value, where the path consists of keys separated by dots and list indices in brackets, e.g.
+// app.replicas=3 or app.env[0].value=prod. The value is coerced to a number, boolean or null if it looks
+// like one, JSON objects, lists and quoted strings are decoded as JSON, others are kept as strings.
+//
+// Arguments whose key is a path are treated as set values too. Values of the same top-level key are deep
+// merged in order, starting from the plain argument of the key if any, and then formatted as key=json.
+func MergeArguments(arguments, sets []string) ([]string, error) {
+	var plain []string
+	var paths []string
+	for _, arg := range arguments {
+		k, _, ok := cutUnescaped(arg, '=')
+		if ok && strings.ContainsAny(k, ".[") {
+			paths = append(paths, arg)
+		} else {
+			plain = append(plain, arg)
+		}
+	}
+	paths = append(paths, sets...)
+	if len(paths) == 0 {
+		return arguments, nil
+	}
+
+	values := map[string]interface{}{}
+	for _, set := range paths {
+		path, raw, ok := cutUnescaped(set, '=')
+		if !ok {
+			return nil, fmt.Errorf("invalid set value %q, expected path=value", set)
+		}
+		segments, err := parsePath(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid set value %q: %w", set, err)
+		}
+		key := segments[0].(string)
+
+		if _, ok := values[key]; !ok {
+			// start from the plain argument of the key
+			for i, arg := range plain {
+				if k, v, _ := cutUnescaped(arg, '='); k == key {
+					values[key] = parseValue(v)
+					plain = append(plain[:i], plain[i+1:]...)
+					break
+				}
+			}
+		}
+		if values[key], err = setPath(values[key], segments[1:], parseValue(raw)); err != nil {
+			return nil, fmt.Errorf("invalid set value %q: %w", set, err)
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := plain
+	for _, k := range keys {
+		v, err := formatValue(values[k])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, k+"="+v)
+	}
+	return result, nil
+}
+
+// parsePath parses the path into keys of type string and list indices of type int, the first one is always a key
+func parsePath(path string) ([]interface{}, error) {
+	var segments []interface{}
+	var key strings.Builder
+	// expectKey is true if the next character begins a key
+	expectKey := true
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case c == '\\' && i+1 < len(path):
+			i++
+			key.WriteByte(path[i])
+			expectKey = false
+		case c == '.':
+			if key.Len() == 0 && (expectKey || len(segments) == 0) {
+				return nil, fmt.Errorf("empty key in path %q", path)
+			}
+			if key.Len() > 0 {
+				segments = append(segments, key.String())
+				key.Reset()
+			}
+			expectKey = true
+		case c == '[':
+			if key.Len() > 0 {
+				segments = append(segments, key.String())
+				key.Reset()
+			} else if len(segments) == 0 || expectKey {
+				return nil, fmt.Errorf("missing key before index in path %q", path)
+			}
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed index in path %q", path)
+			}
+			index, err := strconv.Atoi(path[i+1 : i+end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid index %q in path %q", path[i+1:i+end], path)
+			}
+			segments = append(segments, index)
+			i += end
+			expectKey = false
+		default:
+			key.WriteByte(c)
+			expectKey = false
+		}
+	}
+	if key.Len() > 0 {
+		segments = append(segments, key.String())
+	} else if expectKey {
+		return nil, fmt.Errorf("empty key in path %q", path)
+	}
+	return segments, nil
+}
+
+// setPath sets the value at the path in the target, and returns the updated target
+func setPath(target interface{}, path []interface{}, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	var err error
+	switch segment := path[0].(type) {
+	case string:
+		m, ok := target.(map[string]interface{})
+		if !ok {
+			m = map[string]interface{}{}
+		}
+		if m[segment], err = setPath(m[segment], path[1:], value); err != nil {
+			return nil, err
+		}
+		return m, nil
+	default:
+		index := segment.(int)
+		l, _ := target.([]interface{})
+		for len(l) <= index {
+			l = append(l, nil)
+		}
+		if l[index], err = setPath(l[index], path[1:], value); err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+}
+
+// parseValue coerces the raw string into a JSON value
+func parseValue(raw string) interface{} {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return raw
+	}
+	switch trimmed[0] {
+	case '{', '[', '"':
+	default:
+		if trimmed != "true" && trimmed != "false" && trimmed != "null" {
+			if _, err := strconv.ParseFloat(trimmed, 64); err != nil {
+				return raw
+			}
+		}
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(trimmed), &v); err != nil {
+		return raw
+	}
+	return v
+}
+
+// formatValue formats the value of a top-level argument, strings are kept as they are unless they would be
+// coerced into other values, e.g. "1.0" and "true", which are quoted
+func formatValue(v interface{}) (string, error) {
+	if s, ok := v.(string); ok {
+		if p, ok := parseValue(s).(string); ok && p == s {
+			return s, nil
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// cutUnescaped slices s around the first sep which is not escaped by a backslash
+func cutUnescaped(s string, sep byte) (before, after string, found bool) {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == sep {
+			return s[:i], s[i+1:], true
+		}
+	}
+	return s, "", false
+}
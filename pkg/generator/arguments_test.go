@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeArguments(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments []string
+		sets      []string
+		want      []string
+		wantErr   string
+	}{
+		{
+			name:      "no sets",
+			arguments: []string{"env=prod"},
+			want:      []string{"env=prod"},
+		},
+		{
+			name:      "paths and type coercion",
+			arguments: []string{"env=prod"},
+			sets: []string{
+				"app.replicas=3",
+				"app.env[0].name=MODE",
+				"app.env[0].value=prod",
+				"app.env[1].value=\"8080\"",
+				"app.debug=false",
+				"app.ratio=0.5",
+				"app.image=nginx:1.23",
+				"app.labels={\"team\":\"infra\"}",
+				"app\\.name=demo",
+				"tier=backend",
+			},
+			want: []string{
+				"env=prod",
+				`app={"debug":false,"env":[{"name":"MODE","value":"prod"},{"value":"8080"}],"image":"nginx:1.23","labels":{"team":"infra"},"ratio":0.5,"replicas":3}`,
+				"app.name=demo",
+				"tier=backend",
+			},
+		},
+		{
+			name:      "deep merge into arguments",
+			arguments: []string{`app={"replicas":1,"image":"nginx"}`, "app.port=80", "region=us"},
+			sets:      []string{"app.replicas=2", "app.ports[2]=443"},
+			want:      []string{"region=us", `app={"image":"nginx","port":80,"ports":[null,null,443],"replicas":2}`},
+		},
+		{
+			name:    "missing value",
+			sets:    []string{"app.replicas"},
+			wantErr: `invalid set value "app.replicas", expected path=value`,
+		},
+		{
+			name:    "empty key",
+			sets:    []string{"app..replicas=1"},
+			wantErr: `empty key in path "app..replicas"`,
+		},
+		{
+			name:    "invalid index",
+			sets:    []string{"app.env[-1]=1"},
+			wantErr: `invalid index "-1"`,
+		},
+		{
+			name:    "unclosed index",
+			sets:    []string{"app.env[0=1"},
+			wantErr: "unclosed index",
+		},
+		{
+			name:    "index without key",
+			sets:    []string{"[0]=1"},
+			wantErr: "missing key before index",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergeArguments(tt.arguments, tt.sets)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,247 @@
+// Package cache provides a Generator wrapper which caches generated Spec keyed by the hash of all inputs,
+// so that the compilation can be skipped when neither sources nor options have changed.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/resources/crd"
+	"kusionstack.io/kusion/pkg/version"
+)
+
+const (
+	// EnvDisableCache disables the compile cache if it is set to true
+	EnvDisableCache = "KUSION_DISABLE_COMPILE_CACHE"
+
+	// KclModFile marks the root directory of a KCL program
+	KclModFile = "kcl.mod"
+
+	// maxAge is the age after which a cached Spec is removed
+	maxAge = 7 * 24 * time.Hour
+)
+
+// Generator generates Spec by the wrapped Generator and caches the result.
+// The cache key is the hash of all KCL sources and module files under Root,
+// the CRD files of the project, the settings files and the compile options.
+type Generator struct {
+	generator.Generator
+
+	// Root is the root directory of the KCL program, all KCL sources under it are hashed
+	Root string
+
+	// Dir is the directory where cached Specs are saved
+	Dir string
+}
+
+var _ generator.Generator = (*Generator)(nil)
+
+// Enabled returns false if the compile cache is disabled by the environment variable
+func Enabled() bool {
+	return os.Getenv(EnvDisableCache) != "true"
+}
+
+// FindRoot returns the nearest directory containing kcl.mod from the stack directory upwards,
+// or the fallback directory if not found.
+func FindRoot(stackDir, fallback string) string {
+	dir, err := filepath.Abs(stackDir)
+	if err != nil {
+		return fallback
+	}
+	for {
+		if _, err = os.Stat(filepath.Join(dir, KclModFile)); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fallback
+		}
+		dir = parent
+	}
+}
+
+func (g *Generator) GenerateSpec(o *generator.Options, stack *projectstack.Stack) (*models.Spec, error) {
+	// the source files are rewritten by OverrideAST, so never cache it
+	if o.OverrideAST {
+		return g.Generator.GenerateSpec(o, stack)
+	}
+
+	key, err := g.Key(o, stack)
+	if err != nil {
+		log.Warnf("failed to compute compile cache key, skip cache: %v", err)
+		return g.Generator.GenerateSpec(o, stack)
+	}
+
+	cacheFile := filepath.Join(g.Dir, key+".json")
+	if sp, err := load(cacheFile); err == nil {
+		log.Infof("compile cache hit: %s", cacheFile)
+		now := time.Now()
+		_ = os.Chtimes(cacheFile, now, now)
+		return sp, nil
+	}
+
+	sp, err := g.Generator.GenerateSpec(o, stack)
+	if err != nil {
+		return nil, err
+	}
+	if err = save(cacheFile, sp); err != nil {
+		log.Warnf("failed to save compile cache: %v", err)
+	}
+	g.prune()
+	return sp, nil
+}
+
+// Key returns the hash of all inputs of the generation
+func (g *Generator) Key(o *generator.Options, stack *projectstack.Stack) (string, error) {
+	h := sha256.New()
+
+	// options and the kusion version
+	options, err := json.Marshal([]interface{}{
+		version.ReleaseVersion(), stack.Path,
+		o.WorkDir, o.Filenames, o.Settings, o.Arguments, o.Overrides, o.DisableNone,
+	})
+	if err != nil {
+		return "", err
+	}
+	h.Write(options)
+
+	// input files, the ones not found are skipped
+	files, err := Inputs(g.Root, o, stack)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if err = hashFile(h, file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Inputs returns paths of all files read by the generation of the stack: KCL sources and module files under
+// root, the files to compile which may be out of root, CRD files of the project and the settings files. Files
+// to compile and settings files are returned even if they do not exist.
+func Inputs(root string, o *generator.Options, stack *projectstack.Stack) ([]string, error) {
+	var files []string
+
+	// KCL sources and module files
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(p) == ".k" || d.Name() == KclModFile || d.Name() == KclModFile+".lock" {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// files to compile, which are hashed again if they are under root so that none of them is missed
+	for _, filename := range o.Filenames {
+		if o.WorkDir != "" && !filepath.IsAbs(filename) {
+			filename = filepath.Join(o.WorkDir, filename)
+		}
+		files = append(files, filename)
+	}
+
+	// CRD files of the project, which are appended to the Spec
+	crdDir := path.Join(path.Dir(stack.Path), crd.Directory)
+	err = filepath.WalkDir(crdDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// settings files
+	for _, setting := range o.Settings {
+		if o.WorkDir != "" && !filepath.IsAbs(setting) {
+			setting = filepath.Join(o.WorkDir, setting)
+		}
+		files = append(files, setting)
+	}
+	return files, nil
+}
+
+func hashFile(w io.Writer, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Fprintf(w, "%s\x00", filename)
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func load(filename string) (*models.Spec, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	sp := &models.Spec{}
+	if err = json.Unmarshal(data, sp); err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
+
+func save(filename string, sp *models.Spec) error {
+	data, err := json.Marshal(sp)
+	if err != nil {
+		return err
+	}
+	// specs may contain values of settings, so only the current user can read them
+	if err = os.MkdirAll(filepath.Dir(filename), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0o600)
+}
+
+// prune removes cached Specs which are not used for a long time
+func (g *Generator) prune() {
+	entries, err := os.ReadDir(g.Dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || time.Since(info.ModTime()) < maxAge {
+			continue
+		}
+		_ = os.Remove(filepath.Join(g.Dir, e.Name()))
+	}
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+type countingGenerator struct {
+	count int
+}
+
+func (g *countingGenerator) GenerateSpec(o *generator.Options, stack *projectstack.Stack) (*models.Spec, error) {
+	g.count++
+	return &models.Spec{Resources: models.Resources{{ID: "foo", Type: "Kubernetes", Attributes: map[string]interface{}{"a": "b"}}}}, nil
+}
+
+func TestGenerator_GenerateSpec(t *testing.T) {
+	root := t.TempDir()
+	stackDir := filepath.Join(root, "project", "dev")
+	assert.Nil(t, os.MkdirAll(stackDir, os.ModePerm))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, KclModFile), []byte(""), 0o644))
+	mainFile := filepath.Join(stackDir, "main.k")
+	assert.Nil(t, os.WriteFile(mainFile, []byte("a = 1"), 0o644))
+
+	wrapped := &countingGenerator{}
+	g := &Generator{
+		Generator: wrapped,
+		Root:      FindRoot(stackDir, stackDir),
+		Dir:       t.TempDir(),
+	}
+	assert.Equal(t, root, g.Root)
+
+	o := &generator.Options{WorkDir: stackDir, Settings: []string{"kcl.yaml"}}
+	stack := &projectstack.Stack{Path: stackDir}
+
+	want, _ := wrapped.GenerateSpec(o, stack)
+	wrapped.count = 0
+
+	t.Run("cache miss", func(t *testing.T) {
+		sp, err := g.GenerateSpec(o, stack)
+		assert.Nil(t, err)
+		assert.Equal(t, want, sp)
+		assert.Equal(t, 1, wrapped.count)
+
+		entries, err := os.ReadDir(g.Dir)
+		assert.Nil(t, err)
+		assert.Len(t, entries, 1)
+		info, err := entries[0].Info()
+		assert.Nil(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	})
+
+	t.Run("cache hit", func(t *testing.T) {
+		sp, err := g.GenerateSpec(o, stack)
+		assert.Nil(t, err)
+		assert.Equal(t, want, sp)
+		assert.Equal(t, 1, wrapped.count)
+	})
+
+	t.Run("source changed", func(t *testing.T) {
+		assert.Nil(t, os.WriteFile(mainFile, []byte("a = 2"), 0o644))
+		_, err := g.GenerateSpec(o, stack)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, wrapped.count)
+	})
+
+	t.Run("options changed", func(t *testing.T) {
+		o.Arguments = []string{"name=test"}
+		_, err := g.GenerateSpec(o, stack)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, wrapped.count)
+	})
+
+	t.Run("file out of root changed", func(t *testing.T) {
+		outFile := filepath.Join(t.TempDir(), "base.k")
+		assert.Nil(t, os.WriteFile(outFile, []byte("b = 1"), 0o644))
+		o.Filenames = []string{outFile, "main.k"}
+		_, err := g.GenerateSpec(o, stack)
+		assert.Nil(t, err)
+		_, err = g.GenerateSpec(o, stack)
+		assert.Nil(t, err)
+		assert.Equal(t, 4, wrapped.count)
+
+		assert.Nil(t, os.WriteFile(outFile, []byte("b = 2"), 0o644))
+		_, err = g.GenerateSpec(o, stack)
+		assert.Nil(t, err)
+		assert.Equal(t, 5, wrapped.count)
+	})
+
+	t.Run("override AST", func(t *testing.T) {
+		o.OverrideAST = true
+		_, err := g.GenerateSpec(o, stack)
+		assert.Nil(t, err)
+		_, err = g.GenerateSpec(o, stack)
+		assert.Nil(t, err)
+		assert.Equal(t, 7, wrapped.count)
+	})
+}
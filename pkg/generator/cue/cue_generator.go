@@ -0,0 +1,219 @@
+package cue
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/generator/manifest"
+	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// Config is the generator configs of CUE in project.yaml
+type Config struct {
+	// Packages are CUE packages or files to export, relative to the stack directory. Defaults to the stack directory
+	Packages []string `json:"packages,omitempty"`
+
+	// Expression selects the value to export, e.g. `objects`. The whole value is exported if empty
+	Expression string `json:"expression,omitempty"`
+
+	// Tags are values injected into fields with @tag attributes
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// Generator generates Spec by exporting CUE configurations with the cue CLI.
+// The exported value can be a manifest, a list, or an object whose fields are manifests.
+type Generator struct {
+	Config
+}
+
+var _ generator.Generator = (*Generator)(nil)
+
+// NewGenerator returns a CUE Generator with the generator configs in project.yaml
+func NewGenerator(configs map[string]interface{}) (*Generator, error) {
+	g := &Generator{}
+	data, err := json.Marshal(configs)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &g.Config); err != nil {
+		return nil, fmt.Errorf("invalid cue generator configs: %w", err)
+	}
+	if len(g.Packages) == 0 {
+		g.Packages = []string{"."}
+	}
+	return g, nil
+}
+
+func (g *Generator) GenerateSpec(o *generator.Options, stack *projectstack.Stack) (*models.Spec, error) {
+	tags, err := declaredTags(stack.Path, g.Packages)
+	if err != nil {
+		return nil, err
+	}
+	out, err := runCue(stack.Path, g.exportArgs(o, tags)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if err = json.Unmarshal(out, &value); err != nil {
+		return nil, fmt.Errorf("failed to parse cue export result: %w", err)
+	}
+	resources, err := manifest.FromValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Spec{Resources: resources}, nil
+}
+
+// exportArgs returns arguments of the `cue export` command. Top-level arguments
+// specified by `-D name=value` are injected as tags, and override tags in configs.
+// Only tags declared by the packages are injected, since cue fails on undeclared ones,
+// e.g. values layered for all stacks. All tags are injected if declared is nil.
+func (g *Generator) exportArgs(o *generator.Options, declared map[string]bool) []string {
+	args := append([]string{"export"}, g.Packages...)
+	args = append(args, "--out", "json")
+	if g.Expression != "" {
+		args = append(args, "--expression", g.Expression)
+	}
+
+	keys := make([]string, 0, len(g.Tags))
+	for k := range g.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	tags := make([]string, 0, len(keys)+len(o.Arguments))
+	for _, k := range keys {
+		tags = append(tags, fmt.Sprintf("%s=%s", k, g.Tags[k]))
+	}
+	tags = append(tags, o.Arguments...)
+
+	for _, tag := range tags {
+		name, _, _ := strings.Cut(tag, "=")
+		if declared != nil && !declared[name] {
+			log.Warnf("tag %s is not declared by cue packages %v, skip it", name, g.Packages)
+			continue
+		}
+		args = append(args, "--inject", tag)
+	}
+	return args
+}
+
+// tagAttribute matches @tag attributes of fields, whose first argument is the tag name
+var tagAttribute = regexp.MustCompile(`@tag\(\s*([A-Za-z_$#][\w$#]*)`)
+
+// declaredTags returns names of tags declared by @tag attributes in CUE files of the packages relative to the
+// directory, including files in parent directories up to the CUE module root which belong to the packages too.
+// It returns nil if no CUE file is found, e.g. the packages are import paths.
+func declaredTags(dir string, packages []string) (map[string]bool, error) {
+	var files []string
+	for _, pkg := range packages {
+		recursive := strings.HasSuffix(filepath.ToSlash(pkg), "/...")
+		if recursive {
+			pkg = filepath.FromSlash(strings.TrimSuffix(filepath.ToSlash(pkg), "/..."))
+		}
+		if !filepath.IsAbs(pkg) {
+			pkg = filepath.Join(dir, pkg)
+		}
+		info, err := os.Stat(pkg)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			files = append(files, pkg)
+			continue
+		}
+		found, err := cueFiles(pkg, recursive)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+		if root := moduleRoot(pkg); root != "" {
+			for parent := pkg; parent != root; {
+				parent = filepath.Dir(parent)
+				if found, err = cueFiles(parent, false); err != nil {
+					return nil, err
+				}
+				files = append(files, found...)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	tags := map[string]bool{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range tagAttribute.FindAllSubmatch(data, -1) {
+			tags[string(match[1])] = true
+		}
+	}
+	return tags, nil
+}
+
+// cueFiles returns CUE files in the directory, and in its subdirectories if recursive
+func cueFiles(dir string, recursive bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && (!recursive || d.Name() == cueModDir || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) == ".cue" {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// cueModDir marks the root directory of a CUE module
+const cueModDir = "cue.mod"
+
+// moduleRoot returns the root directory of the CUE module of the directory, or an empty string if the
+// directory is not in a CUE module
+func moduleRoot(dir string) string {
+	for {
+		if info, err := os.Stat(filepath.Join(dir, cueModDir)); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// runCue runs the cue CLI in the directory and returns its stdout
+func runCue(dir string, args ...string) ([]byte, error) {
+	log.Debugf("run cue with args: %v", args)
+	cmd := exec.Command("cue", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if e, ok := err.(*exec.ExitError); ok {
+		return nil, fmt.Errorf("cue %s failed: %s", args[0], string(e.Stderr))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,75 @@
+package cue
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+const exported = `{
+    "deployment": {
+        "nginx": {"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "nginx", "namespace": "web"}}
+    },
+    "service": {
+        "nginx": {"apiVersion": "v1", "kind": "Service", "metadata": {"name": "nginx", "namespace": "web"}}
+    }
+}`
+
+func TestGenerator_GenerateSpec(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	var gotArgs []string
+	monkey.Patch(runCue, func(dir string, args ...string) ([]byte, error) {
+		gotArgs = args
+		return []byte(exported), nil
+	})
+
+	g, err := NewGenerator(map[string]interface{}{
+		"expression": "objects",
+		"tags":       map[string]interface{}{"env": "dev"},
+	})
+	assert.Nil(t, err)
+
+	sp, err := g.GenerateSpec(&generator.Options{Arguments: []string{"image=nginx:1.23"}}, &projectstack.Stack{Path: t.TempDir()})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"export", ".", "--out", "json", "--expression", "objects", "--inject", "env=dev", "--inject", "image=nginx:1.23",
+	}, gotArgs)
+	assert.Len(t, sp.Resources, 2)
+	assert.Equal(t, "apps/v1:Deployment:web:nginx", sp.Resources[0].ID)
+	assert.Equal(t, "v1:Service:web:nginx", sp.Resources[1].ID)
+}
+
+func TestGenerator_GenerateSpecWithDeclaredTags(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	var gotArgs []string
+	monkey.Patch(runCue, func(dir string, args ...string) ([]byte, error) {
+		gotArgs = args
+		return []byte(exported), nil
+	})
+
+	root := t.TempDir()
+	stackDir := filepath.Join(root, "dev")
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, "cue.mod"), 0o755))
+	assert.Nil(t, os.MkdirAll(stackDir, 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "base.cue"), []byte("package app\n\nenv: string @tag(env)\n"), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(stackDir, "main.cue"), []byte("package app\n\nimage: string @tag( image, short=latest)\n"), 0o644))
+
+	g, err := NewGenerator(map[string]interface{}{
+		"tags": map[string]interface{}{"env": "dev", "region": "us"},
+	})
+	assert.Nil(t, err)
+
+	_, err = g.GenerateSpec(&generator.Options{Arguments: []string{"image=nginx:1.23", "replicas=2"}}, &projectstack.Stack{Path: stackDir})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"export", ".", "--out", "json", "--inject", "env=dev", "--inject", "image=nginx:1.23",
+	}, gotArgs)
+}
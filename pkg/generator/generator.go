@@ -28,6 +28,9 @@ type Options struct {
 	// Arguments are args used for a specified Generator. All Generator related args should be passed through this field
 	Arguments []string
 
+	// Sets are values set by paths like app.env[0].value=prod, they are merged into Arguments before generating
+	Sets []string
+
 	// Overrides contains all override args of this operation
 	Overrides []string
 
@@ -0,0 +1,212 @@
+package helm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/imdario/mergo"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kusionstack.io/kusion/pkg/engine"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/generator/kcl"
+	"kusionstack.io/kusion/pkg/generator/manifest"
+	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// Config is the generator configs of a Helm chart in project.yaml
+type Config struct {
+	// Chart is the chart reference, which can be a local path, a chart name in Repo or an OCI reference
+	Chart string `json:"chart,omitempty"`
+
+	// Version is the chart version. The latest version is used if empty
+	Version string `json:"version,omitempty"`
+
+	// Repo is the chart repository URL
+	Repo string `json:"repo,omitempty"`
+
+	// Release is the release name. The stack name is used if empty
+	Release string `json:"release,omitempty"`
+
+	// Namespace is the namespace of the release, which is also set on namespaced resources without a namespace
+	Namespace string `json:"namespace,omitempty"`
+
+	// ValuesFiles are values files relative to the stack directory
+	ValuesFiles []string `json:"valuesFiles,omitempty"`
+
+	// Values are inline values, which override all other values
+	Values map[string]interface{} `json:"values,omitempty"`
+
+	// KCLValues means the compile result of KCL files in the stack is used as values.
+	// It overrides ValuesFiles and is overridden by Values
+	KCLValues bool `json:"kclValues,omitempty"`
+}
+
+// Generator renders a Helm chart into Spec resources by the helm CLI
+type Generator struct {
+	Config
+
+	// Scope tells namespaced resources to set Namespace on, common cluster-scoped kinds are skipped if it is nil
+	Scope *manifest.Scope
+}
+
+var _ generator.Generator = (*Generator)(nil)
+
+// NewGenerator returns a Helm Generator with the generator configs in project.yaml
+func NewGenerator(configs map[string]interface{}) (*Generator, error) {
+	g := &Generator{}
+	data, err := json.Marshal(configs)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &g.Config); err != nil {
+		return nil, fmt.Errorf("invalid helm generator configs: %w", err)
+	}
+	if g.Chart == "" {
+		return nil, errors.New("chart is required in helm generator configs")
+	}
+	return g, nil
+}
+
+func (g *Generator) GenerateSpec(o *generator.Options, stack *projectstack.Stack) (*models.Spec, error) {
+	args, cleanup, err := g.templateArgs(o, stack)
+	if cleanup != nil {
+		defer cleanup()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := runHelm(stack.Path, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := manifest.Parse(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rendered chart %s: %w", g.Chart, err)
+	}
+	if g.Namespace != "" {
+		crdScopes := customResourceScopes(resources)
+		for i := range resources {
+			g.setNamespace(&resources[i], crdScopes)
+		}
+	}
+	return &models.Spec{Resources: resources}, nil
+}
+
+// templateArgs returns arguments of the `helm template` command, and a function to clean up the temporary values file
+func (g *Generator) templateArgs(o *generator.Options, stack *projectstack.Stack) ([]string, func(), error) {
+	release := g.Release
+	if release == "" {
+		release = stack.Name
+	}
+	args := []string{"template", release, g.Chart, "--include-crds"}
+	if g.Repo != "" {
+		args = append(args, "--repo", g.Repo)
+	}
+	if g.Version != "" {
+		args = append(args, "--version", g.Version)
+	}
+	if g.Namespace != "" {
+		args = append(args, "--namespace", g.Namespace)
+	}
+	for _, f := range g.ValuesFiles {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(stack.Path, f)
+		}
+		args = append(args, "--values", f)
+	}
+
+	values := map[string]interface{}{}
+	if g.KCLValues {
+		result, err := kcl.Run(o, stack)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, doc := range result.Documents {
+			if err = mergo.Merge(&values, map[string]interface{}(doc), mergo.WithOverride); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if err := mergo.Merge(&values, g.Values, mergo.WithOverride); err != nil {
+		return nil, nil, err
+	}
+	if len(values) == 0 {
+		return args, nil, nil
+	}
+
+	// Values with the highest priority are written into a temporary file and passed at last
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.CreateTemp("", "kusion-helm-values-*.yaml")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { _ = os.Remove(f.Name()) }
+	defer f.Close()
+	if _, err = f.Write(data); err != nil {
+		return nil, cleanup, err
+	}
+	return append(args, "--values", f.Name()), cleanup, nil
+}
+
+// customResourceScopes returns whether kinds of the CRDs in the resources are cluster-scoped, since the CRDs
+// rendered with the chart may not be installed in the cluster yet
+func customResourceScopes(resources []models.Resource) map[schema.GroupKind]bool {
+	scopes := map[schema.GroupKind]bool{}
+	for _, r := range resources {
+		u := &unstructured.Unstructured{Object: r.Attributes}
+		if u.GetKind() != "CustomResourceDefinition" {
+			continue
+		}
+		group, _, _ := unstructured.NestedString(u.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(u.Object, "spec", "names", "kind")
+		scope, _, _ := unstructured.NestedString(u.Object, "spec", "scope")
+		scopes[schema.GroupKind{Group: group, Kind: kind}] = scope == "Cluster"
+	}
+	return scopes
+}
+
+// setNamespace sets Namespace on a namespaced resource without one, and updates its ID
+func (g *Generator) setNamespace(r *models.Resource, crdScopes map[schema.GroupKind]bool) {
+	u := &unstructured.Unstructured{Object: r.Attributes}
+	if u.GetNamespace() != "" {
+		return
+	}
+	if clusterScoped, ok := crdScopes[u.GroupVersionKind().GroupKind()]; ok {
+		if clusterScoped {
+			return
+		}
+	} else if g.Scope.IsClusterScoped(u.GetAPIVersion(), u.GetKind()) {
+		return
+	}
+	u.SetNamespace(g.Namespace)
+	r.ID = engine.BuildIDForKubernetes(u.GetAPIVersion(), u.GetKind(), g.Namespace, u.GetName())
+}
+
+// runHelm runs the helm CLI in the directory and returns its stdout
+func runHelm(dir string, args ...string) ([]byte, error) {
+	log.Debugf("run helm with args: %v", args)
+	cmd := exec.Command("helm", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if e, ok := err.(*exec.ExitError); ok {
+		return nil, fmt.Errorf("helm %s failed: %s", args[0], string(e.Stderr))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,109 @@
+package helm
+
+import (
+	"os"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/generator/manifest"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+const rendered = `
+---
+# Source: nginx/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+spec:
+  ports:
+  - port: 80
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: nginx
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: bars.example.com
+spec:
+  group: example.com
+  names:
+    kind: Bar
+  scope: Cluster
+---
+apiVersion: example.com/v1
+kind: Bar
+metadata:
+  name: nginx
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: nginx
+`
+
+func TestNewGenerator(t *testing.T) {
+	_, err := NewGenerator(map[string]interface{}{})
+	assert.NotNil(t, err)
+
+	g, err := NewGenerator(map[string]interface{}{
+		"chart":       "nginx",
+		"repo":        "https://charts.example.com",
+		"valuesFiles": []interface{}{"values.yaml"},
+		"values":      map[string]interface{}{"replicaCount": 2},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "nginx", g.Chart)
+	assert.Equal(t, []string{"values.yaml"}, g.ValuesFiles)
+	assert.Equal(t, float64(2), g.Values["replicaCount"])
+}
+
+func TestGenerator_GenerateSpec(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	var gotArgs []string
+	var gotValues string
+	monkey.Patch(runHelm, func(dir string, args ...string) ([]byte, error) {
+		gotArgs = args
+		data, err := os.ReadFile(args[len(args)-1])
+		assert.Nil(t, err)
+		gotValues = string(data)
+		return []byte(rendered), nil
+	})
+
+	g := &Generator{Config: Config{
+		Chart:       "nginx",
+		Version:     "1.0.0",
+		Namespace:   "web",
+		ValuesFiles: []string{"/path/to/values.yaml"},
+		Values:      map[string]interface{}{"replicaCount": 2},
+	}}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}, meta.RESTScopeRoot)
+	g.Scope = manifest.NewScope(mapper)
+	sp, err := g.GenerateSpec(&generator.Options{}, &projectstack.Stack{
+		StackConfiguration: projectstack.StackConfiguration{Name: "dev"},
+		Path:               t.TempDir(),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"template", "dev", "nginx", "--include-crds", "--version", "1.0.0", "--namespace", "web",
+		"--values", "/path/to/values.yaml", "--values",
+	}, gotArgs[:len(gotArgs)-1])
+	assert.Equal(t, "replicaCount: 2\n", gotValues)
+
+	assert.Len(t, sp.Resources, 5)
+	assert.Equal(t, "v1:Service:web:nginx", sp.Resources[0].ID)
+	assert.Equal(t, "rbac.authorization.k8s.io/v1:ClusterRole:nginx", sp.Resources[1].ID)
+	assert.Equal(t, "example.com/v1:Bar:nginx", sp.Resources[3].ID)
+	assert.Equal(t, "example.com/v1:Widget:nginx", sp.Resources[4].ID)
+}
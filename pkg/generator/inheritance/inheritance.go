@@ -0,0 +1,212 @@
+// Package inheritance merges the spec of a stack onto the spec of its base stack, so that stacks of different
+// environments only declare what differs from the shared base.
+//
+// Resources are matched by their IDs, and attributes of matched resources are merged strategically:
+//   - maps are merged recursively, and a null value removes the key from the base
+//   - lists whose elements are all maps with a "name" field, e.g. containers, env and volumes, are merged
+//     by the name, and other lists are replaced
+//   - the "$patch" directive in a map overrides the merge: "replace" replaces the map of the base, and
+//     "delete" removes the map from the base. In the top level of attributes, it applies to the resource.
+//
+// Resources only in the stack are appended after the ones of the base.
+package inheritance
+
+import (
+	"fmt"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+const (
+	// PatchDirective is the key of the directive which overrides the merge
+	PatchDirective = "$patch"
+	// PatchReplace replaces the value of the base
+	PatchReplace = "replace"
+	// PatchDelete deletes the value of the base
+	PatchDelete = "delete"
+
+	mergeKey = "name"
+)
+
+// Merge returns the spec of the stack merged onto the spec of the base stack, both of them are not modified
+func Merge(base, overlay *models.Spec) *models.Spec {
+	if base == nil {
+		return overlay
+	}
+	if overlay == nil {
+		return base
+	}
+
+	overlayIndex := overlay.Resources.Index()
+	merged := &models.Spec{Resources: models.Resources{}}
+	for i := range base.Resources {
+		res := base.Resources[i].DeepCopy()
+		if o, ok := overlayIndex[res.ID]; ok {
+			if res = mergeResource(res, o.DeepCopy()); res == nil {
+				continue
+			}
+		}
+		merged.Resources = append(merged.Resources, *res)
+	}
+
+	baseIndex := base.Resources.Index()
+	for i := range overlay.Resources {
+		if _, ok := baseIndex[overlay.Resources[i].ID]; ok {
+			continue
+		}
+		res := overlay.Resources[i].DeepCopy()
+		if directive(res.Attributes) == PatchDelete {
+			continue
+		}
+		delete(res.Attributes, PatchDirective)
+		merged.Resources = append(merged.Resources, *res)
+	}
+	return merged
+}
+
+// mergeResource merges the overlay resource onto the base one, and returns nil if the resource is deleted
+func mergeResource(base, overlay *models.Resource) *models.Resource {
+	switch directive(overlay.Attributes) {
+	case PatchDelete:
+		return nil
+	case PatchReplace:
+		delete(overlay.Attributes, PatchDirective)
+		return overlay
+	}
+
+	if overlay.Type != "" {
+		base.Type = overlay.Type
+	}
+	if attributes, ok := mergeValue(base.Attributes, overlay.Attributes).(map[string]interface{}); ok {
+		base.Attributes = attributes
+	}
+	if overlay.DependsOn != nil {
+		base.DependsOn = overlay.DependsOn
+	}
+	if overlay.Extensions != nil {
+		if extensions, ok := mergeValue(base.Extensions, overlay.Extensions).(map[string]interface{}); ok {
+			base.Extensions = extensions
+		}
+	}
+	return base
+}
+
+// mergeValue merges the overlay value onto the base one
+func mergeValue(base, overlay interface{}) interface{} {
+	switch o := overlay.(type) {
+	case map[string]interface{}:
+		b, ok := base.(map[string]interface{})
+		if !ok || directive(o) == PatchReplace {
+			delete(o, PatchDirective)
+			return o
+		}
+		return mergeMap(b, o)
+	case []interface{}:
+		b, ok := base.([]interface{})
+		if !ok || !mergeable(b) || !mergeable(o) {
+			return o
+		}
+		return mergeList(b, o)
+	default:
+		return overlay
+	}
+}
+
+func mergeMap(base, overlay map[string]interface{}) map[string]interface{} {
+	if base == nil {
+		base = map[string]interface{}{}
+	}
+	for k, v := range overlay {
+		if k == PatchDirective {
+			continue
+		}
+		if v == nil {
+			delete(base, k)
+			continue
+		}
+		if m, ok := v.(map[string]interface{}); ok && directive(m) == PatchDelete {
+			delete(base, k)
+			continue
+		}
+		base[k] = mergeValue(base[k], v)
+	}
+	return base
+}
+
+// mergeList merges lists of maps by the name of elements
+func mergeList(base, overlay []interface{}) []interface{} {
+	overlayIndex := map[interface{}]map[string]interface{}{}
+	for _, item := range overlay {
+		m := item.(map[string]interface{})
+		overlayIndex[m[mergeKey]] = m
+	}
+
+	merged := make([]interface{}, 0, len(base)+len(overlay))
+	baseIndex := map[interface{}]bool{}
+	for _, item := range base {
+		m := item.(map[string]interface{})
+		baseIndex[m[mergeKey]] = true
+		o, ok := overlayIndex[m[mergeKey]]
+		if !ok {
+			merged = append(merged, m)
+			continue
+		}
+		if directive(o) == PatchDelete {
+			continue
+		}
+		merged = append(merged, mergeValue(m, o))
+	}
+	for _, item := range overlay {
+		m := item.(map[string]interface{})
+		if baseIndex[m[mergeKey]] || directive(m) == PatchDelete {
+			continue
+		}
+		delete(m, PatchDirective)
+		merged = append(merged, m)
+	}
+	return merged
+}
+
+// mergeable returns true if all elements of the list are maps with the merge key
+func mergeable(list []interface{}) bool {
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok = m[mergeKey]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func directive(m map[string]interface{}) interface{} {
+	if m == nil {
+		return nil
+	}
+	return m[PatchDirective]
+}
+
+// Generator generates the spec of a stack by the wrapped Generator, and merges it onto the spec of the base stack
+type Generator struct {
+	generator.Generator
+	// Base generates the spec of the base stack
+	Base func() (*models.Spec, error)
+}
+
+var _ generator.Generator = (*Generator)(nil)
+
+func (g *Generator) GenerateSpec(o *generator.Options, stack *projectstack.Stack) (*models.Spec, error) {
+	base, err := g.Base()
+	if err != nil {
+		return nil, fmt.Errorf("generate the spec of the base stack %s failed: %w", stack.Base, err)
+	}
+	spec, err := g.Generator.GenerateSpec(o, stack)
+	if err != nil {
+		return nil, err
+	}
+	return Merge(base, spec), nil
+}
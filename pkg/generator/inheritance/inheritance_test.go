@@ -0,0 +1,116 @@
+package inheritance
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func deployment(replicas int, containers ...interface{}) models.Resource {
+	return models.Resource{
+		ID:   "apps/v1:Deployment:default:app",
+		Type: "Kubernetes",
+		Attributes: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "app", "labels": map[string]interface{}{"app": "app", "tier": "web"}},
+			"spec": map[string]interface{}{
+				"replicas": replicas,
+				"template": map[string]interface{}{"spec": map[string]interface{}{"containers": containers}},
+			},
+		},
+	}
+}
+
+func resource(id string, attributes map[string]interface{}) models.Resource {
+	return models.Resource{ID: id, Type: "Kubernetes", Attributes: attributes}
+}
+
+func TestMerge(t *testing.T) {
+	base := &models.Spec{Resources: models.Resources{
+		deployment(1,
+			map[string]interface{}{"name": "app", "image": "app:v1", "args": []interface{}{"--a"}},
+			map[string]interface{}{"name": "sidecar", "image": "sidecar:v1"},
+		),
+		resource("v1:ConfigMap:default:config", map[string]interface{}{"data": map[string]interface{}{"a": "1"}}),
+		resource("v1:ConfigMap:default:debug", map[string]interface{}{"data": map[string]interface{}{"debug": "true"}}),
+	}}
+	overlay := &models.Spec{Resources: models.Resources{
+		{
+			ID: "apps/v1:Deployment:default:app",
+			Attributes: map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"tier": nil, "env": "prod"}},
+				"spec": map[string]interface{}{
+					"replicas": 3,
+					"template": map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+						map[string]interface{}{"name": "app", "image": "app:v2", "args": []interface{}{"--b"}},
+						map[string]interface{}{"name": "sidecar", PatchDirective: PatchDelete},
+						map[string]interface{}{"name": "proxy", "image": "proxy:v1"},
+					}}},
+				},
+			},
+		},
+		resource("v1:ConfigMap:default:config", map[string]interface{}{
+			"data": map[string]interface{}{PatchDirective: PatchReplace, "b": "2"},
+		}),
+		resource("v1:ConfigMap:default:debug", map[string]interface{}{PatchDirective: PatchDelete}),
+		resource("v1:Secret:default:prod", map[string]interface{}{PatchDirective: PatchReplace, "type": "Opaque"}),
+	}}
+
+	merged := Merge(base, overlay)
+	assert.Equal(t, &models.Spec{Resources: models.Resources{
+		{
+			ID:   "apps/v1:Deployment:default:app",
+			Type: "Kubernetes",
+			Attributes: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "app", "labels": map[string]interface{}{"app": "app", "env": "prod"}},
+				"spec": map[string]interface{}{
+					"replicas": 3,
+					"template": map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+						map[string]interface{}{"name": "app", "image": "app:v2", "args": []interface{}{"--b"}},
+						map[string]interface{}{"name": "proxy", "image": "proxy:v1"},
+					}}},
+				},
+			},
+		},
+		resource("v1:ConfigMap:default:config", map[string]interface{}{"data": map[string]interface{}{"b": "2"}}),
+		resource("v1:Secret:default:prod", map[string]interface{}{"type": "Opaque"}),
+	}}, merged)
+
+	// inputs are not modified
+	assert.Len(t, base.Resources, 3)
+	assert.Equal(t, PatchDelete, overlay.Resources[2].Attributes[PatchDirective])
+
+	assert.Same(t, overlay, Merge(nil, overlay))
+	assert.Same(t, base, Merge(base, nil))
+}
+
+type fakeGenerator struct {
+	spec *models.Spec
+	err  error
+}
+
+func (g *fakeGenerator) GenerateSpec(*generator.Options, *projectstack.Stack) (*models.Spec, error) {
+	return g.spec, g.err
+}
+
+func TestGenerator(t *testing.T) {
+	stack := &projectstack.Stack{StackConfiguration: projectstack.StackConfiguration{Name: "prod", Base: "../base"}}
+	base := &models.Spec{Resources: models.Resources{resource("a", map[string]interface{}{"k": "base"})}}
+	overlay := &models.Spec{Resources: models.Resources{resource("b", map[string]interface{}{"k": "prod"})}}
+
+	g := &Generator{
+		Generator: &fakeGenerator{spec: overlay},
+		Base:      func() (*models.Spec, error) { return base, nil },
+	}
+	spec, err := g.GenerateSpec(&generator.Options{}, stack)
+	assert.Nil(t, err)
+	assert.Equal(t, models.Resources{base.Resources[0], overlay.Resources[0]}, spec.Resources)
+
+	g.Base = func() (*models.Spec, error) { return nil, errors.New("compile failed") }
+	_, err = g.GenerateSpec(&generator.Options{}, stack)
+	assert.EqualError(t, err, "generate the spec of the base stack ../base failed: compile failed")
+}
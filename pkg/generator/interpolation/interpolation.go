@@ -0,0 +1,163 @@
+// Package interpolation resolves ${env:VAR} and ${file:path} references in settings files at compile time,
+// so that values provided by CI, such as image tags and git SHAs, can flow into the compilation. Only
+// references allowed explicitly in project.yaml are resolved, and $${...} is kept as a literal ${...}.
+// References are resolved in scalars of the parsed YAML, which is encoded again, so that values never change
+// the structure of settings files.
+package interpolation
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// referencePattern matches ${env:VAR} and ${file:path}, and the escaped form $${...}
+var referencePattern = regexp.MustCompile(`\$?\$\{(env|file):([^}]*)\}`)
+
+// Interpolator resolves references allowed by the allowlist
+type Interpolator struct {
+	// Allowlist contains allowed environment variables and files
+	Allowlist *projectstack.InterpolationConfig
+
+	// ProjectDir is the directory relative paths of files are resolved against
+	ProjectDir string
+}
+
+// Interpolate replaces all references in scalars of the YAML data with their values. Values of plain scalars
+// are resolved as YAML scalars, e.g. numbers and booleans, while quoted scalars are kept as strings.
+func (i *Interpolator) Interpolate(data []byte) ([]byte, error) {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("parse settings failed: %w", err)
+	}
+	if doc.Kind == 0 {
+		return data, nil
+	}
+	if err := i.interpolateNode(doc); err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// interpolateNode replaces references in all scalars of the node
+func (i *Interpolator) interpolateNode(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode && referencePattern.MatchString(node.Value) {
+		value, err := i.interpolateString(node.Value)
+		if err != nil {
+			return err
+		}
+		node.Value = value
+		if node.Style == 0 {
+			// resolve the tag of the value when the node is encoded
+			node.Tag = ""
+		}
+		return nil
+	}
+	for _, child := range node.Content {
+		if err := i.interpolateNode(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// interpolateString replaces all references in the string with their values
+func (i *Interpolator) interpolateString(s string) (string, error) {
+	var err error
+	result := referencePattern.ReplaceAllStringFunc(s, func(match string) string {
+		if err != nil {
+			return match
+		}
+		if strings.HasPrefix(match, "$$") {
+			return match[1:]
+		}
+		groups := referencePattern.FindStringSubmatch(match)
+		var value string
+		value, err = i.resolve(groups[1], strings.TrimSpace(groups[2]))
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+func (i *Interpolator) resolve(kind, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("empty reference ${%s:}", kind)
+	}
+
+	var patterns []string
+	if i.Allowlist != nil {
+		if kind == "env" {
+			patterns = i.Allowlist.Env
+		} else {
+			patterns = i.Allowlist.Files
+		}
+	}
+
+	switch kind {
+	case "env":
+		if !matchAny(patterns, name) {
+			return "", fmt.Errorf("environment variable %s is not allowed to be interpolated, add it to interpolation.env in project.yaml", name)
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s referenced by ${env:%s} is not set", name, name)
+		}
+		return value, nil
+	default:
+		filename := filepath.Clean(name)
+		if !filepath.IsAbs(filename) {
+			filename = filepath.Join(i.ProjectDir, filename)
+		}
+		rel, err := filepath.Rel(i.ProjectDir, filename)
+		if err != nil || !matchAny(patterns, filepath.ToSlash(rel)) {
+			return "", fmt.Errorf("file %s is not allowed to be interpolated, add it to interpolation.files in project.yaml", name)
+		}
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return "", fmt.Errorf("read file referenced by ${file:%s} failed: %w", name, err)
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	}
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Process interpolates the content of a settings file if it contains references
+func (i *Interpolator) Process(_ string, data []byte) ([]byte, bool, error) {
+	if !referencePattern.Match(data) {
+		return data, false, nil
+	}
+	result, err := i.Interpolate(data)
+	if err != nil {
+		return nil, false, err
+	}
+	return result, true, nil
+}
@@ -0,0 +1,114 @@
+package interpolation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func TestInterpolator_Interpolate(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "ci"), 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "ci", "sha.txt"), []byte("4f2a9c1\n"), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0o644))
+	t.Setenv("CI_IMAGE_TAG", "v1.2.0")
+	t.Setenv("HOME_SECRET", "secret")
+	t.Setenv("CI_INJECTED", "v1\nadmin: true")
+	t.Setenv("CI_REPLICAS", "3")
+
+	i := &Interpolator{
+		Allowlist:  &projectstack.InterpolationConfig{Env: []string{"CI_*", "UNSET"}, Files: []string{"ci/*.txt"}},
+		ProjectDir: dir,
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "env and file",
+			data: "image: nginx:${env:CI_IMAGE_TAG}\nsha: ${file:ci/sha.txt}\n",
+			want: "image: nginx:v1.2.0\nsha: 4f2a9c1\n",
+		},
+		{
+			name: "escaped",
+			data: "literal: $${env:CI_IMAGE_TAG}",
+			want: "literal: ${env:CI_IMAGE_TAG}\n",
+		},
+		{
+			name: "values never change the structure",
+			data: "tag: ${env:CI_INJECTED}\nquoted: '${env:CI_IMAGE_TAG}'\n",
+			want: "tag: |-\n  v1\n  admin: true\nquoted: 'v1.2.0'\n",
+		},
+		{
+			name: "plain scalars are resolved",
+			data: "replicas: ${env:CI_REPLICAS}\nimage:\n  tag: \"${env:CI_REPLICAS}\"\n",
+			want: "replicas: 3\nimage:\n  tag: \"3\"\n",
+		},
+		{
+			name:    "env not allowed",
+			data:    "secret: ${env:HOME_SECRET}",
+			wantErr: "environment variable HOME_SECRET is not allowed to be interpolated",
+		},
+		{
+			name:    "env not set",
+			data:    "value: ${env:UNSET}",
+			wantErr: "environment variable UNSET referenced by ${env:UNSET} is not set",
+		},
+		{
+			name:    "file not allowed",
+			data:    "secret: ${file:secret.txt}",
+			wantErr: "file secret.txt is not allowed to be interpolated",
+		},
+		{
+			name:    "file outside the project",
+			data:    "secret: ${file:ci/../../secret.txt}",
+			wantErr: "file ci/../../secret.txt is not allowed to be interpolated",
+		},
+		{
+			name:    "missing file",
+			data:    "sha: ${file:ci/missing.txt}",
+			wantErr: "read file referenced by ${file:ci/missing.txt} failed",
+		},
+		{
+			name:    "empty reference",
+			data:    "value: ${env:}",
+			wantErr: "empty reference ${env:}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := i.Interpolate([]byte(tt.data))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestInterpolator_Process(t *testing.T) {
+	t.Setenv("CI_TAG", "v1")
+	i := &Interpolator{Allowlist: &projectstack.InterpolationConfig{Env: []string{"CI_TAG"}}}
+
+	data, changed, err := i.Process("kcl.yaml", []byte("tag: latest"))
+	assert.Nil(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, "tag: latest", string(data))
+
+	data, changed, err = i.Process("kcl.yaml", []byte("tag: ${env:CI_TAG}"))
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "tag: v1\n", string(data))
+
+	_, _, err = i.Process("kcl.yaml", []byte("tag: [${env:CI_TAG}"))
+	assert.ErrorContains(t, err, "parse settings failed")
+}
@@ -0,0 +1,121 @@
+package jsonnet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/generator/manifest"
+	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// DefaultFile is the Jsonnet file evaluated if no file is configured
+const DefaultFile = "main.jsonnet"
+
+// Config is the generator configs of Jsonnet in project.yaml
+type Config struct {
+	// File is the Jsonnet file to evaluate, relative to the stack directory
+	File string `json:"file,omitempty"`
+
+	// JPath are library search directories, relative to the stack directory
+	JPath []string `json:"jpath,omitempty"`
+
+	// ExtVars are external string variables, which can be read by std.extVar()
+	ExtVars map[string]string `json:"extVars,omitempty"`
+
+	// ExtCode are external variables given as Jsonnet code
+	ExtCode map[string]string `json:"extCode,omitempty"`
+}
+
+// Generator generates Spec by evaluating Jsonnet with the jsonnet CLI.
+// The evaluated value can be a manifest, a list, or an object whose fields are manifests.
+type Generator struct {
+	Config
+}
+
+var _ generator.Generator = (*Generator)(nil)
+
+// NewGenerator returns a Jsonnet Generator with the generator configs in project.yaml
+func NewGenerator(configs map[string]interface{}) (*Generator, error) {
+	g := &Generator{}
+	data, err := json.Marshal(configs)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &g.Config); err != nil {
+		return nil, fmt.Errorf("invalid jsonnet generator configs: %w", err)
+	}
+	if g.File == "" {
+		g.File = DefaultFile
+	}
+	return g, nil
+}
+
+func (g *Generator) GenerateSpec(o *generator.Options, stack *projectstack.Stack) (*models.Spec, error) {
+	out, err := runJsonnet(stack.Path, g.evalArgs(o, stack)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if err = json.Unmarshal(out, &value); err != nil {
+		return nil, fmt.Errorf("failed to parse jsonnet output: %w", err)
+	}
+	resources, err := manifest.FromValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Spec{Resources: resources}, nil
+}
+
+// evalArgs returns arguments of the jsonnet command. Top-level arguments specified
+// by `-D name=value` are passed as external string variables.
+func (g *Generator) evalArgs(o *generator.Options, stack *projectstack.Stack) []string {
+	var args []string
+	for _, p := range g.JPath {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(stack.Path, p)
+		}
+		args = append(args, "--jpath", p)
+	}
+	args = append(args, sortedArgs("--ext-str", g.ExtVars)...)
+	args = append(args, sortedArgs("--ext-code", g.ExtCode)...)
+	for _, arg := range o.Arguments {
+		args = append(args, "--ext-str", arg)
+	}
+	return append(args, g.File)
+}
+
+func sortedArgs(flag string, vars map[string]string) []string {
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var args []string
+	for _, k := range keys {
+		args = append(args, flag, fmt.Sprintf("%s=%s", k, vars[k]))
+	}
+	return args
+}
+
+// runJsonnet runs the jsonnet CLI in the directory and returns its stdout
+func runJsonnet(dir string, args ...string) ([]byte, error) {
+	log.Debugf("run jsonnet with args: %v", args)
+	cmd := exec.Command("jsonnet", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if e, ok := err.(*exec.ExitError); ok {
+		return nil, fmt.Errorf("jsonnet failed: %s", string(e.Stderr))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,47 @@
+package jsonnet
+
+import (
+	"path/filepath"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+const evaluated = `[
+    {"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "web"}},
+    {"apiVersion": "v1", "kind": "Service", "metadata": {"name": "nginx", "namespace": "web"}}
+]`
+
+func TestGenerator_GenerateSpec(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	var gotArgs []string
+	monkey.Patch(runJsonnet, func(dir string, args ...string) ([]byte, error) {
+		gotArgs = args
+		return []byte(evaluated), nil
+	})
+
+	g, err := NewGenerator(map[string]interface{}{
+		"jpath":   []interface{}{"vendor"},
+		"extVars": map[string]interface{}{"env": "dev"},
+		"extCode": map[string]interface{}{"replicas": "2"},
+	})
+	assert.Nil(t, err)
+
+	stackDir := t.TempDir()
+	sp, err := g.GenerateSpec(&generator.Options{Arguments: []string{"image=nginx"}}, &projectstack.Stack{Path: stackDir})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"--jpath", filepath.Join(stackDir, "vendor"),
+		"--ext-str", "env=dev",
+		"--ext-code", "replicas=2",
+		"--ext-str", "image=nginx",
+		DefaultFile,
+	}, gotArgs)
+	assert.Len(t, sp.Resources, 2)
+	assert.Equal(t, "v1:Service:web:nginx", sp.Resources[1].ID)
+}
@@ -134,7 +134,7 @@ func BuildOptions(workDir string, settings, arguments, overrides []string, disab
 	optList := []kclvm.Option{}
 	// build settings option
 	for _, setting := range settings {
-		if workDir != "" {
+		if workDir != "" && !filepath.IsAbs(setting) {
 			setting = filepath.Join(workDir, setting)
 		}
 		opt := kclvm.WithSettings(setting)
@@ -0,0 +1,100 @@
+package kustomize
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/kustomize/api/konfig"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/generator/manifest"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/util/io"
+)
+
+// PathConfig is the key of generator configs in project.yaml, which specifies
+// the kustomization directory relative to the stack directory
+const PathConfig = "path"
+
+// Generator generates Spec by building a kustomization directory in-process
+type Generator struct {
+	// Path is the kustomization directory. A relative path is relative to the stack directory
+	Path string
+}
+
+var _ generator.Generator = (*Generator)(nil)
+
+// NewGenerator returns a kustomize Generator with the generator configs in project.yaml.
+// The stack directory is used as the kustomization directory if no path is configured
+func NewGenerator(configs map[string]interface{}) (*Generator, error) {
+	g := &Generator{Path: "."}
+	if v, ok := configs[PathConfig]; ok {
+		path, ok := v.(string)
+		if !ok || path == "" {
+			return nil, fmt.Errorf("generator config %s must be a non-empty string", PathConfig)
+		}
+		g.Path = path
+	}
+	return g, nil
+}
+
+func (g *Generator) GenerateSpec(o *generator.Options, stack *projectstack.Stack) (*models.Spec, error) {
+	path := g.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(stack.Path, path)
+	}
+	return build(path)
+}
+
+// MergedGenerator generates Spec by the wrapped Generator, and merges resources
+// built from the kustomization in the stack directory into it. It enables stacks
+// to migrate from kustomize gradually.
+type MergedGenerator struct {
+	generator.Generator
+}
+
+var _ generator.Generator = (*MergedGenerator)(nil)
+
+func (g *MergedGenerator) GenerateSpec(o *generator.Options, stack *projectstack.Stack) (*models.Spec, error) {
+	sp, err := g.Generator.GenerateSpec(o, stack)
+	if err != nil {
+		return nil, err
+	}
+	kustomized, err := build(stack.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	index := sp.Resources.Index()
+	for _, r := range kustomized.Resources {
+		if _, ok := index[r.ID]; ok {
+			return nil, fmt.Errorf("resource %s is defined in both the kustomization and the stack", r.ID)
+		}
+		sp.Resources = append(sp.Resources, r)
+	}
+	return sp, nil
+}
+
+// HasKustomization returns true if the directory contains a kustomization file
+func HasKustomization(dir string) bool {
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+func build(dir string) (*models.Spec, error) {
+	out, err := io.ReadKustomizeInput(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build kustomization %s: %w", dir, err)
+	}
+	resources, err := manifest.Parse([]byte(out))
+	if err != nil {
+		return nil, err
+	}
+	return &models.Spec{Resources: resources}, nil
+}
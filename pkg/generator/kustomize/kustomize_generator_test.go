@@ -0,0 +1,76 @@
+package kustomize
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+const (
+	kustomization = `
+namePrefix: dev-
+namespace: foo
+resources:
+- cm.yaml
+`
+	configMap = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+data:
+  a: b
+`
+)
+
+type fakeGenerator struct {
+	resources models.Resources
+}
+
+func (g *fakeGenerator) GenerateSpec(o *generator.Options, stack *projectstack.Stack) (*models.Spec, error) {
+	return &models.Spec{Resources: g.resources}, nil
+}
+
+func newStack(t *testing.T) *projectstack.Stack {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "kustomization.yaml"), []byte(kustomization), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "cm.yaml"), []byte(configMap), 0o644))
+	return &projectstack.Stack{Path: dir}
+}
+
+func TestGenerator_GenerateSpec(t *testing.T) {
+	stack := newStack(t)
+	assert.True(t, HasKustomization(stack.Path))
+	assert.False(t, HasKustomization(t.TempDir()))
+
+	g, err := NewGenerator(nil)
+	assert.Nil(t, err)
+	sp, err := g.GenerateSpec(&generator.Options{}, stack)
+	assert.Nil(t, err)
+	assert.Len(t, sp.Resources, 1)
+	assert.Equal(t, "v1:ConfigMap:foo:dev-cm", sp.Resources[0].ID)
+}
+
+func TestMergedGenerator_GenerateSpec(t *testing.T) {
+	stack := newStack(t)
+
+	t.Run("merged", func(t *testing.T) {
+		g := &MergedGenerator{Generator: &fakeGenerator{resources: models.Resources{{ID: "v1:Namespace:foo"}}}}
+		sp, err := g.GenerateSpec(&generator.Options{}, stack)
+		assert.Nil(t, err)
+		assert.Len(t, sp.Resources, 2)
+		assert.Equal(t, "v1:ConfigMap:foo:dev-cm", sp.Resources[1].ID)
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		g := &MergedGenerator{Generator: &fakeGenerator{resources: models.Resources{{ID: "v1:ConfigMap:foo:dev-cm"}}}}
+		_, err := g.GenerateSpec(&generator.Options{}, stack)
+		assert.ErrorContains(t, err, "defined in both")
+	})
+}
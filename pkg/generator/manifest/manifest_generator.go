@@ -0,0 +1,112 @@
+package manifest
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+const (
+	// PathConfig is the key of generator configs in project.yaml, which specifies
+	// the manifest file or directory relative to the stack directory
+	PathConfig = "path"
+
+	// DefaultPath is the manifest directory used if no path is configured
+	DefaultPath = "manifests"
+)
+
+// Generator generates Spec from plain Kubernetes YAML or JSON manifests
+type Generator struct {
+	// Path is the manifest file or directory. A relative path is relative to the stack directory
+	Path string
+}
+
+var _ generator.Generator = (*Generator)(nil)
+
+// NewGenerator returns a manifest Generator with the generator configs in project.yaml
+func NewGenerator(configs map[string]interface{}) (*Generator, error) {
+	g := &Generator{Path: DefaultPath}
+	if v, ok := configs[PathConfig]; ok {
+		path, ok := v.(string)
+		if !ok || path == "" {
+			return nil, fmt.Errorf("generator config %s must be a non-empty string", PathConfig)
+		}
+		g.Path = path
+	}
+	return g, nil
+}
+
+func (g *Generator) GenerateSpec(o *generator.Options, stack *projectstack.Stack) (*models.Spec, error) {
+	path := g.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(stack.Path, path)
+	}
+
+	files, err := manifestFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := models.Resources{}
+	ids := map[string]string{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		rs, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse manifest file %s: %w", file, err)
+		}
+		for _, r := range rs {
+			if prev, ok := ids[r.ID]; ok {
+				return nil, fmt.Errorf("duplicate resource %s in %s and %s", r.ID, prev, file)
+			}
+			ids[r.ID] = file
+		}
+		resources = append(resources, rs...)
+	}
+	return &models.Spec{Resources: resources}, nil
+}
+
+// manifestFiles returns the path itself if it is a file, or all YAML and JSON files
+// in the directory recursively in lexical order. Hidden files and directories are skipped.
+func manifestFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != path && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch filepath.Ext(p) {
+		case ".yaml", ".yml", ".json":
+			files = append(files, p)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
@@ -0,0 +1,61 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+const (
+	namespaceYAML = "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: foo\n"
+	configMapJSON = `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm", "namespace": "foo"}}`
+)
+
+func TestNewGenerator(t *testing.T) {
+	g, err := NewGenerator(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultPath, g.Path)
+
+	g, err = NewGenerator(map[string]interface{}{PathConfig: "deploy/all.yaml"})
+	assert.Nil(t, err)
+	assert.Equal(t, "deploy/all.yaml", g.Path)
+
+	_, err = NewGenerator(map[string]interface{}{PathConfig: 1})
+	assert.NotNil(t, err)
+}
+
+func TestGenerator_GenerateSpec(t *testing.T) {
+	stackDir := t.TempDir()
+	dir := filepath.Join(stackDir, DefaultPath)
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "sub"), os.ModePerm))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "a.yaml"), []byte(namespaceYAML), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "sub", "b.json"), []byte(configMapJSON), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# manifests"), 0o644))
+	stack := &projectstack.Stack{Path: stackDir}
+
+	t.Run("directory", func(t *testing.T) {
+		sp, err := (&Generator{Path: DefaultPath}).GenerateSpec(&generator.Options{}, stack)
+		assert.Nil(t, err)
+		assert.Len(t, sp.Resources, 2)
+		assert.Equal(t, "v1:Namespace:foo", sp.Resources[0].ID)
+		assert.Equal(t, "v1:ConfigMap:foo:cm", sp.Resources[1].ID)
+	})
+
+	t.Run("single file", func(t *testing.T) {
+		sp, err := (&Generator{Path: filepath.Join(dir, "a.yaml")}).GenerateSpec(&generator.Options{}, stack)
+		assert.Nil(t, err)
+		assert.Len(t, sp.Resources, 1)
+	})
+
+	t.Run("duplicate resources", func(t *testing.T) {
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, "c.yaml"), []byte(namespaceYAML), 0o644))
+		defer os.Remove(filepath.Join(dir, "c.yaml"))
+		_, err := (&Generator{Path: DefaultPath}).GenerateSpec(&generator.Options{}, stack)
+		assert.ErrorContains(t, err, "duplicate resource v1:Namespace:foo")
+	})
+}
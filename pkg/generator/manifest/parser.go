@@ -0,0 +1,158 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"kusionstack.io/kusion/pkg/engine"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+// clusterScopedKinds are common kinds which should not be set a namespace, they are checked when scopes can
+// not be discovered from the cluster, see Scope
+var clusterScopedKinds = map[string]bool{
+	"APIService":                     true,
+	"ClusterRole":                    true,
+	"ClusterRoleBinding":             true,
+	"CSIDriver":                      true,
+	"CustomResourceDefinition":       true,
+	"IngressClass":                   true,
+	"MutatingWebhookConfiguration":   true,
+	"Namespace":                      true,
+	"PersistentVolume":               true,
+	"PriorityClass":                  true,
+	"RuntimeClass":                   true,
+	"StorageClass":                   true,
+	"ValidatingWebhookConfiguration": true,
+}
+
+// IsClusterScoped returns true if the kind is a common cluster-scoped kind, which should not be set a namespace
+func IsClusterScoped(kind string) bool {
+	return clusterScopedKinds[kind]
+}
+
+// Parse converts multi-document YAML or JSON of Kubernetes manifests into resources.
+// Empty documents are skipped and items of List kinds are expanded.
+func Parse(data []byte) ([]models.Resource, error) {
+	var resources []models.Resource
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc interface{}
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if doc == nil {
+			continue
+		}
+
+		obj, err := normalize(doc)
+		if err != nil {
+			return nil, err
+		}
+		rs, err := toResources(obj)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, rs...)
+	}
+	return resources, nil
+}
+
+// FromValue extracts resources from a decoded JSON value, which is usually the output of
+// configuration languages. The value can be a manifest, a list of values, or an object
+// whose fields are values. Fields of objects are visited in lexical order.
+func FromValue(v interface{}) ([]models.Resource, error) {
+	switch value := v.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		var resources []models.Resource
+		for _, item := range value {
+			rs, err := FromValue(item)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, rs...)
+		}
+		return resources, nil
+	case map[string]interface{}:
+		if _, ok := value["kind"]; ok {
+			return toResources(value)
+		}
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var resources []models.Resource
+		for _, k := range keys {
+			rs, err := FromValue(value[k])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			resources = append(resources, rs...)
+		}
+		return resources, nil
+	default:
+		return nil, fmt.Errorf("unexpected value %v, manifests must be objects", v)
+	}
+}
+
+// normalize converts the document into the same types as the KCL generator produces, e.g. numbers are float64
+func normalize(doc interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]interface{}
+	if err = json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("manifest must be an object: %w", err)
+	}
+	return obj, nil
+}
+
+func toResources(obj map[string]interface{}) ([]models.Resource, error) {
+	u := &unstructured.Unstructured{Object: obj}
+	if u.IsList() {
+		items, _, err := unstructured.NestedSlice(obj, "items")
+		if err != nil {
+			return nil, err
+		}
+		var resources []models.Resource
+		for _, item := range items {
+			itemObj, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("item of %s must be an object", u.GetKind())
+			}
+			rs, err := toResources(itemObj)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, rs...)
+		}
+		return resources, nil
+	}
+
+	if u.GetAPIVersion() == "" || u.GetKind() == "" || u.GetName() == "" {
+		return nil, fmt.Errorf("apiVersion, kind and metadata.name are required in manifest: %v", obj)
+	}
+	return []models.Resource{{
+		ID:         engine.BuildIDForKubernetes(u.GetAPIVersion(), u.GetKind(), u.GetNamespace(), u.GetName()),
+		Type:       runtime.Kubernetes,
+		Attributes: obj,
+	}}, nil
+}
@@ -0,0 +1,122 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []models.Resource
+		wantErr bool
+	}{
+		{
+			name: "multi documents",
+			data: `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: foo
+---
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: foo
+spec:
+  replicas: 2
+`,
+			want: []models.Resource{
+				{
+					ID:   "v1:Namespace:foo",
+					Type: runtime.Kubernetes,
+					Attributes: map[string]interface{}{
+						"apiVersion": "v1",
+						"kind":       "Namespace",
+						"metadata":   map[string]interface{}{"name": "foo"},
+					},
+				},
+				{
+					ID:   "apps/v1:Deployment:foo:nginx",
+					Type: runtime.Kubernetes,
+					Attributes: map[string]interface{}{
+						"apiVersion": "apps/v1",
+						"kind":       "Deployment",
+						"metadata":   map[string]interface{}{"name": "nginx", "namespace": "foo"},
+						"spec":       map[string]interface{}{"replicas": float64(2)},
+					},
+				},
+			},
+		},
+		{
+			name: "json list",
+			data: `{"apiVersion": "v1", "kind": "List", "items": [{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm", "namespace": "foo"}}]}`,
+			want: []models.Resource{
+				{
+					ID:   "v1:ConfigMap:foo:cm",
+					Type: runtime.Kubernetes,
+					Attributes: map[string]interface{}{
+						"apiVersion": "v1",
+						"kind":       "ConfigMap",
+						"metadata":   map[string]interface{}{"name": "cm", "namespace": "foo"},
+					},
+				},
+			},
+		},
+		{
+			name:    "missing name",
+			data:    "apiVersion: v1\nkind: ConfigMap\n",
+			wantErr: true,
+		},
+		{
+			name:    "not an object",
+			data:    "- a\n- b\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.data))
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFromValue(t *testing.T) {
+	cm := func(name string) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": name},
+		}
+	}
+
+	got, err := FromValue(map[string]interface{}{
+		"configMaps": map[string]interface{}{
+			"b": cm("b"),
+			"a": cm("a"),
+		},
+		"list": []interface{}{cm("c")},
+	})
+	assert.Nil(t, err)
+	var ids []string
+	for _, r := range got {
+		ids = append(ids, r.ID)
+	}
+	assert.Equal(t, []string{"v1:ConfigMap:a", "v1:ConfigMap:b", "v1:ConfigMap:c"}, ids)
+
+	_, err = FromValue(map[string]interface{}{"replicas": float64(1)})
+	assert.NotNil(t, err)
+}
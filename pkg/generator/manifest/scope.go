@@ -0,0 +1,76 @@
+package manifest
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/util/kube/config"
+)
+
+// discoveryTimeout bounds requests of the discovery, so that compiles never hang on unreachable clusters
+const discoveryTimeout = 5 * time.Second
+
+// Scope tells whether kinds are cluster-scoped by the RESTMapper of the cluster. Kinds are checked against
+// common cluster-scoped kinds if there is no RESTMapper, the cluster is unreachable, or the kind is unknown to
+// the cluster, e.g. custom resources of CRDs not installed yet.
+type Scope struct {
+	mu     sync.Mutex
+	mapper meta.RESTMapper
+}
+
+// NewScope returns a Scope with the RESTMapper, which can be nil to only check common cluster-scoped kinds
+func NewScope(mapper meta.RESTMapper) *Scope {
+	return &Scope{mapper: mapper}
+}
+
+// NewClusterScope returns a Scope with the RESTMapper of the cluster in the kubeconfig found by getenv. The
+// discovery is deferred to the first kind checked, so that compiles never call clusters they do not need.
+func NewClusterScope(getenv func(string) string) *Scope {
+	cfg, err := config.BuildConfigWithEnv(getenv)
+	if err != nil {
+		log.Debugf("no cluster to discover scopes of kinds: %v", err)
+		return NewScope(nil)
+	}
+	cfg.Timeout = discoveryTimeout
+	mapper, err := apiutil.NewDynamicRESTMapper(cfg, apiutil.WithLazyDiscovery)
+	if err != nil {
+		log.Debugf("no cluster to discover scopes of kinds: %v", err)
+		return NewScope(nil)
+	}
+	return NewScope(mapper)
+}
+
+// IsClusterScoped returns true if the kind of the apiVersion is cluster-scoped, which should not be set a
+// namespace
+func (s *Scope) IsClusterScoped(apiVersion, kind string) bool {
+	if s == nil {
+		return IsClusterScoped(kind)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mapper == nil {
+		return IsClusterScoped(kind)
+	}
+
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return IsClusterScoped(kind)
+	}
+	mapping, err := s.mapper.RESTMapping(gv.WithKind(kind).GroupKind(), gv.Version)
+	switch {
+	case err == nil:
+		return mapping.Scope.Name() == meta.RESTScopeNameRoot
+	case meta.IsNoMatchError(err):
+		log.Debugf("kind %s of %s is unknown to the cluster: %v", kind, apiVersion, err)
+	default:
+		// the cluster is unreachable, stop calling it
+		log.Warnf("failed to discover the scope of %s, fall back to common cluster-scoped kinds: %v", kind, err)
+		s.mapper = nil
+	}
+	return IsClusterScoped(kind)
+}
@@ -0,0 +1,49 @@
+package manifest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type unreachableMapper struct {
+	meta.RESTMapper
+	calls int
+}
+
+func (m *unreachableMapper) RESTMapping(gk schema.GroupKind, versions ...string) (*meta.RESTMapping, error) {
+	m.calls++
+	return nil, errors.New("connection refused")
+}
+
+func TestScope_IsClusterScoped(t *testing.T) {
+	t.Run("discovered", func(t *testing.T) {
+		mapper := meta.NewDefaultRESTMapper(nil)
+		mapper.Add(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}, meta.RESTScopeRoot)
+		mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeNamespace)
+		s := NewScope(mapper)
+
+		assert.True(t, s.IsClusterScoped("example.com/v1", "Widget"))
+		assert.False(t, s.IsClusterScoped("v1", "Namespace"))
+		// unknown kinds fall back to common cluster-scoped kinds
+		assert.True(t, s.IsClusterScoped("rbac.authorization.k8s.io/v1", "ClusterRole"))
+		assert.False(t, s.IsClusterScoped("v1", "Service"))
+	})
+
+	t.Run("offline", func(t *testing.T) {
+		var s *Scope
+		assert.True(t, s.IsClusterScoped("v1", "Namespace"))
+		assert.False(t, NewScope(nil).IsClusterScoped("example.com/v1", "Widget"))
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		mapper := &unreachableMapper{}
+		s := NewScope(mapper)
+		assert.True(t, s.IsClusterScoped("v1", "Namespace"))
+		assert.False(t, s.IsClusterScoped("v1", "Service"))
+		assert.Equal(t, 1, mapper.calls)
+	})
+}
@@ -0,0 +1,229 @@
+package mutator
+
+import (
+	"errors"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"kusionstack.io/kusion/pkg/engine"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/generator/manifest"
+)
+
+// podSpecPaths are paths of the pod spec in workload kinds
+var podSpecPaths = map[string][]string{
+	"Pod":         {"spec"},
+	"Deployment":  {"spec", "template", "spec"},
+	"StatefulSet": {"spec", "template", "spec"},
+	"DaemonSet":   {"spec", "template", "spec"},
+	"ReplicaSet":  {"spec", "template", "spec"},
+	"Job":         {"spec", "template", "spec"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// kubernetesObjects calls fn on every Kubernetes resource in the spec
+func kubernetesObjects(spec *models.Spec, fn func(r *models.Resource, u *unstructured.Unstructured) error) error {
+	for i := range spec.Resources {
+		r := &spec.Resources[i]
+		if r.Type != runtime.Kubernetes || r.Attributes == nil {
+			continue
+		}
+		if err := fn(r, &unstructured.Unstructured{Object: r.Attributes}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MetadataConfig is the configs of the Metadata mutator
+type MetadataConfig struct {
+	// Labels and Annotations are injected into all Kubernetes resources
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Overwrite replaces existing values of the same keys if true
+	Overwrite bool `json:"overwrite,omitempty"`
+}
+
+type metadata struct {
+	MetadataConfig
+}
+
+func newMetadata(configs map[string]interface{}, _ string) (Mutator, error) {
+	m := &metadata{}
+	if err := decode(configs, &m.MetadataConfig); err != nil {
+		return nil, err
+	}
+	if len(m.Labels) == 0 && len(m.Annotations) == 0 {
+		return nil, errors.New("labels or annotations is required")
+	}
+	return m, nil
+}
+
+func (m *metadata) Mutate(spec *models.Spec) error {
+	return kubernetesObjects(spec, func(_ *models.Resource, u *unstructured.Unstructured) error {
+		u.SetLabels(m.merge(u.GetLabels(), m.Labels))
+		u.SetAnnotations(m.merge(u.GetAnnotations(), m.Annotations))
+		return nil
+	})
+}
+
+func (m *metadata) merge(current, injected map[string]string) map[string]string {
+	if len(injected) == 0 {
+		return current
+	}
+	if current == nil {
+		current = map[string]string{}
+	}
+	for k, v := range injected {
+		if _, ok := current[k]; !ok || m.Overwrite {
+			current[k] = v
+		}
+	}
+	return current
+}
+
+// NamespaceConfig is the configs of the Namespace mutator
+type NamespaceConfig struct {
+	// Namespace is set on all namespaced Kubernetes resources without a namespace
+	Namespace string `json:"namespace"`
+
+	// Overwrite replaces existing namespaces if true
+	Overwrite bool `json:"overwrite,omitempty"`
+}
+
+type namespace struct {
+	NamespaceConfig
+}
+
+func newNamespace(configs map[string]interface{}, _ string) (Mutator, error) {
+	m := &namespace{}
+	if err := decode(configs, &m.NamespaceConfig); err != nil {
+		return nil, err
+	}
+	if m.Namespace == "" {
+		return nil, errors.New("namespace is required")
+	}
+	return m, nil
+}
+
+func (m *namespace) Mutate(spec *models.Spec) error {
+	renames := map[string]string{}
+	err := kubernetesObjects(spec, func(r *models.Resource, u *unstructured.Unstructured) error {
+		if manifest.IsClusterScoped(u.GetKind()) || (u.GetNamespace() != "" && !m.Overwrite) {
+			return nil
+		}
+		u.SetNamespace(m.Namespace)
+		id := engine.BuildIDForKubernetes(u.GetAPIVersion(), u.GetKind(), m.Namespace, u.GetName())
+		if id != r.ID {
+			renames[r.ID] = id
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	renameIDs(spec, renames)
+	return nil
+}
+
+// ImagePullSecretsConfig is the configs of the ImagePullSecrets mutator
+type ImagePullSecretsConfig struct {
+	// Secrets are names of secrets added to imagePullSecrets of all pod templates
+	Secrets []string `json:"secrets"`
+}
+
+type imagePullSecrets struct {
+	ImagePullSecretsConfig
+}
+
+func newImagePullSecrets(configs map[string]interface{}, _ string) (Mutator, error) {
+	m := &imagePullSecrets{}
+	if err := decode(configs, &m.ImagePullSecretsConfig); err != nil {
+		return nil, err
+	}
+	if len(m.Secrets) == 0 {
+		return nil, errors.New("secrets is required")
+	}
+	return m, nil
+}
+
+func (m *imagePullSecrets) Mutate(spec *models.Spec) error {
+	return kubernetesObjects(spec, func(_ *models.Resource, u *unstructured.Unstructured) error {
+		path, ok := podSpecPaths[u.GetKind()]
+		if !ok {
+			return nil
+		}
+		podSpec, found, err := unstructured.NestedMap(u.Object, path...)
+		if err != nil || !found {
+			return err
+		}
+
+		secrets, _, err := unstructured.NestedSlice(podSpec, "imagePullSecrets")
+		if err != nil {
+			return err
+		}
+		existing := map[string]bool{}
+		for _, s := range secrets {
+			if ref, ok := s.(map[string]interface{}); ok {
+				if name, ok := ref["name"].(string); ok {
+					existing[name] = true
+				}
+			}
+		}
+		for _, name := range m.Secrets {
+			if !existing[name] {
+				secrets = append(secrets, map[string]interface{}{"name": name})
+			}
+		}
+		podSpec["imagePullSecrets"] = secrets
+		return unstructured.SetNestedMap(u.Object, podSpec, path...)
+	})
+}
+
+// StripFieldsConfig is the configs of the StripFields mutator
+type StripFieldsConfig struct {
+	// Fields are dot-separated paths of fields removed from attributes of Kubernetes resources, e.g. status
+	Fields []string `json:"fields"`
+
+	// Kinds limits the mutator to resources of these kinds, all kinds if empty
+	Kinds []string `json:"kinds,omitempty"`
+}
+
+type stripFields struct {
+	StripFieldsConfig
+}
+
+func newStripFields(configs map[string]interface{}, _ string) (Mutator, error) {
+	m := &stripFields{}
+	if err := decode(configs, &m.StripFieldsConfig); err != nil {
+		return nil, err
+	}
+	if len(m.Fields) == 0 {
+		return nil, errors.New("fields is required")
+	}
+	return m, nil
+}
+
+func (m *stripFields) Mutate(spec *models.Spec) error {
+	return kubernetesObjects(spec, func(_ *models.Resource, u *unstructured.Unstructured) error {
+		if len(m.Kinds) > 0 && !contains(m.Kinds, u.GetKind()) {
+			return nil
+		}
+		for _, field := range m.Fields {
+			unstructured.RemoveNestedField(u.Object, strings.Split(field, ".")...)
+		}
+		return nil
+	})
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
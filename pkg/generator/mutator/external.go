@@ -0,0 +1,171 @@
+package mutator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+)
+
+// defaultTimeout is the default timeout of external mutators
+const defaultTimeout = 30 * time.Second
+
+// CommandPrefix prefixes names of mutator commands looked up in PATH, e.g. the command labels is
+// kusion-mutator-labels
+const CommandPrefix = "kusion-mutator-"
+
+// commandName matches names of mutator commands, which are never paths
+var commandName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ExecConfig is the configs of the Exec mutator
+type ExecConfig struct {
+	// Command is the name of the command kusion-mutator-<name> in PATH and its arguments. It is run in the project
+	// directory, reads the Spec in JSON from stdin and writes the mutated one to stdout. Mutators are configured in
+	// project.yaml, which is checked in repositories, so paths are rejected to never run executables of
+	// repositories, e.g. when the server compiles repositories checked out.
+	Command []string `json:"command"`
+
+	// Timeout is the timeout of the command, e.g. 30s
+	Timeout string `json:"timeout,omitempty"`
+}
+
+type execMutator struct {
+	ExecConfig
+	dir     string
+	timeout time.Duration
+}
+
+func newExec(configs map[string]interface{}, dir string) (Mutator, error) {
+	m := &execMutator{dir: dir}
+	if err := decode(configs, &m.ExecConfig); err != nil {
+		return nil, err
+	}
+	if len(m.Command) == 0 {
+		return nil, errors.New("command is required")
+	}
+	if !commandName.MatchString(m.Command[0]) {
+		return nil, fmt.Errorf("invalid command %s, must be a name of %s<name> in PATH", m.Command[0], CommandPrefix)
+	}
+	var err error
+	if m.timeout, err = parseTimeout(m.Timeout); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *execMutator) Mutate(spec *models.Spec) error {
+	input, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+
+	path, err := exec.LookPath(CommandPrefix + m.Command[0])
+	if err != nil {
+		return fmt.Errorf("mutator command %s not found in PATH: %w", CommandPrefix+m.Command[0], err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, m.Command[1:]...)
+	cmd.Dir = m.dir
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		return fmt.Errorf("mutator command %q failed: %w, stderr: %s", strings.Join(m.Command, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return replace(spec, stdout.Bytes(), "mutator command "+m.Command[0])
+}
+
+// WebhookConfig is the configs of the Webhook mutator
+type WebhookConfig struct {
+	// URL receives a POST request with the Spec in JSON, and responds the mutated one
+	URL string `json:"url"`
+
+	// Headers are added to the request, e.g. Authorization
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Timeout is the timeout of the request, e.g. 30s
+	Timeout string `json:"timeout,omitempty"`
+}
+
+type webhook struct {
+	WebhookConfig
+	client *http.Client
+}
+
+func newWebhook(configs map[string]interface{}, _ string) (Mutator, error) {
+	m := &webhook{}
+	if err := decode(configs, &m.WebhookConfig); err != nil {
+		return nil, err
+	}
+	if m.URL == "" {
+		return nil, errors.New("url is required")
+	}
+	timeout, err := parseTimeout(m.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	m.client = &http.Client{Timeout: timeout}
+	return m, nil
+}
+
+func (m *webhook) Mutate(spec *models.Spec) error {
+	input, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, m.URL, bytes.NewReader(input))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range m.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("mutator webhook %s failed: %w", m.URL, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("mutator webhook %s failed: %w", m.URL, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("mutator webhook %s failed with status %d: %s", m.URL, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return replace(spec, body, "mutator webhook "+m.URL)
+}
+
+// replace replaces the spec with the mutated one in JSON
+func replace(spec *models.Spec, data []byte, source string) error {
+	mutated := &models.Spec{}
+	if err := json.Unmarshal(data, mutated); err != nil {
+		return fmt.Errorf("invalid spec returned by %s: %w", source, err)
+	}
+	*spec = *mutated
+	return nil
+}
+
+func parseTimeout(timeout string) (time.Duration, error) {
+	if timeout == "" {
+		return defaultTimeout, nil
+	}
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: %w", timeout, err)
+	}
+	return d, nil
+}
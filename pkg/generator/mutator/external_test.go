@@ -0,0 +1,88 @@
+package mutator
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+)
+
+// fakeCommand installs the script as the mutator command kusion-mutator-<name> in PATH
+func fakeCommand(t *testing.T, name, script string) {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, CommandPrefix+name), []byte("#!/bin/sh\n"+script+"\n"), 0o755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestExec_Mutate(t *testing.T) {
+	t.Run("mutated", func(t *testing.T) {
+		fakeCommand(t, "replace", `cat > /dev/null; echo '{"resources":[{"id":"a","type":"Kubernetes","attributes":{}}]}'`)
+		m, err := newExec(map[string]interface{}{"command": []interface{}{"replace"}}, t.TempDir())
+		assert.Nil(t, err)
+
+		spec := newSpec()
+		assert.Nil(t, m.Mutate(spec))
+		assert.Equal(t, models.Resources{{ID: "a", Type: "Kubernetes", Attributes: map[string]interface{}{}}}, spec.Resources)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		fakeCommand(t, "deny", "echo denied >&2; exit 1")
+		m, err := newExec(map[string]interface{}{"command": []interface{}{"deny"}}, t.TempDir())
+		assert.Nil(t, err)
+		assert.ErrorContains(t, m.Mutate(newSpec()), "stderr: denied")
+	})
+
+	t.Run("invalid output", func(t *testing.T) {
+		fakeCommand(t, "echo", `echo "$1"`)
+		m, err := newExec(map[string]interface{}{"command": []interface{}{"echo", "oops"}}, t.TempDir())
+		assert.Nil(t, err)
+		assert.ErrorContains(t, m.Mutate(newSpec()), "invalid spec returned by mutator command echo")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		m, err := newExec(map[string]interface{}{"command": []interface{}{"missing"}}, t.TempDir())
+		assert.Nil(t, err)
+		assert.ErrorContains(t, m.Mutate(newSpec()), "kusion-mutator-missing not found in PATH")
+	})
+
+	t.Run("paths", func(t *testing.T) {
+		for _, command := range []string{"./mutate.sh", "/bin/sh", "../bin/mutate"} {
+			_, err := newExec(map[string]interface{}{"command": []interface{}{command}}, t.TempDir())
+			assert.ErrorContains(t, err, "invalid command")
+		}
+	})
+}
+
+func TestWebhook_Mutate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte("unauthorized"))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		spec := &models.Spec{}
+		_ = json.Unmarshal(body, spec)
+		spec.Resources = spec.Resources[:1]
+		_ = json.NewEncoder(w).Encode(spec)
+	}))
+	defer server.Close()
+
+	m, err := newWebhook(map[string]interface{}{"url": server.URL, "headers": map[string]interface{}{"Authorization": "Bearer token"}}, "")
+	assert.Nil(t, err)
+	spec := newSpec()
+	assert.Nil(t, m.Mutate(spec))
+	assert.Len(t, spec.Resources, 1)
+	assert.Equal(t, "apps/v1:Deployment:nginx", spec.Resources[0].ID)
+
+	m, err = newWebhook(map[string]interface{}{"url": server.URL}, "")
+	assert.Nil(t, err)
+	assert.ErrorContains(t, m.Mutate(newSpec()), "failed with status 401: unauthorized")
+}
@@ -0,0 +1,132 @@
+// Package mutator provides a chain of Spec mutators, which run after the Spec is generated and before any operation.
+// Mutators are configured in project.yaml, and are used to enforce platform conventions on all resources, e.g.
+// injecting standard labels, setting namespaces, adding imagePullSecrets and stripping disallowed fields. Besides
+// built-in mutators, a Spec can also be mutated by an external command or a webhook.
+package mutator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/operation/graph"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// Mutator modifies the Spec in place
+type Mutator interface {
+	Mutate(spec *models.Spec) error
+}
+
+// Types of mutators
+const (
+	MetadataMutator         = "Metadata"
+	NamespaceMutator        = "Namespace"
+	ImagePullSecretsMutator = "ImagePullSecrets"
+	StripFieldsMutator      = "StripFields"
+	ExecMutator             = "Exec"
+	WebhookMutator          = "Webhook"
+)
+
+// newMutatorFunc creates a Mutator with its configs, dir is the project directory
+type newMutatorFunc func(configs map[string]interface{}, dir string) (Mutator, error)
+
+var mutators = map[string]newMutatorFunc{
+	MetadataMutator:         newMetadata,
+	NamespaceMutator:        newNamespace,
+	ImagePullSecretsMutator: newImagePullSecrets,
+	StripFieldsMutator:      newStripFields,
+	ExecMutator:             newExec,
+	WebhookMutator:          newWebhook,
+}
+
+// NewMutators creates all mutators configured in the project, in the order they are configured
+func NewMutators(project *projectstack.Project) ([]Mutator, error) {
+	var result []Mutator
+	for i, c := range project.Mutators {
+		fn, ok := mutators[c.Type]
+		if !ok {
+			types := make([]string, 0, len(mutators))
+			for t := range mutators {
+				types = append(types, t)
+			}
+			sort.Strings(types)
+			return nil, fmt.Errorf("unknown mutator type %q in mutators[%d], supported types are %s", c.Type, i, strings.Join(types, ", "))
+		}
+		m, err := fn(c.Configs, project.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s mutator in mutators[%d]: %w", c.Type, i, err)
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// Chain is a Mutator which runs mutators in order
+type Chain []Mutator
+
+func (c Chain) Mutate(spec *models.Spec) error {
+	for _, m := range c {
+		if err := m.Mutate(spec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decode decodes configs into the config struct with a JSON round-trip
+func decode(configs map[string]interface{}, config interface{}) error {
+	data, err := json.Marshal(configs)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, config)
+}
+
+// renameIDs replaces old IDs with new ones in IDs, dependencies and implicit refs of all resources
+func renameIDs(spec *models.Spec, renames map[string]string) {
+	if len(renames) == 0 {
+		return
+	}
+	for i := range spec.Resources {
+		r := &spec.Resources[i]
+		if id, ok := renames[r.ID]; ok {
+			r.ID = id
+		}
+		for j, dep := range r.DependsOn {
+			if id, ok := renames[dep]; ok {
+				r.DependsOn[j] = id
+			}
+		}
+		r.Attributes, _ = renameRefs(r.Attributes, renames).(map[string]interface{})
+	}
+}
+
+func renameRefs(value interface{}, renames map[string]string) interface{} {
+	switch v := value.(type) {
+	case string:
+		if !strings.HasPrefix(v, graph.ImplicitRefPrefix) {
+			return v
+		}
+		ref := strings.TrimPrefix(v, graph.ImplicitRefPrefix)
+		id, attribute, _ := strings.Cut(ref, ".")
+		if newID, ok := renames[id]; ok {
+			return graph.ImplicitRefPrefix + newID + "." + attribute
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = renameRefs(item, renames)
+		}
+		return v
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = renameRefs(item, renames)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,153 @@
+package mutator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func newSpec() *models.Spec {
+	return &models.Spec{Resources: models.Resources{
+		{
+			ID:   "apps/v1:Deployment:nginx",
+			Type: runtime.Kubernetes,
+			Attributes: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]interface{}{
+					"name":   "nginx",
+					"labels": map[string]interface{}{"app": "nginx", "team": "web"},
+				},
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"imagePullSecrets": []interface{}{map[string]interface{}{"name": "existing"}},
+						},
+					},
+				},
+				"status": map[string]interface{}{"replicas": float64(1)},
+			},
+		},
+		{
+			ID:   "v1:ConfigMap:nginx",
+			Type: runtime.Kubernetes,
+			Attributes: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": "nginx", "namespace": "web"},
+				"data":       map[string]interface{}{"deployment": "$kusion_path.apps/v1:Deployment:nginx.metadata.name"},
+			},
+			DependsOn: []string{"apps/v1:Deployment:nginx"},
+		},
+		{
+			ID:         "rbac.authorization.k8s.io/v1:ClusterRole:reader",
+			Type:       runtime.Kubernetes,
+			Attributes: map[string]interface{}{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "ClusterRole", "metadata": map[string]interface{}{"name": "reader"}},
+		},
+		{
+			ID:         "bucket",
+			Type:       runtime.Terraform,
+			Attributes: map[string]interface{}{"name": "bucket"},
+		},
+	}}
+}
+
+func newProject(mutators ...*projectstack.MutatorConfig) *projectstack.Project {
+	return &projectstack.Project{ProjectConfiguration: projectstack.ProjectConfiguration{Mutators: mutators}}
+}
+
+func TestNewMutators(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *projectstack.MutatorConfig
+		wantErr string
+	}{
+		{
+			name:    "unknown type",
+			config:  &projectstack.MutatorConfig{Type: "Unknown"},
+			wantErr: `unknown mutator type "Unknown" in mutators[0], supported types are Exec, ImagePullSecrets, Metadata, Namespace, StripFields, Webhook`,
+		},
+		{
+			name:    "missing labels",
+			config:  &projectstack.MutatorConfig{Type: MetadataMutator},
+			wantErr: "invalid Metadata mutator in mutators[0]: labels or annotations is required",
+		},
+		{
+			name:    "missing namespace",
+			config:  &projectstack.MutatorConfig{Type: NamespaceMutator},
+			wantErr: "namespace is required",
+		},
+		{
+			name:    "missing secrets",
+			config:  &projectstack.MutatorConfig{Type: ImagePullSecretsMutator},
+			wantErr: "secrets is required",
+		},
+		{
+			name:    "missing fields",
+			config:  &projectstack.MutatorConfig{Type: StripFieldsMutator},
+			wantErr: "fields is required",
+		},
+		{
+			name:    "missing command",
+			config:  &projectstack.MutatorConfig{Type: ExecMutator},
+			wantErr: "command is required",
+		},
+		{
+			name:    "invalid timeout",
+			config:  &projectstack.MutatorConfig{Type: WebhookMutator, Configs: map[string]interface{}{"url": "http://localhost", "timeout": "soon"}},
+			wantErr: `invalid timeout "soon"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMutators(newProject(tt.config))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestChain_Mutate(t *testing.T) {
+	mutators, err := NewMutators(newProject(
+		&projectstack.MutatorConfig{Type: MetadataMutator, Configs: map[string]interface{}{
+			"labels":      map[string]interface{}{"team": "platform", "managed-by": "kusion"},
+			"annotations": map[string]interface{}{"owner": "platform"},
+		}},
+		&projectstack.MutatorConfig{Type: NamespaceMutator, Configs: map[string]interface{}{"namespace": "default"}},
+		&projectstack.MutatorConfig{Type: ImagePullSecretsMutator, Configs: map[string]interface{}{"secrets": []interface{}{"existing", "registry"}}},
+		&projectstack.MutatorConfig{Type: StripFieldsMutator, Configs: map[string]interface{}{"fields": []interface{}{"status"}, "kinds": []interface{}{"Deployment"}}},
+	))
+	assert.Nil(t, err)
+
+	spec := newSpec()
+	assert.Nil(t, Chain(mutators).Mutate(spec))
+
+	deploy := spec.Resources[0]
+	assert.Equal(t, "apps/v1:Deployment:default:nginx", deploy.ID)
+	assert.Equal(t, map[string]interface{}{
+		"name":        "nginx",
+		"namespace":   "default",
+		"labels":      map[string]interface{}{"app": "nginx", "team": "web", "managed-by": "kusion"},
+		"annotations": map[string]interface{}{"owner": "platform"},
+	}, deploy.Attributes["metadata"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "existing"},
+		map[string]interface{}{"name": "registry"},
+	}, deploy.Attributes["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})["imagePullSecrets"])
+	assert.NotContains(t, deploy.Attributes, "status")
+
+	cm := spec.Resources[1]
+	assert.Equal(t, "v1:ConfigMap:nginx", cm.ID)
+	assert.Equal(t, "web", cm.Attributes["metadata"].(map[string]interface{})["namespace"])
+	assert.Equal(t, []string{"apps/v1:Deployment:default:nginx"}, cm.DependsOn)
+	assert.Equal(t, "$kusion_path.apps/v1:Deployment:default:nginx.metadata.name", cm.Attributes["data"].(map[string]interface{})["deployment"])
+
+	role := spec.Resources[2]
+	assert.Equal(t, "rbac.authorization.k8s.io/v1:ClusterRole:reader", role.ID)
+	assert.NotContains(t, role.Attributes["metadata"], "namespace")
+
+	assert.Equal(t, map[string]interface{}{"name": "bucket"}, spec.Resources[3].Attributes)
+}
@@ -0,0 +1,281 @@
+// Package provenance builds SLSA provenance documents of compiled specs, which record the inputs,
+// tool versions and digests of a compile so that deployments are auditable end-to-end.
+package provenance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"kusionstack.io/kusion/pkg/version"
+)
+
+const (
+	// StatementType is the type of in-toto statements
+	StatementType = "https://in-toto.io/Statement/v0.1"
+	// PredicateType is the type of SLSA provenance predicates
+	PredicateType = "https://slsa.dev/provenance/v0.2"
+	// BuilderID identifies Kusion as the builder of specs
+	BuilderID = "https://kusionstack.io/kusion"
+	// BuildType is the type of Kusion compiles
+	BuildType = "https://kusionstack.io/compile@v1"
+	// FileSuffix is the suffix of the provenance file saved beside the spec file
+	FileSuffix = ".provenance.json"
+)
+
+// DigestSet maps digest algorithms to digests, such as "sha256" to a hex encoded SHA-256 digest
+type DigestSet map[string]string
+
+// Statement is an in-toto statement whose predicate is a SLSA provenance
+type Statement struct {
+	Type          string     `json:"_type"`
+	Subject       []*Subject `json:"subject"`
+	PredicateType string     `json:"predicateType"`
+	Predicate     *Predicate `json:"predicate"`
+}
+
+// Subject is an artifact produced by the compile
+type Subject struct {
+	Name   string    `json:"name"`
+	Digest DigestSet `json:"digest"`
+}
+
+// Predicate describes how the artifacts are produced
+type Predicate struct {
+	Builder    Builder     `json:"builder"`
+	BuildType  string      `json:"buildType"`
+	Invocation Invocation  `json:"invocation"`
+	Metadata   Metadata    `json:"metadata"`
+	Materials  []*Material `json:"materials,omitempty"`
+}
+
+type Builder struct {
+	ID string `json:"id"`
+}
+
+// Invocation records the source, parameters and environment of the compile
+type Invocation struct {
+	ConfigSource ConfigSource      `json:"configSource"`
+	Parameters   interface{}       `json:"parameters,omitempty"`
+	Environment  map[string]string `json:"environment,omitempty"`
+}
+
+// ConfigSource is the git repository of the project, empty if the project is not in a git repository
+type ConfigSource struct {
+	URI        string    `json:"uri,omitempty"`
+	Digest     DigestSet `json:"digest,omitempty"`
+	EntryPoint string    `json:"entryPoint,omitempty"`
+}
+
+type Metadata struct {
+	BuildStartedOn  *time.Time   `json:"buildStartedOn,omitempty"`
+	BuildFinishedOn *time.Time   `json:"buildFinishedOn,omitempty"`
+	Completeness    Completeness `json:"completeness"`
+	Reproducible    bool         `json:"reproducible"`
+}
+
+type Completeness struct {
+	Parameters  bool `json:"parameters"`
+	Environment bool `json:"environment"`
+	Materials   bool `json:"materials"`
+}
+
+// Material is an input file of the compile
+type Material struct {
+	URI    string    `json:"uri"`
+	Digest DigestSet `json:"digest"`
+}
+
+// Options are the inputs of the compile to be recorded
+type Options struct {
+	// ProjectDir is the root directory of the project, materials are collected from it
+	ProjectDir string
+	// EntryPoint is the path of the stack relative to the project
+	EntryPoint string
+	// Settings are setting files which are also recorded as materials even out of the project
+	Settings []string
+	// Parameters are the parameters of the compile, such as arguments and overrides
+	Parameters interface{}
+	// StartedOn and FinishedOn are the time the compile starts and finishes
+	StartedOn  time.Time
+	FinishedOn time.Time
+}
+
+// New returns the provenance statement of the artifacts compiled with the options
+func New(opts *Options, artifacts ...string) (*Statement, error) {
+	subjects := make([]*Subject, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		digest, err := Digest(artifact)
+		if err != nil {
+			return nil, err
+		}
+		subjects = append(subjects, &Subject{Name: filepath.Base(artifact), Digest: digest})
+	}
+	materials, err := CollectMaterials(opts.ProjectDir, opts.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	startedOn, finishedOn := opts.StartedOn.UTC(), opts.FinishedOn.UTC()
+	return &Statement{
+		Type:          StatementType,
+		Subject:       subjects,
+		PredicateType: PredicateType,
+		Predicate: &Predicate{
+			Builder:   Builder{ID: BuilderID},
+			BuildType: BuildType,
+			Invocation: Invocation{
+				ConfigSource: configSource(opts.ProjectDir, opts.EntryPoint),
+				Parameters:   opts.Parameters,
+				Environment:  environment(),
+			},
+			Metadata: Metadata{
+				BuildStartedOn:  &startedOn,
+				BuildFinishedOn: &finishedOn,
+				Completeness:    Completeness{Parameters: true, Environment: true, Materials: true},
+			},
+			Materials: materials,
+		},
+	}, nil
+}
+
+// Write writes the statement into the file
+func (s *Statement) Write(filename string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0o666)
+}
+
+// Digest returns the SHA-256 digest of the file
+func Digest(filename string) (DigestSet, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return DigestSet{"sha256": hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// isMaterial returns true if the file is an input of compiles, which are KCL files, KCL modules and
+// Kusion project and stack files
+func isMaterial(name string) bool {
+	switch name {
+	case "kcl.mod", "kcl.mod.lock", "project.yaml", "stack.yaml":
+		return true
+	}
+	return filepath.Ext(name) == ".k"
+}
+
+// CollectMaterials returns digests of all materials in the project directory and the setting files.
+// URIs of materials are paths relative to the project directory, hidden directories are skipped.
+func CollectMaterials(projectDir string, settings []string) ([]*Material, error) {
+	files := map[string]string{}
+	err := filepath.WalkDir(projectDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != projectDir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isMaterial(d.Name()) {
+			files[uri(projectDir, path)] = path
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, setting := range settings {
+		files[uri(projectDir, setting)] = setting
+	}
+
+	uris := make([]string, 0, len(files))
+	for u := range files {
+		uris = append(uris, u)
+	}
+	sort.Strings(uris)
+	materials := make([]*Material, 0, len(uris))
+	for _, u := range uris {
+		digest, err := Digest(files[u])
+		if err != nil {
+			return nil, err
+		}
+		materials = append(materials, &Material{URI: u, Digest: digest})
+	}
+	return materials, nil
+}
+
+// uri returns the slash-separated path relative to the project directory if the file is in it,
+// or the absolute path of the file otherwise
+func uri(projectDir, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	if projectAbs, err := filepath.Abs(projectDir); err == nil {
+		if rel, err := filepath.Rel(projectAbs, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(abs)
+}
+
+// environment returns versions of the tools involved in the compile
+func environment() map[string]string {
+	env := map[string]string{}
+	info := version.Get()
+	if info == nil {
+		return env
+	}
+	env["kusion"] = info.ReleaseVersion
+	if info.BuildInfo != nil {
+		env["go"] = info.BuildInfo.GoVersion
+		env["os"] = info.BuildInfo.GOOS
+		env["arch"] = info.BuildInfo.GOARCH
+	}
+	if info.Dependency != nil {
+		env["kclvm-go"] = info.Dependency.KclvmgoVersion
+		env["kcl-plugin"] = info.Dependency.KclPluginVersion
+	}
+	return env
+}
+
+// configSource returns the remote URL and the head commit of the git repository of the project.
+// It is best-effort and returns an empty source if the project is not in a git repository.
+func configSource(projectDir, entryPoint string) ConfigSource {
+	source := ConfigSource{EntryPoint: filepath.ToSlash(entryPoint)}
+	commit, err := git(projectDir, "rev-parse", "HEAD")
+	if err != nil {
+		return source
+	}
+	source.Digest = DigestSet{"sha1": commit}
+	if remote, err := git(projectDir, "config", "--get", "remote.origin.url"); err == nil {
+		source.URI = "git+" + remote
+	}
+	return source
+}
+
+func git(dir string, args ...string) (string, error) {
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
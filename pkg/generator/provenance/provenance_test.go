@@ -0,0 +1,83 @@
+package provenance
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	assert.Nil(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestNew(t *testing.T) {
+	project := t.TempDir()
+	writeFile(t, filepath.Join(project, "project.yaml"), "name: demo\n")
+	writeFile(t, filepath.Join(project, "kcl.mod"), "")
+	writeFile(t, filepath.Join(project, "base", "base.k"), "a = 1\n")
+	writeFile(t, filepath.Join(project, "dev", "main.k"), "b = 2\n")
+	writeFile(t, filepath.Join(project, "dev", "stack.yaml"), "name: dev\n")
+	writeFile(t, filepath.Join(project, "dev", "README.md"), "")
+	writeFile(t, filepath.Join(project, ".git", "config.k"), "")
+	settings := filepath.Join(t.TempDir(), "settings.yaml")
+	writeFile(t, settings, "kcl_options: []\n")
+	output := filepath.Join(project, "dev", "spec.yaml")
+	writeFile(t, output, "- id: a\n")
+
+	started := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	statement, err := New(&Options{
+		ProjectDir: project,
+		EntryPoint: "dev",
+		Settings:   []string{settings},
+		Parameters: map[string]interface{}{"arguments": []string{"a=1"}},
+		StartedOn:  started,
+		FinishedOn: started.Add(time.Second),
+	}, output)
+	assert.Nil(t, err)
+
+	outputDigest, _ := Digest(output)
+	assert.Equal(t, []*Subject{{Name: "spec.yaml", Digest: outputDigest}}, statement.Subject)
+	assert.Equal(t, PredicateType, statement.PredicateType)
+	assert.Equal(t, BuilderID, statement.Predicate.Builder.ID)
+	assert.Equal(t, "dev", statement.Predicate.Invocation.ConfigSource.EntryPoint)
+	assert.Contains(t, statement.Predicate.Invocation.Environment, "kusion")
+
+	var uris []string
+	for _, m := range statement.Predicate.Materials {
+		uris = append(uris, m.URI)
+		assert.Len(t, m.Digest["sha256"], 64)
+	}
+	assert.Equal(t, []string{
+		filepath.ToSlash(settings),
+		"base/base.k",
+		"dev/main.k",
+		"dev/stack.yaml",
+		"kcl.mod",
+		"project.yaml",
+	}, uris)
+
+	filename := output + FileSuffix
+	assert.Nil(t, statement.Write(filename))
+	data, err := os.ReadFile(filename)
+	assert.Nil(t, err)
+	got := &Statement{}
+	assert.Nil(t, json.Unmarshal(data, got))
+	assert.Equal(t, StatementType, got.Type)
+	assert.Equal(t, started, *got.Predicate.Metadata.BuildStartedOn)
+}
+
+func TestDigest(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "file")
+	writeFile(t, filename, "hello")
+	digest, err := Digest(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, DigestSet{"sha256": "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"}, digest)
+
+	_, err = Digest(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,101 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// ResourceGenerator turns models in the Spec into resources, e.g. resources of internal CRDs or database claims.
+// Platform teams can implement this interface and register it by RegisterResourceGenerator in their own build of Kusion,
+// then enable it in the generator configs of project.yaml. All enabled ResourceGenerators run in order right after the
+// Spec is generated, and can add, update or remove resources in the Spec. The Spec is validated after all of them,
+// so models with resource types unknown to Kusion must be replaced by ResourceGenerators.
+type ResourceGenerator interface {
+	GenerateResources(spec *models.Spec) error
+}
+
+// NewResourceGeneratorFunc creates a ResourceGenerator with configs of it in project.yaml
+type NewResourceGeneratorFunc func(project *projectstack.Project, stack *projectstack.Stack, configs map[string]interface{}) (ResourceGenerator, error)
+
+var (
+	resourceGeneratorsMu sync.RWMutex
+	resourceGenerators   = map[string]NewResourceGeneratorFunc{}
+)
+
+// RegisterResourceGenerator makes a ResourceGenerator available by the provided name.
+// It panics if it is called twice with the same name or if fn is nil.
+func RegisterResourceGenerator(name string, fn NewResourceGeneratorFunc) {
+	resourceGeneratorsMu.Lock()
+	defer resourceGeneratorsMu.Unlock()
+	if fn == nil {
+		panic("generator: register resource generator " + name + " is nil")
+	}
+	if _, dup := resourceGenerators[name]; dup {
+		panic("generator: register resource generator " + name + " twice")
+	}
+	resourceGenerators[name] = fn
+}
+
+// ResourceGenerators returns a sorted list of names of the registered ResourceGenerators
+func ResourceGenerators() []string {
+	resourceGeneratorsMu.RLock()
+	defer resourceGeneratorsMu.RUnlock()
+	return registeredNames()
+}
+
+func registeredNames() []string {
+	names := make([]string, 0, len(resourceGenerators))
+	for name := range resourceGenerators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewResourceGenerators creates ResourceGenerators enabled in the project, in the order they are configured
+func NewResourceGenerators(project *projectstack.Project, stack *projectstack.Stack) ([]ResourceGenerator, error) {
+	if project.Generator == nil {
+		return nil, nil
+	}
+
+	resourceGeneratorsMu.RLock()
+	defer resourceGeneratorsMu.RUnlock()
+	var result []ResourceGenerator
+	for _, c := range project.Generator.ResourceGenerators {
+		fn, ok := resourceGenerators[c.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown resource generator %q, registered resource generators: %v", c.Name, registeredNames())
+		}
+		rg, err := fn(project, stack, c.Configs)
+		if err != nil {
+			return nil, fmt.Errorf("create resource generator %q failed: %w", c.Name, err)
+		}
+		result = append(result, rg)
+	}
+	return result, nil
+}
+
+// ExtendedGenerator generates Spec by the wrapped Generator, then runs ResourceGenerators on it in order
+type ExtendedGenerator struct {
+	Generator
+	ResourceGenerators []ResourceGenerator
+}
+
+var _ Generator = (*ExtendedGenerator)(nil)
+
+func (g *ExtendedGenerator) GenerateSpec(o *Options, stack *projectstack.Stack) (*models.Spec, error) {
+	spec, err := g.Generator.GenerateSpec(o, stack)
+	if err != nil {
+		return nil, err
+	}
+	for _, rg := range g.ResourceGenerators {
+		if err = rg.GenerateResources(spec); err != nil {
+			return nil, err
+		}
+	}
+	return spec, nil
+}
@@ -0,0 +1,106 @@
+package generator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// databaseGenerator turns database models into Kubernetes resources
+type databaseGenerator struct {
+	namespace string
+}
+
+func (g *databaseGenerator) GenerateResources(spec *models.Spec) error {
+	for i, r := range spec.Resources {
+		if r.Type != "Database" {
+			continue
+		}
+		spec.Resources[i] = models.Resource{
+			ID:   "example.com/v1:DatabaseClaim:" + g.namespace + ":" + r.ID,
+			Type: "Kubernetes",
+			Attributes: map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "DatabaseClaim",
+				"metadata":   map[string]interface{}{"name": r.ID, "namespace": g.namespace},
+			},
+		}
+	}
+	return nil
+}
+
+type fakeGenerator struct {
+	spec *models.Spec
+}
+
+func (g *fakeGenerator) GenerateSpec(*Options, *projectstack.Stack) (*models.Spec, error) {
+	return g.spec, nil
+}
+
+func TestRegisterResourceGenerator(t *testing.T) {
+	RegisterResourceGenerator("test-register", func(*projectstack.Project, *projectstack.Stack, map[string]interface{}) (ResourceGenerator, error) {
+		return &databaseGenerator{}, nil
+	})
+	assert.Contains(t, ResourceGenerators(), "test-register")
+	assert.Panics(t, func() {
+		RegisterResourceGenerator("test-register", func(*projectstack.Project, *projectstack.Stack, map[string]interface{}) (ResourceGenerator, error) {
+			return nil, nil
+		})
+	})
+	assert.Panics(t, func() { RegisterResourceGenerator("test-nil", nil) })
+}
+
+func TestNewResourceGenerators(t *testing.T) {
+	RegisterResourceGenerator("test-database", func(_ *projectstack.Project, _ *projectstack.Stack, configs map[string]interface{}) (ResourceGenerator, error) {
+		namespace, ok := configs["namespace"].(string)
+		if !ok {
+			return nil, errors.New("namespace is required")
+		}
+		return &databaseGenerator{namespace: namespace}, nil
+	})
+
+	newProject := func(configs ...*projectstack.ResourceGeneratorConfig) *projectstack.Project {
+		return &projectstack.Project{ProjectConfiguration: projectstack.ProjectConfiguration{
+			Generator: &projectstack.GeneratorConfig{Type: projectstack.KCLGenerator, ResourceGenerators: configs},
+		}}
+	}
+	stack := &projectstack.Stack{}
+
+	t.Run("no generator config", func(t *testing.T) {
+		rgs, err := NewResourceGenerators(&projectstack.Project{}, stack)
+		assert.Nil(t, err)
+		assert.Empty(t, rgs)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := NewResourceGenerators(newProject(&projectstack.ResourceGeneratorConfig{Name: "test-unknown"}), stack)
+		assert.ErrorContains(t, err, `unknown resource generator "test-unknown"`)
+	})
+
+	t.Run("invalid configs", func(t *testing.T) {
+		_, err := NewResourceGenerators(newProject(&projectstack.ResourceGeneratorConfig{Name: "test-database"}), stack)
+		assert.ErrorContains(t, err, "namespace is required")
+	})
+
+	t.Run("generate", func(t *testing.T) {
+		rgs, err := NewResourceGenerators(newProject(&projectstack.ResourceGeneratorConfig{
+			Name:    "test-database",
+			Configs: map[string]interface{}{"namespace": "db"},
+		}), stack)
+		assert.Nil(t, err)
+
+		g := &ExtendedGenerator{
+			Generator: &fakeGenerator{spec: &models.Spec{Resources: models.Resources{
+				{ID: "orders", Type: "Database"},
+			}}},
+			ResourceGenerators: rgs,
+		}
+		spec, err := g.GenerateSpec(&Options{}, stack)
+		assert.Nil(t, err)
+		assert.Equal(t, "example.com/v1:DatabaseClaim:db:orders", spec.Resources[0].ID)
+	})
+}
@@ -0,0 +1,140 @@
+// Package settings preprocesses settings files before the compilation, e.g. decrypting and interpolating them.
+// Processed settings files may contain decrypted secrets, so they are never written beside the original ones.
+// They are written into a private temporary directory instead, which is removed after the compilation.
+// KCL resolves relative paths of files to compile against the directory of the settings file, so they are
+// rewritten into absolute paths against the directory of the original file in processed ones.
+package settings
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Processor transforms the content of a settings file, and returns false if nothing is changed
+type Processor func(filename string, data []byte) ([]byte, bool, error)
+
+// tempDirPattern is the name pattern of temporary directories of processed settings files
+const tempDirPattern = "kusion-settings-*"
+
+// Process runs processors on every settings file in order. The returned settings refer to processed files,
+// by absolute paths, instead of original ones which are changed by any processor. The cleanup function
+// removes all processed files, and must be called after the compilation.
+func Process(workDir string, settings []string, processors ...Processor) ([]string, func(), error) {
+	var tempDir string
+	cleanup := func() {
+		if tempDir != "" {
+			_ = os.RemoveAll(tempDir)
+		}
+	}
+
+	result := make([]string, len(settings))
+	for i, setting := range settings {
+		result[i] = setting
+		filename := setting
+		if workDir != "" && !filepath.IsAbs(setting) {
+			filename = filepath.Join(workDir, setting)
+		}
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			// missing settings files are reported by the generator
+			continue
+		}
+
+		changed := false
+		for _, p := range processors {
+			var c bool
+			if data, c, err = p(filename, data); err != nil {
+				cleanup()
+				return nil, nil, fmt.Errorf("process settings file %s failed: %w", setting, err)
+			}
+			changed = changed || c
+		}
+		if !changed {
+			continue
+		}
+		if data, err = absFiles(data, filepath.Dir(filename)); err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("process settings file %s failed: %w", setting, err)
+		}
+
+		// the directory is only accessible by the current user, and unique per call, so that concurrent
+		// compilations of the same stack never share processed files
+		if tempDir == "" {
+			if tempDir, err = os.MkdirTemp("", tempDirPattern); err != nil {
+				return nil, nil, err
+			}
+		}
+		target := filepath.Join(tempDir, fmt.Sprintf("%d-%s", i, filepath.Base(setting)))
+		if err = os.WriteFile(target, data, 0o600); err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		result[i] = target
+	}
+	return result, cleanup, nil
+}
+
+// cliConfigsKey and fileKeys are keys of the files to compile in settings files
+const cliConfigsKey = "kcl_cli_configs"
+
+var fileKeys = map[string]bool{"file": true, "files": true}
+
+// absFiles rewrites relative paths of files to compile in the settings into absolute paths against the
+// directory. The settings are returned as they are if no path is rewritten.
+func absFiles(data []byte, dir string) ([]byte, error) {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return data, nil
+	}
+
+	rewritten := false
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != cliConfigsKey || root.Content[i+1].Kind != yaml.MappingNode {
+			continue
+		}
+		configs := root.Content[i+1]
+		for j := 0; j+1 < len(configs.Content); j += 2 {
+			if !fileKeys[configs.Content[j].Value] || configs.Content[j+1].Kind != yaml.SequenceNode {
+				continue
+			}
+			for _, file := range configs.Content[j+1].Content {
+				if file.Kind == yaml.ScalarNode && file.Value != "" && !filepath.IsAbs(file.Value) {
+					file.Value = filepath.Join(dir, file.Value)
+					rewritten = true
+				}
+			}
+		}
+	}
+	if !rewritten {
+		return data, nil
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Changed returns true if any settings file is replaced by a processed one
+func Changed(settings, processed []string) bool {
+	for i := range settings {
+		if processed[i] != settings[i] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package settings
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"kusionstack.io/kusion/pkg/generator/interpolation"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func upper(_ string, data []byte) ([]byte, bool, error) {
+	if !bytes.Contains(data, []byte("tag")) {
+		return data, false, nil
+	}
+	return bytes.ToUpper(data), true, nil
+}
+
+func TestProcess(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "ci-test"), 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "ci-test", "settings.yaml"), []byte("tag: v1"), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "kcl.yaml"), []byte("name: demo"), 0o644))
+
+	settings, cleanup, err := Process(dir, []string{filepath.Join("ci-test", "settings.yaml"), "kcl.yaml", "missing.yaml"}, upper)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"kcl.yaml", "missing.yaml"}, settings[1:])
+	assert.True(t, filepath.IsAbs(settings[0]))
+	assert.NotEqual(t, filepath.Join(dir, "ci-test"), filepath.Dir(settings[0]))
+
+	data, err := os.ReadFile(settings[0])
+	assert.Nil(t, err)
+	assert.Equal(t, "TAG: V1", string(data))
+	info, err := os.Stat(settings[0])
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// concurrent calls never share processed files
+	again, cleanupAgain, err := Process(dir, []string{filepath.Join("ci-test", "settings.yaml")}, upper)
+	assert.Nil(t, err)
+	assert.NotEqual(t, settings[0], again[0])
+	cleanupAgain()
+
+	cleanup()
+	_, err = os.Stat(filepath.Dir(settings[0]))
+	assert.True(t, os.IsNotExist(err))
+	entries, err := os.ReadDir(filepath.Join(dir, "ci-test"))
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+
+	failed := func(string, []byte) ([]byte, bool, error) { return nil, false, errors.New("denied") }
+	_, _, err = Process(dir, []string{"kcl.yaml"}, failed)
+	assert.EqualError(t, err, "process settings file kcl.yaml failed: denied")
+}
+
+func TestProcessRelativeFiles(t *testing.T) {
+	dir := t.TempDir()
+	stackDir := filepath.Join(dir, "dev")
+	assert.Nil(t, os.MkdirAll(stackDir, 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(stackDir, "kcl.yaml"), []byte(`kcl_cli_configs:
+  file:
+    - ../base/base.k
+    - main.k
+    - /abs/extra.k
+kcl_options:
+  - key: image
+    value: ${env:KUSION_TEST_IMAGE}
+`), 0o644))
+	t.Setenv("KUSION_TEST_IMAGE", "nginx:1.23")
+	ip := &interpolation.Interpolator{
+		Allowlist:  &projectstack.InterpolationConfig{Env: []string{"KUSION_TEST_IMAGE"}},
+		ProjectDir: dir,
+	}
+
+	settings, cleanup, err := Process(stackDir, []string{"kcl.yaml"}, ip.Process)
+	assert.Nil(t, err)
+	defer cleanup()
+	assert.NotEqual(t, stackDir, filepath.Dir(settings[0]))
+
+	processed := struct {
+		CLIConfigs struct {
+			File []string `yaml:"file"`
+		} `yaml:"kcl_cli_configs"`
+		Options []map[string]string `yaml:"kcl_options"`
+	}{}
+	data, err := os.ReadFile(settings[0])
+	assert.Nil(t, err)
+	assert.Nil(t, yaml.Unmarshal(data, &processed))
+	assert.Equal(t, []string{
+		filepath.Join(dir, "base", "base.k"), filepath.Join(stackDir, "main.k"), "/abs/extra.k",
+	}, processed.CLIConfigs.File)
+	assert.Equal(t, "nginx:1.23", processed.Options[0]["value"])
+}
+
+func TestChanged(t *testing.T) {
+	assert.False(t, Changed([]string{"kcl.yaml"}, []string{"kcl.yaml"}))
+	assert.True(t, Changed([]string{"kcl.yaml"}, []string{"/tmp/kusion-settings-1/0-kcl.yaml"}))
+}
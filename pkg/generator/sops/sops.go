@@ -0,0 +1,52 @@
+// Package sops decrypts SOPS-encrypted settings files during the compilation, so that secret values of stacks
+// can be committed to git. Keys are found the same way as the sops CLI, e.g. by SOPS_AGE_KEY_FILE for age, by
+// the AWS, GCP or Azure credentials for KMS, and by the GnuPG agent for PGP.
+package sops
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"go.mozilla.org/sops/v3/cmd/sops/formats"
+	"go.mozilla.org/sops/v3/decrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// metadataKey is the key of SOPS metadata in encrypted documents
+const metadataKey = "sops"
+
+// IsEncrypted returns true if the data is a SOPS-encrypted YAML or JSON document
+func IsEncrypted(data []byte) bool {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false
+	}
+	metadata, ok := doc[metadataKey].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = metadata["mac"]
+	return ok
+}
+
+// Decrypt decrypts the content of a settings file if it is encrypted by SOPS
+func Decrypt(filename string, data []byte) ([]byte, bool, error) {
+	if !IsEncrypted(data) {
+		return data, false, nil
+	}
+
+	format := formats.Yaml
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		format = formats.Json
+	}
+	cleartext, err := decryptData(data, format)
+	if err != nil {
+		return nil, false, fmt.Errorf("decrypt SOPS-encrypted file %s failed: %w", filename, err)
+	}
+	return cleartext, true, nil
+}
+
+func decryptData(data []byte, format formats.Format) ([]byte, error) {
+	return decrypt.DataWithFormat(data, format)
+}
@@ -0,0 +1,57 @@
+package sops
+
+import (
+	"errors"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+	"go.mozilla.org/sops/v3/cmd/sops/formats"
+)
+
+const encrypted = `password: ENC[AES256_GCM,data:cGFzcw==,iv:aXY=,tag:dGFn,type:str]
+sops:
+    age:
+        - recipient: age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+    lastmodified: "2022-09-01T00:00:00Z"
+    mac: ENC[AES256_GCM,data:bWFj,iv:aXY=,tag:dGFn,type:str]
+    version: 3.7.1
+`
+
+func TestIsEncrypted(t *testing.T) {
+	assert.True(t, IsEncrypted([]byte(encrypted)))
+	assert.True(t, IsEncrypted([]byte(`{"password":"ENC[...]","sops":{"mac":"ENC[...]"}}`)))
+	assert.False(t, IsEncrypted([]byte("password: plain")))
+	assert.False(t, IsEncrypted([]byte("sops: enabled")))
+	assert.False(t, IsEncrypted([]byte("- not a map")))
+}
+
+func TestDecrypt(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	data, changed, err := Decrypt("settings.yaml", []byte("password: plain"))
+	assert.Nil(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, "password: plain", string(data))
+
+	var gotFormat formats.Format
+	monkey.Patch(decryptData, func(data []byte, format formats.Format) ([]byte, error) {
+		gotFormat = format
+		return []byte("password: pass\n"), nil
+	})
+	data, changed, err = Decrypt("settings.yaml", []byte(encrypted))
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, formats.Yaml, gotFormat)
+	assert.Equal(t, "password: pass\n", string(data))
+
+	_, _, err = Decrypt("settings.json", []byte(`{"sops":{"mac":"ENC[...]"}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, formats.Json, gotFormat)
+
+	monkey.Patch(decryptData, func([]byte, formats.Format) ([]byte, error) {
+		return nil, errors.New("no key could decrypt the data key")
+	})
+	_, _, err = Decrypt("settings.yaml", []byte(encrypted))
+	assert.EqualError(t, err, "decrypt SOPS-encrypted file settings.yaml failed: no key could decrypt the data key")
+}
@@ -0,0 +1,167 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// LoadValues loads layered values of the stack. Values files named values.yaml in the project directory and
+// each directory down to the stack directory are deep merged in order, so that values of deeper directories
+// override the ones of their parents, e.g. values.yaml of the project as the base and the one of the stack for
+// each environment. Maps are merged key by key, other values including lists are replaced, and null deletes
+// the key. It returns the merged values and paths of the values files loaded relative to the project directory.
+func LoadValues(projectDir, stackDir string) (map[string]interface{}, []string, error) {
+	values := map[string]interface{}{}
+	if stackDir == "" {
+		return values, nil, nil
+	}
+
+	paths, err := ValuesFiles(projectDir, stackDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var files []string
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, nil, err
+		}
+		layer := map[string]interface{}{}
+		if err = yamlv3.Unmarshal(data, &layer); err != nil {
+			return nil, nil, fmt.Errorf("parse values file %s failed: %w", path, err)
+		}
+		MergeValues(values, layer)
+
+		file := path
+		if projectDir != "" {
+			if rel, err := filepath.Rel(projectDir, path); err == nil {
+				file = rel
+			}
+		}
+		files = append(files, file)
+	}
+	return values, files, nil
+}
+
+// ValuesFiles returns paths of the values files that may be layered for the stack in order, whether they exist
+// or not. They are values.yaml in the project directory and each directory down to the stack directory, or only
+// the one in the stack directory if the stack is not in the project directory.
+func ValuesFiles(projectDir, stackDir string) ([]string, error) {
+	if stackDir == "" {
+		return nil, nil
+	}
+
+	dirs := []string{stackDir}
+	if projectDir != "" {
+		rel, err := filepath.Rel(projectDir, stackDir)
+		if err != nil {
+			return nil, err
+		}
+		if rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			dirs = []string{projectDir}
+			dir := projectDir
+			for _, segment := range strings.Split(rel, string(filepath.Separator)) {
+				if segment == "." {
+					continue
+				}
+				dir = filepath.Join(dir, segment)
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+
+	files := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		files = append(files, filepath.Join(dir, projectstack.ValuesFile))
+	}
+	return files, nil
+}
+
+// MergeValues deep merges values of src into dst. Maps are merged key by key, other values are replaced and
+// null deletes the key.
+func MergeValues(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if v == nil {
+			delete(dst, k)
+			continue
+		}
+		srcMap, ok := v.(map[string]interface{})
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		dstMap, ok := dst[k].(map[string]interface{})
+		if !ok {
+			dstMap = map[string]interface{}{}
+		}
+		MergeValues(dstMap, srcMap)
+		dst[k] = dstMap
+	}
+}
+
+// LayerArguments returns top-level arguments of values followed by the arguments given on the command line.
+// Values of keys given by plain arguments are replaced by them, while arguments and sets by paths are merged
+// into the values by MergeArguments.
+func LayerArguments(values map[string]interface{}, arguments []string) ([]string, error) {
+	if len(values) == 0 {
+		return arguments, nil
+	}
+	given := map[string]bool{}
+	for _, arg := range arguments {
+		if k, _, ok := cutUnescaped(arg, '='); ok && !strings.ContainsAny(k, ".[") {
+			given[k] = true
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := make([]string, 0, len(keys)+len(arguments))
+	for _, k := range keys {
+		if given[k] {
+			continue
+		}
+		v, err := formatValue(values[k])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, k+"="+v)
+	}
+	return append(result, arguments...), nil
+}
+
+// EffectiveValues returns top-level values of the stack after layered values are overridden by arguments and
+// sets given on the command line, which are the values passed to generators
+func EffectiveValues(values map[string]interface{}, arguments, sets []string) (map[string]interface{}, error) {
+	layered, err := LayerArguments(values, arguments)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := MergeArguments(layered, sets)
+	if err != nil {
+		return nil, err
+	}
+	effective := map[string]interface{}{}
+	for _, arg := range merged {
+		k, v, ok := cutUnescaped(arg, '=')
+		if !ok {
+			return nil, fmt.Errorf("invalid argument %q, expected key=value", arg)
+		}
+		effective[k] = parseValue(v)
+	}
+	return effective, nil
+}
@@ -0,0 +1,97 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeValues(t *testing.T, dir, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "values.yaml"), []byte(content), 0o644))
+}
+
+func TestLoadValues(t *testing.T) {
+	project := t.TempDir()
+	stack := filepath.Join(project, "prod", "us-east")
+	writeValues(t, project, `app:
+  image: web:1.0
+  replicas: 1
+  env: [a, b]
+  debug: true
+tier: backend
+`)
+	writeValues(t, filepath.Join(project, "prod"), `app:
+  replicas: 3
+  env: [c]
+  debug: null
+`)
+	writeValues(t, stack, `region: us-east-1
+`)
+
+	values, files, err := LoadValues(project, stack)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"app": map[string]interface{}{
+			"image":    "web:1.0",
+			"replicas": 3,
+			"env":      []interface{}{"c"},
+		},
+		"tier":   "backend",
+		"region": "us-east-1",
+	}, values)
+	assert.Equal(t, []string{"values.yaml", filepath.Join("prod", "values.yaml"), filepath.Join("prod", "us-east", "values.yaml")}, files)
+
+	// the stack out of the project only loads its own values
+	other := t.TempDir()
+	writeValues(t, other, "tier: frontend\n")
+	values, files, err = LoadValues(project, other)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"tier": "frontend"}, values)
+	assert.Len(t, files, 1)
+
+	values, files, err = LoadValues("", "")
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+	assert.Empty(t, files)
+
+	writeValues(t, other, "- a\n")
+	_, _, err = LoadValues("", other)
+	assert.ErrorContains(t, err, "parse values file")
+}
+
+func TestLayerArguments(t *testing.T) {
+	values := map[string]interface{}{
+		"app":     map[string]interface{}{"replicas": 1},
+		"env":     "prod",
+		"version": "1.0",
+	}
+	args, err := LayerArguments(values, []string{"env=dev", "app.replicas=3"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`app={"replicas":1}`, `version="1.0"`, "env=dev", "app.replicas=3"}, args)
+
+	args, err = LayerArguments(nil, []string{"env=dev"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"env=dev"}, args)
+}
+
+func TestEffectiveValues(t *testing.T) {
+	values := map[string]interface{}{
+		"app": map[string]interface{}{"replicas": 1, "image": "web:1.0"},
+		"env": "prod",
+	}
+	effective, err := EffectiveValues(values, []string{"debug=true"}, []string{"app.replicas=3"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"app":   map[string]interface{}{"replicas": float64(3), "image": "web:1.0"},
+		"env":   "prod",
+		"debug": true,
+	}, effective)
+
+	_, err = EffectiveValues(values, nil, []string{"app"})
+	assert.EqualError(t, err, `invalid set value "app", expected path=value`)
+}
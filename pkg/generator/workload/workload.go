@@ -0,0 +1,455 @@
+// Package workload provides the built-in "workload" ResourceGenerator, which turns workload models in the Spec
+// into Kubernetes Deployments, Jobs, CronJobs, StatefulSets and DaemonSets with sane defaults, so that batch
+// and stateful workloads can be declared as concisely as Deployments.
+//
+// A workload model is a resource of the Workload type in the Spec:
+//
+//	id: app
+//	type: Workload
+//	attributes:
+//	  kind: StatefulSet
+//	  name: db
+//	  namespace: default
+//	  replicas: 3
+//	  containers:
+//	    - name: db
+//	      image: postgres:14
+//	  volumeClaims:
+//	    - name: data
+//	      storage: 10Gi
+//	      mountPath: /var/lib/postgresql/data
+//
+// Containers are Kubernetes containers. Dependencies on the model are replaced by dependencies on the workload.
+package workload
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"kusionstack.io/kusion/pkg/engine"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+const (
+	// Name is the name of the ResourceGenerator to enable in project.yaml
+	Name = "workload"
+	// Type is the resource type of workload models
+	Type models.Type = "Workload"
+)
+
+// Kinds of workloads
+const (
+	Deployment  = "Deployment"
+	Job         = "Job"
+	CronJob     = "CronJob"
+	StatefulSet = "StatefulSet"
+	DaemonSet   = "DaemonSet"
+)
+
+func init() {
+	generator.RegisterResourceGenerator(Name, New)
+}
+
+// Model is the workload model in attributes of the Workload resource
+type Model struct {
+	Kind        string            `json:"kind"`
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	Containers         []corev1.Container `json:"containers"`
+	InitContainers     []corev1.Container `json:"initContainers,omitempty"`
+	Volumes            []corev1.Volume    `json:"volumes,omitempty"`
+	ServiceAccountName string             `json:"serviceAccountName,omitempty"`
+
+	// Replicas of Deployments and StatefulSets, defaults to 1
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Schedule of CronJobs in the cron format
+	Schedule          string                    `json:"schedule,omitempty"`
+	ConcurrencyPolicy batchv1.ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+	// HistoryLimit is the number of successful finished jobs of CronJobs to retain, defaults to 3,
+	// or the number of old revisions of other workloads to retain, defaults to 10
+	HistoryLimit *int32 `json:"historyLimit,omitempty"`
+	// FailedHistoryLimit is the number of failed finished jobs of CronJobs to retain, defaults to 1
+	FailedHistoryLimit *int32 `json:"failedHistoryLimit,omitempty"`
+
+	// BackoffLimit is the number of retries of Jobs, defaults to 3
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+	// TTLSecondsAfterFinished is the TTL of finished Jobs, defaults to one day
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+	// RestartPolicy of Jobs and CronJobs, defaults to Never
+	RestartPolicy corev1.RestartPolicy `json:"restartPolicy,omitempty"`
+
+	// VolumeClaims of StatefulSets, which are mounted into all containers at their mount paths
+	VolumeClaims []VolumeClaim `json:"volumeClaims,omitempty"`
+	// MaxUnavailable of rolling updates of Deployments and DaemonSets, defaults to 25% and 1
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// VolumeClaim is a PVC template of StatefulSets
+type VolumeClaim struct {
+	Name             string                              `json:"name"`
+	Storage          string                              `json:"storage"`
+	StorageClassName *string                             `json:"storageClassName,omitempty"`
+	AccessModes      []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	MountPath        string                              `json:"mountPath,omitempty"`
+}
+
+// Config is the config of the generator in project.yaml
+type Config struct {
+	// Namespace is the default namespace of workloads
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// Generator generates workloads from workload models
+type Generator struct {
+	Config
+}
+
+var _ generator.ResourceGenerator = (*Generator)(nil)
+
+// New creates the workload Generator with its configs in project.yaml
+func New(_ *projectstack.Project, _ *projectstack.Stack, configs map[string]interface{}) (generator.ResourceGenerator, error) {
+	g := &Generator{}
+	if err := decode(configs, &g.Config, false); err != nil {
+		return nil, fmt.Errorf("invalid configs: %w", err)
+	}
+	return g, nil
+}
+
+func (g *Generator) GenerateResources(spec *models.Spec) error {
+	renames := map[string]string{}
+	resources := make(models.Resources, 0, len(spec.Resources))
+	for _, r := range spec.Resources {
+		if r.Type != Type {
+			resources = append(resources, r)
+			continue
+		}
+		m := &Model{}
+		if err := decode(r.Attributes, m, true); err != nil {
+			return fmt.Errorf("invalid workload %s: %w", r.ID, err)
+		}
+		if m.Namespace == "" {
+			m.Namespace = g.Namespace
+		}
+		generated, err := m.resources()
+		if err != nil {
+			return fmt.Errorf("invalid workload %s: %w", r.ID, err)
+		}
+		// the workload itself is the last one, which the model is renamed to
+		for i := range generated {
+			generated[i].DependsOn = append(generated[i].DependsOn, r.DependsOn...)
+		}
+		renames[r.ID] = generated[len(generated)-1].ID
+		resources = append(resources, generated...)
+	}
+
+	for i := range resources {
+		for j, dep := range resources[i].DependsOn {
+			if id, ok := renames[dep]; ok {
+				resources[i].DependsOn[j] = id
+			}
+		}
+	}
+	spec.Resources = resources
+	return nil
+}
+
+// resources returns Kubernetes resources of the workload, the workload itself is the last one
+func (m *Model) resources() (models.Resources, error) {
+	if m.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if len(m.Containers) == 0 {
+		return nil, fmt.Errorf("containers are required")
+	}
+
+	meta := metav1.ObjectMeta{Name: m.Name, Namespace: m.Namespace, Labels: m.Labels, Annotations: m.Annotations}
+	selector := &metav1.LabelSelector{MatchLabels: m.selectorLabels()}
+	pod := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: m.podLabels(), Annotations: m.Annotations},
+		Spec: corev1.PodSpec{
+			Containers:         m.Containers,
+			InitContainers:     m.InitContainers,
+			Volumes:            m.Volumes,
+			ServiceAccountName: m.ServiceAccountName,
+		},
+	}
+
+	var objects []k8sruntime.Object
+	switch m.Kind {
+	case Deployment:
+		maxUnavailable := intOrDefault(m.MaxUnavailable, intstr.FromString("25%"))
+		maxSurge := intstr.FromString("25%")
+		objects = append(objects, &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.String(), Kind: Deployment},
+			ObjectMeta: meta,
+			Spec: appsv1.DeploymentSpec{
+				Replicas: int32OrDefault(m.Replicas, 1),
+				Selector: selector,
+				Template: pod,
+				Strategy: appsv1.DeploymentStrategy{
+					Type:          appsv1.RollingUpdateDeploymentStrategyType,
+					RollingUpdate: &appsv1.RollingUpdateDeployment{MaxUnavailable: &maxUnavailable, MaxSurge: &maxSurge},
+				},
+				RevisionHistoryLimit: int32OrDefault(m.HistoryLimit, 10),
+			},
+		})
+	case Job:
+		objects = append(objects, &batchv1.Job{
+			TypeMeta:   metav1.TypeMeta{APIVersion: batchv1.SchemeGroupVersion.String(), Kind: Job},
+			ObjectMeta: meta,
+			Spec:       m.jobSpec(pod),
+		})
+	case CronJob:
+		if m.Schedule == "" {
+			return nil, fmt.Errorf("schedule is required by CronJob")
+		}
+		concurrencyPolicy := m.ConcurrencyPolicy
+		if concurrencyPolicy == "" {
+			concurrencyPolicy = batchv1.ForbidConcurrent
+		}
+		objects = append(objects, &batchv1.CronJob{
+			TypeMeta:   metav1.TypeMeta{APIVersion: batchv1.SchemeGroupVersion.String(), Kind: CronJob},
+			ObjectMeta: meta,
+			Spec: batchv1.CronJobSpec{
+				Schedule:                   m.Schedule,
+				ConcurrencyPolicy:          concurrencyPolicy,
+				SuccessfulJobsHistoryLimit: int32OrDefault(m.HistoryLimit, 3),
+				FailedJobsHistoryLimit:     int32OrDefault(m.FailedHistoryLimit, 1),
+				JobTemplate: batchv1.JobTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: m.podLabels()},
+					Spec:       m.jobSpec(pod),
+				},
+			},
+		})
+	case StatefulSet:
+		claims, err := m.volumeClaimTemplates(&pod.Spec)
+		if err != nil {
+			return nil, err
+		}
+		// the headless service governs the network identity of pods
+		objects = append(objects, &corev1.Service{
+			TypeMeta:   metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: "Service"},
+			ObjectMeta: metav1.ObjectMeta{Name: m.Name, Namespace: m.Namespace, Labels: m.Labels},
+			Spec: corev1.ServiceSpec{
+				ClusterIP: corev1.ClusterIPNone,
+				Selector:  m.selectorLabels(),
+				Ports:     servicePorts(m.Containers),
+			},
+		})
+		objects = append(objects, &appsv1.StatefulSet{
+			TypeMeta:   metav1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.String(), Kind: StatefulSet},
+			ObjectMeta: meta,
+			Spec: appsv1.StatefulSetSpec{
+				Replicas:            int32OrDefault(m.Replicas, 1),
+				Selector:            selector,
+				Template:            pod,
+				ServiceName:         m.Name,
+				PodManagementPolicy: appsv1.OrderedReadyPodManagement,
+				UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+					Type:          appsv1.RollingUpdateStatefulSetStrategyType,
+					RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{Partition: int32OrDefault(nil, 0)},
+				},
+				VolumeClaimTemplates: claims,
+				RevisionHistoryLimit: int32OrDefault(m.HistoryLimit, 10),
+			},
+		})
+	case DaemonSet:
+		maxUnavailable := intOrDefault(m.MaxUnavailable, intstr.FromInt(1))
+		objects = append(objects, &appsv1.DaemonSet{
+			TypeMeta:   metav1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.String(), Kind: DaemonSet},
+			ObjectMeta: meta,
+			Spec: appsv1.DaemonSetSpec{
+				Selector: selector,
+				Template: pod,
+				UpdateStrategy: appsv1.DaemonSetUpdateStrategy{
+					Type:          appsv1.RollingUpdateDaemonSetStrategyType,
+					RollingUpdate: &appsv1.RollingUpdateDaemonSet{MaxUnavailable: &maxUnavailable},
+				},
+				RevisionHistoryLimit: int32OrDefault(m.HistoryLimit, 10),
+			},
+		})
+	default:
+		return nil, fmt.Errorf("unsupported kind %q, must be one of %s, %s, %s, %s and %s",
+			m.Kind, Deployment, Job, CronJob, StatefulSet, DaemonSet)
+	}
+
+	resources := make(models.Resources, 0, len(objects))
+	for _, obj := range objects {
+		r, err := toResource(obj)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, r)
+	}
+	return resources, nil
+}
+
+func (m *Model) jobSpec(pod corev1.PodTemplateSpec) batchv1.JobSpec {
+	pod.Spec.RestartPolicy = m.RestartPolicy
+	if pod.Spec.RestartPolicy == "" {
+		pod.Spec.RestartPolicy = corev1.RestartPolicyNever
+	}
+	return batchv1.JobSpec{
+		Template:                pod,
+		BackoffLimit:            int32OrDefault(m.BackoffLimit, 3),
+		TTLSecondsAfterFinished: int32OrDefault(m.TTLSecondsAfterFinished, 24*60*60),
+	}
+}
+
+// volumeClaimTemplates returns PVC templates of the volume claims, and mounts them into containers
+func (m *Model) volumeClaimTemplates(pod *corev1.PodSpec) ([]corev1.PersistentVolumeClaim, error) {
+	claims := make([]corev1.PersistentVolumeClaim, 0, len(m.VolumeClaims))
+	for _, vc := range m.VolumeClaims {
+		if vc.Name == "" {
+			return nil, fmt.Errorf("name of volume claims is required")
+		}
+		storage, err := resource.ParseQuantity(vc.Storage)
+		if err != nil {
+			return nil, fmt.Errorf("invalid storage of the volume claim %s: %w", vc.Name, err)
+		}
+		accessModes := vc.AccessModes
+		if len(accessModes) == 0 {
+			accessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+		}
+		claims = append(claims, corev1.PersistentVolumeClaim{
+			TypeMeta:   metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: "PersistentVolumeClaim"},
+			ObjectMeta: metav1.ObjectMeta{Name: vc.Name},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes:      accessModes,
+				StorageClassName: vc.StorageClassName,
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: storage},
+				},
+			},
+		})
+		if vc.MountPath == "" {
+			continue
+		}
+		for i := range pod.Containers {
+			pod.Containers[i].VolumeMounts = append(pod.Containers[i].VolumeMounts,
+				corev1.VolumeMount{Name: vc.Name, MountPath: vc.MountPath})
+		}
+	}
+	return claims, nil
+}
+
+// selectorLabels are labels to select pods of the workload
+func (m *Model) selectorLabels() map[string]string {
+	return map[string]string{"app.kubernetes.io/name": m.Name}
+}
+
+func (m *Model) podLabels() map[string]string {
+	labels := map[string]string{}
+	for k, v := range m.Labels {
+		labels[k] = v
+	}
+	for k, v := range m.selectorLabels() {
+		labels[k] = v
+	}
+	return labels
+}
+
+func servicePorts(containers []corev1.Container) []corev1.ServicePort {
+	var ports []corev1.ServicePort
+	for _, c := range containers {
+		for _, p := range c.Ports {
+			name := p.Name
+			if name == "" {
+				name = fmt.Sprintf("%s-%d", c.Name, p.ContainerPort)
+			}
+			ports = append(ports, corev1.ServicePort{
+				Name:       name,
+				Port:       p.ContainerPort,
+				Protocol:   p.Protocol,
+				TargetPort: intstr.FromInt(int(p.ContainerPort)),
+			})
+		}
+	}
+	return ports
+}
+
+// toResource converts the Kubernetes object to a resource, null fields are dropped
+func toResource(obj k8sruntime.Object) (models.Resource, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return models.Resource{}, err
+	}
+	attributes := map[string]interface{}{}
+	if err = json.Unmarshal(data, &attributes); err != nil {
+		return models.Resource{}, err
+	}
+	pruneEmpty(attributes)
+
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	metadata := attributes["metadata"].(map[string]interface{})
+	namespace, _ := metadata["namespace"].(string)
+	name, _ := metadata["name"].(string)
+	return models.Resource{
+		ID:         engine.BuildIDForKubernetes(gvk.GroupVersion().String(), gvk.Kind, namespace, name),
+		Type:       runtime.Kubernetes,
+		Attributes: attributes,
+	}, nil
+}
+
+// pruneEmpty removes null values and empty status, e.g. creationTimestamp and status of typed objects
+func pruneEmpty(m map[string]interface{}) {
+	for k, v := range m {
+		switch value := v.(type) {
+		case nil:
+			delete(m, k)
+		case map[string]interface{}:
+			pruneEmpty(value)
+			if k == "status" && len(value) == 0 {
+				delete(m, k)
+			}
+		case []interface{}:
+			for _, item := range value {
+				if im, ok := item.(map[string]interface{}); ok {
+					pruneEmpty(im)
+				}
+			}
+		}
+	}
+}
+
+func decode(in map[string]interface{}, out interface{}, strict bool) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	return decoder.Decode(out)
+}
+
+func int32OrDefault(v *int32, d int32) *int32 {
+	if v != nil {
+		return v
+	}
+	return &d
+}
+
+func intOrDefault(v *intstr.IntOrString, d intstr.IntOrString) intstr.IntOrString {
+	if v != nil {
+		return *v
+	}
+	return d
+}
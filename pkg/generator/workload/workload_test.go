@@ -0,0 +1,116 @@
+package workload
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/validation"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func model(attributes map[string]interface{}) models.Resource {
+	attributes["containers"] = []interface{}{map[string]interface{}{
+		"name":  "main",
+		"image": "app:v1",
+		"ports": []interface{}{map[string]interface{}{"containerPort": 5432}},
+	}}
+	return models.Resource{ID: attributes["name"].(string), Type: Type, Attributes: attributes}
+}
+
+func newGenerator(t *testing.T) generator.ResourceGenerator {
+	project := &projectstack.Project{ProjectConfiguration: projectstack.ProjectConfiguration{
+		Generator: &projectstack.GeneratorConfig{ResourceGenerators: []*projectstack.ResourceGeneratorConfig{
+			{Name: Name, Configs: map[string]interface{}{"namespace": "default"}},
+		}},
+	}}
+	rgs, err := generator.NewResourceGenerators(project, &projectstack.Stack{})
+	assert.Nil(t, err)
+	assert.Len(t, rgs, 1)
+	return rgs[0]
+}
+
+func TestGenerator_GenerateResources(t *testing.T) {
+	spec := &models.Spec{Resources: models.Resources{
+		model(map[string]interface{}{"kind": "Job", "name": "migrate"}),
+		model(map[string]interface{}{"kind": "CronJob", "name": "backup", "schedule": "0 * * * *"}),
+		model(map[string]interface{}{
+			"kind":     "StatefulSet",
+			"name":     "db",
+			"replicas": 3,
+			"volumeClaims": []interface{}{
+				map[string]interface{}{"name": "data", "storage": "10Gi", "mountPath": "/data"},
+			},
+		}),
+		model(map[string]interface{}{"kind": "DaemonSet", "name": "agent", "namespace": "kube-system"}),
+		{
+			ID:         "v1:ConfigMap:default:config",
+			Type:       "Kubernetes",
+			Attributes: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "config", "namespace": "default"}},
+			DependsOn:  []string{"db"},
+		},
+	}}
+
+	assert.Nil(t, newGenerator(t).GenerateResources(spec))
+	index := spec.Resources.Index()
+	assert.Len(t, spec.Resources, 6)
+
+	job := index["batch/v1:Job:default:migrate"]
+	assert.NotNil(t, job)
+	assert.Equal(t, float64(3), job.Attributes["spec"].(map[string]interface{})["backoffLimit"])
+	assert.Equal(t, "Never", job.Attributes["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})["restartPolicy"])
+	assert.NotContains(t, job.Attributes, "status")
+	assert.NotContains(t, job.Attributes["metadata"], "creationTimestamp")
+
+	cronJob := index["batch/v1:CronJob:default:backup"].Attributes["spec"].(map[string]interface{})
+	assert.Equal(t, float64(3), cronJob["successfulJobsHistoryLimit"])
+	assert.Equal(t, float64(1), cronJob["failedJobsHistoryLimit"])
+	assert.Equal(t, "Forbid", cronJob["concurrencyPolicy"])
+
+	assert.NotNil(t, index["v1:Service:default:db"])
+	sts := index["apps/v1:StatefulSet:default:db"].Attributes["spec"].(map[string]interface{})
+	assert.Equal(t, float64(3), sts["replicas"])
+	assert.Equal(t, "db", sts["serviceName"])
+	claim := sts["volumeClaimTemplates"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{"ReadWriteOnce"}, claim["spec"].(map[string]interface{})["accessModes"])
+	container := sts["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "data", "mountPath": "/data"}}, container["volumeMounts"])
+
+	ds := index["apps/v1:DaemonSet:kube-system:agent"].Attributes["spec"].(map[string]interface{})
+	assert.Equal(t, "RollingUpdate", ds["updateStrategy"].(map[string]interface{})["type"])
+
+	assert.Equal(t, []string{"apps/v1:StatefulSet:default:db"}, index["v1:ConfigMap:default:config"].DependsOn)
+
+	// generated resources are valid
+	warnings, err := validation.ValidateSpec(spec)
+	assert.Nil(t, err)
+	assert.Empty(t, warnings)
+}
+
+func TestGenerator_GenerateResourcesInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes map[string]interface{}
+		wantErr    string
+	}{
+		{name: "unknown kind", attributes: map[string]interface{}{"kind": "Pod", "name": "a"}, wantErr: `unsupported kind "Pod"`},
+		{name: "no schedule", attributes: map[string]interface{}{"kind": "CronJob", "name": "a"}, wantErr: "schedule is required"},
+		{name: "unknown field", attributes: map[string]interface{}{"kind": "Job", "name": "a", "replica": 1}, wantErr: `unknown field "replica"`},
+		{
+			name: "invalid storage",
+			attributes: map[string]interface{}{
+				"kind": "StatefulSet", "name": "a",
+				"volumeClaims": []interface{}{map[string]interface{}{"name": "data", "storage": "ten"}},
+			},
+			wantErr: "invalid storage of the volume claim data",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &models.Spec{Resources: models.Resources{model(tt.attributes)}}
+			assert.ErrorContains(t, newGenerator(t).GenerateResources(spec), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package lint
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/policy"
+)
+
+// CELRule is a custom rule written in CEL, e.g.
+//
+//	name: no-node-port
+//	expression: "object.kind != 'Service' || !has(object.spec.type) || object.spec.type != 'NodePort'"
+//	message: NodePort services are not allowed
+type CELRule struct {
+	// Name is the unique name of the rule
+	Name string `json:"name" yaml:"name"`
+
+	// Expression returns true if the resource passes the rule. Variables are resource, with the id and the type
+	// of the resource, and object, the attributes of the resource.
+	Expression string `json:"expression" yaml:"expression"`
+
+	// Message is the message of findings, defaults to the expression
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+
+	// Severity is the severity of findings, defaults to medium
+	Severity string `json:"severity,omitempty" yaml:"severity,omitempty"`
+}
+
+// newCELEnv returns the CEL environment with variables of CEL rules, which has the same functions as
+// admission policies
+func newCELEnv() (*cel.Env, error) {
+	return policy.NewCELEnv(
+		cel.Variable("resource", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("object", cel.DynType),
+	)
+}
+
+// compile returns the checker of the rule
+func (r *CELRule) compile() (*ruleChecker, error) {
+	if r.Name == "" || r.Expression == "" {
+		return nil, fmt.Errorf("name and expression of CEL rules are required")
+	}
+	severity, err := severityOf(r.Severity, policy.SeverityMedium)
+	if err != nil {
+		return nil, fmt.Errorf("invalid severity of the rule %s: %w", r.Name, err)
+	}
+	env, err := newCELEnv()
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(r.Expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("compile the expression of the rule %s failed: %w", r.Name, issues.Err())
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("the expression of the rule %s must return a bool, got %s", r.Name, ast.OutputType())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+
+	message := r.Message
+	if message == "" {
+		message = "failed " + r.Expression
+	}
+	return &ruleChecker{
+		name:     r.Name,
+		severity: severity,
+		check: func(res *models.Resource) ([]string, error) {
+			out, _, err := program.Eval(map[string]interface{}{
+				"resource": map[string]string{"id": res.ID, "type": string(res.Type)},
+				"object":   res.Attributes,
+			})
+			if err != nil {
+				return nil, err
+			}
+			passed, ok := out.Value().(bool)
+			if !ok {
+				return nil, fmt.Errorf("the expression returns %v instead of a bool", out.Value())
+			}
+			if passed {
+				return nil, nil
+			}
+			return []string{message}, nil
+		},
+	}, nil
+}
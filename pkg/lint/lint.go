@@ -0,0 +1,272 @@
+// Package lint checks compiled resources of a stack against conventions, e.g. required labels, naming
+// conventions, image tags and replica minimums, before they are previewed or applied.
+//
+// Rules are Go implementations of Rule, which are registered by Register, and CEL expressions configured in
+// the config file. Rules are enabled, disabled and parametrized by the config file, lint.yaml in the project
+// directory by default.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/policy"
+	"kusionstack.io/kusion/pkg/util/yaml"
+)
+
+// DefaultThreshold is the lowest severity of findings which fail the lint by default
+const DefaultThreshold = policy.SeverityMedium
+
+// Rule checks each resource of the spec
+type Rule interface {
+	// Name is the unique name of the rule, which is used in the config file, e.g. required-labels
+	Name() string
+
+	// Severity is the default severity of findings of the rule
+	Severity() policy.Severity
+
+	// Check returns messages of findings of the resource, with params of the rule in the config file
+	Check(res *models.Resource, params Params) ([]string, error)
+}
+
+var (
+	rulesLock sync.RWMutex
+	rules     = map[string]Rule{}
+)
+
+// Register registers the rule, so that it is checked unless it is disabled in the config file. Rules of
+// other packages are registered in their init functions. Register panics if the name is registered already.
+func Register(rule Rule) {
+	rulesLock.Lock()
+	defer rulesLock.Unlock()
+	if _, ok := rules[rule.Name()]; ok {
+		panic(fmt.Sprintf("lint rule %s is registered twice", rule.Name()))
+	}
+	rules[rule.Name()] = rule
+}
+
+// Rules returns registered rules sorted by names
+func Rules() []Rule {
+	rulesLock.RLock()
+	defer rulesLock.RUnlock()
+	result := make([]Rule, 0, len(rules))
+	for _, r := range rules {
+		result = append(result, r)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result
+}
+
+// Config is the config file of the lint
+type Config struct {
+	// Threshold is the lowest severity of findings which fail the lint, defaults to medium. Findings below
+	// the threshold are warnings.
+	Threshold string `json:"threshold,omitempty" yaml:"threshold,omitempty"`
+
+	// Rules configure registered rules keyed by their names. Rules not listed are enabled with default params.
+	Rules map[string]*RuleConfig `json:"rules,omitempty" yaml:"rules,omitempty"`
+
+	// CELRules are custom rules written in CEL
+	CELRules []*CELRule `json:"celRules,omitempty" yaml:"celRules,omitempty"`
+}
+
+// RuleConfig configures a registered rule
+type RuleConfig struct {
+	// Disabled disables the rule
+	Disabled bool `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+
+	// Severity overrides the default severity of findings of the rule
+	Severity string `json:"severity,omitempty" yaml:"severity,omitempty"`
+
+	// Params are parameters of the rule, e.g. labels of required-labels
+	Params Params `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// LoadConfig reads the config file
+func LoadConfig(path string) (*Config, error) {
+	config := &Config{}
+	if err := yaml.ParseYamlFromFile(path, config); err != nil {
+		return nil, fmt.Errorf("read the lint config %s failed: %w", path, err)
+	}
+	return config, nil
+}
+
+// Linter checks resources against registered rules and CEL rules configured by the config
+type Linter struct {
+	Config *Config
+}
+
+var _ policy.Checker = (*Linter)(nil)
+
+// Check returns findings of resources of the input, where findings whose severities reach the threshold are
+// denies, and the others are warnings
+func (l *Linter) Check(input *policy.Input) (*policy.Result, error) {
+	config := l.Config
+	if config == nil {
+		config = &Config{}
+	}
+	threshold := DefaultThreshold
+	if config.Threshold != "" {
+		var err error
+		if threshold, err = policy.ParseSeverity(config.Threshold); err != nil {
+			return nil, err
+		}
+	}
+	checkers, err := config.checkers()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &policy.Result{}
+	for i := range input.Resources {
+		res := &input.Resources[i]
+		for _, c := range checkers {
+			messages, err := c.check(res)
+			if err != nil {
+				return nil, fmt.Errorf("check the rule %s on %s failed: %w", c.name, res.ID, err)
+			}
+			for _, m := range messages {
+				v := policy.Violation{Rule: c.name, Resource: res.ID, Severity: c.severity, Message: m}
+				if c.severity.AtLeast(threshold) {
+					result.Denies = append(result.Denies, v)
+				} else {
+					result.Warnings = append(result.Warnings, v)
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// ruleChecker is an enabled rule with its severity
+type ruleChecker struct {
+	name     string
+	severity policy.Severity
+	check    func(res *models.Resource) ([]string, error)
+}
+
+// checkers returns checkers of enabled registered rules and CEL rules
+func (c *Config) checkers() ([]*ruleChecker, error) {
+	registered := Rules()
+	names := make(map[string]bool, len(registered))
+	var checkers []*ruleChecker
+	for _, r := range registered {
+		rule, rc := r, c.Rules[r.Name()]
+		names[rule.Name()] = true
+		if rc == nil {
+			rc = &RuleConfig{}
+		}
+		if rc.Disabled {
+			continue
+		}
+		severity, err := severityOf(rc.Severity, rule.Severity())
+		if err != nil {
+			return nil, fmt.Errorf("invalid severity of the rule %s: %w", rule.Name(), err)
+		}
+		params := rc.Params
+		checkers = append(checkers, &ruleChecker{
+			name:     rule.Name(),
+			severity: severity,
+			check: func(res *models.Resource) ([]string, error) {
+				return rule.Check(res, params)
+			},
+		})
+	}
+	for name := range c.Rules {
+		if !names[name] {
+			return nil, fmt.Errorf("unknown lint rule %s", name)
+		}
+	}
+
+	for _, cr := range c.CELRules {
+		if names[cr.Name] {
+			return nil, fmt.Errorf("the CEL rule %s conflicts with another rule", cr.Name)
+		}
+		names[cr.Name] = true
+		checker, err := cr.compile()
+		if err != nil {
+			return nil, err
+		}
+		checkers = append(checkers, checker)
+	}
+	return checkers, nil
+}
+
+// severityOf parses the severity, or returns the default one if it is empty
+func severityOf(name string, defaultSeverity policy.Severity) (policy.Severity, error) {
+	if name == "" {
+		return defaultSeverity, nil
+	}
+	return policy.ParseSeverity(name)
+}
+
+// Params are parameters of a rule in the config file
+type Params map[string]interface{}
+
+// String returns the string parameter of the key, or the default value if it is not specified
+func (p Params) String(key, defaultValue string) (string, error) {
+	v, ok := p[key]
+	if !ok {
+		return defaultValue, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("param %s must be a string, got %v", key, v)
+	}
+	return s, nil
+}
+
+// Strings returns the string list parameter of the key, or the default value if it is not specified
+func (p Params) Strings(key string, defaultValue []string) ([]string, error) {
+	v, ok := p[key]
+	if !ok {
+		return defaultValue, nil
+	}
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("param %s must be a list of strings, got %v", key, v)
+	}
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("param %s must be a list of strings, got %v", key, v)
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// Int returns the integer parameter of the key, or the default value if it is not specified
+func (p Params) Int(key string, defaultValue int) (int, error) {
+	v, ok := p[key]
+	if !ok {
+		return defaultValue, nil
+	}
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case int64:
+		return int(n), nil
+	case float64:
+		if n == float64(int(n)) {
+			return int(n), nil
+		}
+	}
+	return 0, fmt.Errorf("param %s must be an integer, got %v", key, v)
+}
+
+// Bool returns the boolean parameter of the key, or the default value if it is not specified
+func (p Params) Bool(key string, defaultValue bool) (bool, error) {
+	v, ok := p[key]
+	if !ok {
+		return defaultValue, nil
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("param %s must be a boolean, got %v", key, v)
+	}
+	return b, nil
+}
@@ -0,0 +1,148 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/policy"
+)
+
+// forbiddenNameRule is a custom rule reporting resources of the name
+type forbiddenNameRule struct{}
+
+func (r *forbiddenNameRule) Name() string              { return "forbidden-name" }
+func (r *forbiddenNameRule) Severity() policy.Severity { return policy.SeverityCritical }
+
+func (r *forbiddenNameRule) Check(res *models.Resource, params Params) ([]string, error) {
+	name, err := params.String("name", "forbidden")
+	if err != nil {
+		return nil, err
+	}
+	if res.ID == "apps/v1:Deployment:default:"+name {
+		return []string{"the name is forbidden"}, nil
+	}
+	return nil, nil
+}
+
+func TestLinter_Check(t *testing.T) {
+	input := &policy.Input{Resources: models.Resources{
+		*deployment("app", 2, appLabels, "nginx:1.23"),
+		*deployment("Bad", 1, nil, "nginx"),
+	}}
+
+	t.Run("default config", func(t *testing.T) {
+		result, err := (&Linter{}).Check(input)
+		assert.Nil(t, err)
+		assert.Equal(t, []policy.Violation{
+			{Rule: RuleImageTag, Resource: "apps/v1:Deployment:default:Bad", Severity: policy.SeverityHigh, Message: "image nginx of container main uses the tag latest"},
+			{Rule: RuleReplicaMinimum, Resource: "apps/v1:Deployment:default:Bad", Severity: policy.SeverityMedium, Message: "1 replica(s) are fewer than the minimum 2"},
+			{Rule: RuleRequiredLabels, Resource: "apps/v1:Deployment:default:Bad", Severity: policy.SeverityMedium, Message: "label app.kubernetes.io/name is missing in metadata"},
+			{Rule: RuleRequiredLabels, Resource: "apps/v1:Deployment:default:Bad", Severity: policy.SeverityMedium, Message: "label app.kubernetes.io/name is missing in the pod template"},
+		}, result.Denies)
+		assert.Equal(t, []policy.Violation{
+			{Rule: RuleNamingConvention, Resource: "apps/v1:Deployment:default:Bad", Severity: policy.SeverityLow, Message: "name Bad does not match the pattern " + defaultNamePattern},
+		}, result.Warnings)
+	})
+
+	t.Run("configured rules", func(t *testing.T) {
+		result, err := (&Linter{Config: &Config{
+			Threshold: "high",
+			Rules: map[string]*RuleConfig{
+				RuleRequiredLabels:   {Disabled: true},
+				RuleReplicaMinimum:   {Params: Params{"min": 1}},
+				RuleNamingConvention: {Severity: "critical"},
+			},
+		}}).Check(input)
+		assert.Nil(t, err)
+		assert.Len(t, result.Denies, 2)
+		assert.Equal(t, RuleImageTag, result.Denies[0].Rule)
+		assert.Equal(t, RuleNamingConvention, result.Denies[1].Rule)
+		assert.Empty(t, result.Warnings)
+	})
+
+	t.Run("CEL rules", func(t *testing.T) {
+		result, err := (&Linter{Config: &Config{
+			Rules: map[string]*RuleConfig{
+				RuleRequiredLabels:   {Disabled: true},
+				RuleReplicaMinimum:   {Disabled: true},
+				RuleNamingConvention: {Disabled: true},
+				RuleImageTag:         {Disabled: true},
+			},
+			CELRules: []*CELRule{{
+				Name:       "lower-case-names",
+				Expression: "resource.type != 'Kubernetes' || object.metadata.name.find('[A-Z]') == ''",
+				Message:    "names must be lower case",
+				Severity:   "low",
+			}},
+		}}).Check(input)
+		assert.Nil(t, err)
+		assert.Empty(t, result.Denies)
+		assert.Equal(t, []policy.Violation{
+			{Rule: "lower-case-names", Resource: "apps/v1:Deployment:default:Bad", Severity: policy.SeverityLow, Message: "names must be lower case"},
+		}, result.Warnings)
+	})
+
+	t.Run("custom Go rules", func(t *testing.T) {
+		Register(&forbiddenNameRule{})
+		t.Cleanup(func() {
+			rulesLock.Lock()
+			defer rulesLock.Unlock()
+			delete(rules, "forbidden-name")
+		})
+		assert.Panics(t, func() { Register(&forbiddenNameRule{}) })
+
+		result, err := (&Linter{Config: &Config{
+			Rules: map[string]*RuleConfig{"forbidden-name": {Params: Params{"name": "app"}}},
+		}}).Check(input)
+		assert.Nil(t, err)
+		assert.Contains(t, result.Denies, policy.Violation{
+			Rule: "forbidden-name", Resource: "apps/v1:Deployment:default:app", Severity: policy.SeverityCritical, Message: "the name is forbidden",
+		})
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		tests := map[string]*Config{
+			"unknown lint rule unknown": {Rules: map[string]*RuleConfig{"unknown": {}}},
+			"invalid severity of the rule image-tag: invalid severity bad, must be one of low, medium, high and critical": {Rules: map[string]*RuleConfig{RuleImageTag: {Severity: "bad"}}},
+			"the CEL rule image-tag conflicts with another rule":                                                          {CELRules: []*CELRule{{Name: RuleImageTag, Expression: "true"}}},
+			"the expression of the rule str must return a bool, got string":                                               {CELRules: []*CELRule{{Name: "str", Expression: "'a'"}}},
+		}
+		for want, config := range tests {
+			_, err := (&Linter{Config: config}).Check(input)
+			assert.EqualError(t, err, want)
+		}
+	})
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lint.yaml")
+	assert.Nil(t, os.WriteFile(path, []byte(`threshold: high
+rules:
+  required-labels:
+    params:
+      labels: [app.kubernetes.io/name, team]
+  naming-convention:
+    disabled: true
+celRules:
+  - name: no-node-port
+    expression: "true"
+`), 0o644))
+
+	config, err := LoadConfig(path)
+	assert.Nil(t, err)
+	assert.Equal(t, &Config{
+		Threshold: "high",
+		Rules: map[string]*RuleConfig{
+			RuleRequiredLabels:   {Params: Params{"labels": []interface{}{"app.kubernetes.io/name", "team"}}},
+			RuleNamingConvention: {Disabled: true},
+		},
+		CELRules: []*CELRule{{Name: "no-node-port", Expression: "true"}},
+	}, config)
+
+	_, err = LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "read the lint config")
+}
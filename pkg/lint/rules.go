@@ -0,0 +1,221 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/policy"
+)
+
+// Built-in rules, which only check Kubernetes resources
+const (
+	RuleRequiredLabels   = "required-labels"
+	RuleNamingConvention = "naming-convention"
+	RuleImageTag         = "image-tag"
+	RuleReplicaMinimum   = "replica-minimum"
+)
+
+// Default params of built-in rules
+const (
+	// defaultNamePattern and defaultNameMaxLength are of DNS labels defined in RFC 1123
+	defaultNamePattern    = `^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	defaultNameMaxLength  = 63
+	defaultReplicaMinimum = 2
+)
+
+var defaultRequiredLabels = []string{"app.kubernetes.io/name"}
+
+func init() {
+	Register(&requiredLabelsRule{})
+	Register(&namingConventionRule{})
+	Register(&imageTagRule{})
+	Register(&replicaMinimumRule{})
+}
+
+// requiredLabelsRule requires labels of resources, and of pod templates of workloads.
+// Params: labels, the required label keys, defaults to app.kubernetes.io/name.
+type requiredLabelsRule struct{}
+
+func (r *requiredLabelsRule) Name() string              { return RuleRequiredLabels }
+func (r *requiredLabelsRule) Severity() policy.Severity { return policy.SeverityMedium }
+
+func (r *requiredLabelsRule) Check(res *models.Resource, params Params) ([]string, error) {
+	required, err := params.Strings("labels", defaultRequiredLabels)
+	if err != nil || res.Type != runtime.Kubernetes {
+		return nil, err
+	}
+	var messages []string
+	missing := func(meta map[string]interface{}, of string) {
+		labels, _ := meta["labels"].(map[string]interface{})
+		for _, key := range required {
+			if _, ok := labels[key]; !ok {
+				messages = append(messages, fmt.Sprintf("label %s is missing in %s", key, of))
+			}
+		}
+	}
+	meta, _ := res.Attributes["metadata"].(map[string]interface{})
+	missing(meta, "metadata")
+	if template := podTemplateOf(res.Attributes); template != nil {
+		meta, _ = template["metadata"].(map[string]interface{})
+		missing(meta, "the pod template")
+	}
+	return messages, nil
+}
+
+// namingConventionRule requires names of resources to match the pattern.
+// Params: pattern, the regular expression of names, defaults to DNS labels; maxLength, defaults to 63.
+type namingConventionRule struct{}
+
+func (r *namingConventionRule) Name() string              { return RuleNamingConvention }
+func (r *namingConventionRule) Severity() policy.Severity { return policy.SeverityLow }
+
+func (r *namingConventionRule) Check(res *models.Resource, params Params) ([]string, error) {
+	pattern, err := params.String("pattern", defaultNamePattern)
+	if err != nil {
+		return nil, err
+	}
+	maxLength, err := params.Int("maxLength", defaultNameMaxLength)
+	if err != nil || res.Type != runtime.Kubernetes {
+		return nil, err
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid param pattern: %w", err)
+	}
+	meta, _ := res.Attributes["metadata"].(map[string]interface{})
+	name, _ := meta["name"].(string)
+	if name == "" {
+		return nil, nil
+	}
+	var messages []string
+	if !re.MatchString(name) {
+		messages = append(messages, fmt.Sprintf("name %s does not match the pattern %s", name, pattern))
+	}
+	if maxLength > 0 && len(name) > maxLength {
+		messages = append(messages, fmt.Sprintf("name %s is longer than %d characters", name, maxLength))
+	}
+	return messages, nil
+}
+
+// imageTagRule forbids tags of container images, and untagged images which are pulled as latest.
+// Params: disallowedTags, defaults to latest; requireDigest, requires images pinned by digests, defaults to false.
+type imageTagRule struct{}
+
+func (r *imageTagRule) Name() string              { return RuleImageTag }
+func (r *imageTagRule) Severity() policy.Severity { return policy.SeverityHigh }
+
+func (r *imageTagRule) Check(res *models.Resource, params Params) ([]string, error) {
+	disallowed, err := params.Strings("disallowedTags", []string{"latest"})
+	if err != nil {
+		return nil, err
+	}
+	requireDigest, err := params.Bool("requireDigest", false)
+	if err != nil || res.Type != runtime.Kubernetes {
+		return nil, err
+	}
+	var messages []string
+	for _, container := range containersOf(res.Attributes) {
+		image, _ := container["image"].(string)
+		if image == "" {
+			continue
+		}
+		tag, digest := splitImage(image)
+		if requireDigest && digest == "" {
+			messages = append(messages, fmt.Sprintf("image %s of container %v is not pinned by a digest", image, container["name"]))
+		}
+		if digest != "" && tag == "" {
+			continue
+		}
+		if tag == "" {
+			tag = "latest"
+		}
+		for _, d := range disallowed {
+			if tag == d {
+				messages = append(messages, fmt.Sprintf("image %s of container %v uses the tag %s", image, container["name"], tag))
+			}
+		}
+	}
+	return messages, nil
+}
+
+// splitImage returns the tag and the digest of the image reference, either of which can be empty
+func splitImage(image string) (tag, digest string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image, digest = image[:i], image[i+1:]
+	}
+	// the colon before the last slash separates the port of the registry
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		tag = image[i+1:]
+	}
+	return tag, digest
+}
+
+// replicaMinimumRule requires the minimum number of replicas of Deployments, StatefulSets and ReplicaSets.
+// Params: min, defaults to 2.
+type replicaMinimumRule struct{}
+
+func (r *replicaMinimumRule) Name() string              { return RuleReplicaMinimum }
+func (r *replicaMinimumRule) Severity() policy.Severity { return policy.SeverityMedium }
+
+func (r *replicaMinimumRule) Check(res *models.Resource, params Params) ([]string, error) {
+	minimum, err := params.Int("min", defaultReplicaMinimum)
+	if err != nil || res.Type != runtime.Kubernetes {
+		return nil, err
+	}
+	switch kind, _ := res.Attributes["kind"].(string); kind {
+	case "Deployment", "StatefulSet", "ReplicaSet":
+	default:
+		return nil, nil
+	}
+	spec, _ := res.Attributes["spec"].(map[string]interface{})
+	// replicas default to 1 if not specified
+	replicas, err := Params(spec).Int("replicas", 1)
+	if err != nil {
+		return nil, err
+	}
+	if replicas < minimum {
+		return []string{fmt.Sprintf("%d replica(s) are fewer than the minimum %d", replicas, minimum)}, nil
+	}
+	return nil, nil
+}
+
+// podTemplateOf returns the pod template of workloads
+func podTemplateOf(attributes map[string]interface{}) map[string]interface{} {
+	kind, _ := attributes["kind"].(string)
+	var path []string
+	switch kind {
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job", "ReplicationController":
+		path = []string{"spec", "template"}
+	case "CronJob":
+		path = []string{"spec", "jobTemplate", "spec", "template"}
+	default:
+		return nil
+	}
+	current := attributes
+	for _, p := range path {
+		current, _ = current[p].(map[string]interface{})
+	}
+	return current
+}
+
+// containersOf returns init containers and containers of Pods and workloads
+func containersOf(attributes map[string]interface{}) []map[string]interface{} {
+	podSpec, _ := attributes["spec"].(map[string]interface{})
+	if kind, _ := attributes["kind"].(string); kind != "Pod" {
+		template := podTemplateOf(attributes)
+		podSpec, _ = template["spec"].(map[string]interface{})
+	}
+	var containers []map[string]interface{}
+	for _, field := range []string{"initContainers", "containers"} {
+		items, _ := podSpec[field].([]interface{})
+		for _, item := range items {
+			if container, ok := item.(map[string]interface{}); ok {
+				containers = append(containers, container)
+			}
+		}
+	}
+	return containers
+}
@@ -0,0 +1,128 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+func deployment(name string, replicas interface{}, labels map[string]interface{}, images ...string) *models.Resource {
+	var containers []interface{}
+	for _, image := range images {
+		containers = append(containers, map[string]interface{}{"name": "main", "image": image})
+	}
+	spec := map[string]interface{}{
+		"template": map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": labels},
+			"spec":     map[string]interface{}{"containers": containers},
+		},
+	}
+	if replicas != nil {
+		spec["replicas"] = replicas
+	}
+	return &models.Resource{
+		ID:   "apps/v1:Deployment:default:" + name,
+		Type: runtime.Kubernetes,
+		Attributes: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default", "labels": labels},
+			"spec":       spec,
+		},
+	}
+}
+
+var appLabels = map[string]interface{}{"app.kubernetes.io/name": "app"}
+
+func TestRequiredLabelsRule(t *testing.T) {
+	rule := &requiredLabelsRule{}
+	got, err := rule.Check(deployment("app", 2, appLabels), nil)
+	assert.Nil(t, err)
+	assert.Empty(t, got)
+
+	got, err = rule.Check(deployment("app", 2, nil), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"label app.kubernetes.io/name is missing in metadata",
+		"label app.kubernetes.io/name is missing in the pod template",
+	}, got)
+
+	got, err = rule.Check(deployment("app", 2, appLabels), Params{"labels": []interface{}{"team"}})
+	assert.Nil(t, err)
+	assert.Len(t, got, 2)
+
+	_, err = rule.Check(deployment("app", 2, appLabels), Params{"labels": "team"})
+	assert.EqualError(t, err, "param labels must be a list of strings, got team")
+
+	// resources of other runtimes are not checked
+	got, err = rule.Check(&models.Resource{ID: "aliyun:alicloud:alicloud_vpc:vpc", Type: runtime.Terraform}, nil)
+	assert.Nil(t, err)
+	assert.Empty(t, got)
+}
+
+func TestNamingConventionRule(t *testing.T) {
+	rule := &namingConventionRule{}
+	got, err := rule.Check(deployment("my-app", 2, appLabels), nil)
+	assert.Nil(t, err)
+	assert.Empty(t, got)
+
+	got, err = rule.Check(deployment("My_App", 2, appLabels), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"name My_App does not match the pattern " + defaultNamePattern}, got)
+
+	got, err = rule.Check(deployment("my-app", 2, appLabels), Params{"pattern": "^prod-", "maxLength": 5})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"name my-app does not match the pattern ^prod-",
+		"name my-app is longer than 5 characters",
+	}, got)
+
+	_, err = rule.Check(deployment("my-app", 2, appLabels), Params{"pattern": "("})
+	assert.ErrorContains(t, err, "invalid param pattern")
+}
+
+func TestImageTagRule(t *testing.T) {
+	rule := &imageTagRule{}
+	tests := []struct {
+		image  string
+		params Params
+		want   []string
+	}{
+		{image: "nginx:1.23"},
+		{image: "nginx", want: []string{"image nginx of container main uses the tag latest"}},
+		{image: "registry:5000/nginx:latest", want: []string{"image registry:5000/nginx:latest of container main uses the tag latest"}},
+		{image: "registry:5000/nginx", want: []string{"image registry:5000/nginx of container main uses the tag latest"}},
+		{image: "nginx@sha256:abc"},
+		{image: "nginx:1.23", params: Params{"disallowedTags": []interface{}{"1.23"}}, want: []string{"image nginx:1.23 of container main uses the tag 1.23"}},
+		{image: "nginx:1.23", params: Params{"requireDigest": true}, want: []string{"image nginx:1.23 of container main is not pinned by a digest"}},
+		{image: "nginx:1.23@sha256:abc", params: Params{"requireDigest": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			got, err := rule.Check(deployment("app", 2, appLabels, tt.image), tt.params)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReplicaMinimumRule(t *testing.T) {
+	rule := &replicaMinimumRule{}
+	got, err := rule.Check(deployment("app", 2, appLabels), nil)
+	assert.Nil(t, err)
+	assert.Empty(t, got)
+
+	got, err = rule.Check(deployment("app", nil, appLabels), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1 replica(s) are fewer than the minimum 2"}, got)
+
+	got, err = rule.Check(deployment("app", 2, appLabels), Params{"min": 3})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2 replica(s) are fewer than the minimum 3"}, got)
+
+	_, err = rule.Check(deployment("app", 2, appLabels), Params{"min": "3"})
+	assert.EqualError(t, err, "param min must be an integer, got 3")
+}
@@ -0,0 +1,107 @@
+package notification
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+)
+
+// CloudEventsSpecVersion is the version of the CloudEvents specification of events sent to sinks
+const CloudEventsSpecVersion = "1.0"
+
+// cloudEventsTypePrefix prefixes types of CloudEvents, e.g. io.kusionstack.operation.started
+const cloudEventsTypePrefix = "io.kusionstack."
+
+// Names of CloudEvents, which are the types of CloudEvents without the prefix and subscribed by events of sinks
+const (
+	CloudEventOperationStarted   = "operation.started"
+	CloudEventOperationSucceeded = "operation.succeeded"
+	CloudEventOperationFailed    = "operation.failed"
+	CloudEventResourceApplied    = "resource.applied"
+	CloudEventResourceDeleted    = "resource.deleted"
+	CloudEventResourceFailed     = "resource.failed"
+)
+
+// Content types of requests to sinks
+const (
+	cloudEventsContentType = "application/cloudevents+json"
+	kafkaRESTContentType   = "application/vnd.kafka.json.v2+json"
+)
+
+// CloudEvent is an event in the structured content mode of CloudEvents, see
+// https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/formats/json-format.md
+type CloudEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Subject         string    `json:"subject,omitempty"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+	Data            *Event    `json:"data"`
+}
+
+// isCloudEvents returns true if the sink receives CloudEvents, which are the only sinks notified of resources
+func isCloudEvents(typ string) bool {
+	return typ == TypeCloudEvents || typ == TypeKafka
+}
+
+// CloudEventName returns the name of the CloudEvent of the event, e.g. operation.started
+func CloudEventName(e *Event) string {
+	if e.ResourceID != "" {
+		switch {
+		case e.Phase == PhaseFailure:
+			return CloudEventResourceFailed
+		case e.Action == opsmodels.Delete.String():
+			return CloudEventResourceDeleted
+		default:
+			return CloudEventResourceApplied
+		}
+	}
+	switch e.Phase {
+	case PhaseStart:
+		return CloudEventOperationStarted
+	case PhaseSuccess:
+		return CloudEventOperationSucceeded
+	default:
+		return CloudEventOperationFailed
+	}
+}
+
+// NewCloudEvent returns the CloudEvent of the event, whose source is the stack and whose subject is the
+// resource of resource events
+func NewCloudEvent(e *Event) (*CloudEvent, error) {
+	id, err := eventID()
+	if err != nil {
+		return nil, err
+	}
+	return &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              id,
+		Source:          fmt.Sprintf("/kusion/projects/%s/stacks/%s", e.Project, e.Stack),
+		Type:            cloudEventsTypePrefix + CloudEventName(e),
+		Subject:         e.ResourceID,
+		Time:            e.Time,
+		DataContentType: "application/json",
+		Data:            e,
+	}, nil
+}
+
+// kafkaRecords returns the body of requests to the Kafka REST Proxy, which produces the CloudEvent to the
+// topic keyed by its source, so that events of a stack are kept in order in a partition
+func kafkaRecords(ce *CloudEvent) interface{} {
+	return map[string]interface{}{
+		"records": []map[string]interface{}{{"key": ce.Source, "value": ce}},
+	}
+}
+
+// eventID returns a random ID of CloudEvents
+func eventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", b), nil
+}
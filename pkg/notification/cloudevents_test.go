@@ -0,0 +1,86 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func TestNotifier_CloudEvents(t *testing.T) {
+	server, requests := newServer(t, `{}`)
+	project := &projectstack.Project{ProjectConfiguration: projectstack.ProjectConfiguration{
+		Name: "project",
+		Notifications: []*projectstack.NotificationConfig{
+			{Type: TypeSlack, URL: server.URL + "/slack"},
+			{Type: TypeCloudEvents, URL: server.URL + "/sink"},
+			{
+				Type:   TypeKafka,
+				URL:    server.URL + "/topics/deploys",
+				Events: []string{CloudEventResourceFailed, CloudEventOperationFailed},
+			},
+		},
+	}}
+	stack := &projectstack.Stack{StackConfiguration: projectstack.StackConfiguration{Name: "dev"}}
+	a := &opsmodels.ChangeStep{ID: "a", Action: opsmodels.Create}
+	b := &opsmodels.ChangeStep{ID: "b", Action: opsmodels.Delete}
+	c := &opsmodels.ChangeStep{ID: "c", Action: opsmodels.UnChange}
+	changes := opsmodels.NewChanges(project, stack, &opsmodels.ChangeOrder{
+		StepKeys:    []string{"a", "b", "c"},
+		ChangeSteps: map[string]*opsmodels.ChangeStep{"a": a, "b": b, "c": c},
+	})
+
+	n := NewNotifier("apply", project, stack, "alice", changes)
+	assert.Nil(t, n.Start(context.Background()))
+	n.Resource(a, opsmodels.Message{ResourceID: "a"})
+	n.Resource(a, opsmodels.Message{ResourceID: "a", OpResult: opsmodels.Success})
+	n.Resource(b, opsmodels.Message{ResourceID: "b", OpResult: opsmodels.Failed, OpErr: errors.New("forbidden")})
+	n.Resource(c, opsmodels.Message{ResourceID: "c", OpResult: opsmodels.Success})
+	assert.Nil(t, n.Finish(context.Background(), errors.New("apply failed")))
+
+	var got []string
+	for _, r := range *requests {
+		switch r.path {
+		case "/sink":
+			assert.Equal(t, "application/cloudevents+json", r.header.Get("Content-Type"))
+			assert.Equal(t, "1.0", r.body["specversion"])
+			assert.Equal(t, "/kusion/projects/project/stacks/dev", r.body["source"])
+			got = append(got, r.path+" "+r.body["type"].(string))
+		case "/topics/deploys":
+			assert.Equal(t, "application/vnd.kafka.json.v2+json", r.header.Get("Content-Type"))
+			record := r.body["records"].([]interface{})[0].(map[string]interface{})
+			assert.Equal(t, "/kusion/projects/project/stacks/dev", record["key"])
+			got = append(got, r.path+" "+record["value"].(map[string]interface{})["type"].(string))
+		default:
+			got = append(got, r.path)
+		}
+	}
+	assert.Equal(t, []string{
+		"/slack",
+		"/sink io.kusionstack.operation.started",
+		"/sink io.kusionstack.resource.applied",
+		"/sink io.kusionstack.resource.failed",
+		"/topics/deploys io.kusionstack.resource.failed",
+		"/slack",
+		"/sink io.kusionstack.operation.failed",
+		"/topics/deploys io.kusionstack.operation.failed",
+	}, got)
+
+	failed := (*requests)[3].body
+	assert.Equal(t, "b", failed["subject"])
+	assert.Equal(t, map[string]interface{}{"operation": "apply", "phase": "failure", "project": "project",
+		"stack": "dev", "operator": "alice", "resourceId": "b", "action": "Delete", "error": "forbidden",
+		"summary": map[string]interface{}{"create": float64(1), "update": float64(0), "delete": float64(1), "unchange": float64(1)},
+		"time":    failed["data"].(map[string]interface{})["time"]},
+		failed["data"])
+}
+
+func TestCloudEventName(t *testing.T) {
+	assert.Equal(t, CloudEventOperationStarted, CloudEventName(&Event{Phase: PhaseStart}))
+	assert.Equal(t, CloudEventOperationSucceeded, CloudEventName(&Event{Phase: PhaseSuccess}))
+	assert.Equal(t, CloudEventResourceDeleted, CloudEventName(&Event{Phase: PhaseSuccess, ResourceID: "a", Action: "Delete"}))
+}
@@ -0,0 +1,396 @@
+// Package notification notifies webhooks, such as Slack, DingTalk and Feishu robots, of results of operations,
+// so that teams get deployment notifications without wrapping the CLI. CloudEvents sinks, i.e. HTTP endpoints
+// and Kafka topics behind the Kafka REST Proxy, are also notified of each applied resource, so that automation
+// can be driven by events of deploys without polling.
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// Types of webhooks
+const (
+	TypeSlack    = "slack"
+	TypeDingTalk = "dingtalk"
+	TypeFeishu   = "feishu"
+	TypeWebhook  = "webhook"
+	// TypeCloudEvents sinks receive CloudEvents in the structured content mode of the HTTP binding
+	TypeCloudEvents = "cloudevents"
+	// TypeKafka sinks are topics of the Kafka REST Proxy, e.g. http://rest-proxy:8082/topics/deploys, which
+	// receive CloudEvents as records
+	TypeKafka = "kafka"
+)
+
+// Phase is the phase of the operation notified
+type Phase string
+
+const (
+	PhaseStart   Phase = "start"
+	PhaseSuccess Phase = "success"
+	PhaseFailure Phase = "failure"
+)
+
+// Timeout of requests to webhooks
+const Timeout = 10 * time.Second
+
+var client = &http.Client{Timeout: Timeout}
+
+// Summary counts resources changed by the operation
+type Summary struct {
+	Create   int `json:"create"`
+	Update   int `json:"update"`
+	Delete   int `json:"delete"`
+	UnChange int `json:"unchange"`
+}
+
+// NewSummary counts resources of the changes by their actions
+func NewSummary(changes *opsmodels.Changes) Summary {
+	var summary Summary
+	if changes == nil {
+		return summary
+	}
+	for _, step := range changes.Values() {
+		switch step.Action {
+		case opsmodels.Create:
+			summary.Create++
+		case opsmodels.Update:
+			summary.Update++
+		case opsmodels.Delete:
+			summary.Delete++
+		case opsmodels.UnChange:
+			summary.UnChange++
+		}
+	}
+	return summary
+}
+
+// Event is the payload of generic webhooks
+type Event struct {
+	// Operation is apply or destroy
+	Operation string    `json:"operation"`
+	Phase     Phase     `json:"phase"`
+	Project   string    `json:"project"`
+	Stack     string    `json:"stack"`
+	Operator  string    `json:"operator,omitempty"`
+	Summary   Summary   `json:"summary"`
+	Error     string    `json:"error,omitempty"`
+	Duration  string    `json:"duration,omitempty"`
+	Time      time.Time `json:"time"`
+
+	// ResourceID and Action are the resource and the action of resource events, which are only sent to
+	// CloudEvents sinks
+	ResourceID string `json:"resourceId,omitempty"`
+	Action     string `json:"action,omitempty"`
+}
+
+// Name returns the name of the event, e.g. apply-success
+func (e *Event) Name() string {
+	return e.Operation + "-" + string(e.Phase)
+}
+
+// Text returns the message of the event sent to chat robots
+func (e *Event) Text() string {
+	b := &strings.Builder{}
+	switch e.Phase {
+	case PhaseStart:
+		fmt.Fprintf(b, "Kusion %s of %s/%s started", e.Operation, e.Project, e.Stack)
+	case PhaseSuccess:
+		fmt.Fprintf(b, "Kusion %s of %s/%s succeeded", e.Operation, e.Project, e.Stack)
+	default:
+		fmt.Fprintf(b, "Kusion %s of %s/%s failed", e.Operation, e.Project, e.Stack)
+	}
+	if e.Operator != "" {
+		fmt.Fprintf(b, " by %s", e.Operator)
+	}
+	if e.Duration != "" {
+		fmt.Fprintf(b, " in %s", e.Duration)
+	}
+	fmt.Fprintf(b, "\nResources: %d to create, %d to update, %d to delete, %d unchanged",
+		e.Summary.Create, e.Summary.Update, e.Summary.Delete, e.Summary.UnChange)
+	if e.Error != "" {
+		fmt.Fprintf(b, "\nError: %s", e.Error)
+	}
+	return b.String()
+}
+
+// Notifier notifies webhooks of the project and the stack of the operation
+type Notifier struct {
+	configs []*projectstack.NotificationConfig
+	event   Event
+	start   time.Time
+
+	// resources queues resource events, which are sent in order without blocking the operation
+	resources chan *Event
+	wg        sync.WaitGroup
+	errs      []string
+}
+
+// NewNotifier returns the notifier of the operation, or nil if no webhook is configured. The operator is the
+// current user if it is empty.
+func NewNotifier(
+	operation string,
+	project *projectstack.Project,
+	stack *projectstack.Stack,
+	operator string,
+	changes *opsmodels.Changes,
+) *Notifier {
+	configs := append(append([]*projectstack.NotificationConfig{}, project.Notifications...), stack.Notifications...)
+	if len(configs) == 0 {
+		return nil
+	}
+	if operator == "" {
+		if u, err := user.Current(); err == nil {
+			operator = u.Username
+		}
+	}
+	return &Notifier{
+		configs: configs,
+		event: Event{
+			Operation: operation,
+			Project:   project.Name,
+			Stack:     stack.Name,
+			Operator:  operator,
+			Summary:   NewSummary(changes),
+		},
+	}
+}
+
+// Start notifies webhooks that the operation starts
+func (n *Notifier) Start(ctx context.Context) error {
+	if n == nil {
+		return nil
+	}
+	n.start = time.Now()
+	event := n.event
+	event.Phase = PhaseStart
+	if n.resources == nil && hasCloudEvents(n.configs) {
+		n.resources = make(chan *Event, 1024)
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			for e := range n.resources {
+				if err := Notify(ctx, n.configs, e); err != nil {
+					n.errs = append(n.errs, err.Error())
+				}
+			}
+		}()
+	}
+	return Notify(ctx, n.configs, &event)
+}
+
+// Resource notifies CloudEvents sinks that the resource of the change step is applied or fails, unless it is
+// unchanged or skipped. Events are sent in the background, and errors are returned by Finish.
+func (n *Notifier) Resource(step *opsmodels.ChangeStep, msg opsmodels.Message) {
+	if n == nil || n.resources == nil || step == nil || step.Action == opsmodels.UnChange {
+		return
+	}
+	event := n.event
+	event.ResourceID = msg.ResourceID
+	event.Action = step.Action.String()
+	event.Time = time.Now()
+	switch msg.OpResult {
+	case opsmodels.Success:
+		event.Phase = PhaseSuccess
+	case opsmodels.Failed:
+		event.Phase = PhaseFailure
+		if msg.OpErr != nil {
+			event.Error = msg.OpErr.Error()
+		}
+	default:
+		return
+	}
+	n.resources <- &event
+}
+
+// Finish notifies webhooks that the operation succeeds, or fails with the error
+func (n *Notifier) Finish(ctx context.Context, err error) error {
+	if n == nil {
+		return nil
+	}
+	event := n.event
+	event.Phase = PhaseSuccess
+	if err != nil {
+		event.Phase = PhaseFailure
+		event.Error = err.Error()
+	}
+	if !n.start.IsZero() {
+		event.Duration = time.Since(n.start).Round(time.Second).String()
+	}
+
+	// events of resources are sent before the operation finishes
+	if n.resources != nil {
+		close(n.resources)
+		n.wg.Wait()
+		n.resources = nil
+	}
+	errs := n.errs
+	if err := Notify(ctx, n.configs, &event); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func hasCloudEvents(configs []*projectstack.NotificationConfig) bool {
+	for _, c := range configs {
+		if isCloudEvents(c.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// Notify sends the event to webhooks which subscribe it. All webhooks are notified even if some of them fail.
+func Notify(ctx context.Context, configs []*projectstack.NotificationConfig, event *Event) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	var errs []string
+	for _, c := range configs {
+		if !subscribed(c, event) {
+			continue
+		}
+		if err := send(ctx, c, event); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("notify webhooks failed: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// subscribed returns true if the webhook subscribes the event. Sinks of CloudEvents subscribe names of
+// CloudEvents, e.g. resource.applied, and other webhooks are not notified of resources.
+func subscribed(c *projectstack.NotificationConfig, event *Event) bool {
+	name := event.Name()
+	if isCloudEvents(c.Type) {
+		name = CloudEventName(event)
+	} else if event.ResourceID != "" {
+		return false
+	}
+	if len(c.Events) == 0 {
+		return true
+	}
+	for _, e := range c.Events {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Payload returns the body of the request to the webhook
+func Payload(c *projectstack.NotificationConfig, event *Event) (interface{}, error) {
+	switch c.Type {
+	case TypeSlack:
+		return map[string]interface{}{"text": event.Text()}, nil
+	case TypeDingTalk:
+		return map[string]interface{}{
+			"msgtype": "text",
+			"text":    map[string]string{"content": event.Text()},
+		}, nil
+	case TypeFeishu:
+		return map[string]interface{}{
+			"msg_type": "text",
+			"content":  map[string]string{"text": event.Text()},
+		}, nil
+	case TypeWebhook, "":
+		return event, nil
+	case TypeCloudEvents:
+		return NewCloudEvent(event)
+	case TypeKafka:
+		ce, err := NewCloudEvent(event)
+		if err != nil {
+			return nil, err
+		}
+		return kafkaRecords(ce), nil
+	default:
+		return nil, fmt.Errorf("unsupported notification type %s, must be one of %s, %s, %s, %s, %s and %s",
+			c.Type, TypeSlack, TypeDingTalk, TypeFeishu, TypeWebhook, TypeCloudEvents, TypeKafka)
+	}
+}
+
+func send(ctx context.Context, c *projectstack.NotificationConfig, event *Event) error {
+	payload, err := Payload(c, event)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, os.ExpandEnv(c.URL), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("notify %s webhook failed: %w", c.Type, unwrapURLError(err))
+	}
+	switch c.Type {
+	case TypeCloudEvents:
+		req.Header.Set("Content-Type", cloudEventsContentType)
+	case TypeKafka:
+		req.Header.Set("Content-Type", kafkaRESTContentType)
+	default:
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range c.Headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// the URL may contain tokens, so it is not included in the error
+		return fmt.Errorf("notify %s webhook failed: %w", c.Type, unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notify %s webhook failed: %s %s", c.Type, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return checkResponse(c.Type, body)
+}
+
+// checkResponse checks error codes in responses of DingTalk and Feishu robots, which respond 200 even if
+// the message is rejected
+func checkResponse(typ string, body []byte) error {
+	var result struct {
+		ErrCode *int   `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+		Code    *int   `json:"code"`
+		Msg     string `json:"msg"`
+	}
+	if json.Unmarshal(body, &result) != nil {
+		return nil
+	}
+	switch {
+	case typ == TypeDingTalk && result.ErrCode != nil && *result.ErrCode != 0:
+		return fmt.Errorf("notify %s webhook failed: %d %s", typ, *result.ErrCode, result.ErrMsg)
+	case typ == TypeFeishu && result.Code != nil && *result.Code != 0:
+		return fmt.Errorf("notify %s webhook failed: %d %s", typ, *result.Code, result.Msg)
+	}
+	return nil
+}
+
+func unwrapURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
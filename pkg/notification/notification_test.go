@@ -0,0 +1,120 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+type request struct {
+	path   string
+	header http.Header
+	body   map[string]interface{}
+}
+
+func newServer(t *testing.T, response string) (*httptest.Server, *[]request) {
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body := map[string]interface{}{}
+		assert.Nil(t, json.Unmarshal(data, &body))
+		requests = append(requests, request{path: r.URL.Path, header: r.Header, body: body})
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestNotifier(t *testing.T) {
+	server, requests := newServer(t, `{"errcode":0,"code":0}`)
+	t.Setenv("WEBHOOK_TOKEN", "token")
+
+	project := &projectstack.Project{ProjectConfiguration: projectstack.ProjectConfiguration{
+		Name: "project",
+		Notifications: []*projectstack.NotificationConfig{
+			{Type: TypeSlack, URL: server.URL + "/slack", Events: []string{"apply-failure"}},
+			{Type: TypeDingTalk, URL: server.URL + "/dingtalk"},
+		},
+	}}
+	stack := &projectstack.Stack{StackConfiguration: projectstack.StackConfiguration{
+		Name: "dev",
+		Notifications: []*projectstack.NotificationConfig{
+			{Type: TypeWebhook, URL: server.URL + "/webhook", Headers: map[string]string{"Authorization": "Bearer ${WEBHOOK_TOKEN}"}},
+		},
+	}}
+	changes := opsmodels.NewChanges(project, stack, &opsmodels.ChangeOrder{
+		StepKeys: []string{"a", "b"},
+		ChangeSteps: map[string]*opsmodels.ChangeStep{
+			"a": {ID: "a", Action: opsmodels.Create},
+			"b": {ID: "b", Action: opsmodels.Update},
+		},
+	})
+
+	n := NewNotifier("apply", project, stack, "alice", changes)
+	assert.Nil(t, n.Start(context.Background()))
+	assert.Nil(t, n.Finish(context.Background(), errors.New("apply failed")))
+
+	paths := make([]string, 0, len(*requests))
+	for _, r := range *requests {
+		paths = append(paths, r.path)
+	}
+	assert.Equal(t, []string{"/dingtalk", "/webhook", "/slack", "/dingtalk", "/webhook"}, paths)
+
+	start := (*requests)[1]
+	assert.Equal(t, "Bearer token", start.header.Get("Authorization"))
+	assert.Equal(t, "start", start.body["phase"])
+	assert.Equal(t, map[string]interface{}{"create": float64(1), "update": float64(1), "delete": float64(0), "unchange": float64(0)},
+		start.body["summary"])
+
+	slack := (*requests)[2]
+	assert.Contains(t, slack.body["text"], "Kusion apply of project/dev failed by alice")
+	assert.Contains(t, slack.body["text"], "Error: apply failed")
+
+	dingtalk := (*requests)[3]
+	assert.Equal(t, "text", dingtalk.body["msgtype"])
+}
+
+func TestNewNotifier(t *testing.T) {
+	project := &projectstack.Project{}
+	stack := &projectstack.Stack{}
+	n := NewNotifier("destroy", project, stack, "", nil)
+	assert.Nil(t, n)
+	assert.Nil(t, n.Start(context.Background()))
+	assert.Nil(t, n.Finish(context.Background(), nil))
+}
+
+func TestNotify(t *testing.T) {
+	t.Run("rejected by robots", func(t *testing.T) {
+		server, _ := newServer(t, `{"code":19001,"msg":"param invalid"}`)
+		err := Notify(context.Background(), []*projectstack.NotificationConfig{
+			{Type: TypeFeishu, URL: server.URL},
+		}, &Event{Operation: "apply", Phase: PhaseSuccess})
+		assert.EqualError(t, err, "notify webhooks failed: notify feishu webhook failed: 19001 param invalid")
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		err := Notify(context.Background(), []*projectstack.NotificationConfig{
+			{Type: "email", URL: "http://localhost"},
+		}, &Event{Operation: "apply", Phase: PhaseSuccess})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+		err := Notify(context.Background(), []*projectstack.NotificationConfig{{URL: server.URL}},
+			&Event{Operation: "destroy", Phase: PhaseStart})
+		assert.ErrorContains(t, err, "403 Forbidden")
+	})
+}
@@ -0,0 +1,92 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	applycmd "kusionstack.io/kusion/pkg/cmd/apply"
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/engine/backend"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// Operator is the operator recorded in states of stacks applied by the operator
+const Operator = "kusion-operator"
+
+// Applier compiles and applies stacks
+type Applier interface {
+	// Apply compiles the stack in the directory and applies it
+	Apply(ctx context.Context, dir string) (*ApplyResult, error)
+}
+
+// ApplyResult is the result of applying a stack
+type ApplyResult struct {
+	// Resources is the number of resources in the stack
+	Resources int
+
+	// Changed is the number of resources changed, unchanged stacks are not applied
+	Changed int
+}
+
+// EngineApplier compiles and applies stacks with the engine, the same as kusion apply --yes
+type EngineApplier struct{}
+
+var _ Applier = (*EngineApplier)(nil)
+
+// Apply compiles the stack, and applies it through the same gates as kusion apply --yes if any resource is
+// changed. Stacks which can only be applied from signed spec files are not applied, since there are no
+// signatures in repositories.
+func (a *EngineApplier) Apply(ctx context.Context, dir string) (*ApplyResult, error) {
+	project, stack, err := projectstack.DetectProjectAndStack(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !stack.Verification.IsEmpty() {
+		return nil, fmt.Errorf("the stack %s can only be applied from a signed spec file", stack.Name)
+	}
+	sp, err := spec.GenerateSpec(&generator.Options{WorkDir: dir}, project, stack)
+	if err != nil {
+		return nil, err
+	}
+	storage, err := backend.BackendFromConfig(project.Backend, backend.BackendOps{}, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	o := applycmd.NewApplyOptions()
+	o.WorkDir = dir
+	o.Operator = Operator
+	o.NoStyle = true
+	o.Yes = true
+	if o.RuntimeEnv, err = runtime.StackEnv(ctx, project, stack); err != nil {
+		return nil, err
+	}
+	changes, err := applycmd.Prepare(o, storage, sp, project, stack)
+	if err != nil {
+		return nil, err
+	}
+	result := &ApplyResult{Resources: len(sp.Resources)}
+	for _, step := range changes.Values() {
+		if step.Action != opsmodels.UnChange {
+			result.Changed++
+		}
+	}
+	if result.Changed == 0 {
+		return result, nil
+	}
+
+	o.OnMessage = func(_ *opsmodels.ChangeStep, msg opsmodels.Message) {
+		if msg.OpErr != nil {
+			log.Errorf("apply %s of the stack %s failed: %v", msg.ResourceID, stack.Name, msg.OpErr)
+		}
+	}
+	if _, err = applycmd.Execute(o, storage, sp, changes, io.Discard); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
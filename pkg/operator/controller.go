@@ -0,0 +1,149 @@
+// Package operator implements the Kusion operator, which reconciles Stack resources by fetching their sources
+// from git, then compiling and applying them in the cluster continuously.
+package operator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"kusionstack.io/kusion/pkg/apis/kusionstack/v1alpha1"
+	"kusionstack.io/kusion/pkg/log"
+)
+
+// StackReconciler reconciles Stack resources
+type StackReconciler struct {
+	client.Client
+
+	Fetcher  Fetcher
+	Applier  Applier
+	Recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=kusionstack.io,resources=stacks,verbs=get;list;watch
+//+kubebuilder:rbac:groups=kusionstack.io,resources=stacks/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile fetches the source of the stack, compiles and applies it, and reports the result by conditions of
+// the stack. Stacks are reconciled again after their intervals, which applies new commits of their sources and
+// corrects drifts of their resources.
+func (r *StackReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	stack := &v1alpha1.Stack{}
+	if err := r.Get(ctx, req.NamespacedName, stack); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if stack.Spec.Suspend {
+		r.setCondition(stack, v1alpha1.ConditionReconciling, metav1.ConditionFalse, v1alpha1.ReasonSuspended,
+			"reconciliation is suspended")
+		return ctrl.Result{}, r.updateStatus(ctx, stack)
+	}
+
+	interval := stack.GetInterval().Duration
+	r.setCondition(stack, v1alpha1.ConditionReconciling, metav1.ConditionTrue, v1alpha1.ReasonProgressing,
+		"fetching the source")
+	if err := r.updateStatus(ctx, stack); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	credentials, err := r.credentials(ctx, stack)
+	if err != nil {
+		return r.fail(ctx, stack, v1alpha1.ReasonFetchFailed, err, interval)
+	}
+	dir, commit, release, err := r.Fetcher.Fetch(ctx, &stack.Spec.Source, credentials)
+	if err != nil {
+		return r.fail(ctx, stack, v1alpha1.ReasonFetchFailed, err, interval)
+	}
+	defer release()
+	stack.Status.LastAttemptedRevision = commit
+
+	log.Infof("apply the stack %s at %s", req.NamespacedName, commit)
+	result, err := r.Applier.Apply(ctx, dir)
+	if err != nil {
+		return r.fail(ctx, stack, v1alpha1.ReasonApplyFailed, fmt.Errorf("apply %s failed: %w", commit, err), interval)
+	}
+
+	now := metav1.Now()
+	stack.Status.LastAppliedRevision = commit
+	stack.Status.LastAppliedTime = &now
+	stack.Status.Resources = result.Resources
+	stack.Status.ObservedGeneration = stack.Generation
+	message := fmt.Sprintf("applied %s, %d of %d resources changed", commit, result.Changed, result.Resources)
+	r.setCondition(stack, v1alpha1.ConditionReady, metav1.ConditionTrue, v1alpha1.ReasonApplied, message)
+	r.setCondition(stack, v1alpha1.ConditionReconciling, metav1.ConditionFalse, v1alpha1.ReasonApplied, message)
+	if result.Changed > 0 {
+		r.event(stack, corev1.EventTypeNormal, v1alpha1.ReasonApplied, message)
+	}
+	return ctrl.Result{RequeueAfter: interval}, r.updateStatus(ctx, stack)
+}
+
+// fail reports the failure by conditions and events of the stack, and retries after the interval
+func (r *StackReconciler) fail(
+	ctx context.Context,
+	stack *v1alpha1.Stack,
+	reason string,
+	err error,
+	interval time.Duration,
+) (ctrl.Result, error) {
+	log.Errorf("reconcile the stack %s/%s failed: %v", stack.Namespace, stack.Name, err)
+	stack.Status.ObservedGeneration = stack.Generation
+	r.setCondition(stack, v1alpha1.ConditionReady, metav1.ConditionFalse, reason, err.Error())
+	r.setCondition(stack, v1alpha1.ConditionReconciling, metav1.ConditionFalse, reason, err.Error())
+	r.event(stack, corev1.EventTypeWarning, reason, err.Error())
+	return ctrl.Result{RequeueAfter: interval}, r.updateStatus(ctx, stack)
+}
+
+// credentials reads the username and password in the secret of the source if any
+func (r *StackReconciler) credentials(ctx context.Context, stack *v1alpha1.Stack) (*Credentials, error) {
+	ref := stack.Spec.Source.SecretRef
+	if ref == nil {
+		return nil, nil
+	}
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: stack.Namespace, Name: ref.Name}, secret); err != nil {
+		return nil, fmt.Errorf("get the secret %s of the source failed: %w", ref.Name, err)
+	}
+	return &Credentials{Username: string(secret.Data["username"]), Password: string(secret.Data["password"])}, nil
+}
+
+func (r *StackReconciler) setCondition(stack *v1alpha1.Stack, t string, s metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&stack.Status.Conditions, metav1.Condition{
+		Type:               t,
+		Status:             s,
+		ObservedGeneration: stack.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+func (r *StackReconciler) updateStatus(ctx context.Context, stack *v1alpha1.Stack) error {
+	if err := r.Status().Update(ctx, stack); err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (r *StackReconciler) event(stack *v1alpha1.Stack, eventType, reason, message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(stack, eventType, reason, message)
+	}
+}
+
+// SetupWithManager sets up the controller with the manager. Updates of statuses do not trigger reconciles,
+// since they change no generation.
+func (r *StackReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.Stack{}).
+		WithEventFilter(predicate.GenerationChangedPredicate{}).
+		Complete(r)
+}
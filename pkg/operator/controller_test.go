@@ -0,0 +1,161 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kusionstack.io/kusion/pkg/apis/kusionstack/v1alpha1"
+)
+
+type fakeFetcher struct {
+	credentials *Credentials
+	released    bool
+	err         error
+}
+
+func (f *fakeFetcher) Fetch(_ context.Context, source *v1alpha1.GitSource, credentials *Credentials) (string, string, func(), error) {
+	f.credentials = credentials
+	if f.err != nil {
+		return "", "", nil, f.err
+	}
+	return "/cache/" + source.Path, "abc123", func() { f.released = true }, nil
+}
+
+type fakeApplier struct {
+	dir    string
+	result *ApplyResult
+	err    error
+}
+
+func (a *fakeApplier) Apply(_ context.Context, dir string) (*ApplyResult, error) {
+	a.dir = dir
+	return a.result, a.err
+}
+
+func newStack() *v1alpha1.Stack {
+	return &v1alpha1.Stack{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev", Namespace: "default", Generation: 2},
+		Spec: v1alpha1.StackSpec{
+			Source: v1alpha1.GitSource{
+				URL:       "https://github.com/KusionStack/konfig.git",
+				Path:      "appops/guestbook/dev",
+				SecretRef: &corev1.LocalObjectReference{Name: "git"},
+			},
+			Interval: &metav1.Duration{Duration: time.Minute},
+		},
+	}
+}
+
+func newReconciler(t *testing.T, fetcher Fetcher, applier Applier, objs ...client.Object) (*StackReconciler, *record.FakeRecorder) {
+	scheme, err := NewScheme()
+	require.NoError(t, err)
+	recorder := record.NewFakeRecorder(10)
+	return &StackReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+		Fetcher:  fetcher,
+		Applier:  applier,
+		Recorder: recorder,
+	}, recorder
+}
+
+func getStack(t *testing.T, r *StackReconciler) *v1alpha1.Stack {
+	stack := &v1alpha1.Stack{}
+	require.NoError(t, r.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "dev"}, stack))
+	return stack
+}
+
+var request = ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "dev"}}
+
+func TestStackReconciler_Reconcile(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "git", Namespace: "default"},
+		Data:       map[string][]byte{"username": []byte("bot"), "password": []byte("token")},
+	}
+
+	t.Run("applied", func(t *testing.T) {
+		fetcher := &fakeFetcher{}
+		applier := &fakeApplier{result: &ApplyResult{Resources: 3, Changed: 1}}
+		r, recorder := newReconciler(t, fetcher, applier, newStack(), secret)
+
+		result, err := r.Reconcile(context.Background(), request)
+		require.NoError(t, err)
+		assert.Equal(t, time.Minute, result.RequeueAfter)
+		assert.Equal(t, &Credentials{Username: "bot", Password: "token"}, fetcher.credentials)
+		assert.Equal(t, "/cache/appops/guestbook/dev", applier.dir)
+		assert.True(t, fetcher.released)
+
+		stack := getStack(t, r)
+		assert.Equal(t, "abc123", stack.Status.LastAppliedRevision)
+		assert.Equal(t, "abc123", stack.Status.LastAttemptedRevision)
+		assert.Equal(t, 3, stack.Status.Resources)
+		assert.Equal(t, int64(2), stack.Status.ObservedGeneration)
+		assert.NotNil(t, stack.Status.LastAppliedTime)
+		assert.True(t, meta.IsStatusConditionTrue(stack.Status.Conditions, v1alpha1.ConditionReady))
+		assert.True(t, meta.IsStatusConditionFalse(stack.Status.Conditions, v1alpha1.ConditionReconciling))
+		assert.Contains(t, <-recorder.Events, "1 of 3 resources changed")
+	})
+
+	t.Run("apply failed", func(t *testing.T) {
+		applier := &fakeApplier{err: errors.New("quota exceeded")}
+		r, recorder := newReconciler(t, &fakeFetcher{}, applier, newStack(), secret)
+
+		result, err := r.Reconcile(context.Background(), request)
+		require.NoError(t, err)
+		assert.Equal(t, time.Minute, result.RequeueAfter)
+
+		stack := getStack(t, r)
+		assert.Equal(t, "abc123", stack.Status.LastAttemptedRevision)
+		assert.Empty(t, stack.Status.LastAppliedRevision)
+		ready := meta.FindStatusCondition(stack.Status.Conditions, v1alpha1.ConditionReady)
+		require.NotNil(t, ready)
+		assert.Equal(t, metav1.ConditionFalse, ready.Status)
+		assert.Equal(t, v1alpha1.ReasonApplyFailed, ready.Reason)
+		assert.Contains(t, ready.Message, "quota exceeded")
+		assert.Contains(t, <-recorder.Events, "Warning ApplyFailed")
+	})
+
+	t.Run("secret not found", func(t *testing.T) {
+		r, _ := newReconciler(t, &fakeFetcher{}, &fakeApplier{}, newStack())
+
+		_, err := r.Reconcile(context.Background(), request)
+		require.NoError(t, err)
+		ready := meta.FindStatusCondition(getStack(t, r).Status.Conditions, v1alpha1.ConditionReady)
+		require.NotNil(t, ready)
+		assert.Equal(t, v1alpha1.ReasonFetchFailed, ready.Reason)
+	})
+
+	t.Run("suspended", func(t *testing.T) {
+		stack := newStack()
+		stack.Spec.Suspend = true
+		applier := &fakeApplier{}
+		r, _ := newReconciler(t, &fakeFetcher{}, applier, stack)
+
+		result, err := r.Reconcile(context.Background(), request)
+		require.NoError(t, err)
+		assert.Zero(t, result.RequeueAfter)
+		assert.Empty(t, applier.dir)
+		reconciling := meta.FindStatusCondition(getStack(t, r).Status.Conditions, v1alpha1.ConditionReconciling)
+		require.NotNil(t, reconciling)
+		assert.Equal(t, v1alpha1.ReasonSuspended, reconciling.Reason)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		r, _ := newReconciler(t, &fakeFetcher{}, &fakeApplier{})
+		result, err := r.Reconcile(context.Background(), request)
+		assert.NoError(t, err)
+		assert.Zero(t, result.RequeueAfter)
+	})
+}
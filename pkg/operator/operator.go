@@ -0,0 +1,85 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+
+	"kusionstack.io/kusion/pkg/apis/kusionstack/v1alpha1"
+)
+
+// LeaderElectionID is the name of the lease of the leader, only the leader reconciles stacks
+const LeaderElectionID = "kusion-operator.kusionstack.io"
+
+// Options are options of the operator
+type Options struct {
+	// CacheDir is the directory to keep clones of repositories in
+	CacheDir string
+
+	// Namespace restricts the operator to stacks in the namespace, all namespaces if empty
+	Namespace string
+
+	// MetricsAddr and HealthAddr are addresses to serve metrics and health probes at, "0" disables them
+	MetricsAddr string
+	HealthAddr  string
+
+	// LeaderElect enables leader election, so that replicas of the operator do not apply stacks concurrently
+	LeaderElect bool
+}
+
+// NewScheme returns the scheme of types reconciled by the operator
+func NewScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	return scheme, nil
+}
+
+// Run runs the operator in the cluster of the current kube config or the in-cluster config, until the
+// context is done
+func Run(ctx context.Context, o *Options) error {
+	scheme, err := NewScheme()
+	if err != nil {
+		return err
+	}
+	cfg, err := ctrl.GetConfig()
+	if err != nil {
+		return fmt.Errorf("get the config of the cluster failed: %w", err)
+	}
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:                 scheme,
+		Namespace:              o.Namespace,
+		MetricsBindAddress:     o.MetricsAddr,
+		HealthProbeBindAddress: o.HealthAddr,
+		LeaderElection:         o.LeaderElect,
+		LeaderElectionID:       LeaderElectionID,
+	})
+	if err != nil {
+		return fmt.Errorf("create the manager failed: %w", err)
+	}
+
+	reconciler := &StackReconciler{
+		Client:   mgr.GetClient(),
+		Fetcher:  NewGitFetcher(o.CacheDir),
+		Applier:  &EngineApplier{},
+		Recorder: mgr.GetEventRecorderFor("kusion-operator"),
+	}
+	if err = reconciler.SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("create the controller of stacks failed: %w", err)
+	}
+	if err = mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		return err
+	}
+	if err = mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+		return err
+	}
+	return mgr.Start(ctx)
+}
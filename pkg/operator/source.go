@@ -0,0 +1,169 @@
+package operator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+
+	"kusionstack.io/kusion/pkg/apis/kusionstack/v1alpha1"
+)
+
+// Fetcher fetches sources of stacks
+type Fetcher interface {
+	// Fetch checks out the revision of the source with the credentials which may be nil, and returns the
+	// directory of the stack and the commit checked out. The directory must not be used after calling release.
+	Fetch(ctx context.Context, source *v1alpha1.GitSource, credentials *Credentials) (dir, commit string, release func(), err error)
+}
+
+// Credentials are the username and password to clone private repositories over HTTPS
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// refSpecs fetches all branches, tags and the default branch of the remote
+var refSpecs = []config.RefSpec{
+	"+refs/heads/*:refs/remotes/origin/*",
+	"+refs/tags/*:refs/tags/*",
+	"+HEAD:refs/remotes/origin/HEAD",
+}
+
+// GitFetcher keeps a clone of each repository in the cache directory, which is fetched incrementally instead
+// of cloned again on each reconcile
+type GitFetcher struct {
+	CacheDir string
+
+	// locks of repositories, since stacks in the same repository share the clone
+	mu    sync.Mutex
+	repos map[string]*sync.Mutex
+}
+
+// NewGitFetcher returns a fetcher keeping clones in the cache directory
+func NewGitFetcher(cacheDir string) *GitFetcher {
+	return &GitFetcher{CacheDir: cacheDir, repos: map[string]*sync.Mutex{}}
+}
+
+// Fetch fetches the repository and checks out the revision, which is a branch, a tag or a commit. The default
+// branch of the repository is checked out if the revision is not specified.
+//
+// The clone is shared by stacks in the same repository, so it is locked until release is called, otherwise
+// another stack may check out another revision while the stack is applied.
+func (f *GitFetcher) Fetch(
+	ctx context.Context,
+	source *v1alpha1.GitSource,
+	credentials *Credentials,
+) (string, string, func(), error) {
+	if source.URL == "" {
+		return "", "", nil, fmt.Errorf("url of the source is required")
+	}
+	release := f.lock(source.URL)
+	dir, commit, err := f.checkout(ctx, source, credentials)
+	if err != nil {
+		release()
+		return "", "", nil, err
+	}
+	return dir, commit, release, nil
+}
+
+func (f *GitFetcher) checkout(
+	ctx context.Context,
+	source *v1alpha1.GitSource,
+	credentials *Credentials,
+) (string, string, error) {
+	sum := sha256.Sum256([]byte(source.URL))
+	repoDir := filepath.Join(f.CacheDir, hex.EncodeToString(sum[:8]))
+	dir := filepath.Join(repoDir, filepath.Clean("/"+source.Path))
+
+	repo, err := openOrInit(repoDir, source.URL)
+	if err != nil {
+		return "", "", err
+	}
+	var auth transport.AuthMethod
+	if credentials != nil {
+		auth = &http.BasicAuth{Username: credentials.Username, Password: credentials.Password}
+	}
+	err = repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   refSpecs,
+		Auth:       auth,
+		Force:      true,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return "", "", fmt.Errorf("fetch %s failed: %w", source.URL, err)
+	}
+
+	hash, err := resolveRevision(repo, source.Revision)
+	if err != nil {
+		return "", "", err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", "", err
+	}
+	if err = worktree.Checkout(&git.CheckoutOptions{Hash: *hash, Force: true}); err != nil {
+		return "", "", fmt.Errorf("checkout %s of %s failed: %w", hash, source.URL, err)
+	}
+	if _, err = os.Stat(dir); err != nil {
+		return "", "", fmt.Errorf("path %s is not found in %s at %s", source.Path, source.URL, hash)
+	}
+	return dir, hash.String(), nil
+}
+
+// lock locks the clone of the repository, and returns the function to unlock it
+func (f *GitFetcher) lock(url string) func() {
+	f.mu.Lock()
+	l, ok := f.repos[url]
+	if !ok {
+		l = &sync.Mutex{}
+		f.repos[url] = l
+	}
+	f.mu.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+func openOrInit(dir, url string) (*git.Repository, error) {
+	repo, err := git.PlainOpen(dir)
+	if err == nil {
+		return repo, nil
+	}
+	if !errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, fmt.Errorf("open the clone of %s failed: %w", url, err)
+	}
+	if repo, err = git.PlainInit(dir, false); err != nil {
+		return nil, fmt.Errorf("init the clone of %s failed: %w", url, err)
+	}
+	if _, err = repo.CreateRemote(&config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{url}}); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// resolveRevision resolves the revision as a branch, a tag, then a commit
+func resolveRevision(repo *git.Repository, revision string) (*plumbing.Hash, error) {
+	if revision == "" {
+		revision = "HEAD"
+	}
+	candidates := []string{"refs/remotes/origin/" + revision, "refs/tags/" + revision}
+	if revision != "HEAD" {
+		candidates = append(candidates, revision)
+	}
+	for _, c := range candidates {
+		if hash, err := repo.ResolveRevision(plumbing.Revision(c)); err == nil {
+			return hash, nil
+		}
+	}
+	return nil, fmt.Errorf("revision %s is not found", strings.TrimSpace(revision))
+}
@@ -0,0 +1,81 @@
+package operator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+
+	"kusionstack.io/kusion/pkg/apis/kusionstack/v1alpha1"
+)
+
+// commit writes the file in the repository and commits it
+func commit(t *testing.T, repo *git.Repository, dir, file, content string) plumbing.Hash {
+	path := filepath.Join(dir, file)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = worktree.Add(file)
+	require.NoError(t, err)
+	hash, err := worktree.Commit("update "+file, &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@kusionstack.io", When: time.Now()},
+	})
+	require.NoError(t, err)
+	return hash
+}
+
+func TestGitFetcher_Fetch(t *testing.T) {
+	origin := t.TempDir()
+	repo, err := git.PlainInit(origin, false)
+	require.NoError(t, err)
+	first := commit(t, repo, origin, "demo/dev/main.k", "v1")
+	_, err = repo.CreateTag("v1", first, nil)
+	require.NoError(t, err)
+	second := commit(t, repo, origin, "demo/dev/main.k", "v2")
+
+	f := NewGitFetcher(t.TempDir())
+	fetch := func(revision, path string) (string, string, error) {
+		dir, commit, release, err := f.Fetch(context.Background(),
+			&v1alpha1.GitSource{URL: origin, Revision: revision, Path: path}, nil)
+		if err != nil {
+			return "", "", err
+		}
+		defer release()
+		data, err := os.ReadFile(filepath.Join(dir, "main.k"))
+		return string(data), commit, err
+	}
+
+	content, hash, err := fetch("", "demo/dev")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", content)
+	assert.Equal(t, second.String(), hash)
+
+	content, hash, err = fetch("v1", "demo/dev")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", content)
+	assert.Equal(t, first.String(), hash)
+
+	// new commits are fetched into the same clone
+	third := commit(t, repo, origin, "demo/dev/main.k", "v3")
+	content, hash, err = fetch("master", "demo/dev")
+	require.NoError(t, err)
+	assert.Equal(t, "v3", content)
+	assert.Equal(t, third.String(), hash)
+
+	content, _, err = fetch(first.String(), "demo/dev")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", content)
+
+	_, _, err = fetch("missing", "demo/dev")
+	assert.ErrorContains(t, err, "revision missing is not found")
+	_, _, err = fetch("", "demo/prod")
+	assert.ErrorContains(t, err, "path demo/prod is not found")
+}
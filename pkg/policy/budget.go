@@ -0,0 +1,45 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"kusionstack.io/kusion/pkg/cost"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// RuleBudget is the rule of violations of budgets
+const RuleBudget = "budget"
+
+// BudgetChecker estimates the monthly cost of the stack after changes, which is the cost of resources in
+// the spec, and denies the changes if it exceeds the budget
+type BudgetChecker struct {
+	// Budget is the monthly budget ceiling of the stack
+	Budget float64
+	// Currency of prices and the budget
+	Currency string
+	// Prices are monthly prices of resources
+	Prices *projectstack.CostPrices
+}
+
+var _ Checker = (*BudgetChecker)(nil)
+
+func (c *BudgetChecker) Check(input *Input) (*Result, error) {
+	estimate, err := cost.EstimateResources(input.Resources, c.Prices)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{}
+	if estimate.Total > c.Budget {
+		result.Denies = append(result.Denies, Violation{
+			Rule: RuleBudget,
+			Message: fmt.Sprintf("estimated monthly cost %s of the stack %s exceeds its budget %s",
+				c.format(estimate.Total), input.Stack, c.format(c.Budget)),
+		})
+	}
+	return result, nil
+}
+
+func (c *BudgetChecker) format(amount float64) string {
+	return strings.TrimSpace(fmt.Sprintf("%.2f %s", amount, c.Currency))
+}
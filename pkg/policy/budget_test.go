@@ -0,0 +1,33 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func TestBudgetChecker_Check(t *testing.T) {
+	input := &Input{
+		Stack: "dev",
+		Resources: models.Resources{
+			{ID: "vm", Type: runtime.Terraform, Extensions: map[string]interface{}{"resourceType": "alicloud_instance"}},
+			{ID: "vpc", Type: runtime.Terraform, Extensions: map[string]interface{}{"resourceType": "alicloud_vpc"}},
+		},
+	}
+	prices := &projectstack.CostPrices{Resources: map[string]float64{"alicloud_instance": 120}}
+
+	result, err := (&BudgetChecker{Budget: 200, Currency: "USD", Prices: prices}).Check(input)
+	assert.Nil(t, err)
+	assert.Equal(t, &Result{}, result)
+
+	result, err = (&BudgetChecker{Budget: 100, Currency: "USD", Prices: prices}).Check(input)
+	assert.Nil(t, err)
+	assert.Equal(t, []Violation{{
+		Rule:    RuleBudget,
+		Message: "estimated monthly cost 120.00 USD of the stack dev exceeds its budget 100.00 USD",
+	}}, result.Denies)
+}
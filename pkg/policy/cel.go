@@ -0,0 +1,641 @@
+package policy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/cel/library"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+// Kinds of admission policies loaded by CELChecker
+const (
+	ValidatingAdmissionPolicyKind        = "ValidatingAdmissionPolicy"
+	ValidatingAdmissionPolicyBindingKind = "ValidatingAdmissionPolicyBinding"
+)
+
+// Validation actions of ValidatingAdmissionPolicyBindings
+const (
+	ActionDeny  = "Deny"
+	ActionWarn  = "Warn"
+	ActionAudit = "Audit"
+)
+
+// Operations of admission requests
+const (
+	OperationCreate = "CREATE"
+	OperationUpdate = "UPDATE"
+	OperationDelete = "DELETE"
+)
+
+// ValidatingAdmissionPolicy is the subset of the Kubernetes ValidatingAdmissionPolicy evaluated locally
+type ValidatingAdmissionPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ValidatingAdmissionPolicySpec `json:"spec"`
+}
+
+type ValidatingAdmissionPolicySpec struct {
+	MatchConstraints *MatchResources  `json:"matchConstraints,omitempty"`
+	MatchConditions  []MatchCondition `json:"matchConditions,omitempty"`
+	Variables        []Variable       `json:"variables,omitempty"`
+	Validations      []Validation     `json:"validations,omitempty"`
+	// FailurePolicy is Fail or Ignore, and decides whether an evaluation error is a violation
+	FailurePolicy string `json:"failurePolicy,omitempty"`
+}
+
+// MatchResources decides which resources are validated. The namespaceSelector is not supported,
+// since labels of live namespaces are unknown before apply.
+type MatchResources struct {
+	ObjectSelector       *metav1.LabelSelector     `json:"objectSelector,omitempty"`
+	ResourceRules        []NamedRuleWithOperations `json:"resourceRules,omitempty"`
+	ExcludeResourceRules []NamedRuleWithOperations `json:"excludeResourceRules,omitempty"`
+}
+
+type NamedRuleWithOperations struct {
+	ResourceNames []string `json:"resourceNames,omitempty"`
+	Operations    []string `json:"operations,omitempty"`
+	APIGroups     []string `json:"apiGroups,omitempty"`
+	APIVersions   []string `json:"apiVersions,omitempty"`
+	Resources     []string `json:"resources,omitempty"`
+	// Scope is Cluster, Namespaced or *
+	Scope string `json:"scope,omitempty"`
+}
+
+type MatchCondition struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+}
+
+type Variable struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+}
+
+type Validation struct {
+	Expression        string `json:"expression"`
+	Message           string `json:"message,omitempty"`
+	MessageExpression string `json:"messageExpression,omitempty"`
+	Reason            string `json:"reason,omitempty"`
+}
+
+// ValidatingAdmissionPolicyBinding is the subset of the Kubernetes ValidatingAdmissionPolicyBinding
+// evaluated locally
+type ValidatingAdmissionPolicyBinding struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ValidatingAdmissionPolicyBindingSpec `json:"spec"`
+}
+
+type ValidatingAdmissionPolicyBindingSpec struct {
+	PolicyName        string          `json:"policyName"`
+	MatchResources    *MatchResources `json:"matchResources,omitempty"`
+	ValidationActions []string        `json:"validationActions,omitempty"`
+}
+
+// CELChecker evaluates Kubernetes ValidatingAdmissionPolicies against Kubernetes resources of the spec
+// with the same semantics as the API server, so that violations surface before the apply. Each resource
+// is validated as the admission request of its planned change, and unchanged resources are skipped. If
+// the input has no change, all resources are validated as created.
+//
+// Policies are bound by ValidatingAdmissionPolicyBindings in the same files, and policies without any
+// binding are checked as if bound with the Deny action. Params and the authorizer are not supported,
+// the params variable is always null. The URL, regex and list libraries of Kubernetes are available, while
+// libraries added after Kubernetes 1.24, e.g. quantity and IP libraries, are not.
+type CELChecker struct {
+	// Paths are YAML files or directories of policies and their bindings
+	Paths []string
+}
+
+var _ Checker = (*CELChecker)(nil)
+
+func (c *CELChecker) Check(input *Input) (*Result, error) {
+	if len(c.Paths) == 0 {
+		return &Result{}, nil
+	}
+	policies, bindings, err := LoadAdmissionPolicies(c.Paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := newCELEnv()
+	if err != nil {
+		return nil, err
+	}
+	compiled := make([]*compiledPolicy, 0, len(policies))
+	for _, p := range policies {
+		cp, err := compilePolicy(env, p, bindings)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, cp)
+	}
+
+	result := &Result{}
+	for _, req := range admissionRequests(input) {
+		for _, cp := range compiled {
+			result.Merge(cp.validate(req))
+		}
+	}
+	return result, nil
+}
+
+// LoadAdmissionPolicies loads ValidatingAdmissionPolicies and ValidatingAdmissionPolicyBindings from
+// YAML files, directories are walked recursively. Documents of other kinds are ignored.
+func LoadAdmissionPolicies(paths ...string) ([]*ValidatingAdmissionPolicy, []*ValidatingAdmissionPolicyBinding, error) {
+	var policies []*ValidatingAdmissionPolicy
+	var bindings []*ValidatingAdmissionPolicyBinding
+	for _, root := range paths {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			if ext := filepath.Ext(path); path != root && ext != ".yaml" && ext != ".yml" && ext != ".json" {
+				return nil
+			}
+			p, b, err := loadAdmissionPolicyFile(path)
+			if err != nil {
+				return fmt.Errorf("load admission policies from %s failed: %w", path, err)
+			}
+			policies = append(policies, p...)
+			bindings = append(bindings, b...)
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return policies, bindings, nil
+}
+
+func loadAdmissionPolicyFile(path string) ([]*ValidatingAdmissionPolicy, []*ValidatingAdmissionPolicyBinding, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	var policies []*ValidatingAdmissionPolicy
+	var bindings []*ValidatingAdmissionPolicyBinding
+	reader := yamlutil.NewYAMLReader(bufio.NewReader(f))
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		var typeMeta metav1.TypeMeta
+		if err = yamlutil.Unmarshal(doc, &typeMeta); err != nil {
+			return nil, nil, err
+		}
+		switch typeMeta.Kind {
+		case ValidatingAdmissionPolicyKind:
+			p := &ValidatingAdmissionPolicy{}
+			if err = yamlutil.Unmarshal(doc, p); err != nil {
+				return nil, nil, err
+			}
+			policies = append(policies, p)
+		case ValidatingAdmissionPolicyBindingKind:
+			b := &ValidatingAdmissionPolicyBinding{}
+			if err = yamlutil.Unmarshal(doc, b); err != nil {
+				return nil, nil, err
+			}
+			bindings = append(bindings, b)
+		}
+	}
+	return policies, bindings, nil
+}
+
+// NewCELEnv returns the CEL environment with the options, e.g. variables, and the libraries of Kubernetes,
+// e.g. isURL, matches and isSorted, as the API server. All CEL expressions of kusion, such as admission
+// policies and lint rules, are compiled in environments returned by it, so that they share the same functions.
+func NewCELEnv(options ...cel.EnvOption) (*cel.Env, error) {
+	options = append(append(options, ext.Encoders()), library.ExtensionLibs...)
+	return cel.NewEnv(options...)
+}
+
+// newCELEnv returns the CEL environment with variables of ValidatingAdmissionPolicy expressions
+func newCELEnv() (*cel.Env, error) {
+	return NewCELEnv(
+		cel.Variable("object", cel.DynType),
+		cel.Variable("oldObject", cel.DynType),
+		cel.Variable("request", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("params", cel.DynType),
+		cel.Variable("namespaceObject", cel.DynType),
+		cel.Variable("variables", cel.MapType(cel.StringType, cel.DynType)),
+	)
+}
+
+type compiledExpression struct {
+	name       string
+	expression string
+	program    cel.Program
+}
+
+type compiledValidation struct {
+	Validation
+	program        cel.Program
+	messageProgram cel.Program
+}
+
+type compiledPolicy struct {
+	policy          *ValidatingAdmissionPolicy
+	bindings        []*ValidatingAdmissionPolicyBinding
+	matchConditions []compiledExpression
+	variables       []compiledExpression
+	validations     []compiledValidation
+}
+
+func compilePolicy(
+	env *cel.Env,
+	p *ValidatingAdmissionPolicy,
+	bindings []*ValidatingAdmissionPolicyBinding,
+) (*compiledPolicy, error) {
+	compile := func(expression string) (cel.Program, error) {
+		ast, issues := env.Compile(expression)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("compile expression %q of the policy %s failed: %w", expression, p.Name, issues.Err())
+		}
+		return env.Program(ast)
+	}
+
+	cp := &compiledPolicy{policy: p}
+	for _, b := range bindings {
+		if b.Spec.PolicyName == p.Name {
+			cp.bindings = append(cp.bindings, b)
+		}
+	}
+	for _, c := range p.Spec.MatchConditions {
+		program, err := compile(c.Expression)
+		if err != nil {
+			return nil, err
+		}
+		cp.matchConditions = append(cp.matchConditions, compiledExpression{name: c.Name, expression: c.Expression, program: program})
+	}
+	for _, v := range p.Spec.Variables {
+		program, err := compile(v.Expression)
+		if err != nil {
+			return nil, err
+		}
+		cp.variables = append(cp.variables, compiledExpression{name: v.Name, expression: v.Expression, program: program})
+	}
+	for _, v := range p.Spec.Validations {
+		cv := compiledValidation{Validation: v}
+		var err error
+		if cv.program, err = compile(v.Expression); err != nil {
+			return nil, err
+		}
+		if v.MessageExpression != "" {
+			if cv.messageProgram, err = compile(v.MessageExpression); err != nil {
+				return nil, err
+			}
+		}
+		cp.validations = append(cp.validations, cv)
+	}
+	return cp, nil
+}
+
+// admissionRequest is the admission request of a planned change
+type admissionRequest struct {
+	id        string
+	operation string
+	gvk       schema.GroupVersionKind
+	name      string
+	namespace string
+	object    map[string]interface{}
+	oldObject map[string]interface{}
+}
+
+func (r *admissionRequest) resource() schema.GroupVersionResource {
+	plural, _ := meta.UnsafeGuessKindToResource(r.gvk)
+	return plural
+}
+
+// labels returns labels of the object, or the old object if it is deleted
+func (r *admissionRequest) labels() labels.Set {
+	obj := r.object
+	if obj == nil {
+		obj = r.oldObject
+	}
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	result := labels.Set{}
+	if l, ok := metadata["labels"].(map[string]interface{}); ok {
+		for k, v := range l {
+			result[k] = fmt.Sprint(v)
+		}
+	}
+	return result
+}
+
+func (r *admissionRequest) activation() map[string]interface{} {
+	resource := r.resource()
+	var object, oldObject interface{}
+	if r.object != nil {
+		object = r.object
+	}
+	if r.oldObject != nil {
+		oldObject = r.oldObject
+	}
+	return map[string]interface{}{
+		"object":    object,
+		"oldObject": oldObject,
+		"request": map[string]interface{}{
+			"operation": r.operation,
+			"name":      r.name,
+			"namespace": r.namespace,
+			"kind":      map[string]interface{}{"group": r.gvk.Group, "version": r.gvk.Version, "kind": r.gvk.Kind},
+			"resource":  map[string]interface{}{"group": resource.Group, "version": resource.Version, "resource": resource.Resource},
+			"dryRun":    true,
+		},
+		"params":          nil,
+		"namespaceObject": nil,
+		"variables":       map[string]interface{}{},
+	}
+}
+
+// admissionRequests returns admission requests of Kubernetes resources in the input
+func admissionRequests(input *Input) []*admissionRequest {
+	index := input.Resources.Index()
+	var requests []*admissionRequest
+	if len(input.Changes) == 0 {
+		for i := range input.Resources {
+			if req := newAdmissionRequest(input.Resources[i].ID, OperationCreate, &input.Resources[i], nil); req != nil {
+				requests = append(requests, req)
+			}
+		}
+		return requests
+	}
+
+	for _, c := range input.Changes {
+		var req *admissionRequest
+		switch c.Action {
+		case "Create":
+			req = newAdmissionRequest(c.ID, OperationCreate, resourceOf(c.After, index[c.ID]), nil)
+		case "Update":
+			req = newAdmissionRequest(c.ID, OperationUpdate, resourceOf(c.After, index[c.ID]), resourceOf(c.Before, nil))
+		case "Delete":
+			req = newAdmissionRequest(c.ID, OperationDelete, nil, resourceOf(c.Before, nil))
+		}
+		if req != nil {
+			requests = append(requests, req)
+		}
+	}
+	return requests
+}
+
+// resourceOf returns the resource of a change, or the default one if the change has no resource
+func resourceOf(v interface{}, defaultResource *models.Resource) *models.Resource {
+	switch r := v.(type) {
+	case *models.Resource:
+		if r != nil {
+			return r
+		}
+	case models.Resource:
+		return &r
+	}
+	return defaultResource
+}
+
+func newAdmissionRequest(id, operation string, object, oldObject *models.Resource) *admissionRequest {
+	req := &admissionRequest{id: id, operation: operation}
+	ref := object
+	if ref == nil {
+		ref = oldObject
+	}
+	if ref == nil || ref.Type != runtime.Kubernetes {
+		return nil
+	}
+	if object != nil {
+		req.object = normalize(object.Attributes).(map[string]interface{})
+	}
+	if oldObject != nil {
+		req.oldObject = normalize(oldObject.Attributes).(map[string]interface{})
+	}
+
+	attributes := ref.Attributes
+	apiVersion, _ := attributes["apiVersion"].(string)
+	kind, _ := attributes["kind"].(string)
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil || kind == "" {
+		return nil
+	}
+	req.gvk = gv.WithKind(kind)
+	if metadata, ok := attributes["metadata"].(map[string]interface{}); ok {
+		req.name, _ = metadata["name"].(string)
+		req.namespace, _ = metadata["namespace"].(string)
+	}
+	return req
+}
+
+// normalize converts whole float numbers decoded from JSON into integers, so that expressions like
+// `object.spec.replicas <= 5` are evaluated as the API server does
+func normalize(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			result[k] = normalize(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, item := range value {
+			result[i] = normalize(item)
+		}
+		return result
+	case float64:
+		if value == math.Trunc(value) && math.Abs(value) < math.MaxInt64 {
+			return int64(value)
+		}
+		return value
+	case int:
+		return int64(value)
+	case int32:
+		return int64(value)
+	default:
+		return v
+	}
+}
+
+// validate validates the admission request, and reports violations by actions of the policy bindings
+func (cp *compiledPolicy) validate(req *admissionRequest) *Result {
+	if !matchResources(cp.policy.Spec.MatchConstraints, req) {
+		return nil
+	}
+	actions := []string{ActionDeny}
+	if len(cp.bindings) > 0 {
+		actions = nil
+		for _, b := range cp.bindings {
+			if b.Spec.MatchResources == nil || matchResources(b.Spec.MatchResources, req) {
+				actions = append(actions, b.Spec.ValidationActions...)
+			}
+		}
+		if len(actions) == 0 {
+			return nil
+		}
+	}
+
+	messages := cp.evaluate(req)
+	if len(messages) == 0 {
+		return nil
+	}
+	result := &Result{}
+	for _, action := range dedup(actions) {
+		for _, msg := range messages {
+			v := Violation{Rule: cp.policy.Name, Resource: req.id, Message: msg}
+			if action == ActionDeny {
+				result.Denies = append(result.Denies, v)
+			} else {
+				result.Warnings = append(result.Warnings, v)
+			}
+		}
+	}
+	return result
+}
+
+// evaluate returns messages of failed validations
+func (cp *compiledPolicy) evaluate(req *admissionRequest) []string {
+	ignoreErr := cp.policy.Spec.FailurePolicy == "Ignore"
+	activation := req.activation()
+	variables := activation["variables"].(map[string]interface{})
+
+	for _, c := range cp.matchConditions {
+		out, _, err := c.program.Eval(activation)
+		if err != nil {
+			if ignoreErr {
+				return nil
+			}
+			return []string{fmt.Sprintf("failed to evaluate the match condition %s: %v", c.name, err)}
+		}
+		if matched, ok := out.Value().(bool); !ok || !matched {
+			return nil
+		}
+	}
+
+	for _, v := range cp.variables {
+		out, _, err := v.program.Eval(activation)
+		if err != nil {
+			if ignoreErr {
+				return nil
+			}
+			return []string{fmt.Sprintf("failed to evaluate the variable %s: %v", v.name, err)}
+		}
+		variables[v.name] = out
+	}
+
+	var messages []string
+	for _, v := range cp.validations {
+		out, _, err := v.program.Eval(activation)
+		if err != nil {
+			if !ignoreErr {
+				messages = append(messages, fmt.Sprintf("expression %q resulted in error: %v", v.Expression, err))
+			}
+			continue
+		}
+		if passed, ok := out.Value().(bool); ok && passed {
+			continue
+		}
+		messages = append(messages, v.message(activation))
+	}
+	return messages
+}
+
+// message returns the message of the failed validation in the same way as the API server
+func (v *compiledValidation) message(activation map[string]interface{}) string {
+	if v.messageProgram != nil {
+		if out, _, err := v.messageProgram.Eval(activation); err == nil {
+			if msg, ok := out.Value().(string); ok && strings.TrimSpace(msg) != "" && !strings.Contains(msg, "\n") {
+				return msg
+			}
+		}
+	}
+	if v.Message != "" {
+		return v.Message
+	}
+	return fmt.Sprintf("failed expression: %s", v.Expression)
+}
+
+// matchResources returns true if the request is matched by the resource rules and the object selector
+func matchResources(m *MatchResources, req *admissionRequest) bool {
+	if m == nil {
+		return false
+	}
+	if m.ObjectSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(m.ObjectSelector)
+		if err != nil || !selector.Matches(req.labels()) {
+			return false
+		}
+	}
+	for _, r := range m.ExcludeResourceRules {
+		if matchRule(r, req) {
+			return false
+		}
+	}
+	for _, r := range m.ResourceRules {
+		if matchRule(r, req) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRule(r NamedRuleWithOperations, req *admissionRequest) bool {
+	resource := req.resource()
+	if !contains(r.APIGroups, req.gvk.Group) || !contains(r.APIVersions, req.gvk.Version) ||
+		!contains(r.Resources, resource.Resource) || !contains(r.Operations, req.operation) {
+		return false
+	}
+	if len(r.ResourceNames) > 0 && !contains(r.ResourceNames, req.name) {
+		return false
+	}
+	switch r.Scope {
+	case "Cluster":
+		return req.namespace == ""
+	case "Namespaced":
+		return req.namespace != ""
+	default:
+		return true
+	}
+}
+
+// contains returns true if values contain the value or the wildcard
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func dedup(values []string) []string {
+	seen := map[string]bool{}
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
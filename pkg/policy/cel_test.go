@@ -0,0 +1,114 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+func deployment(name, namespace, image string, replicas float64, labels map[string]interface{}) models.Resource {
+	metadata := map[string]interface{}{"name": name, "namespace": namespace}
+	if labels != nil {
+		metadata["labels"] = labels
+	}
+	return models.Resource{
+		ID:   "apps/v1:Deployment:" + namespace + ":" + name,
+		Type: runtime.Kubernetes,
+		Attributes: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   metadata,
+			"spec": map[string]interface{}{
+				"replicas": replicas,
+				"template": map[string]interface{}{"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"name": "main", "image": image}},
+				}},
+			},
+		},
+	}
+}
+
+func TestLoadAdmissionPolicies(t *testing.T) {
+	policies, bindings, err := LoadAdmissionPolicies("testdata/admission")
+	assert.Nil(t, err)
+	assert.Len(t, policies, 2)
+	assert.Equal(t, "replica-limit", policies[0].Name)
+	assert.Len(t, policies[0].Spec.Validations, 2)
+	assert.Len(t, bindings, 1)
+	assert.Equal(t, []string{ActionWarn}, bindings[0].Spec.ValidationActions)
+
+	_, _, err = LoadAdmissionPolicies("testdata/not-exist")
+	assert.NotNil(t, err)
+}
+
+func TestCELChecker_Check(t *testing.T) {
+	team := map[string]interface{}{"team": "a"}
+	good := deployment("good", "default", "app:v1", 3, team)
+	tooMany := deployment("too-many", "default", "app:latest", 10, nil)
+	system := deployment("system", "kube-system", "app:v1", 1, nil)
+	configMap := models.Resource{
+		ID:         "v1:ConfigMap:default:config",
+		Type:       runtime.Kubernetes,
+		Attributes: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "config", "namespace": "default"}},
+	}
+	other := models.Resource{ID: "other", Type: runtime.Terraform, Attributes: map[string]interface{}{}}
+	checker := &CELChecker{Paths: []string{"testdata/admission"}}
+
+	t.Run("all resources are created without changes", func(t *testing.T) {
+		result, err := checker.Check(&Input{Resources: models.Resources{good, tooMany, system, configMap, other}})
+		assert.Nil(t, err)
+		assert.Equal(t, []Violation{
+			{Rule: "replica-limit", Resource: tooMany.ID, Message: "replicas of too-many must be no more than 5"},
+			{Rule: "replica-limit", Resource: tooMany.ID, Message: "latest images are not allowed"},
+		}, result.Denies)
+		assert.Equal(t, []Violation{
+			{Rule: "required-team-label", Resource: tooMany.ID, Message: "failed expression: " +
+				"request.operation == 'DELETE' || (has(object.metadata.labels) && 'team' in object.metadata.labels)"},
+		}, result.Warnings)
+	})
+
+	t.Run("unchanged and deleted resources", func(t *testing.T) {
+		result, err := checker.Check(&Input{
+			Resources: models.Resources{good},
+			Changes: []Change{
+				{ID: good.ID, Action: "Update", Before: &tooMany, After: &good},
+				{ID: tooMany.ID, Action: "UnChange", Before: &tooMany, After: &tooMany},
+				{ID: system.ID, Action: "Delete", Before: &tooMany},
+			},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, &Result{}, result)
+	})
+
+	t.Run("invalid expression", func(t *testing.T) {
+		_, err := (&CELChecker{Paths: []string{"testdata/invalid.yaml"}}).Check(&Input{Resources: models.Resources{good}})
+		assert.ErrorContains(t, err, "compile expression")
+	})
+}
+
+func Test_newCELEnv(t *testing.T) {
+	env, err := newCELEnv()
+	assert.Nil(t, err)
+	for _, expression := range []string{
+		"isURL('https://kusionstack.io')",
+		"object.metadata.name.matches('^[a-z0-9-]+$')",
+		"[1, 2, 3].isSorted()",
+		"'a1b2'.findAll('[0-9]') == ['1', '2']",
+		"base64.encode(b'a') == 'YQ=='",
+		"'a,b'.split(',').size() == 2",
+	} {
+		_, issues := env.Compile(expression)
+		assert.Nil(t, issues.Err(), expression)
+	}
+}
+
+func Test_normalize(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{
+		"a": int64(1), "b": 1.5, "c": []interface{}{int64(2), "d"},
+	}, normalize(map[string]interface{}{
+		"a": float64(1), "b": 1.5, "c": []interface{}{2, "d"},
+	}))
+}
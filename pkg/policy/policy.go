@@ -0,0 +1,114 @@
+// Package policy checks compiled resources and planned changes of a stack against policies, so that
+// guardrails of platform teams, e.g. "no :latest images" and "resource limits are required", are enforced
+// before any change is made.
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+)
+
+// Input is the document policies are evaluated against
+type Input struct {
+	Project   string           `json:"project"`
+	Stack     string           `json:"stack"`
+	Resources models.Resources `json:"resources"`
+	Changes   []Change         `json:"changes,omitempty"`
+}
+
+// Change is a planned change of a resource
+type Change struct {
+	ID string `json:"id"`
+	// Action is one of UnChange, Create, Update and Delete
+	Action string      `json:"action"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// Violation is a message reported by a policy rule
+type Violation struct {
+	// Rule is the name of the reporting rule if the policy provides it
+	Rule string `json:"rule,omitempty"`
+	// Resource is the ID of the violating resource if the policy provides it
+	Resource string `json:"resource,omitempty"`
+	// Severity is the severity of the violation if the policy provides it
+	Severity Severity `json:"severity,omitempty"`
+	Message  string   `json:"msg"`
+}
+
+func (v Violation) String() string {
+	var prefix []string
+	if v.Severity != "" {
+		prefix = append(prefix, string(v.Severity))
+	}
+	if v.Rule != "" {
+		prefix = append(prefix, v.Rule)
+	}
+	if v.Resource != "" {
+		prefix = append(prefix, v.Resource)
+	}
+	if len(prefix) == 0 {
+		return v.Message
+	}
+	return fmt.Sprintf("[%s] %s", strings.Join(prefix, "] ["), v.Message)
+}
+
+// Result is the result of policy checks. Denies block the operation, and warnings are only reported.
+type Result struct {
+	Denies   []Violation
+	Warnings []Violation
+}
+
+// Merge appends violations of other results
+func (r *Result) Merge(others ...*Result) *Result {
+	for _, o := range others {
+		if o == nil {
+			continue
+		}
+		r.Denies = append(r.Denies, o.Denies...)
+		r.Warnings = append(r.Warnings, o.Warnings...)
+	}
+	return r
+}
+
+// Err returns a ViolationsError listing all denies, or nil if nothing is denied
+func (r *Result) Err() error {
+	if len(r.Denies) == 0 {
+		return nil
+	}
+	return &ViolationsError{Violations: r.Denies}
+}
+
+// ViolationsError is the error of denied violations, whose violations can be reported per resource
+type ViolationsError struct {
+	Violations []Violation
+}
+
+func (e *ViolationsError) Error() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%d policy violation(s) found:", len(e.Violations))
+	for _, v := range e.Violations {
+		fmt.Fprintf(b, "\n  - %s", v)
+	}
+	return b.String()
+}
+
+// Checker checks the input against policies
+type Checker interface {
+	Check(input *Input) (*Result, error)
+}
+
+// Check runs all checkers and merges their results
+func Check(input *Input, checkers ...Checker) (*Result, error) {
+	result := &Result{}
+	for _, c := range checkers {
+		r, err := c.Check(input)
+		if err != nil {
+			return nil, err
+		}
+		result.Merge(r)
+	}
+	return result, nil
+}
@@ -0,0 +1,45 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeChecker struct {
+	result *Result
+	err    error
+}
+
+func (c *fakeChecker) Check(*Input) (*Result, error) {
+	return c.result, c.err
+}
+
+func TestCheck(t *testing.T) {
+	result, err := Check(&Input{},
+		&fakeChecker{result: &Result{Denies: []Violation{{Message: "a"}}}},
+		&fakeChecker{result: &Result{Warnings: []Violation{{Message: "b"}}}},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, &Result{Denies: []Violation{{Message: "a"}}, Warnings: []Violation{{Message: "b"}}}, result)
+
+	_, err = Check(&Input{}, &fakeChecker{err: errors.New("failed")})
+	assert.EqualError(t, err, "failed")
+}
+
+func TestResult_Err(t *testing.T) {
+	assert.Nil(t, (&Result{Warnings: []Violation{{Message: "a"}}}).Err())
+
+	err := (&Result{Denies: []Violation{
+		{Message: "image tag is required"},
+		{Rule: "limits", Resource: "apps/v1:Deployment:default:app", Message: "resource limits are required"},
+	}}).Err()
+	assert.EqualError(t, err, `2 policy violation(s) found:
+  - image tag is required
+  - [limits] [apps/v1:Deployment:default:app] resource limits are required`)
+
+	var violationsErr *ViolationsError
+	assert.ErrorAs(t, err, &violationsErr)
+	assert.Len(t, violationsErr.Violations, 2)
+}
@@ -0,0 +1,167 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"kusionstack.io/kusion/pkg/log"
+)
+
+const (
+	// DefaultRegoPackage is the package of Rego policies, whose deny and warn rules are evaluated
+	DefaultRegoPackage = "kusion"
+
+	denyRule = "deny"
+	warnRule = "warn"
+)
+
+// RegoChecker evaluates Rego policies with the opa CLI. Policies declare deny and warn rules in the package,
+// whose values are messages, or objects with the msg field and optional rule and resource fields:
+//
+//	package kusion
+//
+//	deny[{"rule": "no-latest-image", "resource": r.id, "msg": msg}] {
+//	    r := input.resources[_]
+//	    c := r.attributes.spec.template.spec.containers[_]
+//	    endswith(c.image, ":latest")
+//	    msg := sprintf("container %s uses the latest image", [c.name])
+//	}
+type RegoChecker struct {
+	// Paths are directories or files of policies and data, or bundles ending with .tar.gz
+	Paths []string
+	// Package is the package of policies, defaults to DefaultRegoPackage
+	Package string
+}
+
+var _ Checker = (*RegoChecker)(nil)
+
+func (c *RegoChecker) Check(input *Input) (*Result, error) {
+	if len(c.Paths) == 0 {
+		return &Result{}, nil
+	}
+
+	f, err := os.CreateTemp("", "kusion-policy-input-*.json")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	if err = json.NewEncoder(f).Encode(input); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err = f.Close(); err != nil {
+		return nil, err
+	}
+
+	out, err := runOpa(c.evalArgs(f.Name())...)
+	if err != nil {
+		return nil, err
+	}
+	return parseEvalOutput(out)
+}
+
+// evalArgs returns arguments of the `opa eval` command
+func (c *RegoChecker) evalArgs(inputFile string) []string {
+	pkg := c.Package
+	if pkg == "" {
+		pkg = DefaultRegoPackage
+	}
+	args := []string{"eval", "--format", "json", "--input", inputFile}
+	for _, p := range c.Paths {
+		if strings.HasSuffix(p, ".tar.gz") {
+			args = append(args, "--bundle", p)
+		} else {
+			args = append(args, "--data", p)
+		}
+	}
+	return append(args, "data."+pkg)
+}
+
+type evalOutput struct {
+	Result []struct {
+		Expressions []struct {
+			Value map[string]interface{} `json:"value"`
+		} `json:"expressions"`
+	} `json:"result"`
+}
+
+// parseEvalOutput parses violations from the output of `opa eval`. The result is empty if the package
+// is not defined by any policy.
+func parseEvalOutput(out []byte) (*Result, error) {
+	var o evalOutput
+	if err := json.Unmarshal(out, &o); err != nil {
+		return nil, fmt.Errorf("failed to parse opa eval result: %w", err)
+	}
+
+	result := &Result{}
+	for _, r := range o.Result {
+		for _, e := range r.Expressions {
+			denies, err := violations(e.Value[denyRule])
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s rule: %w", denyRule, err)
+			}
+			warnings, err := violations(e.Value[warnRule])
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s rule: %w", warnRule, err)
+			}
+			result.Denies = append(result.Denies, denies...)
+			result.Warnings = append(result.Warnings, warnings...)
+		}
+	}
+	return result, nil
+}
+
+// violations converts values of a rule, which is a set or a boolean, into violations
+func violations(value interface{}) ([]Violation, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		if v {
+			return []Violation{{Message: "denied by policy"}}, nil
+		}
+		return nil, nil
+	case []interface{}:
+		result := make([]Violation, 0, len(v))
+		for _, item := range v {
+			switch i := item.(type) {
+			case string:
+				result = append(result, Violation{Message: i})
+			case map[string]interface{}:
+				data, err := json.Marshal(i)
+				if err != nil {
+					return nil, err
+				}
+				var violation Violation
+				if err = json.Unmarshal(data, &violation); err != nil {
+					return nil, err
+				}
+				if violation.Message == "" {
+					violation.Message = string(data)
+				}
+				result = append(result, violation)
+			default:
+				result = append(result, Violation{Message: fmt.Sprint(i)})
+			}
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("unexpected value %v, must be a set or a boolean", value)
+	}
+}
+
+// runOpa runs the opa CLI and returns its stdout
+var runOpa = func(args ...string) ([]byte, error) {
+	log.Debugf("run opa with args: %v", args)
+	out, err := exec.Command("opa", args...).Output()
+	if e, ok := err.(*exec.ExitError); ok {
+		return nil, fmt.Errorf("opa %s failed: %s", args[0], string(e.Stderr))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,92 @@
+package policy
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegoChecker_evalArgs(t *testing.T) {
+	c := &RegoChecker{Paths: []string{"policies", "bundle.tar.gz"}}
+	assert.Equal(t, []string{
+		"eval", "--format", "json", "--input", "input.json",
+		"--data", "policies", "--bundle", "bundle.tar.gz", "data.kusion",
+	}, c.evalArgs("input.json"))
+
+	c.Package = "guardrails"
+	assert.Equal(t, "data.guardrails", c.evalArgs("input.json")[len(c.evalArgs("input.json"))-1])
+}
+
+func TestRegoChecker_Check(t *testing.T) {
+	runOpaBackup := runOpa
+	defer func() { runOpa = runOpaBackup }()
+
+	var input Input
+	runOpa = func(args ...string) ([]byte, error) {
+		data, err := os.ReadFile(args[4])
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(data, &input); err != nil {
+			return nil, err
+		}
+		return []byte(`{"result": [{"expressions": [{"value": {
+			"deny": ["image tag is required", {"rule": "limits", "resource": "a", "msg": "limits are required"}],
+			"warn": [{"resource": "b"}],
+			"other": true
+		}}]}]}`), nil
+	}
+
+	result, err := (&RegoChecker{Paths: []string{"policies"}}).Check(&Input{
+		Project: "p",
+		Stack:   "dev",
+		Changes: []Change{{ID: "a", Action: "Create"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, &Result{
+		Denies: []Violation{
+			{Message: "image tag is required"},
+			{Rule: "limits", Resource: "a", Message: "limits are required"},
+		},
+		Warnings: []Violation{{Resource: "b", Message: `{"resource":"b"}`}},
+	}, result)
+	assert.Equal(t, "dev", input.Stack)
+	assert.Equal(t, []Change{{ID: "a", Action: "Create"}}, input.Changes)
+
+	// no path, no evaluation
+	result, err = (&RegoChecker{}).Check(&Input{})
+	assert.Nil(t, err)
+	assert.Equal(t, &Result{}, result)
+}
+
+func Test_parseEvalOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		out     string
+		want    *Result
+		wantErr bool
+	}{
+		{name: "undefined package", out: `{}`, want: &Result{}},
+		{name: "no violation", out: `{"result": [{"expressions": [{"value": {"deny": []}}]}]}`, want: &Result{}},
+		{
+			name: "boolean deny",
+			out:  `{"result": [{"expressions": [{"value": {"deny": true}}]}]}`,
+			want: &Result{Denies: []Violation{{Message: "denied by policy"}}},
+		},
+		{name: "invalid deny", out: `{"result": [{"expressions": [{"value": {"deny": "no"}}]}]}`, wantErr: true},
+		{name: "invalid output", out: `deny`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEvalOutput([]byte(tt.out))
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
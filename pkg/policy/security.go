@@ -0,0 +1,223 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+// Severity is the severity of a security finding
+type Severity string
+
+// Severities of security findings, from the lowest to the highest
+const (
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+var severityLevels = map[Severity]int{
+	SeverityLow:      1,
+	SeverityMedium:   2,
+	SeverityHigh:     3,
+	SeverityCritical: 4,
+}
+
+// ParseSeverity returns the severity of the name, which is case-insensitive
+func ParseSeverity(name string) (Severity, error) {
+	s := Severity(strings.ToLower(name))
+	if _, ok := severityLevels[s]; !ok {
+		return "", fmt.Errorf("invalid severity %s, must be one of low, medium, high and critical", name)
+	}
+	return s, nil
+}
+
+// AtLeast returns true if the severity is not lower than the other one
+func (s Severity) AtLeast(other Severity) bool {
+	return severityLevels[s] >= severityLevels[other]
+}
+
+// Rules of the security scanner
+const (
+	RulePrivilegedContainer    = "privileged-container"
+	RulePrivilegeEscalation    = "privilege-escalation"
+	RuleHostPath               = "host-path"
+	RuleHostNamespace          = "host-namespace"
+	RuleMissingSecurityContext = "missing-security-context"
+	RuleRunAsRoot              = "run-as-root"
+	RuleWildcardRBAC           = "wildcard-rbac"
+)
+
+// securityRules are severities of rules of the security scanner
+var securityRules = map[string]Severity{
+	RulePrivilegedContainer:    SeverityCritical,
+	RulePrivilegeEscalation:    SeverityMedium,
+	RuleHostPath:               SeverityHigh,
+	RuleHostNamespace:          SeverityHigh,
+	RuleMissingSecurityContext: SeverityLow,
+	RuleRunAsRoot:              SeverityMedium,
+	RuleWildcardRBAC:           SeverityHigh,
+}
+
+// SecurityChecker scans Kubernetes resources of the spec for insecure settings: privileged containers,
+// privilege escalation, hostPath volumes, host namespaces, containers without securityContext or running as
+// root, and RBAC rules with wildcards. Findings whose severities reach the threshold are denies, and the
+// others are warnings.
+type SecurityChecker struct {
+	// Threshold is the lowest severity of findings which fail the check, defaults to high
+	Threshold Severity
+	// SkipRules are rules not to check
+	SkipRules []string
+}
+
+var _ Checker = (*SecurityChecker)(nil)
+
+func (c *SecurityChecker) Check(input *Input) (*Result, error) {
+	threshold := SeverityHigh
+	if c.Threshold != "" {
+		var err error
+		if threshold, err = ParseSeverity(string(c.Threshold)); err != nil {
+			return nil, err
+		}
+	}
+	skipped := make(map[string]bool, len(c.SkipRules))
+	for _, r := range c.SkipRules {
+		if _, ok := securityRules[r]; !ok {
+			return nil, fmt.Errorf("unknown security rule %s", r)
+		}
+		skipped[r] = true
+	}
+
+	result := &Result{}
+	for i := range input.Resources {
+		res := &input.Resources[i]
+		if res.Type != runtime.Kubernetes {
+			continue
+		}
+		for _, f := range scanResource(res) {
+			if skipped[f.Rule] {
+				continue
+			}
+			if f.Severity.AtLeast(threshold) {
+				result.Denies = append(result.Denies, f)
+			} else {
+				result.Warnings = append(result.Warnings, f)
+			}
+		}
+	}
+	return result, nil
+}
+
+// scanResource returns security findings of the resource
+func scanResource(res *models.Resource) []Violation {
+	var findings []Violation
+	report := func(rule, format string, args ...interface{}) {
+		findings = append(findings, Violation{
+			Rule:     rule,
+			Resource: res.ID,
+			Severity: securityRules[rule],
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	kind, _ := res.Attributes["kind"].(string)
+	switch kind {
+	case "Role", "ClusterRole":
+		rules, _ := res.Attributes["rules"].([]interface{})
+		for _, r := range rules {
+			rule, _ := r.(map[string]interface{})
+			for _, field := range []string{"apiGroups", "resources", "verbs"} {
+				if hasWildcard(rule[field]) {
+					report(RuleWildcardRBAC, "%s of the rule contain the wildcard *", field)
+				}
+			}
+		}
+		return findings
+	}
+
+	podSpec := podSpecOf(res.Attributes)
+	if podSpec == nil {
+		return nil
+	}
+	for _, field := range []string{"hostNetwork", "hostPID", "hostIPC"} {
+		if enabled, _ := podSpec[field].(bool); enabled {
+			report(RuleHostNamespace, "%s is enabled", field)
+		}
+	}
+	volumes, _ := podSpec["volumes"].([]interface{})
+	for _, v := range volumes {
+		volume, _ := v.(map[string]interface{})
+		if _, ok := volume["hostPath"]; ok {
+			report(RuleHostPath, "volume %v mounts a hostPath", volume["name"])
+		}
+	}
+
+	podContext, _ := podSpec["securityContext"].(map[string]interface{})
+	podNonRoot, _ := podContext["runAsNonRoot"].(bool)
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _ := podSpec[field].([]interface{})
+		for _, c := range containers {
+			container, _ := c.(map[string]interface{})
+			name := container["name"]
+			sc, ok := container["securityContext"].(map[string]interface{})
+			if !ok {
+				report(RuleMissingSecurityContext, "container %v has no securityContext", name)
+			}
+			if privileged, _ := sc["privileged"].(bool); privileged {
+				report(RulePrivilegedContainer, "container %v is privileged", name)
+			}
+			if escalation, ok := sc["allowPrivilegeEscalation"].(bool); !ok || escalation {
+				report(RulePrivilegeEscalation, "container %v allows privilege escalation", name)
+			}
+			nonRoot, ok := sc["runAsNonRoot"].(bool)
+			if !ok {
+				nonRoot = podNonRoot
+			}
+			if uid, ok := sc["runAsUser"]; ok && fmt.Sprint(uid) == "0" {
+				nonRoot = false
+			}
+			if !nonRoot {
+				report(RuleRunAsRoot, "container %v may run as root", name)
+			}
+		}
+	}
+	return findings
+}
+
+// podSpecOf returns the pod spec of Pods and workloads
+func podSpecOf(attributes map[string]interface{}) map[string]interface{} {
+	kind, _ := attributes["kind"].(string)
+	var path []string
+	switch kind {
+	case "Pod":
+		path = []string{"spec"}
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job", "ReplicationController":
+		path = []string{"spec", "template", "spec"}
+	case "CronJob":
+		path = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return nil
+	}
+	current := attributes
+	for _, p := range path {
+		next, ok := current[p].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+func hasWildcard(value interface{}) bool {
+	values, _ := value.([]interface{})
+	for _, v := range values {
+		if v == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+func newK8sResource(id string, attributes map[string]interface{}) models.Resource {
+	return models.Resource{ID: id, Type: runtime.Kubernetes, Attributes: attributes}
+}
+
+var (
+	insecureDeployment = newK8sResource("apps/v1:Deployment:default:app", map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"hostNetwork": true,
+					"volumes": []interface{}{
+						map[string]interface{}{"name": "docker", "hostPath": map[string]interface{}{"path": "/var/run"}},
+					},
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":            "main",
+							"securityContext": map[string]interface{}{"privileged": true},
+						},
+						map[string]interface{}{"name": "sidecar"},
+					},
+				},
+			},
+		},
+	})
+	secureCronJob = newK8sResource("batch/v1:CronJob:default:job", map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "CronJob",
+		"spec": map[string]interface{}{
+			"jobTemplate": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"securityContext": map[string]interface{}{"runAsNonRoot": true},
+							"containers": []interface{}{
+								map[string]interface{}{
+									"name":            "main",
+									"securityContext": map[string]interface{}{"allowPrivilegeEscalation": false},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	wildcardRole = newK8sResource("rbac.authorization.k8s.io/v1:ClusterRole:admin", map[string]interface{}{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "ClusterRole",
+		"rules": []interface{}{
+			map[string]interface{}{
+				"apiGroups": []interface{}{""},
+				"resources": []interface{}{"pods"},
+				"verbs":     []interface{}{"*"},
+			},
+		},
+	})
+)
+
+func TestSecurityChecker_Check(t *testing.T) {
+	input := &Input{Resources: models.Resources{insecureDeployment, secureCronJob, wildcardRole}}
+
+	result, err := (&SecurityChecker{}).Check(input)
+	assert.Nil(t, err)
+	deploy, role := insecureDeployment.ID, wildcardRole.ID
+	assert.Equal(t, []Violation{
+		{Rule: RuleHostNamespace, Resource: deploy, Severity: SeverityHigh, Message: "hostNetwork is enabled"},
+		{Rule: RuleHostPath, Resource: deploy, Severity: SeverityHigh, Message: "volume docker mounts a hostPath"},
+		{Rule: RulePrivilegedContainer, Resource: deploy, Severity: SeverityCritical, Message: "container main is privileged"},
+		{Rule: RuleWildcardRBAC, Resource: role, Severity: SeverityHigh, Message: "verbs of the rule contain the wildcard *"},
+	}, result.Denies)
+	assert.Equal(t, []Violation{
+		{Rule: RulePrivilegeEscalation, Resource: deploy, Severity: SeverityMedium, Message: "container main allows privilege escalation"},
+		{Rule: RuleRunAsRoot, Resource: deploy, Severity: SeverityMedium, Message: "container main may run as root"},
+		{Rule: RuleMissingSecurityContext, Resource: deploy, Severity: SeverityLow, Message: "container sidecar has no securityContext"},
+		{Rule: RulePrivilegeEscalation, Resource: deploy, Severity: SeverityMedium, Message: "container sidecar allows privilege escalation"},
+		{Rule: RuleRunAsRoot, Resource: deploy, Severity: SeverityMedium, Message: "container sidecar may run as root"},
+	}, result.Warnings)
+
+	result, err = (&SecurityChecker{
+		Threshold: SeverityCritical,
+		SkipRules: []string{RulePrivilegeEscalation, RuleRunAsRoot, RuleMissingSecurityContext},
+	}).Check(input)
+	assert.Nil(t, err)
+	assert.Len(t, result.Denies, 1)
+	assert.Equal(t, RulePrivilegedContainer, result.Denies[0].Rule)
+	assert.Len(t, result.Warnings, 3)
+
+	result, err = (&SecurityChecker{}).Check(&Input{Resources: models.Resources{secureCronJob}})
+	assert.Nil(t, err)
+	assert.Equal(t, &Result{}, result)
+
+	_, err = (&SecurityChecker{Threshold: "severe"}).Check(input)
+	assert.EqualError(t, err, "invalid severity severe, must be one of low, medium, high and critical")
+	_, err = (&SecurityChecker{SkipRules: []string{"unknown"}}).Check(input)
+	assert.EqualError(t, err, "unknown security rule unknown")
+}
+
+func TestViolation_String(t *testing.T) {
+	v := Violation{Rule: RuleHostPath, Resource: "v1:Pod:default:p", Severity: SeverityHigh, Message: "volume a mounts a hostPath"}
+	assert.Equal(t, "[high] [host-path] [v1:Pod:default:p] volume a mounts a hostPath", v.String())
+}
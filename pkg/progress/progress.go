@@ -0,0 +1,151 @@
+// Package progress streams events of operations over Server-Sent Events, so that IDE plugins and web consoles
+// can render live progress of an apply instead of parsing the output of the CLI.
+package progress
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"kusionstack.io/kusion/pkg/log"
+)
+
+// Path is the path of the event stream
+const Path = "/events"
+
+// Types of events
+const (
+	// EventQueued is sent by the server mode while the apply waits in the queue of the stack, with its position
+	EventQueued = "queued"
+	// EventStart is sent when the operation starts, with the number of resources to apply
+	EventStart = "start"
+	// EventResource is sent when a resource starts to be applied or is applied
+	EventResource = "resource"
+	// EventHeartbeat is sent periodically when the lock of the state is refreshed, with the error if it fails
+	EventHeartbeat = "heartbeat"
+	// EventFinish is the last event of the stream, sent when the operation finishes
+	EventFinish = "finish"
+)
+
+// Event is an event of the operation
+type Event struct {
+	Type       string    `json:"type"`
+	Operation  string    `json:"operation,omitempty"`
+	Project    string    `json:"project,omitempty"`
+	Stack      string    `json:"stack,omitempty"`
+	Total      int       `json:"total,omitempty"`
+	ResourceID string    `json:"resourceId,omitempty"`
+	Action     string    `json:"action,omitempty"`
+	Result     string    `json:"result,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// Server serves events of an operation at the path /events. Events published before a client connects are
+// replayed to it, and streams end after the finish event.
+type Server struct {
+	listener net.Listener
+
+	lock     sync.Mutex
+	events   []*Event
+	updated  chan struct{}
+	finished bool
+}
+
+// Serve starts the server at the address in background
+func Serve(addr string) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("serve progress at %s failed: %w", addr, err)
+	}
+	s := &Server{listener: listener, updated: make(chan struct{})}
+	mux := http.NewServeMux()
+	mux.HandleFunc(Path, s.handle)
+	go func() {
+		if err := http.Serve(listener, mux); err != nil && !errors.Is(err, net.ErrClosed) {
+			log.Errorf("serve progress at %s failed: %v", listener.Addr(), err)
+		}
+	}()
+	return s, nil
+}
+
+// Addr returns the address listened
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Publish sends the event to all clients. Events published after the finish event are dropped.
+func (s *Server) Publish(e *Event) {
+	if s == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.finished {
+		return
+	}
+	s.events = append(s.events, e)
+	s.finished = e.Type == EventFinish
+	// wake up all clients waiting for new events
+	close(s.updated)
+	s.updated = make(chan struct{})
+}
+
+// Close stops accepting clients. Clients connected keep receiving events until the finish event.
+func (s *Server) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+// next returns events after the index, and the channel closed when new events are published
+func (s *Server) next(index int) ([]*Event, bool, <-chan struct{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.events[index:], s.finished, s.updated
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	sent := 0
+	for {
+		events, finished, updated := s.next(sent)
+		for _, e := range events {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return
+			}
+			if _, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", sent, e.Type, data); err != nil {
+				return
+			}
+			sent++
+		}
+		flusher.Flush()
+		if finished {
+			return
+		}
+		select {
+		case <-updated:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
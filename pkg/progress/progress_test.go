@@ -0,0 +1,64 @@
+package progress
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readEvents reads events from the stream until it ends
+func readEvents(t *testing.T, addr string) []*Event {
+	resp, err := http.Get("http://" + addr + Path)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	var events []*Event
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if data := strings.TrimPrefix(scanner.Text(), "data: "); data != scanner.Text() {
+			e := &Event{}
+			assert.Nil(t, json.Unmarshal([]byte(data), e))
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func TestServer(t *testing.T) {
+	s, err := Serve("127.0.0.1:0")
+	assert.Nil(t, err)
+	defer s.Close()
+
+	s.Publish(&Event{Type: EventStart, Operation: "apply", Total: 1})
+	done := make(chan []*Event)
+	go func() {
+		done <- readEvents(t, s.Addr())
+	}()
+	s.Publish(&Event{Type: EventResource, ResourceID: "v1:Namespace:default", Result: "success"})
+	s.Publish(&Event{Type: EventFinish, Result: "success"})
+	s.Publish(&Event{Type: EventResource, ResourceID: "dropped"})
+
+	events := <-done
+	assert.Len(t, events, 3)
+	assert.Equal(t, EventStart, events[0].Type)
+	assert.Equal(t, "v1:Namespace:default", events[1].ResourceID)
+	assert.Equal(t, EventFinish, events[2].Type)
+	assert.False(t, events[2].Time.IsZero())
+
+	// clients connected after the operation finishes get all events
+	assert.Len(t, readEvents(t, s.Addr()), 3)
+}
+
+func TestServe(t *testing.T) {
+	_, err := Serve("invalid-address")
+	assert.NotNil(t, err)
+
+	var s *Server
+	s.Publish(&Event{Type: EventStart})
+	assert.Nil(t, s.Close())
+}
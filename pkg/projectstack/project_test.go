@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
 
 	"kusionstack.io/kusion/pkg/util/json"
 )
@@ -622,3 +623,22 @@ func mockFindProjectPathFrom(mockProjectDir string, mockErr error) {
 		return "", mockErr
 	})
 }
+
+func TestParseProjectConfiguration_generator(t *testing.T) {
+	dir := t.TempDir()
+	content := `name: demo
+generator:
+  type: KCL
+  resourceGenerators:
+    - name: app
+      configs:
+        replicas: 2
+mutators:
+  - type: metadata
+`
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, ProjectFile), []byte(content), 0o644))
+	config, err := ParseProjectConfiguration(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []*ResourceGeneratorConfig{{Name: "app", Configs: map[string]interface{}{"replicas": 2}}}, config.Generator.ResourceGenerators)
+	assert.Equal(t, "metadata", config.Mutators[0].Type)
+}
@@ -0,0 +1,90 @@
+package projectstack
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"k8s.io/client-go/util/homedir"
+
+	"kusionstack.io/kusion/pkg/util/kube/config"
+)
+
+// EnvTFWorkspace selects the workspace of Terraform commands
+const EnvTFWorkspace = "TF_WORKSPACE"
+
+// invalidWorkspaceChars matches characters not allowed in derived names of Terraform workspaces
+var invalidWorkspaceChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// RuntimeEnv returns environment variables of runtimes pinned by the runtime config of the stack of the project,
+// e.g. $KUBECONFIG and $KUSION_KUBE_CONTEXT of the Kubernetes runtime and $TF_WORKSPACE of Terraform. They are
+// passed to runtimes and the processes they run instead of being set in this process, so that they never leak
+// into operations of other stacks. Nil is returned if the stack has no runtime config.
+func (s *Stack) RuntimeEnv(project *Project) map[string]string {
+	if s == nil || s.Runtime == nil {
+		return nil
+	}
+	env := map[string]string{}
+	if k := s.Runtime.Kubernetes; k != nil {
+		if k.Kubeconfig != "" {
+			env[config.RecommendedConfigPathEnvVar] = s.resolvePath(k.Kubeconfig)
+		}
+		if k.Context != "" {
+			env[config.KubeContextEnvVar] = k.Context
+		}
+	}
+	if tf := s.Runtime.Terraform; tf != nil {
+		for k, v := range tf.Env {
+			env[k] = v
+		}
+		if workspace := s.TerraformWorkspace(project); workspace != "" {
+			env[EnvTFWorkspace] = workspace
+		}
+	}
+	return env
+}
+
+// NamespaceCreation returns whether missing namespaces of the stack are created before applies, along with
+// labels of created namespaces
+func (s *Stack) NamespaceCreation() (bool, map[string]string) {
+	if s == nil || s.Runtime == nil || s.Runtime.Kubernetes == nil {
+		return false, nil
+	}
+	return s.Runtime.Kubernetes.CreateNamespaces, s.Runtime.Kubernetes.NamespaceLabels
+}
+
+// TerraformProviders returns provider configs pinned by the runtime config of the stack
+func (s *Stack) TerraformProviders() map[string]map[string]interface{} {
+	if s == nil || s.Runtime == nil || s.Runtime.Terraform == nil {
+		return nil
+	}
+	return s.Runtime.Terraform.Providers
+}
+
+// TerraformWorkspace returns the Terraform workspace of the stack of the project, or empty if it is not
+// configured
+func (s *Stack) TerraformWorkspace(project *Project) string {
+	if s == nil || s.Runtime == nil || s.Runtime.Terraform == nil {
+		return ""
+	}
+	workspace := s.Runtime.Terraform.Workspace
+	if workspace != AutoWorkspace {
+		return workspace
+	}
+	name := s.Name
+	if project != nil && project.Name != "" {
+		name = project.Name + "-" + s.Name
+	}
+	return strings.Trim(invalidWorkspaceChars.ReplaceAllString(name, "-"), "-")
+}
+
+// resolvePath returns the path relative to the stack directory, where ~ is the home directory
+func (s *Stack) resolvePath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return filepath.Join(homedir.HomeDir(), path[1:])
+	}
+	if filepath.IsAbs(path) || s.Path == "" {
+		return path
+	}
+	return filepath.Join(s.Path, path)
+}
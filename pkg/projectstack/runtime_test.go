@@ -0,0 +1,58 @@
+package projectstack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/util/kube/config"
+)
+
+func TestStack_RuntimeEnv(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "dev")
+
+	// stacks without runtime configs use the local environment
+	assert.Nil(t, (&Stack{}).RuntimeEnv(nil))
+
+	stack := &Stack{
+		StackConfiguration: StackConfiguration{
+			Name: "prod",
+			Runtime: &RuntimeConfig{
+				Kubernetes: &KubernetesRuntimeConfig{Kubeconfig: "kubeconfig.yaml", Context: "prod-cluster"},
+				Terraform: &TerraformRuntimeConfig{
+					Providers: map[string]map[string]interface{}{"aws": {"region": "us-east-1"}},
+					Env:       map[string]string{"AWS_PROFILE": "prod"},
+				},
+			},
+		},
+		Path: "/project/prod",
+	}
+	assert.Equal(t, map[string]string{
+		config.RecommendedConfigPathEnvVar: filepath.Join("/project/prod", "kubeconfig.yaml"),
+		config.KubeContextEnvVar:           "prod-cluster",
+		"AWS_PROFILE":                      "prod",
+	}, stack.RuntimeEnv(nil))
+	// this process is never changed
+	assert.Equal(t, "dev", os.Getenv("AWS_PROFILE"))
+	assert.Equal(t, map[string]map[string]interface{}{"aws": {"region": "us-east-1"}}, stack.TerraformProviders())
+	assert.Nil(t, (&Stack{}).TerraformProviders())
+}
+
+func TestStack_TerraformWorkspace(t *testing.T) {
+	project := &Project{ProjectConfiguration: ProjectConfiguration{Name: "demo"}}
+	stack := func(workspace string) *Stack {
+		return &Stack{StackConfiguration: StackConfiguration{
+			Name:    "prod/us",
+			Runtime: &RuntimeConfig{Terraform: &TerraformRuntimeConfig{Workspace: workspace}},
+		}}
+	}
+	assert.Equal(t, "", (&Stack{}).TerraformWorkspace(project))
+	assert.Equal(t, "", stack("").TerraformWorkspace(project))
+	assert.Equal(t, "blue", stack("blue").TerraformWorkspace(project))
+	assert.Equal(t, "demo-prod-us", stack(AutoWorkspace).TerraformWorkspace(project))
+	assert.Equal(t, "prod-us", stack(AutoWorkspace).TerraformWorkspace(nil))
+
+	assert.Equal(t, map[string]string{EnvTFWorkspace: "demo-prod-us"}, stack(AutoWorkspace).RuntimeEnv(project))
+}
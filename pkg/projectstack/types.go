@@ -8,6 +8,7 @@ import (
 
 	"kusionstack.io/kusion/pkg/engine/backend"
 	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/signing"
 	"kusionstack.io/kusion/pkg/vals"
 )
 
@@ -19,21 +20,141 @@ var (
 )
 
 const (
-	StackFile                      = "stack.yaml"
-	ProjectFile                    = "project.yaml"
-	CiTestDir                      = "ci-test"
-	SettingsFile                   = "settings.yaml"
-	StdoutGoldenFile               = "stdout.golden.yaml"
-	KclFile                        = "kcl.yaml"
-	KCLGenerator     GeneratorType = "KCL"
+	StackFile                        = "stack.yaml"
+	ProjectFile                      = "project.yaml"
+	CiTestDir                        = "ci-test"
+	SettingsFile                     = "settings.yaml"
+	StdoutGoldenFile                 = "stdout.golden.yaml"
+	KclFile                          = "kcl.yaml"
+	ValuesFile                       = "values.yaml"
+	LintFile                         = "lint.yaml"
+	KCLGenerator       GeneratorType = "KCL"
+	ManifestGenerator  GeneratorType = "Manifest"
+	HelmGenerator      GeneratorType = "Helm"
+	KustomizeGenerator GeneratorType = "Kustomize"
+	CUEGenerator       GeneratorType = "CUE"
+	JsonnetGenerator   GeneratorType = "Jsonnet"
 )
 
 type GeneratorType string
 
 // GeneratorConfig represent Generator configs saved in project.yaml
 type GeneratorConfig struct {
-	Type    GeneratorType          `json:"type"`
-	Configs map[string]interface{} `json:"configs,omitempty"`
+	Type    GeneratorType          `json:"type" yaml:"type"`
+	Configs map[string]interface{} `json:"configs,omitempty" yaml:"configs,omitempty"`
+
+	// ResourceGenerators are registered generators which turn models in the Spec into resources, run in order
+	ResourceGenerators []*ResourceGeneratorConfig `json:"resourceGenerators,omitempty" yaml:"resourceGenerators,omitempty"`
+}
+
+// ResourceGeneratorConfig represent a ResourceGenerator enabled in project.yaml
+type ResourceGeneratorConfig struct {
+	Name    string                 `json:"name" yaml:"name"`
+	Configs map[string]interface{} `json:"configs,omitempty" yaml:"configs,omitempty"`
+}
+
+// MutatorConfig represent a Spec mutator configured in project.yaml
+type MutatorConfig struct {
+	Type    string                 `json:"type" yaml:"type"`
+	Configs map[string]interface{} `json:"configs,omitempty" yaml:"configs,omitempty"`
+}
+
+// InterpolationConfig is the allowlist of references which can be interpolated in settings files
+type InterpolationConfig struct {
+	// Env contains names or glob patterns of environment variables, e.g. CI_*
+	Env []string `json:"env,omitempty" yaml:"env,omitempty"`
+
+	// Files contains paths or glob patterns of files relative to the project directory
+	Files []string `json:"files,omitempty" yaml:"files,omitempty"`
+}
+
+// PolicyConfig configures policies which are checked before resources are changed
+type PolicyConfig struct {
+	// Paths are Rego policy directories, files or bundles relative to the project directory
+	Paths []string `json:"paths,omitempty" yaml:"paths,omitempty"`
+
+	// Package is the Rego package whose deny and warn rules are evaluated, defaults to kusion
+	Package string `json:"package,omitempty" yaml:"package,omitempty"`
+
+	// AdmissionPolicies are files or directories of Kubernetes ValidatingAdmissionPolicies and their
+	// bindings relative to the project directory, which are evaluated locally
+	AdmissionPolicies []string `json:"admissionPolicies,omitempty" yaml:"admissionPolicies,omitempty"`
+
+	// Security enables the security scan of Kubernetes resources in the spec
+	Security *SecurityScanConfig `json:"security,omitempty" yaml:"security,omitempty"`
+}
+
+// SecurityScanConfig configures the security scan of Kubernetes resources
+type SecurityScanConfig struct {
+	// Threshold is the lowest severity of findings which fail the preview, one of low, medium, high and
+	// critical, defaults to high. Findings below the threshold are printed as warnings.
+	Threshold string `json:"threshold,omitempty" yaml:"threshold,omitempty"`
+
+	// SkipRules are rules of the scan to skip, e.g. missing-security-context
+	SkipRules []string `json:"skipRules,omitempty" yaml:"skipRules,omitempty"`
+}
+
+// CostConfig configures the estimation of monthly costs of stacks and their budgets
+type CostConfig struct {
+	// Currency of prices and budgets, only used in messages
+	Currency string `json:"currency,omitempty" yaml:"currency,omitempty"`
+
+	// Prices are monthly prices to estimate costs of resources
+	Prices *CostPrices `json:"prices,omitempty" yaml:"prices,omitempty"`
+
+	// Budgets are monthly budget ceilings keyed by stack names. Preview and apply fail if the estimated
+	// cost of the stack after changes exceeds its budget.
+	Budgets map[string]float64 `json:"budgets,omitempty" yaml:"budgets,omitempty"`
+}
+
+// CostPrices are monthly prices of resources
+type CostPrices struct {
+	// CPU is the price of one CPU core requested by Kubernetes pods
+	CPU float64 `json:"cpu,omitempty" yaml:"cpu,omitempty"`
+
+	// Memory is the price of one GiB of memory requested by Kubernetes pods
+	Memory float64 `json:"memory,omitempty" yaml:"memory,omitempty"`
+
+	// Storage is the price of one GiB of storage requested by Kubernetes PersistentVolumeClaims
+	Storage float64 `json:"storage,omitempty" yaml:"storage,omitempty"`
+
+	// Resources are prices of each resource keyed by Terraform resource types or Kubernetes kinds,
+	// e.g. alicloud_instance or Ingress
+	Resources map[string]float64 `json:"resources,omitempty" yaml:"resources,omitempty"`
+}
+
+// NotificationConfig configures a webhook notified when apply and destroy start, succeed and fail
+type NotificationConfig struct {
+	// Type of the webhook, slack, dingtalk, feishu or webhook, where webhook receives the event in JSON.
+	// Sinks of the type cloudevents and kafka receive CloudEvents of operations and resources, where kafka
+	// sinks are topics of the Kafka REST Proxy.
+	Type string `json:"type" yaml:"type"`
+
+	// URL of the webhook, environment variables in it are expanded, e.g. ${SLACK_WEBHOOK_URL}
+	URL string `json:"url" yaml:"url"`
+
+	// Events notified, e.g. apply-start, apply-success, apply-failure and destroy-failure, or names of
+	// CloudEvents for CloudEvents sinks, e.g. operation.started, resource.applied and operation.failed.
+	// All events are notified if empty.
+	Events []string `json:"events,omitempty" yaml:"events,omitempty"`
+
+	// Headers of requests to the webhook, environment variables in values are expanded
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+}
+
+// RetryConfig configures retries of requests to runtimes which fail with transient errors. The engine applies
+// it to requests of all runtimes when resources are applied and destroyed.
+type RetryConfig struct {
+	// Errors are classes of errors to retry, conflict, throttled and webhook-timeout, all of them if empty
+	Errors []string `json:"errors,omitempty" yaml:"errors,omitempty"`
+
+	// MaxAttempts is the max number of attempts of each request including the first one, defaults to 3
+	MaxAttempts int `json:"maxAttempts,omitempty" yaml:"maxAttempts,omitempty"`
+
+	// Backoff is the delay before the first retry, which doubles for each later retry up to MaxBackoff,
+	// e.g. 500ms. It defaults to 1s and MaxBackoff defaults to 30s.
+	Backoff    string `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+	MaxBackoff string `json:"maxBackoff,omitempty" yaml:"maxBackoff,omitempty"`
 }
 
 // ProjectConfiguration is the project configuration
@@ -47,11 +168,63 @@ type ProjectConfiguration struct {
 	// Backend storage config
 	Backend *backend.Storage `json:"backend,omitempty" yaml:"backend,omitempty"`
 
+	// CredentialHelper vends environment variables of runtimes before operations, e.g. credentials of Terraform
+	// providers, see the package credentials
+	CredentialHelper string `json:"credentialHelper,omitempty" yaml:"credentialHelper,omitempty"`
+
 	// SpecGenerator configs
 	Generator *GeneratorConfig `json:"generator,omitempty" yaml:"generator,omitempty"`
 
+	// Mutators modify the Spec in order after it is generated
+	Mutators []*MutatorConfig `json:"mutators,omitempty" yaml:"mutators,omitempty"`
+
+	// Interpolation allows ${env:VAR} and ${file:path} references in settings files
+	Interpolation *InterpolationConfig `json:"interpolation,omitempty" yaml:"interpolation,omitempty"`
+
 	// Secret stores
 	SecretStores *vals.SecretStores `json:"secret_stores,omitempty" yaml:"secret_stores,omitempty"`
+
+	// Policies checked by preview and apply
+	Policy *PolicyConfig `json:"policy,omitempty" yaml:"policy,omitempty"`
+
+	// Cost configures cost estimation and budgets of stacks
+	Cost *CostConfig `json:"cost,omitempty" yaml:"cost,omitempty"`
+
+	// Notifications are webhooks notified of results of operations of all stacks
+	Notifications []*NotificationConfig `json:"notifications,omitempty" yaml:"notifications,omitempty"`
+
+	// Retry configures retries of requests to runtimes failed with transient errors, which are not retried if empty
+	Retry *RetryConfig `json:"retry,omitempty" yaml:"retry,omitempty"`
+
+	// IgnoreFields are fields whose differences are ignored by previews of all stacks, e.g. fields set by
+	// controllers and sidecar injectors
+	IgnoreFields []*IgnoreFieldsConfig `json:"ignoreFields,omitempty" yaml:"ignoreFields,omitempty"`
+
+	// Promotion configures how kusion promote copies configurations between stacks
+	Promotion *PromotionConfig `json:"promotion,omitempty" yaml:"promotion,omitempty"`
+}
+
+// PromotionConfig configures promotions of configurations between stacks
+type PromotionConfig struct {
+	// Exclude are keys of kcl_options which are specific to each stack and never promoted, e.g. cluster
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+}
+
+// IgnoreFieldsConfig lists fields whose differences are ignored by previews
+type IgnoreFieldsConfig struct {
+	// APIVersion and Kind select resources of the fields, e.g. apps/v1 and Deployment. Fields of all resources
+	// are ignored if both are empty, and an empty one matches all.
+	APIVersion string `json:"apiVersion,omitempty" yaml:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty" yaml:"kind,omitempty"`
+
+	// Fields are paths of fields separated by dots like --ignore-fields, e.g. metadata.generation, where dots
+	// in keys are escaped by backslashes, e.g. metadata.annotations.sidecar\.istio\.io/status
+	Fields []string `json:"fields" yaml:"fields"`
+}
+
+// Matches returns true if fields of the resource of the apiVersion and the kind are ignored
+func (c *IgnoreFieldsConfig) Matches(apiVersion, kind string) bool {
+	return (c.APIVersion == "" || c.APIVersion == apiVersion) && (c.Kind == "" || c.Kind == kind)
 }
 
 type Project struct {
@@ -117,6 +290,87 @@ func (p *Project) TableReport() string {
 // StackConfiguration is the stack configuration
 type StackConfiguration struct {
 	Name string `json:"name" yaml:"name"` // Stack name
+
+	// Base is the path of the base stack relative to this stack directory. The compile output of the stack
+	// is merged onto the one of the base stack.
+	Base string `json:"base,omitempty" yaml:"base,omitempty"`
+
+	// Secret stores of the stack, which override secret stores of the project
+	SecretStores *vals.SecretStores `json:"secret_stores,omitempty" yaml:"secret_stores,omitempty"`
+
+	// Verification requires the stack to be applied only from spec files signed by the key or the identity,
+	// e.g. specs compiled by the trusted CI. The key path is relative to the stack directory.
+	Verification *signing.VerifyOptions `json:"verification,omitempty" yaml:"verification,omitempty"`
+
+	// Notifications are webhooks notified of results of operations of the stack, besides the ones of the project
+	Notifications []*NotificationConfig `json:"notifications,omitempty" yaml:"notifications,omitempty"`
+	// Outputs are values of applied resources recorded in the state after each apply, which are read by
+	// kusion output
+	Outputs []*OutputConfig `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+
+	// IgnoreFields are fields whose differences are ignored by previews of the stack, besides the ones of the
+	// project
+	IgnoreFields []*IgnoreFieldsConfig `json:"ignoreFields,omitempty" yaml:"ignoreFields,omitempty"`
+
+	// Runtime pins runtimes of the stack, e.g. the kube context, so that operations of the stack never use the
+	// runtimes selected in the local environment
+	Runtime *RuntimeConfig `json:"runtime,omitempty" yaml:"runtime,omitempty"`
+}
+
+// RuntimeConfig pins runtimes of a stack
+type RuntimeConfig struct {
+	Kubernetes *KubernetesRuntimeConfig `json:"kubernetes,omitempty" yaml:"kubernetes,omitempty"`
+	Terraform  *TerraformRuntimeConfig  `json:"terraform,omitempty" yaml:"terraform,omitempty"`
+}
+
+// KubernetesRuntimeConfig pins the cluster of the Kubernetes runtime
+type KubernetesRuntimeConfig struct {
+	// Kubeconfig is the path of the kubeconfig relative to the stack directory, which defaults to $KUBECONFIG
+	// or ~/.kube/config
+	Kubeconfig string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`
+
+	// Context is the context in the kubeconfig, which defaults to its current context. Operations fail if the
+	// context is not in the kubeconfig.
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+
+	// CreateNamespaces creates missing namespaces of namespaced resources before applying them. Otherwise, applies
+	// fail fast listing all missing namespaces.
+	CreateNamespaces bool `json:"createNamespaces,omitempty" yaml:"createNamespaces,omitempty"`
+
+	// NamespaceLabels are labels of namespaces created by CreateNamespaces
+	NamespaceLabels map[string]string `json:"namespaceLabels,omitempty" yaml:"namespaceLabels,omitempty"`
+}
+
+// TerraformRuntimeConfig pins providers of the Terraform runtime
+type TerraformRuntimeConfig struct {
+	// Providers override configs of providers by provider names, e.g. region and profile of the aws provider
+	Providers map[string]map[string]interface{} `json:"providers,omitempty" yaml:"providers,omitempty"`
+
+	// Env are environment variables of Terraform and providers, e.g. AWS_PROFILE
+	Env map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
+
+	// Workspace is the Terraform workspace of resources of the stack, which isolates their Terraform states from
+	// the ones of other stacks. It is derived from names of the project and the stack if it is auto.
+	Workspace string `json:"workspace,omitempty" yaml:"workspace,omitempty"`
+}
+
+// AutoWorkspace derives Terraform workspaces from names of projects and stacks
+const AutoWorkspace = "auto"
+
+// OutputConfig declares an output of the stack, which is a value extracted from an applied resource
+type OutputConfig struct {
+	// Name of the output
+	Name string `json:"name" yaml:"name"`
+
+	// Resource is the ID of the resource the value is extracted from, e.g. v1:Service:default:nginx
+	Resource string `json:"resource" yaml:"resource"`
+
+	// Path is the JSONPath of the value in the live attributes of the resource, e.g.
+	// {.status.loadBalancer.ingress[0].ip}, where the braces and the leading dot can be omitted
+	Path string `json:"path" yaml:"path"`
+
+	// Description of the output
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
 }
 
 type Stack struct {
@@ -132,6 +386,30 @@ func NewStack(config *StackConfiguration, path string) *Stack {
 	}
 }
 
+// GetSecretStores returns secret stores of the project overridden by the ones configured in the stack
+func (s *Stack) GetSecretStores(project *Project) *vals.SecretStores {
+	var ss *vals.SecretStores
+	if project != nil {
+		ss = project.SecretStores
+	}
+	if s != nil {
+		ss = ss.Override(s.SecretStores)
+	}
+	return ss
+}
+
+// GetIgnoreFields returns ignored fields of the project and the stack
+func (s *Stack) GetIgnoreFields(project *Project) []*IgnoreFieldsConfig {
+	var configs []*IgnoreFieldsConfig
+	if project != nil {
+		configs = append(configs, project.IgnoreFields...)
+	}
+	if s != nil {
+		configs = append(configs, s.IgnoreFields...)
+	}
+	return configs
+}
+
 // GetName returns the name of the stack
 func (s *Stack) GetName() string {
 	return s.Name
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/pterm/pterm"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestNewProject(t *testing.T) {
@@ -305,3 +306,18 @@ func TestStack_TableReport(t *testing.T) {
 		})
 	}
 }
+
+func TestStack_GetIgnoreFields(t *testing.T) {
+	project := &Project{ProjectConfiguration: ProjectConfiguration{
+		IgnoreFields: []*IgnoreFieldsConfig{{Fields: []string{"status"}}},
+	}}
+	stack := &Stack{StackConfiguration: StackConfiguration{
+		IgnoreFields: []*IgnoreFieldsConfig{{Kind: "Deployment", Fields: []string{"spec.replicas"}}},
+	}}
+	configs := stack.GetIgnoreFields(project)
+	assert.Len(t, configs, 2)
+	assert.True(t, configs[0].Matches("v1", "Service"))
+	assert.True(t, configs[1].Matches("apps/v1", "Deployment"))
+	assert.False(t, configs[1].Matches("v1", "Service"))
+	assert.Nil(t, (*Stack)(nil).GetIgnoreFields(nil))
+}
@@ -0,0 +1,469 @@
+// Package promote promotes configurations of a stack to another stack of the project, e.g. image tags and
+// values tested in dev to staging. Values are kcl_options in settings files of stacks and images are ones of
+// containers in their compiled specs.
+package promote
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// Kinds of changes
+const (
+	KindValue = "value"
+	KindImage = "image"
+)
+
+const optionsKey = "kcl_options"
+
+// SettingsFiles are settings files of stacks whose kcl_options are promoted, where later ones override
+// earlier ones
+var SettingsFiles = []string{filepath.Join(projectstack.CiTestDir, projectstack.SettingsFile), projectstack.KclFile}
+
+// imageFileExts are extensions of files in stack directories where references of images are replaced
+var imageFileExts = map[string]bool{
+	".k": true, ".yaml": true, ".yml": true, ".json": true, ".jsonnet": true, ".libsonnet": true, ".cue": true,
+}
+
+// Config is the configuration of a stack which can be promoted
+type Config struct {
+	// Values are kcl_options of the stack keyed by their keys
+	Values map[string]interface{} `json:"values,omitempty" yaml:"values,omitempty"`
+
+	// Images are references of container images keyed by their repositories, e.g. nginx:1.25
+	Images map[string]string `json:"images,omitempty" yaml:"images,omitempty"`
+}
+
+// Change is a value or an image promoted to the target stack
+type Change struct {
+	Kind string
+
+	// Key is the key of the value or the repository of the image
+	Key string
+
+	// Old is the value or the image reference in the target stack, which is nil for values added
+	Old interface{}
+
+	// New is the value or the image reference in the source stack
+	New interface{}
+}
+
+// LoadValues returns kcl_options in settings files of the stack directory
+func LoadValues(stackDir string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for _, file := range SettingsFiles {
+		doc, err := loadSettings(filepath.Join(stackDir, file))
+		if err != nil {
+			return nil, err
+		}
+		if doc == nil {
+			continue
+		}
+		options, err := optionsOf(doc, false)
+		if err != nil {
+			return nil, fmt.Errorf("%s of %s: %w", optionsKey, file, err)
+		}
+		if options == nil {
+			continue
+		}
+		for _, item := range options.Content {
+			key, value := optionOf(item)
+			if key == "" {
+				continue
+			}
+			var v interface{}
+			if value != nil {
+				if err = value.Decode(&v); err != nil {
+					return nil, fmt.Errorf("decode the value of %s in %s: %w", key, file, err)
+				}
+			}
+			values[key] = v
+		}
+	}
+	return values, nil
+}
+
+// Images returns references of images of containers of Kubernetes resources in the spec keyed by their
+// repositories. The first reference found is kept if a repository is referenced by several tags.
+func Images(spec *models.Spec) map[string]string {
+	images := map[string]string{}
+	if spec == nil {
+		return images
+	}
+	for _, res := range spec.Resources {
+		if res.Type != runtime.Kubernetes {
+			continue
+		}
+		collectImages(res.Attributes, images)
+	}
+	return images
+}
+
+func collectImages(v interface{}, images map[string]string) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if key == "containers" || key == "initContainers" {
+				containers, _ := field.([]interface{})
+				for _, c := range containers {
+					container, _ := c.(map[string]interface{})
+					if image, ok := container["image"].(string); ok && image != "" {
+						if _, found := images[Repository(image)]; !found {
+							images[Repository(image)] = image
+						}
+					}
+				}
+				continue
+			}
+			collectImages(field, images)
+		}
+	case []interface{}:
+		for _, item := range value {
+			collectImages(item, images)
+		}
+	}
+}
+
+// Repository returns the repository of the image reference without its tag and digest
+func Repository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// Diff returns changes to promote the configuration from to the configuration to. Values are promoted except
+// the excluded keys, and images are promoted if their repositories are also referenced by the target stack.
+func Diff(from, to *Config, exclude []string) []*Change {
+	excluded := map[string]bool{}
+	for _, key := range exclude {
+		excluded[key] = true
+	}
+
+	var changes []*Change
+	for _, key := range sortedKeys(from.Values) {
+		if excluded[key] {
+			continue
+		}
+		old, ok := to.Values[key]
+		if ok && reflect.DeepEqual(old, from.Values[key]) {
+			continue
+		}
+		changes = append(changes, &Change{Kind: KindValue, Key: key, Old: old, New: from.Values[key]})
+	}
+
+	repos := make([]string, 0, len(from.Images))
+	for repo := range from.Images {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	for _, repo := range repos {
+		old, ok := to.Images[repo]
+		if !ok || old == from.Images[repo] {
+			continue
+		}
+		changes = append(changes, &Change{Kind: KindImage, Key: repo, Old: old, New: from.Images[repo]})
+	}
+	return changes
+}
+
+// Promoted returns the configuration after changes are promoted to it
+func Promoted(config *Config, changes []*Change) *Config {
+	promoted := &Config{Values: map[string]interface{}{}, Images: map[string]string{}}
+	for k, v := range config.Values {
+		promoted.Values[k] = v
+	}
+	for k, v := range config.Images {
+		promoted.Images[k] = v
+	}
+	for _, c := range changes {
+		switch c.Kind {
+		case KindValue:
+			promoted.Values[c.Key] = c.New
+		case KindImage:
+			promoted.Images[c.Key] = c.New.(string)
+		}
+	}
+	return promoted
+}
+
+// Apply writes changes into files of the stack directory. Values are set in the last settings file defining
+// them, or kcl.yaml if none does. References of images are replaced in files of the stack directory, and
+// changes of images not referenced by any file are returned, e.g. images defined in a shared base.
+func Apply(stackDir string, changes []*Change) ([]*Change, error) {
+	if err := applyValues(stackDir, changes); err != nil {
+		return nil, err
+	}
+
+	var images []*Change
+	for _, c := range changes {
+		if c.Kind == KindImage {
+			images = append(images, c)
+		}
+	}
+	if len(images) == 0 {
+		return nil, nil
+	}
+	replaced := map[*Change]bool{}
+	err := filepath.WalkDir(stackDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// skip hidden directories and nested stacks
+			if path != stackDir && (strings.HasPrefix(d.Name(), ".") || projectstack.IsStack(path)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !imageFileExts[filepath.Ext(path)] {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		content := string(data)
+		for _, c := range images {
+			if updated := replaceImage(content, c.Old.(string), c.New.(string)); updated != content {
+				content = updated
+				replaced[c] = true
+			}
+		}
+		if content == string(data) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, []byte(content), info.Mode().Perm())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var unresolved []*Change
+	for _, c := range images {
+		if !replaced[c] {
+			unresolved = append(unresolved, c)
+		}
+	}
+	return unresolved, nil
+}
+
+// replaceImage replaces the image reference old with new in the content, where the reference must not be a
+// part of a longer one, e.g. nginx:1.2 in nginx:1.25
+func replaceImage(content, old, new string) string {
+	pattern := regexp.MustCompile(`(^|[^\w./:@-])` + regexp.QuoteMeta(old) + `($|[^\w.:@-])`)
+	return pattern.ReplaceAllString(content, "${1}"+strings.ReplaceAll(new, "$", "$$")+"${2}")
+}
+
+func applyValues(stackDir string, changes []*Change) error {
+	docs := map[string]*yamlv3.Node{}
+	changed := map[string]bool{}
+	for _, c := range changes {
+		if c.Kind != KindValue {
+			continue
+		}
+		// the last settings file defining the key overrides the others
+		target := projectstack.KclFile
+		for _, file := range SettingsFiles {
+			doc, err := cachedSettings(docs, stackDir, file)
+			if err != nil {
+				return err
+			}
+			if doc == nil {
+				continue
+			}
+			options, err := optionsOf(doc, false)
+			if err != nil {
+				return fmt.Errorf("%s of %s: %w", optionsKey, file, err)
+			}
+			if findOption(options, c.Key) != nil {
+				target = file
+			}
+		}
+
+		doc, err := cachedSettings(docs, stackDir, target)
+		if err != nil {
+			return err
+		}
+		if doc == nil {
+			doc = &yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{{Kind: yamlv3.MappingNode}}}
+			docs[target] = doc
+		}
+		options, err := optionsOf(doc, true)
+		if err != nil {
+			return fmt.Errorf("%s of %s: %w", optionsKey, target, err)
+		}
+		changed[target] = true
+		value := &yamlv3.Node{}
+		if err = value.Encode(c.New); err != nil {
+			return err
+		}
+		if item := findOption(options, c.Key); item != nil {
+			for i := 0; i+1 < len(item.Content); i += 2 {
+				if item.Content[i].Value == "value" {
+					item.Content[i+1] = value
+				}
+			}
+			if _, v := optionOf(item); v == nil {
+				item.Content = append(item.Content, scalar("value"), value)
+			}
+			continue
+		}
+		options.Content = append(options.Content, &yamlv3.Node{
+			Kind:    yamlv3.MappingNode,
+			Content: []*yamlv3.Node{scalar("key"), scalar(c.Key), scalar("value"), value},
+		})
+	}
+
+	for file := range changed {
+		data, err := encode(docs[file])
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(stackDir, file)
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err = os.WriteFile(path, data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cachedSettings loads the settings file once, so that several changes are written into the same document
+func cachedSettings(docs map[string]*yamlv3.Node, stackDir, file string) (*yamlv3.Node, error) {
+	if doc, ok := docs[file]; ok {
+		return doc, nil
+	}
+	doc, err := loadSettings(filepath.Join(stackDir, file))
+	if err != nil {
+		return nil, err
+	}
+	docs[file] = doc
+	return doc, nil
+}
+
+// loadSettings returns the document of the settings file, or nil if it doesn't exist
+func loadSettings(path string) (*yamlv3.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	doc := &yamlv3.Node{}
+	if err = yamlv3.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		// the file is empty
+		doc = &yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{{Kind: yamlv3.MappingNode}}}
+	}
+	return doc, nil
+}
+
+// optionsOf returns the sequence of kcl_options in the document, which is added if create is true
+func optionsOf(doc *yamlv3.Node, create bool) (*yamlv3.Node, error) {
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yamlv3.MappingNode {
+		return nil, errors.New("settings must be a mapping")
+	}
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != optionsKey {
+			continue
+		}
+		options := root.Content[i+1]
+		if options.Kind == yamlv3.ScalarNode && options.Tag == "!!null" {
+			if !create {
+				return nil, nil
+			}
+			*options = yamlv3.Node{Kind: yamlv3.SequenceNode}
+		}
+		if options.Kind != yamlv3.SequenceNode {
+			return nil, errors.New("must be a list of keys and values")
+		}
+		return options, nil
+	}
+	if !create {
+		return nil, nil
+	}
+	options := &yamlv3.Node{Kind: yamlv3.SequenceNode}
+	root.Content = append(root.Content, scalar(optionsKey), options)
+	return options, nil
+}
+
+// optionOf returns the key and the value node of the item of kcl_options
+func optionOf(item *yamlv3.Node) (key string, value *yamlv3.Node) {
+	if item.Kind != yamlv3.MappingNode {
+		return "", nil
+	}
+	for i := 0; i+1 < len(item.Content); i += 2 {
+		switch item.Content[i].Value {
+		case "key":
+			key = item.Content[i+1].Value
+		case "value":
+			value = item.Content[i+1]
+		}
+	}
+	return key, value
+}
+
+func findOption(options *yamlv3.Node, key string) *yamlv3.Node {
+	if options == nil {
+		return nil
+	}
+	for _, item := range options.Content {
+		if k, _ := optionOf(item); k == key {
+			return item
+		}
+	}
+	return nil
+}
+
+func encode(doc *yamlv3.Node) ([]byte, error) {
+	var sb strings.Builder
+	encoder := yamlv3.NewEncoder(&sb)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(sb.String()), nil
+}
+
+func scalar(value string) *yamlv3.Node {
+	return &yamlv3.Node{Kind: yamlv3.ScalarNode, Value: value}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
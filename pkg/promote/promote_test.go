@@ -0,0 +1,190 @@
+package promote
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestLoadValues(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "ci-test", "settings.yaml"), `kcl_options:
+  - key: cluster
+    value: dev
+  - key: replicas
+    value: 1
+`)
+	writeFile(t, filepath.Join(dir, "kcl.yaml"), `kcl_cli_configs:
+  file:
+    - main.k
+kcl_options:
+  - key: replicas
+    value: 2
+  - key: features
+    value: [a, b]
+`)
+
+	values, err := LoadValues(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"cluster":  "dev",
+		"replicas": 2,
+		"features": []interface{}{"a", "b"},
+	}, values)
+
+	values, err = LoadValues(t.TempDir())
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+
+	bad := t.TempDir()
+	writeFile(t, filepath.Join(bad, "kcl.yaml"), "kcl_options: foo\n")
+	_, err = LoadValues(bad)
+	assert.EqualError(t, err, "kcl_options of kcl.yaml: must be a list of keys and values")
+}
+
+func TestImages(t *testing.T) {
+	spec := &models.Spec{Resources: models.Resources{
+		{
+			ID:   "apps/v1:Deployment:default:web",
+			Type: runtime.Kubernetes,
+			Attributes: map[string]interface{}{
+				"kind": "Deployment",
+				"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+					"initContainers": []interface{}{map[string]interface{}{"image": "busybox@sha256:abc"}},
+					"containers": []interface{}{
+						map[string]interface{}{"image": "registry:5000/team/web:1.2.0"},
+						map[string]interface{}{"image": "nginx"},
+					},
+				}}},
+			},
+		},
+		{
+			ID:         "aliyun:alicloud:alicloud_eci:web",
+			Type:       "Terraform",
+			Attributes: map[string]interface{}{"containers": []interface{}{map[string]interface{}{"image": "redis:7"}}},
+		},
+	}}
+	assert.Equal(t, map[string]string{
+		"busybox":                "busybox@sha256:abc",
+		"registry:5000/team/web": "registry:5000/team/web:1.2.0",
+		"nginx":                  "nginx",
+	}, Images(spec))
+	assert.Empty(t, Images(nil))
+}
+
+func TestDiff(t *testing.T) {
+	from := &Config{
+		Values: map[string]interface{}{"cluster": "dev", "replicas": 2, "image.tag": "1.2.0", "debug": true},
+		Images: map[string]string{"web": "web:1.2.0", "worker": "worker:2.0", "dev-only": "dev-only:1"},
+	}
+	to := &Config{
+		Values: map[string]interface{}{"cluster": "staging", "replicas": 2, "image.tag": "1.1.0"},
+		Images: map[string]string{"web": "web:1.1.0", "worker": "worker:2.0"},
+	}
+	changes := Diff(from, to, []string{"cluster"})
+	assert.Equal(t, []*Change{
+		{Kind: KindValue, Key: "debug", New: true},
+		{Kind: KindValue, Key: "image.tag", Old: "1.1.0", New: "1.2.0"},
+		{Kind: KindImage, Key: "web", Old: "web:1.1.0", New: "web:1.2.0"},
+	}, changes)
+
+	assert.Equal(t, &Config{
+		Values: map[string]interface{}{"cluster": "staging", "replicas": 2, "image.tag": "1.2.0", "debug": true},
+		Images: map[string]string{"web": "web:1.2.0", "worker": "worker:2.0"},
+	}, Promoted(to, changes))
+	assert.Equal(t, "1.1.0", to.Values["image.tag"])
+}
+
+func TestApply(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "ci-test", "settings.yaml"), `kcl_options:
+  - key: replicas
+    value: 1
+`)
+	writeFile(t, filepath.Join(dir, "kcl.yaml"), `# files compiled
+kcl_cli_configs:
+  file:
+    - main.k
+`)
+	writeFile(t, filepath.Join(dir, "main.k"), `web = "web:1.1.0"
+web2 = "web:1.1.05"
+sidecar = "my-web:1.1.0"
+`)
+	writeFile(t, filepath.Join(dir, "nested", "stack.yaml"), "name: nested\n")
+	writeFile(t, filepath.Join(dir, "nested", "main.k"), `web = "web:1.1.0"`)
+
+	unresolved, err := Apply(dir, []*Change{
+		{Kind: KindValue, Key: "replicas", Old: 1, New: 3},
+		{Kind: KindValue, Key: "image.tag", New: "1.2.0"},
+		{Kind: KindImage, Key: "web", Old: "web:1.1.0", New: "web:1.2.0"},
+		{Kind: KindImage, Key: "base", Old: "base:1", New: "base:2"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []*Change{{Kind: KindImage, Key: "base", Old: "base:1", New: "base:2"}}, unresolved)
+
+	assert.Equal(t, `kcl_options:
+  - key: replicas
+    value: 3
+`, readFile(t, filepath.Join(dir, "ci-test", "settings.yaml")))
+	assert.Equal(t, `# files compiled
+kcl_cli_configs:
+  file:
+    - main.k
+kcl_options:
+  - key: image.tag
+    value: 1.2.0
+`, readFile(t, filepath.Join(dir, "kcl.yaml")))
+	assert.Equal(t, `web = "web:1.2.0"
+web2 = "web:1.1.05"
+sidecar = "my-web:1.1.0"
+`, readFile(t, filepath.Join(dir, "main.k")))
+	assert.Equal(t, `web = "web:1.1.0"`, readFile(t, filepath.Join(dir, "nested", "main.k")))
+
+	values, err := LoadValues(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"replicas": 3, "image.tag": "1.2.0"}, values)
+}
+
+func TestApply_NewSettings(t *testing.T) {
+	dir := t.TempDir()
+	unresolved, err := Apply(dir, []*Change{{Kind: KindValue, Key: "replicas", New: 2}})
+	assert.NoError(t, err)
+	assert.Empty(t, unresolved)
+	assert.Equal(t, `kcl_options:
+  - key: replicas
+    value: 2
+`, readFile(t, filepath.Join(dir, "kcl.yaml")))
+}
+
+func TestRepository(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                         "nginx",
+		"nginx:1.25":                    "nginx",
+		"localhost:5000/nginx":          "localhost:5000/nginx",
+		"localhost:5000/nginx:1.25":     "localhost:5000/nginx",
+		"nginx@sha256:abc":              "nginx",
+		"ghcr.io/a/nginx:1.25@sha256:a": "ghcr.io/a/nginx",
+	}
+	for image, want := range tests {
+		assert.Equal(t, want, Repository(image), image)
+	}
+}
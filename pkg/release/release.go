@@ -0,0 +1,150 @@
+// Package release queries releases of kusion in the release channel, and upgrades the running binary to one of
+// them after verifying the signature of its checksums and the checksum of its archive.
+//
+// Releases are published by goreleaser to GitHub, whose checksums.txt is signed by cosign keyless signing in the
+// release workflow. Air-gapped environments set KUSION_OFFLINE=true to disable all requests to the channel.
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"kusionstack.io/kusion/pkg/signing"
+)
+
+const (
+	// EnvURL overrides the URL of the release channel, e.g. a mirror of GitHub releases API in the intranet
+	EnvURL = "KUSION_RELEASE_URL"
+	// EnvOffline disables requests to the release channel if it is true, including update checks of commands
+	EnvOffline = "KUSION_OFFLINE"
+
+	// DefaultURL is the GitHub releases API of kusion
+	DefaultURL = "https://api.github.com/repos/KusionStack/kusion/releases"
+
+	// ChecksumsFile is the asset of checksums of archives, which is signed by the release workflow
+	ChecksumsFile = "checksums.txt"
+
+	// workflowIdentity is the identity of certificates of signatures made by the release workflow of a tag
+	workflowIdentity = "https://github.com/KusionStack/kusion/.github/workflows/release.yaml@refs/tags/"
+	// oidcIssuer is the issuer of identities of GitHub Actions
+	oidcIssuer = "https://token.actions.githubusercontent.com"
+)
+
+// Timeout is the timeout of requests to the release channel
+var Timeout = 5 * time.Minute
+
+var client = &http.Client{Timeout: Timeout}
+
+// ErrOffline is returned by requests to the release channel if they are disabled by KUSION_OFFLINE
+var ErrOffline = fmt.Errorf("requests to the release channel are disabled by %s=true", EnvOffline)
+
+// Release is a release of kusion
+type Release struct {
+	// Version is the tag of the release, e.g. v0.7.0
+	Version string  `json:"tag_name"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset is a file of a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Offline returns true if requests to the release channel are disabled
+func Offline() bool {
+	return os.Getenv(EnvOffline) == "true"
+}
+
+// URL returns the URL of the release channel
+func URL() string {
+	if u := os.Getenv(EnvURL); u != "" {
+		return strings.TrimSuffix(u, "/")
+	}
+	return DefaultURL
+}
+
+// Latest returns the latest release
+func Latest(ctx context.Context) (*Release, error) {
+	return get(ctx, URL()+"/latest")
+}
+
+// Get returns the release of the version, e.g. v0.7.0
+func Get(ctx context.Context, version string) (*Release, error) {
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	return get(ctx, URL()+"/tags/"+version)
+}
+
+func get(ctx context.Context, url string) (*Release, error) {
+	body, err := download(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	r := &Release{}
+	if err = json.NewDecoder(body).Decode(r); err != nil {
+		return nil, fmt.Errorf("decode the release from %s failed: %w", url, err)
+	}
+	return r, nil
+}
+
+// Asset returns the asset of the name, or nil if the release has no such asset
+func (r *Release) Asset(name string) *Asset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+// ArchiveName returns the name of the archive of the release for the platform of this process
+func (r *Release) ArchiveName() string {
+	return archiveName(r.Version, runtime.GOOS, runtime.GOARCH)
+}
+
+// VerifyOptions returns options to verify signatures made by the release workflow of the release
+func (r *Release) VerifyOptions() *signing.VerifyOptions {
+	return &signing.VerifyOptions{
+		CertificateIdentity:   workflowIdentity + r.Version,
+		CertificateOIDCIssuer: oidcIssuer,
+	}
+}
+
+// archiveName returns the name of the archive of the version for the platform, see archives of .goreleaser.yml
+func archiveName(version, goos, goarch string) string {
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("kusion_%s_%s_%s%s", strings.TrimPrefix(version, "v"), goos, goarch, ext)
+}
+
+// download returns the body of the URL
+func download(ctx context.Context, url string) (io.ReadCloser, error) {
+	if Offline() {
+		return nil, ErrOffline
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("get %s failed: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,57 @@
+package release
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestAndGet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/releases/latest":
+			_, _ = w.Write([]byte(`{"tag_name":"v0.8.0","assets":[{"name":"checksums.txt","browser_download_url":"http://x/checksums.txt"}]}`))
+		case "/releases/tags/v0.7.0":
+			_, _ = w.Write([]byte(`{"tag_name":"v0.7.0"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	t.Setenv(EnvURL, server.URL+"/releases/")
+
+	r, err := Latest(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "v0.8.0", r.Version)
+	assert.Equal(t, "http://x/checksums.txt", r.Asset(ChecksumsFile).URL)
+	assert.Nil(t, r.Asset("unknown"))
+
+	r, err = Get(context.Background(), "0.7.0")
+	require.NoError(t, err)
+	assert.Equal(t, "v0.7.0", r.Version)
+
+	_, err = Get(context.Background(), "v0.6.0")
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestOffline(t *testing.T) {
+	t.Setenv(EnvOffline, "true")
+	assert.True(t, Offline())
+	_, err := Latest(context.Background())
+	assert.ErrorIs(t, err, ErrOffline)
+}
+
+func TestArchiveName(t *testing.T) {
+	assert.Equal(t, "kusion_0.7.0_linux_amd64.tar.gz", archiveName("v0.7.0", "linux", "amd64"))
+	assert.Equal(t, "kusion_0.7.0_windows_amd64.zip", archiveName("v0.7.0", "windows", "amd64"))
+}
+
+func TestVerifyOptions(t *testing.T) {
+	o := (&Release{Version: "v0.7.0"}).VerifyOptions()
+	assert.Equal(t, "https://github.com/KusionStack/kusion/.github/workflows/release.yaml@refs/tags/v0.7.0", o.CertificateIdentity)
+	assert.Equal(t, "https://token.actions.githubusercontent.com", o.CertificateOIDCIssuer)
+}
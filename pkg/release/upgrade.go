@@ -0,0 +1,203 @@
+package release
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"kusionstack.io/kusion/pkg/signing"
+)
+
+// verifySignature verifies the signature of the checksums file, which is replaced in tests
+var verifySignature = signing.Verify
+
+// Upgrade replaces the executable with the binary of the release. The signature of checksums of the release is
+// verified before the archive of this platform is downloaded, and the archive is verified by its checksum.
+func Upgrade(ctx context.Context, r *Release, executable string) error {
+	dir, err := os.MkdirTemp("", "kusion-upgrade")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	// download and verify checksums signed by the release workflow
+	checksums := filepath.Join(dir, ChecksumsFile)
+	for _, name := range []string{ChecksumsFile, signing.SignatureFile(ChecksumsFile), signing.CertificateFile(ChecksumsFile)} {
+		if err = downloadAsset(ctx, r, name, filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	data, err := os.ReadFile(checksums)
+	if err != nil {
+		return err
+	}
+	if err = verifySignature(checksums, data, r.VerifyOptions()); err != nil {
+		return err
+	}
+
+	// download and verify the archive of this platform
+	name := r.ArchiveName()
+	want, err := checksumOf(data, name)
+	if err != nil {
+		return err
+	}
+	archive := filepath.Join(dir, name)
+	if err = downloadAsset(ctx, r, name, archive); err != nil {
+		return err
+	}
+	got, err := sha256File(archive)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("checksum of %s is %s, want %s in %s", name, got, want, ChecksumsFile)
+	}
+
+	return replace(archive, executable)
+}
+
+// downloadAsset downloads the asset of the name to the path
+func downloadAsset(ctx context.Context, r *Release, name, path string) error {
+	asset := r.Asset(name)
+	if asset == nil {
+		return fmt.Errorf("the release %s has no asset %s", r.Version, name)
+	}
+	body, err := download(ctx, asset.URL)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err = io.Copy(f, body); err != nil {
+		return fmt.Errorf("download %s failed: %w", name, err)
+	}
+	return nil
+}
+
+// checksumOf returns the checksum of the file in the content of the checksums file, whose lines are like
+// `<sha256>  <name>`
+func checksumOf(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == name {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no checksum of %s in %s", name, ChecksumsFile)
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// replace extracts the binary from the archive beside the executable, and renames it to the executable, so that
+// the executable is never left half written
+func replace(archive, executable string) error {
+	binary := "kusion"
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	tmp := executable + ".new"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o755)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	if strings.HasSuffix(archive, ".zip") {
+		err = extractZip(archive, binary, out)
+	} else {
+		err = extractTarGz(archive, binary, out)
+	}
+	if e := out.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+
+	// running executables can not be replaced on Windows, but they can be renamed
+	if runtime.GOOS == "windows" {
+		old := executable + ".old"
+		_ = os.Remove(old)
+		if err = os.Rename(executable, old); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp, executable)
+}
+
+func extractTarGz(archive, binary string, out io.Writer) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("no %s in %s", binary, filepath.Base(archive))
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag == tar.TypeReg && filepath.Base(header.Name) == binary {
+			_, err = io.Copy(out, tr)
+			return err
+		}
+	}
+}
+
+func extractZip(archive, binary string, out io.Writer) error {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || filepath.Base(f.Name) != binary {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		_, err = io.Copy(out, rc)
+		return err
+	}
+	return fmt.Errorf("no %s in %s", binary, filepath.Base(archive))
+}
@@ -0,0 +1,112 @@
+package release
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kusionstack.io/kusion/pkg/signing"
+)
+
+func tarGz(t *testing.T, name string, content []byte) []byte {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+	_, err := tw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+func mockVerifySignature(t *testing.T, err error) *string {
+	verified := new(string)
+	old := verifySignature
+	verifySignature = func(file string, _ []byte, _ *signing.VerifyOptions) error {
+		*verified = filepath.Base(file)
+		return err
+	}
+	t.Cleanup(func() { verifySignature = old })
+	return verified
+}
+
+func newRelease(t *testing.T, archive []byte, checksum string) *Release {
+	name := archiveName("v0.8.0", runtime.GOOS, runtime.GOARCH)
+	files := map[string][]byte{
+		ChecksumsFile:                          []byte(fmt.Sprintf("%s  %s\n", checksum, name)),
+		signing.SignatureFile(ChecksumsFile):   []byte("sig"),
+		signing.CertificateFile(ChecksumsFile): []byte("pem"),
+		name:                                   archive,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(files[filepath.Base(r.URL.Path)])
+	}))
+	t.Cleanup(server.Close)
+	r := &Release{Version: "v0.8.0"}
+	for n := range files {
+		r.Assets = append(r.Assets, Asset{Name: n, URL: server.URL + "/" + n})
+	}
+	return r
+}
+
+func TestUpgrade(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("archives of windows are zip")
+	}
+	archive := tarGz(t, "kusion", []byte("new"))
+	sum := sha256.Sum256(archive)
+	exe := filepath.Join(t.TempDir(), "kusion")
+	require.NoError(t, os.WriteFile(exe, []byte("old"), 0o755))
+
+	t.Run("upgraded", func(t *testing.T) {
+		verified := mockVerifySignature(t, nil)
+		err := Upgrade(context.Background(), newRelease(t, archive, hex.EncodeToString(sum[:])), exe)
+		require.NoError(t, err)
+		assert.Equal(t, ChecksumsFile, *verified)
+		content, err := os.ReadFile(exe)
+		require.NoError(t, err)
+		assert.Equal(t, "new", string(content))
+		require.NoError(t, os.WriteFile(exe, []byte("old"), 0o755))
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		mockVerifySignature(t, errors.New("invalid signature"))
+		err := Upgrade(context.Background(), newRelease(t, archive, hex.EncodeToString(sum[:])), exe)
+		assert.ErrorContains(t, err, "invalid signature")
+		content, _ := os.ReadFile(exe)
+		assert.Equal(t, "old", string(content))
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		mockVerifySignature(t, nil)
+		err := Upgrade(context.Background(), newRelease(t, archive, "0000"), exe)
+		assert.ErrorContains(t, err, "want 0000")
+		content, _ := os.ReadFile(exe)
+		assert.Equal(t, "old", string(content))
+	})
+
+	t.Run("no binary", func(t *testing.T) {
+		mockVerifySignature(t, nil)
+		other := tarGz(t, "README.md", []byte("readme"))
+		otherSum := sha256.Sum256(other)
+		err := Upgrade(context.Background(), newRelease(t, other, hex.EncodeToString(otherSum[:])), exe)
+		assert.ErrorContains(t, err, "no kusion in")
+		_, err = os.Stat(exe + ".new")
+		assert.True(t, os.IsNotExist(err))
+	})
+}
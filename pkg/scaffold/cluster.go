@@ -0,0 +1,388 @@
+package scaffold
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"kusionstack.io/kusion/pkg/engine"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/engine/states/local"
+	"kusionstack.io/kusion/pkg/generator/manifest"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// Formats of the stack content generated from live objects
+const (
+	ManifestFormat = "manifest"
+	KCLFormat      = "kcl"
+)
+
+// skippedResources are resources which are managed by the cluster, and should not be imported
+var skippedResources = map[string]bool{
+	"events":                    true,
+	"events.events.k8s.io":      true,
+	"endpoints":                 true,
+	"endpointslices":            true,
+	"leases":                    true,
+	"pods.metrics.k8s.io":       true,
+	"controllerrevisions":       true,
+	"localsubjectaccessreviews": true,
+}
+
+// skippedObjects are objects created by the cluster in every namespace, keyed by Kind/name
+var skippedObjects = map[string]bool{
+	"ServiceAccount/default":     true,
+	"ConfigMap/kube-root-ca.crt": true,
+}
+
+// helmValuesFile is the values file of the stack generated from a Helm release, with the values the release
+// was installed with
+const helmValuesFile = "values.yaml"
+
+// serverAnnotations are annotations set by the cluster or kubectl
+var serverAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"deployment.kubernetes.io/revision",
+	"pv.kubernetes.io/bind-completed",
+	"pv.kubernetes.io/bound-by-controller",
+	"volume.beta.kubernetes.io/storage-provisioner",
+	"volume.kubernetes.io/storage-provisioner",
+}
+
+// ExportNamespace lists live objects of the namespaced resources in the namespace. Objects owned by other
+// objects, e.g. Pods of ReplicaSets, and objects created by the cluster are skipped, since they are managed
+// by the cluster. Returned objects are cleaned by CleanObject and sorted by their resource IDs.
+func ExportNamespace(
+	ctx context.Context,
+	client dynamic.Interface,
+	resourceLists []*metav1.APIResourceList,
+	namespace string,
+) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	for _, list := range resourceLists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range list.APIResources {
+			if !r.Namespaced || strings.Contains(r.Name, "/") || !hasVerb(r.Verbs, "list") {
+				continue
+			}
+			qualified := r.Name
+			if gv.Group != "" {
+				qualified = r.Name + "." + gv.Group
+			}
+			if skippedResources[r.Name] || skippedResources[qualified] {
+				continue
+			}
+
+			items, err := client.Resource(gv.WithResource(r.Name)).Namespace(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("list %s in the namespace %s failed: %w", qualified, namespace, err)
+			}
+			for i := range items.Items {
+				obj := &items.Items[i]
+				if len(obj.GetOwnerReferences()) > 0 || skippedObjects[obj.GetKind()+"/"+obj.GetName()] {
+					continue
+				}
+				if obj.GetKind() == "Secret" && obj.Object["type"] == "kubernetes.io/service-account-token" {
+					continue
+				}
+				if obj.GetAPIVersion() == "" {
+					obj.SetAPIVersion(list.GroupVersion)
+				}
+				if obj.GetKind() == "" {
+					obj.SetKind(r.Kind)
+				}
+				CleanObject(obj)
+				objects = append(objects, obj)
+			}
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return resourceID(objects[i]) < resourceID(objects[j])
+	})
+	return objects, nil
+}
+
+func hasVerb(verbs metav1.Verbs, verb string) bool {
+	for _, v := range verbs {
+		if v == verb {
+			return true
+		}
+	}
+	return false
+}
+
+// CleanObject strips fields managed by the cluster from the live object, so that it can be applied again
+func CleanObject(obj *unstructured.Unstructured) {
+	for _, field := range []string{
+		"uid", "resourceVersion", "generation", "creationTimestamp", "deletionTimestamp",
+		"deletionGracePeriodSeconds", "selfLink", "managedFields",
+	} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(obj.Object, "status")
+
+	annotations := obj.GetAnnotations()
+	for _, a := range serverAnnotations {
+		delete(annotations, a)
+	}
+	if len(annotations) == 0 {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "annotations")
+	} else {
+		obj.SetAnnotations(annotations)
+	}
+
+	switch obj.GetKind() {
+	case "Service":
+		// cluster IPs and node ports are allocated by the cluster unless the service is headless
+		if ip, _, _ := unstructured.NestedString(obj.Object, "spec", "clusterIP"); ip != "None" {
+			unstructured.RemoveNestedField(obj.Object, "spec", "clusterIP")
+			unstructured.RemoveNestedField(obj.Object, "spec", "clusterIPs")
+		}
+		unstructured.RemoveNestedField(obj.Object, "spec", "healthCheckNodePort")
+	case "PersistentVolumeClaim":
+		unstructured.RemoveNestedField(obj.Object, "spec", "volumeName")
+	}
+}
+
+func resourceID(obj *unstructured.Unstructured) string {
+	return engine.BuildIDForKubernetes(obj.GetAPIVersion(), obj.GetKind(), obj.GetNamespace(), obj.GetName())
+}
+
+// ClusterProject describes the project generated from live objects
+type ClusterProject struct {
+	// ProjectName and StackName are names of the project and the stack
+	ProjectName string
+	StackName   string
+	// Format is the format of the stack content, manifest or kcl
+	Format string
+	// Force overwrites existing files
+	Force bool
+	// Release is the Helm release the objects are read from, whose chart is rendered by the Helm generator
+	// in the helm format
+	Release *HelmRelease
+	// ChartRepo is the repository of the chart of the Release, which is not recorded in releases
+	ChartRepo string
+}
+
+// GenerateFromObjects writes a project with one stack into the directory, whose stack content declares the
+// objects, and writes the local state of the stack with the objects imported, so that the first apply of the
+// stack does not recreate them.
+func GenerateFromObjects(dir string, p *ClusterProject, objects []*unstructured.Unstructured) error {
+	stackDir := filepath.Join(dir, p.StackName)
+	files := map[string][]byte{}
+
+	config := &projectstack.ProjectConfiguration{Name: p.ProjectName}
+	switch p.Format {
+	case ManifestFormat:
+		config.Generator = &projectstack.GeneratorConfig{Type: projectstack.ManifestGenerator}
+		for _, obj := range objects {
+			data, err := yamlv3.Marshal(obj.Object)
+			if err != nil {
+				return err
+			}
+			name := fmt.Sprintf("%s-%s.yaml", strings.ToLower(obj.GetKind()), obj.GetName())
+			files[filepath.Join(stackDir, manifest.DefaultPath, name)] = data
+		}
+	case KCLFormat:
+		files[filepath.Join(stackDir, "main.k")] = []byte(kclStack(objects))
+		files[filepath.Join(stackDir, projectstack.KclFile)] = []byte("kcl_cli_configs:\n  file:\n    - main.k\n")
+	case HelmFormat:
+		if p.Release == nil {
+			return fmt.Errorf("unsupported format %s without a Helm release", p.Format)
+		}
+		configs := map[string]interface{}{
+			"chart":     p.Release.Chart.Metadata.Name,
+			"version":   p.Release.Chart.Metadata.Version,
+			"release":   p.Release.Name,
+			"namespace": p.Release.Namespace,
+		}
+		if p.ChartRepo != "" {
+			configs["repo"] = p.ChartRepo
+		}
+		if len(p.Release.Config) > 0 {
+			data, err := yamlv3.Marshal(p.Release.Config)
+			if err != nil {
+				return err
+			}
+			files[filepath.Join(stackDir, helmValuesFile)] = data
+			configs["valuesFiles"] = []string{helmValuesFile}
+		}
+		config.Generator = &projectstack.GeneratorConfig{Type: projectstack.HelmGenerator, Configs: configs}
+	default:
+		return fmt.Errorf("unsupported format %s, must be %s or %s", p.Format, ManifestFormat, KCLFormat)
+	}
+
+	projectData, err := yamlv3.Marshal(config)
+	if err != nil {
+		return err
+	}
+	files[filepath.Join(dir, projectstack.ProjectFile)] = projectData
+	stackData, err := yamlv3.Marshal(&projectstack.StackConfiguration{Name: p.StackName})
+	if err != nil {
+		return err
+	}
+	files[filepath.Join(stackDir, projectstack.StackFile)] = stackData
+
+	statePath := filepath.Join(stackDir, local.KusionState)
+	if !p.Force {
+		for _, f := range append(sortedKeys(files), statePath) {
+			if _, err = os.Stat(f); err == nil {
+				return fmt.Errorf("%s already exists, use --force to overwrite it", f)
+			}
+		}
+	}
+	for _, f := range sortedKeys(files) {
+		if err = os.MkdirAll(filepath.Dir(f), 0o755); err != nil {
+			return err
+		}
+		if err = os.WriteFile(f, files[f], 0o644); err != nil {
+			return err
+		}
+	}
+
+	state := states.NewState()
+	state.Project = p.ProjectName
+	state.Stack = p.StackName
+	state.Serial = 1
+	state.Operator = "kusion init --from-cluster"
+	if p.Release != nil {
+		state.Operator = "kusion init --from-helm"
+	}
+	for _, obj := range objects {
+		state.Resources = append(state.Resources, models.Resource{
+			ID:         resourceID(obj),
+			Type:       runtime.Kubernetes,
+			Attributes: obj.Object,
+		})
+	}
+	_ = os.Remove(statePath)
+	return (&local.FileSystemState{Path: statePath}).Apply(state)
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// kclStack returns KCL code which outputs the objects as resources of the spec
+func kclStack(objects []*unstructured.Unstructured) string {
+	resources := make(models.Resources, 0, len(objects))
+	for _, obj := range objects {
+		resources = append(resources, models.Resource{
+			ID:         resourceID(obj),
+			Type:       runtime.Kubernetes,
+			Attributes: obj.Object,
+		})
+	}
+	return KCLStack(resources, "kusion init --from-cluster")
+}
+
+// KCLStack returns KCL code which outputs the resources as resources of the spec, with a header telling
+// which command generated it
+func KCLStack(resources models.Resources, generatedBy string) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "# Generated by %s, each item is a resource of the spec\n", generatedBy)
+	b.WriteString("import manifests\n\n")
+	b.WriteString("_resources = [\n")
+	for i := range resources {
+		r := &resources[i]
+		b.WriteString("    {\n")
+		fmt.Fprintf(b, "        id = %s\n", kclString(r.ID))
+		fmt.Fprintf(b, "        type = %s\n", kclString(string(r.Type)))
+		if len(r.DependsOn) > 0 {
+			b.WriteString("        dependsOn = ")
+			dependsOn := make([]interface{}, 0, len(r.DependsOn))
+			for _, dep := range r.DependsOn {
+				dependsOn = append(dependsOn, dep)
+			}
+			writeKCLValue(b, dependsOn, 2)
+			b.WriteString("\n")
+		}
+		b.WriteString("        attributes = ")
+		writeKCLValue(b, r.Attributes, 2)
+		b.WriteString("\n")
+		if len(r.Extensions) > 0 {
+			b.WriteString("        extensions = ")
+			writeKCLValue(b, r.Extensions, 2)
+			b.WriteString("\n")
+		}
+		b.WriteString("    }\n")
+	}
+	b.WriteString("]\n\nmanifests.yaml_stream(_resources)\n")
+	return b.String()
+}
+
+// writeKCLValue writes the value as a KCL literal
+func writeKCLValue(b *strings.Builder, value interface{}, depth int) {
+	indent := strings.Repeat("    ", depth)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			b.WriteString("{}")
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteString("{\n")
+		for _, k := range keys {
+			fmt.Fprintf(b, "%s    %s: ", indent, kclString(k))
+			writeKCLValue(b, v[k], depth+1)
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + "}")
+	case []interface{}:
+		if len(v) == 0 {
+			b.WriteString("[]")
+			return
+		}
+		b.WriteString("[\n")
+		for _, item := range v {
+			b.WriteString(indent + "    ")
+			writeKCLValue(b, item, depth+1)
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + "]")
+	case string:
+		b.WriteString(kclString(v))
+	case bool:
+		if v {
+			b.WriteString("True")
+		} else {
+			b.WriteString("False")
+		}
+	case nil:
+		b.WriteString("None")
+	default:
+		data, _ := json.Marshal(v)
+		b.Write(data)
+	}
+}
+
+// kclString returns the quoted KCL string, where "${" is escaped to avoid the string interpolation
+func kclString(s string) string {
+	data, _ := json.Marshal(s)
+	return strings.ReplaceAll(string(data), "${", "\\${")
+}
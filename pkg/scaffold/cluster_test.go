@@ -0,0 +1,131 @@
+package scaffold
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"kusionstack.io/kusion/pkg/engine/states/local"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func newObject(apiVersion, kind, namespace, name string, fields map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":              name,
+			"namespace":         namespace,
+			"uid":               "uid",
+			"resourceVersion":   "1",
+			"creationTimestamp": "2022-01-01T00:00:00Z",
+			"annotations": map[string]interface{}{
+				"kubectl.kubernetes.io/last-applied-configuration": "{}",
+			},
+		},
+	}}
+	for k, v := range fields {
+		obj.Object[k] = v
+	}
+	return obj
+}
+
+func TestExportNamespace(t *testing.T) {
+	svc := newObject("v1", "Service", "foo", "web", map[string]interface{}{
+		"spec":   map[string]interface{}{"clusterIP": "10.0.0.1", "clusterIPs": []interface{}{"10.0.0.1"}, "type": "ClusterIP"},
+		"status": map[string]interface{}{"loadBalancer": map[string]interface{}{}},
+	})
+	cm := newObject("v1", "ConfigMap", "foo", "config", map[string]interface{}{"data": map[string]interface{}{"a": "b"}})
+	rootCA := newObject("v1", "ConfigMap", "foo", "kube-root-ca.crt", nil)
+	other := newObject("v1", "ConfigMap", "bar", "other", nil)
+	pod := newObject("v1", "Pod", "foo", "web-1", nil)
+	pod.SetOwnerReferences([]metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web"}})
+	event := newObject("v1", "Event", "foo", "e", nil)
+
+	scheme := k8sruntime.NewScheme()
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(scheme, map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "services"}:   "ServiceList",
+		{Version: "v1", Resource: "configmaps"}: "ConfigMapList",
+		{Version: "v1", Resource: "pods"}:       "PodList",
+		{Version: "v1", Resource: "events"}:     "EventList",
+	}, svc, cm, rootCA, other, pod, event)
+	resourceLists := []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "services", Kind: "Service", Namespaced: true, Verbs: metav1.Verbs{"list"}},
+			{Name: "services/status", Kind: "Service", Namespaced: true, Verbs: metav1.Verbs{"get"}},
+			{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: metav1.Verbs{"list"}},
+			{Name: "pods", Kind: "Pod", Namespaced: true, Verbs: metav1.Verbs{"list"}},
+			{Name: "events", Kind: "Event", Namespaced: true, Verbs: metav1.Verbs{"list"}},
+			{Name: "namespaces", Kind: "Namespace", Namespaced: false, Verbs: metav1.Verbs{"list"}},
+		},
+	}}
+
+	objects, err := ExportNamespace(context.Background(), client, resourceLists, "foo")
+	assert.Nil(t, err)
+	assert.Len(t, objects, 2)
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "config", "namespace": "foo"},
+		"data":       map[string]interface{}{"a": "b"},
+	}, objects[0].Object)
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "foo"},
+		"spec":       map[string]interface{}{"type": "ClusterIP"},
+	}, objects[1].Object)
+}
+
+func TestGenerateFromObjects(t *testing.T) {
+	objects := []*unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "config", "namespace": "foo"},
+			"data":       map[string]interface{}{"a": "${b}", "enabled": true, "replicas": int64(2)},
+		}},
+	}
+
+	dir := filepath.Join(t.TempDir(), "foo")
+	p := &ClusterProject{ProjectName: "foo", StackName: "dev", Format: ManifestFormat}
+	assert.Nil(t, GenerateFromObjects(dir, p, objects))
+
+	config, err := projectstack.ParseProjectConfiguration(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, projectstack.ManifestGenerator, config.Generator.Type)
+	stack, err := projectstack.GetStackFrom(filepath.Join(dir, "dev"))
+	assert.Nil(t, err)
+	assert.Equal(t, "dev", stack.Name)
+	assert.FileExists(t, filepath.Join(dir, "dev", "manifests", "configmap-config.yaml"))
+
+	state, err := (&local.FileSystemState{Path: filepath.Join(dir, "dev", local.KusionState)}).GetLatestState(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", state.Project)
+	assert.Equal(t, "v1:ConfigMap:foo:config", state.Resources[0].ID)
+
+	// existing files are not overwritten without force
+	assert.ErrorContains(t, GenerateFromObjects(dir, p, objects), "already exists")
+
+	p.Format = KCLFormat
+	p.Force = true
+	assert.Nil(t, GenerateFromObjects(dir, p, objects))
+	code, err := os.ReadFile(filepath.Join(dir, "dev", "main.k"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(code), `id = "v1:ConfigMap:foo:config"`)
+	assert.Contains(t, string(code), `"a": "\${b}"`)
+	assert.Contains(t, string(code), `"enabled": True`)
+	assert.Contains(t, string(code), `"replicas": 2`)
+
+	p.Format = "helm"
+	assert.ErrorContains(t, GenerateFromObjects(dir, p, objects), "unsupported format helm")
+}
@@ -0,0 +1,152 @@
+package scaffold
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"kusionstack.io/kusion/pkg/generator/manifest"
+	"kusionstack.io/kusion/pkg/log"
+)
+
+// HelmFormat means the stack content is the chart of a Helm release rendered by the Helm generator
+const HelmFormat = "helm"
+
+// gzipMagic is the header of gzipped releases
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// HelmRelease is a revision of a release installed by Helm, which is stored in a Secret of the release namespace
+type HelmRelease struct {
+	Name      string                 `json:"name"`
+	Namespace string                 `json:"namespace"`
+	Version   int                    `json:"version"`
+	Manifest  string                 `json:"manifest"`
+	Config    map[string]interface{} `json:"config,omitempty"`
+	Chart     struct {
+		Metadata struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"metadata"`
+	} `json:"chart"`
+}
+
+// ReadHelmRelease reads the deployed revision of the release in the namespace, or the latest revision if none of
+// them is deployed
+func ReadHelmRelease(ctx context.Context, client dynamic.Interface, namespace, name string) (*HelmRelease, error) {
+	secrets, err := client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "secrets"}).
+		Namespace(namespace).
+		List(ctx, metav1.ListOptions{LabelSelector: "owner=helm,name=" + name})
+	if err != nil {
+		return nil, err
+	}
+	if len(secrets.Items) == 0 {
+		return nil, fmt.Errorf("release %s not found in the namespace %s", name, namespace)
+	}
+
+	items := secrets.Items
+	sort.SliceStable(items, func(i, j int) bool {
+		deployedI := items[i].GetLabels()["status"] == "deployed"
+		deployedJ := items[j].GetLabels()["status"] == "deployed"
+		if deployedI != deployedJ {
+			return deployedI
+		}
+		versionI, _ := strconv.Atoi(items[i].GetLabels()["version"])
+		versionJ, _ := strconv.Atoi(items[j].GetLabels()["version"])
+		return versionI > versionJ
+	})
+	data, _, _ := unstructured.NestedString(items[0].Object, "data", "release")
+	release, err := DecodeHelmRelease(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode release in the secret %s failed: %w", items[0].GetName(), err)
+	}
+	return release, nil
+}
+
+// DecodeHelmRelease decodes the release field in the data of a release Secret, which is the base64 encoded
+// release encoded by Helm, i.e. the base64 encoded and gzipped release in JSON
+func DecodeHelmRelease(data string) (*HelmRelease, error) {
+	encoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+	b, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(b, gzipMagic) {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if b, err = io.ReadAll(r); err != nil {
+			return nil, err
+		}
+	}
+	release := &HelmRelease{}
+	if err = json.Unmarshal(b, release); err != nil {
+		return nil, err
+	}
+	return release, nil
+}
+
+// HelmReleaseObjects reads live objects in the manifest of the release, which are cleaned by CleanObject and
+// sorted by their resource IDs. Namespaced objects without namespaces are in the release namespace, and
+// objects which have been deleted from the cluster are skipped. Hooks are not in the manifest, and are not
+// read either.
+func HelmReleaseObjects(
+	ctx context.Context,
+	client dynamic.Interface,
+	mapper meta.RESTMapper,
+	release *HelmRelease,
+) ([]*unstructured.Unstructured, error) {
+	resources, err := manifest.Parse([]byte(release.Manifest))
+	if err != nil {
+		return nil, fmt.Errorf("parse manifest of the release %s failed: %w", release.Name, err)
+	}
+
+	var objects []*unstructured.Unstructured
+	for i := range resources {
+		u := &unstructured.Unstructured{Object: resources[i].Attributes}
+		gvk := u.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		var ri dynamic.ResourceInterface = client.Resource(mapping.Resource)
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			if u.GetNamespace() == "" {
+				u.SetNamespace(release.Namespace)
+			}
+			ri = client.Resource(mapping.Resource).Namespace(u.GetNamespace())
+		}
+		live, err := ri.Get(ctx, u.GetName(), metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			log.Warnf("%s of the release %s is not found in the cluster, skipped", resourceID(u), release.Name)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get %s failed: %w", resourceID(u), err)
+		}
+		CleanObject(live)
+		objects = append(objects, live)
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return resourceID(objects[i]) < resourceID(objects[j])
+	})
+	return objects, nil
+}
@@ -0,0 +1,127 @@
+package scaffold
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"kusionstack.io/kusion/pkg/engine/states/local"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+const releaseJSON = `{
+  "name": "web",
+  "namespace": "foo",
+  "version": 2,
+  "manifest": "---\n# Source: nginx/templates/service.yaml\napiVersion: v1\nkind: Service\nmetadata:\n  name: web\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: deleted\n---\napiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: web\n",
+  "config": {"replicaCount": 2},
+  "chart": {"metadata": {"name": "nginx", "version": "13.2.0"}}
+}`
+
+// releaseSecret returns the Secret storing the release as Helm does
+func releaseSecret(t *testing.T, name, version, status string) *unstructured.Unstructured {
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	_, err := w.Write([]byte(releaseJSON))
+	assert.Nil(t, err)
+	assert.Nil(t, w.Close())
+	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":      "sh.helm.release.v1." + name + ".v" + version,
+			"namespace": "foo",
+			"labels":    map[string]interface{}{"owner": "helm", "name": name, "version": version, "status": status},
+		},
+		"type": "helm.sh/release.v1",
+		"data": map[string]interface{}{"release": base64.StdEncoding.EncodeToString([]byte(encoded))},
+	}}
+}
+
+func TestReadHelmRelease(t *testing.T) {
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(k8sruntime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "secrets"}: "SecretList",
+	}, releaseSecret(t, "web", "1", "superseded"), releaseSecret(t, "web", "2", "deployed"))
+
+	release, err := ReadHelmRelease(context.TODO(), client, "foo", "web")
+	assert.Nil(t, err)
+	assert.Equal(t, "web", release.Name)
+	assert.Equal(t, 2, release.Version)
+	assert.Equal(t, "nginx", release.Chart.Metadata.Name)
+	assert.Equal(t, float64(2), release.Config["replicaCount"])
+
+	_, err = ReadHelmRelease(context.TODO(), client, "foo", "missing")
+	assert.ErrorContains(t, err, "release missing not found in the namespace foo")
+}
+
+func TestHelmReleaseObjects(t *testing.T) {
+	decoded := releaseSecret(t, "web", "2", "deployed")
+	data, _, _ := unstructured.NestedString(decoded.Object, "data", "release")
+	release, err := DecodeHelmRelease(data)
+	assert.Nil(t, err)
+
+	svc := newObject("v1", "Service", "foo", "web", map[string]interface{}{
+		"spec": map[string]interface{}{"clusterIP": "10.0.0.1", "type": "ClusterIP"},
+	})
+	role := newObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "web", nil)
+	client := fake.NewSimpleDynamicClient(k8sruntime.NewScheme(), svc, role)
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, meta.RESTScopeRoot)
+
+	objects, err := HelmReleaseObjects(context.TODO(), client, mapper, release)
+	assert.Nil(t, err)
+	// the deleted ConfigMap is skipped
+	assert.Len(t, objects, 2)
+	assert.Equal(t, "rbac.authorization.k8s.io/v1:ClusterRole:web", resourceID(objects[0]))
+	assert.Equal(t, "v1:Service:foo:web", resourceID(objects[1]))
+	assert.Empty(t, objects[1].GetResourceVersion())
+}
+
+func TestGenerateFromObjects_Helm(t *testing.T) {
+	decoded := releaseSecret(t, "web", "2", "deployed")
+	data, _, _ := unstructured.NestedString(decoded.Object, "data", "release")
+	release, err := DecodeHelmRelease(data)
+	assert.Nil(t, err)
+	objects := []*unstructured.Unstructured{newObject("v1", "Service", "foo", "web", nil)}
+
+	dir := filepath.Join(t.TempDir(), "web")
+	p := &ClusterProject{
+		ProjectName: "web",
+		StackName:   "dev",
+		Format:      HelmFormat,
+		Release:     release,
+		ChartRepo:   "https://charts.bitnami.com/bitnami",
+	}
+	assert.Nil(t, GenerateFromObjects(dir, p, objects))
+
+	config, err := projectstack.ParseProjectConfiguration(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, projectstack.HelmGenerator, config.Generator.Type)
+	assert.Equal(t, "nginx", config.Generator.Configs["chart"])
+	assert.Equal(t, "13.2.0", config.Generator.Configs["version"])
+	assert.Equal(t, "https://charts.bitnami.com/bitnami", config.Generator.Configs["repo"])
+	assert.Equal(t, []interface{}{"values.yaml"}, config.Generator.Configs["valuesFiles"])
+	values, err := os.ReadFile(filepath.Join(dir, "dev", "values.yaml"))
+	assert.Nil(t, err)
+	assert.Equal(t, "replicaCount: 2\n", string(values))
+
+	state, err := (&local.FileSystemState{Path: filepath.Join(dir, "dev", local.KusionState)}).GetLatestState(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "kusion init --from-helm", state.Operator)
+	assert.Equal(t, "v1:Service:foo:web", state.Resources[0].ID)
+}
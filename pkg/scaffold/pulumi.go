@@ -0,0 +1,198 @@
+package scaffold
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+)
+
+// Secret values in Pulumi deployments are objects whose pulumiSecretSig key is pulumiSecretValue
+const (
+	pulumiSecretSig   = "4dabf18193072939515e22adb298388d"
+	pulumiSecretValue = "1b47061264138c4ac30d75fd1eb44270"
+)
+
+// pulumiCheckpoint is the output of `pulumi stack export`, whose schema is from
+// https://github.com/pulumi/pulumi/blob/master/sdk/go/common/apitype/core.go
+type pulumiCheckpoint struct {
+	Version    int `json:"version"`
+	Deployment struct {
+		Resources []pulumiResource `json:"resources"`
+	} `json:"deployment"`
+}
+
+type pulumiResource struct {
+	URN          string                 `json:"urn"`
+	Custom       bool                   `json:"custom"`
+	Delete       bool                   `json:"delete,omitempty"`
+	Type         string                 `json:"type"`
+	Outputs      map[string]interface{} `json:"outputs,omitempty"`
+	Dependencies []string               `json:"dependencies,omitempty"`
+	External     bool                   `json:"external,omitempty"`
+}
+
+// PulumiMapping records which kusion resource a Pulumi resource is imported as, or why it is not imported
+type PulumiMapping struct {
+	// URN is the URN of the Pulumi resource
+	URN string `json:"urn" yaml:"urn"`
+
+	// ID is the ID of the kusion resource, empty if not imported
+	ID string `json:"id,omitempty" yaml:"id,omitempty"`
+
+	// Reason is why the resource is not imported
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+
+	// DroppedDependencies are URNs of dependencies not imported, which kusion will not know about
+	DroppedDependencies []string `json:"droppedDependencies,omitempty" yaml:"droppedDependencies,omitempty"`
+}
+
+// ConvertPulumiCheckpoint converts custom resources in the output of `pulumi stack export` to kusion resources.
+// Resources of the Pulumi Kubernetes provider are imported as Kubernetes resources, whose attributes are their
+// outputs cleaned by CleanObject. Resources of other providers can't be mapped to resources of the Terraform
+// runtime reliably since Pulumi renames their types and attributes, and are skipped like component resources,
+// providers and external resources. The returned mappings record the ID of each imported resource and the
+// reason of each skipped one.
+func ConvertPulumiCheckpoint(data []byte) (models.Resources, []PulumiMapping, error) {
+	var checkpoint pulumiCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, nil, fmt.Errorf("parse pulumi stack export failed: %w", err)
+	}
+	if checkpoint.Version != 3 {
+		return nil, nil, fmt.Errorf("unsupported pulumi deployment version %d, only version 3 is supported", checkpoint.Version)
+	}
+
+	var resources models.Resources
+	var mappings []PulumiMapping
+	ids := map[string]string{}
+	dependencies := map[string][]string{}
+	for _, r := range checkpoint.Deployment.Resources {
+		m := PulumiMapping{URN: r.URN}
+		switch {
+		case !r.Custom:
+			m.Reason = "component resources are not real resources"
+		case strings.HasPrefix(r.Type, "pulumi:"):
+			m.Reason = "providers are declared in the configuration of stacks"
+		case r.Delete:
+			m.Reason = "it is pending deletion"
+		case r.External:
+			m.Reason = "it is not managed by pulumi"
+		case !strings.HasPrefix(r.Type, "kubernetes:"):
+			m.Reason = fmt.Sprintf("resources of the %s provider can't be mapped to kusion resources", pulumiProvider(r.Type))
+		}
+		if m.Reason != "" {
+			mappings = append(mappings, m)
+			continue
+		}
+
+		outputs, err := pulumiPlaintext(r.Outputs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read outputs of %s failed: %w", r.URN, err)
+		}
+		attributes, _ := outputs.(map[string]interface{})
+		for k := range attributes {
+			// internal outputs of the provider, e.g. __inputs
+			if strings.HasPrefix(k, "__") {
+				delete(attributes, k)
+			}
+		}
+		obj := &unstructured.Unstructured{Object: attributes}
+		if obj.GetKind() == "" || obj.GetName() == "" {
+			m.Reason = "its outputs are not a kubernetes object"
+			mappings = append(mappings, m)
+			continue
+		}
+		CleanObject(obj)
+		annotations := obj.GetAnnotations()
+		for k := range annotations {
+			if strings.HasPrefix(k, "pulumi.com/") {
+				delete(annotations, k)
+			}
+		}
+		if len(annotations) == 0 {
+			unstructured.RemoveNestedField(obj.Object, "metadata", "annotations")
+		} else {
+			obj.SetAnnotations(annotations)
+		}
+
+		m.ID = resourceID(obj)
+		ids[r.URN] = m.ID
+		dependencies[m.ID] = r.Dependencies
+		mappings = append(mappings, m)
+		resources = append(resources, models.Resource{
+			ID:         m.ID,
+			Type:       runtime.Kubernetes,
+			Attributes: obj.Object,
+		})
+	}
+
+	index := map[string]int{}
+	for i := range mappings {
+		if mappings[i].ID != "" {
+			index[mappings[i].ID] = i
+		}
+	}
+	for i := range resources {
+		r := &resources[i]
+		m := &mappings[index[r.ID]]
+		for _, urn := range dependencies[r.ID] {
+			if id, ok := ids[urn]; ok {
+				r.DependsOn = append(r.DependsOn, id)
+			} else {
+				m.DroppedDependencies = append(m.DroppedDependencies, urn)
+			}
+		}
+		sort.Strings(r.DependsOn)
+	}
+	return resources, mappings, nil
+}
+
+// pulumiProvider returns the package of the Pulumi resource type, e.g. aws of aws:s3/bucket:Bucket
+func pulumiProvider(resourceType string) string {
+	return strings.SplitN(resourceType, ":", 2)[0]
+}
+
+// pulumiPlaintext returns the value with secrets replaced by their plaintexts, which are exported by
+// `pulumi stack export --show-secrets`
+func pulumiPlaintext(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if v[pulumiSecretSig] == pulumiSecretValue {
+			plaintext, ok := v["plaintext"].(string)
+			if !ok {
+				return nil, fmt.Errorf("secret values are encrypted, export the stack with pulumi stack export --show-secrets")
+			}
+			var secret interface{}
+			if err := json.Unmarshal([]byte(plaintext), &secret); err != nil {
+				return nil, err
+			}
+			return pulumiPlaintext(secret)
+		}
+		result := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			plain, err := pulumiPlaintext(item)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = plain
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			plain, err := pulumiPlaintext(item)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = plain
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
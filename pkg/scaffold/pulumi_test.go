@@ -0,0 +1,129 @@
+package scaffold
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion/pkg/engine/models"
+)
+
+const pulumiExport = `{
+  "version": 3,
+  "deployment": {
+    "resources": [
+      {"urn": "urn:pulumi:dev::app::pulumi:pulumi:Stack::app-dev", "custom": false, "type": "pulumi:pulumi:Stack"},
+      {"urn": "urn:pulumi:dev::app::pulumi:providers:kubernetes::default", "custom": true, "type": "pulumi:providers:kubernetes"},
+      {"urn": "urn:pulumi:dev::app::aws:s3/bucket:Bucket::logs", "custom": true, "type": "aws:s3/bucket:Bucket"},
+      {
+        "urn": "urn:pulumi:dev::app::kubernetes:core/v1:ConfigMap::config",
+        "custom": true,
+        "type": "kubernetes:core/v1:ConfigMap",
+        "outputs": {
+          "__inputs": {"data": {"a": "b"}},
+          "apiVersion": "v1",
+          "kind": "ConfigMap",
+          "metadata": {
+            "name": "config-x1y2",
+            "namespace": "default",
+            "uid": "123",
+            "annotations": {"pulumi.com/autonamed": "true"}
+          },
+          "data": {
+            "4dabf18193072939515e22adb298388d": "1b47061264138c4ac30d75fd1eb44270",
+            "plaintext": "{\"a\":\"b\"}"
+          }
+        }
+      },
+      {
+        "urn": "urn:pulumi:dev::app::kubernetes:apps/v1:Deployment::nginx",
+        "custom": true,
+        "type": "kubernetes:apps/v1:Deployment",
+        "dependencies": [
+          "urn:pulumi:dev::app::kubernetes:core/v1:ConfigMap::config",
+          "urn:pulumi:dev::app::aws:s3/bucket:Bucket::logs"
+        ],
+        "outputs": {
+          "apiVersion": "apps/v1",
+          "kind": "Deployment",
+          "metadata": {"name": "nginx", "namespace": "default"},
+          "spec": {"replicas": 1},
+          "status": {"replicas": 1}
+        }
+      }
+    ]
+  }
+}`
+
+func TestConvertPulumiCheckpoint(t *testing.T) {
+	resources, mappings, err := ConvertPulumiCheckpoint([]byte(pulumiExport))
+	assert.Nil(t, err)
+	assert.Equal(t, models.Resources{
+		{
+			ID:   "v1:ConfigMap:default:config-x1y2",
+			Type: "Kubernetes",
+			Attributes: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": "config-x1y2", "namespace": "default"},
+				"data":       map[string]interface{}{"a": "b"},
+			},
+		},
+		{
+			ID:        "apps/v1:Deployment:default:nginx",
+			Type:      "Kubernetes",
+			DependsOn: []string{"v1:ConfigMap:default:config-x1y2"},
+			Attributes: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]interface{}{"name": "nginx", "namespace": "default"},
+				"spec":       map[string]interface{}{"replicas": float64(1)},
+			},
+		},
+	}, resources)
+
+	assert.Len(t, mappings, 5)
+	assert.Equal(t, "component resources are not real resources", mappings[0].Reason)
+	assert.Equal(t, "providers are declared in the configuration of stacks", mappings[1].Reason)
+	assert.Equal(t, "resources of the aws provider can't be mapped to kusion resources", mappings[2].Reason)
+	assert.Equal(t, "v1:ConfigMap:default:config-x1y2", mappings[3].ID)
+	assert.Equal(t, []string{"urn:pulumi:dev::app::aws:s3/bucket:Bucket::logs"}, mappings[4].DroppedDependencies)
+}
+
+func TestConvertPulumiCheckpoint_Errors(t *testing.T) {
+	_, _, err := ConvertPulumiCheckpoint([]byte(`{"version": 2}`))
+	assert.EqualError(t, err, "unsupported pulumi deployment version 2, only version 3 is supported")
+
+	_, _, err = ConvertPulumiCheckpoint([]byte(`{"version": 3, "deployment": {"resources": [{
+		"urn": "urn:pulumi:dev::app::kubernetes:core/v1:Secret::s", "custom": true, "type": "kubernetes:core/v1:Secret",
+		"outputs": {"data": {"4dabf18193072939515e22adb298388d": "1b47061264138c4ac30d75fd1eb44270", "ciphertext": "xxx"}}
+	}]}}`))
+	assert.ErrorContains(t, err, "secret values are encrypted")
+}
+
+func TestKCLStack(t *testing.T) {
+	code := KCLStack(models.Resources{{
+		ID:         "v1:Namespace:default",
+		Type:       "Kubernetes",
+		DependsOn:  []string{"v1:Namespace:kube-system"},
+		Attributes: map[string]interface{}{"kind": "Namespace"},
+	}}, "kusion state import-pulumi")
+	assert.Equal(t, `# Generated by kusion state import-pulumi, each item is a resource of the spec
+import manifests
+
+_resources = [
+    {
+        id = "v1:Namespace:default"
+        type = "Kubernetes"
+        dependsOn = [
+            "v1:Namespace:kube-system"
+        ]
+        attributes = {
+            "kind": "Namespace"
+        }
+    }
+]
+
+manifests.yaml_stream(_resources)
+`, code)
+}
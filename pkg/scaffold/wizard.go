@@ -0,0 +1,329 @@
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"kusionstack.io/kusion/pkg/engine/backend"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// Workload types of projects generated by the init wizard
+const (
+	WorkloadService = "service"
+	WorkloadJob     = "job"
+	WorkloadCronJob = "cronjob"
+)
+
+// Runtimes of projects generated by the init wizard
+const (
+	RuntimeKubernetes = "kubernetes"
+	RuntimeTerraform  = "terraform"
+)
+
+// LocalBackend is the default backend, which stores states in stack directories
+const LocalBackend = "local"
+
+// BackendFields are config fields of backends prompted by the init wizard. Credentials are not prompted and
+// are left to environment variables or credential helpers, so that they are never written to project.yaml.
+var BackendFields = map[string][]string{
+	LocalBackend: nil,
+	"oss":        {"endpoint", "bucket"},
+	"s3":         {"endpoint", "bucket", "region"},
+	"db":         {"dbHost", "dbPort", "dbName", "dbUser"},
+	"http":       {"urlPrefix"},
+}
+
+// WizardProject describes the project answered in the init wizard
+type WizardProject struct {
+	ProjectName string
+	// Workload is the type of the workload deployed by each stack on Kubernetes, one of service, job and cronjob
+	Workload string
+	// Runtime is the runtime of resources, kubernetes or terraform
+	Runtime string
+	// Backend stores states of all stacks, states are stored in stack directories if it is nil
+	Backend *backend.Storage
+	// Environments are names of stacks, e.g. dev and prod
+	Environments []string
+	// Force overwrites existing files
+	Force bool
+}
+
+// Validate returns an error if any answer of the wizard is invalid
+func (p *WizardProject) Validate() error {
+	if err := ValidateProjectName(p.ProjectName); err != nil {
+		return fmt.Errorf("'%s' is not a valid project name as [%v]", p.ProjectName, err)
+	}
+	switch p.Runtime {
+	case RuntimeKubernetes:
+		if p.Workload != WorkloadService && p.Workload != WorkloadJob && p.Workload != WorkloadCronJob {
+			return fmt.Errorf("unsupported workload %s, must be %s, %s or %s", p.Workload, WorkloadService, WorkloadJob, WorkloadCronJob)
+		}
+	case RuntimeTerraform:
+	default:
+		return fmt.Errorf("unsupported runtime %s, must be %s or %s", p.Runtime, RuntimeKubernetes, RuntimeTerraform)
+	}
+	if len(p.Environments) == 0 {
+		return fmt.Errorf("at least one environment is required")
+	}
+	seen := map[string]bool{}
+	for _, env := range p.Environments {
+		if err := ValidateEnvironment(env); err != nil {
+			return err
+		}
+		if seen[env] {
+			return fmt.Errorf("duplicate environment %s", env)
+		}
+		seen[env] = true
+	}
+	return nil
+}
+
+var environmentRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// ValidateEnvironment returns an error if the environment is not a valid stack name, which is also a part of
+// names of namespaces
+func ValidateEnvironment(env string) error {
+	if !environmentRegexp.MatchString(env) || len(env) > 30 {
+		return fmt.Errorf("invalid environment %q, must consist of at most 30 lower case alphanumeric characters or '-'", env)
+	}
+	return nil
+}
+
+// GenerateWizardProject writes the project into the directory with a stack of each environment, whose KCL code
+// declares the workload or an example Terraform resource
+func GenerateWizardProject(dir string, p *WizardProject) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	files := map[string][]byte{}
+
+	config := &projectstack.ProjectConfiguration{Name: p.ProjectName, Backend: p.Backend}
+	data, err := yamlv3.Marshal(config)
+	if err != nil {
+		return err
+	}
+	files[filepath.Join(dir, projectstack.ProjectFile)] = data
+
+	name := dnsLabel(p.ProjectName)
+	for _, env := range p.Environments {
+		stackDir := filepath.Join(dir, env)
+		stack := &projectstack.StackConfiguration{Name: env}
+		if p.Runtime == RuntimeTerraform {
+			stack.Runtime = &projectstack.RuntimeConfig{
+				Terraform: &projectstack.TerraformRuntimeConfig{Workspace: projectstack.AutoWorkspace},
+			}
+		}
+		if data, err = yamlv3.Marshal(stack); err != nil {
+			return err
+		}
+		files[filepath.Join(stackDir, projectstack.StackFile)] = data
+		files[filepath.Join(stackDir, projectstack.KclFile)] = []byte("kcl_cli_configs:\n  file:\n    - main.k\n")
+
+		t := wizardTemplates[p.Workload]
+		if p.Runtime == RuntimeTerraform {
+			t = wizardTemplates[RuntimeTerraform]
+		}
+		main := &bytes.Buffer{}
+		if err = t.Execute(main, map[string]string{
+			"Project":     p.ProjectName,
+			"Environment": env,
+			"Name":        name,
+			"Namespace":   name + "-" + env,
+		}); err != nil {
+			return err
+		}
+		files[filepath.Join(stackDir, "main.k")] = main.Bytes()
+	}
+
+	if !p.Force {
+		for _, f := range sortedKeys(files) {
+			if _, err = os.Stat(f); err == nil {
+				return fmt.Errorf("%s already exists, use --force to overwrite it", f)
+			}
+		}
+	}
+	for _, f := range sortedKeys(files) {
+		if err = os.MkdirAll(filepath.Dir(f), 0o755); err != nil {
+			return err
+		}
+		if err = os.WriteFile(f, files[f], 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dnsLabel returns the project name as a name of Kubernetes objects
+func dnsLabel(name string) string {
+	name = strings.ToLower(name)
+	name = strings.NewReplacer("_", "-", ".", "-").Replace(name)
+	name = strings.Trim(name, "-")
+	if len(name) > 32 {
+		name = strings.TrimRight(name[:32], "-")
+	}
+	return name
+}
+
+const wizardHeader = `# Generated by kusion init for the environment {{ .Environment }} of the project {{ .Project }}.
+# Each item of _resources is a resource of the spec, run kusion preview to see what will be applied.
+import manifests
+
+`
+
+const wizardNamespace = `_name = "{{ .Name }}"
+_namespace = "{{ .Namespace }}"
+_labels = {
+    "app.kubernetes.io/name": _name
+    "app.kubernetes.io/instance": "{{ .Environment }}"
+}
+
+_namespaceResource = {
+    id = "v1:Namespace:${_namespace}"
+    type = "Kubernetes"
+    attributes = {
+        apiVersion = "v1"
+        kind = "Namespace"
+        metadata = {name = _namespace}
+    }
+}
+`
+
+var wizardTemplates = map[string]*template.Template{
+	WorkloadService: template.Must(template.New(WorkloadService).Parse(wizardHeader + wizardNamespace + `
+_image = "nginx:1.25"
+_replicas = 1
+
+_resources = [
+    _namespaceResource
+    {
+        id = "apps/v1:Deployment:${_namespace}:${_name}"
+        type = "Kubernetes"
+        dependsOn = [_namespaceResource.id]
+        attributes = {
+            apiVersion = "apps/v1"
+            kind = "Deployment"
+            metadata = {name = _name, namespace = _namespace, labels = _labels}
+            spec = {
+                replicas = _replicas
+                selector = {matchLabels = _labels}
+                template = {
+                    metadata = {labels = _labels}
+                    spec = {
+                        containers = [{name = _name, image = _image, ports = [{containerPort = 80}]}]
+                    }
+                }
+            }
+        }
+    }
+    {
+        id = "v1:Service:${_namespace}:${_name}"
+        type = "Kubernetes"
+        dependsOn = [_namespaceResource.id]
+        attributes = {
+            apiVersion = "v1"
+            kind = "Service"
+            metadata = {name = _name, namespace = _namespace, labels = _labels}
+            spec = {
+                selector = _labels
+                ports = [{port = 80, targetPort = 80}]
+            }
+        }
+    }
+]
+
+manifests.yaml_stream(_resources)
+`)),
+	WorkloadJob: template.Must(template.New(WorkloadJob).Parse(wizardHeader + wizardNamespace + `
+_image = "busybox:1.36"
+_command = ["echo", "hello from kusion"]
+
+_resources = [
+    _namespaceResource
+    {
+        id = "batch/v1:Job:${_namespace}:${_name}"
+        type = "Kubernetes"
+        dependsOn = [_namespaceResource.id]
+        attributes = {
+            apiVersion = "batch/v1"
+            kind = "Job"
+            metadata = {name = _name, namespace = _namespace, labels = _labels}
+            spec = {
+                backoffLimit = 3
+                template = {
+                    metadata = {labels = _labels}
+                    spec = {
+                        restartPolicy = "Never"
+                        containers = [{name = _name, image = _image, command = _command}]
+                    }
+                }
+            }
+        }
+    }
+]
+
+manifests.yaml_stream(_resources)
+`)),
+	WorkloadCronJob: template.Must(template.New(WorkloadCronJob).Parse(wizardHeader + wizardNamespace + `
+_image = "busybox:1.36"
+_command = ["echo", "hello from kusion"]
+_schedule = "0 * * * *"
+
+_resources = [
+    _namespaceResource
+    {
+        id = "batch/v1:CronJob:${_namespace}:${_name}"
+        type = "Kubernetes"
+        dependsOn = [_namespaceResource.id]
+        attributes = {
+            apiVersion = "batch/v1"
+            kind = "CronJob"
+            metadata = {name = _name, namespace = _namespace, labels = _labels}
+            spec = {
+                schedule = _schedule
+                jobTemplate = {
+                    spec = {
+                        backoffLimit = 3
+                        template = {
+                            metadata = {labels = _labels}
+                            spec = {
+                                restartPolicy = "Never"
+                                containers = [{name = _name, image = _image, command = _command}]
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    }
+]
+
+manifests.yaml_stream(_resources)
+`)),
+	RuntimeTerraform: template.Must(template.New(RuntimeTerraform).Parse(wizardHeader + `_name = "{{ .Name }}-{{ .Environment }}"
+
+_resources = [
+    {
+        id = "hashicorp:random:random_password:${_name}"
+        type = "Terraform"
+        attributes = {
+            length = 16
+            special = True
+        }
+        extensions = {
+            provider = "registry.terraform.io/hashicorp/random/3.4.3"
+            resourceType = "random_password"
+        }
+    }
+]
+
+manifests.yaml_stream(_resources)
+`)),
+}
@@ -0,0 +1,82 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kusionstack.io/kusion/pkg/engine/backend"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+func TestGenerateWizardProject(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "My_App")
+	p := &WizardProject{
+		ProjectName:  "My_App",
+		Workload:     WorkloadService,
+		Runtime:      RuntimeKubernetes,
+		Backend:      &backend.Storage{Type: "s3", Config: map[string]interface{}{"bucket": "states"}},
+		Environments: []string{"dev", "prod"},
+	}
+	require.NoError(t, GenerateWizardProject(dir, p))
+
+	project, err := projectstack.GetProjectFrom(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "My_App", project.Name)
+	assert.Equal(t, "s3", project.Backend.Type)
+	assert.Equal(t, "states", project.Backend.Config["bucket"])
+	for _, env := range p.Environments {
+		stack, err := projectstack.GetStackFrom(filepath.Join(dir, env))
+		require.NoError(t, err)
+		assert.Equal(t, env, stack.Name)
+		main, err := os.ReadFile(filepath.Join(dir, env, "main.k"))
+		require.NoError(t, err)
+		assert.Contains(t, string(main), `_namespace = "my-app-`+env+`"`)
+		assert.Contains(t, string(main), `id = "apps/v1:Deployment:${_namespace}:${_name}"`)
+		assert.FileExists(t, filepath.Join(dir, env, projectstack.KclFile))
+	}
+
+	// existing files are not overwritten without force
+	assert.ErrorContains(t, GenerateWizardProject(dir, p), "already exists")
+	p.Force = true
+	assert.NoError(t, GenerateWizardProject(dir, p))
+}
+
+func TestGenerateWizardProject_Terraform(t *testing.T) {
+	dir := t.TempDir()
+	p := &WizardProject{ProjectName: "infra", Runtime: RuntimeTerraform, Environments: []string{"dev"}}
+	require.NoError(t, GenerateWizardProject(dir, p))
+
+	stack, err := projectstack.GetStackFrom(filepath.Join(dir, "dev"))
+	require.NoError(t, err)
+	assert.Equal(t, projectstack.AutoWorkspace, stack.Runtime.Terraform.Workspace)
+	main, err := os.ReadFile(filepath.Join(dir, "dev", "main.k"))
+	require.NoError(t, err)
+	assert.Contains(t, string(main), `resourceType = "random_password"`)
+	project, err := projectstack.GetProjectFrom(dir)
+	require.NoError(t, err)
+	assert.Nil(t, project.Backend)
+}
+
+func TestWizardProject_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		project WizardProject
+		wantErr string
+	}{
+		{"invalid name", WizardProject{ProjectName: "a b", Runtime: RuntimeTerraform, Environments: []string{"dev"}}, "not a valid project name"},
+		{"invalid runtime", WizardProject{ProjectName: "a", Runtime: "vm", Environments: []string{"dev"}}, "unsupported runtime"},
+		{"invalid workload", WizardProject{ProjectName: "a", Runtime: RuntimeKubernetes, Workload: "daemon", Environments: []string{"dev"}}, "unsupported workload"},
+		{"no environment", WizardProject{ProjectName: "a", Runtime: RuntimeTerraform}, "at least one environment"},
+		{"invalid environment", WizardProject{ProjectName: "a", Runtime: RuntimeTerraform, Environments: []string{"Dev"}}, "invalid environment"},
+		{"duplicate environment", WizardProject{ProjectName: "a", Runtime: RuntimeTerraform, Environments: []string{"dev", "dev"}}, "duplicate environment"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorContains(t, tt.project.Validate(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// Default claims of ID tokens identifying users and their groups
+const (
+	DefaultUsernameClaim = "email"
+	DefaultGroupsClaim   = "groups"
+)
+
+// Identity is the authenticated user of a request
+type Identity struct {
+	User   string
+	Groups []string
+}
+
+// Authenticator authenticates bearer tokens of requests
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Identity, error)
+}
+
+// OIDCAuthenticator authenticates ID tokens issued by an OpenID Connect provider
+type OIDCAuthenticator struct {
+	verifier      *oidc.IDTokenVerifier
+	usernameClaim string
+	groupsClaim   string
+}
+
+// NewOIDCAuthenticator discovers the provider of the issuer, and returns the authenticator of ID tokens issued
+// to the client. Users are identified by the username claim, and their groups by the groups claim.
+func NewOIDCAuthenticator(ctx context.Context, issuer, clientID, usernameClaim, groupsClaim string) (*OIDCAuthenticator, error) {
+	provider, err := oidc.NewProvider(ctx, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("discover the OIDC provider %s failed: %w", issuer, err)
+	}
+	return newOIDCAuthenticator(provider.Verifier(&oidc.Config{ClientID: clientID}), usernameClaim, groupsClaim), nil
+}
+
+func newOIDCAuthenticator(verifier *oidc.IDTokenVerifier, usernameClaim, groupsClaim string) *OIDCAuthenticator {
+	if usernameClaim == "" {
+		usernameClaim = DefaultUsernameClaim
+	}
+	if groupsClaim == "" {
+		groupsClaim = DefaultGroupsClaim
+	}
+	return &OIDCAuthenticator{verifier: verifier, usernameClaim: usernameClaim, groupsClaim: groupsClaim}
+}
+
+// Authenticate verifies the ID token and returns the user and groups in its claims
+func (a *OIDCAuthenticator) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	idToken, err := a.verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	var claims map[string]interface{}
+	if err = idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+	user, _ := claims[a.usernameClaim].(string)
+	if user == "" {
+		return nil, fmt.Errorf("claim %s of the user is missing in the token", a.usernameClaim)
+	}
+	id := &Identity{User: user}
+	// groups are usually an array, while some providers return a single group as a string
+	switch groups := claims[a.groupsClaim].(type) {
+	case string:
+		id.Groups = []string{groups}
+	case []interface{}:
+		for _, g := range groups {
+			if s, ok := g.(string); ok {
+				id.Groups = append(id.Groups, s)
+			}
+		}
+	}
+	return id, nil
+}
+
+type identityKey struct{}
+
+// WithIdentity returns the context of a request authenticated as the identity
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFrom returns the identity of the request, which is nil if the request is not authenticated
+func IdentityFrom(ctx context.Context) *Identity {
+	id, _ := ctx.Value(identityKey{}).(*Identity)
+	return id
+}
+
+// AuthOptions returns options of the gRPC server authenticating bearer tokens in the authorization metadata
+// of all calls, calls without valid tokens are rejected with the status Unauthenticated
+func AuthOptions(authenticator Authenticator) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo,
+			handler grpc.UnaryHandler,
+		) (interface{}, error) {
+			ctx, err := authenticate(ctx, authenticator)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo,
+			handler grpc.StreamHandler,
+		) error {
+			ctx, err := authenticate(ss.Context(), authenticator)
+			if err != nil {
+				return err
+			}
+			return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+		}),
+	}
+}
+
+func authenticate(ctx context.Context, authenticator Authenticator) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, grpcstatus.Error(codes.Unauthenticated, "bearer token is required in the authorization metadata")
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(values[0], "Bearer "))
+	id, err := authenticator.Authenticate(ctx, token)
+	if err != nil {
+		return nil, grpcstatus.Errorf(codes.Unauthenticated, "invalid bearer token: %v", err)
+	}
+	return WithIdentity(ctx, id), nil
+}
+
+// authenticatedStream overrides the context of the stream with the identity
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const issuer = "https://accounts.example.com"
+
+var trusted = base64.RawURLEncoding.EncodeToString([]byte("trusted"))
+
+// fakeKeySet trusts tokens with the signature "trusted", instead of verifying them with keys of the provider
+type fakeKeySet struct{}
+
+func (fakeKeySet) VerifySignature(_ context.Context, jwt string) ([]byte, error) {
+	parts := strings.Split(jwt, ".")
+	if parts[2] != trusted {
+		return nil, errors.New("invalid signature")
+	}
+	return base64.RawURLEncoding.DecodeString(parts[1])
+}
+
+func idToken(t *testing.T, claims map[string]interface{}, signature string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256"}`))
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	return header + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + signature
+}
+
+func TestOIDCAuthenticator_Authenticate(t *testing.T) {
+	a := newOIDCAuthenticator(oidc.NewVerifier(issuer, fakeKeySet{}, &oidc.Config{ClientID: "kusion", SkipExpiryCheck: true}), "", "")
+	tests := []struct {
+		name      string
+		claims    map[string]interface{}
+		signature string
+		want      *Identity
+		wantErr   string
+	}{
+		{
+			name:      "groups",
+			claims:    map[string]interface{}{"iss": issuer, "aud": "kusion", "email": "alice@example.com", "groups": []string{"dev", "ops"}},
+			signature: trusted,
+			want:      &Identity{User: "alice@example.com", Groups: []string{"dev", "ops"}},
+		},
+		{
+			name:      "single group",
+			claims:    map[string]interface{}{"iss": issuer, "aud": "kusion", "email": "alice@example.com", "groups": "dev"},
+			signature: trusted,
+			want:      &Identity{User: "alice@example.com", Groups: []string{"dev"}},
+		},
+		{
+			name:      "no user",
+			claims:    map[string]interface{}{"iss": issuer, "aud": "kusion", "sub": "alice"},
+			signature: trusted,
+			wantErr:   "claim email",
+		},
+		{
+			name:      "other client",
+			claims:    map[string]interface{}{"iss": issuer, "aud": "other", "email": "alice@example.com"},
+			signature: trusted,
+			wantErr:   "audience",
+		},
+		{
+			name:      "invalid signature",
+			claims:    map[string]interface{}{"iss": issuer, "aud": "kusion", "email": "alice@example.com"},
+			signature: base64.RawURLEncoding.EncodeToString([]byte("forged")),
+			wantErr:   "invalid signature",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := a.Authenticate(context.Background(), idToken(t, tt.claims, tt.signature))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, id)
+		})
+	}
+}
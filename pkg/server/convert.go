@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	enginev1 "kusionstack.io/kusion/pkg/apis/engine/v1"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/sensitive"
+	"kusionstack.io/kusion/pkg/engine/states"
+)
+
+// stateToProto converts the state with sensitive attributes masked, which are never sent to clients
+func stateToProto(state *states.State) (*enginev1.State, error) {
+	if state == nil {
+		return nil, nil
+	}
+	resources, err := resourcesToProto(sensitive.MaskResources(state.Resources))
+	if err != nil {
+		return nil, err
+	}
+	s := &enginev1.State{
+		Id:        state.ID,
+		Tenant:    state.Tenant,
+		Project:   state.Project,
+		Stack:     state.Stack,
+		Serial:    state.Serial,
+		Operator:  state.Operator,
+		Resources: resources,
+	}
+	if !state.CreateTime.IsZero() {
+		s.CreateTime = timestamppb.New(state.CreateTime)
+	}
+	if !state.ModifiedTime.IsZero() {
+		s.ModifiedTime = timestamppb.New(state.ModifiedTime)
+	}
+	return s, nil
+}
+
+func resourcesToProto(resources models.Resources) ([]*enginev1.Resource, error) {
+	result := make([]*enginev1.Resource, 0, len(resources))
+	for _, r := range resources {
+		attributes, err := mapToStruct(r.Attributes)
+		if err != nil {
+			return nil, fmt.Errorf("convert attributes of %s failed: %w", r.ID, err)
+		}
+		extensions, err := mapToStruct(r.Extensions)
+		if err != nil {
+			return nil, fmt.Errorf("convert extensions of %s failed: %w", r.ID, err)
+		}
+		result = append(result, &enginev1.Resource{
+			Id:         r.ID,
+			Type:       string(r.Type),
+			Attributes: attributes,
+			DependsOn:  r.DependsOn,
+			Extensions: extensions,
+		})
+	}
+	return result, nil
+}
+
+// mapToStruct converts the map through JSON, since attributes may contain values of any types which can be
+// marshaled, e.g. []string, while structpb only accepts generic maps and slices
+func mapToStruct(m map[string]interface{}) (*structpb.Struct, error) {
+	if m == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	s := &structpb.Struct{}
+	if err = s.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
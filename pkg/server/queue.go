@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrTicketNotFound is returned when canceling an operation which is not in the queue
+	ErrTicketNotFound = errors.New("operation is not in the queue")
+	// ErrTicketRunning is returned when canceling an operation which is already running
+	ErrTicketRunning = errors.New("operation is already running")
+)
+
+// Queue serializes applies of each stack. Operations of a stack run one by one, the ones with higher
+// priorities first, and the ones with the same priority in the order they are enqueued.
+type Queue struct {
+	lock   sync.Mutex
+	stacks map[string]*stackQueue
+	seq    uint64
+}
+
+// stackQueue is the queue of a stack, changed is closed and replaced whenever the queue changes
+type stackQueue struct {
+	running *Ticket
+	waiting []*Ticket
+	changed chan struct{}
+}
+
+// Ticket is an operation in the queue of a stack
+type Ticket struct {
+	ID          string
+	Key         string
+	Operation   string
+	Operator    string
+	Priority    int32
+	EnqueueTime time.Time
+
+	seq uint64
+	// canceled is the person who canceled the operation
+	canceled string
+}
+
+// NewQueue returns an empty queue
+func NewQueue() *Queue {
+	return &Queue{stacks: map[string]*stackQueue{}}
+}
+
+// Enqueue adds an operation to the queue of the stack with the key, which runs immediately if the stack is idle
+func (q *Queue) Enqueue(key, operation, operator string, priority int32) *Ticket {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.seq++
+	t := &Ticket{
+		ID:          strconv.FormatUint(q.seq, 10),
+		Key:         key,
+		Operation:   operation,
+		Operator:    operator,
+		Priority:    priority,
+		EnqueueTime: time.Now(),
+		seq:         q.seq,
+	}
+	sq, ok := q.stacks[key]
+	if !ok {
+		sq = &stackQueue{changed: make(chan struct{})}
+		q.stacks[key] = sq
+	}
+	if sq.running == nil {
+		sq.running = t
+	} else {
+		sq.waiting = append(sq.waiting, t)
+		sort.SliceStable(sq.waiting, func(i, j int) bool {
+			if sq.waiting[i].Priority != sq.waiting[j].Priority {
+				return sq.waiting[i].Priority > sq.waiting[j].Priority
+			}
+			return sq.waiting[i].seq < sq.waiting[j].seq
+		})
+	}
+	sq.notify()
+	return t
+}
+
+// Wait blocks until the operation runs. onPosition is called with the number of operations ahead of it
+// whenever the number changes, and the operation is removed from the queue if it is canceled or ctx is done.
+func (q *Queue) Wait(ctx context.Context, t *Ticket, onPosition func(position int)) error {
+	last := -1
+	for {
+		q.lock.Lock()
+		if t.canceled != "" {
+			q.lock.Unlock()
+			return fmt.Errorf("operation %s is canceled by %s", t.ID, t.canceled)
+		}
+		sq, ok := q.stacks[t.Key]
+		if !ok {
+			q.lock.Unlock()
+			return ErrTicketNotFound
+		}
+		if sq.running == t {
+			q.lock.Unlock()
+			return nil
+		}
+		position := sq.position(t)
+		changed := sq.changed
+		q.lock.Unlock()
+
+		if position != last && onPosition != nil {
+			onPosition(position)
+			last = position
+		}
+		select {
+		case <-ctx.Done():
+			q.Done(t)
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// Done removes the operation from the queue, and runs the next waiting operation if it was running
+func (q *Queue) Done(t *Ticket) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	sq, ok := q.stacks[t.Key]
+	if !ok {
+		return
+	}
+	if sq.running == t {
+		sq.running = nil
+		if len(sq.waiting) > 0 {
+			sq.running, sq.waiting = sq.waiting[0], sq.waiting[1:]
+		}
+	} else if !sq.remove(t.ID) {
+		return
+	}
+	sq.notify()
+	if sq.running == nil {
+		delete(q.stacks, t.Key)
+	}
+}
+
+// Cancel removes a waiting operation from the queue of the stack. Running operations can not be canceled.
+func (q *Queue) Cancel(key, id, operator string) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	sq, ok := q.stacks[key]
+	if !ok {
+		return ErrTicketNotFound
+	}
+	if sq.running != nil && sq.running.ID == id {
+		return ErrTicketRunning
+	}
+	for _, t := range sq.waiting {
+		if t.ID == id {
+			if operator == "" {
+				operator = "unknown"
+			}
+			t.canceled = operator
+			sq.remove(id)
+			sq.notify()
+			return nil
+		}
+	}
+	return ErrTicketNotFound
+}
+
+// List returns the running operation of the stack followed by the waiting ones in the order they will run
+func (q *Queue) List(key string) []Ticket {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	sq, ok := q.stacks[key]
+	if !ok {
+		return nil
+	}
+	result := make([]Ticket, 0, len(sq.waiting)+1)
+	if sq.running != nil {
+		result = append(result, *sq.running)
+	}
+	for _, t := range sq.waiting {
+		result = append(result, *t)
+	}
+	return result
+}
+
+// position returns the number of operations ahead of the waiting one, including the running one
+func (sq *stackQueue) position(t *Ticket) int {
+	for i, w := range sq.waiting {
+		if w == t {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func (sq *stackQueue) remove(id string) bool {
+	for i, t := range sq.waiting {
+		if t.ID == id {
+			sq.waiting = append(sq.waiting[:i], sq.waiting[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (sq *stackQueue) notify() {
+	close(sq.changed)
+	sq.changed = make(chan struct{})
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueue(t *testing.T) {
+	q := NewQueue()
+	first := q.Enqueue("stack", "apply", "alice", 0)
+	second := q.Enqueue("stack", "apply", "bob", 0)
+	third := q.Enqueue("stack", "apply", "carol", 0)
+	urgent := q.Enqueue("stack", "apply", "dave", 10)
+	other := q.Enqueue("other", "apply", "erin", 0)
+
+	// stacks are queued separately
+	require.NoError(t, q.Wait(context.Background(), first, nil))
+	require.NoError(t, q.Wait(context.Background(), other, nil))
+
+	var ids []string
+	for _, ticket := range q.List("stack") {
+		ids = append(ids, ticket.ID)
+	}
+	assert.Equal(t, []string{first.ID, urgent.ID, second.ID, third.ID}, ids)
+
+	positions := make(chan int, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- q.Wait(context.Background(), third, func(position int) { positions <- position })
+	}()
+	assert.Equal(t, 3, <-positions)
+
+	q.Done(first)
+	assert.Equal(t, 2, <-positions)
+	q.Done(urgent)
+	assert.Equal(t, 1, <-positions)
+	q.Done(second)
+	require.NoError(t, <-done)
+	assert.Equal(t, third.ID, q.List("stack")[0].ID)
+
+	q.Done(third)
+	assert.Empty(t, q.List("stack"))
+}
+
+func TestQueue_Cancel(t *testing.T) {
+	q := NewQueue()
+	running := q.Enqueue("stack", "apply", "alice", 0)
+	waiting := q.Enqueue("stack", "apply", "bob", 0)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- q.Wait(context.Background(), waiting, nil)
+	}()
+
+	assert.ErrorIs(t, q.Cancel("stack", running.ID, "carol"), ErrTicketRunning)
+	assert.ErrorIs(t, q.Cancel("other", waiting.ID, "carol"), ErrTicketNotFound)
+	require.NoError(t, q.Cancel("stack", waiting.ID, "carol"))
+	err := <-done
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "canceled by carol")
+	assert.ErrorIs(t, q.Cancel("stack", waiting.ID, "carol"), ErrTicketNotFound)
+	assert.Len(t, q.List("stack"), 1)
+}
+
+func TestQueue_WaitContextDone(t *testing.T) {
+	q := NewQueue()
+	running := q.Enqueue("stack", "apply", "alice", 0)
+	waiting := q.Enqueue("stack", "apply", "bob", 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, q.Wait(ctx, waiting, nil), context.DeadlineExceeded)
+	assert.Len(t, q.List("stack"), 1)
+
+	q.Done(running)
+	assert.Empty(t, q.List("stack"))
+}
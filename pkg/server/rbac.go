@@ -0,0 +1,142 @@
+package server
+
+import (
+	"fmt"
+	"path"
+
+	"kusionstack.io/kusion/pkg/util/yaml"
+)
+
+// Role is a set of operations allowed on stacks, each role includes operations of the roles before it
+type Role int
+
+// Roles of users on stacks
+const (
+	// RoleNone allows nothing
+	RoleNone Role = iota
+	// RoleViewer allows reading states and queues of stacks
+	RoleViewer
+	// RolePreviewer allows previewing changes of stacks, and applying them with dry runs
+	RolePreviewer
+	// RoleApplier allows applying stacks, and canceling applies of their own
+	RoleApplier
+	// RoleAdmin allows canceling applies of others
+	RoleAdmin
+)
+
+var roleNames = map[Role]string{
+	RoleNone:      "none",
+	RoleViewer:    "viewer",
+	RolePreviewer: "previewer",
+	RoleApplier:   "applier",
+	RoleAdmin:     "admin",
+}
+
+func (r Role) String() string {
+	if name, ok := roleNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("Role(%d)", int(r))
+}
+
+// ParseRole returns the role with the name
+func ParseRole(name string) (Role, error) {
+	for role, n := range roleNames {
+		if n == name && role != RoleNone {
+			return role, nil
+		}
+	}
+	return RoleNone, fmt.Errorf("unknown role %q, which should be viewer, previewer, applier or admin", name)
+}
+
+// RoleBinding grants the role to users and groups on stacks
+type RoleBinding struct {
+	Role   string   `json:"role" yaml:"role"`
+	Users  []string `json:"users,omitempty" yaml:"users,omitempty"`
+	Groups []string `json:"groups,omitempty" yaml:"groups,omitempty"`
+
+	// Stacks are patterns of project/stack, e.g. demo/dev or demo/*, the binding applies to all stacks if
+	// they are empty
+	Stacks []string `json:"stacks,omitempty" yaml:"stacks,omitempty"`
+}
+
+// RBAC authorizes users on stacks with role bindings
+type RBAC struct {
+	Bindings []RoleBinding `json:"bindings" yaml:"bindings"`
+}
+
+// LoadRBAC reads role bindings from the YAML file
+func LoadRBAC(file string) (*RBAC, error) {
+	rbac := &RBAC{}
+	if err := yaml.ParseYamlFromFile(file, rbac); err != nil {
+		return nil, fmt.Errorf("read role bindings from %s failed: %w", file, err)
+	}
+	if err := rbac.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid role bindings in %s: %w", file, err)
+	}
+	return rbac, nil
+}
+
+// Validate checks roles and stack patterns of the bindings
+func (r *RBAC) Validate() error {
+	for i, b := range r.Bindings {
+		if _, err := ParseRole(b.Role); err != nil {
+			return fmt.Errorf("binding %d: %w", i, err)
+		}
+		if len(b.Users) == 0 && len(b.Groups) == 0 {
+			return fmt.Errorf("binding %d: users or groups are required", i)
+		}
+		for _, pattern := range b.Stacks {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("binding %d: invalid stack pattern %q: %w", i, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Role returns the highest role granted to the identity on the stack of the project
+func (r *RBAC) Role(id *Identity, project, stack string) Role {
+	result := RoleNone
+	if id == nil {
+		return result
+	}
+	for _, b := range r.Bindings {
+		role, err := ParseRole(b.Role)
+		if err != nil || role <= result {
+			continue
+		}
+		if b.bound(id) && b.matches(project+"/"+stack) {
+			result = role
+		}
+	}
+	return result
+}
+
+func (b *RoleBinding) bound(id *Identity) bool {
+	for _, user := range b.Users {
+		if user == id.User {
+			return true
+		}
+	}
+	for _, group := range b.Groups {
+		for _, g := range id.Groups {
+			if group == g {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (b *RoleBinding) matches(stack string) bool {
+	if len(b.Stacks) == 0 {
+		return true
+	}
+	for _, pattern := range b.Stacks {
+		if ok, _ := path.Match(pattern, stack); ok {
+			return true
+		}
+	}
+	return false
+}
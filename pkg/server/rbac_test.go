@@ -0,0 +1,83 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRole(t *testing.T) {
+	role, err := ParseRole("applier")
+	require.NoError(t, err)
+	assert.Equal(t, RoleApplier, role)
+	assert.Equal(t, "applier", role.String())
+
+	_, err = ParseRole("none")
+	assert.Error(t, err)
+	_, err = ParseRole("owner")
+	assert.ErrorContains(t, err, "unknown role")
+}
+
+func TestRBAC_Role(t *testing.T) {
+	rbac := &RBAC{Bindings: []RoleBinding{
+		{Role: "viewer", Groups: []string{"dev"}},
+		{Role: "applier", Users: []string{"alice"}, Stacks: []string{"demo/dev", "demo/test"}},
+		{Role: "previewer", Users: []string{"alice"}, Stacks: []string{"demo/*"}},
+		{Role: "admin", Groups: []string{"platform"}, Stacks: []string{"*/*"}},
+	}}
+	tests := []struct {
+		name  string
+		id    *Identity
+		stack string
+		want  Role
+	}{
+		{name: "anonymous", id: nil, stack: "dev", want: RoleNone},
+		{name: "unbound", id: &Identity{User: "bob"}, stack: "dev", want: RoleNone},
+		{name: "group on all stacks", id: &Identity{User: "bob", Groups: []string{"dev"}}, stack: "prod", want: RoleViewer},
+		{name: "highest role", id: &Identity{User: "alice", Groups: []string{"dev"}}, stack: "dev", want: RoleApplier},
+		{name: "pattern", id: &Identity{User: "alice"}, stack: "prod", want: RolePreviewer},
+		{name: "admin", id: &Identity{User: "carol", Groups: []string{"dev", "platform"}}, stack: "prod", want: RoleAdmin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rbac.Role(tt.id, "demo", tt.stack))
+		})
+	}
+}
+
+func TestLoadRBAC(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "rbac.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+bindings:
+  - role: admin
+    groups: [platform]
+  - role: applier
+    users: [alice@example.com]
+    stacks: [demo/dev]
+`), 0o644))
+	rbac, err := LoadRBAC(file)
+	require.NoError(t, err)
+	require.Len(t, rbac.Bindings, 2)
+	assert.Equal(t, []string{"demo/dev"}, rbac.Bindings[1].Stacks)
+
+	invalid := map[string]string{
+		"unknown role":    "bindings:\n  - role: owner\n    users: [alice]\n",
+		"no subjects":     "bindings:\n  - role: viewer\n",
+		"invalid pattern": "bindings:\n  - role: viewer\n    users: [alice]\n    stacks: ['demo/[']\n",
+	}
+	for name, content := range invalid {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(dir, "invalid.yaml")
+			require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+			_, err := LoadRBAC(file)
+			assert.ErrorContains(t, err, "invalid role bindings")
+		})
+	}
+
+	_, err = LoadRBAC(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
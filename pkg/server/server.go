@@ -0,0 +1,430 @@
+// Package server serves the engine over gRPC, so that integrations in any language can preview and apply
+// stacks and stream the progress of operations, instead of running the CLI and parsing its output.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	enginev1 "kusionstack.io/kusion/pkg/apis/engine/v1"
+	applycmd "kusionstack.io/kusion/pkg/cmd/apply"
+	previewcmd "kusionstack.io/kusion/pkg/cmd/preview"
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/engine/backend"
+	"kusionstack.io/kusion/pkg/engine/models"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/progress"
+	"kusionstack.io/kusion/pkg/projectstack"
+)
+
+// Results of operations in events, the same as results streamed by kusion apply --progress-addr
+const (
+	ResultSuccess = "success"
+	ResultFailed  = "failed"
+)
+
+var _ enginev1.EngineServer = (*EngineServer)(nil)
+
+// EngineServer implements the gRPC engine service on stacks in the root directory
+type EngineServer struct {
+	enginev1.UnimplementedEngineServer
+
+	// Root is the directory containing projects, work directories of requests must be in it
+	Root string
+
+	// BackendOps overrides the backend config of projects
+	BackendOps backend.BackendOps
+
+	// RBAC authorizes authenticated users on stacks, all requests are allowed if it is nil
+	RBAC *RBAC
+
+	// queue serializes applies of each stack
+	queue *Queue
+}
+
+// NewEngineServer returns the engine server of stacks in the root directory
+func NewEngineServer(root string) *EngineServer {
+	return &EngineServer{Root: root, queue: NewQueue()}
+}
+
+// target is the stack of a request and the spec compiled from it
+type target struct {
+	dir     string
+	project *projectstack.Project
+	stack   *projectstack.Stack
+	spec    *models.Spec
+	storage states.StateStorage
+
+	// operator is the authenticated user if RBAC is enabled, otherwise the operator of the request
+	operator string
+	role     Role
+}
+
+func (t *target) query() *states.StateQuery {
+	return &states.StateQuery{Tenant: t.project.Tenant, Project: t.project.Name, Stack: t.stack.Name}
+}
+
+// key returns the key of the stack in the queue
+func (t *target) key() string {
+	return t.project.Tenant + "/" + t.project.Name + "/" + t.stack.Name
+}
+
+// Preview streams a start event with the number of changed resources, an event with the diff of each
+// resource, and a finish event
+func (s *EngineServer) Preview(req *enginev1.OperationRequest, stream enginev1.Engine_PreviewServer) error {
+	t, err := s.loadSpec(stream.Context(), req, false, RolePreviewer)
+	if err != nil {
+		return err
+	}
+	o, err := s.options(t, false)
+	if err != nil {
+		return grpcstatus.Error(codes.Internal, err.Error())
+	}
+	changes, err := previewcmd.Preview(&o.PreviewOptions, t.storage, t.spec, t.project, t.stack)
+	if err != nil {
+		return grpcstatus.Error(codes.Internal, err.Error())
+	}
+	// policies are reported by previews the same as kusion preview
+	if _, err = previewcmd.CheckPolicies(&o.PreviewOptions, t.project, t.stack, t.spec, changes); err != nil {
+		return grpcstatus.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	send := newSender(stream.Send)
+	if err = send(&enginev1.Event{
+		Type:      progress.EventStart,
+		Operation: "preview",
+		Project:   t.project.Name,
+		Stack:     t.stack.Name,
+		Total:     int32(len(changes.StepKeys)),
+	}); err != nil {
+		return err
+	}
+	for _, key := range changes.StepKeys {
+		step := changes.Get(key)
+		e := &enginev1.Event{
+			Type:       progress.EventResource,
+			ResourceId: key,
+			Action:     step.Action.String(),
+			Result:     ResultSuccess,
+		}
+		if e.Diff, err = step.Diff(); err != nil {
+			e.Result, e.Error = ResultFailed, err.Error()
+		}
+		if err = send(e); err != nil {
+			return err
+		}
+	}
+	return send(&enginev1.Event{Type: progress.EventFinish, Operation: "preview", Result: ResultSuccess})
+}
+
+// Apply waits in the queue of the stack with queued events of its position, then streams a start event with
+// the number of changed resources, events of resources while they are applied, heartbeats of the lock of the
+// state, and a finish event with the state applied. Failures of the apply are reported by the finish event
+// instead of the status of the call.
+func (s *EngineServer) Apply(req *enginev1.OperationRequest, stream enginev1.Engine_ApplyServer) error {
+	// dry runs change nothing, the same as previews
+	required := RoleApplier
+	if req.GetDryRun() {
+		required = RolePreviewer
+	}
+	t, err := s.loadSpec(stream.Context(), req, true, required)
+	if err != nil {
+		return err
+	}
+
+	send := newSender(stream.Send)
+	ticket := s.queue.Enqueue(t.key(), "apply", t.operator, req.GetPriority())
+	defer s.queue.Done(ticket)
+	if err = s.queue.Wait(stream.Context(), ticket, func(position int) {
+		_ = send(&enginev1.Event{
+			Type:        progress.EventQueued,
+			Operation:   "apply",
+			Project:     t.project.Name,
+			Stack:       t.stack.Name,
+			OperationId: ticket.ID,
+			Position:    int32(position),
+		})
+	}); err != nil {
+		return grpcstatus.Error(codes.Canceled, err.Error())
+	}
+
+	// preview after waiting, since the state may be changed by applies ahead in the queue. Applies pass the
+	// same gates as kusion apply, whose failures are reported by the status of the call.
+	o, err := s.options(t, req.GetDryRun())
+	if err != nil {
+		return grpcstatus.Error(codes.Internal, err.Error())
+	}
+	changes, err := applycmd.Prepare(o, t.storage, t.spec, t.project, t.stack)
+	if err != nil {
+		return grpcstatus.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err = send(&enginev1.Event{
+		Type:        progress.EventStart,
+		Operation:   "apply",
+		Project:     t.project.Name,
+		Stack:       t.stack.Name,
+		Total:       int32(len(changes.StepKeys)),
+		OperationId: ticket.ID,
+	}); err != nil {
+		return err
+	}
+
+	finish := &enginev1.Event{Type: progress.EventFinish, Operation: "apply", Result: ResultSuccess}
+	state, err := s.apply(o, t, changes, send)
+	if err == nil {
+		finish.State, err = stateToProto(state)
+	}
+	if err != nil {
+		finish.Result, finish.Error = ResultFailed, err.Error()
+	}
+	return send(finish)
+}
+
+// GetState returns the latest state of the stack with sensitive attributes masked
+func (s *EngineServer) GetState(ctx context.Context, req *enginev1.StateRequest) (*enginev1.State, error) {
+	t, err := s.load(ctx, req.GetWorkDir(), false, RoleViewer, "")
+	if err != nil {
+		return nil, err
+	}
+	state, err := t.storage.GetLatestState(t.query())
+	if err != nil {
+		return nil, grpcstatus.Error(codes.Internal, err.Error())
+	}
+	if state == nil {
+		return nil, grpcstatus.Errorf(codes.NotFound, "no state of the stack %s is found", t.stack.Name)
+	}
+	result, err := stateToProto(state)
+	if err != nil {
+		return nil, grpcstatus.Error(codes.Internal, err.Error())
+	}
+	return result, nil
+}
+
+// ListQueue returns the running apply of the stack followed by the waiting ones in the order they will run
+func (s *EngineServer) ListQueue(ctx context.Context, req *enginev1.QueueRequest) (*enginev1.Queue, error) {
+	t, err := s.load(ctx, req.GetWorkDir(), false, RoleViewer, "")
+	if err != nil {
+		return nil, err
+	}
+	tickets := s.queue.List(t.key())
+	queue := &enginev1.Queue{Items: make([]*enginev1.QueueItem, 0, len(tickets))}
+	for i, ticket := range tickets {
+		queue.Items = append(queue.Items, &enginev1.QueueItem{
+			Id:          ticket.ID,
+			Operation:   ticket.Operation,
+			Operator:    ticket.Operator,
+			Priority:    ticket.Priority,
+			EnqueueTime: timestamppb.New(ticket.EnqueueTime),
+			Running:     i == 0,
+		})
+	}
+	return queue, nil
+}
+
+// CancelOperation removes a waiting apply from the queue of the stack, whose stream ends with the status
+// Canceled. Running applies can not be canceled, and only admins can cancel applies of others if RBAC is
+// enabled.
+func (s *EngineServer) CancelOperation(ctx context.Context, req *enginev1.CancelRequest) (*enginev1.CancelResponse, error) {
+	if req.GetOperationId() == "" {
+		return nil, grpcstatus.Error(codes.InvalidArgument, "operation id is required")
+	}
+	t, err := s.load(ctx, req.GetWorkDir(), false, RoleApplier, req.GetOperator())
+	if err != nil {
+		return nil, err
+	}
+	if s.RBAC != nil && t.role < RoleAdmin {
+		for _, ticket := range s.queue.List(t.key()) {
+			if ticket.ID == req.GetOperationId() && ticket.Operator != t.operator {
+				return nil, grpcstatus.Errorf(codes.PermissionDenied,
+					"user %s can not cancel the operation of %s, which requires the role %s", t.operator, ticket.Operator, RoleAdmin)
+			}
+		}
+	}
+	err = s.queue.Cancel(t.key(), req.GetOperationId(), t.operator)
+	switch {
+	case errors.Is(err, ErrTicketNotFound):
+		return nil, grpcstatus.Errorf(codes.NotFound, "operation %s: %v", req.GetOperationId(), err)
+	case errors.Is(err, ErrTicketRunning):
+		return nil, grpcstatus.Errorf(codes.FailedPrecondition, "operation %s: %v", req.GetOperationId(), err)
+	case err != nil:
+		return nil, grpcstatus.Error(codes.Internal, err.Error())
+	}
+	return &enginev1.CancelResponse{}, nil
+}
+
+// load detects the stack of the work directory and its state storage, after the user of the request is
+// authorized with the required role on the stack
+func (s *EngineServer) load(ctx context.Context, workDir string, apply bool, required Role, operator string) (*target, error) {
+	dir, err := s.resolve(workDir)
+	if err != nil {
+		return nil, grpcstatus.Error(codes.InvalidArgument, err.Error())
+	}
+	project, stack, err := projectstack.DetectProjectAndStack(dir)
+	if err != nil {
+		return nil, grpcstatus.Error(codes.NotFound, err.Error())
+	}
+	// stacks which can only be applied from signed spec files are not applied over gRPC, since their
+	// signatures can not be verified
+	if apply && !stack.Verification.IsEmpty() {
+		return nil, grpcstatus.Errorf(codes.FailedPrecondition,
+			"the stack %s can only be applied from a signed spec file with the CLI", stack.Name)
+	}
+	t := &target{dir: dir, project: project, stack: stack, operator: operator}
+	if err = s.authorize(ctx, t, required); err != nil {
+		return nil, err
+	}
+	if t.storage, err = backend.BackendFromConfig(project.Backend, s.BackendOps, dir); err != nil {
+		return nil, grpcstatus.Error(codes.Internal, err.Error())
+	}
+	return t, nil
+}
+
+// authorize checks the role of the user on the stack if RBAC is enabled, and replaces the operator with the
+// user, so that states and locks always record who really operates
+func (s *EngineServer) authorize(ctx context.Context, t *target, required Role) error {
+	if s.RBAC == nil {
+		return nil
+	}
+	id := IdentityFrom(ctx)
+	if id == nil {
+		return grpcstatus.Error(codes.Unauthenticated, "the request is not authenticated")
+	}
+	t.operator = id.User
+	t.role = s.RBAC.Role(id, t.project.Name, t.stack.Name)
+	if t.role < required {
+		return grpcstatus.Errorf(codes.PermissionDenied, "user %s has the role %s on the stack %s/%s, while %s is required",
+			id.User, t.role, t.project.Name, t.stack.Name, required)
+	}
+	return nil
+}
+
+// loadSpec compiles the stack of the request. Specs in requests are rejected, since they would bypass the
+// project, e.g. its secret stores and policies, and nothing proves that they are compiled from the stack.
+func (s *EngineServer) loadSpec(ctx context.Context, req *enginev1.OperationRequest, apply bool, required Role) (*target, error) {
+	if req.GetSpec() != nil {
+		return nil, grpcstatus.Error(codes.InvalidArgument,
+			"specs in requests are not accepted, stacks are compiled from their work directories")
+	}
+	t, err := s.load(ctx, req.GetWorkDir(), apply, required, req.GetOperator())
+	if err != nil {
+		return nil, err
+	}
+	if t.spec, err = spec.GenerateSpec(&generator.Options{WorkDir: t.dir}, t.project, t.stack); err != nil {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "compile the stack %s failed: %v", t.stack.Name, err)
+	}
+	return t, nil
+}
+
+// resolve returns the absolute path of the work directory with symlinks evaluated, which must be in the root
+// directory, so that neither .. nor symlinks lead out of it
+func (s *EngineServer) resolve(workDir string) (string, error) {
+	root, err := filepath.Abs(s.Root)
+	if err != nil {
+		return "", err
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return "", err
+	}
+	if filepath.IsAbs(workDir) {
+		return "", fmt.Errorf("work directory %s must be relative to the root directory", workDir)
+	}
+	dir := filepath.Join(root, workDir)
+	if !inDir(dir, root) {
+		return "", fmt.Errorf("work directory %s is not in the root directory", workDir)
+	}
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		return "", fmt.Errorf("work directory %s: %w", workDir, err)
+	}
+	if !inDir(dir, root) {
+		return "", fmt.Errorf("work directory %s links out of the root directory", workDir)
+	}
+	return dir, nil
+}
+
+// inDir returns whether the clean absolute path is the directory or in it
+func inDir(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// options returns the options of operations on the target, which are the defaults of kusion apply --yes with
+// the runtime env of the stack
+func (s *EngineServer) options(t *target, dryRun bool) (*applycmd.ApplyOptions, error) {
+	o := applycmd.NewApplyOptions()
+	o.WorkDir = t.dir
+	o.BackendOps = s.BackendOps
+	o.Operator = t.operator
+	o.NoStyle = true
+	o.Yes = true
+	o.DryRun = dryRun
+	var err error
+	if o.RuntimeEnv, err = runtime.StackEnv(context.Background(), t.project, t.stack); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// apply applies the changes prepared by applycmd.Prepare, streams events of resources and heartbeats of the
+// lock of the state, and returns the state applied. Dry runs report all resources as applied without changing
+// them, and return no state.
+func (s *EngineServer) apply(
+	o *applycmd.ApplyOptions,
+	t *target,
+	changes *opsmodels.Changes,
+	send func(*enginev1.Event) error,
+) (*states.State, error) {
+	o.OnMessage = func(step *opsmodels.ChangeStep, msg opsmodels.Message) {
+		// keep receiving messages if the client is gone, so that the apply is not blocked
+		_ = send(resourceEvent(step, msg))
+	}
+	o.OnHeartbeat = func(err error) {
+		e := &enginev1.Event{Type: progress.EventHeartbeat, Operation: "apply"}
+		if err != nil {
+			e.Error = err.Error()
+		}
+		_ = send(e)
+	}
+	return applycmd.Execute(o, t.storage, t.spec, changes, io.Discard)
+}
+
+// resourceEvent returns the event of the message of the resource
+func resourceEvent(changeStep *opsmodels.ChangeStep, msg opsmodels.Message) *enginev1.Event {
+	e := &enginev1.Event{
+		Type:       progress.EventResource,
+		ResourceId: msg.ResourceID,
+		Result:     strings.ToLower(string(msg.OpResult)),
+	}
+	if changeStep != nil {
+		e.Action = changeStep.Action.String()
+	}
+	if e.Result == "" {
+		e.Result = "applying"
+	}
+	if msg.OpErr != nil {
+		e.Error = msg.OpErr.Error()
+	}
+	return e
+}
+
+// newSender returns a function sending events to the stream with the time set, which can be called
+// concurrently, e.g. by heartbeats of the lock during the apply
+func newSender(send func(*enginev1.Event) error) func(*enginev1.Event) error {
+	var lock sync.Mutex
+	return func(e *enginev1.Event) error {
+		lock.Lock()
+		defer lock.Unlock()
+		e.Time = timestamppb.Now()
+		return send(e)
+	}
+}
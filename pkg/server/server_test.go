@@ -0,0 +1,481 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	enginev1 "kusionstack.io/kusion/pkg/apis/engine/v1"
+	"kusionstack.io/kusion/pkg/cmd/spec"
+	"kusionstack.io/kusion/pkg/engine/models"
+	"kusionstack.io/kusion/pkg/engine/operation"
+	opsmodels "kusionstack.io/kusion/pkg/engine/operation/models"
+	"kusionstack.io/kusion/pkg/engine/runtime"
+	"kusionstack.io/kusion/pkg/engine/runtime/kubernetes"
+	"kusionstack.io/kusion/pkg/engine/states"
+	"kusionstack.io/kusion/pkg/engine/states/local"
+	"kusionstack.io/kusion/pkg/generator"
+	"kusionstack.io/kusion/pkg/progress"
+	"kusionstack.io/kusion/pkg/projectstack"
+	"kusionstack.io/kusion/pkg/status"
+)
+
+var resource = models.Resource{
+	ID:   "v1:ConfigMap:default:cm",
+	Type: "Kubernetes",
+	Attributes: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "cm", "namespace": "default"},
+		"data":       map[string]interface{}{"replicas": 3},
+	},
+}
+
+// newClient serves the engine on stacks in a temporary root directory, with the stack detected at any
+// directory in it
+func newClient(t *testing.T, opts ...grpc.ServerOption) (enginev1.EngineClient, *EngineServer) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "demo", "dev"), 0o755))
+	monkey.Patch(projectstack.DetectProjectAndStack, func(dir string) (*projectstack.Project, *projectstack.Stack, error) {
+		project := &projectstack.Project{
+			ProjectConfiguration: projectstack.ProjectConfiguration{Name: "demo", Tenant: "admin"},
+			Path:                 dir,
+		}
+		stack := &projectstack.Stack{
+			StackConfiguration: projectstack.StackConfiguration{Name: "dev"},
+			Path:               dir,
+		}
+		return project, stack, nil
+	})
+	monkey.Patch(spec.GenerateSpec, func(*generator.Options, *projectstack.Project, *projectstack.Stack) (*models.Spec, error) {
+		return &models.Spec{Resources: models.Resources{resource}}, nil
+	})
+	// checks of permissions, capacity and namespaces are skipped by runtimes not implementing them
+	monkey.Patch(kubernetes.NewKubernetesRuntime, func(runtime.Env) (runtime.Runtime, error) {
+		return fakeRuntime{}, nil
+	})
+
+	listener := bufconn.Listen(1 << 20)
+	engine := NewEngineServer(root)
+	s := grpc.NewServer(opts...)
+	enginev1.RegisterEngineServer(s, engine)
+	go func() {
+		_ = s.Serve(listener)
+	}()
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+		s.Stop()
+	})
+	return enginev1.NewEngineClient(conn), engine
+}
+
+// fakeRuntime is a Kubernetes runtime which implements no checks before applies
+type fakeRuntime struct {
+	runtime.Runtime
+}
+
+func mockPreview() {
+	monkey.Patch((*operation.PreviewOperation).Preview,
+		func(_ *operation.PreviewOperation, req *operation.PreviewRequest) (*operation.PreviewResponse, status.Status) {
+			r := req.Spec.Resources[0]
+			return &operation.PreviewResponse{Order: &opsmodels.ChangeOrder{
+				StepKeys:    []string{r.ID},
+				ChangeSteps: map[string]*opsmodels.ChangeStep{r.ID: opsmodels.NewChangeStep(r.ID, opsmodels.Create, nil, &r)},
+			}}, nil
+		})
+}
+
+func mockApply(err error) {
+	monkey.Patch((*operation.ApplyOperation).Apply,
+		func(o *operation.ApplyOperation, req *operation.ApplyRequest) (*operation.ApplyResponse, status.Status) {
+			id := req.Spec.Resources[0].ID
+			o.MsgCh <- opsmodels.Message{ResourceID: id}
+			defer close(o.MsgCh)
+			if err != nil {
+				o.MsgCh <- opsmodels.Message{ResourceID: id, OpResult: opsmodels.Failed, OpErr: err}
+				return nil, status.NewErrorStatus(err)
+			}
+			o.MsgCh <- opsmodels.Message{ResourceID: id, OpResult: opsmodels.Success}
+			state := states.NewState()
+			state.Project, state.Stack, state.Serial = "demo", "dev", 1
+			state.Resources = req.Spec.Resources
+			return &operation.ApplyResponse{State: state}, nil
+		})
+}
+
+// receive reads events of the stream until it ends, streams of previews and applies are the same
+func receive(t *testing.T, stream enginev1.Engine_PreviewClient) []*enginev1.Event {
+	var events []*enginev1.Event
+	for {
+		e, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return events
+		}
+		require.NoError(t, err)
+		events = append(events, e)
+	}
+}
+
+func TestEngineServer_Preview(t *testing.T) {
+	defer monkey.UnpatchAll()
+	client, _ := newClient(t)
+	mockPreview()
+
+	stream, err := client.Preview(context.Background(), &enginev1.OperationRequest{WorkDir: "demo/dev"})
+	require.NoError(t, err)
+	events := receive(t, stream)
+	require.Len(t, events, 3)
+	assert.Equal(t, progress.EventStart, events[0].Type)
+	assert.Equal(t, int32(1), events[0].Total)
+	assert.Equal(t, "dev", events[0].Stack)
+	assert.Equal(t, progress.EventResource, events[1].Type)
+	assert.Equal(t, resource.ID, events[1].ResourceId)
+	assert.Equal(t, opsmodels.Create.String(), events[1].Action)
+	assert.Contains(t, events[1].Diff, "ConfigMap")
+	assert.Equal(t, progress.EventFinish, events[2].Type)
+	assert.Equal(t, ResultSuccess, events[2].Result)
+	assert.NotNil(t, events[2].Time)
+}
+
+func TestEngineServer_Apply(t *testing.T) {
+	t.Run("apply", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		client, _ := newClient(t)
+		mockPreview()
+		mockApply(nil)
+
+		stream, err := client.Apply(context.Background(), &enginev1.OperationRequest{WorkDir: "demo/dev"})
+		require.NoError(t, err)
+		events := receive(t, stream)
+		require.Len(t, events, 4)
+		assert.Equal(t, "applying", events[1].Result)
+		assert.Equal(t, "success", events[2].Result)
+		finish := events[3]
+		assert.Equal(t, ResultSuccess, finish.Result)
+		require.NotNil(t, finish.State)
+		assert.Equal(t, uint64(1), finish.State.Serial)
+		assert.Equal(t, resource.ID, finish.State.Resources[0].Id)
+	})
+
+	t.Run("apply failed", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		client, _ := newClient(t)
+		mockPreview()
+		mockApply(errors.New("quota exceeded"))
+
+		stream, err := client.Apply(context.Background(), &enginev1.OperationRequest{WorkDir: "demo/dev"})
+		require.NoError(t, err)
+		events := receive(t, stream)
+		require.Len(t, events, 4)
+		assert.Equal(t, "quota exceeded", events[2].Error)
+		assert.Equal(t, ResultFailed, events[3].Result)
+		assert.Contains(t, events[3].Error, "quota exceeded")
+		assert.Nil(t, events[3].State)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		client, _ := newClient(t)
+		mockPreview()
+
+		stream, err := client.Apply(context.Background(),
+			&enginev1.OperationRequest{WorkDir: "demo/dev", DryRun: true})
+		require.NoError(t, err)
+		events := receive(t, stream)
+		require.Len(t, events, 3)
+		assert.Equal(t, "success", events[1].Result)
+		assert.Equal(t, ResultSuccess, events[2].Result)
+	})
+
+	t.Run("queued", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		client, engine := newClient(t)
+		mockPreview()
+		mockApply(nil)
+		running := engine.queue.Enqueue("admin/demo/dev", "apply", "alice", 0)
+
+		stream, err := client.Apply(context.Background(), &enginev1.OperationRequest{WorkDir: "demo/dev"})
+		require.NoError(t, err)
+		queued, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, progress.EventQueued, queued.Type)
+		assert.Equal(t, int32(1), queued.Position)
+		assert.NotEmpty(t, queued.OperationId)
+
+		engine.queue.Done(running)
+		events := receive(t, stream)
+		require.Len(t, events, 4)
+		assert.Equal(t, progress.EventStart, events[0].Type)
+		assert.Equal(t, queued.OperationId, events[0].OperationId)
+		assert.Equal(t, ResultSuccess, events[3].Result)
+		assert.Empty(t, engine.queue.List("admin/demo/dev"))
+	})
+
+	t.Run("canceled in the queue", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		client, engine := newClient(t)
+		running := engine.queue.Enqueue("admin/demo/dev", "apply", "alice", 0)
+		defer engine.queue.Done(running)
+
+		stream, err := client.Apply(context.Background(), &enginev1.OperationRequest{WorkDir: "demo/dev"})
+		require.NoError(t, err)
+		queued, err := stream.Recv()
+		require.NoError(t, err)
+
+		_, err = client.CancelOperation(context.Background(),
+			&enginev1.CancelRequest{WorkDir: "demo/dev", OperationId: queued.OperationId, Operator: "bob"})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Canceled, grpcstatus.Code(err))
+		assert.Contains(t, grpcstatus.Convert(err).Message(), "bob")
+	})
+
+	t.Run("spec in the request", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		client, _ := newClient(t)
+		mockPreview()
+		mockApply(nil)
+
+		stream, err := client.Apply(context.Background(), &enginev1.OperationRequest{
+			WorkDir: "demo/dev",
+			Spec:    &enginev1.Spec{Resources: []*enginev1.Resource{{Id: resource.ID, Type: "Kubernetes"}}},
+		})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.InvalidArgument, grpcstatus.Code(err))
+		assert.Contains(t, grpcstatus.Convert(err).Message(), "specs in requests are not accepted")
+	})
+
+	t.Run("work directory out of the root", func(t *testing.T) {
+		defer monkey.UnpatchAll()
+		client, _ := newClient(t)
+
+		stream, err := client.Apply(context.Background(), &enginev1.OperationRequest{WorkDir: "../dev"})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.InvalidArgument, grpcstatus.Code(err))
+	})
+}
+
+func TestEngineServer_GetState(t *testing.T) {
+	defer monkey.UnpatchAll()
+	client, engine := newClient(t)
+
+	_, err := client.GetState(context.Background(), &enginev1.StateRequest{WorkDir: "demo/dev"})
+	assert.Equal(t, codes.NotFound, grpcstatus.Code(err))
+
+	state := states.NewState()
+	state.Project, state.Stack, state.Serial = "demo", "dev", 2
+	secret := models.Resource{
+		ID:         "v1:Secret:default:db",
+		Type:       "Kubernetes",
+		Attributes: map[string]interface{}{"apiVersion": "v1", "kind": "Secret", "data": map[string]interface{}{"password": "cGFzcw=="}},
+	}
+	state.Resources = models.Resources{resource, secret}
+	storage := &local.FileSystemState{Path: filepath.Join(engine.Root, "demo", "dev", local.KusionState)}
+	require.NoError(t, storage.Apply(state))
+
+	result, err := client.GetState(context.Background(), &enginev1.StateRequest{WorkDir: "demo/dev"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), result.Serial)
+	require.Len(t, result.Resources, 2)
+	data := result.Resources[1].Attributes.Fields["data"].GetStructValue().AsMap()
+	assert.NotEqual(t, "cGFzcw==", data["password"])
+}
+
+func TestEngineServer_ListQueue(t *testing.T) {
+	defer monkey.UnpatchAll()
+	client, engine := newClient(t)
+
+	queue, err := client.ListQueue(context.Background(), &enginev1.QueueRequest{WorkDir: "demo/dev"})
+	require.NoError(t, err)
+	assert.Empty(t, queue.Items)
+
+	running := engine.queue.Enqueue("admin/demo/dev", "apply", "alice", 0)
+	defer engine.queue.Done(running)
+	engine.queue.Enqueue("admin/demo/dev", "apply", "bob", 0)
+	engine.queue.Enqueue("admin/demo/dev", "apply", "carol", 10)
+
+	queue, err = client.ListQueue(context.Background(), &enginev1.QueueRequest{WorkDir: "demo/dev"})
+	require.NoError(t, err)
+	require.Len(t, queue.Items, 3)
+	assert.Equal(t, "alice", queue.Items[0].Operator)
+	assert.True(t, queue.Items[0].Running)
+	assert.Equal(t, "carol", queue.Items[1].Operator)
+	assert.Equal(t, int32(10), queue.Items[1].Priority)
+	assert.False(t, queue.Items[1].Running)
+	assert.Equal(t, "bob", queue.Items[2].Operator)
+}
+
+func TestEngineServer_CancelOperation(t *testing.T) {
+	defer monkey.UnpatchAll()
+	client, engine := newClient(t)
+	running := engine.queue.Enqueue("admin/demo/dev", "apply", "alice", 0)
+	defer engine.queue.Done(running)
+	waiting := engine.queue.Enqueue("admin/demo/dev", "apply", "bob", 0)
+
+	tests := []struct {
+		name string
+		id   string
+		want codes.Code
+	}{
+		{name: "no id", id: "", want: codes.InvalidArgument},
+		{name: "running", id: running.ID, want: codes.FailedPrecondition},
+		{name: "waiting", id: waiting.ID, want: codes.OK},
+		{name: "not found", id: waiting.ID, want: codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.CancelOperation(context.Background(),
+				&enginev1.CancelRequest{WorkDir: "demo/dev", OperationId: tt.id, Operator: "carol"})
+			assert.Equal(t, tt.want, grpcstatus.Code(err))
+		})
+	}
+}
+
+// tokenAuthenticator authenticates users whose names are their tokens
+type tokenAuthenticator map[string][]string
+
+func (a tokenAuthenticator) Authenticate(_ context.Context, token string) (*Identity, error) {
+	groups, ok := a[token]
+	if !ok {
+		return nil, errors.New("unknown token")
+	}
+	return &Identity{User: token, Groups: groups}, nil
+}
+
+func TestEngineServer_RBAC(t *testing.T) {
+	defer monkey.UnpatchAll()
+	client, engine := newClient(t, AuthOptions(tokenAuthenticator{
+		"vera": nil, "pete": nil, "alice": nil, "bob": nil, "carol": {"platform"}, "dave": nil,
+	})...)
+	engine.RBAC = &RBAC{Bindings: []RoleBinding{
+		{Role: "viewer", Users: []string{"vera"}},
+		{Role: "previewer", Users: []string{"pete"}},
+		{Role: "applier", Users: []string{"alice", "bob"}, Stacks: []string{"demo/dev"}},
+		{Role: "admin", Groups: []string{"platform"}},
+		{Role: "applier", Users: []string{"dave"}, Stacks: []string{"demo/prod"}},
+	}}
+	mockPreview()
+	mockApply(nil)
+	as := func(user string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+user)
+	}
+	apply := func(ctx context.Context, dryRun bool) error {
+		stream, err := client.Apply(ctx, &enginev1.OperationRequest{WorkDir: "demo/dev", DryRun: dryRun})
+		require.NoError(t, err)
+		for {
+			if _, err = stream.Recv(); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return err
+			}
+		}
+	}
+	preview := func(ctx context.Context) error {
+		stream, err := client.Preview(ctx, &enginev1.OperationRequest{WorkDir: "demo/dev"})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		return err
+	}
+
+	_, err := client.GetState(context.Background(), &enginev1.StateRequest{WorkDir: "demo/dev"})
+	assert.Equal(t, codes.Unauthenticated, grpcstatus.Code(err))
+	_, err = client.GetState(as("mallory"), &enginev1.StateRequest{WorkDir: "demo/dev"})
+	assert.Equal(t, codes.Unauthenticated, grpcstatus.Code(err))
+
+	// viewers can read states, which are not found
+	_, err = client.GetState(as("vera"), &enginev1.StateRequest{WorkDir: "demo/dev"})
+	assert.Equal(t, codes.NotFound, grpcstatus.Code(err))
+	assert.Equal(t, codes.PermissionDenied, grpcstatus.Code(preview(as("vera"))))
+
+	assert.NoError(t, preview(as("pete")))
+	assert.NoError(t, apply(as("pete"), true))
+	assert.Equal(t, codes.PermissionDenied, grpcstatus.Code(apply(as("pete"), false)))
+
+	assert.Equal(t, codes.PermissionDenied, grpcstatus.Code(apply(as("dave"), false)))
+	assert.NoError(t, apply(as("alice"), false))
+
+	// operators of applies are the authenticated users, whatever requests claim
+	running := engine.queue.Enqueue("admin/demo/dev", "apply", "someone", 0)
+	defer engine.queue.Done(running)
+	stream, err := client.Apply(as("alice"),
+		&enginev1.OperationRequest{WorkDir: "demo/dev", Operator: "bob"})
+	require.NoError(t, err)
+	queued, err := stream.Recv()
+	require.NoError(t, err)
+	items := engine.queue.List("admin/demo/dev")
+	require.Len(t, items, 2)
+	assert.Equal(t, "alice", items[1].Operator)
+
+	cancel := func(user string) error {
+		_, err := client.CancelOperation(as(user),
+			&enginev1.CancelRequest{WorkDir: "demo/dev", OperationId: queued.OperationId})
+		return err
+	}
+	assert.Equal(t, codes.PermissionDenied, grpcstatus.Code(cancel("bob")))
+	assert.NoError(t, cancel("carol"))
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, grpcstatus.Code(err))
+	assert.Contains(t, grpcstatus.Convert(err).Message(), "canceled by carol")
+}
+
+func TestEngineServer_resolve(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "stacks")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "demo", "dev"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "dev"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(base, "stacks-other", "dev"), 0o755))
+	require.NoError(t, os.Symlink(filepath.Join(base, "stacks-other"), filepath.Join(root, "escape")))
+	require.NoError(t, os.Symlink(filepath.Join(root, "demo"), filepath.Join(root, "alias")))
+	base, err := filepath.EvalSymlinks(base)
+	require.NoError(t, err)
+	root = filepath.Join(base, "stacks")
+
+	s := NewEngineServer(root)
+	tests := []struct {
+		workDir string
+		want    string
+		wantErr bool
+	}{
+		{workDir: "", want: root},
+		{workDir: "demo/dev", want: filepath.Join(root, "demo", "dev")},
+		{workDir: "demo/../dev", want: filepath.Join(root, "dev")},
+		{workDir: "alias/dev", want: filepath.Join(root, "demo", "dev")},
+		{workDir: "missing", wantErr: true},
+		{workDir: "escape/dev", wantErr: true},
+		{workDir: "../other", wantErr: true},
+		{workDir: "../stacks-other/dev", wantErr: true},
+		{workDir: "/etc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.workDir, func(t *testing.T) {
+			got, err := s.resolve(tt.workDir)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
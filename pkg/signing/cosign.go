@@ -0,0 +1,134 @@
+// Package signing signs compiled specs with cosign and verifies their signatures, so that only specs
+// produced by trusted pipelines are applied.
+package signing
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"kusionstack.io/kusion/pkg/log"
+)
+
+const (
+	// SignatureSuffix is the suffix of the signature file saved beside the spec file
+	SignatureSuffix = ".sig"
+	// CertificateSuffix is the suffix of the certificate file saved beside the spec file by keyless signing
+	CertificateSuffix = ".pem"
+)
+
+// SignatureFile returns the path of the signature file of the spec file
+func SignatureFile(file string) string {
+	return file + SignatureSuffix
+}
+
+// CertificateFile returns the path of the certificate file of the spec file
+func CertificateFile(file string) string {
+	return file + CertificateSuffix
+}
+
+// SignOptions are options to sign spec files
+type SignOptions struct {
+	// Key is the private key, or a KMS URI of the key. Keyless signing with the OIDC identity of the
+	// current environment, e.g. GitHub Actions, is used if the key is empty.
+	Key string
+}
+
+// Sign signs the spec file with cosign, and saves the signature, and the certificate of keyless signing,
+// beside the file
+func Sign(file string, o *SignOptions) error {
+	args := []string{"sign-blob", "--yes", "--output-signature", SignatureFile(file)}
+	if o.Key != "" {
+		args = append(args, "--key", o.Key)
+	} else {
+		args = append(args, "--output-certificate", CertificateFile(file))
+	}
+	if _, err := runCosign(append(args, file)...); err != nil {
+		return fmt.Errorf("sign %s failed: %w", file, err)
+	}
+	return nil
+}
+
+// VerifyOptions are options to verify signatures of spec files. Either the public key, or the identity
+// and the OIDC issuer of the certificate of keyless signing must be specified.
+type VerifyOptions struct {
+	// Key is the public key, or a KMS URI of the key
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+
+	// CertificateIdentity is the expected identity in the certificate, e.g. the workflow of the CI
+	CertificateIdentity string `json:"certificateIdentity,omitempty" yaml:"certificateIdentity,omitempty"`
+
+	// CertificateOIDCIssuer is the expected OIDC issuer in the certificate,
+	// e.g. https://token.actions.githubusercontent.com
+	CertificateOIDCIssuer string `json:"certificateOIDCIssuer,omitempty" yaml:"certificateOIDCIssuer,omitempty"`
+}
+
+// IsEmpty returns true if no key or certificate is specified to verify with
+func (o *VerifyOptions) IsEmpty() bool {
+	return o == nil || (o.Key == "" && o.CertificateIdentity == "" && o.CertificateOIDCIssuer == "")
+}
+
+// Validate checks whether the options are complete
+func (o *VerifyOptions) Validate() error {
+	if o.Key != "" {
+		return nil
+	}
+	if o.CertificateIdentity == "" || o.CertificateOIDCIssuer == "" {
+		return fmt.Errorf("a public key, or both the certificate identity and OIDC issuer are required to verify signatures")
+	}
+	return nil
+}
+
+// Verify verifies the data read from the spec file with the signature saved beside the file by Sign. The data
+// is verified instead of the file, so that callers parse exactly the bytes verified even if the file is
+// replaced after it is read.
+func Verify(file string, data []byte, o *VerifyOptions) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	signature := SignatureFile(file)
+	if _, err := os.Stat(signature); err != nil {
+		return fmt.Errorf("signature of %s not found: %w", file, err)
+	}
+
+	// cosign reads the blob from a private copy of the data
+	dir, err := os.MkdirTemp("", "kusion-verify-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	blob := filepath.Join(dir, filepath.Base(file))
+	if err = os.WriteFile(blob, data, 0o600); err != nil {
+		return err
+	}
+
+	args := []string{"verify-blob", "--signature", signature}
+	if o.Key != "" {
+		args = append(args, "--key", o.Key)
+	} else {
+		args = append(args,
+			"--certificate", CertificateFile(file),
+			"--certificate-identity", o.CertificateIdentity,
+			"--certificate-oidc-issuer", o.CertificateOIDCIssuer,
+		)
+	}
+	if _, err = runCosign(append(args, blob)...); err != nil {
+		return fmt.Errorf("verify the signature of %s failed: %w", file, err)
+	}
+	return nil
+}
+
+// runCosign runs the cosign CLI and returns its stdout
+var runCosign = func(args ...string) ([]byte, error) {
+	log.Debugf("run cosign with args: %v", args)
+	out, err := exec.Command("cosign", args...).Output()
+	if e, ok := err.(*exec.ExitError); ok {
+		return nil, fmt.Errorf("cosign %s failed: %s", args[0], strings.TrimSpace(string(e.Stderr)))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
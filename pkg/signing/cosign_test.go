@@ -0,0 +1,85 @@
+package signing
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mockCosign(t *testing.T, err error) *[][]string {
+	var calls [][]string
+	origin := runCosign
+	runCosign = func(args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		return nil, err
+	}
+	t.Cleanup(func() {
+		runCosign = origin
+	})
+	return &calls
+}
+
+func TestSign(t *testing.T) {
+	calls := mockCosign(t, nil)
+	assert.Nil(t, Sign("spec.yaml", &SignOptions{Key: "cosign.key"}))
+	assert.Nil(t, Sign("spec.yaml", &SignOptions{}))
+	assert.Equal(t, [][]string{
+		{"sign-blob", "--yes", "--output-signature", "spec.yaml.sig", "--key", "cosign.key", "spec.yaml"},
+		{"sign-blob", "--yes", "--output-signature", "spec.yaml.sig", "--output-certificate", "spec.yaml.pem", "spec.yaml"},
+	}, *calls)
+
+	mockCosign(t, errors.New("cosign sign-blob failed: no identity token"))
+	assert.EqualError(t, Sign("spec.yaml", &SignOptions{}),
+		"sign spec.yaml failed: cosign sign-blob failed: no identity token")
+}
+
+func TestVerify(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "spec.yaml")
+	assert.Nil(t, os.WriteFile(file, []byte("[]"), 0o644))
+
+	// the data read by the caller is verified instead of the file
+	calls := mockCosign(t, nil)
+	var blobs []string
+	origin := runCosign
+	runCosign = func(args ...string) ([]byte, error) {
+		blob := args[len(args)-1]
+		data, err := os.ReadFile(blob)
+		assert.Nil(t, err)
+		assert.Equal(t, "- id: a\n", string(data))
+		blobs = append(blobs, blob)
+		return origin(args[:len(args)-1]...)
+	}
+	err := Verify(file, []byte("- id: a\n"), &VerifyOptions{Key: "cosign.pub"})
+	assert.ErrorContains(t, err, "signature of "+file+" not found")
+
+	assert.Nil(t, os.WriteFile(SignatureFile(file), []byte("sig"), 0o644))
+	assert.Nil(t, Verify(file, []byte("- id: a\n"), &VerifyOptions{Key: "cosign.pub"}))
+	assert.Nil(t, Verify(file, []byte("- id: a\n"), &VerifyOptions{CertificateIdentity: "ci", CertificateOIDCIssuer: "https://issuer"}))
+	assert.Equal(t, [][]string{
+		{"verify-blob", "--signature", file + ".sig", "--key", "cosign.pub"},
+		{
+			"verify-blob", "--signature", file + ".sig", "--certificate", file + ".pem",
+			"--certificate-identity", "ci", "--certificate-oidc-issuer", "https://issuer",
+		},
+	}, *calls)
+	for _, blob := range blobs {
+		assert.NotEqual(t, file, blob)
+		assert.NoFileExists(t, blob)
+	}
+
+	assert.ErrorContains(t, Verify(file, nil, &VerifyOptions{CertificateIdentity: "ci"}), "OIDC issuer are required")
+
+	mockCosign(t, errors.New("cosign verify-blob failed: invalid signature"))
+	assert.EqualError(t, Verify(file, nil, &VerifyOptions{Key: "cosign.pub"}),
+		"verify the signature of "+file+" failed: cosign verify-blob failed: invalid signature")
+}
+
+func TestVerifyOptions_IsEmpty(t *testing.T) {
+	var o *VerifyOptions
+	assert.True(t, o.IsEmpty())
+	assert.True(t, (&VerifyOptions{}).IsEmpty())
+	assert.False(t, (&VerifyOptions{Key: "cosign.pub"}).IsEmpty())
+}
@@ -40,6 +40,9 @@ type BaseStatus struct {
 	kind    Kind
 	code    Code
 	message string
+
+	// cause is the error the status is created with, nil if it is created with a message
+	cause error
 }
 
 func (b *BaseStatus) Kind() Kind {
@@ -67,13 +70,46 @@ func NewBaseStatus(kind Kind, code Code, message string) *BaseStatus {
 }
 
 func NewErrorStatus(err error) *BaseStatus {
-	return &BaseStatus{kind: Error, code: Internal, message: err.Error()}
+	return &BaseStatus{kind: Error, code: Internal, message: err.Error(), cause: err}
 }
 
 func NewErrorStatusWithCode(code Code, err error) *BaseStatus {
-	return &BaseStatus{kind: Error, code: code, message: err.Error()}
+	return &BaseStatus{kind: Error, code: code, message: err.Error(), cause: err}
 }
 
 func NewErrorStatusWithMsg(code Code, msg string) *BaseStatus {
 	return &BaseStatus{kind: Error, code: code, message: msg}
 }
+
+// Cause returns the error the status is created with, nil if it is created with a message
+func Cause(s Status) error {
+	if b, ok := s.(*BaseStatus); ok {
+		return b.cause
+	}
+	return nil
+}
+
+// Wrap returns an error with the message, which unwraps to the error the status is created with, so that callers
+// can still tell typed errors apart with errors.Is and errors.As, e.g. lock conflicts of the state
+func Wrap(s Status, message string) error {
+	return WrapError(message, Cause(s))
+}
+
+// WrapError returns an error with the message, which unwraps to the cause
+func WrapError(message string, cause error) error {
+	return &causedError{message: message, cause: cause}
+}
+
+// causedError is an error with its own message, which unwraps to the error causing it
+type causedError struct {
+	message string
+	cause   error
+}
+
+func (e *causedError) Error() string {
+	return e.message
+}
+
+func (e *causedError) Unwrap() error {
+	return e.cause
+}
@@ -0,0 +1,147 @@
+// Package telemetry reports anonymous usage of kusion commands to help maintainers prioritize, which is disabled
+// unless users opt in by `kusion telemetry on`.
+//
+// An event only contains the name of the builtin command, the version and platform of kusion, the duration and
+// the class of the error if the command failed. It never contains identifiers of users or machines, arguments,
+// error messages, or anything of projects, stacks and resources.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"kusionstack.io/kusion/pkg/release"
+	"kusionstack.io/kusion/pkg/util/kfile"
+)
+
+const (
+	// EnvURL overrides the URL which events are reported to
+	EnvURL = "KUSION_TELEMETRY_URL"
+	// EnvDoNotTrack disables telemetry even if it is turned on, see https://consoledonottrack.com
+	EnvDoNotTrack = "DO_NOT_TRACK"
+
+	// DefaultURL is the URL which events are reported to by default
+	DefaultURL = "https://telemetry.kusionstack.io/v1/events"
+
+	// ConfigFile is the file in the kusion data folder that saves whether telemetry is turned on
+	ConfigFile = "telemetry.json"
+)
+
+// Timeout is the timeout of reporting an event, which is short to not delay the exit of commands
+var Timeout = 2 * time.Second
+
+var client = &http.Client{Timeout: Timeout}
+
+// Config is the telemetry config of the current user
+type Config struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Event is the usage of a command
+type Event struct {
+	// Command is the path of the builtin command, e.g. kusion apply
+	Command  string `json:"command"`
+	Version  string `json:"version"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	Duration int64  `json:"durationMillis"`
+	// ErrorClass is the class of the error if the command failed, e.g. policy_violation
+	ErrorClass string `json:"errorClass,omitempty"`
+}
+
+// Status returns whether telemetry is turned on, and the environment variable that disables it if any
+func Status() (enabled bool, disabledBy string, err error) {
+	if v := os.Getenv(EnvDoNotTrack); v == "1" || v == "true" {
+		disabledBy = EnvDoNotTrack
+	} else if release.Offline() {
+		disabledBy = release.EnvOffline
+	}
+	config, err := readConfig()
+	if err != nil {
+		return false, disabledBy, err
+	}
+	return config.Enabled, disabledBy, nil
+}
+
+// Enabled returns true if telemetry is turned on and not disabled by environment variables
+func Enabled() bool {
+	enabled, disabledBy, err := Status()
+	return err == nil && enabled && disabledBy == ""
+}
+
+// SetEnabled turns telemetry on or off for the current user
+func SetEnabled(enabled bool) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(&Config{Enabled: enabled})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// Report reports the event if telemetry is enabled
+func Report(ctx context.Context, event *Event) error {
+	if !Enabled() {
+		return nil
+	}
+	url := DefaultURL
+	if u := os.Getenv(EnvURL); u != "" {
+		url = u
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("report usage to %s failed: %s", url, resp.Status)
+	}
+	return nil
+}
+
+func configPath() (string, error) {
+	dir, err := kfile.KusionDataFolder()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, ConfigFile), nil
+}
+
+// readConfig reads the config of the current user, telemetry is off if there is no config
+func readConfig() (*Config, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if err = json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("parse %s failed: %w", path, err)
+	}
+	return config, nil
+}
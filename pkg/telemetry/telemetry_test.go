@@ -0,0 +1,69 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kusionstack.io/kusion/pkg/release"
+	"kusionstack.io/kusion/pkg/util/kfile"
+)
+
+func TestSetEnabled(t *testing.T) {
+	t.Setenv(kfile.EnvKusionPath, t.TempDir())
+	t.Setenv(EnvDoNotTrack, "")
+	t.Setenv(release.EnvOffline, "")
+
+	enabled, disabledBy, err := Status()
+	require.NoError(t, err)
+	assert.False(t, enabled)
+	assert.Empty(t, disabledBy)
+	assert.False(t, Enabled())
+
+	require.NoError(t, SetEnabled(true))
+	assert.True(t, Enabled())
+
+	t.Setenv(EnvDoNotTrack, "1")
+	enabled, disabledBy, err = Status()
+	require.NoError(t, err)
+	assert.True(t, enabled)
+	assert.Equal(t, EnvDoNotTrack, disabledBy)
+	assert.False(t, Enabled())
+
+	t.Setenv(EnvDoNotTrack, "")
+	t.Setenv(release.EnvOffline, "true")
+	_, disabledBy, _ = Status()
+	assert.Equal(t, release.EnvOffline, disabledBy)
+
+	t.Setenv(release.EnvOffline, "")
+	require.NoError(t, SetEnabled(false))
+	assert.False(t, Enabled())
+}
+
+func TestReport(t *testing.T) {
+	t.Setenv(kfile.EnvKusionPath, t.TempDir())
+	t.Setenv(EnvDoNotTrack, "")
+	t.Setenv(release.EnvOffline, "")
+
+	var events []Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e := Event{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&e))
+		events = append(events, e)
+	}))
+	defer server.Close()
+	t.Setenv(EnvURL, server.URL)
+
+	event := &Event{Command: "kusion apply", Version: "v0.8.0", OS: "linux", Arch: "amd64", Duration: 1000}
+	require.NoError(t, Report(context.Background(), event))
+	assert.Empty(t, events, "nothing is reported unless telemetry is turned on")
+
+	require.NoError(t, SetEnabled(true))
+	require.NoError(t, Report(context.Background(), event))
+	assert.Equal(t, []Event{*event}, events)
+}
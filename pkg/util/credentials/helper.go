@@ -0,0 +1,114 @@
+// Package credentials runs credential helpers, which are executables vending credentials of state backends and
+// runtimes, like credential helpers of docker and git. A helper is run as `<helper> get` with a Request in JSON
+// on its stdin, and prints Credentials in JSON to its stdout.
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// HelperPrefix prefixes names of helpers looked up in PATH, e.g. the helper vault is kusion-credential-vault
+const HelperPrefix = "kusion-credential-"
+
+// Timeout is how long a helper may run
+var Timeout = 30 * time.Second
+
+// helperName matches names of helpers, which are never paths
+var helperName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Kinds of requests
+const (
+	KindBackend = "backend"
+	KindRuntime = "runtime"
+)
+
+// Request is what the credentials are requested for, which is written to the stdin of the helper
+type Request struct {
+	// Kind is backend or runtime
+	Kind string `json:"kind"`
+
+	// Type is the type of the backend, e.g. s3, or empty for runtimes
+	Type string `json:"type,omitempty"`
+
+	// Config is the config of the backend, which tells the helper e.g. which bucket is accessed
+	Config map[string]interface{} `json:"config,omitempty"`
+
+	// Project and Stack are the project and the stack of runtimes
+	Project string `json:"project,omitempty"`
+	Stack   string `json:"stack,omitempty"`
+}
+
+// Credentials is printed by the helper to its stdout
+type Credentials struct {
+	// Config is merged into the config of the backend, e.g. accessKeyID and accessKeySecret of s3 backends
+	Config map[string]interface{} `json:"config,omitempty"`
+
+	// Env are environment variables of runtimes, e.g. AWS_ACCESS_KEY_ID of Terraform providers or KUBECONFIG
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// Get runs the helper and returns the credentials it prints. The helper is a name whose executable prefixed by
+// HelperPrefix is in PATH.
+func Get(ctx context.Context, helper string, req *Request) (*Credentials, error) {
+	path, err := lookPath(helper)
+	if err != nil {
+		return nil, err
+	}
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, "get")
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		return nil, fmt.Errorf("credential helper %s failed: %w: %s", helper, err, strings.TrimSpace(stderr.String()))
+	}
+
+	creds := &Credentials{}
+	if err = json.Unmarshal(stdout.Bytes(), creds); err != nil {
+		return nil, fmt.Errorf("invalid credentials printed by the helper %s: %w", helper, err)
+	}
+	return creds, nil
+}
+
+// Env gets environment variables of runtimes of the stack from the helper, which are passed only to the
+// runtimes and the processes they run. Nothing is returned if the helper is empty.
+func Env(ctx context.Context, helper, project, stack string) (map[string]string, error) {
+	if helper == "" {
+		return nil, nil
+	}
+	creds, err := Get(ctx, helper, &Request{Kind: KindRuntime, Project: project, Stack: stack})
+	if err != nil {
+		return nil, err
+	}
+	return creds.Env, nil
+}
+
+// lookPath returns the executable of the helper in PATH. Helpers are configured in project.yaml and backend
+// configs, which are checked in repositories, so paths are rejected to never run executables of repositories.
+func lookPath(helper string) (string, error) {
+	if helper == "" {
+		return "", fmt.Errorf("the credential helper is empty")
+	}
+	if !helperName.MatchString(helper) {
+		return "", fmt.Errorf("invalid credential helper %s, must be a name of %s<name> in PATH", helper, HelperPrefix)
+	}
+	path, err := exec.LookPath(HelperPrefix + helper)
+	if err != nil {
+		return "", fmt.Errorf("credential helper %s not found in PATH: %w", HelperPrefix+helper, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,65 @@
+package credentials
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeHelper writes a helper printing the output if its stdin contains the expected request
+func writeHelper(t *testing.T, dir, name, expected, output string) string {
+	path := filepath.Join(dir, name)
+	script := "#!/bin/sh\n" +
+		"[ \"$1\" = get ] || exit 1\n" +
+		"grep -q '" + expected + "' || { echo 'unexpected request' >&2; exit 1; }\n" +
+		"echo '" + output + "'\n"
+	assert.Nil(t, os.WriteFile(path, []byte(script), 0o755))
+	return path
+}
+
+func TestGet(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	writeHelper(t, dir, HelperPrefix+"vault", `"kind":"backend","type":"s3"`,
+		`{"config":{"accessKeyID":"id","accessKeySecret":"secret"}}`)
+
+	creds, err := Get(context.Background(), "vault", &Request{Kind: KindBackend, Type: "s3"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"accessKeyID": "id", "accessKeySecret": "secret"}, creds.Config)
+
+	_, err = Get(context.Background(), "vault", &Request{Kind: KindRuntime})
+	assert.ErrorContains(t, err, "unexpected request")
+	_, err = Get(context.Background(), "missing", &Request{Kind: KindRuntime})
+	assert.NotNil(t, err)
+
+	writeHelper(t, dir, HelperPrefix+"invalid", "", "credentials")
+	_, err = Get(context.Background(), "invalid", &Request{Kind: KindRuntime})
+	assert.NotNil(t, err)
+
+	// paths are never run, even if they are executables
+	path := writeHelper(t, dir, "helper", "", "{}")
+	for _, helper := range []string{path, "./helper", "../" + HelperPrefix + "vault"} {
+		_, err = Get(context.Background(), helper, &Request{Kind: KindRuntime})
+		assert.ErrorContains(t, err, "invalid credential helper")
+	}
+}
+
+func TestEnv(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	writeHelper(t, dir, HelperPrefix+"env", `"project":"p","stack":"dev"`,
+		`{"env":{"KUSION_TEST_CREDENTIAL":"token"}}`)
+	t.Setenv("KUSION_TEST_CREDENTIAL", "")
+
+	env, err := Env(context.Background(), "", "p", "dev")
+	assert.Nil(t, err)
+	assert.Nil(t, env)
+	env, err = Env(context.Background(), "env", "p", "dev")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"KUSION_TEST_CREDENTIAL": "token"}, env)
+	// the env of this process is never changed
+	assert.Equal(t, "", os.Getenv("KUSION_TEST_CREDENTIAL"))
+}
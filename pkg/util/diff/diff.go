@@ -75,6 +75,10 @@ func ToReport(oldData, newData interface{}) (*dyff.Report, error) {
 		return nil, err
 	}
 
+	return compare(from, to)
+}
+
+func compare(from, to ytbx.InputFile) (*dyff.Report, error) {
 	report, err := dyff.CompareInputFiles(from, to, dyff.IgnoreOrderChanges(true))
 	if err != nil {
 		return nil, err
@@ -0,0 +1,32 @@
+package diff
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// ContentHash returns the sha256 of the data in JSON. Keys of maps are sorted in JSON, so equal data always
+// have equal hashes regardless of the order of their keys.
+func ContentHash(data interface{}) (string, error) {
+	h := sha256.New()
+	if err := json.NewEncoder(h).Encode(data); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Identical reports whether the objects have the same content by their hashes, which is much cheaper than
+// comparing them field by field. Objects which can not be hashed are reported as not identical, so that
+// callers fall back to full comparisons.
+func Identical(oldData, newData interface{}) bool {
+	oldHash, err := ContentHash(oldData)
+	if err != nil {
+		return false
+	}
+	newHash, err := ContentHash(newData)
+	if err != nil {
+		return false
+	}
+	return oldHash == newHash
+}
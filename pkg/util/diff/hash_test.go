@@ -0,0 +1,23 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentical(t *testing.T) {
+	a := map[string]interface{}{"kind": "ConfigMap", "data": map[string]interface{}{"a": "1", "b": []interface{}{1, 2}}}
+	b := map[string]interface{}{"data": map[string]interface{}{"b": []interface{}{1, 2}, "a": "1"}, "kind": "ConfigMap"}
+	assert.True(t, Identical(a, b))
+
+	hash, err := ContentHash(a)
+	assert.Nil(t, err)
+	assert.Len(t, hash, 64)
+
+	b["data"].(map[string]interface{})["b"] = []interface{}{2, 1}
+	assert.False(t, Identical(a, b))
+
+	// objects which can not be hashed are never identical
+	assert.False(t, Identical(map[string]interface{}{"f": func() {}}, map[string]interface{}{"f": func() {}}))
+}
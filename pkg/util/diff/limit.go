@@ -0,0 +1,114 @@
+package diff
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"unicode/utf8"
+
+	"kusionstack.io/kusion/pkg/util/yaml"
+	"kusionstack.io/kusion/third_party/dyff"
+)
+
+// Limits bound the memory and output of diffs of large resources, e.g. huge ConfigMaps and CRDs
+type Limits struct {
+	// MaxStringLength is the max length of string values shown in diffs, longer values are truncated and
+	// followed by their lengths and digests, so that changes of the truncated parts are still reported
+	MaxStringLength int
+
+	// MaxResourceSize is the max size in bytes of a resource in YAML compared in detail, diffs of larger
+	// resources are summarized by their sizes and digests
+	MaxResourceSize int
+}
+
+// DefaultLimits are limits of diffs unless they are configured
+var DefaultLimits = Limits{MaxStringLength: 4096, MaxResourceSize: 1 << 20}
+
+// OrDefault returns the limits with unset ones replaced by default limits
+func (l Limits) OrDefault() Limits {
+	if l.MaxStringLength <= 0 {
+		l.MaxStringLength = DefaultLimits.MaxStringLength
+	}
+	if l.MaxResourceSize <= 0 {
+		l.MaxResourceSize = DefaultLimits.MaxResourceSize
+	}
+	return l
+}
+
+// ToLimitedHumanString compares the objects with string values truncated, and returns the human-readable
+// report, or a summary if either object is larger than the limit
+func ToLimitedHumanString(oldData, newData interface{}, limits Limits) (string, error) {
+	limits = limits.OrDefault()
+	oldYAML := yaml.MergeToOneYAML(Truncate(oldData, limits.MaxStringLength))
+	newYAML := yaml.MergeToOneYAML(Truncate(newData, limits.MaxStringLength))
+	if len(oldYAML) > limits.MaxResourceSize || len(newYAML) > limits.MaxResourceSize {
+		return summarize(oldYAML, newYAML, limits.MaxResourceSize), nil
+	}
+	// unchanged objects within the limit are reported without being compared field by field
+	if Identical(oldData, newData) {
+		return ToHumanString(NewHumanReport(&dyff.Report{}))
+	}
+
+	from, err := LoadFile(oldYAML, "Old item")
+	if err != nil {
+		return "", err
+	}
+	to, err := LoadFile(newYAML, "New item")
+	if err != nil {
+		return "", err
+	}
+	report, err := compare(from, to)
+	if err != nil {
+		return "", err
+	}
+	return ToHumanString(NewHumanReport(report))
+}
+
+// Truncate returns a copy of the data with string values longer than maxLength truncated, maps and slices
+// are copied while other values are shared
+func Truncate(data interface{}, maxLength int) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			result[key] = Truncate(value, maxLength)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, value := range v {
+			result[i] = Truncate(value, maxLength)
+		}
+		return result
+	case string:
+		return truncateString(v, maxLength)
+	default:
+		return data
+	}
+}
+
+func truncateString(s string, maxLength int) string {
+	if len(s) <= maxLength {
+		return s
+	}
+	// cut at the start of a rune, so that the prefix is valid UTF-8
+	end := maxLength
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return fmt.Sprintf("%s... (%d bytes truncated, sha256:%s)", s[:end], len(s)-end, digest(s))
+}
+
+func summarize(oldYAML, newYAML string, maxSize int) string {
+	if oldYAML == newYAML {
+		return fmt.Sprintf("diff omitted: the resource is %d bytes, over the limit of %d bytes, and unchanged\n",
+			len(newYAML), maxSize)
+	}
+	return fmt.Sprintf("diff omitted: the resource is over the limit of %d bytes\n"+
+		"  old: %d bytes, sha256:%s\n"+
+		"  new: %d bytes, sha256:%s\n", maxSize, len(oldYAML), digest(oldYAML), len(newYAML), digest(newYAML))
+}
+
+func digest(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return fmt.Sprintf("%x", sum[:8])
+}
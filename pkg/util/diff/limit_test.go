@@ -0,0 +1,50 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncate(t *testing.T) {
+	data := map[string]interface{}{
+		"short": "abc",
+		"long":  strings.Repeat("a", 10),
+		"list":  []interface{}{"世界世界", 1},
+	}
+	actual := Truncate(data, 4).(map[string]interface{})
+
+	assert.Equal(t, "abc", actual["short"])
+	assert.True(t, strings.HasPrefix(actual["long"].(string), "aaaa... (6 bytes truncated, sha256:"))
+	// the prefix is cut at the start of a rune
+	assert.True(t, strings.HasPrefix(actual["list"].([]interface{})[0].(string), "世... (9 bytes truncated"))
+	assert.Equal(t, 1, actual["list"].([]interface{})[1])
+	// the data is not modified
+	assert.Equal(t, strings.Repeat("a", 10), data["long"])
+}
+
+func TestToLimitedHumanString(t *testing.T) {
+	t.Run("changes in truncated strings", func(t *testing.T) {
+		from := map[string]interface{}{"data": strings.Repeat("a", 100) + "old"}
+		to := map[string]interface{}{"data": strings.Repeat("a", 100) + "new"}
+		actual, err := ToLimitedHumanString(from, to, Limits{MaxStringLength: 10})
+		assert.Nil(t, err)
+		assert.Contains(t, actual, "data")
+		assert.Contains(t, actual, "93 bytes truncated")
+		assert.NotContains(t, actual, strings.Repeat("a", 11))
+	})
+
+	t.Run("oversized resources", func(t *testing.T) {
+		from := map[string]interface{}{"data": strings.Repeat("a", 100)}
+		to := map[string]interface{}{"data": strings.Repeat("b", 100)}
+		actual, err := ToLimitedHumanString(from, to, Limits{MaxResourceSize: 50})
+		assert.Nil(t, err)
+		assert.True(t, strings.HasPrefix(actual, "diff omitted: the resource is over the limit of 50 bytes"))
+		assert.Contains(t, actual, "old: 107 bytes, sha256:")
+
+		actual, err = ToLimitedHumanString(from, from, Limits{MaxResourceSize: 50})
+		assert.Nil(t, err)
+		assert.Contains(t, actual, "and unchanged")
+	})
+}
@@ -0,0 +1,209 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gonvenience/term"
+	"github.com/pmezard/go-difflib/difflib"
+	"gomodules.xyz/jsonpatch/v2"
+
+	"kusionstack.io/kusion/pkg/util/pretty"
+	"kusionstack.io/kusion/pkg/util/yaml"
+)
+
+// Modes of rendering diffs
+const (
+	// ModeHuman lists changed fields with their old and new values
+	ModeHuman = "human"
+	// ModeSideBySide shows changed lines of old and new YAML in two columns, which suits wide terminals
+	ModeSideBySide = "side-by-side"
+	// ModeUnified shows changed lines of old and new YAML like diff -u
+	ModeUnified = "unified"
+	// ModeJSONPatch shows a JSON patch (RFC 6902) from old to new objects, which suits machine consumption
+	ModeJSONPatch = "json-patch"
+)
+
+// Modes are all modes of rendering diffs
+var Modes = []string{ModeHuman, ModeSideBySide, ModeUnified, ModeJSONPatch}
+
+// diffContext is the number of unchanged lines around changed lines in side-by-side and unified diffs
+const diffContext = 3
+
+// ValidateMode returns an error if the mode of rendering diffs is unknown
+func ValidateMode(mode string) error {
+	for _, m := range Modes {
+		if m == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown diff mode %s, must be one of %s", mode, strings.Join(Modes, ", "))
+}
+
+// ToLimitedString compares the objects like ToLimitedHumanString, and renders the diff in the mode, which
+// defaults to ModeHuman. Diffs of unchanged objects are empty in other modes.
+func ToLimitedString(oldData, newData interface{}, limits Limits, mode string) (string, error) {
+	if mode == "" || mode == ModeHuman {
+		return ToLimitedHumanString(oldData, newData, limits)
+	}
+	if err := ValidateMode(mode); err != nil {
+		return "", err
+	}
+	if Identical(oldData, newData) {
+		return "", nil
+	}
+
+	limits = limits.OrDefault()
+	oldData, newData = Truncate(oldData, limits.MaxStringLength), Truncate(newData, limits.MaxStringLength)
+	oldYAML, newYAML := yaml.MergeToOneYAML(oldData), yaml.MergeToOneYAML(newData)
+	if len(oldYAML) > limits.MaxResourceSize || len(newYAML) > limits.MaxResourceSize {
+		return summarize(oldYAML, newYAML, limits.MaxResourceSize), nil
+	}
+
+	switch mode {
+	case ModeSideBySide:
+		return sideBySide(oldYAML, newYAML, term.GetTerminalWidth()), nil
+	case ModeUnified:
+		return unified(oldYAML, newYAML)
+	default:
+		return jsonPatch(oldData, newData)
+	}
+}
+
+// unified renders lines of the old and new YAML like diff -u
+func unified(oldYAML, newYAML string) (string, error) {
+	text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        yamlLines(oldYAML),
+		B:        yamlLines(newYAML),
+		FromFile: "old",
+		ToFile:   "new",
+		Context:  diffContext,
+	})
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			b.WriteString(line)
+		case strings.HasPrefix(line, "-"):
+			b.WriteString(pretty.Red("%s", line))
+		case strings.HasPrefix(line, "+"):
+			b.WriteString(pretty.Green("%s", line))
+		case strings.HasPrefix(line, "@@"):
+			b.WriteString(pretty.Cyan("%s", line))
+		default:
+			b.WriteString(line)
+		}
+	}
+	return b.String(), nil
+}
+
+// sideBySide renders changed lines of the old and new YAML in two columns fitting the width like diff -y,
+// where changed lines are marked by |, deleted lines by < and inserted lines by >
+func sideBySide(oldYAML, newYAML string, width int) string {
+	a, b := strings.Split(strings.TrimSuffix(oldYAML, "\n"), "\n"), strings.Split(strings.TrimSuffix(newYAML, "\n"), "\n")
+	column := (width - 3) / 2
+	if column < 20 {
+		column = 20
+	}
+
+	var out strings.Builder
+	row := func(left, marker, right string, color func(string, ...interface{}) string) {
+		line := fmt.Sprintf("%s %s %s", pad(left, column), marker, fit(right, column))
+		if color != nil {
+			line = color("%s", line)
+		}
+		out.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+
+	matcher := difflib.NewMatcher(a, b)
+	for i, group := range matcher.GetGroupedOpCodes(diffContext) {
+		if i > 0 {
+			out.WriteString(pretty.Cyan("%s", strings.Repeat("-", column*2+3)) + "\n")
+		}
+		for _, op := range group {
+			oldLines, newLines := a[op.I1:op.I2], b[op.J1:op.J2]
+			switch op.Tag {
+			case 'e':
+				for j := range oldLines {
+					row(oldLines[j], " ", newLines[j], nil)
+				}
+			case 'd':
+				for _, line := range oldLines {
+					row(line, "<", "", pretty.Red)
+				}
+			case 'i':
+				for _, line := range newLines {
+					row("", ">", line, pretty.Green)
+				}
+			case 'r':
+				for j := 0; j < len(oldLines) || j < len(newLines); j++ {
+					switch {
+					case j >= len(newLines):
+						row(oldLines[j], "<", "", pretty.Red)
+					case j >= len(oldLines):
+						row("", ">", newLines[j], pretty.Green)
+					default:
+						row(oldLines[j], "|", newLines[j], pretty.Yellow)
+					}
+				}
+			}
+		}
+	}
+	return out.String()
+}
+
+// jsonPatch renders the JSON patch from the old object to the new one
+func jsonPatch(oldData, newData interface{}) (string, error) {
+	oldJSON, err := json.Marshal(oldData)
+	if err != nil {
+		return "", err
+	}
+	newJSON, err := json.Marshal(newData)
+	if err != nil {
+		return "", err
+	}
+	patch, err := jsonpatch.CreatePatch(oldJSON, newJSON)
+	if err != nil {
+		return "", err
+	}
+	if len(patch) == 0 {
+		return "", nil
+	}
+	// operations are created by iterating maps, sort them to render stable patches
+	sort.SliceStable(patch, func(i, j int) bool {
+		return patch[i].Path < patch[j].Path
+	})
+	data, err := json.MarshalIndent(patch, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+func yamlLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return difflib.SplitLines(strings.TrimSuffix(text, "\n"))
+}
+
+// fit cuts the line to the width, ending with ~ if it is cut
+func fit(line string, width int) string {
+	if utf8.RuneCountInString(line) <= width {
+		return line
+	}
+	runes := []rune(line)
+	return string(runes[:width-1]) + "~"
+}
+
+// pad fits the line to the width and pads it with spaces
+func pad(line string, width int) string {
+	line = fit(line, width)
+	return line + strings.Repeat(" ", width-utf8.RuneCountInString(line))
+}
@@ -0,0 +1,66 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pterm/pterm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToLimitedString(t *testing.T) {
+	from := map[string]interface{}{
+		"kind": "Deployment",
+		"spec": map[string]interface{}{"replicas": 1, "paused": false},
+	}
+	to := map[string]interface{}{
+		"kind": "Deployment",
+		"spec": map[string]interface{}{"replicas": 3, "minReadySeconds": 10},
+	}
+
+	t.Run("unified", func(t *testing.T) {
+		actual, err := ToLimitedString(from, to, Limits{}, ModeUnified)
+		assert.Nil(t, err)
+		actual = pterm.RemoveColorFromString(actual)
+		assert.Contains(t, actual, "--- old\n+++ new\n")
+		assert.Contains(t, actual, "-  paused: false\n")
+		assert.Contains(t, actual, "-  replicas: 1\n")
+		assert.Contains(t, actual, "+  replicas: 3\n")
+		assert.Contains(t, actual, "+  minReadySeconds: 10\n")
+	})
+
+	t.Run("json-patch", func(t *testing.T) {
+		actual, err := ToLimitedString(from, to, Limits{}, ModeJSONPatch)
+		assert.Nil(t, err)
+		assert.JSONEq(t, `[
+			{"op": "add", "path": "/spec/minReadySeconds", "value": 10},
+			{"op": "remove", "path": "/spec/paused"},
+			{"op": "replace", "path": "/spec/replicas", "value": 3}
+		]`, actual)
+	})
+
+	t.Run("identical", func(t *testing.T) {
+		for _, mode := range []string{ModeSideBySide, ModeUnified, ModeJSONPatch} {
+			actual, err := ToLimitedString(from, from, Limits{}, mode)
+			assert.Nil(t, err)
+			assert.Empty(t, actual, mode)
+		}
+	})
+
+	t.Run("unknown mode", func(t *testing.T) {
+		_, err := ToLimitedString(from, to, Limits{}, "table")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestSideBySide(t *testing.T) {
+	oldYAML := "kind: Deployment\nspec:\n  paused: false\n  replicas: 1\n"
+	newYAML := "kind: Deployment\nspec:\n  replicas: 3\n  template: " + strings.Repeat("x", 40) + "\n"
+	actual := pterm.RemoveColorFromString(sideBySide(oldYAML, newYAML, 63))
+	// columns are 30 characters wide, and changed lines are marked by |
+	assert.Equal(t, ""+
+		"kind: Deployment                 kind: Deployment\n"+
+		"spec:                            spec:\n"+
+		"  paused: false                |   replicas: 3\n"+
+		"  replicas: 1                  |   template: xxxxxxxxxxxxxxxxx~\n", actual)
+}
@@ -0,0 +1,93 @@
+// Package junit writes results of operations as JUnit XML reports, which CI systems such as Jenkins, GitLab CI
+// and GitHub Actions render natively in their test report UIs.
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"time"
+)
+
+// TestSuites is the root element of a JUnit XML report
+type TestSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Name     string      `xml:"name,attr,omitempty"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr,omitempty"`
+	Suites   []TestSuite `xml:"testsuite"`
+}
+
+// TestSuite is a suite of test cases, e.g. an operation on a stack
+type TestSuite struct {
+	Name      string     `xml:"name,attr"`
+	Tests     int        `xml:"tests,attr"`
+	Failures  int        `xml:"failures,attr"`
+	Skipped   int        `xml:"skipped,attr"`
+	Time      string     `xml:"time,attr,omitempty"`
+	Timestamp string     `xml:"timestamp,attr,omitempty"`
+	Cases     []TestCase `xml:"testcase"`
+}
+
+// TestCase is a test case, e.g. the change of a resource. It fails if Failure is set, and is skipped if
+// Skipped is set.
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Time      string   `xml:"time,attr,omitempty"`
+	Failure   *Failure `xml:"failure,omitempty"`
+	Skipped   *Skipped `xml:"skipped,omitempty"`
+	SystemOut string   `xml:"system-out,omitempty"`
+}
+
+// Failure is the failure of a test case, whose message is shown as the summary and contents as the detail
+type Failure struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr,omitempty"`
+	Contents string `xml:",chardata"`
+}
+
+// Skipped marks a skipped test case
+type Skipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// Seconds formats the duration in seconds, which is the unit of time attributes
+func Seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// Add adds the test case to the suite and counts it
+func (s *TestSuite) Add(c TestCase) {
+	s.Cases = append(s.Cases, c)
+	s.Tests++
+	switch {
+	case c.Failure != nil:
+		s.Failures++
+	case c.Skipped != nil:
+		s.Skipped++
+	}
+}
+
+// Add adds the suite to the report and counts its test cases
+func (s *TestSuites) Add(suite TestSuite) {
+	s.Suites = append(s.Suites, suite)
+	s.Tests += suite.Tests
+	s.Failures += suite.Failures
+	s.Skipped += suite.Skipped
+}
+
+// Write writes the report to the file
+func Write(report *TestSuites, path string) error {
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("write JUnit report failed: %w", err)
+	}
+	data = append([]byte(xml.Header), append(data, '\n')...)
+	if err = os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write JUnit report failed: %w", err)
+	}
+	return nil
+}
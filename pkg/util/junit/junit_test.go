@@ -0,0 +1,41 @@
+package junit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	suite := TestSuite{Name: "kusion apply helloworld/dev", Time: Seconds(1500 * time.Millisecond)}
+	suite.Add(TestCase{Name: "a", ClassName: "helloworld/dev", SystemOut: "action: Create"})
+	suite.Add(TestCase{Name: "b", ClassName: "helloworld/dev", Failure: &Failure{Message: "failed", Contents: "<diff>"}})
+	suite.Add(TestCase{Name: "c", ClassName: "helloworld/dev", Skipped: &Skipped{Message: "skipped"}})
+	report := &TestSuites{Name: "kusion"}
+	report.Add(suite)
+
+	path := filepath.Join(t.TempDir(), "report.xml")
+	assert.NoError(t, Write(report, path))
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="kusion" tests="3" failures="1" skipped="1">
+  <testsuite name="kusion apply helloworld/dev" tests="3" failures="1" skipped="1" time="1.500">
+    <testcase name="a" classname="helloworld/dev">
+      <system-out>action: Create</system-out>
+    </testcase>
+    <testcase name="b" classname="helloworld/dev">
+      <failure message="failed">&lt;diff&gt;</failure>
+    </testcase>
+    <testcase name="c" classname="helloworld/dev">
+      <skipped message="skipped"></skipped>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, string(data))
+
+	assert.Error(t, Write(report, filepath.Join(t.TempDir(), "missing", "report.xml")))
+}
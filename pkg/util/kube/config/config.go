@@ -1,9 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
 )
 
@@ -11,6 +14,12 @@ const (
 	RecommendedConfigPathEnvVar   = "KUBECONFIG"
 	RecommendedHomeDir            = ".kube"
 	RecommendedKubeConfigFileName = "config"
+
+	// KubeContextEnvVar pins the context of the kubeconfig, e.g. by the runtime config of the stack
+	KubeContextEnvVar = "KUSION_KUBE_CONTEXT"
+
+	// inClusterHostEnvVar is set in all pods
+	inClusterHostEnvVar = "KUBERNETES_SERVICE_HOST"
 )
 
 var (
@@ -19,11 +28,55 @@ var (
 )
 
 // 1. If $KUBECONFIG environment variable is set, then it is used it.
-// 2. Otherwise, ${HOME}/.kube/config is used.
+// 2. If running in a pod without ${HOME}/.kube/config, e.g. the operator, empty is returned so that
+// the in-cluster config is used.
+// 3. Otherwise, ${HOME}/.kube/config is used.
 func GetKubeConfig() string {
-	if kubeConfigFile := os.Getenv(RecommendedConfigPathEnvVar); kubeConfigFile != "" {
+	return getKubeConfig(os.Getenv)
+}
+
+func getKubeConfig(getenv func(string) string) string {
+	if kubeConfigFile := getenv(RecommendedConfigPathEnvVar); kubeConfigFile != "" {
 		return kubeConfigFile
-	} else {
-		return RecommendedKubeConfigFile
 	}
+	if os.Getenv(inClusterHostEnvVar) != "" {
+		if _, err := os.Stat(RecommendedKubeConfigFile); os.IsNotExist(err) {
+			return ""
+		}
+	}
+	return RecommendedKubeConfigFile
+}
+
+// GetKubeContext returns the context pinned by $KUSION_KUBE_CONTEXT, or empty if the current context of the
+// kubeconfig is used
+func GetKubeContext() string {
+	return os.Getenv(KubeContextEnvVar)
+}
+
+// BuildConfig builds the config of the kubeconfig returned by GetKubeConfig in the context returned by
+// GetKubeContext. It fails if the pinned context is not in the kubeconfig instead of falling back to the
+// current context, which may select another cluster.
+func BuildConfig() (*rest.Config, error) {
+	return BuildConfigWithEnv(os.Getenv)
+}
+
+// BuildConfigWithEnv is BuildConfig with $KUBECONFIG and $KUSION_KUBE_CONTEXT looked up by getenv, e.g. in the
+// runtime env of a stack instead of this process
+func BuildConfigWithEnv(getenv func(string) string) (*rest.Config, error) {
+	kubeConfig := getKubeConfig(getenv)
+	kubeContext := getenv(KubeContextEnvVar)
+	if kubeContext == "" {
+		return clientcmd.BuildConfigFromFlags("", kubeConfig)
+	}
+	if kubeConfig == "" {
+		return nil, fmt.Errorf("the kube context %s is pinned but no kubeconfig is found", kubeContext)
+	}
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeConfig},
+		&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load the kube context %s from %s failed: %w", kubeContext, kubeConfig, err)
+	}
+	return cfg, nil
 }
@@ -5,6 +5,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"bou.ke/monkey"
@@ -25,3 +26,78 @@ func TestGetKubeConfig(t *testing.T) {
 	mockGetenv("test")
 	assert.Equal(t, "test", GetKubeConfig())
 }
+
+func TestGetKubeConfig_InCluster(t *testing.T) {
+	defer monkey.UnpatchAll()
+	monkey.Patch(os.Getenv, func(key string) string {
+		if key == inClusterHostEnvVar {
+			return "10.0.0.1"
+		}
+		return ""
+	})
+	monkey.Patch(os.Stat, func(name string) (os.FileInfo, error) {
+		return nil, os.ErrNotExist
+	})
+	assert.Equal(t, "", GetKubeConfig())
+}
+
+func TestBuildConfig(t *testing.T) {
+	kubeConfig := filepath.Join(t.TempDir(), "config")
+	assert.Nil(t, os.WriteFile(kubeConfig, []byte(`apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com
+- name: prod
+  cluster:
+    server: https://prod.example.com
+contexts:
+- name: dev
+  context:
+    cluster: dev
+- name: prod
+  context:
+    cluster: prod
+`), 0o600))
+	t.Setenv(RecommendedConfigPathEnvVar, kubeConfig)
+
+	t.Setenv(KubeContextEnvVar, "")
+	cfg, err := BuildConfig()
+	assert.Nil(t, err)
+	assert.Equal(t, "https://dev.example.com", cfg.Host)
+
+	t.Setenv(KubeContextEnvVar, "prod")
+	cfg, err = BuildConfig()
+	assert.Nil(t, err)
+	assert.Equal(t, "https://prod.example.com", cfg.Host)
+
+	// missing contexts never fall back to the current context
+	t.Setenv(KubeContextEnvVar, "staging")
+	_, err = BuildConfig()
+	assert.NotNil(t, err)
+}
+
+func TestBuildConfigWithEnv(t *testing.T) {
+	kubeConfig := filepath.Join(t.TempDir(), "config")
+	assert.Nil(t, os.WriteFile(kubeConfig, []byte(`apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com
+contexts:
+- name: dev
+  context:
+    cluster: dev
+`), 0o600))
+	t.Setenv(RecommendedConfigPathEnvVar, filepath.Join(t.TempDir(), "missing"))
+	t.Setenv(KubeContextEnvVar, "prod")
+
+	env := map[string]string{RecommendedConfigPathEnvVar: kubeConfig, KubeContextEnvVar: "dev"}
+	cfg, err := BuildConfigWithEnv(func(key string) string { return env[key] })
+	assert.Nil(t, err)
+	assert.Equal(t, "https://dev.example.com", cfg.Host)
+}
@@ -0,0 +1,58 @@
+package oci
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// dockerConfig is the config of the docker CLI with credentials of registries logged in
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth     string `json:"auth"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"auths"`
+}
+
+// DockerCredentials returns the username and password of the registry logged in by docker login, which are read
+// from config.json in DOCKER_CONFIG or ~/.docker. Empty ones are returned if the registry is not logged in.
+// Credential helpers of docker are not supported.
+func DockerCredentials(registry string) (string, string) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", ""
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return "", ""
+	}
+	var config dockerConfig
+	if err = json.Unmarshal(data, &config); err != nil {
+		return "", ""
+	}
+
+	for key, auth := range config.Auths {
+		// keys may be URLs, e.g. https://index.docker.io/v1/
+		host := strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://")
+		if host, _, _ = strings.Cut(host, "/"); host != registry {
+			continue
+		}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return "", ""
+			}
+			username, password, _ := strings.Cut(string(decoded), ":")
+			return username, password
+		}
+		return auth.Username, auth.Password
+	}
+	return "", ""
+}
@@ -0,0 +1,332 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Media types of Flux artifacts, which are read by the OCIRepository of the Flux source controller
+const (
+	FluxConfigMediaType  = "application/vnd.cncf.flux.config.v1+json"
+	FluxContentMediaType = "application/vnd.cncf.flux.content.v1.tar+gzip"
+	ManifestMediaType    = "application/vnd.oci.image.manifest.v1+json"
+)
+
+// Annotations of Flux artifacts
+const (
+	AnnotationCreated  = "org.opencontainers.image.created"
+	AnnotationSource   = "org.opencontainers.image.source"
+	AnnotationRevision = "org.opencontainers.image.revision"
+)
+
+// Scheme is the scheme of OCI references, e.g. oci://ghcr.io/org/stack:v1
+const Scheme = "oci://"
+
+// Reference is a reference to a tagged artifact in a registry
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+}
+
+// ParseReference parses a reference like oci://ghcr.io/org/stack:v1, whose tag is latest if not specified
+func ParseReference(ref string) (*Reference, error) {
+	if !strings.HasPrefix(ref, Scheme) {
+		return nil, fmt.Errorf("invalid reference %s, must start with %s", ref, Scheme)
+	}
+	rest := strings.TrimPrefix(ref, Scheme)
+	slash := strings.Index(rest, "/")
+	if slash <= 0 || slash == len(rest)-1 {
+		return nil, fmt.Errorf("invalid reference %s, must be like %sregistry/repository:tag", ref, Scheme)
+	}
+	r := &Reference{Registry: rest[:slash], Repository: rest[slash+1:], Tag: "latest"}
+	if colon := strings.LastIndex(r.Repository, ":"); colon > 0 {
+		r.Tag = r.Repository[colon+1:]
+		r.Repository = r.Repository[:colon]
+	}
+	if r.Tag == "" || strings.ContainsAny(r.Repository, "@:") || r.Repository != strings.ToLower(r.Repository) {
+		return nil, fmt.Errorf("invalid reference %s, repositories must be lowercase and tags must not be empty", ref)
+	}
+	return r, nil
+}
+
+func (r *Reference) String() string {
+	return fmt.Sprintf("%s/%s:%s", r.Registry, r.Repository, r.Tag)
+}
+
+// descriptor describes a blob in an OCI manifest
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int               `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	Config        descriptor        `json:"config"`
+	Layers        []descriptor      `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// TarGz archives the files keyed by their paths in a gzipped tarball, which is reproducible since the files are
+// sorted and their modification times are zero
+func TarGz(files map[string][]byte) ([]byte, error) {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for _, p := range paths {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     p,
+			Mode:     0o644,
+			Size:     int64(len(files[p])),
+			ModTime:  time.Unix(0, 0),
+			Typeflag: tar.TypeReg,
+		}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(files[p]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Client pushes artifacts to registries by the OCI distribution API
+type Client struct {
+	HTTPClient *http.Client
+	// PlainHTTP connects to registries by HTTP instead of HTTPS, e.g. local registries
+	PlainHTTP bool
+	// Credentials returns the username and password of the registry, or empty ones for anonymous access
+	Credentials func(registry string) (string, string)
+
+	// token is the bearer token of the last authorized request
+	token string
+}
+
+// NewClient returns a client with credentials of registries in the docker config
+func NewClient(plainHTTP bool) *Client {
+	return &Client{HTTPClient: http.DefaultClient, PlainHTTP: plainHTTP, Credentials: DockerCredentials}
+}
+
+// PushFluxArtifact pushes the gzipped tarball as a Flux artifact with the annotations, and returns the digest of
+// its manifest
+func (c *Client) PushFluxArtifact(ctx context.Context, ref *Reference, content []byte, annotations map[string]string) (string, error) {
+	config := []byte("{}")
+	m := manifest{
+		SchemaVersion: 2,
+		MediaType:     ManifestMediaType,
+		Config:        descriptor{MediaType: FluxConfigMediaType, Digest: digestOf(config), Size: len(config)},
+		Layers:        []descriptor{{MediaType: FluxContentMediaType, Digest: digestOf(content), Size: len(content)}},
+		Annotations:   annotations,
+	}
+	for _, blob := range [][]byte{config, content} {
+		if err := c.pushBlob(ctx, ref, blob); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.do(ctx, ref, http.MethodPut, c.url(ref, "manifests/"+ref.Tag), data, ManifestMediaType)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", responseError("push manifest", resp)
+	}
+	return digestOf(data), nil
+}
+
+// pushBlob uploads the blob unless it exists in the repository
+func (c *Client) pushBlob(ctx context.Context, ref *Reference, blob []byte) error {
+	digest := digestOf(blob)
+	resp, err := c.do(ctx, ref, http.MethodHead, c.url(ref, "blobs/"+digest), nil, "")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	resp, err = c.do(ctx, ref, http.MethodPost, c.url(ref, "blobs/uploads/"), nil, "")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return responseError("start uploading blob "+digest, resp)
+	}
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return fmt.Errorf("invalid upload location: %w", err)
+	}
+	query := location.Query()
+	query.Set("digest", digest)
+	location.RawQuery = query.Encode()
+
+	resp, err = c.do(ctx, ref, http.MethodPut, location.String(), blob, "application/octet-stream")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return responseError("upload blob "+digest, resp)
+	}
+	return nil
+}
+
+func (c *Client) url(ref *Reference, path string) string {
+	scheme := "https"
+	if c.PlainHTTP {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/%s", scheme, ref.Registry, ref.Repository, path)
+}
+
+// do sends the request, and retries it once with the authorization the registry challenges
+func (c *Client) do(ctx context.Context, ref *Reference, method, u string, body []byte, contentType string) (*http.Response, error) {
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", c.token)
+		}
+		return c.HTTPClient.Do(req)
+	}
+
+	resp, err := send()
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	resp.Body.Close()
+	if c.token, err = c.authorize(ctx, ref, resp.Header.Get("WWW-Authenticate")); err != nil {
+		return nil, err
+	}
+	return send()
+}
+
+// authorize returns the Authorization header answering the challenge of the registry
+func (c *Client) authorize(ctx context.Context, ref *Reference, challenge string) (string, error) {
+	username, password := "", ""
+	if c.Credentials != nil {
+		username, password = c.Credentials(ref.Registry)
+	}
+	scheme, params := parseChallenge(challenge)
+	switch scheme {
+	case "basic":
+		if username == "" {
+			return "", fmt.Errorf("no credentials of the registry %s", ref.Registry)
+		}
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(username, password)
+		return req.Header.Get("Authorization"), nil
+	case "bearer":
+		realm, err := url.Parse(params["realm"])
+		if err != nil || params["realm"] == "" {
+			return "", fmt.Errorf("invalid realm in the challenge %q", challenge)
+		}
+		query := realm.Query()
+		if params["service"] != "" {
+			query.Set("service", params["service"])
+		}
+		query.Set("scope", fmt.Sprintf("repository:%s:pull,push", ref.Repository))
+		realm.RawQuery = query.Encode()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", responseError("get token of the registry "+ref.Registry, resp)
+		}
+		var token struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+			return "", err
+		}
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+		return "Bearer " + token.Token, nil
+	default:
+		return "", fmt.Errorf("unsupported challenge %q of the registry %s", challenge, ref.Registry)
+	}
+}
+
+// parseChallenge parses the WWW-Authenticate header, e.g. Bearer realm="https://auth.io/token",service="registry.io"
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := map[string]string{}
+	for rest != "" {
+		var pair string
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(key), ","))
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				break
+			}
+			pair, rest = value[1:end+1], value[end+2:]
+		} else {
+			pair, rest, _ = strings.Cut(value, ",")
+		}
+		params[strings.ToLower(key)] = pair
+		rest = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+	}
+	return strings.ToLower(scheme), params
+}
+
+func digestOf(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+func responseError(action string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("%s failed with status %s: %s", action, resp.Status, strings.TrimSpace(string(body)))
+}
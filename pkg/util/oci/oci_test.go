@@ -0,0 +1,181 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReference(t *testing.T) {
+	ref, err := ParseReference("oci://ghcr.io/org/app/dev:v1.0.0")
+	assert.Nil(t, err)
+	assert.Equal(t, &Reference{Registry: "ghcr.io", Repository: "org/app/dev", Tag: "v1.0.0"}, ref)
+
+	ref, err = ParseReference("oci://localhost:5000/app")
+	assert.Nil(t, err)
+	assert.Equal(t, &Reference{Registry: "localhost:5000", Repository: "app", Tag: "latest"}, ref)
+	assert.Equal(t, "localhost:5000/app:latest", ref.String())
+
+	for _, invalid := range []string{"ghcr.io/org/app:v1", "oci://ghcr.io", "oci://ghcr.io/", "oci://ghcr.io/Org/app", "oci://ghcr.io/app:"} {
+		_, err = ParseReference(invalid)
+		assert.NotNil(t, err, invalid)
+	}
+}
+
+func TestTarGz(t *testing.T) {
+	files := map[string][]byte{"b.yaml": []byte("b"), "a.yaml": []byte("a")}
+	data, err := TarGz(files)
+	assert.Nil(t, err)
+	again, err := TarGz(files)
+	assert.Nil(t, err)
+	assert.Equal(t, data, again)
+
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	assert.Nil(t, err)
+	tr := tar.NewReader(gr)
+	var names []string
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		names = append(names, h.Name)
+	}
+	assert.Equal(t, []string{"a.yaml", "b.yaml"}, names)
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.io/token",service="registry.io",scope="repository:app:pull"`)
+	assert.Equal(t, "bearer", scheme)
+	assert.Equal(t, map[string]string{
+		"realm":   "https://auth.io/token",
+		"service": "registry.io",
+		"scope":   "repository:app:pull",
+	}, params)
+
+	scheme, params = parseChallenge(`Basic realm=registry`)
+	assert.Equal(t, "basic", scheme)
+	assert.Equal(t, "registry", params["realm"])
+}
+
+// fakeRegistry is a registry which requires bearer tokens
+type fakeRegistry struct {
+	lock      sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if r.URL.Path == "/token" {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "secret"})
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="http://`+r.Host+`/token",service="fake"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case r.Method == http.MethodHead && strings.HasPrefix(r.URL.Path, "/v2/org/app/blobs/"):
+		if _, ok := f.blobs[strings.TrimPrefix(r.URL.Path, "/v2/org/app/blobs/")]; ok {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodPost && r.URL.Path == "/v2/org/app/blobs/uploads/":
+		w.Header().Set("Location", "/v2/org/app/blobs/uploads/1?state=a")
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodPut && r.URL.Path == "/v2/org/app/blobs/uploads/1":
+		if r.URL.Query().Get("state") != "a" || digestOf(body) != r.URL.Query().Get("digest") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.blobs[r.URL.Query().Get("digest")] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v2/org/app/manifests/"):
+		f.manifests[strings.TrimPrefix(r.URL.Path, "/v2/org/app/manifests/")] = body
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestClient_PushFluxArtifact(t *testing.T) {
+	registry := &fakeRegistry{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	ref, err := ParseReference(Scheme + strings.TrimPrefix(server.URL, "http://") + "/org/app:v1")
+	assert.Nil(t, err)
+	content, err := TarGz(map[string][]byte{"dev.yaml": []byte("kind: Namespace\n")})
+	assert.Nil(t, err)
+
+	client := &Client{
+		HTTPClient:  server.Client(),
+		PlainHTTP:   true,
+		Credentials: func(string) (string, string) { return "user", "pass" },
+	}
+	digest, err := client.PushFluxArtifact(context.TODO(), ref, content, map[string]string{AnnotationRevision: "main@sha1:abc"})
+	assert.Nil(t, err)
+	assert.Equal(t, content, registry.blobs[digestOf(content)])
+	assert.Equal(t, digest, digestOf(registry.manifests["v1"]))
+
+	var m manifest
+	assert.Nil(t, json.Unmarshal(registry.manifests["v1"], &m))
+	assert.Equal(t, FluxConfigMediaType, m.Config.MediaType)
+	assert.Equal(t, FluxContentMediaType, m.Layers[0].MediaType)
+	assert.Equal(t, "main@sha1:abc", m.Annotations[AnnotationRevision])
+
+	// existing blobs are not uploaded again
+	delete(registry.blobs, digestOf(content))
+	registry.blobs[digestOf(content)] = []byte("kept")
+	_, err = client.PushFluxArtifact(context.TODO(), ref, content, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("kept"), registry.blobs[digestOf(content)])
+
+	// pushing without credentials is denied
+	client = &Client{HTTPClient: server.Client(), PlainHTTP: true}
+	_, err = client.PushFluxArtifact(context.TODO(), ref, content, nil)
+	assert.ErrorContains(t, err, "401")
+}
+
+func TestDockerCredentials(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{
+  "auths": {
+    "ghcr.io": {"auth": "dXNlcjpwYXNz"},
+    "https://index.docker.io/v1/": {"username": "hub", "password": "secret"}
+  }
+}`), 0o600))
+
+	username, password := DockerCredentials("ghcr.io")
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "pass", password)
+	username, password = DockerCredentials("index.docker.io")
+	assert.Equal(t, "hub", username)
+	assert.Equal(t, "secret", password)
+	username, _ = DockerCredentials("quay.io")
+	assert.Empty(t, username)
+}
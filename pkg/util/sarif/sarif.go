@@ -0,0 +1,189 @@
+// Package sarif writes findings of policy checks and validations as SARIF 2.1.0 logs, which code scanning of
+// GitHub and GitLab surfaces inline on pull requests.
+//
+// Resources have no positions in their source files, so findings are located at the entry file of the stack
+// and carry IDs of resources as logical locations.
+package sarif
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// Version is the version of SARIF of the logs
+	Version = "2.1.0"
+	// Schema is the JSON schema of SARIF 2.1.0
+	Schema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// toolName and toolURI identify kusion as the tool of the findings
+	toolName = "kusion"
+	toolURI  = "https://kusionstack.io"
+)
+
+// Levels of results
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelNote    = "note"
+)
+
+// Log is a SARIF log
+type Log struct {
+	Version string `json:"version"`
+	Schema  string `json:"$schema"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run is a run of kusion
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+type Driver struct {
+	Name           string `json:"name"`
+	Version        string `json:"version,omitempty"`
+	InformationURI string `json:"informationUri,omitempty"`
+	Rules          []Rule `json:"rules,omitempty"`
+}
+
+// Rule describes the rule of results, e.g. a policy rule
+type Rule struct {
+	ID               string   `json:"id"`
+	ShortDescription *Message `json:"shortDescription,omitempty"`
+}
+
+// Result is a finding
+type Result struct {
+	RuleID    string     `json:"ruleId"`
+	Level     string     `json:"level"`
+	Message   Message    `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+}
+
+type Message struct {
+	Text string `json:"text"`
+}
+
+type Location struct {
+	PhysicalLocation *PhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []LogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+}
+
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// LogicalLocation is the resource of a finding, whose name is the ID of the resource
+type LogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind,omitempty"`
+}
+
+// NewResult returns a result of the resource located at the file, either of which can be empty
+func NewResult(ruleID, level, message, file, resourceID string) Result {
+	r := Result{RuleID: ruleID, Level: level, Message: Message{Text: message}}
+	if file == "" && resourceID == "" {
+		return r
+	}
+	l := Location{}
+	if file != "" {
+		l.PhysicalLocation = &PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: ArtifactURI(file)}}
+	}
+	if resourceID != "" {
+		l.LogicalLocations = []LogicalLocation{{Name: resourceID, Kind: "resource"}}
+	}
+	r.Locations = []Location{l}
+	return r
+}
+
+// NewLog returns a log of the results of a run of kusion of the version, whose rules are collected from results
+func NewLog(version string, results []Result) *Log {
+	ids := map[string]bool{}
+	var rules []Rule
+	for _, r := range results {
+		if !ids[r.RuleID] {
+			ids[r.RuleID] = true
+			rules = append(rules, Rule{ID: r.RuleID})
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	if results == nil {
+		// an empty list of results tells code scanning that previous findings are fixed
+		results = []Result{}
+	}
+	return &Log{
+		Version: Version,
+		Schema:  Schema,
+		Runs: []Run{{
+			Tool:    Tool{Driver: Driver{Name: toolName, Version: version, InformationURI: toolURI, Rules: rules}},
+			Results: results,
+		}},
+	}
+}
+
+// Write writes the log to the file
+func Write(log *Log, path string) error {
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return fmt.Errorf("write SARIF log failed: %w", err)
+	}
+	if err = os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write SARIF log failed: %w", err)
+	}
+	return nil
+}
+
+// EntryFile returns the file that findings of the stack are located at, which is the first file compiled if
+// specified, otherwise main.k of the stack if it exists, otherwise stack.yaml
+func EntryFile(stackPath string, filenames []string) string {
+	if len(filenames) > 0 {
+		if filepath.IsAbs(filenames[0]) {
+			return filenames[0]
+		}
+		return filepath.Join(stackPath, filenames[0])
+	}
+	if main := filepath.Join(stackPath, "main.k"); fileExists(main) {
+		return main
+	}
+	return filepath.Join(stackPath, "stack.yaml")
+}
+
+// ArtifactURI returns the URI of the file relative to the root of its git repository, which code scanning
+// matches against files of pull requests. The path is returned as is if it is not in a git repository.
+func ArtifactURI(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		// .git is a file in worktrees and submodules
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			if rel, err := filepath.Rel(dir, abs); err == nil {
+				return filepath.ToSlash(rel)
+			}
+			break
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,54 @@
+package sarif
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	results := []Result{
+		NewResult("limits", LevelError, "resource limits are required", "", "v1:Pod:default:foo"),
+		NewResult("policy", LevelWarning, "too many resources", "", ""),
+		NewResult("limits", LevelError, "resource limits are required", "", "v1:Pod:default:bar"),
+	}
+	path := filepath.Join(t.TempDir(), "kusion.sarif")
+	require.NoError(t, Write(NewLog("v0.8.0", results), path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	log := &Log{}
+	require.NoError(t, json.Unmarshal(data, log))
+	assert.Equal(t, Version, log.Version)
+	run := log.Runs[0]
+	assert.Equal(t, "kusion", run.Tool.Driver.Name)
+	assert.Equal(t, []Rule{{ID: "limits"}, {ID: "policy"}}, run.Tool.Driver.Rules)
+	assert.Equal(t, results, run.Results)
+	assert.Equal(t, []LogicalLocation{{Name: "v1:Pod:default:foo", Kind: "resource"}}, run.Results[0].Locations[0].LogicalLocations)
+	assert.Nil(t, run.Results[1].Locations)
+
+	// empty logs have an empty list of results
+	require.NoError(t, Write(NewLog("v0.8.0", nil), path))
+	data, _ = os.ReadFile(path)
+	assert.Contains(t, string(data), `"results": []`)
+}
+
+func TestEntryFileAndArtifactURI(t *testing.T) {
+	repo := t.TempDir()
+	stack := filepath.Join(repo, "apps", "dev")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0o755))
+	require.NoError(t, os.MkdirAll(stack, 0o755))
+
+	assert.Equal(t, filepath.Join(stack, "stack.yaml"), EntryFile(stack, nil))
+	assert.Equal(t, filepath.Join(stack, "app.k"), EntryFile(stack, []string{"app.k"}))
+	require.NoError(t, os.WriteFile(filepath.Join(stack, "main.k"), nil, 0o644))
+	assert.Equal(t, filepath.Join(stack, "main.k"), EntryFile(stack, nil))
+
+	assert.Equal(t, "apps/dev/main.k", ArtifactURI(EntryFile(stack, nil)))
+	result := NewResult("limits", LevelError, "msg", EntryFile(stack, nil), "")
+	assert.Equal(t, "apps/dev/main.k", result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+}
@@ -0,0 +1,181 @@
+package vals
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables of Alibaba Cloud credentials and region
+const (
+	EnvAlicloudAccessKeyID     = "ALIBABA_CLOUD_ACCESS_KEY_ID"
+	EnvAlicloudAccessKeySecret = "ALIBABA_CLOUD_ACCESS_KEY_SECRET"
+	EnvAlicloudSecurityToken   = "ALIBABA_CLOUD_SECURITY_TOKEN"
+	EnvAlicloudRegion          = "ALIBABA_CLOUD_REGION_ID"
+)
+
+// alicloudKMS resolves Alibaba Cloud KMS secret refs by the GetSecretValue API, resolved secrets are cached
+var alicloudKMS = &alicloudKMSResolver{client: &http.Client{Timeout: 30 * time.Second}, cache: map[string]string{}}
+
+type alicloudKMSResolver struct {
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// resolve resolves ref+alicloudkms://name[?version_id=ID][&version_stage=STAGE][&region=REGION][&endpoint=URL][#/key]
+func (r *alicloudKMSResolver) resolve(ref string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if v, ok := r.cache[ref]; ok {
+		return v, nil
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid format for secret ref: %s", ref)
+	}
+	name := strings.TrimPrefix(u.Host+u.Path, "/")
+	query := u.Query()
+	data, err := r.getSecretValue(name, query)
+	if err != nil {
+		return "", fmt.Errorf("get secret %s from Alibaba Cloud KMS failed: %w", name, err)
+	}
+
+	value := data
+	if key := strings.Trim(u.Fragment, "/"); key != "" {
+		if value, err = jsonField(data, strings.Split(key, "/")); err != nil {
+			return "", fmt.Errorf("secret %s: %w", name, err)
+		}
+	}
+	r.cache[ref] = value
+	return value, nil
+}
+
+func (r *alicloudKMSResolver) getSecretValue(name string, query url.Values) (string, error) {
+	accessKeyID := os.Getenv(EnvAlicloudAccessKeyID)
+	accessKeySecret := os.Getenv(EnvAlicloudAccessKeySecret)
+	if accessKeyID == "" || accessKeySecret == "" {
+		return "", fmt.Errorf("%s and %s are required", EnvAlicloudAccessKeyID, EnvAlicloudAccessKeySecret)
+	}
+	region := query.Get("region")
+	if region == "" {
+		region = os.Getenv(EnvAlicloudRegion)
+	}
+	endpoint := query.Get("endpoint")
+	if endpoint == "" {
+		if region == "" {
+			return "", fmt.Errorf("region is required, set it in secret_stores.alicloud or %s", EnvAlicloudRegion)
+		}
+		endpoint = fmt.Sprintf("https://kms.%s.aliyuncs.com", region)
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	params := url.Values{
+		"Action":           {"GetSecretValue"},
+		"SecretName":       {name},
+		"Format":           {"JSON"},
+		"Version":          {"2016-01-20"},
+		"AccessKeyId":      {accessKeyID},
+		"SignatureMethod":  {"HMAC-SHA1"},
+		"SignatureVersion": {"1.0"},
+		"SignatureNonce":   {hex.EncodeToString(nonce)},
+		"Timestamp":        {time.Now().UTC().Format("2006-01-02T15:04:05Z")},
+	}
+	if v := query.Get("version_id"); v != "" {
+		params.Set("VersionId", v)
+	}
+	if v := query.Get("version_stage"); v != "" {
+		params.Set("VersionStage", v)
+	}
+	if token := os.Getenv(EnvAlicloudSecurityToken); token != "" {
+		params.Set("SecurityToken", token)
+	}
+	params.Set("Signature", signRPC(http.MethodGet, params, accessKeySecret))
+
+	resp, err := r.client.Get(strings.TrimSuffix(endpoint, "/") + "/?" + params.Encode())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		SecretData string `json:"SecretData"`
+		Code       string `json:"Code"`
+		Message    string `json:"Message"`
+	}
+	if err = json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("invalid response with status %d: %s", resp.StatusCode, string(body))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", result.Code, result.Message)
+	}
+	return result.SecretData, nil
+}
+
+// signRPC computes the signature of Alibaba Cloud RPC APIs
+func signRPC(method string, params url.Values, accessKeySecret string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, percentEncode(k)+"="+percentEncode(params.Get(k)))
+	}
+	stringToSign := method + "&" + percentEncode("/") + "&" + percentEncode(strings.Join(pairs, "&"))
+
+	mac := hmac.New(sha1.New, []byte(accessKeySecret+"&"))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func percentEncode(s string) string {
+	s = url.QueryEscape(s)
+	s = strings.ReplaceAll(s, "+", "%20")
+	s = strings.ReplaceAll(s, "*", "%2A")
+	return strings.ReplaceAll(s, "%7E", "~")
+}
+
+// jsonField returns the field at the path of the JSON secret data
+func jsonField(data string, path []string) (string, error) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		return "", fmt.Errorf("secret data is not JSON: %w", err)
+	}
+	for _, key := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("key %s not found", strings.Join(path, "/"))
+		}
+		if v, ok = m[key]; !ok {
+			return "", fmt.Errorf("key %s not found", strings.Join(path, "/"))
+		}
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	out, err := json.Marshal(v)
+	return string(out), err
+}
@@ -3,7 +3,30 @@ package vals
 import "reflect"
 
 type SecretStores struct {
-	Vault *Vault `json:"vault,omitempty" yaml:"vault,omitempty"`
+	Vault    *Vault    `json:"vault,omitempty" yaml:"vault,omitempty"`
+	AWS      *AWS      `json:"aws,omitempty" yaml:"aws,omitempty"`
+	Alicloud *Alicloud `json:"alicloud,omitempty" yaml:"alicloud,omitempty"`
+}
+
+// Override returns secret stores of ss overridden by stores configured in override
+func (ss *SecretStores) Override(override *SecretStores) *SecretStores {
+	if override == nil {
+		return ss
+	}
+	if ss == nil {
+		return override
+	}
+	result := *ss
+	if override.Vault != nil {
+		result.Vault = override.Vault
+	}
+	if override.AWS != nil {
+		result.AWS = override.AWS
+	}
+	if override.Alicloud != nil {
+		result.Alicloud = override.Alicloud
+	}
+	return &result
 }
 
 // A valid SecretStore must has one backend at least
@@ -15,7 +38,7 @@ func (ss *SecretStores) IsValid() bool {
 	v := reflect.ValueOf(*ss)
 	validStores := 0
 	for i := 0; i < v.NumField(); i++ {
-		if v.Field(i).Interface() != nil {
+		if !v.Field(i).IsNil() {
 			validStores++
 		}
 	}
@@ -39,3 +62,18 @@ type Vault struct {
 	SecretID   string `json:"secret_id" yaml:"secret_id"`
 	Version    string `json:"version" yaml:"version"`
 }
+
+// AWS is the configuration of AWS Secrets Manager, credentials are read from the environment
+// or the shared credentials file of the profile.
+// GCP Secret Manager needs no configuration, it uses Application Default Credentials.
+type AWS struct {
+	Region  string `json:"region" yaml:"region"`
+	Profile string `json:"profile" yaml:"profile"`
+}
+
+// Alicloud is the configuration of Alibaba Cloud KMS Secrets, credentials are read from
+// ALIBABA_CLOUD_ACCESS_KEY_ID, ALIBABA_CLOUD_ACCESS_KEY_SECRET and ALIBABA_CLOUD_SECURITY_TOKEN.
+type Alicloud struct {
+	Region   string `json:"region" yaml:"region"`
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+}
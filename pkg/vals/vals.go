@@ -10,13 +10,20 @@ import (
 )
 
 const (
-	VaultPrefix = "ref+vault://"
+	VaultPrefix       = "ref+vault://"
+	AWSSecretsPrefix  = "ref+awssecrets://"
+	GCPSecretsPrefix  = "ref+gcpsecrets://"
+	AlicloudKMSPrefix = "ref+alicloudkms://"
 )
 
 var supported = []string{
 	VaultPrefix,
+	AWSSecretsPrefix,
+	GCPSecretsPrefix,
+	AlicloudKMSPrefix,
 }
 
+// runtime resolves secret refs except Alibaba Cloud KMS ones, resolved secrets are cached in it
 var runtime *vals.Runtime
 
 func init() {
@@ -34,9 +41,21 @@ func IsSecured(str string) (string, bool) {
 	return "", false
 }
 
+// ParseSecretRef resolves the secret ref. Versions of secrets can be pinned by query parameters of the ref:
+//   - ref+vault://path/to/backend#/key
+//   - ref+awssecrets://name[?version_id=ID][&version_stage=STAGE][#/key]
+//   - ref+gcpsecrets://project/name[?version=VERSION][#/key]
+//   - ref+alicloudkms://name[?version_id=ID][&version_stage=STAGE][#/key]
 func ParseSecretRef(prefix, src string, ss *SecretStores) (string, error) {
 	params := buildParams(prefix, ss)
-	fullFormat := constructURI(src, params)
+	fullFormat, err := constructURI(prefix, src, params)
+	if err != nil {
+		return "", err
+	}
+	if prefix == AlicloudKMSPrefix {
+		return alicloudKMS.resolve(fullFormat)
+	}
+
 	tmpMap := map[string]interface{}{
 		"tmp": fullFormat,
 	}
@@ -48,26 +67,30 @@ func ParseSecretRef(prefix, src string, ss *SecretStores) (string, error) {
 	return restored, nil
 }
 
-var paramsInMemory = map[string]string{}
-
-// buildParams joints all no-nil field value with '&'
+// buildParams joints all no-nil field value with '&'. Without the configuration of the secret store,
+// secret stores read it from environment variables, e.g. VAULT_ADDR and VAULT_TOKEN for Vault.
 func buildParams(prefix string, ss *SecretStores) string {
-	if v, ok := paramsInMemory[prefix]; ok {
-		return v
+	if ss == nil {
+		return ""
 	}
 
-	ret := []string{}
-	var t reflect.Type
-	var v reflect.Value
+	var store interface{}
 	switch prefix {
 	case VaultPrefix:
-		contract.Require(ss.Vault != nil, "secret_store.vault is nil")
-		t = reflect.TypeOf(*ss.Vault)
-		v = reflect.ValueOf(*ss.Vault)
-	default:
-		return "" // Never reach
+		store = ss.Vault
+	case AWSSecretsPrefix:
+		store = ss.AWS
+	case AlicloudKMSPrefix:
+		store = ss.Alicloud
 	}
+	v := reflect.ValueOf(store)
+	if store == nil || v.IsNil() {
+		return ""
+	}
+	v = v.Elem()
+	t := v.Type()
 
+	ret := []string{}
 	for i := 0; i < v.NumField(); i++ {
 		if v.Field(i).Len() == 0 {
 			continue
@@ -75,19 +98,27 @@ func buildParams(prefix string, ss *SecretStores) string {
 		ret = append(ret, fmt.Sprintf("%s=%s", t.Field(i).Tag.Get("yaml"), v.Field(i).String()))
 	}
 
-	params := strings.Join(ret, "&")
-	paramsInMemory[prefix] = params
-
-	return params
+	return strings.Join(ret, "&")
 }
 
 // constructURI transforms "ref+vault://path/to/backend#/key" to:
 // "ref+vault://PATH/TO/KV_BACKEND[?address=VAULT_ADDR:PORT&token_file=PATH/TO/FILE&token_env=VAULT_TOKEN&namespace=VAULT_NAMESPACE]#/key" or
-// "ref+vault://PATH/TO/KV_BACKEND[?address=VAULT_ADDR:PORT&auth_method=approle&role_id=vault_role&secret_id=vault_secret]#/key"
-func constructURI(str string, params string) string {
-	splits := strings.Split(str, "#")
-	if len(splits) != 2 {
-		panic(fmt.Sprintf("invalid format for secret ref: %s", str))
+// "ref+vault://PATH/TO/KV_BACKEND[?address=VAULT_ADDR:PORT&auth_method=approle&role_id=vault_role&secret_id=vault_secret]#/key".
+// Parameters in the ref, e.g. versions of secrets, take precedence over the ones of secret stores.
+func constructURI(prefix, str, params string) (string, error) {
+	base, fragment, hasFragment := strings.Cut(str, "#")
+	if prefix == VaultPrefix && (!hasFragment || strings.Contains(fragment, "#")) {
+		return "", fmt.Errorf("invalid format for secret ref: %s", str)
+	}
+	if params != "" {
+		if strings.Contains(base, "?") {
+			base = base + "&" + params
+		} else {
+			base = base + "?" + params
+		}
+	}
+	if hasFragment {
+		return base + "#" + fragment, nil
 	}
-	return fmt.Sprintf("%s?%s#%s", splits[0], params, splits[1])
+	return base, nil
 }
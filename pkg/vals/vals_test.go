@@ -0,0 +1,137 @@
+package vals
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSecured(t *testing.T) {
+	for _, ref := range []string{
+		"ref+vault://secret/db#/password",
+		"ref+awssecrets://db?version_stage=AWSPREVIOUS#/password",
+		"ref+gcpsecrets://project/db?version=3",
+		"ref+alicloudkms://db#/password",
+	} {
+		_, ok := IsSecured(ref)
+		assert.True(t, ok, ref)
+	}
+	_, ok := IsSecured("ref+unknown://db")
+	assert.False(t, ok)
+}
+
+func Test_constructURI(t *testing.T) {
+	ss := &SecretStores{
+		Vault: &Vault{Address: "https://vault:8200", TokenEnv: "VAULT_TOKEN"},
+		AWS:   &AWS{Region: "us-east-1"},
+	}
+	tests := []struct {
+		name    string
+		prefix  string
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "vault",
+			prefix: VaultPrefix,
+			ref:    "ref+vault://secret/db#/password",
+			want:   "ref+vault://secret/db?address=https://vault:8200&token_env=VAULT_TOKEN#/password",
+		},
+		{
+			name:    "vault without key",
+			prefix:  VaultPrefix,
+			ref:     "ref+vault://secret/db",
+			wantErr: true,
+		},
+		{
+			name:   "aws with a pinned version",
+			prefix: AWSSecretsPrefix,
+			ref:    "ref+awssecrets://db?version_id=v1",
+			want:   "ref+awssecrets://db?version_id=v1&region=us-east-1",
+		},
+		{
+			name:   "gcp without store",
+			prefix: GCPSecretsPrefix,
+			ref:    "ref+gcpsecrets://project/db?version=3#/password",
+			want:   "ref+gcpsecrets://project/db?version=3#/password",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := constructURI(tt.prefix, tt.ref, buildParams(tt.prefix, ss))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			u, _ := url.Parse(tt.want)
+			gotURL, _ := url.Parse(got)
+			assert.Equal(t, u.Query().Get("version_id"), gotURL.Query().Get("version_id"))
+			assert.Equal(t, u.Fragment, gotURL.Fragment)
+			assert.Equal(t, u.Query().Get("region"), gotURL.Query().Get("region"))
+		})
+	}
+}
+
+func TestParseSecretRef_Alicloud(t *testing.T) {
+	t.Setenv(EnvAlicloudAccessKeyID, "id")
+	t.Setenv(EnvAlicloudAccessKeySecret, "secret")
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		q := r.URL.Query()
+		if q.Get("Action") != "GetSecretValue" || q.Get("Signature") == "" || q.Get("AccessKeyId") != "id" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"Code":"InvalidParameter","Message":"bad request"}`))
+			return
+		}
+		if q.Get("SecretName") != "db" || q.Get("VersionId") != "v2" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"Code":"Forbidden.ResourceNotFound","Message":"secret not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"SecretData":"{\"password\":\"pass\"}","VersionId":"v2"}`))
+	}))
+	defer server.Close()
+
+	ss := &SecretStores{Alicloud: &Alicloud{Endpoint: server.URL}}
+	value, err := ParseSecretRef(AlicloudKMSPrefix, "ref+alicloudkms://db?version_id=v2#/password", ss)
+	assert.Nil(t, err)
+	assert.Equal(t, "pass", value)
+
+	// resolved secrets are cached
+	value, err = ParseSecretRef(AlicloudKMSPrefix, "ref+alicloudkms://db?version_id=v2#/password", ss)
+	assert.Nil(t, err)
+	assert.Equal(t, "pass", value)
+	assert.Equal(t, 1, requests)
+
+	value, err = ParseSecretRef(AlicloudKMSPrefix, "ref+alicloudkms://db?version_id=v2", ss)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"password":"pass"}`, value)
+
+	_, err = ParseSecretRef(AlicloudKMSPrefix, "ref+alicloudkms://db?version_id=v2#/user", ss)
+	assert.ErrorContains(t, err, "key user not found")
+
+	_, err = ParseSecretRef(AlicloudKMSPrefix, "ref+alicloudkms://db?version_id=v1", ss)
+	assert.ErrorContains(t, err, "Forbidden.ResourceNotFound: secret not found")
+}
+
+func Test_signRPC(t *testing.T) {
+	// the example in the documentation of Alibaba Cloud RPC API signatures
+	params := url.Values{
+		"Format":           {"XML"},
+		"AccessKeyId":      {"testid"},
+		"Action":           {"DescribeRegions"},
+		"SignatureMethod":  {"HMAC-SHA1"},
+		"SignatureNonce":   {"3ee8c1b8-83d3-44af-a94f-4e0ad82fd6cf"},
+		"SignatureVersion": {"1.0"},
+		"Version":          {"2014-05-26"},
+		"Timestamp":        {"2016-02-23T12:46:24Z"},
+	}
+	assert.Equal(t, "OLeaidS1JvxuMvnyHOwuJ+uX5qY=", signRPC(http.MethodGet, params, "testsecret"))
+}
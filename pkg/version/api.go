@@ -19,3 +19,7 @@ func JSON() string {
 func YAML() string {
 	return info.YAML()
 }
+
+func Get() *Info {
+	return info
+}